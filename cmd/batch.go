@@ -0,0 +1,594 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/Norgate-AV/vtpc/internal/artifact"
+	"github.com/Norgate-AV/vtpc/internal/batch"
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/pathutil"
+)
+
+// batchCmd compiles several projects in turn, recording its progress so an
+// interrupted batch can be resumed
+var batchCmd = &cobra.Command{
+	Use:   "batch <file-path>...",
+	Short: "Compile several projects in turn, resumable with --resume",
+	Args:  validateBatchArgs,
+	RunE:  runBatchCmd,
+}
+
+func init() {
+	addBatchFlags(batchCmd.Flags())
+	RootCmd.AddCommand(batchCmd)
+}
+
+// addBatchFlags adds the batch's own flags, which vtpc and vtpc batch both
+// take. They are not passed on to each of the batch's compiles.
+func addBatchFlags(flags *pflag.FlagSet) {
+	flags.String("state", "", "file to record the batch's progress in (default: batch.json next to the log file)")
+	flags.String("resume", "", "continue the batch recorded in this state file, skipping projects that compiled and have not changed")
+	flags.Bool("strict-inputs", false, "fail the batch when an input is skipped because it is missing, unreadable or not a .vtp file")
+	flags.StringArray("exclude", nil, "when a directory is given, skip the files and subdirectories whose name or path within it matches this case-insensitive glob, e.g. \"Archive\" (repeatable)")
+}
+
+// validateBatchArgs requires files to compile, or none when the batch is
+// resumed. The files themselves are checked as the batch reaches them, so one
+// that cannot be compiled is skipped rather than failing the batch.
+func validateBatchArgs(cmd *cobra.Command, args []string) error {
+	resume, err := cmd.Flags().GetString("resume")
+	if err != nil {
+		return err
+	}
+
+	if resume == "" && len(args) == 0 {
+		return fmt.Errorf("requires at least one .vtp file, or --resume")
+	}
+
+	return nil
+}
+
+// batchInputs returns args as absolute, normalized paths, so a batch resumed
+// from another directory compiles the same files. Wildcards are expanded, as
+// the Windows shell leaves them to the program, and each directory is replaced
+// by the projects under it that excludes do not match. The paths are sorted,
+// ignoring case as Windows does, so the same projects always compile in the
+// same order however they were given.
+func batchInputs(args, excludes []string) ([]string, error) {
+	var inputs []string
+
+	for _, arg := range args {
+		path, _ := pathutil.Normalize(arg, pathutil.OSEnv())
+
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			projects, err := discoverProjects(path, excludes)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(projects) == 0 {
+				return nil, fmt.Errorf("no .vtp files found in %s", path)
+			}
+
+			inputs = append(inputs, projects...)
+
+			continue
+		}
+
+		for _, match := range expandInput(path) {
+			abs, err := filepath.Abs(match)
+			if err != nil {
+				return nil, err
+			}
+
+			inputs = append(inputs, abs)
+		}
+	}
+
+	slices.SortStableFunc(inputs, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+
+	return inputs, nil
+}
+
+// expandInput returns the files path matches. A path that names a file, even
+// one with wildcard characters in its name, or that matches nothing is
+// returned as it is, so the batch reports it rather than dropping it.
+func expandInput(path string) []string {
+	if _, err := os.Lstat(path); err == nil {
+		return []string{path}
+	}
+
+	matches, err := filepath.Glob(path)
+	if err != nil || len(matches) == 0 {
+		return []string{path}
+	}
+
+	return matches
+}
+
+// discoverProjects returns the absolute paths of the .vtp files anywhere under
+// dir, in the order a directory listing sorts them. Unlike findProjects, which
+// lists projects to pick from, it looks at every level. A file or directory
+// that matches an exclude by its name or its slash-separated path within dir
+// is left out, with everything under it.
+func discoverProjects(dir string, excludes []string) ([]string, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []string
+
+	err = filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if file == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+
+		if excluded(filepath.ToSlash(rel), excludes) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !d.IsDir() && filepath.Ext(file) == ".vtp" {
+			projects = append(projects, file)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look for projects in %s: %w", dir, err)
+	}
+
+	return projects, nil
+}
+
+// excluded reports whether a slash-separated path, or its last element,
+// matches any of the case-insensitive globs in excludes
+func excluded(rel string, excludes []string) bool {
+	rel = strings.ToLower(rel)
+	name := path.Base(rel)
+
+	for _, pattern := range excludes {
+		pattern = excludePattern(pattern)
+
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// excludePattern returns an --exclude glob as excluded matches it: lower case,
+// with backslashes as the path separators they are on Windows rather than escapes
+func excludePattern(pattern string) string {
+	return strings.ToLower(strings.ReplaceAll(pattern, `\`, "/"))
+}
+
+// batchExcludes returns the --exclude globs, checking each is a valid pattern
+func batchExcludes(cmd *cobra.Command) ([]string, error) {
+	excludes, err := cmd.Flags().GetStringArray("exclude")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range excludes {
+		if _, err := path.Match(excludePattern(pattern), ""); err != nil {
+			return nil, fmt.Errorf("--exclude %q: %w", pattern, err)
+		}
+	}
+
+	return excludes, nil
+}
+
+// skipReason returns why a batch input cannot be compiled, "" if it can.
+// open opens a file for reading, as os.Open does.
+func skipReason(path string, open func(name string) (fs.File, error)) string {
+	if filepath.Ext(path) != ".vtp" {
+		return "not a .vtp file"
+	}
+
+	f, err := open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "not found"
+	}
+
+	if err != nil {
+		return "unreadable: " + pathError(err)
+	}
+
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		return "a directory"
+	}
+
+	// A cloud placeholder, e.g. mid-sync from OneDrive, opens but fails to read
+	if _, err := f.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) {
+		return "unreadable: " + pathError(err)
+	}
+
+	return ""
+}
+
+// pathError returns err's message without the operation and path an
+// *fs.PathError adds, as the batch already names the file
+func pathError(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+
+	return err.Error()
+}
+
+// openInput opens a batch input for skipReason
+func openInput(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+// runBatchCmd compiles each project with a child vtpc given the same flags
+func runBatchCmd(cmd *cobra.Command, args []string) error {
+	cfg := NewConfigFromFlags(cmd)
+
+	if err := checkBatchFlags(cfg); err != nil {
+		return err
+	}
+
+	// The batch gets its own log so it does not rotate the log its compiles write to
+	log, err := logger.NewLogger(logger.LoggerOptions{
+		Verbose:  cfg.Verbose,
+		LogDir:   filepath.Join(filepath.Dir(logger.GetLogPath(logger.LoggerOptions{})), "batch"),
+		Compress: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+
+	defer log.Close()
+
+	// Compiles inherit the batch's elevation, so elevate once up front
+	if err := ensureElevated(log); err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate vtpc executable: %w", err)
+	}
+
+	flags := compileFlags(cmd)
+
+	resume, err := cmd.Flags().GetString("resume")
+	if err != nil {
+		return err
+	}
+
+	excludes, err := batchExcludes(cmd)
+	if err != nil {
+		return err
+	}
+
+	strictInputs, err := cmd.Flags().GetBool("strict-inputs")
+	if err != nil {
+		return err
+	}
+
+	statePath, err := cmd.Flags().GetString("state")
+	if err != nil {
+		return err
+	}
+
+	if statePath == "" {
+		statePath = resume
+	}
+
+	if statePath == "" {
+		statePath = filepath.Join(filepath.Dir(logger.GetLogPath(logger.LoggerOptions{})), "batch.json")
+	}
+
+	state, err := loadBatchState(args, excludes, resume, flags, log)
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C reaches the child too, which closes VTPro and exits with
+	// ExitInterrupted; the batch outlives it to record that and stop
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	labels := projectLabels(state.Inputs())
+
+	run := func(path string) int {
+		child := exec.Command(exe, append([]string{path}, projectArgs(flags, labels[path])...)...)
+		child.Stdout = cmd.OutOrStdout()
+		child.Stderr = cmd.ErrOrStderr()
+
+		err := child.Run()
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+
+		if err != nil {
+			log.Error("Failed to run vtpc", slog.String("file", path), slog.Any("error", err))
+			return ExitFailure
+		}
+
+		if ctx.Err() != nil {
+			return ExitInterrupted
+		}
+
+		return ExitSuccess
+	}
+
+	check := func(path string) string { return skipReason(path, openInput) }
+
+	return runBatch(state, statePath, run, artifact.HashFile, check, strictInputs, clock.New(), log, cmd.OutOrStdout())
+}
+
+// compileFlags returns the flags the batch gives each compile: those set on
+// the command line other than the batch's own, such as --resume. A batch is
+// started by vtpc batch or by giving vtpc several projects.
+func compileFlags(cmd *cobra.Command) []string {
+	own := cmd.LocalNonPersistentFlags()
+
+	var args []string
+
+	// Only the command's full flag set records which flags were set
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if own.Lookup(f.Name) == nil {
+			args = append(args, flagArgs(f)...)
+		}
+	})
+
+	return args
+}
+
+// checkBatchFlags rejects flags that cannot work across a batch's compiles.
+// Each compile would print its own JSON document to stdout between the batch's
+// progress lines, and only the first could read a password from stdin.
+func checkBatchFlags(cfg *Config) error {
+	if cfg.Output == outputJSON {
+		return fmt.Errorf("-o json prints one run's result, not a batch's; use --out json=PATH, which writes a report for each project")
+	}
+
+	if cfg.DeployPassword == "stdin:" {
+		return fmt.Errorf("--deploy-password stdin: can only be read by one compile; use env:NAME or file:PATH for a batch")
+	}
+
+	return nil
+}
+
+// projectLabels returns the name each of a batch's projects gives the files
+// its compile writes: the project's name without .vtp, followed by -2, -3 and
+// so on for a name an earlier project in the batch already has
+func projectLabels(paths []string) map[string]string {
+	labels := make(map[string]string, len(paths))
+	seen := make(map[string]int)
+
+	for _, p := range paths {
+		name := strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+
+		key := strings.ToLower(name)
+		seen[key]++
+
+		if n := seen[key]; n > 1 {
+			name = fmt.Sprintf("%s-%d", name, n)
+		}
+
+		labels[p] = name
+	}
+
+	return labels
+}
+
+// projectArgs returns the flags one project of a batch is compiled with. Each
+// flag that names a file the compile writes, --out, --record-events and
+// --profile, is given a file of its own with label before its extension, so
+// --out json=report.json writes report.Lobby.json for Lobby.vtp rather than
+// every project overwriting the same report.
+func projectArgs(flags []string, label string) []string {
+	args := make([]string, 0, len(flags))
+
+	for _, arg := range flags {
+		name, value, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+
+		switch name {
+		case "record-events":
+			value = labelledPath(value, label)
+		case "out", "profile":
+			if kind, file, ok := strings.Cut(value, "="); ok {
+				value = kind + "=" + labelledPath(file, label)
+			}
+		default:
+			args = append(args, arg)
+			continue
+		}
+
+		args = append(args, "--"+name+"="+value)
+	}
+
+	return args
+}
+
+// labelledPath inserts label before path's extension
+func labelledPath(path, label string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + label + ext
+}
+
+// loadBatchState returns the state of a new batch of args, or the batch
+// recorded at resume. A recorded batch whose inputs or flags have changed is
+// started again.
+func loadBatchState(args, excludes []string, resume string, flags []string, log logger.LoggerInterface) (*batch.State, error) {
+	var prior *batch.State
+
+	if resume != "" {
+		var err error
+		if prior, err = batch.Load(resume); err != nil {
+			return nil, err
+		}
+
+		if len(args) == 0 {
+			args = prior.Inputs()
+		}
+	}
+
+	inputs, err := batchInputs(args, excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := batch.Fingerprint(inputs, flags)
+
+	if prior == nil {
+		return batch.New(inputs, fingerprint), nil
+	}
+
+	state, invalidated := batch.Resume(prior, inputs, fingerprint)
+	if invalidated {
+		log.Warn("The projects or flags have changed since the batch was recorded, starting it again",
+			slog.String("state", resume),
+			slog.String("recorded", prior.Fingerprint),
+			slog.String("now", fingerprint),
+		)
+	}
+
+	return state, nil
+}
+
+// runBatch compiles every project the state's plan says to, saving the state
+// after each one. A project check gives a reason for is skipped. It stops at
+// the first compile that was interrupted, leaving that project pending.
+func runBatch(state *batch.State, statePath string, run func(path string) int, hash func(path string) (string, error), check func(path string) string, strictInputs bool, clk clock.Clock, log logger.LoggerInterface, w io.Writer) error {
+	if err := state.Save(statePath); err != nil {
+		return fmt.Errorf("failed to save batch state: %w", err)
+	}
+
+	log.Info("Batch started", slog.String("state", statePath), slog.String("fingerprint", state.Fingerprint), slog.Int("projects", len(state.Entries)))
+
+	skipped := 0
+
+	for i, d := range state.Plan(hash) {
+		if !d.Run {
+			skipped++
+			log.Info("Skipping project", slog.String("file", d.Path), slog.String("reason", d.Reason))
+			fmt.Fprintf(w, "batch: [%d/%d] skipping %s (%s)\n", i+1, len(state.Entries), d.Path, d.Reason)
+
+			continue
+		}
+
+		if reason := check(d.Path); reason != "" {
+			log.Warn("Skipping input", slog.String("file", d.Path), slog.String("reason", reason))
+			fmt.Fprintf(w, "batch: [%d/%d] skipping %s (%s)\n", i+1, len(state.Entries), d.Path, reason)
+
+			state.Skip(i, reason, clk.Now())
+			if err := state.Save(statePath); err != nil {
+				return fmt.Errorf("failed to save batch state: %w", err)
+			}
+
+			continue
+		}
+
+		log.Info("Compiling project", slog.String("file", d.Path), slog.String("reason", d.Reason))
+		fmt.Fprintf(w, "batch: [%d/%d] compiling %s\n", i+1, len(state.Entries), d.Path)
+
+		code := run(d.Path)
+		if code == ExitInterrupted {
+			log.Info("Batch interrupted", slog.String("file", d.Path))
+
+			return &ExitError{
+				Code: ExitInterrupted,
+				Err:  fmt.Errorf("batch interrupted at %s, continue it with --resume %s", d.Path, statePath),
+			}
+		}
+
+		state.Record(i, code, d.SHA256, clk.Now())
+
+		if err := state.Save(statePath); err != nil {
+			return fmt.Errorf("failed to save batch state: %w", err)
+		}
+	}
+
+	counts := state.Counts()
+	summary := fmt.Sprintf("batch: %d succeeded, %d failed, %d skipped",
+		counts[batch.StatusSucceeded]-skipped, counts[batch.StatusFailed], skipped+counts[batch.StatusSkipped])
+
+	if n := counts[batch.StatusSkipped]; n > 0 {
+		summary += fmt.Sprintf(" (unusable inputs: %d)", n)
+	}
+
+	fmt.Fprintln(w, summary)
+
+	// Listed last, so the projects to look at are the last thing printed
+	for _, e := range state.Entries {
+		if e.Status == batch.StatusFailed {
+			fmt.Fprintf(w, "batch: failed %s (exit code %d)\n", e.Path, e.ExitCode)
+		}
+	}
+
+	return batchError(counts, len(state.Entries), strictInputs, statePath)
+}
+
+// batchError returns the error a finished batch with the given counts exits
+// with, nil if it succeeded. Skipped inputs only fail it with strictInputs.
+func batchError(counts map[batch.Status]int, total int, strictInputs bool, statePath string) error {
+	failed, skipped := counts[batch.StatusFailed], counts[batch.StatusSkipped]
+	if !strictInputs {
+		skipped = 0
+	}
+
+	var problems []string
+	if failed > 0 {
+		problems = append(problems, fmt.Sprintf("%d of %d projects failed", failed, total))
+	}
+
+	if skipped > 0 {
+		problems = append(problems, fmt.Sprintf("%d of %d inputs were skipped (--strict-inputs)", skipped, total))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return &ExitError{
+		Code: ExitFailure,
+		Err:  fmt.Errorf("%s, compile them again with --resume %s", strings.Join(problems, " and "), statePath),
+	}
+}
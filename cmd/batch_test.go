@@ -0,0 +1,488 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/batch"
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+)
+
+// fakeBatchRun compiles by looking up each project's exit code, and checks the
+// state was saved before every compile
+type fakeBatchRun struct {
+	t         *testing.T
+	statePath string
+	codes     map[string]int
+	ran       []string
+}
+
+func (f *fakeBatchRun) run(path string) int {
+	saved, err := batch.Load(f.statePath)
+	require.NoError(f.t, err, "the state is saved before %s compiles", path)
+	assert.Len(f.t, saved.Entries, 3)
+
+	for _, prev := range f.ran {
+		assert.NotEqual(f.t, batch.StatusPending, saved.Entries[slices.Index(saved.Inputs(), prev)].Status, "%s is recorded before the next compile", prev)
+	}
+
+	f.ran = append(f.ran, path)
+
+	return f.codes[path]
+}
+
+func hashByName(path string) (string, error) {
+	return "sum-" + filepath.Base(path), nil
+}
+
+// usable finds nothing wrong with any input
+func usable(string) string { return "" }
+
+func TestRunBatch(t *testing.T) {
+	t.Parallel()
+
+	statePath := filepath.Join(t.TempDir(), "batch.json")
+	state := batch.New([]string{"a.vtp", "b.vtp", "c.vtp"}, "fp")
+	fake := &fakeBatchRun{t: t, statePath: statePath, codes: map[string]int{"b.vtp": ExitFailure}}
+
+	var out bytes.Buffer
+	err := runBatch(state, statePath, fake.run, hashByName, usable, false, clock.NewFake(time.Now()), testutil.NewMockLogger(), &out)
+
+	assert.Equal(t, ExitFailure, ExitCode(err))
+	assert.ErrorContains(t, err, "1 of 3 projects failed, compile them again with --resume "+statePath)
+	assert.Equal(t, []string{"a.vtp", "b.vtp", "c.vtp"}, fake.ran)
+	assert.Contains(t, out.String(), "batch: 2 succeeded, 1 failed, 0 skipped\nbatch: failed b.vtp (exit code 1)\n")
+
+	saved, err := batch.Load(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, batch.StatusSucceeded, saved.Entries[0].Status)
+	assert.Equal(t, "sum-a.vtp", saved.Entries[0].SHA256)
+	assert.Equal(t, batch.StatusFailed, saved.Entries[1].Status)
+	assert.Equal(t, batch.StatusSucceeded, saved.Entries[2].Status)
+}
+
+func TestRunBatch_ResumeSkipsCompiledProjects(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	statePath := filepath.Join(t.TempDir(), "batch.json")
+
+	state := batch.New([]string{"a.vtp", "b.vtp", "c.vtp"}, "fp")
+	state.Record(0, ExitSuccess, "sum-a.vtp", at)
+	state.Record(1, ExitFailure, "sum-b.vtp", at)
+
+	fake := &fakeBatchRun{t: t, statePath: statePath, codes: map[string]int{}}
+
+	var out bytes.Buffer
+	err := runBatch(state, statePath, fake.run, hashByName, usable, false, clock.NewFake(time.Now()), testutil.NewMockLogger(), &out)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.vtp", "c.vtp"}, fake.ran, "the failed and pending projects compile")
+	assert.Contains(t, out.String(), "batch: [1/3] skipping a.vtp (compiled and unchanged)")
+	assert.Contains(t, out.String(), "batch: 2 succeeded, 0 failed, 1 skipped")
+}
+
+func TestRunBatch_StopsWhenInterrupted(t *testing.T) {
+	t.Parallel()
+
+	statePath := filepath.Join(t.TempDir(), "batch.json")
+	state := batch.New([]string{"a.vtp", "b.vtp", "c.vtp"}, "fp")
+	fake := &fakeBatchRun{t: t, statePath: statePath, codes: map[string]int{"b.vtp": ExitInterrupted}}
+
+	err := runBatch(state, statePath, fake.run, hashByName, usable, false, clock.NewFake(time.Now()), testutil.NewMockLogger(), &bytes.Buffer{})
+
+	assert.Equal(t, ExitInterrupted, ExitCode(err))
+	assert.ErrorContains(t, err, "batch interrupted at b.vtp, continue it with --resume "+statePath)
+	assert.Equal(t, []string{"a.vtp", "b.vtp"}, fake.ran)
+
+	saved, err := batch.Load(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, batch.StatusSucceeded, saved.Entries[0].Status)
+	assert.Equal(t, batch.StatusPending, saved.Entries[1].Status, "the interrupted project compiles again on resume")
+	assert.Equal(t, batch.StatusPending, saved.Entries[2].Status)
+}
+
+func TestRunBatch_SkipsUnusableInputs(t *testing.T) {
+	t.Parallel()
+
+	statePath := filepath.Join(t.TempDir(), "batch.json")
+	state := batch.New([]string{"a.vtp", "b.vtp", "c.vtp"}, "fp")
+	fake := &fakeBatchRun{t: t, statePath: statePath, codes: map[string]int{}}
+	check := func(path string) string {
+		if path == "b.vtp" {
+			return "unreadable: Access is denied."
+		}
+
+		return ""
+	}
+
+	var out bytes.Buffer
+	err := runBatch(state, statePath, fake.run, hashByName, check, false, clock.NewFake(time.Now()), testutil.NewMockLogger(), &out)
+
+	require.NoError(t, err, "skipped inputs alone do not fail the batch")
+	assert.Equal(t, []string{"a.vtp", "c.vtp"}, fake.ran)
+	assert.Contains(t, out.String(), "batch: [2/3] skipping b.vtp (unreadable: Access is denied.)")
+	assert.Contains(t, out.String(), "batch: 2 succeeded, 0 failed, 1 skipped (unusable inputs: 1)")
+
+	saved, err := batch.Load(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, batch.StatusSkipped, saved.Entries[1].Status)
+	assert.Equal(t, "unreadable: Access is denied.", saved.Entries[1].Reason)
+}
+
+func TestBatchError(t *testing.T) {
+	t.Parallel()
+
+	const statePath = `C:\batch.json`
+
+	tests := []struct {
+		name         string
+		counts       map[batch.Status]int
+		strictInputs bool
+		want         string // "" if the batch succeeds
+	}{
+		{
+			name:   "all succeeded",
+			counts: map[batch.Status]int{batch.StatusSucceeded: 3},
+		},
+		{
+			name:   "skipped",
+			counts: map[batch.Status]int{batch.StatusSucceeded: 2, batch.StatusSkipped: 1},
+		},
+		{
+			name:         "skipped with strict inputs",
+			counts:       map[batch.Status]int{batch.StatusSucceeded: 2, batch.StatusSkipped: 1},
+			strictInputs: true,
+			want:         `1 of 3 inputs were skipped (--strict-inputs), compile them again with --resume C:\batch.json`,
+		},
+		{
+			name:   "failed and skipped",
+			counts: map[batch.Status]int{batch.StatusSucceeded: 1, batch.StatusFailed: 1, batch.StatusSkipped: 1},
+			want:   `1 of 3 projects failed, compile them again with --resume C:\batch.json`,
+		},
+		{
+			name:         "failed and skipped with strict inputs",
+			counts:       map[batch.Status]int{batch.StatusFailed: 1, batch.StatusSkipped: 2},
+			strictInputs: true,
+			want:         `1 of 3 projects failed and 2 of 3 inputs were skipped (--strict-inputs), compile them again with --resume C:\batch.json`,
+		},
+		{
+			name:         "strict inputs with nothing skipped",
+			counts:       map[batch.Status]int{batch.StatusSucceeded: 3},
+			strictInputs: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := batchError(tt.counts, 3, tt.strictInputs, statePath)
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Equal(t, ExitFailure, ExitCode(err))
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+// unreadableFile opens, as a cloud placeholder does, but cannot be read
+type unreadableFile struct{ fs.File }
+
+func (unreadableFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: "Lobby.vtp", Err: errors.New("The cloud file provider is not running.")}
+}
+
+func TestSkipReason(t *testing.T) {
+	t.Parallel()
+
+	files := fstest.MapFS{
+		"Lobby.vtp":         {Data: []byte("<VTPro>")},
+		"Empty.vtp":         {},
+		"notes.txt":         {Data: []byte("notes")},
+		"Archive.vtp/a.vtp": {},
+	}
+
+	denied := func(name string) (fs.File, error) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	placeholder := func(name string) (fs.File, error) {
+		f, err := files.Open(name)
+		return unreadableFile{f}, err
+	}
+
+	tests := []struct {
+		name string
+		path string
+		open func(string) (fs.File, error)
+		want string
+	}{
+		{name: "readable", path: "Lobby.vtp", open: files.Open},
+		{name: "empty", path: "Empty.vtp", open: files.Open},
+		{name: "wrong extension", path: "notes.txt", open: files.Open, want: "not a .vtp file"},
+		{name: "pattern matched nothing", path: "*.vtp", open: files.Open, want: "not found"},
+		{name: "not found", path: "Foyer.vtp", open: files.Open, want: "not found"},
+		{name: "directory", path: "Archive.vtp", open: files.Open, want: "a directory"},
+		{name: "access denied", path: "Lobby.vtp", open: denied, want: "unreadable: permission denied"},
+		{name: "cloud placeholder", path: "Lobby.vtp", open: placeholder, want: "unreadable: The cloud file provider is not running."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, skipReason(tt.path, tt.open))
+		})
+	}
+}
+
+func TestBatchInputs(t *testing.T) {
+	t.Parallel()
+
+	dir := projectTree(t, "Lobby.vtp", "Boardroom.vtp", "notes.txt", "Panel [v2].vtp")
+
+	inputs, err := batchInputs([]string{
+		filepath.Join(dir, "*o*.vtp"),
+		filepath.Join(dir, "Panel [v2].vtp"),
+		filepath.Join(dir, "Foyer*.vtp"),
+		filepath.Join(dir, "notes.txt"),
+	}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		filepath.Join(dir, "Boardroom.vtp"),
+		filepath.Join(dir, "Foyer*.vtp"),
+		filepath.Join(dir, "Lobby.vtp"),
+		filepath.Join(dir, "notes.txt"),
+		filepath.Join(dir, "Panel [v2].vtp"),
+	}, inputs, "a pattern is expanded, a file whose name looks like one is not, and one that matches nothing is kept to be reported, all sorted")
+
+	reversed, err := batchInputs([]string{filepath.Join(dir, "Panel [v2].vtp"), filepath.Join(dir, "Lobby.vtp")}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "Lobby.vtp"), filepath.Join(dir, "Panel [v2].vtp")}, reversed, "the order given does not matter")
+}
+
+func TestBatchInputs_Directory(t *testing.T) {
+	t.Parallel()
+
+	dir := projectTree(t, "Lobby.vtp", "Panels/Boardroom.vtp", "Panels/Archive/Boardroom.vtp")
+	other := projectTree(t, "Foyer.vtp")
+
+	inputs, err := batchInputs([]string{dir, filepath.Join(other, "Foyer.vtp")}, []string{"archive"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		filepath.Join(dir, "Lobby.vtp"),
+		filepath.Join(dir, "Panels", "Boardroom.vtp"),
+		filepath.Join(other, "Foyer.vtp"),
+	}, inputs, "a directory is replaced by the projects under it")
+
+	_, err = batchInputs([]string{projectTree(t, "notes.txt")}, nil)
+	assert.ErrorContains(t, err, "no .vtp files found in")
+}
+
+func TestDiscoverProjects(t *testing.T) {
+	t.Parallel()
+
+	dir := projectTree(t,
+		"Lobby.vtp",
+		"notes.txt",
+		"Lobby.vtp.vtpc.yaml",
+		"Panels/TSW-770.vtp",
+		"Panels/TSW-770_old.vtp",
+		"Panels/Deep/Er/Foyer.vtp",
+		"Archive/2019/Lobby.vtp",
+		"Backup/Lobby.vtp",
+	)
+
+	tests := []struct {
+		name     string
+		excludes []string
+		want     []string
+	}{
+		{
+			name: "every level",
+			want: []string{"Archive/2019/Lobby.vtp", "Backup/Lobby.vtp", "Lobby.vtp", "Panels/Deep/Er/Foyer.vtp", "Panels/TSW-770.vtp", "Panels/TSW-770_old.vtp"},
+		},
+		{
+			name:     "by directory name, at any depth",
+			excludes: []string{"ARCHIVE", "er"},
+			want:     []string{"Backup/Lobby.vtp", "Lobby.vtp", "Panels/TSW-770.vtp", "Panels/TSW-770_old.vtp"},
+		},
+		{
+			name:     "by file name",
+			excludes: []string{"*_old.vtp"},
+			want:     []string{"Archive/2019/Lobby.vtp", "Backup/Lobby.vtp", "Lobby.vtp", "Panels/Deep/Er/Foyer.vtp", "Panels/TSW-770.vtp"},
+		},
+		{
+			name:     "by path",
+			excludes: []string{"backup/*", `Panels\Deep`},
+			want:     []string{"Archive/2019/Lobby.vtp", "Lobby.vtp", "Panels/TSW-770.vtp", "Panels/TSW-770_old.vtp"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			projects, err := discoverProjects(dir, tt.excludes)
+			require.NoError(t, err)
+
+			want := make([]string, len(tt.want))
+			for i, p := range tt.want {
+				want[i] = filepath.Join(dir, filepath.FromSlash(p))
+			}
+
+			assert.Equal(t, want, projects)
+		})
+	}
+}
+
+func TestBatchExcludes(t *testing.T) {
+	t.Parallel()
+
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().StringArray("exclude", nil, "")
+		require.NoError(t, cmd.Flags().Parse(args))
+
+		return cmd
+	}
+
+	excludes, err := batchExcludes(newCmd("--exclude", "Archive", "--exclude", "*_old.vtp"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Archive", "*_old.vtp"}, excludes)
+
+	_, err = batchExcludes(newCmd("--exclude", "Panels[1"))
+	assert.ErrorContains(t, err, `--exclude "Panels[1"`)
+}
+
+func TestLoadBatchState(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.vtp"), filepath.Join(dir, "b.vtp")
+	flags := []string{"--timeout=5m"}
+	statePath := filepath.Join(dir, "batch.json")
+
+	recorded := batch.New([]string{a, b}, batch.Fingerprint([]string{a, b}, flags))
+	recorded.Record(0, ExitSuccess, "sum", time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC))
+	require.NoError(t, recorded.Save(statePath))
+
+	resumed, err := loadBatchState(nil, nil, statePath, flags, testutil.NewMockLogger())
+	require.NoError(t, err)
+	assert.Equal(t, recorded, resumed, "no projects given resumes the recorded ones")
+
+	log := testutil.NewMockLogger()
+	restarted, err := loadBatchState(nil, nil, statePath, []string{"--timeout=10m"}, log)
+	require.NoError(t, err)
+	assert.Equal(t, batch.StatusPending, restarted.Entries[0].Status, "changed flags start the batch again")
+	assert.Contains(t, log.Messages(), "The projects or flags have changed since the batch was recorded, starting it again")
+
+	fresh, err := loadBatchState([]string{a}, nil, "", flags, testutil.NewMockLogger())
+	require.NoError(t, err)
+	assert.Equal(t, []string{a}, fresh.Inputs())
+}
+
+func TestCompileFlags(t *testing.T) {
+	t.Parallel()
+
+	newRoot := func() (*cobra.Command, *[]string) {
+		var got []string
+
+		root := &cobra.Command{Use: "vtpc", Args: cobra.ArbitraryArgs, RunE: func(c *cobra.Command, _ []string) error {
+			got = compileFlags(c)
+			return nil
+		}}
+		root.PersistentFlags().Bool("save-first", false, "")
+		root.PersistentFlags().StringArray("out", nil, "")
+
+		batch := &cobra.Command{Use: "batch", RunE: root.RunE}
+		batch.Flags().String("resume", "", "")
+		root.AddCommand(batch)
+
+		return root, &got
+	}
+
+	root, got := newRoot()
+	root.SetArgs([]string{"batch", "--save-first", "--resume", "batch.json", "--out", "text=a.txt", "Lobby.vtp"})
+	require.NoError(t, root.Execute())
+	assert.Equal(t, []string{"--out=text=a.txt", "--save-first=true"}, *got, "the root flags reach each compile, the batch's own do not")
+
+	root, got = newRoot()
+	addBatchFlags(root.Flags())
+	root.SetArgs([]string{"--save-first", "--state", "lobby.json", "--strict-inputs", "Lobby.vtp", "Boardroom.vtp"})
+	require.NoError(t, root.Execute())
+	assert.Equal(t, []string{"--save-first=true"}, *got, "an implicit batch keeps its own flags too")
+}
+
+func TestRootCmd_TakesBatchFlags(t *testing.T) {
+	t.Parallel()
+
+	// vtpc a.vtp b.vtp is run by runBatchCmd with RootCmd, which reads these
+	for _, name := range []string{"state", "resume", "strict-inputs", "exclude"} {
+		assert.NotNil(t, RootCmd.Flags().Lookup(name), name)
+		assert.NotNil(t, batchCmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestCheckBatchFlags(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, checkBatchFlags(&Config{Output: outputText, DeployPassword: "env:PANEL_PASSWORD"}))
+	assert.ErrorContains(t, checkBatchFlags(&Config{Output: outputJSON}), "use --out json=PATH")
+	assert.ErrorContains(t, checkBatchFlags(&Config{DeployPassword: "stdin:"}), "use env:NAME or file:PATH")
+}
+
+func TestProjectLabels(t *testing.T) {
+	t.Parallel()
+
+	labels := projectLabels([]string{
+		filepath.Join("a", "Lobby.vtp"),
+		filepath.Join("a", "Boardroom.vtp"),
+		filepath.Join("b", "lobby.vtp"),
+		filepath.Join("c", "Lobby.vtp"),
+	})
+
+	assert.Equal(t, map[string]string{
+		filepath.Join("a", "Lobby.vtp"):     "Lobby",
+		filepath.Join("a", "Boardroom.vtp"): "Boardroom",
+		filepath.Join("b", "lobby.vtp"):     "lobby-2",
+		filepath.Join("c", "Lobby.vtp"):     "Lobby-3",
+	}, labels)
+}
+
+func TestProjectArgs(t *testing.T) {
+	t.Parallel()
+
+	flags := []string{
+		"--out=json=reports/run.json",
+		"--out=junit=junit.xml",
+		"--profile=cpu=cpu.pprof",
+		"--record-events=events.jsonl",
+		"--save-first=true",
+	}
+
+	assert.Equal(t, []string{
+		"--out=json=reports/run.Lobby.json",
+		"--out=junit=junit.Lobby.xml",
+		"--profile=cpu=cpu.Lobby.pprof",
+		"--record-events=events.Lobby.jsonl",
+		"--save-first=true",
+	}, projectArgs(flags, "Lobby"))
+	assert.Equal(t, "--out=json=reports/run.json", flags[0], "the batch's flags are left as they were")
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// bellTone returns the sound --bell plays for a run that ended with status.
+// Runs ended by the user, by cancelling or interrupting them, play nothing.
+func bellTone(status string) (windows.Tone, bool) {
+	switch status {
+	case report.StatusOK:
+		return windows.ToneSuccess, true
+	case report.StatusCancelled, report.StatusInterrupted:
+		return 0, false
+	default:
+		return windows.ToneFailure, true
+	}
+}
+
+// ringBell plays the sound for a finished run when the bell is enabled and
+// someone is watching: it is silent when stdout is not a terminal or under CI.
+// The sound is a courtesy, so a failure to play it is ignored.
+func ringBell(sound windows.Sound, status string, enabled, interactive bool) {
+	if !enabled || !interactive {
+		return
+	}
+
+	if tone, ok := bellTone(status); ok {
+		_ = sound.Play(tone)
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/config"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestRingBell_TonePerResult(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		status string
+		want   []windows.Tone
+	}{
+		{report.StatusOK, []windows.Tone{windows.ToneSuccess}},
+		{report.StatusFailed, []windows.Tone{windows.ToneFailure}},
+		{report.StatusTimeout, []windows.Tone{windows.ToneFailure}},
+		{report.StatusCancelled, nil},
+		{report.StatusInterrupted, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			t.Parallel()
+
+			sound := testutil.NewMockSound()
+			ringBell(sound, tt.status, true, true)
+
+			assert.Equal(t, tt.want, sound.Played)
+		})
+	}
+}
+
+func TestRingBell_OnlyWhenEnabled(t *testing.T) {
+	t.Parallel()
+
+	sound := testutil.NewMockSound()
+	ringBell(sound, report.StatusOK, false, true)
+
+	assert.Empty(t, sound.Played)
+}
+
+func TestRingBell_SuppressedWithoutATerminal(t *testing.T) {
+	t.Parallel()
+
+	noEnv := func(string) string { return "" }
+	ciEnv := func(key string) string {
+		if key == "CI" {
+			return "true"
+		}
+
+		return ""
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	sound := testutil.NewMockSound()
+	ringBell(sound, report.StatusFailed, true, isInteractive(f, noEnv))
+	ringBell(sound, report.StatusFailed, true, isInteractive(os.Stdout, ciEnv))
+
+	assert.Empty(t, sound.Played, "redirected output and CI play nothing")
+}
+
+func TestBuildBell(t *testing.T) {
+	t.Parallel()
+
+	on := &config.File{Notify: config.NotifyConfig{Bell: true}}
+	off := &config.File{}
+
+	assert.False(t, buildBell(&Config{}, off))
+	assert.True(t, buildBell(&Config{}, on), "the config file turns the bell on by default")
+	assert.True(t, buildBell(&Config{Bell: true, setFlags: map[string]bool{"bell": true}}, off))
+	assert.False(t, buildBell(&Config{Bell: false, setFlags: map[string]bool{"bell": true}}, on), "--bell=false overrides the config file")
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// ErrBudgetExceeded is returned when a compile finished but took longer than --max-compile-time
+var ErrBudgetExceeded = errcode.New(errcode.BudgetExceeded, "compile time budget exceeded")
+
+// checkCompileBudget returns an ExitBudget error if compileTime is over budget.
+// A zero budget, or a compile whose Compiling dialog was never seen, always passes.
+func checkCompileBudget(compileTime, budget time.Duration) error {
+	if budget <= 0 || compileTime <= budget {
+		return nil
+	}
+
+	return &ExitError{
+		Code: ExitBudget,
+		Err: fmt.Errorf("%w: compiling took %s, over the --max-compile-time budget of %s",
+			ErrBudgetExceeded, compileTime.Round(100*time.Millisecond), budget),
+	}
+}
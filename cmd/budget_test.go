@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCompileBudget(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		compileTime time.Duration
+		budget      time.Duration
+		wantErr     string // Empty when the compile is within budget
+	}{
+		{name: "no budget", compileTime: time.Hour},
+		{name: "within budget", compileTime: 40 * time.Second, budget: 2 * time.Minute},
+		{name: "exactly on budget", compileTime: 2 * time.Minute, budget: 2 * time.Minute},
+		{name: "compiling dialog never seen", budget: 2 * time.Minute},
+		{
+			name:        "over budget",
+			compileTime: 4*time.Minute + 1234*time.Millisecond,
+			budget:      2 * time.Minute,
+			wantErr:     "compile time budget exceeded: compiling took 4m1.2s, over the --max-compile-time budget of 2m0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkCompileBudget(tt.compileTime, tt.budget)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrBudgetExceeded)
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Equal(t, ExitBudget, ExitCode(err))
+		})
+	}
+}
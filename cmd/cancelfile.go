@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// defaultCancelPollInterval is how often the cancel file is checked when no interval is given
+const defaultCancelPollInterval = 2 * time.Second
+
+// watchCancelFile polls for a sentinel file at path and calls onCancel once it appears.
+// The sentinel is deleted before onCancel runs. A sentinel left over from an earlier
+// run is removed up front so it cannot abort this one. The returned function stops
+// the watcher and waits for it to exit.
+func watchCancelFile(path string, interval time.Duration, clk clock.Clock, log logger.LoggerInterface, onCancel func()) (stop func()) {
+	if interval <= 0 {
+		interval = defaultCancelPollInterval
+	}
+
+	if fileExists(path) {
+		log.Warn("Removing stale cancel file", slog.String("path", path))
+		removeCancelFile(path, log)
+	}
+
+	ticker := clk.NewTicker(interval)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	log.Debug("Watching for cancel file",
+		slog.String("path", path),
+		slog.Duration("interval", interval),
+	)
+
+	go func() {
+		defer close(exited)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+
+			case <-ticker.C():
+				if !fileExists(path) {
+					continue
+				}
+
+				log.Info("Cancel file found, cancelling run", slog.String("path", path))
+				removeCancelFile(path, log)
+				onCancel()
+
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// fileExists reports whether a file exists at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// removeCancelFile deletes the sentinel, logging rather than failing if it cannot
+func removeCancelFile(path string, log logger.LoggerInterface) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn("Failed to delete cancel file", slog.String("path", path), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// waitForCancel waits for the watcher to signal cancellation or fails the test
+func waitForCancel(t *testing.T, cancelled <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("cancel file was not detected")
+	}
+}
+
+func TestWatchCancelFile_CancelsWhenFileAppears(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "cancel")
+	clk := clock.NewFake(time.Now())
+	cancelled := make(chan struct{})
+
+	stop := watchCancelFile(path, 2*time.Second, clk, logger.NewNoOpLogger(), func() { close(cancelled) })
+	defer stop()
+
+	// A poll without the file does nothing
+	clk.Advance(2 * time.Second)
+
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+
+	// Keep polling until the watcher picks the file up
+	deadline := time.Now().Add(2 * time.Second)
+	for fileExists(path) && time.Now().Before(deadline) {
+		clk.Advance(2 * time.Second)
+		time.Sleep(time.Millisecond)
+	}
+
+	waitForCancel(t, cancelled)
+	assert.False(t, fileExists(path), "sentinel is deleted")
+}
+
+func TestWatchCancelFile_RemovesStaleSentinel(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "cancel")
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+
+	called := false
+	stop := watchCancelFile(path, time.Second, clock.NewFake(time.Now()), logger.NewNoOpLogger(), func() { called = true })
+	defer stop()
+
+	assert.False(t, fileExists(path))
+	assert.False(t, called)
+}
+
+func TestWatchCancelFile_StopPreventsCancel(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "cancel")
+	clk := clock.NewFake(time.Now())
+	cancelled := make(chan struct{}, 1)
+
+	stop := watchCancelFile(path, time.Second, clk, logger.NewNoOpLogger(), func() { cancelled <- struct{}{} })
+	stop()
+
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+	clk.Advance(time.Second)
+
+	select {
+	case <-cancelled:
+		t.Fatal("stopped watcher must not cancel")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.True(t, fileExists(path), "a stopped watcher leaves the file alone")
+}
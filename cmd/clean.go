@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Norgate-AV/vtpc/internal/config"
+	"github.com/Norgate-AV/vtpc/internal/diagfiles"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// cleanCmd applies the diagnostics retention limits on demand, as every run does when it finishes
+var cleanCmd = &cobra.Command{
+	Use:   "clean --diagnostics",
+	Short: "Delete the oldest diagnostic files beyond their retention limits",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := NewConfigFromFlags(cmd)
+
+		log, err := initializeLogger(cfg)
+		if err != nil {
+			return err
+		}
+
+		defer log.Close()
+
+		file, err := loadConfigFile(cfg, log)
+		if err != nil {
+			return err
+		}
+
+		retention, err := buildRetention(file)
+		if err != nil {
+			return err
+		}
+
+		result, err := newDiagnostics(retention, log).Enforce()
+		writeCleanResult(cmd.OutOrStdout(), result)
+
+		return err
+	},
+}
+
+func init() {
+	cleanCmd.Flags().Bool("diagnostics", false, "delete the oldest screenshots, dumps and raw logs beyond their limits")
+	_ = cleanCmd.MarkFlagRequired("diagnostics")
+	RootCmd.AddCommand(cleanCmd)
+}
+
+// buildRetention applies the diagnostics section of the config file to the default limits
+func buildRetention(file *config.File) ([]diagfiles.Category, error) {
+	categories := diagfiles.DefaultCategories()
+
+	for name, rc := range file.Diagnostics {
+		i := slices.IndexFunc(categories, func(c diagfiles.Category) bool { return c.Name == name })
+		if i < 0 {
+			var known []string
+			for _, c := range categories {
+				known = append(known, c.Name)
+			}
+
+			return nil, fmt.Errorf("config diagnostics: unknown category %q, must be one of %s", name, strings.Join(known, ", "))
+		}
+
+		if rc.MaxFiles != nil {
+			if *rc.MaxFiles < 0 {
+				return nil, fmt.Errorf("config diagnostics.%s.maxFiles must not be negative, got %d", name, *rc.MaxFiles)
+			}
+
+			categories[i].MaxFiles = *rc.MaxFiles
+		}
+
+		if rc.MaxMB != nil {
+			if *rc.MaxMB < 0 {
+				return nil, fmt.Errorf("config diagnostics.%s.maxMB must not be negative, got %d", name, *rc.MaxMB)
+			}
+
+			categories[i].MaxBytes = *rc.MaxMB << 20
+		}
+	}
+
+	return categories, nil
+}
+
+// newDiagnostics creates the retention manager for the diagnostics under the vtpc data directory
+func newDiagnostics(retention []diagfiles.Category, log logger.LoggerInterface) *diagfiles.Manager {
+	return diagfiles.New(filepath.Dir(logger.GetLogPath(logger.LoggerOptions{})), retention, log)
+}
+
+// enforceRetention deletes the oldest diagnostic files after a run. A failure
+// is logged but does not change the run's result.
+func enforceRetention(m *diagfiles.Manager, log logger.LoggerInterface) {
+	result, err := m.Enforce()
+	if err != nil {
+		log.Warn("Could not apply the diagnostics retention limits", slog.Any("error", err))
+	}
+
+	if len(result.Deleted) > 0 || len(result.Locked) > 0 {
+		log.Debug("Applied the diagnostics retention limits",
+			slog.Int("deleted", len(result.Deleted)),
+			slog.Int64("freedBytes", result.Freed),
+			slog.Int("locked", len(result.Locked)),
+		)
+	}
+}
+
+// writeCleanResult writes what vtpc clean deleted, and what it could not
+func writeCleanResult(w io.Writer, result diagfiles.Result) {
+	fmt.Fprintf(w, "Deleted %d diagnostic file(s), freeing %.1f MB\n", len(result.Deleted), float64(result.Freed)/(1<<20))
+
+	if len(result.Locked) > 0 {
+		fmt.Fprintf(w, "Could not delete %d file(s) that are in use; they are retried after the next run:\n", len(result.Locked))
+
+		for _, path := range result.Locked {
+			fmt.Fprintf(w, "  %s\n", path)
+		}
+	}
+}
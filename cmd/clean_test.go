@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/config"
+	"github.com/Norgate-AV/vtpc/internal/diagfiles"
+)
+
+func TestBuildRetention(t *testing.T) {
+	t.Parallel()
+
+	ten, zero, negative := 10, 0, -1
+	mb := int64(5)
+
+	tests := []struct {
+		name    string
+		config  map[string]config.RetentionConfig
+		want    map[string]diagfiles.Category
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			want: map[string]diagfiles.Category{diagfiles.Dumps: diagfiles.DefaultCategories()[1]},
+		},
+		{
+			name:   "override one limit",
+			config: map[string]config.RetentionConfig{diagfiles.Screenshots: {MaxFiles: &ten}},
+			want:   map[string]diagfiles.Category{diagfiles.Screenshots: {Name: diagfiles.Screenshots, MaxFiles: 10, MaxBytes: 200 << 20}},
+		},
+		{
+			name:   "remove a limit and set a size",
+			config: map[string]config.RetentionConfig{diagfiles.RawLogs: {MaxFiles: &zero, MaxMB: &mb}},
+			want:   map[string]diagfiles.Category{diagfiles.RawLogs: {Name: diagfiles.RawLogs, MaxBytes: 5 << 20}},
+		},
+		{
+			name:    "unknown category",
+			config:  map[string]config.RetentionConfig{"videos": {MaxFiles: &ten}},
+			wantErr: `unknown category "videos", must be one of screenshots, dumps, raw-logs`,
+		},
+		{
+			name:    "negative limit",
+			config:  map[string]config.RetentionConfig{diagfiles.Dumps: {MaxFiles: &negative}},
+			wantErr: "diagnostics.dumps.maxFiles must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := buildRetention(&config.File{Diagnostics: tt.config})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, got, len(diagfiles.DefaultCategories()))
+
+			for _, c := range got {
+				if want, ok := tt.want[c.Name]; ok {
+					assert.Equal(t, want, c)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteCleanResult(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	writeCleanResult(&buf, diagfiles.Result{
+		Deleted: []string{"a.png", "b.png"},
+		Freed:   3 << 19,
+		Locked:  []string{`C:\vtpc\diagnostics\screenshots\c.png`},
+	})
+
+	assert.Equal(t, "Deleted 2 diagnostic file(s), freeing 1.5 MB\n"+
+		"Could not delete 1 file(s) that are in use; they are retried after the next run:\n"+
+		"  C:\\vtpc\\diagnostics\\screenshots\\c.png\n", buf.String())
+}
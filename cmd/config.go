@@ -1,12 +1,76 @@
 // Package cmd implements the command-line interface for vtpc.
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/Norgate-AV/vtpc/internal/config"
+)
 
 // Config holds all application configuration
 type Config struct {
-	Verbose  bool
-	ShowLogs bool
+	FilePath      string // Project given on the command line, if any
+	Verbose       bool
+	ShowLogs      bool
+	ConfigPath    string   // Path to the config file (defaults to config.yaml next to the log file)
+	MessageOrder  string   // How messages are printed: "severity" (grouped) or "log" (log order)
+	Format        string   // How messages are rendered: "list" or "table"
+	Output        string   // What stdout carries: "text", or "json" for the run as one JSON document
+	SaveFirst     bool     // Save the project with Ctrl+S before compiling
+	ForceCleanup  bool     // Force terminate VTPro without asking when it will not close
+	StrictDialogs bool     // Fail on any unknown dialog during the compile, leaving it open
+	ExpectTitle   string   // Substring the selected VTPro main window's title must contain
+	StrictParse   bool     // Flag counts and sizes that are not plain English numbers
+	IgnorePages   []string // Globs of pages whose messages are left out of the results
+	Outputs       []string // Reports to write, each "format=path"
+	MinFreeMB     uint     // Free disk space required before compiling, 0 to skip the check
+	AbsoluteTimes bool     // Show when the run started and finished in the exit banner
+	Pause         bool     // Wait for Enter before exiting
+	Bell          bool     // Play a success or failure sound when the run finishes
+
+	MessageLinkTemplate string // Template linking each reported message to its source
+	MainWindowClass     string // Window class of the VTPro main window, "" for the config file's or the default
+
+	Heartbeat      time.Duration // Interval between "still compiling" messages, 0 to disable
+	MaxCompileTime time.Duration // Compile time over which a finished run fails, 0 to disable
+	SlowDialog     time.Duration // How long a dialog may stay open before it is warned about, 0 to disable
+	OnSleep        string        // What waits do if the system sleeps: "extend", "fail" or "ignore"
+	WaitForWindow  bool          // Wait for the config file's maintenance window rather than fail outside it
+	IgnoreSchedule bool          // Compile even outside the config file's maintenance window
+	LiveLog        bool          // Echo lines as VTPro adds them to the Message Log while compiling
+	InputMethod    string        // How the compile keystroke is sent: "auto", "sendinput", "keybd_event" or "postmessage"
+
+	SlowInputMultiplier float64 // Factor keystroke and focus delays are lengthened by in a VM or remote session
+	NoInputAdapt        bool    // Keep the default delays even in a VM or remote session
+
+	LaunchMinimized bool // Launch VTPro minimized and only show it for the compile keystroke
+	NeverTerminate  bool // Leave a VTPro that will not close running rather than terminate it
+
+	RecordEvents string // JSONL file every window event is recorded to, for vtpc replay
+	TraceWin32   bool   // Log every Win32 call made to drive VTPro at Trace level
+	EventLog     bool   // Write a summary of the run to the Windows Event Log
+
+	Profiles []string // pprof profiles of vtpc itself to write, each "cpu=path" or "mem=path"
+
+	CancelFile         string        // Sentinel file that aborts the run when it appears
+	CancelPollInterval time.Duration // How often CancelFile is checked
+
+	Isolate           bool     // Compile a copy of the project in a temporary directory
+	Sidecars          []string // Files/directories next to the project copied with Isolate
+	RequireSidecars   bool     // Fail before launching VTPro when the project's sidecars are missing
+	OutDir            string   // Where compiled artifacts are copied (default: next to the project)
+	KeepTempOnFailure bool     // Keep the isolated directory when the compile fails
+	VerifyArtifact    bool     // Check the compiled artifact is a well-formed archive
+	Provenance        bool     // Write a provenance sidecar beside each compiled artifact
+	Deploy            string   // ftp:// or sftp:// URL the compiled artifact is uploaded to
+	DeployPassword    string   // env:, file: or stdin: reference to the --deploy password
+
+	setFlags map[string]bool     // Flags given on the command line, rather than left at their defaults
+	locator  ArtifactLocator     // Replaces the built-in artifact search, from SetArtifactLocator; not a flag
+	project  *config.ProjectFile // The project file next to FilePath, nil until it is loaded; not a flag
 }
 
 // NewConfigFromFlags creates a Config from parsed command flags
@@ -14,13 +78,123 @@ func NewConfigFromFlags(cmd *cobra.Command) *Config {
 	// Try to get from local flags first, fall back to persistent flags
 	verbose := getBoolFlag(cmd, "verbose")
 	showLogs := getBoolFlag(cmd, "logs")
+	configPath := getStringFlag(cmd, "config")
+	messageOrder := getStringFlag(cmd, "message-order")
+	format := getStringFlag(cmd, "format")
+	outputFormat := getStringFlag(cmd, "output")
+	saveFirst := getBoolFlag(cmd, "save-first")
+	forceCleanup := getBoolFlag(cmd, "force-cleanup")
+	neverTerminate := getBoolFlag(cmd, "never-terminate")
+	strictDialogs := getBoolFlag(cmd, "strict-dialogs")
+	launchMinimized := getBoolFlag(cmd, "launch-minimized")
+	expectTitle := getStringFlag(cmd, "expect-title")
+	mainWindowClass := getStringFlag(cmd, "main-window-class")
+	strictParse := getBoolFlag(cmd, "strict-parse")
+	ignorePages := getStringArrayFlag(cmd, "ignore-pages")
+	outputs := getStringArrayFlag(cmd, "out")
+	messageLinkTemplate := getStringFlag(cmd, "message-link-template")
+	minFreeMB := getUintFlag(cmd, "min-free-mb")
+	absoluteTimes := getBoolFlag(cmd, "absolute-times")
+	pause := getBoolFlag(cmd, "pause")
+	bell := getBoolFlag(cmd, "bell")
+	heartbeat := getDurationFlag(cmd, "heartbeat")
+	maxCompileTime := getDurationFlag(cmd, "max-compile-time")
+	slowDialog := getDurationFlag(cmd, "slow-dialog")
+	onSleep := getStringFlag(cmd, "on-sleep")
+	waitForWindow := getBoolFlag(cmd, "wait-for-window")
+	ignoreSchedule := getBoolFlag(cmd, "ignore-schedule")
+	liveLog := getBoolFlag(cmd, "live-log")
+	inputMethod := getStringFlag(cmd, "input-method")
+	slowInputMultiplier := getFloat64Flag(cmd, "slow-input-multiplier")
+	noInputAdapt := getBoolFlag(cmd, "no-input-adapt")
+	cancelFile := getStringFlag(cmd, "cancel-file")
+	cancelPollInterval := getDurationFlag(cmd, "cancel-poll-interval")
+	isolate := getBoolFlag(cmd, "isolate")
+	sidecars := getStringSliceFlag(cmd, "sidecar")
+	requireSidecars := getBoolFlag(cmd, "require-sidecars")
+	outDir := getStringFlag(cmd, "out-dir")
+	keepTempOnFailure := getBoolFlag(cmd, "keep-temp-on-failure")
+	verifyArtifact := getBoolFlag(cmd, "verify-artifact")
+	provenance := getBoolFlag(cmd, "provenance")
+	deployURL := getStringFlag(cmd, "deploy")
+	deployPassword := getStringFlag(cmd, "deploy-password")
+	recordEvents := getStringFlag(cmd, "record-events")
+	traceWin32 := getBoolFlag(cmd, "trace-win32")
+	eventLog := getBoolFlag(cmd, "eventlog")
+	profiles := getStringArrayFlag(cmd, "profile")
 
 	return &Config{
-		Verbose:  verbose,
-		ShowLogs: showLogs,
+		Verbose:       verbose,
+		ShowLogs:      showLogs,
+		ConfigPath:    configPath,
+		MessageOrder:  messageOrder,
+		Format:        format,
+		Output:        outputFormat,
+		SaveFirst:     saveFirst,
+		ForceCleanup:  forceCleanup,
+		StrictDialogs: strictDialogs,
+		ExpectTitle:   expectTitle,
+		StrictParse:   strictParse,
+		IgnorePages:   ignorePages,
+		Outputs:       outputs,
+		MinFreeMB:     minFreeMB,
+		AbsoluteTimes: absoluteTimes,
+		Pause:         pause,
+		Bell:          bell,
+
+		MessageLinkTemplate: messageLinkTemplate,
+		MainWindowClass:     mainWindowClass,
+
+		Heartbeat:      heartbeat,
+		MaxCompileTime: maxCompileTime,
+		SlowDialog:     slowDialog,
+		OnSleep:        onSleep,
+		WaitForWindow:  waitForWindow,
+		IgnoreSchedule: ignoreSchedule,
+		LiveLog:        liveLog,
+		InputMethod:    inputMethod,
+
+		SlowInputMultiplier: slowInputMultiplier,
+		NoInputAdapt:        noInputAdapt,
+
+		LaunchMinimized: launchMinimized,
+		NeverTerminate:  neverTerminate,
+
+		RecordEvents: recordEvents,
+		TraceWin32:   traceWin32,
+		EventLog:     eventLog,
+
+		Profiles: profiles,
+
+		CancelFile:         cancelFile,
+		CancelPollInterval: cancelPollInterval,
+
+		Isolate:           isolate,
+		Sidecars:          sidecars,
+		RequireSidecars:   requireSidecars,
+		OutDir:            outDir,
+		KeepTempOnFailure: keepTempOnFailure,
+		VerifyArtifact:    verifyArtifact,
+		Provenance:        provenance,
+		Deploy:            deployURL,
+		DeployPassword:    deployPassword,
+
+		setFlags: changedFlags(cmd),
+		locator:  artifactLocator,
 	}
 }
 
+// changedFlags returns the names of the flags given on the command line
+func changedFlags(cmd *cobra.Command) map[string]bool {
+	changed := make(map[string]bool)
+	mark := func(f *pflag.Flag) { changed[f.Name] = true }
+
+	cmd.Flags().Visit(mark)
+	cmd.PersistentFlags().Visit(mark)
+
+	return changed
+}
+
 // getBoolFlag retrieves a boolean flag, checking both local and persistent flags
 func getBoolFlag(cmd *cobra.Command, name string) bool {
 	val, err := cmd.Flags().GetBool(name)
@@ -31,3 +205,69 @@ func getBoolFlag(cmd *cobra.Command, name string) bool {
 
 	return val
 }
+
+// getStringFlag retrieves a string flag, checking both local and persistent flags
+func getStringFlag(cmd *cobra.Command, name string) string {
+	val, err := cmd.Flags().GetString(name)
+	if err != nil {
+		// Try persistent flags if not found in local flags
+		val, _ = cmd.PersistentFlags().GetString(name)
+	}
+
+	return val
+}
+
+// getUintFlag retrieves an unsigned integer flag, checking both local and persistent flags
+func getUintFlag(cmd *cobra.Command, name string) uint {
+	val, err := cmd.Flags().GetUint(name)
+	if err != nil {
+		// Try persistent flags if not found in local flags
+		val, _ = cmd.PersistentFlags().GetUint(name)
+	}
+
+	return val
+}
+
+// getDurationFlag retrieves a duration flag, checking both local and persistent flags
+func getDurationFlag(cmd *cobra.Command, name string) time.Duration {
+	val, err := cmd.Flags().GetDuration(name)
+	if err != nil {
+		// Try persistent flags if not found in local flags
+		val, _ = cmd.PersistentFlags().GetDuration(name)
+	}
+
+	return val
+}
+
+// getFloat64Flag retrieves a float flag, checking both local and persistent flags
+func getFloat64Flag(cmd *cobra.Command, name string) float64 {
+	val, err := cmd.Flags().GetFloat64(name)
+	if err != nil {
+		// Try persistent flags if not found in local flags
+		val, _ = cmd.PersistentFlags().GetFloat64(name)
+	}
+
+	return val
+}
+
+// getStringSliceFlag retrieves a string slice flag, checking both local and persistent flags
+func getStringSliceFlag(cmd *cobra.Command, name string) []string {
+	val, err := cmd.Flags().GetStringSlice(name)
+	if err != nil {
+		// Try persistent flags if not found in local flags
+		val, _ = cmd.PersistentFlags().GetStringSlice(name)
+	}
+
+	return val
+}
+
+// getStringArrayFlag retrieves a string array flag, checking both local and persistent flags
+func getStringArrayFlag(cmd *cobra.Command, name string) []string {
+	val, err := cmd.Flags().GetStringArray(name)
+	if err != nil {
+		// Try persistent flags if not found in local flags
+		val, _ = cmd.PersistentFlags().GetStringArray(name)
+	}
+
+	return val
+}
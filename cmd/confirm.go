@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+)
+
+// confirmTimeout is how long a confirmation prompt waits before answering no
+const confirmTimeout = 10 * time.Second
+
+// confirm asks a yes/no question on out and reads the answer from in. Anything
+// but "y" or "yes", including no answer within timeout, means no.
+func confirm(in io.Reader, out io.Writer, clk clock.Clock, question string, timeout time.Duration) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+
+	// The read is abandoned on timeout; vtpc is exiting by then
+	answers := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(in).ReadString('\n')
+		answers <- line
+	}()
+
+	ticker := clk.NewTicker(timeout)
+	defer ticker.Stop()
+
+	select {
+	case line := <-answers:
+		answer := strings.ToLower(strings.TrimSpace(line))
+		return answer == "y" || answer == "yes"
+	case <-ticker.C():
+		fmt.Fprintln(out)
+		return false
+	}
+}
+
+// isInteractive reports whether a person can answer prompts on in: it must be a
+// terminal, and the CI environment variable set by CI services must be empty
+func isInteractive(in *os.File, getenv func(string) string) bool {
+	if getenv("CI") != "" {
+		return false
+	}
+
+	info, err := in.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+)
+
+var confirmEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestConfirm_Answers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "y\n", want: true},
+		{input: "YES\r\n", want: true},
+		{input: "n\n", want: false},
+		{input: "\n", want: false},
+		{input: "", want: false}, // EOF, e.g. stdin closed
+		{input: "maybe\n", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.input), func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			got := confirm(strings.NewReader(tt.input), &out, clock.NewFake(confirmEpoch), "Force terminate VTPro (PID 1234, 'other.vtp')?", confirmTimeout)
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, "Force terminate VTPro (PID 1234, 'other.vtp')? [y/N] ", out.String())
+		})
+	}
+}
+
+func TestConfirm_TimeoutMeansNo(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(confirmEpoch)
+	in, w := io.Pipe()
+	defer func() { _ = w.Close() }()
+
+	done := make(chan bool, 1)
+	go func() { done <- confirm(in, io.Discard, clk, "Continue?", confirmTimeout) }()
+
+	// The ticker may not exist yet on the first advance, so keep advancing until it fires
+	deadline := time.After(5 * time.Second)
+	for {
+		clk.Advance(confirmTimeout)
+
+		select {
+		case got := <-done:
+			assert.False(t, got)
+			return
+		case <-deadline:
+			t.Fatal("confirm did not time out")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestIsInteractive(t *testing.T) {
+	t.Parallel()
+
+	noEnv := func(string) string { return "" }
+	ciEnv := func(key string) string {
+		if key == "CI" {
+			return "true"
+		}
+
+		return ""
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdin"))
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	assert.False(t, isInteractive(f, noEnv), "a redirected file is not a terminal")
+	assert.False(t, isInteractive(os.Stdin, ciEnv), "CI is never interactive")
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/textutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// consoleOut is where output meant for the console is written. It is stdout,
+// transliterated when the console could not be switched to UTF-8.
+var consoleOut io.Writer = os.Stdout
+
+// consoleState is what SetupConsole found, kept for the debug log
+var consoleState windows.ConsoleOutput
+
+// restoreConsole puts back the console code page vtpc found at startup. The
+// abort path calls it too, since it exits without returning to main.
+var restoreConsole = func() {}
+
+// SetupConsole switches the console to UTF-8 output so text from VTPro is shown
+// as written, and returns the function that switches it back on exit
+func SetupConsole() func() {
+	state, restore := windows.EnsureUTF8Output(windows.StdoutConsole{})
+
+	consoleState = state
+	consoleOut = consoleWriter(os.Stdout, state)
+	restoreConsole = sync.OnceFunc(restore)
+
+	// Subcommands write through OutOrStdout, which falls back to the root's writer
+	RootCmd.SetOut(consoleOut)
+
+	return restoreConsole
+}
+
+// redirectConsole sends console output to stderr, so stdout carries only the
+// JSON document of --output json
+func redirectConsole() {
+	consoleOut = consoleWriter(os.Stderr, consoleState)
+}
+
+// logConsoleState records the console's code page and what SetupConsole did with it
+func logConsoleState(state windows.ConsoleOutput, log logger.LoggerInterface) {
+	if state.Err != nil {
+		log.Debug("Could not switch the console to UTF-8, transliterating console output",
+			slog.Uint64("codePage", uint64(state.Original)),
+			slog.Any("error", state.Err),
+		)
+
+		return
+	}
+
+	log.Debug("Console output",
+		slog.Bool("redirected", state.Redirected),
+		slog.Uint64("codePage", uint64(state.Original)),
+		slog.Bool("switchedToUTF8", state.Switched),
+	)
+}
+
+// consoleWriter returns w, or w with punctuation transliterated to ASCII when
+// the console shows a legacy code page that cannot represent it
+func consoleWriter(w io.Writer, state windows.ConsoleOutput) io.Writer {
+	if state.UTF8() {
+		return w
+	}
+
+	return transliterator{w: w}
+}
+
+// transliterator writes text to w with textutil.Transliterate applied
+type transliterator struct {
+	w io.Writer
+}
+
+func (t transliterator) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(t.w, textutil.Transliterate(string(p))); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
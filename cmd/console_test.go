@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestConsoleWriter(t *testing.T) {
+	t.Parallel()
+
+	const msg = "Object \u201cVolume\u201d \u2013 join 12\u2026\n"
+
+	tests := []struct {
+		name  string
+		state windows.ConsoleOutput
+		want  string
+	}{
+		{name: "switched to UTF-8", state: windows.ConsoleOutput{Original: 850, Switched: true}, want: msg},
+		{name: "redirected", state: windows.ConsoleOutput{Redirected: true}, want: msg},
+		{name: "switch failed", state: windows.ConsoleOutput{Original: 850, Err: errors.New("denied")}, want: "Object \"Volume\" - join 12...\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			n, err := consoleWriter(&buf, tt.state).Write([]byte(msg))
+
+			require.NoError(t, err)
+			assert.Equal(t, len(msg), n, "the bytes written are those given, not those transliterated")
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
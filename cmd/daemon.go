@@ -0,0 +1,433 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/Norgate-AV/vtpc/internal/daemon"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/pathutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// daemonCmd serves compile requests from editors over a named pipe
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Serve compile requests from editors on " + daemon.PipeName,
+	Args:  cobra.NoArgs,
+	RunE:  runDaemon,
+}
+
+// clientCmd groups the commands that talk to a running daemon
+var clientCmd = &cobra.Command{
+	Use:   "client",
+	Short: "Send a request to a running vtpc daemon",
+}
+
+var clientCompileCmd = &cobra.Command{
+	Use:   "compile <file-path>",
+	Short: "Compile through the daemon, or in-process if no daemon is running",
+	Args:  validateClientCompileArgs,
+	RunE:  runClientCompileCmd,
+}
+
+var clientStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show what the daemon is doing",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runClientRequest(dialDaemon, daemon.Request{Type: daemon.RequestStatus}, cmd.OutOrStdout())
+	},
+}
+
+var clientShutdownCmd = &cobra.Command{
+	Use:   "shutdown",
+	Short: "Stop the daemon once any compile in progress finishes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runClientRequest(dialDaemon, daemon.Request{Type: daemon.RequestShutdown}, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	daemonCmd.Flags().Duration("idle-close", daemon.DefaultIdleTimeout, "close the VTPro kept open after this long without a compile request (0 to keep it open)")
+	daemonCmd.Flags().Int("pprof-port", 0, "serve net/http/pprof on this port on 127.0.0.1 only (0 to disable)")
+	clientCmd.AddCommand(clientCompileCmd, clientStatusCmd, clientShutdownCmd)
+	RootCmd.AddCommand(daemonCmd, clientCmd)
+}
+
+// dialDaemon connects to the daemon's named pipe
+func dialDaemon() (io.ReadWriteCloser, error) {
+	return windows.DialPipe(daemon.PipeName)
+}
+
+// runDaemon listens on the pipe until a shutdown request or Ctrl+C
+func runDaemon(cmd *cobra.Command, _ []string) error {
+	cfg := NewConfigFromFlags(cmd)
+
+	// The daemon gets its own log so it does not rotate the log its compiles write to
+	log, err := logger.NewLogger(logger.LoggerOptions{
+		Verbose:  cfg.Verbose,
+		LogDir:   filepath.Join(filepath.Dir(logger.GetLogPath(logger.LoggerOptions{})), "daemon"),
+		Compress: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+
+	defer log.Close()
+
+	// Compiles run in the daemon, so elevate once up front
+	if err := ensureElevated(log); err != nil {
+		return err
+	}
+
+	listener, err := windows.ListenPipe(daemon.PipeName)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	idleClose, _ := cmd.Flags().GetDuration("idle-close")
+
+	if port, _ := cmd.Flags().GetInt("pprof-port"); port != 0 {
+		stopDebug, err := serveDebug(port, log)
+		if err != nil {
+			return err
+		}
+
+		defer stopDebug()
+	}
+
+	log.Info("vtpc daemon listening", slog.String("pipe", daemon.PipeName), slog.Duration("idleClose", idleClose))
+
+	warm := newWarmVTPro(log)
+	defer warm.Close()
+
+	srv := daemon.NewServer(&warmCompiler{warm: warm, log: log}, log).WithIdleTimeout(idleClose)
+	if err := srv.Serve(ctx, listener); err != nil {
+		return err
+	}
+
+	log.Info("vtpc daemon stopped")
+
+	return nil
+}
+
+// warmCompiler runs each daemon compile in the daemon itself, on a VTPro it
+// keeps open between compiles, streaming the compile's console output
+type warmCompiler struct {
+	warm *warmVTPro
+	log  logger.LoggerInterface
+}
+
+// daemonFlags are the flags a daemon compile accepts. The daemon runs elevated
+// for whoever can reach its pipe, so flags that name a file, directory or host
+// vtpc would read, write, delete or upload to, such as --out, --config,
+// --cancel-file or --deploy, are left out, as are --pause and --output, which
+// would stall or garble the streamed output.
+var daemonFlags = map[string]bool{
+	"verbose":               true,
+	"isolate":               true,
+	"keep-temp-on-failure":  true,
+	"provenance":            true,
+	"verify-artifact":       true,
+	"save-first":            true,
+	"launch-minimized":      true,
+	"force-cleanup":         true,
+	"never-terminate":       true,
+	"strict-dialogs":        true,
+	"expect-title":          true,
+	"main-window-class":     true,
+	"require-sidecars":      true,
+	"strict-parse":          true,
+	"ignore-pages":          true,
+	"message-link-template": true,
+	"wait-for-window":       true,
+	"ignore-schedule":       true,
+	"min-free-mb":           true,
+	"max-compile-time":      true,
+	"on-sleep":              true,
+	"heartbeat":             true,
+	"slow-dialog":           true,
+	"input-method":          true,
+	"slow-input-multiplier": true,
+	"no-input-adapt":        true,
+	"trace-win32":           true,
+	"live-log":              true,
+	"message-order":         true,
+	"format":                true,
+	"absolute-times":        true,
+	"bell":                  true,
+}
+
+// maxOutputLine bounds one line of a compile's output. Long message lists
+// can make a line far longer than bufio.Scanner's 64 KB default.
+const maxOutputLine = 1 << 20
+
+// heartbeatProgressRe matches the progress in a "still compiling" heartbeat line
+var heartbeatProgressRe = regexp.MustCompile(`progress (\d{1,3})%`)
+
+// Compile runs vtpc on the requested file. Only the flags in daemonFlags are
+// accepted, and the project must be given by its full path.
+func (c *warmCompiler) Compile(_ context.Context, req daemon.Request, emit func(daemon.Event)) (int, error) {
+	if err := checkDaemonArgs(req.Args); err != nil {
+		return 0, err
+	}
+
+	if !strings.EqualFold(filepath.Ext(req.File), ".vtp") || !filepath.IsAbs(req.File) {
+		return 0, fmt.Errorf("daemon compiles need the full path of a .vtp file, got %q", req.File)
+	}
+
+	// Read into flags of their own, so one compile's flags never reach the next
+	cmd := newRunCmd()
+	if err := cmd.ParseFlags(req.Args); err != nil {
+		return 0, err
+	}
+
+	out, in := io.Pipe()
+	streamed := make(chan error, 1)
+
+	go func() {
+		err := streamOutput(out, emit)
+
+		// Keep reading, or the compile would block writing to a full pipe
+		_, _ = io.Copy(io.Discard, out)
+		streamed <- err
+	}()
+
+	err := runDaemonCompile(cmd, req, in, c.warm)
+	_ = in.Close()
+
+	if serr := <-streamed; serr != nil {
+		c.log.Warn("Stopped streaming vtpc output", slog.Any("error", serr))
+		emit(daemon.Event{Type: daemon.EventLog, Message: "vtpc daemon: " + serr.Error()})
+	}
+
+	return ExitCode(err), nil
+}
+
+// CloseIdle closes the VTPro kept open, for the daemon's idle policy
+func (c *warmCompiler) CloseIdle() bool {
+	return c.warm.Close()
+}
+
+// newRunCmd returns a command with the flags of a single-project run
+func newRunCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: RootCmd.Use, SilenceUsage: true}
+	addRunFlags(cmd.PersistentFlags())
+
+	return cmd
+}
+
+// runDaemonCompile runs req's compile as Execute runs a single project, with
+// its console output written to out and VTPro taken from warm
+func runDaemonCompile(cmd *cobra.Command, req daemon.Request, out io.Writer, warm *warmVTPro) (err error) {
+	// The daemon runs one compile at a time, so each can have the console in turn
+	console := consoleOut
+	consoleOut = out
+
+	defer func() { consoleOut = console }()
+
+	s := startRun(cmd, []string{req.File})
+	s.argv = append(slices.Clone(req.Args), req.File)
+	s.warm = warm
+
+	defer func() { s.finish(err) }()
+
+	if s.reportsErr != nil {
+		return s.reportsErr
+	}
+
+	return s.run(cmd)
+}
+
+// streamOutput emits each line of r as a log event, and a progress event whenever
+// a heartbeat reports a new percentage. It stops at a line longer than
+// maxOutputLine, returning the error.
+func streamOutput(r io.Reader, emit func(daemon.Event)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxOutputLine)
+
+	last := -1
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		emit(daemon.Event{Type: daemon.EventLog, Message: line})
+
+		if m := heartbeatProgressRe.FindStringSubmatch(line); m != nil {
+			if percent, err := strconv.Atoi(m[1]); err == nil && percent != last {
+				last = percent
+				emit(daemon.Event{Type: daemon.EventProgress, Percent: percent})
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read vtpc output: %w", err)
+	}
+
+	return nil
+}
+
+// validateClientCompileArgs requires exactly one .vtp file
+func validateClientCompileArgs(cmd *cobra.Command, args []string) error {
+	if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+		return err
+	}
+
+	return validateArgs(cmd, args)
+}
+
+// runClientCompileCmd sends the compile to the daemon with the flags given on the
+// command line, compiling in-process when no daemon is running
+func runClientCompileCmd(cmd *cobra.Command, args []string) error {
+	cfg := NewConfigFromFlags(cmd)
+
+	// ~ and %VAR% are expanded here, as the daemon may not share this environment
+	cfg.FilePath, _ = pathutil.Normalize(args[0], pathutil.OSEnv())
+
+	// The daemon does not run in this directory, so it needs the full path
+	abs, err := filepath.Abs(cfg.FilePath)
+	if err != nil {
+		return err
+	}
+
+	cfg.FilePath = abs
+
+	if err := loadProjectFile(cfg); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	flags, err := forwardedFlags(cmd.Flags())
+	if err != nil {
+		return err
+	}
+
+	req := daemon.Request{
+		Type: daemon.RequestCompile,
+		File: cfg.FilePath,
+		Args: flags,
+	}
+
+	return runClientCompile(dialDaemon, req, cmd.OutOrStdout(), func() error {
+		return Execute(cmd, args)
+	})
+}
+
+// runClientCompile sends a compile request to the daemon and prints its output.
+// If no daemon is running, it says so and runs fallback instead.
+func runClientCompile(dial daemon.Dialer, req daemon.Request, w io.Writer, fallback func() error) error {
+	final, err := daemon.Send(dial, req, func(ev daemon.Event) {
+		if ev.Type == daemon.EventLog {
+			fmt.Fprintln(w, ev.Message)
+		}
+	})
+	if errors.Is(err, daemon.ErrNoDaemon) {
+		fmt.Fprintln(w, "No vtpc daemon running, compiling in-process")
+		return fallback()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if final.ExitCode != ExitSuccess {
+		return &ExitError{
+			Code: final.ExitCode,
+			Err:  fmt.Errorf("daemon compile failed with exit code %d", final.ExitCode),
+		}
+	}
+
+	return nil
+}
+
+// runClientRequest sends a status or shutdown request and prints the daemon's status
+func runClientRequest(dial daemon.Dialer, req daemon.Request, w io.Writer) error {
+	final, err := daemon.Send(dial, req, nil)
+	if err != nil {
+		return err
+	}
+
+	if s := final.Status; s != nil {
+		state := "idle"
+		if s.Busy {
+			state = "compiling " + s.File
+		}
+
+		fmt.Fprintf(w, "vtpc daemon: %s, %d compile(s), up %s\n", state, s.Compiles, s.Uptime)
+	}
+
+	return nil
+}
+
+// forwardedFlags returns the flags set on the command line as arguments for
+// the daemon's compile, one argument per value of a repeatable flag. It
+// fails on a flag the daemon does not accept, rather than compile without it.
+func forwardedFlags(flags *pflag.FlagSet) ([]string, error) {
+	var (
+		args     []string
+		rejected []string
+	)
+
+	flags.Visit(func(f *pflag.Flag) {
+		if !daemonFlags[f.Name] {
+			rejected = append(rejected, "--"+f.Name)
+			return
+		}
+
+		args = append(args, flagArgs(f)...)
+	})
+
+	if len(rejected) > 0 {
+		return nil, fmt.Errorf("the daemon does not accept %s; run vtpc directly to use them", strings.Join(rejected, ", "))
+	}
+
+	return args, nil
+}
+
+// checkDaemonArgs fails unless every argument is --name=value for a flag in daemonFlags
+func checkDaemonArgs(args []string) error {
+	for _, arg := range args {
+		name, _, ok := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !ok || !strings.HasPrefix(arg, "--") || !daemonFlags[name] {
+			return fmt.Errorf("daemon compiles do not accept %q", arg)
+		}
+	}
+
+	return nil
+}
+
+// flagArgs returns the arguments that give f its value
+func flagArgs(f *pflag.Flag) []string {
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		args := make([]string, 0, len(sv.GetSlice()))
+		for _, v := range sv.GetSlice() {
+			args = append(args, fmt.Sprintf("--%s=%s", f.Name, v))
+		}
+
+		return args
+	}
+
+	return []string{fmt.Sprintf("--%s=%s", f.Name, f.Value.String())}
+}
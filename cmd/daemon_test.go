@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/daemon"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/vtpfile"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+)
+
+// replyingDaemon returns a Dialer whose daemon sends the given NDJSON lines
+func replyingDaemon(t *testing.T, lines ...string) (daemon.Dialer, <-chan daemon.Request) {
+	t.Helper()
+
+	received := make(chan daemon.Request, 1)
+
+	dial := func() (io.ReadWriteCloser, error) {
+		client, server := net.Pipe()
+
+		go func() {
+			defer server.Close()
+
+			var req daemon.Request
+			if err := daemon.ReadFrame(server, &req); err != nil {
+				return
+			}
+
+			received <- req
+			_, _ = io.WriteString(server, strings.Join(lines, "\n")+"\n")
+		}()
+
+		return client, nil
+	}
+
+	return dial, received
+}
+
+func noDaemon() (io.ReadWriteCloser, error) {
+	return nil, errors.New("The system cannot find the file specified.")
+}
+
+func TestRunClientCompile_FallsBackWithoutDaemon(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	fellBack := false
+
+	err := runClientCompile(noDaemon, daemon.Request{Type: daemon.RequestCompile, File: "lobby.vtp"}, &out, func() error {
+		fellBack = true
+		return &ExitError{Code: ExitCancelled, Err: errors.New("cancelled")}
+	})
+
+	assert.True(t, fellBack)
+	assert.Equal(t, ExitCancelled, ExitCode(err), "the in-process exit code is kept")
+	assert.Contains(t, out.String(), "No vtpc daemon running")
+}
+
+func TestRunClientCompile_PrintsDaemonOutput(t *testing.T) {
+	t.Parallel()
+
+	dial, received := replyingDaemon(t,
+		`{"type":"log","message":"Compiling program..."}`,
+		`{"type":"progress","percent":58}`,
+		`{"type":"log","message":"still compiling... (30s elapsed, progress 58%)"}`,
+		`{"type":"result","exitCode":0}`,
+	)
+
+	var out bytes.Buffer
+	err := runClientCompile(dial, daemon.Request{Type: daemon.RequestCompile, File: "lobby.vtp"}, &out, func() error {
+		t.Fatal("must not fall back when the daemon answers")
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Compiling program...\nstill compiling... (30s elapsed, progress 58%)\n", out.String())
+	assert.Equal(t, "lobby.vtp", (<-received).File)
+}
+
+func TestRunClientCompile_DaemonExitCode(t *testing.T) {
+	t.Parallel()
+
+	dial, _ := replyingDaemon(t, `{"type":"result","exitCode":3}`)
+
+	err := runClientCompile(dial, daemon.Request{Type: daemon.RequestCompile, File: "lobby.vtp"}, io.Discard, nil)
+	assert.Equal(t, ExitEnvironment, ExitCode(err))
+}
+
+func TestRunClientCompile_DaemonBusyDoesNotFallBack(t *testing.T) {
+	t.Parallel()
+
+	dial, _ := replyingDaemon(t, `{"type":"error","message":"daemon is busy compiling other.vtp"}`)
+
+	err := runClientCompile(dial, daemon.Request{Type: daemon.RequestCompile, File: "lobby.vtp"}, io.Discard, func() error {
+		t.Fatal("a busy daemon must not trigger a second VTPro in-process")
+		return nil
+	})
+	assert.ErrorIs(t, err, daemon.ErrRequestFailed)
+}
+
+func TestRunClientRequest_PrintsStatus(t *testing.T) {
+	t.Parallel()
+
+	dial, _ := replyingDaemon(t, `{"type":"status","status":{"busy":true,"file":"lobby.vtp","compiles":4,"uptime":"1h2m0s"}}`)
+
+	var out bytes.Buffer
+	require.NoError(t, runClientRequest(dial, daemon.Request{Type: daemon.RequestStatus}, &out))
+	assert.Equal(t, "vtpc daemon: compiling lobby.vtp, 4 compile(s), up 1h2m0s\n", out.String())
+}
+
+func TestStreamOutput_EmitsProgressOnChange(t *testing.T) {
+	t.Parallel()
+
+	input := strings.Join([]string{
+		"Compiling program...",
+		"still compiling... (30s elapsed, progress 40%)",
+		"still compiling... (1m0s elapsed, progress 40%)",
+		"still compiling... (1m30s elapsed, progress 75%)",
+	}, "\n")
+
+	var progress []int
+	logs := 0
+
+	err := streamOutput(strings.NewReader(input), func(ev daemon.Event) {
+		switch ev.Type {
+		case daemon.EventLog:
+			logs++
+		case daemon.EventProgress:
+			progress = append(progress, ev.Percent)
+		}
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, logs)
+	assert.Equal(t, []int{40, 75}, progress)
+}
+
+func TestStreamOutput_LongLine(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", 100*1024)
+
+	var logs []string
+	err := streamOutput(strings.NewReader(long+"\nCompile complete"), func(ev daemon.Event) {
+		logs = append(logs, ev.Message)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{long, "Compile complete"}, logs, "lines past the default 64 KB still stream")
+
+	err = streamOutput(strings.NewReader(strings.Repeat("x", maxOutputLine+1)), func(daemon.Event) {})
+	assert.ErrorContains(t, err, "failed to read vtpc output")
+}
+
+func TestForwardedFlags(t *testing.T) {
+	t.Parallel()
+
+	c := &cobra.Command{Use: "test"}
+	c.Flags().Bool("save-first", false, "")
+	c.Flags().String("expect-title", "", "")
+	c.Flags().StringArray("ignore-pages", nil, "")
+	c.Flags().StringArray("out", nil, "")
+	c.Flags().String("deploy", "", "")
+
+	require.NoError(t, c.ParseFlags([]string{"--save-first", "--expect-title", "Lobby Panel", "--ignore-pages", "ZZ_*", "--ignore-pages", "Test*"}))
+
+	args, err := forwardedFlags(c.Flags())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--expect-title=Lobby Panel",
+		"--ignore-pages=ZZ_*",
+		"--ignore-pages=Test*",
+		"--save-first=true",
+	}, args)
+
+	require.NoError(t, c.ParseFlags([]string{"--out", "text=a.txt", "--deploy", "ftp://host/dir"}))
+
+	_, err = forwardedFlags(c.Flags())
+	assert.EqualError(t, err, "the daemon does not accept --deploy, --out; run vtpc directly to use them")
+}
+
+func TestCheckDaemonArgs(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, checkDaemonArgs(nil))
+	assert.NoError(t, checkDaemonArgs([]string{"--save-first=true", "--ignore-pages=ZZ_*"}))
+
+	for _, arg := range []string{
+		`--out=text=C:\Windows\System32\evil.dll`,
+		"--record-events=events.jsonl",
+		"--profile=cpu=cpu.pprof",
+		"--deploy=ftp://host/dir",
+		"--config=other.yaml",
+		"--cancel-file=C:\\important.txt",
+		"--save-first",
+		"-V",
+		"lobby.vtp",
+	} {
+		assert.Error(t, checkDaemonArgs([]string{"--save-first=true", arg}), arg)
+	}
+}
+
+func TestDaemonFlagsExist(t *testing.T) {
+	t.Parallel()
+
+	for name := range daemonFlags {
+		assert.NotNil(t, RootCmd.PersistentFlags().Lookup(name), name)
+	}
+}
+
+func TestWarmCompiler_RunsInTheDaemon(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "lobby.vtp")
+	require.NoError(t, os.WriteFile(project, bytes.Repeat([]byte("x"), vtpfile.MinProjectSize), 0o644))
+
+	oldOut, oldStartup := consoleOut, startup
+	startup = startupSteps{
+		openLog:    tempLog(t),
+		checkVTPro: func() error { return fmt.Errorf("%w at default path: C:\\VTPro.exe", vtpro.ErrVTProNotFound) },
+	}
+
+	t.Cleanup(func() { startup = oldStartup })
+
+	c := &warmCompiler{warm: newWarmVTPro(testutil.NewMockLogger()), log: testutil.NewMockLogger()}
+
+	var lines []string
+	code, err := c.Compile(context.Background(), daemon.Request{
+		Type: daemon.RequestCompile,
+		File: project,
+		Args: []string{"--strict-parse=true"},
+	}, func(ev daemon.Event) {
+		if ev.Type == daemon.EventLog {
+			lines = append(lines, ev.Message)
+		}
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, ExitFailure, code)
+	require.NotEmpty(t, lines)
+	assert.Regexp(t, `^vtpc-result status=failed file="lobby.vtp" .* code="VTPC_E_VTPRO_MISSING"$`, lines[len(lines)-1],
+		"the compile's console output goes to the client")
+	assert.Same(t, oldOut, consoleOut, "the daemon's console is given back")
+	assert.False(t, RootCmd.PersistentFlags().Changed("strict-parse"), "the request's flags are its own")
+}
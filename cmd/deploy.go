@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/deploy"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/secret"
+)
+
+// maskDeploySecrets adds the deploy passwords vtpc can already see, in the
+// --deploy URL or the environment, to secrets so they are never logged or
+// reported. It warns about a password in the URL, which any user can read
+// from the process list.
+func maskDeploySecrets(cfg *Config, secrets *secret.Store, getenv func(string) string, log logger.LoggerInterface) {
+	if addDeploySecrets(cfg.Deploy, secrets, getenv) {
+		log.Warn("The --deploy URL contains a password, which other users can see in the process list; use --deploy-password instead")
+	}
+}
+
+// warnProjectDeploy warns when the deploy target came from the project file
+// rather than the command line, as whoever wrote the project file chose where
+// the compiled project is uploaded to
+func warnProjectDeploy(cfg *Config, log logger.LoggerInterface) {
+	if !cfg.fromProjectFile("deploy") {
+		return
+	}
+
+	log.Warn("Deploying to the target the project file gives",
+		slog.String("target", deploy.Redact(cfg.Deploy)),
+		slog.String("projectFile", cfg.projectFile().Path))
+}
+
+// addDeploySecrets adds the password in the deploy URL raw and the one in the
+// environment to secrets, reporting whether the URL has one. The password is
+// added both as written and decoded, as the command line holds p%40ss while
+// errors may hold p@ss. It works on a URL that does not parse, so the password
+// is masked in the error that says so.
+func addDeploySecrets(raw string, secrets *secret.Store, getenv func(string) string) bool {
+	if raw == "" {
+		return false
+	}
+
+	secrets.Add(getenv(deploy.PasswordEnv))
+
+	written := urlPassword(raw)
+	secrets.Add(written)
+
+	if u, err := url.Parse(raw); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			secrets.Add(password)
+			return true
+		}
+	}
+
+	return written != ""
+}
+
+// urlPassword returns the password in a URL's userinfo as written, without
+// decoding it, or "" if it has none
+func urlPassword(raw string) string {
+	_, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		return ""
+	}
+
+	// The userinfo ends at the last @ before the path, query or fragment
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		rest = rest[:i]
+	}
+
+	at := strings.LastIndex(rest, "@")
+	if at < 0 {
+		return ""
+	}
+
+	_, password, _ := strings.Cut(rest[:at], ":")
+
+	return password
+}
+
+// resolveDeployPassword resolves --deploy-password, returning "" when it is not set
+func resolveDeployPassword(cfg *Config, secrets *secret.Store) (string, error) {
+	if cfg.DeployPassword == "" {
+		return "", nil
+	}
+
+	password, err := secrets.Resolve(cfg.DeployPassword)
+	if err != nil {
+		return "", fmt.Errorf("--deploy-password: %w", err)
+	}
+
+	return password, nil
+}
+
+// deployGetenv returns the getenv deploy.ParseTarget reads a password missing
+// from the URL with: the --deploy-password value when given, else the environment
+func deployGetenv(password string) func(string) string {
+	return func(key string) string {
+		if key == deploy.PasswordEnv && password != "" {
+			return password
+		}
+
+		return os.Getenv(key)
+	}
+}
+
+// deployArtifacts uploads the compiled artifacts to the --deploy URL, returning
+// the target they were deployed to. Failures map to ExitDeploy so scripts can
+// tell a failed upload from a failed compile.
+func deployArtifacts(ctx context.Context, rawURL, password string, paths []string, uploader *deploy.Uploader, log logger.LoggerInterface) (deploy.Target, error) {
+	target, err := deploy.ParseTarget(rawURL, deployGetenv(password))
+	if err != nil {
+		return deploy.Target{}, &ExitError{Code: ExitDeploy, Err: err}
+	}
+
+	if len(paths) == 0 {
+		err := fmt.Errorf("%w: no compiled artifact was found to deploy to %s", deploy.ErrDeployFailed, target)
+		log.Error("Nothing to deploy", slog.String("target", target.String()))
+		return target, &ExitError{Code: ExitDeploy, Err: err}
+	}
+
+	for _, path := range paths {
+		if err := uploader.Deploy(ctx, target, path); err != nil {
+			log.Error("Deployment failed", slog.Any("error", err))
+			return target, &ExitError{Code: ExitDeploy, Err: err}
+		}
+	}
+
+	return target, nil
+}
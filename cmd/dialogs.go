@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+)
+
+// dialogsCmd groups the commands that describe the dialogs vtpc knows about
+var dialogsCmd = &cobra.Command{
+	Use:   "dialogs",
+	Short: "Show the VTPro dialogs vtpc recognises and what it does with them",
+}
+
+var dialogsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every known dialog with its title, phase and action",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		format, _ := cmd.Flags().GetString("output")
+		return writeDialogList(cmd.OutOrStdout(), dialog.Default(), format)
+	},
+}
+
+func init() {
+	dialogsListCmd.Flags().String("output", "text", "output format: text or json")
+	dialogsCmd.AddCommand(dialogsListCmd)
+	RootCmd.AddCommand(dialogsCmd)
+}
+
+// writeDialogList writes every descriptor in the registry, in the order a run meets them
+func writeDialogList(w io.Writer, registry *dialog.Registry, format string) error {
+	descriptors := registry.All()
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(descriptors)
+
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tPHASE\tACTION\tTITLE\tNOTE")
+
+		for _, d := range descriptors {
+			title := strconv.Quote(d.Title)
+			if d.Title == "" {
+				title = "(untitled)"
+			}
+
+			if d.Prefix {
+				title += " (prefix)"
+			}
+
+			if d.Class != "" {
+				title += " class=" + d.Class
+			}
+
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", d.Name, d.Phase, d.Action, title, d.Note)
+		}
+
+		return tw.Flush()
+
+	default:
+		return fmt.Errorf("unknown output format %q, expected text or json", format)
+	}
+}
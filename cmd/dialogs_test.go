@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// assertGolden compares got with testdata/<name>.golden, rewriting it with -update
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll("testdata", 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got)
+}
+
+func TestWriteDialogList(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			require.NoError(t, writeDialogList(&out, dialog.Default(), format))
+
+			assertGolden(t, "dialogs_"+format, out.String())
+		})
+	}
+}
+
+func TestWriteDialogList_UnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	err := writeDialogList(&bytes.Buffer{}, dialog.Default(), "yaml")
+	assert.EqualError(t, err, `unknown output format "yaml", expected text or json`)
+}
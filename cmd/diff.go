@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/report/diff"
+)
+
+// diffCmd compares the JSON reports of two runs of a project
+var diffCmd = &cobra.Command{
+	Use:   "diff <old-report.json> <new-report.json>",
+	Short: "Compare the --out json reports of two runs of a project",
+	Long: `Compare the --out json reports of two runs of the same project: the warnings
+and errors that appeared or went away, and the change in the error and warning
+counts, output size, duration and panel models compiled.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("output")
+		failOnRegression, _ := cmd.Flags().GetBool("fail-on-regression")
+
+		return runDiff(cmd.OutOrStdout(), args[0], args[1], format, failOnRegression)
+	},
+}
+
+func init() {
+	diffCmd.Flags().String("output", "text", "output format: text, markdown or json")
+	diffCmd.Flags().Bool("fail-on-regression", false, "exit with code 1 if the new report has errors or warnings the old one did not")
+	RootCmd.AddCommand(diffCmd)
+}
+
+// runDiff compares the reports at oldPath and newPath and writes the result
+func runDiff(w io.Writer, oldPath, newPath, format string, failOnRegression bool) error {
+	write, err := diffWriter(format)
+	if err != nil {
+		return err
+	}
+
+	before, err := report.ReadDocument(oldPath)
+	if err != nil {
+		return err
+	}
+
+	after, err := report.ReadDocument(newPath)
+	if err != nil {
+		return err
+	}
+
+	result, err := diff.Compare(before, after)
+	if err != nil {
+		return err
+	}
+
+	if err := write(w, result); err != nil {
+		return err
+	}
+
+	if failOnRegression && result.Regressed {
+		return &ExitError{
+			Code: ExitFailure,
+			Err:  fmt.Errorf("%s has errors or warnings that %s does not (--fail-on-regression)", newPath, oldPath),
+		}
+	}
+
+	return nil
+}
+
+// diffWriter returns the renderer for a --output format
+func diffWriter(format string) (func(io.Writer, *diff.Result) error, error) {
+	switch format {
+	case "text":
+		return diff.WriteText, nil
+	case "markdown":
+		return diff.WriteMarkdown, nil
+	case "json":
+		return diff.WriteJSON, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, expected text, markdown or json", format)
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+// writeReport writes doc as a JSON report in dir and returns its path
+func writeReport(t *testing.T, dir, name string, doc report.Document) string {
+	t.Helper()
+
+	doc.Version = report.DocumentVersion
+
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	return path
+}
+
+func TestRunDiff(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	warning := report.DocumentMessage{Severity: "warning", Text: "Join 27 is already in use", Target: "TSW-770"}
+	before := writeReport(t, dir, "old.json", report.Document{Project: "Lobby.vtp", Status: report.StatusOK})
+	after := writeReport(t, dir, "new.json", report.Document{Project: "Lobby.vtp", Status: report.StatusOK, Warnings: 1, Messages: []report.DocumentMessage{warning}})
+
+	var out bytes.Buffer
+	require.NoError(t, runDiff(&out, before, after, "markdown", false), "a regression only fails with --fail-on-regression")
+	assert.Contains(t, out.String(), "- **warning** TSW-770: Join 27 is already in use\n")
+
+	err := runDiff(&bytes.Buffer{}, before, after, "text", true)
+
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, ExitFailure, exitErr.Code)
+	assert.Contains(t, err.Error(), "(--fail-on-regression)")
+
+	require.NoError(t, runDiff(&bytes.Buffer{}, after, before, "json", true), "removed warnings are not a regression")
+}
+
+func TestRunDiff_Errors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	lobby := writeReport(t, dir, "lobby.json", report.Document{Project: "Lobby.vtp"})
+	board := writeReport(t, dir, "board.json", report.Document{Project: "Boardroom.vtp"})
+
+	assert.EqualError(t, runDiff(&bytes.Buffer{}, lobby, lobby, "yaml", false), `unknown output format "yaml", expected text, markdown or json`)
+	assert.EqualError(t, runDiff(&bytes.Buffer{}, lobby, board, "text", false), "the reports are of different projects: Lobby.vtp and Boardroom.vtp")
+	assert.ErrorIs(t, runDiff(&bytes.Buffer{}, lobby, filepath.Join(dir, "missing.json"), "text", false), os.ErrNotExist)
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/Norgate-AV/vtpc/internal/config"
+	"github.com/Norgate-AV/vtpc/internal/deploy"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/pathutil"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+)
+
+// configCmd groups the commands that inspect vtpc's configuration
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration vtpc runs with",
+}
+
+// configShowCmd prints the configuration a compile run with the same flags would
+// use, for answering "why did it use that?" without starting one
+var configShowCmd = &cobra.Command{
+	Use:   "show [file-path]",
+	Short: "Print every option's effective value once flags, environment variables, the project file and the config file are merged",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sources, _ := cmd.Flags().GetBool("sources")
+		cfg := NewConfigFromFlags(cmd)
+
+		// Given a project, its project file is merged in as a compile of it would
+		if len(args) > 0 {
+			cfg.FilePath, _ = pathutil.Normalize(args[0], pathutil.OSEnv())
+			if err := loadProjectFile(cfg); err != nil {
+				return err
+			}
+		}
+
+		file, err := loadConfigFile(cfg, logger.NewNoOpLogger())
+		if err != nil {
+			return err
+		}
+
+		effective := effectiveConfig(cmd.Root().PersistentFlags(), cfg, file, os.Getenv)
+
+		return writeEffective(cmd.OutOrStdout(), effective, sources)
+	},
+}
+
+func init() {
+	configShowCmd.Flags().Bool("sources", false, "also show where each value came from: flag, env, project-file, config-file or default")
+	configCmd.AddCommand(configShowCmd)
+	RootCmd.AddCommand(configCmd)
+}
+
+// vtproPathSetting is the effective configuration's name for the VTPro
+// executable, which is set by environment variable rather than a flag
+const vtproPathSetting = "vtpro-path"
+
+// effectiveConfig records the final value of every option in flags, and of the
+// settings the environment, project file and config file can also give, with
+// where each came from
+func effectiveConfig(flags *pflag.FlagSet, cfg *Config, file *config.File, getenv func(string) string) *config.Effective {
+	e := config.NewEffective()
+
+	flags.VisitAll(func(f *pflag.Flag) {
+		source := config.SourceDefault
+		if f.Changed {
+			source = config.SourceFlag
+		}
+
+		e.Set(f.Name, f.Value.String(), source)
+	})
+
+	// The project file stands in for the flags not given
+	project := cfg.projectFile()
+	for _, s := range projectSettings {
+		if value, ok := s.value(project); ok && !cfg.isSet(s.flag) {
+			e.Set(s.flag, value, config.SourceProject)
+		}
+	}
+
+	bell, source := resolveBell(cfg, file)
+	e.Set("bell", strconv.FormatBool(bell), source)
+
+	template, source := resolveLinkTemplate(cfg, file)
+	e.Set("message-link-template", template, source)
+
+	// Formatted as pflag formats a string array, so it reads like the other list options
+	pages, source := resolveIgnorePages(cfg, file)
+	e.Set("ignore-pages", "["+strings.Join(pages, ",")+"]", source)
+
+	class, source := resolveMainWindowClass(cfg, file)
+	e.Set("main-window-class", class, source)
+
+	title, source := resolveSplashTitle(file)
+	e.Set("splash-title", title, source)
+
+	splashClass, source := config.Resolve("", config.Candidate[string]{Source: config.SourceFile, Value: file.VTPro.SplashClass, Set: file.VTPro.SplashClass != ""})
+	e.Set("splash-class", splashClass, source)
+
+	telemetryState, source := config.Resolve(config.TelemetryDisabled, config.Candidate[string]{Source: config.SourceFile, Value: file.Telemetry.State, Set: file.Telemetry.State != ""})
+	e.Set("telemetry", telemetryState, source)
+
+	if s, ok := e.Get("deploy"); ok {
+		e.Set("deploy", deploy.Redact(s.Value), s.Source)
+	}
+
+	path, source := resolveVTProPath(getenv)
+	e.Set(vtproPathSetting, path, source)
+
+	return e
+}
+
+// resolveVTProPath resolves the VTPro executable as vtpro.GetVTProPath does,
+// and where it came from
+func resolveVTProPath(getenv func(string) string) (string, config.Source) {
+	env := getenv("VTPRO_PATH")
+
+	return config.Resolve(vtpro.DefaultVTProPath,
+		config.Candidate[string]{Source: config.SourceEnv, Value: env, Set: env != ""},
+	)
+}
+
+// logEffective logs the effective configuration and its fingerprint
+func logEffective(e *config.Effective, log logger.LoggerInterface) {
+	attrs := []any{slog.String("fingerprint", e.Fingerprint())}
+
+	for _, s := range e.Settings() {
+		attrs = append(attrs, slog.Group(s.Name, slog.String("value", s.Value), slog.String("source", string(s.Source))))
+	}
+
+	log.Debug("Effective configuration", attrs...)
+}
+
+// writeEffective writes the effective configuration as a table, with each
+// value's source when sources is set, followed by its fingerprint
+func writeEffective(w io.Writer, e *config.Effective, sources bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if sources {
+		fmt.Fprintln(tw, "OPTION\tVALUE\tSOURCE")
+	} else {
+		fmt.Fprintln(tw, "OPTION\tVALUE")
+	}
+
+	for _, s := range e.Settings() {
+		value := s.Value
+		if value == "" {
+			value = `""`
+		}
+
+		if sources {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Name, value, s.Source)
+		} else {
+			fmt.Fprintf(tw, "%s\t%s\n", s.Name, value)
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "Fingerprint: %s\n", e.Fingerprint())
+
+	return err
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// errorsCmd groups the commands that describe vtpc's error codes
+var errorsCmd = &cobra.Command{
+	Use:   "errors",
+	Short: "Show the error codes a failed run can report",
+}
+
+var errorsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every error code with what it means",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		format, _ := cmd.Flags().GetString("output")
+		return writeErrorList(cmd.OutOrStdout(), errcode.Catalog(), format)
+	},
+}
+
+func init() {
+	errorsListCmd.Flags().String("output", "text", "output format: text or json")
+	errorsCmd.AddCommand(errorsListCmd)
+	RootCmd.AddCommand(errorsCmd)
+}
+
+// writeErrorList writes every code in the catalog
+func writeErrorList(w io.Writer, entries []errcode.Entry, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(entries)
+
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "CODE\tDESCRIPTION")
+
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%s\t%s\n", e.Code, e.Description)
+		}
+
+		return tw.Flush()
+
+	default:
+		return fmt.Errorf("unknown output format %q, expected text or json", format)
+	}
+}
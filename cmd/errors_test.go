@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+func TestWriteErrorList(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			require.NoError(t, writeErrorList(&out, errcode.Catalog(), format))
+
+			assertGolden(t, "errors_"+format, out.String())
+		})
+	}
+}
+
+func TestWriteErrorList_UnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	err := writeErrorList(&bytes.Buffer{}, errcode.Catalog(), "yaml")
+	assert.EqualError(t, err, `unknown output format "yaml", expected text or json`)
+}
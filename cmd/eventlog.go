@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"log/slog"
+
+	"github.com/Norgate-AV/vtpc/internal/eventlog"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// openEventLog opens vtpc's event source in the Application log, registering it if needed
+func openEventLog() (eventlog.Log, error) {
+	l, err := windows.OpenEventLog(eventlog.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// writeEventLog writes the run's entry to the Windows Event Log for --eventlog.
+// A failure is logged but does not change the run's result.
+func writeEventLog(open eventlog.Opener, run *report.Run, log logger.LoggerInterface) {
+	if err := eventlog.Write(open, run); err != nil {
+		log.Warn("Could not write to the Windows Event Log", slog.Any("error", err))
+		return
+	}
+
+	log.Debug("Wrote the run to the Windows Event Log", slog.String("source", eventlog.Source))
+}
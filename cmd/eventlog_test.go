@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/eventlog"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+)
+
+func TestWriteEventLog_FailureIsAWarning(t *testing.T) {
+	t.Parallel()
+
+	log := testutil.NewMockLogger()
+	writeEventLog(func() (eventlog.Log, error) { return nil, errors.New("access is denied") }, &report.Run{}, log)
+
+	assert.Contains(t, log.Messages(), "Could not write to the Windows Event Log")
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"errors"
+	"slices"
+)
+
+// Process exit codes returned by vtpc
+const (
+	ExitSuccess     = 0 // Compilation succeeded (warnings are OK)
+	ExitFailure     = 1 // Compilation failed with errors, or a runtime error occurred
+	ExitCancelled   = 2 // Compilation was cancelled from the Compiling dialog
+	ExitEnvironment = 3 // The build machine failed a pre-flight check, e.g. low disk space
+	ExitAutomation  = 4 // vtpc could not drive VTPro, e.g. an unexpected dialog with --strict-dialogs
+	ExitDeploy      = 5 // The compile succeeded but --deploy could not upload the artifact
+	ExitBudget      = 6 // The compile succeeded but took longer than --max-compile-time
+	ExitPolicy      = 7 // The machine policy refused the project, or could not be read
+
+	ExitInterrupted = 130 // Run was interrupted by Ctrl+C, console close or the cancel file
+)
+
+// ExitError is an error that maps to a specific process exit code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the process exit code for an error returned by Execute
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	return ExitFailure
+}
+
+// exitPrecedence orders exit codes by which wins when a run has more than one
+// reason to fail. A run that was stopped outranks one that finished with errors,
+// which outranks a good compile whose deployment or time budget then failed.
+var exitPrecedence = []int{
+	ExitInterrupted,
+	ExitCancelled,
+	ExitPolicy,
+	ExitEnvironment,
+	ExitAutomation,
+	ExitFailure,
+	ExitDeploy,
+	ExitBudget,
+}
+
+// mostSevere returns whichever non-nil error has the exit code that comes first
+// in exitPrecedence, or the first of them on a tie. It returns nil if all are nil.
+func mostSevere(errs ...error) error {
+	var (
+		worst error
+		rank  int
+	)
+
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		r := slices.Index(exitPrecedence, ExitCode(err))
+		if r == -1 {
+			r = len(exitPrecedence)
+		}
+
+		if worst == nil || r < rank {
+			worst, rank = err, r
+		}
+	}
+
+	return worst
+}
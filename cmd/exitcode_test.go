@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+)
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	cancelled := &ExitError{Code: ExitCancelled, Err: compiler.ErrCompileCancelled}
+
+	assert.Equal(t, ExitSuccess, ExitCode(nil))
+	assert.Equal(t, ExitFailure, ExitCode(errors.New("compilation failed with 1 error(s)")))
+	assert.Equal(t, ExitCancelled, ExitCode(cancelled))
+	assert.Equal(t, ExitCancelled, ExitCode(fmt.Errorf("wrapped: %w", cancelled)))
+}
+
+func TestExitError_UnwrapsCause(t *testing.T) {
+	t.Parallel()
+
+	err := &ExitError{Code: ExitCancelled, Err: compiler.ErrCompileCancelled}
+
+	assert.ErrorIs(t, err, compiler.ErrCompileCancelled)
+	assert.Equal(t, compiler.ErrCompileCancelled.Error(), err.Error())
+}
+
+func TestMostSevere(t *testing.T) {
+	t.Parallel()
+
+	compileErrors := errors.New("compilation failed with 2 error(s)")
+	budget := &ExitError{Code: ExitBudget, Err: ErrBudgetExceeded}
+	deployFailed := &ExitError{Code: ExitDeploy, Err: errors.New("upload failed")}
+	cancelled := &ExitError{Code: ExitCancelled, Err: compiler.ErrCompileCancelled}
+	interrupted := &ExitError{Code: ExitInterrupted, Err: errors.New("interrupted")}
+	unlisted := &ExitError{Code: 42, Err: errors.New("unlisted")}
+
+	tests := []struct {
+		name string
+		errs []error
+		want error
+	}{
+		{"nothing failed", []error{nil, nil}, nil},
+		{"only over budget", []error{nil, budget}, budget},
+		{"compile errors outrank the budget", []error{compileErrors, budget}, compileErrors},
+		{"a failed deploy outranks the budget", []error{deployFailed, budget}, deployFailed},
+		{"compile errors outrank a failed deploy", []error{deployFailed, compileErrors}, compileErrors},
+		{"cancelling outranks compile errors", []error{compileErrors, cancelled}, cancelled},
+		{"an interrupt outranks everything", []error{budget, cancelled, interrupted, compileErrors}, interrupted},
+		{"an unlisted code comes last", []error{unlisted, budget}, budget},
+		{"ties go to the first", []error{compileErrors, errors.New("other")}, compileErrors},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, mostSevere(tt.errs...))
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+)
+
+// explainCmd prints the guidance for a rule ID shown next to a warning or error
+var explainCmd = &cobra.Command{
+	Use:   "explain <rule-id>",
+	Short: "Explain a warning or error rule and how to fix it",
+	Args:  validateExplainArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rules := compiler.DefaultRules()
+
+		if list, _ := cmd.Flags().GetBool("list"); list {
+			writeRuleList(cmd.OutOrStdout(), rules)
+			return nil
+		}
+
+		return explainRule(cmd.OutOrStdout(), rules, args[0])
+	},
+}
+
+func init() {
+	explainCmd.Flags().Bool("list", false, "list every known rule ID")
+	RootCmd.AddCommand(explainCmd)
+}
+
+// validateExplainArgs requires exactly one rule ID, or none with --list
+func validateExplainArgs(cmd *cobra.Command, args []string) error {
+	if list, _ := cmd.Flags().GetBool("list"); list {
+		if len(args) > 0 {
+			return fmt.Errorf("--list does not take a rule ID")
+		}
+
+		return nil
+	}
+
+	return cobra.ExactArgs(1)(cmd, args)
+}
+
+// explainRule writes the description, cause and fix steps of a rule, or an
+// error suggesting the closest IDs if it does not exist
+func explainRule(w io.Writer, rules *compiler.RuleSet, id string) error {
+	rule, ok := rules.Lookup(id)
+	if !ok {
+		if suggestions := rules.Suggest(id); len(suggestions) > 0 {
+			return fmt.Errorf("unknown rule %q, did you mean %s? (vtpc explain --list shows every rule)",
+				id, strings.Join(suggestions, ", "))
+		}
+
+		return fmt.Errorf("unknown rule %q (vtpc explain --list shows every rule)", id)
+	}
+
+	fmt.Fprintf(w, "%s: %s\n", rule.ID, rule.Title)
+
+	if rule.Description != "" {
+		fmt.Fprintf(w, "\n%s\n", rule.Description)
+	}
+
+	if rule.Cause != "" {
+		fmt.Fprintf(w, "\nTypical cause:\n  %s\n", rule.Cause)
+	}
+
+	if len(rule.Remediation) > 0 {
+		fmt.Fprintln(w, "\nHow to fix:")
+
+		for i, step := range rule.Remediation {
+			fmt.Fprintf(w, "  %d. %s\n", i+1, step)
+		}
+	}
+
+	return nil
+}
+
+// writeRuleList writes every rule ID with its title, in the order rules are tried
+func writeRuleList(w io.Writer, rules *compiler.RuleSet) {
+	all := rules.Rules()
+
+	width := len(compiler.RuleUnknown)
+	for _, r := range all {
+		width = max(width, len(r.ID))
+	}
+
+	for _, r := range all {
+		fmt.Fprintf(w, "%-*s  %s\n", width, r.ID, r.Title)
+	}
+
+	unknown, _ := rules.Lookup(compiler.RuleUnknown)
+	fmt.Fprintf(w, "%-*s  %s\n", width, unknown.ID, unknown.Title)
+}
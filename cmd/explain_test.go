@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+)
+
+func TestExplainRule(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	require.NoError(t, explainRule(&out, compiler.DefaultRules(), "unassigned-smart-object-id"))
+
+	text := out.String()
+	assert.Contains(t, text, "unassigned-smart-object-id: Unassigned Smart Object ID\n")
+	assert.Contains(t, text, "\nTypical cause:\n")
+	assert.Contains(t, text, "\nHow to fix:\n  1. ")
+}
+
+func TestExplainRule_Unknown(t *testing.T) {
+	t.Parallel()
+
+	err := explainRule(&bytes.Buffer{}, compiler.DefaultRules(), "dupliate-join")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown rule "dupliate-join", did you mean duplicate-join?`)
+
+	err = explainRule(&bytes.Buffer{}, compiler.DefaultRules(), "frobnicate")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "did you mean")
+	assert.Contains(t, err.Error(), "vtpc explain --list")
+}
+
+func TestWriteRuleList(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	writeRuleList(&out, compiler.DefaultRules())
+
+	text := out.String()
+	assert.Contains(t, text, "unassigned-smart-object-id  Unassigned Smart Object ID\n")
+	assert.Contains(t, text, "duplicate-join              Duplicate join number\n")
+	assert.Contains(t, text, "unknown                     Unrecognized message\n")
+}
+
+func TestValidateExplainArgs(t *testing.T) {
+	t.Parallel()
+
+	newCmd := func(list bool) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().Bool("list", list, "")
+		return cmd
+	}
+
+	assert.NoError(t, validateExplainArgs(newCmd(false), []string{"missing-join"}))
+	assert.Error(t, validateExplainArgs(newCmd(false), nil))
+	assert.NoError(t, validateExplainArgs(newCmd(true), nil))
+	assert.Error(t, validateExplainArgs(newCmd(true), []string{"missing-join"}))
+}
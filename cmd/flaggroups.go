@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// flagGroupAnnotation is the flag annotation naming the help section a flag is listed in
+const flagGroupAnnotation = "vtpc_flag_group"
+
+// Help sections, in the order they are listed
+const (
+	flagGroupInput      = "Input"
+	flagGroupOutput     = "Output"
+	flagGroupAutomation = "Automation"
+	flagGroupLogging    = "Logging"
+)
+
+var flagGroupOrder = []string{flagGroupInput, flagGroupOutput, flagGroupAutomation, flagGroupLogging}
+
+// setFlagGroup lists the named flags under a help section
+func setFlagGroup(fs *pflag.FlagSet, group string, names ...string) {
+	for _, name := range names {
+		if err := fs.SetAnnotation(name, flagGroupAnnotation, []string{group}); err != nil {
+			panic(err) // A misspelled flag name is a programming error
+		}
+	}
+}
+
+// flagGroup returns the help section of a flag, or "" for ungrouped flags such as --help
+func flagGroup(f *pflag.Flag) string {
+	if group := f.Annotations[flagGroupAnnotation]; len(group) > 0 {
+		return group[0]
+	}
+
+	return ""
+}
+
+// groupedFlagUsages formats the flags in fs under a heading per help section,
+// followed by any ungrouped flags
+func groupedFlagUsages(fs *pflag.FlagSet) string {
+	sections := make(map[string]*pflag.FlagSet)
+
+	fs.VisitAll(func(f *pflag.Flag) {
+		group := flagGroup(f)
+		if sections[group] == nil {
+			sections[group] = pflag.NewFlagSet(group, pflag.ContinueOnError)
+		}
+
+		sections[group].AddFlag(f)
+	})
+
+	var parts []string
+
+	for _, group := range append(flagGroupOrder, "") {
+		section := sections[group]
+		if section == nil || !section.HasAvailableFlags() {
+			continue
+		}
+
+		heading := "Flags:"
+		if group != "" {
+			heading = group + " Flags:"
+		}
+
+		parts = append(parts, heading+"\n"+strings.TrimRight(section.FlagUsages(), " \t\n"))
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+// groupFlagsInTemplate rewrites a cobra usage template to list flags by help section
+func groupFlagsInTemplate(tmpl string) string {
+	return strings.NewReplacer(
+		"Flags:\n{{.LocalFlags.FlagUsages | trimTrailingWhitespaces}}", "{{groupedFlagUsages .LocalFlags}}",
+		"Global Flags:\n{{.InheritedFlags.FlagUsages | trimTrailingWhitespaces}}", "{{groupedFlagUsages .InheritedFlags}}",
+	).Replace(tmpl)
+}
+
+func init() {
+	cobra.AddTemplateFunc("groupedFlagUsages", groupedFlagUsages)
+}
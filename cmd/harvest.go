@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/output"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+)
+
+// harvestCmd reads the results of a compile someone already ran in VTPro
+var harvestCmd = &cobra.Command{
+	Use:   "harvest (--pid <pid> | --attach)",
+	Short: "Report the compile shown in an open VTPro's Message Log without touching VTPro",
+	Long: `Harvest reads the Message Log of a VTPro that is already open and reports
+the compile it shows, exactly as vtpc reports its own compiles. No keystrokes are
+sent and nothing is closed, so it can report on a compile run by hand.
+
+--attach uses the only running VTPro with a project open; --pid chooses one.`,
+	Args: cobra.NoArgs,
+	RunE: runHarvest,
+}
+
+func init() {
+	harvestCmd.Flags().Uint32("pid", 0, "PID of the VTPro to read")
+	harvestCmd.Flags().Bool("attach", false, "read the only running VTPro with a project open")
+	harvestCmd.MarkFlagsMutuallyExclusive("pid", "attach")
+	harvestCmd.MarkFlagsOneRequired("pid", "attach")
+	RootCmd.AddCommand(harvestCmd)
+}
+
+// runHarvest finds the VTPro to read, reports its compile and finishes with the exit banner
+func runHarvest(cmd *cobra.Command, args []string) (err error) {
+	clk := clock.New()
+	start := clk.Now()
+	cfg := NewConfigFromFlags(cmd)
+	pid, _ := cmd.Flags().GetUint32("pid")
+
+	if cfg.Output == outputJSON {
+		redirectConsole()
+	}
+
+	// As for a compile, the output ends with the result line however harvest ends
+	var reported *report.Run
+
+	defer func() {
+		finishOutput(cfg, os.Stdout, resultRun(reported, err, "", clk.Now().Sub(start)))
+	}()
+
+	log, err := initializeLogger(cfg)
+	if err != nil {
+		return err
+	}
+
+	defer log.Close()
+
+	reports, err := output.DefaultRegistry.ParseSpecs(cfg.Outputs)
+	if err != nil {
+		return err
+	}
+
+	var outcome runOutcome
+
+	defer func() {
+		summary := buildSummary(err, outcome, log.GetLogPath(), start, clk.Now())
+		summary.AbsoluteTimes = cfg.AbsoluteTimes
+		report.WriteBanner(consoleOut, summary)
+
+		run := buildRun(summary, outcome)
+		if werr := output.DefaultRegistry.WriteAll(context.Background(), reports, &run); werr != nil {
+			log.Error("Could not write all reports", slog.Any("error", werr))
+			fmt.Fprintf(os.Stderr, "vtpc: could not write all reports:\n%v\n", werr)
+		}
+
+		reported = &run
+	}()
+
+	configFile, err := loadConfigFile(cfg, log)
+	if err != nil {
+		return err
+	}
+
+	parserOpts, err := buildParserOptions(configFile)
+	if err != nil {
+		return err
+	}
+
+	parserOpts.Strict = cfg.StrictParse
+
+	messageOrder, err := compiler.ParseMessageOrder(cfg.MessageOrder)
+	if err != nil {
+		return err
+	}
+
+	messageFormat, err := compiler.ParseMessageFormat(cfg.Format)
+	if err != nil {
+		return err
+	}
+
+	client := vtpro.NewClient(log).
+		WithExpectTitle(cfg.ExpectTitle).
+		WithWindowIdentity(buildWindowIdentity(cfg, configFile))
+
+	running, err := client.Attach(pid)
+	outcome.selection = client.Selection()
+	if err != nil {
+		log.Error("Could not find VTPro to read", slog.Any("error", err))
+		return err
+	}
+
+	log.Info("Reading the Message Log of a running VTPro",
+		slog.Uint64("pid", uint64(running.Pid)),
+		slog.String("title", running.Title),
+	)
+
+	outcome.project = running.Title
+
+	comp := compiler.NewCompiler(log, compiler.WithParser(parserOpts))
+	outcome.result, err = harvest(comp, running.Hwnd, messageOrder, messageFormat, log)
+
+	return err
+}
+
+// harvest reads and reports the compile shown in the Message Log of VTPro's main
+// window. A Message Log with no compile in it leaves no result to report.
+func harvest(comp *compiler.Compiler, hwnd uintptr, order compiler.MessageOrder, format compiler.MessageFormat, log logger.LoggerInterface) (*compiler.CompileResult, error) {
+	result, err := comp.Harvest(compiler.HarvestOptions{
+		Hwnd:          hwnd,
+		MessageOrder:  order,
+		MessageFormat: format,
+	})
+
+	if errors.Is(err, compiler.ErrNoMessageLog) {
+		log.Error("VTPro has no compile in its Message Log", slog.Any("error", err))
+		return nil, err
+	}
+
+	if format == compiler.MessageFormatTable {
+		printMessageTable(consoleOut, result, order, tableWidth(), colorEnabled(), log)
+	}
+
+	displayCompilationResults(result, log)
+
+	if errors.Is(err, compiler.ErrCompileCancelled) {
+		log.Error("The compile in the Message Log was cancelled before VTPro finished")
+		return result, &ExitError{Code: ExitCancelled, Err: err}
+	}
+
+	if err != nil {
+		log.Error("Compilation failed", slog.Any("error", err))
+		return result, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestHarvest(t *testing.T) {
+	tests := []struct {
+		name      string
+		log       string
+		wantCode  int
+		wantCause report.Cause
+		wantNil   bool
+	}{
+		{
+			name:      "successful compile",
+			log:       "---------- Compiling for TSW-770: [test.vtp] ---------\nMain\n---------- Successful ---------\n1 warning(s), 0 error(s)",
+			wantCode:  ExitSuccess,
+			wantCause: report.CauseNone,
+		},
+		{
+			name:      "failed compile",
+			log:       "---------- Compiling for TSW-770: [test.vtp] ---------\nMain\n---------- Failed ---------\n0 warning(s), 2 error(s)",
+			wantCode:  ExitFailure,
+			wantCause: report.CauseCompileErrors,
+		},
+		{
+			name:      "cancelled compile",
+			log:       "---------- Compiling for TSW-770: [test.vtp] ---------\nMain\nCompile cancelled",
+			wantCode:  ExitCancelled,
+			wantCause: report.CauseCancelled,
+		},
+		{
+			name:      "nothing compiled yet",
+			log:       "Ready",
+			wantCode:  ExitFailure,
+			wantCause: report.CauseUnknown,
+			wantNil:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockWin := testutil.NewMockWindowManager().
+				WithChildInfosForHwnd(0x9999, windows.ChildInfo{ClassName: "ListBox", Text: tt.log})
+			comp := compiler.NewCompiler(logger.NewNoOpLogger(), compiler.WithWindowManager(mockWin))
+
+			result, err := harvest(comp, 0x9999, compiler.MessageOrderSeverity, compiler.MessageFormatList, logger.NewNoOpLogger())
+
+			assert.Equal(t, tt.wantCode, ExitCode(err))
+			assert.Equal(t, tt.wantCause, classifyFailure(err, result))
+			assert.Empty(t, mockWin.CloseWindowCalls, "harvest must leave VTPro open")
+
+			if tt.wantNil {
+				assert.Nil(t, result)
+			} else {
+				require.NotNil(t, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/timeouts"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// defaultSlowInputMultiplier lengthens the keystroke delay from 50ms to 200ms in
+// a virtual machine or remote session, where injected input has been seen to
+// arrive hundreds of milliseconds late
+const defaultSlowInputMultiplier = 4
+
+// keystrokeHistoryFile is the name of the compile keystroke history, kept next to the log file
+const keystrokeHistoryFile = "keystrokes.json"
+
+// keystrokeHistoryPath returns where the compile keystroke history is kept
+func keystrokeHistoryPath() string {
+	return filepath.Join(filepath.Dir(logger.GetLogPath(logger.LoggerOptions{})), keystrokeHistoryFile)
+}
+
+// trackKeystroke adds the run's compile keystroke to the history at path, and
+// warns when the input method tried first has not worked for several runs on
+// this machine. The history is only a diagnostic, so failing to keep it is not
+// an error.
+func trackKeystroke(k compiler.KeystrokeReport, path string, now time.Time, log logger.LoggerInterface) {
+	if !k.Tried() || !k.Watched {
+		return
+	}
+
+	history, err := compiler.LoadKeystrokeHistory(path)
+	if err != nil {
+		log.Debug("Starting a new keystroke history", slog.Any("error", err))
+		history = &compiler.KeystrokeHistory{}
+	}
+
+	history.Record(now, k)
+
+	if err := history.Save(path); err != nil {
+		log.Debug("Could not save the keystroke history", slog.Any("error", err))
+	}
+
+	if method, ok := history.PersistentFailure(); ok {
+		log.Warn("The compile keystroke's first input method has not worked in recent runs on this machine; pin another with --input-method",
+			slog.String("method", string(method)),
+			slog.Int("runs", compiler.PersistentFailureRuns),
+		)
+	}
+}
+
+// inputDelays returns the delays around injected keystrokes: the defaults,
+// multiplied by multiplier when adapt is set and indicators show a virtual
+// machine or remote session
+func inputDelays(indicators windows.VirtualIndicators, multiplier float64, adapt bool, log logger.LoggerInterface) timeouts.Input {
+	delays := timeouts.DefaultInput()
+
+	reasons := indicators.SlowInputReasons()
+	if len(reasons) == 0 {
+		return delays
+	}
+
+	if !adapt {
+		log.Info("Virtual machine or remote session detected, keeping the default keystroke delays as --no-input-adapt was given",
+			slog.Any("reasons", reasons),
+		)
+
+		return delays
+	}
+
+	adapted := delays.Scale(multiplier)
+
+	log.Info("Virtual machine or remote session detected, lengthening keystroke delays",
+		slog.Any("reasons", reasons),
+		slog.Float64("multiplier", multiplier),
+		slog.Duration("keystroke", adapted.Keystroke),
+		slog.Duration("focusVerification", adapted.FocusVerification),
+	)
+
+	return adapted
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/timeouts"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestTrackKeystroke_WarnsAfterRepeatedFailures(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), keystrokeHistoryFile)
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	fellBack := compiler.KeystrokeReport{
+		Method:       compiler.InputKeybdEvent,
+		Failed:       []compiler.InputMethod{compiler.InputSendInput},
+		Acknowledged: true,
+		Watched:      true,
+	}
+
+	for i := range compiler.PersistentFailureRuns {
+		log := testutil.NewMockLogger()
+		trackKeystroke(fellBack, path, now.Add(time.Duration(i)*time.Hour), log)
+
+		if i < compiler.PersistentFailureRuns-1 {
+			assert.Empty(t, log.Entries, "run %d", i+1)
+			continue
+		}
+
+		require.Len(t, log.Entries, 1)
+		assert.Equal(t, "WARN", log.Entries[0].Level)
+		assert.Contains(t, log.Entries[0].String(), "sendinput")
+	}
+
+	history, err := compiler.LoadKeystrokeHistory(path)
+	require.NoError(t, err)
+	assert.Len(t, history.Runs, compiler.PersistentFailureRuns)
+}
+
+func TestTrackKeystroke_SkipsRunsThatSayNothing(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), keystrokeHistoryFile)
+
+	trackKeystroke(compiler.KeystrokeReport{}, path, time.Now(), testutil.NewMockLogger())
+	trackKeystroke(compiler.KeystrokeReport{Method: compiler.InputSendInput}, path, time.Now(), testutil.NewMockLogger())
+
+	assert.NoFileExists(t, path, "untried and unwatched keystrokes are not recorded")
+}
+
+func TestTrackKeystroke_ReplacesUnreadableHistory(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), keystrokeHistoryFile)
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+
+	log := testutil.NewMockLogger()
+	trackKeystroke(compiler.KeystrokeReport{Method: compiler.InputSendInput, Acknowledged: true, Watched: true}, path, time.Now(), log)
+
+	history, err := compiler.LoadKeystrokeHistory(path)
+	require.NoError(t, err)
+	assert.Len(t, history.Runs, 1)
+}
+
+func TestInputDelays(t *testing.T) {
+	t.Parallel()
+
+	vdi := windows.VirtualIndicators{RemoteSession: true, GuestAgents: []string{"VMware Horizon Agent"}}
+
+	tests := []struct {
+		name       string
+		indicators windows.VirtualIndicators
+		adapt      bool
+		want       timeouts.Input
+		wantLog    string
+	}{
+		{
+			name:  "physical machine",
+			adapt: true,
+			want:  timeouts.DefaultInput(),
+		},
+		{
+			name:       "Hyper-V root partition",
+			indicators: windows.VirtualIndicators{Hypervisor: "Microsoft Hv"},
+			adapt:      true,
+			want:       timeouts.DefaultInput(),
+		},
+		{
+			name:       "VDI session",
+			indicators: vdi,
+			adapt:      true,
+			want:       timeouts.Input{Keystroke: 200 * time.Millisecond, FocusVerification: 4 * time.Second},
+			wantLog:    "lengthening keystroke delays",
+		},
+		{
+			name:       "VDI session with --no-input-adapt",
+			indicators: vdi,
+			want:       timeouts.DefaultInput(),
+			wantLog:    "keeping the default keystroke delays",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			log := testutil.NewMockLogger()
+			assert.Equal(t, tt.want, inputDelays(tt.indicators, defaultSlowInputMultiplier, tt.adapt, log))
+
+			if tt.wantLog == "" {
+				assert.Empty(t, log.Entries)
+				return
+			}
+
+			require.Len(t, log.Entries, 1)
+			assert.Contains(t, log.Entries[0].String(), tt.wantLog)
+			assert.Contains(t, log.Entries[0].String(), "remote session")
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// listWindowsCmd prints the windows on screen, for answering window matching
+// questions from a support request
+var listWindowsCmd = &cobra.Command{
+	Use:   "list-windows",
+	Short: "List the visible top-level windows with their process, class, title and position",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		format, _ := cmd.Flags().GetString("output")
+		pid, _ := cmd.Flags().GetUint32("pid")
+		image, _ := cmd.Flags().GetString("image")
+		children, _ := cmd.Flags().GetBool("children")
+
+		filter := windowFilter{pid: pid, image: image}
+		listed := listWindows(systemWindows{}, filter, children)
+
+		return writeWindowList(cmd.OutOrStdout(), listed, format)
+	},
+}
+
+func init() {
+	listWindowsCmd.Flags().String("output", "text", "output format: text or json")
+	listWindowsCmd.Flags().Uint32("pid", 0, "only list windows of the process with this PID")
+	listWindowsCmd.Flags().String("image", "", "only list windows of processes with this executable name, e.g. vtpro.exe")
+	listWindowsCmd.Flags().Bool("children", false, "also list each window's first level of child controls")
+	RootCmd.AddCommand(listWindowsCmd)
+}
+
+// windowSource reads the windows on screen and what list-windows shows about
+// them. It exists so the output can be tested without real windows.
+type windowSource interface {
+	Windows() []windows.WindowInfo
+	ImagePath(pid uint32) string
+	Class(hwnd uintptr) string
+	Rect(hwnd uintptr) (windows.RECT, bool)
+	Children(hwnd uintptr) []windows.ChildInfo
+}
+
+// systemWindows is the windowSource backed by the Windows API
+type systemWindows struct{}
+
+func (systemWindows) Windows() []windows.WindowInfo          { return windows.EnumerateWindows() }
+func (systemWindows) ImagePath(pid uint32) string            { return windows.ProcessImagePath(pid) }
+func (systemWindows) Class(hwnd uintptr) string              { return windows.GetClassName(hwnd) }
+func (systemWindows) Rect(hwnd uintptr) (windows.RECT, bool) { return windows.WindowRect(hwnd) }
+func (systemWindows) Children(hwnd uintptr) []windows.ChildInfo {
+	return windows.CollectChildInfos(hwnd)
+}
+
+// windowFilter picks the windows list-windows shows; zero values match every window
+type windowFilter struct {
+	pid   uint32
+	image string // Executable name, compared case-insensitively
+}
+
+// listedRect is a window's bounds in screen coordinates
+type listedRect struct {
+	Left   int32 `json:"left"`
+	Top    int32 `json:"top"`
+	Right  int32 `json:"right"`
+	Bottom int32 `json:"bottom"`
+}
+
+// listedChild is one child control of a listed window
+type listedChild struct {
+	Hwnd  string   `json:"hwnd"`
+	Class string   `json:"class"`
+	Text  string   `json:"text"`
+	Items []string `json:"items,omitempty"`
+}
+
+// listedWindow is one line of list-windows output
+type listedWindow struct {
+	Hwnd     string        `json:"hwnd"`
+	Pid      uint32        `json:"pid"`
+	Image    string        `json:"image"` // Full executable path, "" if it could not be read
+	Class    string        `json:"class"`
+	Title    string        `json:"title"`
+	Rect     *listedRect   `json:"rect"` // nil if it could not be read
+	State    string        `json:"state"`
+	Children []listedChild `json:"children,omitempty"`
+}
+
+// Window states shown by list-windows. Only visible windows are enumerated,
+// so a window is either showing or minimized.
+const (
+	windowStateVisible   = "visible"
+	windowStateMinimized = "minimized"
+)
+
+// formatHwnd formats a window handle as Spy++ and the log file do
+func formatHwnd(hwnd uintptr) string {
+	return fmt.Sprintf("0x%08X", hwnd)
+}
+
+// imageName returns the executable name from a full image path. Both separators
+// are split on so it does not depend on the platform the tests run on.
+func imageName(path string) string {
+	return path[strings.LastIndexAny(path, `\/`)+1:]
+}
+
+// listWindows collects what is shown about each window that matches the filter,
+// in the order the source enumerates them, which is front to back
+func listWindows(src windowSource, filter windowFilter, children bool) []listedWindow {
+	listed := []listedWindow{}
+
+	for _, w := range src.Windows() {
+		if filter.pid != 0 && w.Pid != filter.pid {
+			continue
+		}
+
+		image := src.ImagePath(w.Pid)
+		if filter.image != "" && !strings.EqualFold(imageName(image), filter.image) {
+			continue
+		}
+
+		lw := listedWindow{
+			Hwnd:  formatHwnd(w.Hwnd),
+			Pid:   w.Pid,
+			Image: image,
+			Class: src.Class(w.Hwnd),
+			Title: w.Title,
+			State: windowStateVisible,
+		}
+
+		if r, ok := src.Rect(w.Hwnd); ok {
+			lw.Rect = &listedRect{Left: r.Left, Top: r.Top, Right: r.Right, Bottom: r.Bottom}
+
+			if r.Minimized() {
+				lw.State = windowStateMinimized
+			}
+		}
+
+		if children {
+			for _, c := range src.Children(w.Hwnd) {
+				lw.Children = append(lw.Children, listedChild{Hwnd: formatHwnd(c.Hwnd), Class: c.ClassName, Text: c.Text, Items: c.Items})
+			}
+		}
+
+		listed = append(listed, lw)
+	}
+
+	return listed
+}
+
+// writeWindowList writes the listed windows as a table or as JSON
+func writeWindowList(w io.Writer, listed []listedWindow, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(listed)
+
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "HWND\tPID\tIMAGE\tCLASS\tSTATE\tRECT\tTITLE")
+
+		for _, lw := range listed {
+			image := "?"
+			if lw.Image != "" {
+				image = imageName(lw.Image)
+			}
+
+			rect := "?"
+			if lw.Rect != nil {
+				rect = windows.RECT{Left: lw.Rect.Left, Top: lw.Rect.Top, Right: lw.Rect.Right, Bottom: lw.Rect.Bottom}.String()
+			}
+
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", lw.Hwnd, lw.Pid, image, lw.Class, lw.State, rect, strconv.Quote(lw.Title))
+
+			for _, c := range lw.Children {
+				fmt.Fprintf(tw, "  %s\t\t\t%s\t\t\t%s\n", c.Hwnd, c.Class, strconv.Quote(c.Text))
+			}
+		}
+
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		_, err := fmt.Fprintf(w, "%d window(s)\n", len(listed))
+
+		return err
+
+	default:
+		return fmt.Errorf("unknown output format %q, expected text or json", format)
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// fakeWindowSource is a windowSource with fixed windows, keyed by handle
+type fakeWindowSource struct {
+	windows  []windows.WindowInfo
+	images   map[uint32]string
+	classes  map[uintptr]string
+	rects    map[uintptr]windows.RECT
+	children map[uintptr][]windows.ChildInfo
+}
+
+func (f fakeWindowSource) Windows() []windows.WindowInfo { return f.windows }
+func (f fakeWindowSource) ImagePath(pid uint32) string   { return f.images[pid] }
+func (f fakeWindowSource) Class(hwnd uintptr) string     { return f.classes[hwnd] }
+
+func (f fakeWindowSource) Rect(hwnd uintptr) (windows.RECT, bool) {
+	r, ok := f.rects[hwnd]
+	return r, ok
+}
+
+func (f fakeWindowSource) Children(hwnd uintptr) []windows.ChildInfo { return f.children[hwnd] }
+
+// desktopWithVTPro is a desktop with VTPro open, minimized Notepad, and a window
+// whose process and position cannot be read
+var desktopWithVTPro = fakeWindowSource{
+	windows: []windows.WindowInfo{
+		{Hwnd: 0x1A2B, Title: "lobby.vtp - VisionTools Pro-e", Pid: 4321},
+		{Hwnd: 0x3C4D, Title: "VisionTools(R) Pro-e", Pid: 4321},
+		{Hwnd: 0x5E6F, Title: "notes.txt - Notepad", Pid: 1111},
+		{Hwnd: 0x7080, Title: "", Pid: 4},
+	},
+	images: map[uint32]string{
+		4321: `C:\Program Files (x86)\Crestron\VtPro-e\vtpro.exe`,
+		1111: `C:\Windows\System32\notepad.exe`,
+	},
+	classes: map[uintptr]string{
+		0x1A2B: "VTPro-eMainFrame",
+		0x3C4D: "#32770",
+		0x5E6F: "Notepad",
+		0x7080: "Shell_TrayWnd",
+	},
+	rects: map[uintptr]windows.RECT{
+		0x1A2B: {Left: 0, Top: 0, Right: 1920, Bottom: 1040},
+		0x3C4D: {Left: 760, Top: 400, Right: 1160, Bottom: 560},
+		0x5E6F: {Left: -32000, Top: -32000, Right: -31840, Bottom: -31972},
+	},
+	children: map[uintptr][]windows.ChildInfo{
+		0x3C4D: {
+			{Hwnd: 0x3C50, ClassName: "Static", Text: "The path exceeds the windows path limitations"},
+			{Hwnd: 0x3C51, ClassName: "Button", Text: "OK"},
+		},
+	},
+}
+
+func TestWriteWindowList(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			require.NoError(t, writeWindowList(&out, listWindows(desktopWithVTPro, windowFilter{}, true), format))
+
+			assertGolden(t, "list_windows_"+format, out.String())
+		})
+	}
+}
+
+func TestWriteWindowList_UnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	err := writeWindowList(&bytes.Buffer{}, nil, "yaml")
+	assert.EqualError(t, err, `unknown output format "yaml", expected text or json`)
+}
+
+func TestWriteWindowList_NoneMatchedIsEmptyJSONList(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	require.NoError(t, writeWindowList(&out, listWindows(desktopWithVTPro, windowFilter{pid: 9999}, false), "json"))
+
+	assert.Equal(t, "[]\n", out.String())
+}
+
+func TestListWindows_Filters(t *testing.T) {
+	t.Parallel()
+
+	hwnds := func(listed []listedWindow) []string {
+		var out []string
+		for _, lw := range listed {
+			out = append(out, lw.Hwnd)
+		}
+
+		return out
+	}
+
+	assert.Len(t, listWindows(desktopWithVTPro, windowFilter{}, false), 4)
+	assert.Equal(t, []string{"0x00001A2B", "0x00003C4D"}, hwnds(listWindows(desktopWithVTPro, windowFilter{pid: 4321}, false)))
+	assert.Equal(t, []string{"0x00001A2B", "0x00003C4D"}, hwnds(listWindows(desktopWithVTPro, windowFilter{image: "VTPRO.EXE"}, false)),
+		"the image name is matched case-insensitively")
+	assert.Equal(t, []string{"0x00005E6F"}, hwnds(listWindows(desktopWithVTPro, windowFilter{pid: 1111, image: "notepad.exe"}, false)))
+	assert.Empty(t, listWindows(desktopWithVTPro, windowFilter{pid: 1111, image: "vtpro.exe"}, false), "both filters must match")
+}
+
+func TestListWindows_State(t *testing.T) {
+	t.Parallel()
+
+	listed := listWindows(desktopWithVTPro, windowFilter{}, false)
+
+	assert.Equal(t, windowStateVisible, listed[0].State)
+	assert.Equal(t, windowStateMinimized, listed[2].State)
+	assert.Nil(t, listed[3].Rect, "a rectangle that cannot be read is left out")
+	assert.Nil(t, listed[1].Children, "children are only listed when asked for")
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/artifact"
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// ArtifactLocator returns the path of the artifact a compile of source produced,
+// for builds whose output goes somewhere only the caller knows. When one is set,
+// it is used instead of the built-in search, and its path is verified, hashed
+// for --provenance and deployed like any other.
+type ArtifactLocator func(ctx context.Context, source string, result LocatorResult) (string, error)
+
+// LocatorResult is what an ArtifactLocator is told about the compile
+type LocatorResult struct {
+	Warnings    int
+	Errors      int
+	Targets     []string      // The panels the project compiled for, in Message Log order
+	CompileTime time.Duration // From the compile keystroke until the Compiling dialog closed
+}
+
+// artifactLocator is the locator set with SetArtifactLocator, nil for the built-in search
+var artifactLocator ArtifactLocator
+
+// SetArtifactLocator replaces the built-in artifact search for the compiles a
+// program that embeds vtpc's commands runs with RootCmd, or restores the
+// search when locate is nil. Call it before RootCmd.Execute. It does not reach
+// the child processes vtpc batch compiles with, nor the compiles of runs with
+// --isolate, which collect their artifacts from the isolated copy.
+func SetArtifactLocator(locate ArtifactLocator) {
+	artifactLocator = locate
+}
+
+// newLocatorResult is what a locator is told about result
+func newLocatorResult(result *compiler.CompileResult) LocatorResult {
+	if result == nil {
+		return LocatorResult{}
+	}
+
+	targets := make([]string, 0, len(result.Sections))
+	for _, s := range result.Sections {
+		targets = append(targets, s.Target)
+	}
+
+	return LocatorResult{
+		Warnings:    result.Warnings,
+		Errors:      result.Errors,
+		Targets:     targets,
+		CompileTime: result.CompileTime,
+	}
+}
+
+// ErrArtifactLocator is returned when an artifact locator fails or finds nothing
+var ErrArtifactLocator = errcode.New(errcode.ArtifactLocator, "artifact locator failed")
+
+// findArtifact finds the compile's artifact with locate, or with builtin when no
+// locator is set. The built-in search finding nothing is only a warning, since
+// VTPro may have written the artifact elsewhere. A locator was asked for, so a
+// locator that fails, or returns no path, fails the run.
+func findArtifact(
+	ctx context.Context,
+	locate ArtifactLocator,
+	builtin func() (artifact.File, bool),
+	source string,
+	result *compiler.CompileResult,
+	log logger.LoggerInterface,
+) (artifact.File, bool, error) {
+	if locate == nil {
+		found, ok := builtin()
+		return found, ok, nil
+	}
+
+	path, err := locate(ctx, source, newLocatorResult(result))
+	if err == nil && path == "" {
+		err = errors.New("no path returned")
+	}
+
+	if err != nil {
+		err = fmt.Errorf("%w for %s: %w", ErrArtifactLocator, source, err)
+		log.Error("Could not locate compiled artifact", slog.Any("error", err))
+
+		return artifact.File{}, false, err
+	}
+
+	log.Info("Compiled artifact found",
+		slog.String("path", path),
+		slog.String("source", string(artifact.SourceLocator)),
+	)
+
+	return artifact.File{Path: path, Source: artifact.SourceLocator}, true, nil
+}
+
+// locateArtifact finds the artifact VTPro wrote for a compile started at since,
+// looking in the output directory from VTPro's preferences before the project's directory
+func locateArtifact(reader artifact.PreferenceReader, projectPath string, since time.Time, log logger.LoggerInterface) (artifact.File, bool) {
+	locations, err := artifact.SearchLocations(reader, projectPath)
+	if err != nil {
+		log.Debug("Could not read VTPro output directory preference", slog.Any("error", err))
+	}
+
+	found, err := artifact.Find(locations, projectPath, since)
+	if err != nil {
+		log.Warn("Could not find compiled artifact", slog.Any("error", err))
+		return artifact.File{}, false
+	}
+
+	log.Info("Compiled artifact found",
+		slog.String("path", found.Path),
+		slog.String("source", string(found.Source)),
+	)
+
+	return found, true
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/artifact"
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// builtinNotCalled is a built-in search that fails the test if it runs
+func builtinNotCalled(t *testing.T) func() (artifact.File, bool) {
+	return func() (artifact.File, bool) {
+		t.Error("built-in artifact search ran with a locator set")
+		return artifact.File{}, false
+	}
+}
+
+func TestFindArtifact_UsesLocator(t *testing.T) {
+	t.Parallel()
+
+	result := &compiler.CompileResult{
+		Warnings:    2,
+		Sections:    []compiler.TargetResult{{Target: "TSW-770"}, {Target: "TSW-1070"}},
+		CompileTime: 40 * time.Second,
+	}
+
+	var gotSource string
+	var gotResult LocatorResult
+	locate := func(_ context.Context, source string, r LocatorResult) (string, error) {
+		gotSource, gotResult = source, r
+		return `\\build\drops\lobby\42\lobby.vtz`, nil
+	}
+
+	a, ok, err := findArtifact(context.Background(), locate, builtinNotCalled(t), `C:\Projects\lobby.vtp`, result, logger.NewNoOpLogger())
+
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, artifact.File{Path: `\\build\drops\lobby\42\lobby.vtz`, Source: artifact.SourceLocator}, a)
+	assert.Equal(t, `C:\Projects\lobby.vtp`, gotSource)
+	assert.Equal(t, LocatorResult{Warnings: 2, Targets: []string{"TSW-770", "TSW-1070"}, CompileTime: 40 * time.Second}, gotResult)
+}
+
+func TestFindArtifact_LocatorErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		path    string
+		err     error
+		wantErr string
+	}{
+		{name: "locator fails", err: errors.New("build 42 has no drop"), wantErr: `artifact locator failed for C:\lobby.vtp: build 42 has no drop`},
+		{name: "locator returns no path", wantErr: `artifact locator failed for C:\lobby.vtp: no path returned`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			locate := func(context.Context, string, LocatorResult) (string, error) { return tt.path, tt.err }
+
+			_, ok, err := findArtifact(context.Background(), locate, builtinNotCalled(t), `C:\lobby.vtp`, nil, logger.NewNoOpLogger())
+
+			assert.False(t, ok)
+			require.ErrorIs(t, err, ErrArtifactLocator)
+			assert.EqualError(t, err, tt.wantErr)
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestFindArtifact_WithoutLocatorUsesBuiltin(t *testing.T) {
+	t.Parallel()
+
+	want := artifact.File{Path: `C:\Projects\lobby.vtz`, Source: artifact.SourceProjectDir}
+	builtin := func() (artifact.File, bool) { return want, true }
+
+	a, ok, err := findArtifact(context.Background(), nil, builtin, `C:\Projects\lobby.vtp`, nil, logger.NewNoOpLogger())
+
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, want, a)
+}
+
+func TestFindArtifact_LocatedPathIsVerified(t *testing.T) {
+	t.Parallel()
+
+	// The locator points somewhere the built-in search would never look
+	path := filepath.Join(t.TempDir(), "drops", "lobby.vtz")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte("not a zip"), 0o644))
+
+	locate := func(context.Context, string, LocatorResult) (string, error) { return path, nil }
+
+	a, _, err := findArtifact(context.Background(), locate, builtinNotCalled(t), "lobby.vtp", nil, logger.NewNoOpLogger())
+	require.NoError(t, err)
+
+	reports, err := verifyArtifacts([]string{a.Path}, 0, logger.NewNoOpLogger())
+	require.ErrorIs(t, err, artifact.ErrCorrupt)
+	require.Len(t, reports, 1)
+	assert.Equal(t, path, reports[0].Path)
+}
+
+func TestSetArtifactLocator(t *testing.T) {
+	locate := func(context.Context, string, LocatorResult) (string, error) { return `\\build\drops\lobby.vtz`, nil }
+
+	SetArtifactLocator(locate)
+	t.Cleanup(func() { SetArtifactLocator(nil) })
+
+	cfg := NewConfigFromFlags(&cobra.Command{})
+	require.NotNil(t, cfg.locator, "runs started after SetArtifactLocator use the locator")
+
+	path, err := cfg.locator(context.Background(), "lobby.vtp", LocatorResult{})
+	require.NoError(t, err)
+	assert.Equal(t, `\\build\drops\lobby.vtz`, path)
+
+	SetArtifactLocator(nil)
+	assert.Nil(t, NewConfigFromFlags(&cobra.Command{}).locator, "nil restores the built-in search")
+}
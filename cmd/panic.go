@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime/debug"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// ErrPanic is wrapped by the error a recovered panic is turned into
+var ErrPanic = errcode.New(errcode.Internal, "vtpc crashed")
+
+// PanicError is a panic recovered during a run, with the stack it was raised on
+type PanicError struct {
+	Value any
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrPanic, e.Value)
+}
+
+func (e *PanicError) Unwrap() error {
+	return ErrPanic
+}
+
+// compileRunner runs the compile; runCompilation outside of tests
+type compileRunner func(CompilationParams) (*compiler.CompileResult, error)
+
+// runGuarded runs the compile, turning a panic into a *PanicError after cleanup
+// has closed VTPro, so a crash is never reported as success with VTPro left running
+func runGuarded(run compileRunner, params CompilationParams, cleanup func()) (result *compiler.CompileResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoverPanic(r, stack(), cleanup, params.Logger)
+		}
+	}()
+
+	return run(params)
+}
+
+// stack returns the stack of the current goroutine, for a recovered panic
+func stack() string {
+	return string(debug.Stack())
+}
+
+// recoverPanic reports a recovered panic, runs cleanup if there is any yet, and
+// returns the panic as an error
+func recoverPanic(r any, stack string, cleanup func(), log logger.LoggerInterface) error {
+	log.Error("PANIC RECOVERED",
+		slog.Any("panic", r),
+		slog.String("stack", stack),
+	)
+
+	fmt.Fprintf(os.Stderr, "\n*** PANIC: %v ***\n", r)
+	fmt.Fprintf(os.Stderr, "Check log file for details\n")
+
+	if cleanup != nil {
+		log.Info("Closing VTPro after the panic")
+		cleanup()
+	}
+
+	return &PanicError{Value: r, Stack: stack}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+)
+
+func TestRunGuarded_PanickingCompilerCleansUpAndFails(t *testing.T) {
+	log := testutil.NewMockLogger()
+	cleanups := 0
+
+	panicking := func(CompilationParams) (*compiler.CompileResult, error) {
+		var sections []compiler.TargetResult
+		_ = sections[3] // index out of range
+		return nil, nil
+	}
+
+	result, err := runGuarded(panicking, CompilationParams{Logger: log}, func() { cleanups++ })
+
+	assert.Nil(t, result)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrPanic)
+	assert.Equal(t, 1, cleanups, "VTPro is closed after the panic")
+	assert.Equal(t, ExitFailure, ExitCode(err))
+
+	var panicErr *PanicError
+	require.True(t, errors.As(err, &panicErr))
+	assert.Contains(t, panicErr.Error(), "index out of range")
+	assert.Contains(t, panicErr.Stack, "panic_test.go", "the stack points at the panic")
+	assert.Contains(t, log.Messages(), "PANIC RECOVERED")
+}
+
+func TestRunGuarded_PassesThroughResults(t *testing.T) {
+	want := &compiler.CompileResult{Warnings: 2}
+	compileErr := errors.New("compilation failed with 1 error(s)")
+
+	result, err := runGuarded(func(CompilationParams) (*compiler.CompileResult, error) {
+		return want, compileErr
+	}, CompilationParams{Logger: testutil.NewMockLogger()}, func() { t.Error("cleanup ran without a panic") })
+
+	assert.Same(t, want, result)
+	assert.Same(t, compileErr, err)
+}
+
+func TestRecoverPanic_BeforeVTProIsLaunched(t *testing.T) {
+	err := recoverPanic("boom", "stack", nil, testutil.NewMockLogger())
+
+	assert.ErrorIs(t, err, ErrPanic)
+	assert.EqualError(t, err, "vtpc crashed: boom")
+}
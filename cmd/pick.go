@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/pathutil"
+)
+
+// pauseOnExit is set by --pause, or when the project was picked at the prompt,
+// so a console opened just for vtpc stays open until the results are read
+var pauseOnExit bool
+
+// PauseBeforeExit waits for Enter if the run asked to pause before exiting
+func PauseBeforeExit() {
+	if pauseOnExit {
+		waitForEnter(os.Stdin, consoleOut)
+	}
+}
+
+// waitForEnter asks for Enter on out and returns once a line, or EOF, is read from in
+func waitForEnter(in io.Reader, out io.Writer) {
+	fmt.Fprint(out, "\nPress Enter to close...")
+	_, _ = bufio.NewReader(in).ReadString('\n')
+}
+
+// findProjects returns the .vtp files in dir and its immediate subdirectories,
+// relative to dir: those in dir first, then by subdirectory, each sorted by name
+func findProjects(dir string) ([]string, error) {
+	var projects []string
+
+	for _, pattern := range []string{"*.vtp", filepath.Join("*", "*.vtp")} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+
+		slices.Sort(matches)
+
+		for _, m := range matches {
+			rel, err := filepath.Rel(dir, m)
+			if err != nil {
+				return nil, err
+			}
+
+			projects = append(projects, rel)
+		}
+	}
+
+	return projects, nil
+}
+
+// pickProject asks which project to compile when none was given, listing the
+// projects in dir. The user picks one by number or pastes a path, then confirms.
+// It returns false if the user enters nothing, presses Esc or closes the input.
+func pickProject(in io.Reader, out io.Writer, dir string, env pathutil.Env) (string, bool) {
+	r := bufio.NewReader(in)
+
+	projects, err := findProjects(dir)
+	if err != nil {
+		fmt.Fprintf(out, "Could not look for projects in %s: %v\n", dir, err)
+	}
+
+	fmt.Fprintln(out, "No project file given.")
+
+	if len(projects) > 0 {
+		fmt.Fprintln(out, "Projects found here:")
+
+		for i, p := range projects {
+			fmt.Fprintf(out, "  %d. %s\n", i+1, p)
+		}
+	}
+
+	for {
+		if len(projects) > 0 {
+			fmt.Fprint(out, "Enter a number or paste the path of a .vtp file (empty to exit): ")
+		} else {
+			fmt.Fprint(out, "Paste the path of a .vtp file (empty to exit): ")
+		}
+
+		answer, ok := readAnswer(r)
+		if !ok || answer == "" {
+			fmt.Fprintln(out)
+			return "", false
+		}
+
+		path, problem := chooseProject(answer, projects, dir, env)
+		if problem != "" {
+			fmt.Fprintln(out, problem)
+			continue
+		}
+
+		fmt.Fprintf(out, "Compile %s? [Y/n] ", path)
+
+		answer, ok = readAnswer(r)
+		if !ok {
+			fmt.Fprintln(out)
+			return "", false
+		}
+
+		if a := strings.ToLower(answer); a == "" || a == "y" || a == "yes" {
+			return path, true
+		}
+	}
+}
+
+// readAnswer reads a line of input, trimmed. It returns false at EOF or if the
+// line contains Esc, which a console passes through as a character.
+func readAnswer(r *bufio.Reader) (string, bool) {
+	line, err := r.ReadString('\n')
+	if strings.ContainsRune(line, '\x1b') {
+		return "", false
+	}
+
+	if err != nil && line == "" {
+		return "", false
+	}
+
+	return strings.TrimSpace(line), true
+}
+
+// chooseProject turns an answer into the path of a project that exists, or says
+// what is wrong with it. Numbers pick from projects; anything else is a path,
+// resolved against dir when relative.
+func chooseProject(answer string, projects []string, dir string, env pathutil.Env) (string, string) {
+	if n, err := strconv.Atoi(answer); err == nil {
+		if n < 1 || n > len(projects) {
+			return "", fmt.Sprintf("There is no project %d.", n)
+		}
+
+		return filepath.Join(dir, projects[n-1]), ""
+	}
+
+	path, _ := pathutil.Normalize(answer, env)
+	if filepath.Ext(path) != ".vtp" {
+		return "", "The file must have a .vtp extension."
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Sprintf("Cannot open %s.", path)
+	}
+
+	return path, ""
+}
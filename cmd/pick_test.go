@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/pathutil"
+)
+
+// projectTree creates files under a temp directory and returns it
+func projectTree(t *testing.T, files ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+	}
+
+	return dir
+}
+
+func TestFindProjects(t *testing.T) {
+	t.Parallel()
+
+	dir := projectTree(t,
+		"Lobby.vtp",
+		"Boardroom.vtp",
+		"notes.txt",
+		"Panels/TSW-770.vtp",
+		"Archive/Old/Lobby.vtp", // Two levels down
+	)
+
+	projects, err := findProjects(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"Boardroom.vtp",
+		"Lobby.vtp",
+		filepath.Join("Panels", "TSW-770.vtp"),
+	}, projects)
+}
+
+func TestPickProject(t *testing.T) {
+	t.Parallel()
+
+	dir := projectTree(t, "Boardroom.vtp", "Lobby.vtp", "Panels/TSW-770.vtp")
+	pasted := filepath.Join(dir, "Panels", "TSW-770.vtp")
+
+	tests := []struct {
+		name   string
+		input  string
+		want   string // Path picked, empty when the prompt exits
+		output []string
+	}{
+		{
+			name:   "by number, confirming with Enter",
+			input:  "2\n\n",
+			want:   filepath.Join(dir, "Lobby.vtp"),
+			output: []string{"  1. Boardroom.vtp\n", "  2. Lobby.vtp\n", "Compile " + filepath.Join(dir, "Lobby.vtp") + "? [Y/n] "},
+		},
+		{
+			name:  "pasted path in quotes",
+			input: `"` + pasted + "\"\r\ny\r\n",
+			want:  pasted,
+		},
+		{
+			name:   "relative path",
+			input:  filepath.Join("Panels", "TSW-770.vtp") + "\nyes\n",
+			want:   pasted,
+			output: []string{"Compile " + pasted + "?"},
+		},
+		{
+			name:   "out of range, then valid",
+			input:  "7\n1\n\n",
+			want:   filepath.Join(dir, "Boardroom.vtp"),
+			output: []string{"There is no project 7.\n"},
+		},
+		{
+			name:   "not a project, then a missing one",
+			input:  "Lobby.vtz\nMissing.vtp\n\n",
+			output: []string{"The file must have a .vtp extension.\n", "Cannot open " + filepath.Join(dir, "Missing.vtp") + ".\n"},
+		},
+		{
+			name:   "declined, then exit",
+			input:  "1\nn\n\n",
+			output: []string{"Compile " + filepath.Join(dir, "Boardroom.vtp") + "? [Y/n] Enter a number"},
+		},
+		{name: "empty input exits", input: "\n"},
+		{name: "Esc exits", input: "\x1b\n"},
+		{name: "closed input exits", input: ""},
+		{name: "closed input at confirmation exits", input: "1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			got, ok := pickProject(strings.NewReader(tt.input), &out, dir, pathutil.Env{})
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want != "", ok)
+			assert.True(t, strings.HasPrefix(out.String(), "No project file given.\nProjects found here:\n"), out.String())
+
+			for _, s := range tt.output {
+				assert.Contains(t, out.String(), s)
+			}
+		})
+	}
+}
+
+func TestPickProject_NoProjectsFound(t *testing.T) {
+	t.Parallel()
+
+	dir := projectTree(t, "Elsewhere/Deeper/Lobby.vtp")
+	pasted := filepath.Join(dir, "Elsewhere", "Deeper", "Lobby.vtp")
+
+	var out bytes.Buffer
+	got, ok := pickProject(strings.NewReader(pasted+"\n\n"), &out, dir, pathutil.Env{})
+
+	assert.True(t, ok)
+	assert.Equal(t, pasted, got)
+	assert.NotContains(t, out.String(), "Projects found here")
+	assert.Contains(t, out.String(), "Paste the path of a .vtp file (empty to exit): ")
+
+	out.Reset()
+	_, ok = pickProject(strings.NewReader("1\n\n"), &out, dir, pathutil.Env{})
+	assert.False(t, ok)
+	assert.Contains(t, out.String(), "There is no project 1.")
+}
+
+func TestWaitForEnter(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	waitForEnter(strings.NewReader("\n"), &out)
+	assert.Equal(t, "\nPress Enter to close...", out.String())
+
+	// A closed stdin does not hang the exit
+	waitForEnter(strings.NewReader(""), &out)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/policy"
+)
+
+// checkMachinePolicy refuses a project the machine policy does not allow. It fails
+// closed: when the policy file's location is unknown or the file cannot be used,
+// nothing is compiled. programData returns the directory holding vtpc\policy.yaml.
+//
+// outDir is the output directory the project file gave, "" if it gave none. It
+// is held to the same roots, as the project file could otherwise have the
+// elevated instance write anywhere. An --out-dir given on the command line is
+// the user's own choice and is not checked.
+func checkMachinePolicy(projectPath, outDir string, programData func() (string, error), resolve policy.Resolver, log logger.LoggerInterface) error {
+	dir, err := programData()
+	if err != nil {
+		err = fmt.Errorf("%w: could not find the machine policy directory: %w", policy.ErrInvalid, err)
+		log.Error("Machine policy check failed", slog.Any("error", err))
+
+		return &ExitError{Code: ExitPolicy, Err: err}
+	}
+
+	p, err := policy.Load(policy.DefaultPath(dir))
+	if err != nil {
+		log.Error("Machine policy check failed", slog.Any("error", err))
+		return &ExitError{Code: ExitPolicy, Err: err}
+	}
+
+	if !p.Restricted() {
+		log.Debug("No allowed roots in machine policy", slog.String("path", p.Path()))
+		return nil
+	}
+
+	if err := p.CheckProject(projectPath, resolve); err != nil {
+		log.Error("Project refused by machine policy", slog.Any("error", err), slog.Any("allowedRoots", p.AllowedRoots))
+		return &ExitError{Code: ExitPolicy, Err: err}
+	}
+
+	if outDir != "" {
+		if err := p.CheckDir(outDir, resolve); err != nil {
+			err = fmt.Errorf("outDir in the project file: %w", err)
+			log.Error("Project file outDir refused by machine policy", slog.Any("error", err), slog.Any("allowedRoots", p.AllowedRoots))
+
+			return &ExitError{Code: ExitPolicy, Err: err}
+		}
+	}
+
+	log.Debug("Project allowed by machine policy", slog.String("path", p.Path()))
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/policy"
+)
+
+// programDataWith returns a ProgramData directory holding vtpc\policy.yaml with content,
+// or no policy file when content is empty
+func programDataWith(t *testing.T, content string) func() (string, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	if content != "" {
+		path := policy.DefaultPath(dir)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	return func() (string, error) { return dir, nil }
+}
+
+func TestCheckMachinePolicy(t *testing.T) {
+	t.Parallel()
+
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	allowed := filepath.Join(base, "Projects", "lobby.vtp")
+	refused := filepath.Join(base, "Downloads", "lobby.vtp")
+
+	for _, f := range []string{allowed, refused} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(f), 0o755))
+		require.NoError(t, os.WriteFile(f, nil, 0o644))
+	}
+
+	restricted := "allowedRoots:\n  - " + filepath.Join(base, "Projects") + "\n"
+
+	tests := []struct {
+		name        string
+		programData func() (string, error)
+		project     string
+		outDir      string
+		wantErr     error
+	}{
+		{name: "no policy file", programData: programDataWith(t, ""), project: refused},
+		{name: "project under an allowed root", programData: programDataWith(t, restricted), project: allowed},
+		{name: "project outside the allowed roots", programData: programDataWith(t, restricted), project: refused, wantErr: policy.ErrViolation},
+		{
+			name:        "project file outDir under an allowed root",
+			programData: programDataWith(t, restricted),
+			project:     allowed,
+			outDir:      filepath.Join(base, "Projects", "bin"),
+		},
+		{
+			name:        "project file outDir outside the allowed roots",
+			programData: programDataWith(t, restricted),
+			project:     allowed,
+			outDir:      filepath.Join(base, "Downloads", "bin"),
+			wantErr:     policy.ErrViolation,
+		},
+		{name: "outDir without a policy file", programData: programDataWith(t, ""), project: allowed, outDir: filepath.Join(base, "Downloads")},
+		{name: "broken policy file", programData: programDataWith(t, "allowedRoots: [\n"), project: allowed, wantErr: policy.ErrInvalid},
+		{
+			name:        "policy directory unknown",
+			programData: func() (string, error) { return "", errors.New("HRESULT 0x80070002") },
+			project:     allowed,
+			wantErr:     policy.ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkMachinePolicy(tt.project, tt.outDir, tt.programData, filepath.EvalSymlinks, logger.NewNoOpLogger())
+
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, ExitPolicy, ExitCode(err))
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/preflight"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+)
+
+// fixedSpace reports the same free space for every drive
+type fixedSpace uint64
+
+func (f fixedSpace) FreeBytes(string) (uint64, error) { return uint64(f), nil }
+
+func TestRunPreflight_LowDiskSpaceIsAnEnvironmentError(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{MinFreeMB: 500}
+	project := filepath.Join(t.TempDir(), "lobby.vtp")
+
+	err := runPreflight(cfg, project, fixedSpace(100*1024*1024), logger.NewNoOpLogger())
+
+	require.ErrorIs(t, err, preflight.ErrLowDiskSpace)
+	assert.Equal(t, ExitEnvironment, ExitCode(err))
+}
+
+func TestRunPreflight_ChecksOutDir(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{MinFreeMB: 500, OutDir: filepath.Join(t.TempDir(), "builds")}
+	project := filepath.Join(t.TempDir(), "lobby.vtp")
+
+	err := runPreflight(cfg, project, fixedSpace(1024*1024*1024), logger.NewNoOpLogger())
+	require.NoError(t, err)
+}
+
+func TestRunPreflight_Disabled(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{MinFreeMB: 0}
+	project := filepath.Join(t.TempDir(), "lobby.vtp")
+
+	assert.NoError(t, runPreflight(cfg, project, fixedSpace(0), logger.NewNoOpLogger()))
+}
+
+// readOnly is a writability probe that fails for every directory
+func readOnly(dir string) error {
+	return errors.Join(preflight.ErrNotWritable, errors.New("access is denied: "+dir))
+}
+
+func TestStageIfReadOnly(t *testing.T) {
+	t.Parallel()
+
+	writable := func(string) error { return nil }
+	probeNotCalled := func(string) error {
+		t.Error("writability probed with --isolate already set")
+		return nil
+	}
+
+	tests := []struct {
+		name        string
+		cfg         Config
+		probe       func(string) error
+		wantIsolate bool
+		wantErr     error
+	}{
+		{name: "writable project compiles in place", probe: writable},
+		{name: "read-only project is staged", cfg: Config{OutDir: `C:\Builds`}, probe: readOnly, wantIsolate: true},
+		{name: "read-only project needs --out-dir", probe: readOnly, wantErr: preflight.ErrReadOnlyProject},
+		{name: "--isolate skips the probe", cfg: Config{Isolate: true}, probe: probeNotCalled, wantIsolate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := tt.cfg
+			err := stageIfReadOnly(&cfg, `\\nas\projects\Lobby\lobby.vtp`, tt.probe, logger.NewNoOpLogger())
+
+			assert.Equal(t, tt.wantIsolate, cfg.Isolate)
+
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, ExitEnvironment, ExitCode(err))
+			assert.ErrorContains(t, err, "pass --out-dir")
+		})
+	}
+}
+
+func TestStageIfReadOnly_Notice(t *testing.T) {
+	t.Parallel()
+
+	mock := testutil.NewMockLogger()
+	cfg := &Config{OutDir: `C:\Builds`}
+
+	require.NoError(t, stageIfReadOnly(cfg, `\\nas\projects\Lobby\lobby.vtp`, readOnly, mock))
+
+	require.Len(t, mock.Entries, 1)
+	assert.Equal(t, "WARN", mock.Entries[0].Level)
+	assert.Equal(t, "Project directory is read-only, compiling a copy in a temporary directory", mock.Entries[0].Message)
+}
+
+func TestStageIfReadOnly_StagesSidecars(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	project := filepath.Join(dir, "lobby.vtp")
+	require.NoError(t, os.WriteFile(project, []byte("project"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lobby.vta"), []byte("sidecar"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lobby Files"), 0o755))
+
+	cfg := &Config{OutDir: `C:\Builds`, Sidecars: []string{"logo.png", "lobby.vta"}}
+	require.NoError(t, stageIfReadOnly(cfg, project, readOnly, logger.NewNoOpLogger()))
+
+	assert.True(t, cfg.Isolate)
+	assert.Equal(t, []string{"logo.png", "lobby.vta", "lobby Files"}, cfg.Sidecars,
+		"the sidecars found are added to those given, once each")
+}
+
+func TestRunPreflight_IsolatedSkipsProjectDirectory(t *testing.T) {
+	t.Parallel()
+
+	// A file in place of the project's directory would fail the check if it were probed
+	parent := t.TempDir()
+	notADir := filepath.Join(parent, "share")
+	require.NoError(t, os.WriteFile(notADir, nil, 0o644))
+
+	cfg := &Config{Isolate: true, OutDir: filepath.Join(t.TempDir(), "builds")}
+
+	err := runPreflight(cfg, filepath.Join(notADir, "lobby.vtp"), fixedSpace(0), logger.NewNoOpLogger())
+	require.NoError(t, err)
+}
+
+func TestCheckSidecars(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	project := filepath.Join(dir, "lobby.vtp")
+	require.NoError(t, os.WriteFile(project, []byte("project"), 0o644))
+
+	log := testutil.NewMockLogger()
+	require.NoError(t, checkSidecars(&Config{}, project, project, log), "a missing sidecar only warns by default")
+	assert.Contains(t, log.Messages(), "Project sidecar is missing, VTPro will report missing resources")
+
+	err := checkSidecars(&Config{RequireSidecars: true}, project, project, logger.NewNoOpLogger())
+	require.ErrorIs(t, err, preflight.ErrMissingSidecars)
+	assert.Contains(t, err.Error(), filepath.Join(dir, "lobby.vta"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lobby.vta"), []byte("sidecar"), 0o644))
+	assert.NoError(t, checkSidecars(&Config{RequireSidecars: true}, project, project, logger.NewNoOpLogger()))
+}
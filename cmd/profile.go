@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/profiling"
+)
+
+// serveDebug serves net/http/pprof for a long-running vtpc on the loopback
+// interface, returning a function that stops it
+func serveDebug(port int, log logger.LoggerInterface) (stop func(), err error) {
+	if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("%w: --pprof-port must be between 1 and 65535, got %d", ErrInvalidFlags, port)
+	}
+
+	addr, stop, err := profiling.Serve(port)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("pprof debug endpoint listening", slog.String("url", "http://"+addr+"/debug/pprof/"))
+
+	return stop, nil
+}
+
+// startProfiles starts the profiles asked for with --profile. The returned stop
+// writes them out; only its first call does anything, so it is deferred and
+// also called on the ways out of a run that never return, such as an abort.
+func startProfiles(values []string, log logger.LoggerInterface) (stop func(), err error) {
+	if len(values) == 0 {
+		return func() {}, nil
+	}
+
+	specs, err := profiling.ParseSpecs(values)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := profiling.Start(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug("Profiling vtpc", slog.Any("profiles", values))
+
+	return sync.OnceFunc(func() {
+		if err := session.Stop(); err != nil {
+			log.Warn("Could not write profile", slog.Any("error", err))
+			return
+		}
+
+		for _, spec := range specs {
+			log.Info("Profile written", slog.String("kind", string(spec.Kind)), slog.String("path", spec.Path))
+		}
+	}), nil
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+)
+
+// requireProfile checks a profile file is non-empty and pprof can read it
+func requireProfile(t *testing.T, path string) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotEmpty(t, data, "%s is empty", path)
+
+	_, err = profile.ParseData(data)
+	require.NoError(t, err)
+}
+
+func TestStartProfiles_NoneIsANoOp(t *testing.T) {
+	t.Parallel()
+
+	stop, err := startProfiles(nil, logger.NewNoOpLogger())
+	require.NoError(t, err)
+	stop()
+}
+
+func TestStartProfiles_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := startProfiles([]string{"block=vtpc.pprof"}, logger.NewNoOpLogger())
+	assert.ErrorContains(t, err, `kind must be "cpu" or "mem"`)
+}
+
+func TestStartProfiles_StopWritesOnce(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "mem.pprof")
+	log := testutil.NewMockLogger()
+
+	stop, err := startProfiles([]string{"mem=" + path}, log)
+	require.NoError(t, err)
+
+	stop()
+	stop()
+
+	requireProfile(t, path)
+
+	written := 0
+	for _, m := range log.Messages() {
+		if m == "Profile written" {
+			written++
+		}
+	}
+
+	assert.Equal(t, 1, written)
+}
+
+// TestExecutionContext_AbortWritesProfiles tests that a signal-driven exit, which
+// never returns to Execute's deferred stop, still writes the profiles out. It is
+// not parallel: only one CPU profile can run per process.
+func TestExecutionContext_AbortWritesProfiles(t *testing.T) {
+	t.Setenv("VTPRO_PATH", "")
+
+	dir := t.TempDir()
+	cpuPath := filepath.Join(dir, "cpu.pprof")
+	memPath := filepath.Join(dir, "mem.pprof")
+
+	stop, err := startProfiles([]string{"cpu=" + cpuPath, "mem=" + memPath}, logger.NewNoOpLogger())
+	require.NoError(t, err)
+
+	exited := false
+	ctx := &ExecutionContext{
+		log:          logger.NewNoOpLogger(),
+		vtproClient:  vtpro.NewClient(logger.NewNoOpLogger()),
+		exitFunc:     func(int) { exited = true },
+		stopProfiles: stop,
+	}
+
+	ctx.abort()
+
+	assert.True(t, exited)
+	requireProfile(t, cpuPath)
+	requireProfile(t, memPath)
+}
+
+func TestServeDebug(t *testing.T) {
+	t.Parallel()
+
+	_, err := serveDebug(70000, logger.NewNoOpLogger())
+	require.ErrorIs(t, err, ErrInvalidFlags)
+
+	log := testutil.NewMockLogger()
+	stop, err := serveDebug(0, log)
+	require.NoError(t, err)
+	defer stop()
+
+	require.NotEmpty(t, log.Entries)
+
+	attr, ok := log.Entries[len(log.Entries)-1].Args[0].(slog.Attr)
+	require.True(t, ok)
+
+	resp, err := http.Get(attr.Value.String())
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
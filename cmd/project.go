@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/config"
+	"github.com/Norgate-AV/vtpc/internal/deploy"
+)
+
+// projectSetting is a flag the project file can also give
+type projectSetting struct {
+	flag  string
+	value func(p *config.ProjectFile) (string, bool) // The project file's value as the flag shows it, and whether it gives one
+	apply func(cfg *Config, p *config.ProjectFile)
+}
+
+// projectSettings is every flag the project file can give, other than
+// --ignore-pages, whose pages add up across sources
+var projectSettings = []projectSetting{
+	{
+		flag:  "expect-title",
+		value: func(p *config.ProjectFile) (string, bool) { return p.ExpectTitle, p.ExpectTitle != "" },
+		apply: func(cfg *Config, p *config.ProjectFile) { cfg.ExpectTitle = p.ExpectTitle },
+	},
+	{
+		flag:  "out-dir",
+		value: func(p *config.ProjectFile) (string, bool) { return p.OutDir, p.OutDir != "" },
+		apply: func(cfg *Config, p *config.ProjectFile) { cfg.OutDir = p.OutDir },
+	},
+	{
+		flag:  "max-compile-time",
+		value: func(p *config.ProjectFile) (string, bool) { return p.MaxCompileTime.String(), p.MaxCompileTime != 0 },
+		apply: func(cfg *Config, p *config.ProjectFile) { cfg.MaxCompileTime = p.MaxCompileTime },
+	},
+	{
+		flag:  "deploy",
+		value: func(p *config.ProjectFile) (string, bool) { return p.Deploy, p.Deploy != "" },
+		apply: func(cfg *Config, p *config.ProjectFile) { cfg.Deploy = p.Deploy },
+	},
+}
+
+// loadProjectFile reads the project file next to the project being compiled,
+// checks its values and gives cfg the settings no flag gave. A run without a
+// project, or a project without a project file, is left as it is.
+func loadProjectFile(cfg *Config) error {
+	if cfg.FilePath == "" {
+		return nil
+	}
+
+	project, err := config.LoadProject(cfg.FilePath)
+	if err != nil {
+		return err
+	}
+
+	if err := validateProjectFile(project); err != nil {
+		return err
+	}
+
+	applyProjectFile(cfg, project)
+
+	return nil
+}
+
+// validateProjectFile checks the project file's values as the flags they stand
+// for are checked, returning every problem found
+func validateProjectFile(p *config.ProjectFile) error {
+	var errs []error
+
+	if p.MaxCompileTime < 0 {
+		errs = append(errs, p.KeyError("maxCompileTime", fmt.Errorf("must not be negative, got %s", p.MaxCompileTime)))
+	}
+
+	if _, err := compiler.NewPageFilter(p.IgnorePages); err != nil {
+		errs = append(errs, p.KeyError("ignorePages", err))
+	}
+
+	if p.Deploy != "" {
+		// As with --deploy, the password may come from the environment
+		if _, err := deploy.ParseTarget(p.Deploy, func(string) string { return "" }); err != nil {
+			errs = append(errs, p.KeyError("deploy", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// projectFile returns the project file applied to cfg, or an empty one if none was
+func (c *Config) projectFile() *config.ProjectFile {
+	if c.project == nil {
+		return &config.ProjectFile{}
+	}
+
+	return c.project
+}
+
+// applyProjectFile gives cfg each of the project file's settings whose flag
+// was not given on the command line
+func applyProjectFile(cfg *Config, p *config.ProjectFile) {
+	cfg.project = p
+
+	for _, s := range projectSettings {
+		if _, ok := s.value(p); ok && !cfg.isSet(s.flag) {
+			s.apply(cfg, p)
+		}
+	}
+}
+
+// fromProjectFile reports whether the project file gave cfg the flag's value
+func (c *Config) fromProjectFile(flag string) bool {
+	for _, s := range projectSettings {
+		if s.flag == flag {
+			_, ok := s.value(c.projectFile())
+			return ok && !c.isSet(flag)
+		}
+	}
+
+	return false
+}
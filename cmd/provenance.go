@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/user"
+
+	"github.com/Norgate-AV/vtpc/internal/artifact"
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/version"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// newProvenance fills in the provenance every artifact of a compile shares:
+// the source, the versions, the run and where it ran
+func newProvenance(source, runID string, result *compiler.CompileResult, log logger.LoggerInterface) report.Provenance {
+	p := report.Provenance{
+		Source:      source,
+		VtpcVersion: version.GetVersion(),
+		RunID:       runID,
+		StartedAt:   report.Timestamp{Time: result.StartedAt},
+		FinishedAt:  report.Timestamp{Time: result.FinishedAt},
+		Warnings:    result.Warnings,
+		Errors:      result.Errors,
+	}
+
+	var err error
+	if p.VTProVersion, err = windows.FileVersion(vtpro.GetVTProPath()); err != nil {
+		log.Debug("Could not read the VTPro version for provenance", slog.Any("error", err))
+	}
+
+	p.Machine, _ = os.Hostname()
+
+	if u, err := user.Current(); err == nil {
+		p.User = u.Username
+	} else {
+		p.User = os.Getenv("USERNAME")
+	}
+
+	return p
+}
+
+// recordProvenance hashes the source and each artifact and writes a provenance
+// sidecar beside every artifact, returning what was written for the reports
+func recordProvenance(base report.Provenance, artifacts []string, log logger.LoggerInterface) ([]report.Provenance, error) {
+	sourceSum, err := artifact.HashFile(base.Source)
+	if err != nil {
+		return nil, fmt.Errorf("recording provenance: %w", err)
+	}
+
+	records := make([]report.Provenance, 0, len(artifacts))
+
+	for _, file := range artifacts {
+		p := base
+		p.Artifact = file
+		p.SourceSHA256 = sourceSum
+
+		if p.ArtifactSHA256, err = artifact.HashFile(file); err != nil {
+			return records, fmt.Errorf("recording provenance: %w", err)
+		}
+
+		path, err := artifact.WriteProvenance(p)
+		if err != nil {
+			return records, fmt.Errorf("recording provenance of %s: %w", file, err)
+		}
+
+		log.Info("Provenance written", slog.String("path", path), slog.String("sha256", p.ArtifactSHA256))
+		records = append(records, p)
+	}
+
+	return records, nil
+}
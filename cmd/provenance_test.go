@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/artifact"
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/version"
+)
+
+func TestNewProvenance(t *testing.T) {
+	t.Parallel()
+
+	started := time.Date(2025, 3, 4, 9, 15, 0, 0, time.UTC)
+	result := &compiler.CompileResult{Warnings: 2, Errors: 0, StartedAt: started, FinishedAt: started.Add(time.Minute)}
+
+	p := newProvenance(`C:\Projects\lobby.vtp`, "20250304T091500-a1b2c3", result, logger.NewNoOpLogger())
+
+	assert.Equal(t, `C:\Projects\lobby.vtp`, p.Source)
+	assert.Equal(t, "20250304T091500-a1b2c3", p.RunID)
+	assert.Equal(t, version.GetVersion(), p.VtpcVersion)
+	assert.Equal(t, started, p.StartedAt.Time)
+	assert.Equal(t, started.Add(time.Minute), p.FinishedAt.Time)
+	assert.Equal(t, 2, p.Warnings)
+	assert.NotEmpty(t, p.Machine)
+	assert.Empty(t, p.Artifact, "filled in per artifact")
+}
+
+func TestRecordProvenance(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "lobby.vtp")
+	vtz := filepath.Join(dir, "lobby.vtz")
+	require.NoError(t, os.WriteFile(source, []byte("abc"), 0o644))
+	require.NoError(t, os.WriteFile(vtz, []byte("panel"), 0o644))
+
+	base := report.Provenance{Source: source, RunID: "run-1", Warnings: 1}
+
+	records, err := recordProvenance(base, []string{vtz}, logger.NewNoOpLogger())
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	assert.Equal(t, vtz, records[0].Artifact)
+	assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", records[0].SourceSHA256)
+	assert.Len(t, records[0].ArtifactSHA256, 64)
+	assert.Equal(t, "run-1", records[0].RunID)
+	assert.FileExists(t, artifact.ProvenancePath(vtz))
+
+	// The same block goes into the run's reports
+	run := buildRun(report.Summary{}, runOutcome{provenance: records})
+	assert.Equal(t, records, run.Provenance)
+}
+
+func TestRecordProvenance_MissingArtifact(t *testing.T) {
+	t.Parallel()
+
+	source := filepath.Join(t.TempDir(), "lobby.vtp")
+	require.NoError(t, os.WriteFile(source, []byte("abc"), 0o644))
+
+	_, err := recordProvenance(report.Provenance{Source: source}, []string{filepath.Join(t.TempDir(), "lobby.vtz")}, logger.NewNoOpLogger())
+	assert.ErrorContains(t, err, "recording provenance")
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/recording"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+)
+
+// replayGrace is how long a replayed compile may run past the end of the recording
+// before it times out, as it would have waited for VTPro
+const replayGrace = 10 * time.Second
+
+// replayCmd plays a --record-events recording back through the compiler without VTPro
+var replayCmd = &cobra.Command{
+	Use:   "replay <recording>",
+	Short: "Replay a --record-events recording and show what vtpc would have done",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := NewConfigFromFlags(cmd)
+		speed, _ := cmd.Flags().GetFloat64("speed")
+
+		entries, err := recording.Load(args[0])
+		if err != nil {
+			return err
+		}
+
+		log, err := initializeLogger(cfg)
+		if err != nil {
+			return err
+		}
+
+		defer log.Close()
+
+		configFile, err := loadConfigFile(cfg, log)
+		if err != nil {
+			return err
+		}
+
+		return replay(cmd.OutOrStdout(), args[0], entries, speed, cfg.StrictDialogs, buildWindowIdentity(cfg, configFile), log)
+	},
+}
+
+func init() {
+	replayCmd.Flags().Float64("speed", 1, "replay this many times faster than recorded")
+	RootCmd.AddCommand(replayCmd)
+}
+
+// replay feeds a recording to the compiler and writes the actions it took and its
+// result. id recognises VTPro's main window in a recording that does not mark it.
+func replay(w io.Writer, name string, entries []recording.Entry, speed float64, strictDialogs bool, id vtpro.WindowIdentity, log logger.LoggerInterface) error {
+	player, err := recording.NewPlayer(entries, speed, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	defer player.Stop()
+
+	hwnd, pid := player.MainWindow()
+
+	comp := compiler.NewCompiler(log,
+		compiler.WithWindowManager(player),
+		compiler.WithKeyboard(player),
+		compiler.WithControlReader(player),
+		compiler.WithMonitorSource(player.Events),
+	)
+
+	result, compileErr := comp.Compile(compiler.CompileOptions{
+		FilePath:                      name,
+		Hwnd:                          hwnd,
+		VTProPid:                      pid,
+		SkipPreCompilationDialogCheck: true,
+		CompilationTimeout:            player.Duration() + replayGrace,
+		StrictDialogs:                 strictDialogs,
+	})
+
+	writeReplay(w, name, len(entries), player.Actions(), result, compileErr)
+
+	return nil
+}
+
+// writeReplay writes the actions taken during a replay, then how the compile ended
+func writeReplay(w io.Writer, name string, entries int, actions []recording.Action, result *compiler.CompileResult, err error) {
+	fmt.Fprintf(w, "Replayed %d events from %s\n\n", entries, name)
+
+	for _, a := range actions {
+		fmt.Fprintf(w, "  +%5.1fs  %s\n", a.At.Seconds(), a.Text)
+	}
+
+	fmt.Fprintln(w)
+
+	if result != nil {
+		fmt.Fprintf(w, "Result: %d warning(s), %d error(s)\n", result.Warnings, result.Errors)
+	}
+
+	if err != nil {
+		fmt.Fprintf(w, "Stopped: %v\n", err)
+	}
+}
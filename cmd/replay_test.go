@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/recording"
+)
+
+func TestWriteReplay(t *testing.T) {
+	actions := []recording.Action{
+		{At: 0, Text: "send F12"},
+		{At: 1500 * time.Millisecond, Text: `close "Address Book" (0x3333)`},
+	}
+
+	tests := []struct {
+		name   string
+		result *compiler.CompileResult
+		err    error
+		want   string
+	}{
+		{
+			name:   "compile finished",
+			result: &compiler.CompileResult{Warnings: 1},
+			want:   "Result: 1 warning(s), 0 error(s)\n",
+		},
+		{
+			name:   "stopped at a dialog",
+			result: &compiler.CompileResult{Errors: 1, HasErrors: true},
+			err:    fmt.Errorf("%w %q: Your evaluation period has ended.", compiler.ErrUnexpectedDialog, "Trial expired"),
+			want:   "Result: 0 warning(s), 1 error(s)\nStopped: unexpected dialog \"Trial expired\": Your evaluation period has ended.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writeReplay(&buf, "run.jsonl", 9, actions, tt.result, tt.err)
+
+			assert.Equal(t, "Replayed 9 events from run.jsonl\n\n"+
+				"  +  0.0s  send F12\n"+
+				"  +  1.5s  close \"Address Book\" (0x3333)\n\n"+
+				tt.want, buf.String())
+		})
+	}
+}
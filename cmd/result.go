@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+// What --output has stdout carry
+const (
+	outputText = "text" // The banner, messages and result line, as a person reads them
+	outputJSON = "json" // Only the run as a report.Document; the rest goes to stderr
+)
+
+// resultRun returns the run the result line reports: the one the banner
+// reported, or, when the run failed before it had a banner, what was known then
+func resultRun(reported *report.Run, err error, project string, elapsed time.Duration) *report.Run {
+	if reported != nil {
+		return reported
+	}
+
+	return &report.Run{
+		Project: project,
+		Summary: report.Summary{Cause: classifyFailure(err, nil), Code: string(failureCode(err, nil)), Err: err, Duration: elapsed},
+	}
+}
+
+// writeResultLine writes the vtpc-result line. It must be the last thing a run
+// writes to the console, so scripts can read it with a tail or a grep.
+func writeResultLine(w io.Writer, status string, run *report.Run) {
+	fmt.Fprintln(w, report.ResultLine(status, run))
+}
+
+// finishOutput ends a run's output with the result line and, with --output
+// json, the run as a JSON document on stdout, and returns the run's status
+func finishOutput(cfg *Config, stdout io.Writer, run *report.Run) string {
+	status := report.StatusFor(run.Summary.Cause)
+	writeResultLine(consoleOut, status, run)
+
+	if cfg.Output == outputJSON {
+		writeResultDocument(stdout, run)
+	}
+
+	return status
+}
+
+// writeResultDocument writes the run as the same JSON document as the json
+// report, so a pipeline can read the result with jq
+func writeResultDocument(w io.Writer, run *report.Run) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	// A Document always encodes; a closed pipe is the reader's choice
+	_ = enc.Encode(report.NewDocument(run))
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/deploy"
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/preflight"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+)
+
+func TestResultRun_FailurePaths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+		code errcode.Code
+	}{
+		{"invalid flags", fmt.Errorf("%w: --sidecar requires --isolate", ErrInvalidFlags), report.StatusFailed, errcode.InvalidFlags},
+		{"missing file path", errors.New("file path required"), report.StatusFailed, errcode.Unknown},
+		{"VTPro not installed", vtpro.ErrVTProNotFound, report.StatusFailed, errcode.VTProMissing},
+		{"VTPro not ready", errVTProNotReady, report.StatusFailed, errcode.VTProNotReady},
+		{"low disk space", preflight.ErrLowDiskSpace, report.StatusFailed, errcode.DiskSpace},
+		{"compile timeout", fmt.Errorf("waiting for compile: %w", compiler.ErrCompileTimeout), report.StatusTimeout, errcode.TimeoutCompile},
+		{"compile cancelled", compiler.ErrCompileCancelled, report.StatusCancelled, errcode.Cancelled},
+		{"panic", &PanicError{Value: "index out of range"}, report.StatusFailed, errcode.Internal},
+		{"deploy failed", &ExitError{Code: ExitDeploy, Err: deploy.ErrDeployFailed}, report.StatusFailed, errcode.DeployFailed},
+		{"over budget", &ExitError{Code: ExitBudget, Err: ErrBudgetExceeded}, report.StatusFailed, errcode.BudgetExceeded},
+		{"success", nil, report.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			run := resultRun(nil, tt.err, "lobby.vtp", 1500*time.Millisecond)
+			writeResultLine(&buf, report.StatusFor(run.Summary.Cause), run)
+
+			want := fmt.Sprintf(`vtpc-result status=%s file="lobby.vtp" errors=0 warnings=0 duration=1.5s artifact="" code="%s"`+"\n", tt.want, tt.code)
+			assert.Equal(t, want, buf.String())
+		})
+	}
+}
+
+func TestResultRun_UsesTheReportedRun(t *testing.T) {
+	t.Parallel()
+
+	reported := &report.Run{Project: "lobby.vtp", Errors: 2, Summary: report.Summary{Cause: report.CauseCompileErrors}}
+
+	assert.Same(t, reported, resultRun(reported, errors.New("compilation failed"), "other.vtp", time.Second))
+}
+
+// TestExecute_ResultLineIsLast runs a compile that fails before launching VTPro
+// and checks its output still ends with the result line
+func TestExecute_ResultLineIsLast(t *testing.T) {
+	resetFlags()
+
+	vtpFile := filepath.Join(t.TempDir(), "living room.vtp")
+	require.NoError(t, os.WriteFile(vtpFile, []byte("test"), 0o644))
+
+	var buf bytes.Buffer
+	oldOut := consoleOut
+	consoleOut = &buf
+
+	t.Cleanup(func() {
+		consoleOut = oldOut
+		_ = RootCmd.PersistentFlags().Set("keep-temp-on-failure", "false")
+		RootCmd.SetArgs(nil)
+	})
+
+	// --keep-temp-on-failure without --isolate fails flag validation
+	RootCmd.SetArgs([]string{"--keep-temp-on-failure", vtpFile})
+	err := RootCmd.Execute()
+	require.ErrorIs(t, err, ErrInvalidFlags)
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	assert.Regexp(t, `^vtpc-result status=failed file="living room.vtp" errors=0 warnings=0 duration=\d+\.\ds artifact="" code="VTPC_E_INVALID_FLAGS"$`, lines[len(lines)-1])
+}
+
+// TestFinishOutput_JSON checks --output json puts the run on stdout as a JSON
+// document and leaves the result line on the console
+func TestFinishOutput_JSON(t *testing.T) {
+	var console, stdout bytes.Buffer
+	oldOut := consoleOut
+	consoleOut = &console
+
+	t.Cleanup(func() { consoleOut = oldOut })
+
+	run := &report.Run{
+		Project:     `C:\Projects\lobby.vtp`,
+		Summary:     report.Summary{Cause: report.CauseCompileErrors, Duration: 94 * time.Second},
+		Warnings:    2,
+		Errors:      1,
+		ProjectSize: "12 Kb",
+		Messages:    []report.Message{{Severity: "error", Text: "Join 12 is undefined", Target: "TSW-770"}},
+	}
+
+	status := finishOutput(&Config{Output: outputJSON}, &stdout, run)
+	assert.Equal(t, report.StatusFailed, status)
+	assert.True(t, strings.HasPrefix(console.String(), report.ResultPrefix+" status=failed"), "the result line stays on the console")
+
+	var doc report.Document
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc), "stdout is a single JSON document")
+	assert.Equal(t, 1, doc.Errors)
+	assert.Equal(t, 2, doc.Warnings)
+	assert.Equal(t, `C:\Projects\lobby.vtp`, doc.Project)
+	assert.Equal(t, "12 Kb", doc.ProjectSize)
+	assert.InDelta(t, 94.0, doc.DurationSeconds, 0.001)
+	assert.Equal(t, []report.DocumentMessage{{Severity: "error", Text: "Join 12 is undefined", Target: "TSW-770"}}, doc.Messages)
+
+	console.Reset()
+	stdout.Reset()
+
+	finishOutput(&Config{Output: outputText}, &stdout, run)
+	assert.Empty(t, stdout.String(), "text output writes nothing past the console")
+	assert.NotEmpty(t, console.String())
+}
@@ -1,33 +1,58 @@
 package cmd
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
-	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
+	"github.com/Norgate-AV/vtpc/internal/artifact"
+	"github.com/Norgate-AV/vtpc/internal/clock"
 	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/config"
+	"github.com/Norgate-AV/vtpc/internal/deploy"
+	"github.com/Norgate-AV/vtpc/internal/diagfiles"
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/errcode"
 	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/output"
+	"github.com/Norgate-AV/vtpc/internal/pathutil"
+	"github.com/Norgate-AV/vtpc/internal/preflight"
+	"github.com/Norgate-AV/vtpc/internal/recording"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/schedule"
+	"github.com/Norgate-AV/vtpc/internal/secret"
 	"github.com/Norgate-AV/vtpc/internal/timeouts"
 	"github.com/Norgate-AV/vtpc/internal/version"
+	"github.com/Norgate-AV/vtpc/internal/vtpfile"
 	"github.com/Norgate-AV/vtpc/internal/vtpro"
 	"github.com/Norgate-AV/vtpc/internal/windows"
+	"github.com/Norgate-AV/vtpc/internal/workspace"
 )
 
 // ExecutionContext holds state needed throughout the compilation process
 // and for cleanup in signal handlers.
 type ExecutionContext struct {
-	vtproHwnd   uintptr
-	vtproPid    uint32
-	log         logger.LoggerInterface
-	vtproClient *vtpro.Client
-	exitFunc    func(int) // Injectable for testing; defaults to os.Exit
+	vtproHwnd    uintptr
+	vtproPid     uint32
+	log          logger.LoggerInterface
+	vtproClient  *vtpro.Client
+	exitFunc     func(int) // Injectable for testing; defaults to os.Exit
+	onAbort      func()    // Extra cleanup run after VTPro is closed on abort, may be nil
+	stopProfiles func()    // Writes out the --profile profiles before an abort exits, may be nil
 }
 
 // CompilationParams holds parameters for running compilation
@@ -37,12 +62,21 @@ type CompilationParams struct {
 	Pid      uint32
 	PidPtr   *uint32
 	Config   *Config
+	Parser   compiler.ParserOptions
+	Order    compiler.MessageOrder
+	Format   compiler.MessageFormat
 	Logger   logger.LoggerInterface
+	Recorder *recording.Recorder        // Records the controls vtpc reads, nil unless --record-events is set
+	Sleep    clock.SleepPolicy          // What the compile timeout does if the system sleeps
+	OnStep   func(compiler.StepOutcome) // Called as each compile step ends, may be nil
+
+	Input  compiler.InputMethod // How the compile keystroke is sent
+	Delays timeouts.Input       // Delays around the injected keystrokes
 }
 
 // RootCmd is the root command for the vtpc CLI application.
 var RootCmd = &cobra.Command{
-	Use:          "vtpc <file-path>",
+	Use:          "vtpc <file-path>...",
 	Short:        "vtpc - Automate compilation of .vtp files",
 	Version:      version.GetVersion(),
 	Args:         validateArgs,
@@ -55,8 +89,71 @@ func init() {
 	RootCmd.SetVersionTemplate(`{{printf "%s\n" .Version}}`)
 
 	// Add flags
-	RootCmd.PersistentFlags().BoolP("verbose", "V", false, "enable verbose output")
-	RootCmd.PersistentFlags().BoolP("logs", "l", false, "print the current log file to stdout and exit")
+	addRunFlags(RootCmd.PersistentFlags())
+
+	// List flags in help by what they affect
+	flags := RootCmd.PersistentFlags()
+	setFlagGroup(flags, flagGroupInput, "config", "isolate", "sidecar", "require-sidecars", "save-first", "launch-minimized", "expect-title", "main-window-class", "strict-parse", "ignore-pages")
+	setFlagGroup(flags, flagGroupOutput, "out", "message-link-template", "out-dir", "keep-temp-on-failure", "verify-artifact", "provenance", "deploy", "deploy-password", "message-order", "format", "output", "absolute-times", "pause", "bell")
+	setFlagGroup(flags, flagGroupAutomation, "cancel-file", "cancel-poll-interval", "heartbeat", "max-compile-time", "slow-dialog", "on-sleep", "wait-for-window", "ignore-schedule", "min-free-mb", "force-cleanup", "never-terminate", "strict-dialogs", "input-method", "slow-input-multiplier", "no-input-adapt")
+	setFlagGroup(flags, flagGroupLogging, "verbose", "logs", "live-log", "record-events", "trace-win32", "eventlog", "profile")
+
+	// Only for a batch, so not passed on to each of its compiles
+	addBatchFlags(RootCmd.Flags())
+	setFlagGroup(RootCmd.Flags(), flagGroupInput, "exclude")
+	setFlagGroup(RootCmd.Flags(), flagGroupAutomation, "state", "resume", "strict-inputs")
+	RootCmd.SetUsageTemplate(groupFlagsInTemplate(RootCmd.UsageTemplate()))
+}
+
+// addRunFlags registers the flags of a single-project run on flags. RootCmd
+// takes them, and so does each compile the daemon runs.
+func addRunFlags(flags *pflag.FlagSet) {
+	flags.BoolP("verbose", "V", false, "enable verbose output")
+	flags.BoolP("logs", "l", false, "print the current log file to stdout and exit")
+	flags.String("record-events", "", "record every window event to a JSONL file that vtpc replay can play back")
+	flags.Bool("eventlog", false, "write a summary of the run to the Windows Application event log as source \"vtpc\"")
+	flags.String("config", "", "path to the config file (default: config.yaml next to the log file)")
+	flags.String("cancel-file", "", "abort the run when this file appears (it is deleted when detected)")
+	flags.Duration("cancel-poll-interval", defaultCancelPollInterval, "how often to check for the cancel file")
+	flags.Bool("isolate", false, "compile a copy of the project in a temporary directory")
+	flags.StringSlice("sidecar", nil, "file or directory next to the project to copy with --isolate (repeatable)")
+	flags.String("out-dir", "", "directory to copy the compiled artifact to (default: next to the project)")
+	flags.Bool("keep-temp-on-failure", false, "keep the --isolate directory when the compile fails")
+	flags.Bool("provenance", false, "write <artifact>.provenance.json beside each compiled artifact, tracing it to its source and this run")
+	flags.Bool("verify-artifact", false, "check the compiled .vtz is a well-formed archive and fail if it is corrupt")
+	flags.String("deploy", "", "upload the compiled artifact to ftp://user@host/dir or sftp://user@host/dir (password from "+deploy.PasswordEnv+")")
+	flags.String("deploy-password", "", "read the --deploy password from env:NAME, file:PATH or stdin:")
+	flags.Bool("save-first", false, "save the project (Ctrl+S) before compiling")
+	flags.Bool("launch-minimized", false, "keep VTPro minimized except while the compile keystroke is sent")
+	flags.Bool("force-cleanup", false, "force terminate a VTPro that will not close without asking first")
+	flags.Bool("never-terminate", false, "leave a VTPro that will not close running and report its PID, never force terminating it")
+	flags.Bool("strict-dialogs", false, "fail on any unknown dialog during the compile and leave it open for inspection")
+	flags.String("expect-title", "", "only accept a VTPro main window whose title contains this text")
+	flags.String("main-window-class", "", "window class of the VTPro main window, for VTPro builds that use another (default \""+vtpro.DefaultMainWindowClass+"\")")
+	flags.Bool("require-sidecars", false, "fail before launching VTPro if the project's .vta or resource directory is missing")
+	flags.Bool("strict-parse", false, "warn about counts and sizes that are not plain English numbers")
+	flags.StringArray("ignore-pages", nil, "leave messages on pages matching this case-insensitive glob out of the results, e.g. \"ZZ_*\" (repeatable)")
+	flags.StringArray("out", nil, "write a report as format=path, e.g. text=report.txt (repeatable)")
+	flags.String("message-link-template", "", "link each reported message to its source, e.g. \"vtpro://open?project={project}&page={page}&object={object}\"")
+	flags.StringArray("profile", nil, "write a pprof profile of vtpc itself covering the whole run: cpu=path or mem=path (repeatable)")
+	flags.Bool("wait-for-window", false, "outside the config file's maintenance window, wait for it to open rather than fail")
+	flags.Bool("ignore-schedule", false, "compile now even outside the config file's maintenance window")
+	flags.Uint("min-free-mb", preflight.DefaultMinFreeMB, "free disk space in MB required on the project and --out-dir drives (0 to skip)")
+	flags.Duration("max-compile-time", 0, "fail with exit code 6 once a compile that took longer than this finishes (0 to disable)")
+	flags.String("on-sleep", string(clock.SleepExtend), "what waits do if the system sleeps mid-run: \"extend\" their timeouts by the sleep, \"fail\" or \"ignore\" it")
+	flags.Duration("heartbeat", compiler.DefaultHeartbeatInterval, "how often to log \"still compiling\" during a compile (0 to disable)")
+	flags.Duration("slow-dialog", timeouts.SlowDialogThreshold, "warn when a VTPro dialog other than the Compiling dialog stays open longer than this (0 to disable)")
+	flags.String("input-method", string(compiler.InputAuto), "how the compile keystroke is sent: \"auto\" (SendInput, then keybd_event), \"sendinput\", \"keybd_event\" or \"postmessage\"")
+	flags.Float64("slow-input-multiplier", defaultSlowInputMultiplier, "lengthen the delays around injected keystrokes by this factor in a virtual machine or remote session")
+	flags.Bool("no-input-adapt", false, "keep the default keystroke delays even in a virtual machine or remote session")
+	flags.Bool("trace-win32", false, "log every Win32 call made to drive VTPro, with its arguments and result, to the log file")
+	flags.Bool("live-log", false, "print lines as VTPro adds them to the Message Log during the compile")
+	flags.String("message-order", "severity", "print messages grouped by \"severity\" or in \"log\" order")
+	flags.String("format", "list", "print messages as a numbered \"list\" or an aligned \"table\"")
+	flags.StringP("output", "o", outputText, "what stdout carries: \"text\", or \"json\" for the result as one JSON document, with everything else on stderr")
+	flags.Bool("absolute-times", false, "show when the run started and finished in the exit banner")
+	flags.Bool("pause", false, "wait for Enter before exiting, so a console opened for vtpc stays open")
+	flags.Bool("bell", false, "play the system asterisk sound when the run succeeds and the exclamation sound when it fails")
 }
 
 // validateArgs validates that a .vtp file argument is provided (if any args given)
@@ -66,18 +163,42 @@ func validateArgs(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Validate .vtp file argument
-	if err := cobra.ExactArgs(1)(cmd, args); err != nil {
-		return err
-	}
+	// Paths pasted from a browser or PowerShell are checked as vtpc will open them.
+	// A directory stands for the projects under it.
+	for _, arg := range args {
+		if path, _ := pathutil.Normalize(arg, pathutil.OSEnv()); filepath.Ext(path) != ".vtp" && !isDir(path) {
+			if len(args) > 1 {
+				return fmt.Errorf("%s: file must have .vtp extension", arg)
+			}
 
-	if filepath.Ext(args[0]) != ".vtp" {
-		return fmt.Errorf("file must have .vtp extension")
+			return fmt.Errorf("file must have .vtp extension")
+		}
 	}
 
 	return nil
 }
 
+// compilesAsBatch reports whether args name several projects, a wildcard
+// such as Panels\*.vtp or a directory, which vtpc compiles as vtpc batch would
+func compilesAsBatch(args []string) bool {
+	if len(args) != 1 {
+		return len(args) > 1
+	}
+
+	path, _ := pathutil.Normalize(args[0], pathutil.OSEnv())
+	if _, err := os.Lstat(path); err == nil {
+		return isDir(path)
+	}
+
+	return strings.ContainsAny(path, "*?[")
+}
+
+// isDir reports whether path is a directory, following links
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
 // handleLogsFlag processes the --logs flag and exits if needed
 func handleLogsFlag(cfg *Config, exitFunc func(int)) error {
 	if !cfg.ShowLogs {
@@ -99,19 +220,233 @@ func handleLogsFlag(cfg *Config, exitFunc func(int)) error {
 	return nil // Won't actually reach here due to exitFunc
 }
 
+// errLogUnavailable is returned when the log file cannot be opened
+var errLogUnavailable = errcode.New(errcode.LogUnavailable, "failed to create logger")
+
+// errElevation is returned when vtpc cannot relaunch itself as administrator
+var errElevation = errcode.New(errcode.Elevation, "error relaunching as admin")
+
+// startupSteps are the steps of a run that depend on the machine and can fail
+// before VTPro is launched, so tests can fail each one
+type startupSteps struct {
+	openLog    func(*Config) (logger.LoggerInterface, error)
+	checkVTPro func() error
+	isElevated func() bool
+	relaunch   func() error
+}
+
+// startup is the real machine's startup steps
+var startup = startupSteps{
+	openLog:    initializeLogger,
+	checkVTPro: vtpro.ValidateVTProInstallation,
+	isElevated: windows.IsElevated,
+	relaunch:   windows.RelaunchAsAdmin,
+}
+
 // initializeLogger creates a logger and logs startup information
 func initializeLogger(cfg *Config) (logger.LoggerInterface, error) {
 	log, err := logger.NewLogger(logger.LoggerOptions{
 		Verbose:  cfg.Verbose,
 		Compress: true,
+		Console:  consoleOut,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create logger: %w", err)
+		return nil, fmt.Errorf("%w: %w", errLogUnavailable, err)
 	}
 
 	return log, nil
 }
 
+// configFilePath returns where the config file is: --config, or the default location
+func configFilePath(cfg *Config) string {
+	if cfg.ConfigPath != "" {
+		return cfg.ConfigPath
+	}
+
+	return config.DefaultPath()
+}
+
+// loadConfigFile loads the config file from the configured or default location
+func loadConfigFile(cfg *Config, log logger.LoggerInterface) (*config.File, error) {
+	path := configFilePath(cfg)
+
+	log.Debug("Loading config file", slog.String("path", path))
+
+	file, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// buildParserOptions converts the parser section of the config file into compiler options
+func buildParserOptions(file *config.File) (compiler.ParserOptions, error) {
+	opts := compiler.ParserOptions{}
+
+	if file.Parser.MaxContinuations < 0 {
+		return opts, fmt.Errorf("config parser.maxContinuations must not be negative, got %d", file.Parser.MaxContinuations)
+	}
+
+	opts.MaxContinuations = file.Parser.MaxContinuations
+
+	for i, pc := range file.Parser.SummaryPatterns {
+		name := pc.Name
+		if name == "" {
+			name = fmt.Sprintf("custom-%d", i+1)
+		}
+
+		pattern, err := compiler.NewSummaryPattern(name, pc.Pattern)
+		if err != nil {
+			return opts, fmt.Errorf("config parser.summaryPatterns[%d]: %w", i, err)
+		}
+
+		opts.SummaryPatterns = append(opts.SummaryPatterns, pattern)
+	}
+
+	policy, err := buildPolicy(file.Rules)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.Policy = policy
+
+	return opts, nil
+}
+
+// buildPageFilter combines the pages ignored in the parser section of the
+// config file and in the project file with those given by --ignore-pages
+func buildPageFilter(cfg *Config, file *config.File) (*compiler.PageFilter, error) {
+	if _, err := compiler.NewPageFilter(file.Parser.IgnorePages); err != nil {
+		return nil, fmt.Errorf("config parser.ignorePages: %w", err)
+	}
+
+	patterns, _ := resolveIgnorePages(cfg, file)
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	return compiler.NewPageFilter(patterns)
+}
+
+// resolveIgnorePages returns the config file's ignored pages followed by the
+// project file's and those given with --ignore-pages
+func resolveIgnorePages(cfg *Config, file *config.File) ([]string, config.Source) {
+	project := cfg.projectFile()
+
+	return config.ResolveAll(
+		config.Candidate[[]string]{Source: config.SourceFile, Value: file.Parser.IgnorePages, Set: len(file.Parser.IgnorePages) > 0},
+		config.Candidate[[]string]{Source: config.SourceProject, Value: project.IgnorePages, Set: len(project.IgnorePages) > 0},
+		config.Candidate[[]string]{Source: config.SourceFlag, Value: cfg.IgnorePages, Set: len(cfg.IgnorePages) > 0},
+	)
+}
+
+// buildBell returns whether to play the bell when the run finishes: --bell when
+// it is given, otherwise the notify section of the config file
+func buildBell(cfg *Config, file *config.File) bool {
+	bell, _ := resolveBell(cfg, file)
+	return bell
+}
+
+// resolveBell resolves buildBell's value and where it came from
+func resolveBell(cfg *Config, file *config.File) (bool, config.Source) {
+	return config.Resolve(false,
+		config.Candidate[bool]{Source: config.SourceFlag, Value: cfg.Bell, Set: cfg.isSet("bell")},
+		config.Candidate[bool]{Source: config.SourceFile, Value: file.Notify.Bell, Set: file.Notify.Bell},
+	)
+}
+
+// buildLinkTemplate parses the message link template from --message-link-template,
+// or from the report section of the config file when the flag is not given
+func buildLinkTemplate(cfg *Config, file *config.File) (report.LinkTemplate, error) {
+	template, source := resolveLinkTemplate(cfg, file)
+
+	links, err := report.ParseLinkTemplate(template)
+	if err != nil && source == config.SourceFile {
+		return links, fmt.Errorf("config report.messageLinkTemplate: %w", err)
+	}
+
+	return links, err
+}
+
+// resolveLinkTemplate resolves the unparsed message link template and where it came from
+func resolveLinkTemplate(cfg *Config, file *config.File) (string, config.Source) {
+	return config.Resolve("",
+		config.Candidate[string]{Source: config.SourceFlag, Value: cfg.MessageLinkTemplate, Set: cfg.MessageLinkTemplate != ""},
+		config.Candidate[string]{Source: config.SourceFile, Value: file.Report.MessageLinkTemplate, Set: file.Report.MessageLinkTemplate != ""},
+	)
+}
+
+// buildWindowIdentity returns what VTPro's main window and splash screen are
+// recognised by: --main-window-class, then the vtpro section of the config
+// file, then the defaults
+func buildWindowIdentity(cfg *Config, file *config.File) vtpro.WindowIdentity {
+	id := vtpro.DefaultWindowIdentity()
+	id.MainClass, _ = resolveMainWindowClass(cfg, file)
+	id.SplashTitle, _ = resolveSplashTitle(file)
+	id.SplashClass = file.VTPro.SplashClass
+
+	return id
+}
+
+// resolveMainWindowClass resolves the main window class and where it came from
+func resolveMainWindowClass(cfg *Config, file *config.File) (string, config.Source) {
+	return config.Resolve(vtpro.DefaultMainWindowClass,
+		config.Candidate[string]{Source: config.SourceFlag, Value: cfg.MainWindowClass, Set: cfg.MainWindowClass != ""},
+		config.Candidate[string]{Source: config.SourceFile, Value: file.VTPro.MainWindowClass, Set: file.VTPro.MainWindowClass != ""},
+	)
+}
+
+// resolveSplashTitle resolves the splash screen title and where it came from
+func resolveSplashTitle(file *config.File) (string, config.Source) {
+	return config.Resolve(vtpro.DefaultSplashTitle,
+		config.Candidate[string]{Source: config.SourceFile, Value: file.VTPro.SplashTitle, Set: file.VTPro.SplashTitle != ""},
+	)
+}
+
+// buildPolicy converts the rules section of the config file into a message policy
+func buildPolicy(rules map[string]config.RulePolicies) (*compiler.Policy, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	known := map[string]bool{compiler.RuleUnknown: true}
+	for _, r := range compiler.DefaultRules().Rules() {
+		known[r.ID] = true
+	}
+
+	// Sort the rule IDs so errors and the audit trail do not depend on map order
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var entries []compiler.PolicyEntry
+
+	for _, id := range ids {
+		if !known[id] {
+			return nil, fmt.Errorf("config rules: unknown rule %q", id)
+		}
+
+		for _, p := range rules[id] {
+			entries = append(entries, compiler.PolicyEntry{
+				RuleID:  id,
+				Pages:   p.Pages,
+				Objects: p.Objects,
+				Action:  compiler.Action(p.Action),
+			})
+		}
+	}
+
+	policy, err := compiler.NewPolicy(entries)
+	if err != nil {
+		return nil, fmt.Errorf("config rules: %w", err)
+	}
+
+	return policy, nil
+}
+
 // ensureElevated checks for admin privileges and relaunches if needed
 func ensureElevated(log logger.LoggerInterface) error {
 	return ensureElevatedWithDeps(log, windows.IsElevated, windows.RelaunchAsAdmin, os.Exit)
@@ -131,7 +466,7 @@ func ensureElevatedWithDeps(
 
 		if err := relaunchAsAdmin(); err != nil {
 			log.Error("RelaunchAsAdmin failed", slog.Any("error", err))
-			return fmt.Errorf("error relaunching as admin: %w", err)
+			return fmt.Errorf("%w: %w", errElevation, err)
 		}
 
 		// Exit this instance, the elevated one will continue
@@ -161,28 +496,68 @@ func validateAndResolvePath(filePath string, log logger.LoggerInterface) (string
 }
 
 // launchVTPro launches VTPro, starts monitoring with the PID, and returns cleanup function
-func launchVTPro(vtproClient *vtpro.Client, absPath string, log logger.LoggerInterface) (hwnd uintptr, pid uint32, cleanup func(), err error) {
+// The cleanup function stops the monitor and releases the VTPro process handle;
+// until then, cpu reads the CPU time VTPro has used so far
+// With minimized set, VTPro starts minimized without taking focus from the user's window
+func launchVTPro(vtproClient *vtpro.Client, absPath string, minimized bool, log logger.LoggerInterface) (pid uint32, cpu, cleanup func() time.Duration, err error) {
+	showCmd := windows.SW_SHOWNORMAL
+	if minimized {
+		showCmd = windows.SW_SHOWMINNOACTIVE
+	}
+
 	// Open the file with VTPro application using elevated privileges
-	// SW_SHOWNORMAL = 1
-	log.Debug("Launching VTPro with file", slog.String("path", absPath))
-	pid, err = windows.CreateProcessSimple(vtpro.GetVTProPath(), fmt.Sprintf("%q", absPath), 1, log)
+	log.Debug("Launching VTPro with file", slog.String("path", absPath), slog.Bool("minimized", minimized))
+	proc, err := windows.CreateProcess(vtpro.GetVTProPath(), windows.QuoteArg(absPath), showCmd, log)
 	if err != nil {
-		log.Error("CreateProcessSimple failed", slog.Any("error", err))
-		return 0, 0, nil, fmt.Errorf("error opening file: %w", err)
+		log.Error("CreateProcess failed", slog.Any("error", err))
+		return 0, nil, nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	pid = proc.Pid
+
+	closeHandle := func() {
+		if err := proc.Close(); err != nil {
+			log.Debug("Could not release VTPro process handle", slog.Any("error", err))
+		}
 	}
 
 	log.Info("VTPro process started", slog.Uint64("pid", uint64(pid)))
 
 	// Start background window monitor with the exact PID we just launched
-	stopMonitor := vtproClient.StartMonitoring(pid)
+	stopMonitor, err := vtproClient.StartMonitoring(pid)
+	if errors.Is(err, vtpro.ErrNoMonitorPid) {
+		// Without a PID the compile falls back to keystrokes only, with dialog handling disabled
+		log.Warn("VTPro PID unknown, dialogs will not be handled")
+		stopMonitor, err = func() {}, nil
+	}
+
+	if err != nil {
+		log.Error("Could not start window monitor", slog.Any("error", err))
+		vtproClient.ForceCleanup(0, pid)
+		closeHandle()
+		return 0, nil, nil, err
+	}
+
 	log.Debug("Background window monitor started")
 
-	// Return cleanup function that stops monitor
-	cleanup = func() {
+	cpu = func() time.Duration {
+		used, err := proc.CPUTime()
+		if err != nil {
+			log.Debug("Could not read VTPro CPU time", slog.Any("error", err))
+		}
+
+		return used
+	}
+
+	// Return cleanup function that stops monitor and reports the CPU time VTPro used
+	cleanup = func() time.Duration {
 		stopMonitor()
+		defer closeHandle()
+
+		return cpu()
 	}
 
-	return 0, pid, cleanup, nil
+	return pid, cpu, cleanup, nil
 }
 
 // setupSignalHandlers configures console control and interrupt signal handlers
@@ -196,10 +571,8 @@ func setupSignalHandlers(ctx *ExecutionContext) {
 		)
 
 		ctx.log.Info("Cleaning up after console control event")
-		ctx.vtproClient.ForceCleanup(ctx.vtproHwnd, ctx.vtproPid)
-		ctx.log.Debug("Cleanup completed, exiting")
+		ctx.abort()
 
-		ctx.exitFunc(130)
 		return 1
 	})
 
@@ -212,13 +585,46 @@ func setupSignalHandlers(ctx *ExecutionContext) {
 		ctx.log.Debug("Received signal", slog.Any("signal", sig))
 		ctx.log.Info("Interrupt signal received, starting cleanup")
 
-		ctx.vtproClient.ForceCleanup(ctx.vtproHwnd, ctx.vtproPid)
-
-		ctx.log.Debug("Cleanup completed, exiting")
-		ctx.exitFunc(130)
+		ctx.abort()
 	}()
 }
 
+// abort force-closes VTPro and exits with ExitInterrupted.
+// It is shared by every way a run can be cancelled from outside.
+func (ctx *ExecutionContext) abort() {
+	ctx.forceCleanup()
+	warnLeftRunning(ctx.vtproClient, ctx.log)
+
+	if ctx.onAbort != nil {
+		ctx.onAbort()
+	}
+
+	// Exiting skips the deferred stop in Execute
+	if ctx.stopProfiles != nil {
+		ctx.stopProfiles()
+	}
+
+	ctx.log.Debug("Cleanup completed, exiting")
+	restoreConsole()
+	ctx.exitFunc(ExitInterrupted)
+}
+
+// forceCleanup closes VTPro, or terminates it if its window is not known yet
+func (ctx *ExecutionContext) forceCleanup() {
+	ctx.vtproClient.ForceCleanup(ctx.vtproHwnd, ctx.vtproPid)
+}
+
+// warnLeftRunning warns that cleanup is incomplete when --never-terminate left
+// a VTPro that would not close running, naming its PID so it can be closed by hand
+func warnLeftRunning(vtproClient *vtpro.Client, log logger.LoggerInterface) {
+	if pid := vtproClient.LeftRunning(); pid != 0 {
+		log.Warn("Cleanup incomplete: VTPro was left running", slog.Uint64("pid", uint64(pid)))
+	}
+}
+
+// errVTProNotReady is returned when VTPro does not show a responsive window with the file loaded
+var errVTProNotReady = errcode.New(errcode.VTProNotReady, "VTPro did not become ready")
+
 // waitForWindowReady waits for VTPro window to appear and become responsive
 func waitForWindowReady(vtproClient *vtpro.Client, pid uint32, log logger.LoggerInterface) (uintptr, error) {
 	log.Info("Waiting for VTPro window to appear...")
@@ -228,7 +634,8 @@ func waitForWindowReady(vtproClient *vtpro.Client, pid uint32, log logger.Logger
 		log.Error("Timeout waiting for window to appear after 3 minutes")
 		log.Info("Forcing VTPro to terminate due to timeout")
 		vtproClient.ForceCleanup(0, pid)
-		return 0, fmt.Errorf("timed out waiting for VTPro window to appear after 3 minutes")
+		return 0, fmt.Errorf("%w: timed out waiting for VTPro window to appear after 3 minutes (titles: %s): %w",
+			errVTProNotReady, vtproClient.TitleHistory(), vtproClient.AppearFailure())
 	}
 
 	log.Debug("Window appeared", slog.Uint64("hwnd", uint64(hwnd)))
@@ -236,7 +643,7 @@ func waitForWindowReady(vtproClient *vtpro.Client, pid uint32, log logger.Logger
 	// Wait for the window to be fully ready and responsive
 	if !vtproClient.WaitForReady(hwnd, timeouts.WindowReadyTimeout) {
 		log.Error("Window not responding properly")
-		return 0, fmt.Errorf("window appeared but is not responding properly")
+		return 0, fmt.Errorf("%w: window appeared but is not responding properly", errVTProNotReady)
 	}
 
 	log.Debug("Window is responsive")
@@ -245,7 +652,8 @@ func waitForWindowReady(vtproClient *vtpro.Client, pid uint32, log logger.Logger
 	// This is critical for large files that take time to load themes and pages
 	if !vtproClient.WaitForFileLoaded(pid, timeouts.FileLoadTimeout) {
 		log.Error("Timeout waiting for file to load")
-		return 0, fmt.Errorf("file did not finish loading within timeout")
+		return 0, fmt.Errorf("%w: file did not finish loading within timeout (titles: %s)",
+			errVTProNotReady, vtproClient.TitleHistory())
 	}
 
 	// Small extra delay to allow UI to finish settling
@@ -264,17 +672,68 @@ func waitForWindowReady(vtproClient *vtpro.Client, pid uint32, log logger.Logger
 
 // runCompilation creates a compiler and executes the compilation
 func runCompilation(params CompilationParams) (*compiler.CompileResult, error) {
-	comp := compiler.NewCompiler(params.Logger)
+	opts := []compiler.Option{
+		compiler.WithParser(params.Parser),
+		compiler.WithSleepPolicy(params.Sleep),
+		compiler.WithInputDelays(params.Delays),
+		compiler.WithStepObserver(func(o compiler.StepOutcome) {
+			if params.Recorder != nil {
+				params.Recorder.RecordMark(recording.StepMark(string(o.Step)))
+			}
+			if params.OnStep != nil {
+				params.OnStep(o)
+			}
+		}),
+	}
+
+	if params.Recorder != nil {
+		params.Recorder.RecordMain(params.Hwnd)
+		params.Recorder.RecordMark(recording.MarkCompile)
+		opts = append(opts, compiler.WithWindowManager(recording.WindowManager{
+			WindowManager: windows.NewWindowsAPI(params.Logger),
+			Recorder:      params.Recorder,
+		}))
+	}
+
+	comp := compiler.NewCompiler(params.Logger, opts...)
 
 	result, err := comp.Compile(compiler.CompileOptions{
-		FilePath:    params.FilePath,
-		Hwnd:        params.Hwnd,
-		VTProPid:    params.Pid,
-		VTProPidPtr: params.PidPtr,
+		FilePath:        params.FilePath,
+		Hwnd:            params.Hwnd,
+		VTProPid:        params.Pid,
+		VTProPidPtr:     params.PidPtr,
+		MessageOrder:    params.Order,
+		MessageFormat:   params.Format,
+		SaveFirst:       params.Config.SaveFirst,
+		LaunchMinimized: params.Config.LaunchMinimized,
+		Heartbeat:       params.Config.Heartbeat,
+		StrictDialogs:   params.Config.StrictDialogs,
+		LiveLog:         params.Config.LiveLog,
+		InputMethod:     params.Input,
 	})
+
+	if params.Format == compiler.MessageFormatTable {
+		printMessageTable(consoleOut, result, params.Order, tableWidth(), colorEnabled(), params.Logger)
+	}
+	if errors.Is(err, compiler.ErrCompileCancelled) {
+		params.Logger.Error("Compilation was cancelled before VTPro finished")
+		return nil, &ExitError{Code: ExitCancelled, Err: err}
+	}
+
+	if errors.Is(err, compiler.ErrUnexpectedDialog) {
+		params.Logger.Error("Compilation stopped at an unexpected dialog", slog.Any("error", err))
+		return result, &ExitError{Code: ExitAutomation, Err: err}
+	}
+
 	if err != nil {
-		params.Logger.Error("Compilation failed", slog.Any("error", err))
-		return nil, err
+		// Keep the result so the exit banner can list the compile errors
+		attrs := []any{slog.Any("error", err)}
+		if failed, ok := result.FailedStep(); ok {
+			attrs = append(attrs, slog.String("step", string(failed.Step)))
+		}
+
+		params.Logger.Error("Compilation failed", attrs...)
+		return result, err
 	}
 
 	return result, nil
@@ -287,81 +746,608 @@ func displayCompilationResults(result *compiler.CompileResult, log logger.Logger
 		slog.Int("warnings", result.Warnings),
 		slog.String("size", result.Size),
 		slog.String("projectSize", result.ProjectSize),
+		slog.Duration("compileTime", result.CompileTime),
 	)
+
+	if result.MisdirectedKeystrokes > 0 {
+		log.Warn("Some keystrokes landed in another window and were retried",
+			slog.Int("count", result.MisdirectedKeystrokes),
+		)
+	}
+
+	// Only break the summary down by target when the project compiles for several panels
+	if len(result.Sections) > 1 {
+		for _, section := range result.Sections {
+			log.Info("Target summary",
+				slog.String("target", section.Target),
+				slog.Int("errors", section.Errors),
+				slog.Int("warnings", section.Warnings),
+				slog.String("size", section.Size),
+			)
+		}
+	}
 }
 
 // Execute runs the provided command with the given arguments.
-func Execute(cmd *cobra.Command, args []string) error {
-	cfg := NewConfigFromFlags(cmd)
+func Execute(cmd *cobra.Command, args []string) (err error) {
+	// --resume continues a batch, with or without its projects given again
+	if compilesAsBatch(args) || cmd.Flags().Changed("resume") {
+		return runBatchCmd(cmd, args)
+	}
+
+	s := startRun(cmd, args)
+
+	// Every run ends with the banner, reports and result line, however early it fails
+	defer func() { s.finish(err) }()
+
+	if s.reportsErr != nil {
+		return s.reportsErr
+	}
+
+	return s.run(cmd)
+}
+
+// runState is what startRun, run and finish share over a single-project run
+type runState struct {
+	clk         clock.Clock
+	start       time.Time
+	timer       *phaseTimer
+	runID       string
+	cfg         *Config
+	args        []string
+	argv        []string // Reported as the run's arguments: os.Args, or what a daemon compile was asked with
+	pathChanges []pathutil.Change
+	warm        *warmVTPro // Set for a daemon compile, which takes VTPro from it rather than launching its own
+
+	// Until the log file is open, problems are logged to stderr
+	log          logger.LoggerInterface
+	secrets      *secret.Store
+	stopProfiles func() // Stopped after everything else has run, including the banner and reports
+
+	reports    []output.Spec
+	reportsErr error // Parsed first so a run that fails on any other flag still writes its reports
+
+	// Resolved from the config file as the run goes, and reported by finish
+	bell        bool
+	telemetry   config.TelemetryConfig // Off until the config file opts in
+	links       report.LinkTemplate
+	fingerprint string
+	retention   []diagfiles.Category
+
+	execCtx   *ExecutionContext // Set once VTPro is launched, so a panic can close it
+	outcome   runOutcome
+	succeeded bool
+	reported  *report.Run // Set by finish once the banner is written
+}
+
+// startRun reads the flags and the project argument and sets up what every run
+// reports with. Nothing here can fail; failures start once run is called.
+func startRun(cmd *cobra.Command, args []string) *runState {
+	clk := clock.New()
+	start := clk.Now()
+
+	s := &runState{
+		clk:          clk,
+		start:        start,
+		timer:        newPhaseTimer(clk, start),
+		runID:        report.NewRunID(start, rand.Reader),
+		cfg:          NewConfigFromFlags(cmd),
+		argv:         os.Args[1:],
+		log:          logger.NewBootstrapLogger(os.Stderr),
+		stopProfiles: func() {},
+		retention:    diagfiles.DefaultCategories(),
+	}
+
+	cfg := s.cfg
+
+	if cfg.Output == outputJSON {
+		redirectConsole()
+	}
+
+	// A double-clicked vtpc has no project, so ask for one rather than flash a usage error
+	if len(args) == 0 && !cfg.ShowLogs && isInteractive(os.Stdin, os.Getenv) {
+		if path, ok := pickProject(os.Stdin, consoleOut, ".", pathutil.OSEnv()); ok {
+			args = []string{path}
+			cfg.Pause = true
+
+			// Elevation relaunches with os.Args, so the elevated vtpc compiles and pauses the same way
+			os.Args = append(os.Args, "--pause", path)
+		}
+	}
+
+	s.args = args
+	pauseOnExit = cfg.Pause
+
+	if len(args) > 0 {
+		cfg.FilePath, s.pathChanges = pathutil.Normalize(args[0], pathutil.OSEnv())
+	}
+
+	// The bell follows the result line; the config file can turn it on once it is loaded
+	s.bell = cfg.Bell
+
+	// Secrets are masked in everything logged and reported from here on. The
+	// --deploy password is known from the start, so a run that fails before
+	// the log is open still masks it; a project file's is added once it is read.
+	s.secrets = secret.NewStore(secret.OSSources())
+	addDeploySecrets(cfg.Deploy, s.secrets, os.Getenv)
+
+	// Reported as given until it is resolved
+	s.outcome.project = cfg.FilePath
+
+	s.reports, s.reportsErr = output.DefaultRegistry.ParseSpecs(cfg.Outputs)
+
+	return s
+}
+
+// finish writes the banner and the run's reports, sends telemetry and trims
+// the diagnostics directory, then ends the output with the result line.
+// Reports are written for failed runs too; a report that cannot be written
+// is reported but does not change the run's result.
+func (s *runState) finish(err error) {
+	cfg, log := s.cfg, s.log
+
+	// The banner sets reported; a run that fails before it is reported as far as it got
+	defer func() {
+		run := resultRun(s.reported, err, cfg.FilePath, s.clk.Now().Sub(s.start))
+		status := finishOutput(cfg, os.Stdout, run)
+		ringBell(windows.MessageBeepSound{}, status, s.bell, s.warm == nil && isInteractive(os.Stdout, os.Getenv))
+	}()
+
+	outcome := &s.outcome
+	outcome.timing = s.timer.finish()
+	outcome.timing.VTProCPU = outcome.vtproCPU
+	outcome.timing.Dialogs = outcome.dialogs
+	if cpu, cerr := windows.CurrentProcessCPUTime(); cerr == nil {
+		outcome.timing.SelfCPU = cpu
+	}
+
+	summary := buildSummary(err, *outcome, log.GetLogPath(), s.start, s.clk.Now())
+	summary.AbsoluteTimes = cfg.AbsoluteTimes
+	report.WriteBanner(consoleOut, summary)
+
+	// Only a run that compiled has overhead worth comparing against the compile
+	if outcome.result != nil {
+		line := outcome.timing.OverheadLine()
+		fmt.Fprintln(consoleOut, line)
+		log.Info(line,
+			slog.Duration("wall", outcome.timing.Wall),
+			slog.Duration("vtpcCPU", outcome.timing.SelfCPU),
+			slog.Duration("vtproCPU", outcome.timing.VTProCPU),
+		)
+	}
+
+	run := buildRun(summary, *outcome)
+	run.ID = s.runID
+	run.Args = s.secrets.MaskAll(s.argv)
+	run.ConfigFingerprint = s.fingerprint
+	s.links.Apply(&run)
+
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		run.PanicStack = panicErr.Stack
+	}
+
+	if werr := output.DefaultRegistry.WriteAll(context.Background(), s.reports, &run); werr != nil {
+		log.Error("Could not write all reports", slog.Any("error", werr))
+		fmt.Fprintf(os.Stderr, "vtpc: could not write all reports:\n%v\n", werr)
+	}
+
+	if cfg.EventLog {
+		writeEventLog(openEventLog, &run, log)
+	}
+
+	if s.telemetry.Enabled() {
+		sendTelemetry(s.telemetry, &run, telemetryEnvironment(outcome.dialogCounts, log), telemetryIDPath(), http.DefaultClient, log)
+	}
+
+	// Keep the diagnostics directory from growing without bound on build machines
+	enforceRetention(newDiagnostics(s.retention, log), log)
+
+	s.reported = &run
+
+	s.stopProfiles()
+	log.Close()
+}
+
+// run opens the log, resolves the run's settings, checks the project and compiles it
+func (s *runState) run(cmd *cobra.Command) (err error) {
+	if err := s.openLog(); err != nil {
+		return err
+	}
+
+	// A panic fails the run like any other error, after closing VTPro
+	defer func() {
+		if r := recover(); r != nil {
+			var cleanup func()
+			if s.execCtx != nil {
+				cleanup = s.execCtx.forceCleanup
+			}
+
+			err = recoverPanic(r, stack(), cleanup, s.log)
+		}
+	}()
+
+	settings, err := s.configure(cmd)
+	if err != nil {
+		return err
+	}
+
+	absPath, deployPassword, err := s.prepareProject(settings)
+	if err != nil {
+		return err
+	}
+
+	return s.compile(absPath, deployPassword, settings)
+}
+
+// openLog checks the flags and opens the log file and --profile profiles.
+// Everything that fails from here on is in the log as well as the banner.
+func (s *runState) openLog() error {
+	cfg := s.cfg
+
+	// Settings that travel with the project stand in for flags not given, so they are checked with them
+	if err := loadProjectFile(cfg); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
 
 	if err := handleLogsFlag(cfg, os.Exit); err != nil {
 		return err
 	}
 
-	if len(args) == 0 {
+	if len(s.args) == 0 {
 		return fmt.Errorf("file path required")
 	}
 
-	log, err := initializeLogger(cfg)
+	fileLog, err := startup.openLog(cfg)
+	if err != nil {
+		return err
+	}
+
+	s.log = logger.NewRedacting(fileLog, s.secrets.Mask)
+	maskDeploySecrets(cfg, s.secrets, os.Getenv, s.log)
+
+	stop, err := startProfiles(cfg.Profiles, s.log)
 	if err != nil {
 		return err
 	}
 
-	defer log.Close()
+	s.stopProfiles = stop
 
-	log.Debug("Starting vtpc", slog.Any("args", args))
+	s.log.Debug("Starting vtpc", slog.Any("args", s.args), slog.String("runID", s.runID), slog.String("startedAt", report.FormatTimestamp(s.start)))
+	logFlags(cfg, s.log)
+	logConsoleState(consoleState, s.log)
+
+	return nil
+}
+
+// logFlags logs the flags a run was started with
+func logFlags(cfg *Config, log logger.LoggerInterface) {
 	log.Debug("Flags set",
 		slog.Bool("verbose", cfg.Verbose),
+		slog.String("config", cfg.ConfigPath),
+		slog.String("messageOrder", cfg.MessageOrder),
+		slog.String("format", cfg.Format),
+		slog.Bool("absoluteTimes", cfg.AbsoluteTimes),
+		slog.Bool("pause", cfg.Pause),
+		slog.Bool("bell", cfg.Bell),
+		slog.Bool("saveFirst", cfg.SaveFirst),
+		slog.Bool("forceCleanup", cfg.ForceCleanup),
+		slog.Bool("requireSidecars", cfg.RequireSidecars),
+		slog.Bool("neverTerminate", cfg.NeverTerminate),
+		slog.Bool("strictDialogs", cfg.StrictDialogs),
+		slog.Bool("launchMinimized", cfg.LaunchMinimized),
+		slog.String("mainWindowClass", cfg.MainWindowClass),
+		slog.Any("ignorePages", cfg.IgnorePages),
+		slog.Duration("heartbeat", cfg.Heartbeat),
+		slog.Duration("maxCompileTime", cfg.MaxCompileTime),
+		slog.Duration("slowDialog", cfg.SlowDialog),
+		slog.String("onSleep", cfg.OnSleep),
+		slog.Bool("waitForWindow", cfg.WaitForWindow),
+		slog.Bool("ignoreSchedule", cfg.IgnoreSchedule),
+		slog.Bool("liveLog", cfg.LiveLog),
+		slog.String("inputMethod", cfg.InputMethod),
+		slog.Float64("slowInputMultiplier", cfg.SlowInputMultiplier),
+		slog.Bool("noInputAdapt", cfg.NoInputAdapt),
+		slog.String("cancelFile", cfg.CancelFile),
+		slog.Bool("isolate", cfg.Isolate),
+		slog.String("outDir", cfg.OutDir),
+		slog.Bool("verifyArtifact", cfg.VerifyArtifact),
+		slog.Bool("provenance", cfg.Provenance),
+		slog.String("deploy", deploy.Redact(cfg.Deploy)),
+		slog.String("deployPassword", cfg.DeployPassword),
+		slog.String("recordEvents", cfg.RecordEvents),
+		slog.Bool("traceWin32", cfg.TraceWin32),
+		slog.Bool("eventlog", cfg.EventLog),
+		slog.Any("profile", cfg.Profiles),
+		slog.Any("out", cfg.Outputs),
+		slog.String("messageLinkTemplate", cfg.MessageLinkTemplate),
 	)
+}
 
-	// Recover from panics and log them
-	defer func() {
-		if r := recover(); r != nil {
-			log.Error("PANIC RECOVERED",
-				slog.Any("panic", r),
-				slog.String("stack", string(debug.Stack())),
-			)
+// runSettings are what the config file and flags resolve to for the compile
+type runSettings struct {
+	parser      compiler.ParserOptions
+	window      vtpro.WindowIdentity
+	maintenance *schedule.Schedule
+	order       compiler.MessageOrder
+	format      compiler.MessageFormat
+	sleep       clock.SleepPolicy
+	input       compiler.InputMethod
+}
 
-			fmt.Fprintf(os.Stderr, "\n*** PANIC: %v ***\n", r)
-			fmt.Fprintf(os.Stderr, "Check log file for details\n")
-		}
-	}()
+// configure loads the config file and resolves it with the flags. What finish
+// reports with, such as the bell and telemetry, is kept on s as it is resolved.
+func (s *runState) configure(cmd *cobra.Command) (runSettings, error) {
+	var settings runSettings
+
+	cfg, log := s.cfg, s.log
+
+	configFile, err := loadConfigFile(cfg, log)
+	if err != nil {
+		return settings, err
+	}
+
+	if project := cfg.projectFile(); project.Path != "" {
+		log.Debug("Loaded project file", slog.String("path", project.Path))
+	}
+
+	warnProjectDeploy(cfg, log)
+
+	if settings.parser, err = buildParserOptions(configFile); err != nil {
+		return settings, err
+	}
+
+	if s.retention, err = buildRetention(configFile); err != nil {
+		return settings, err
+	}
+
+	if s.links, err = buildLinkTemplate(cfg, configFile); err != nil {
+		return settings, err
+	}
+
+	if settings.parser.IgnorePages, err = buildPageFilter(cfg, configFile); err != nil {
+		return settings, err
+	}
+
+	settings.parser.Strict = cfg.StrictParse
+	s.bell = buildBell(cfg, configFile)
+
+	if s.telemetry, err = buildTelemetry(configFile); err != nil {
+		return settings, err
+	}
+
+	settings.window = buildWindowIdentity(cfg, configFile)
+
+	// Everything a run is configured by is resolved by now
+	effective := effectiveConfig(cmd.Root().PersistentFlags(), cfg, configFile, os.Getenv)
+	s.fingerprint = effective.Fingerprint()
+	logEffective(effective, log)
+
+	if settings.maintenance, err = buildSchedule(configFile); err != nil {
+		return settings, err
+	}
+
+	if settings.order, err = compiler.ParseMessageOrder(cfg.MessageOrder); err != nil {
+		return settings, err
+	}
+
+	if settings.format, err = compiler.ParseMessageFormat(cfg.Format); err != nil {
+		return settings, err
+	}
+
+	if settings.sleep, err = clock.ParseSleepPolicy(cfg.OnSleep); err != nil {
+		return settings, err
+	}
+
+	if settings.input, err = compiler.ParseInputMethod(cfg.InputMethod); err != nil {
+		return settings, err
+	}
+
+	return settings, nil
+}
+
+// prepareProject checks VTPro and the project before anything is launched,
+// waits out a maintenance window and elevates. It returns the project's
+// absolute path and the --deploy password.
+func (s *runState) prepareProject(settings runSettings) (absPath, deployPassword string, err error) {
+	cfg, log := s.cfg, s.log
 
 	// Validate VTPro installation before checking elevation
-	if err := vtpro.ValidateVTProInstallation(); err != nil {
+	if err := startup.checkVTPro(); err != nil {
 		log.Error("VTPro installation check failed", slog.Any("error", err))
-		return err
+		return "", "", err
 	}
 
 	log.Debug("VTPro installation validated", slog.String("path", vtpro.GetVTProPath()))
 
 	// Validate file path before requesting elevation
-	absPath, err := validateAndResolvePath(args[0], log)
+	for _, c := range s.pathChanges {
+		log.Debug("Normalized project path", slog.String("step", c.Step), slog.String("path", c.Path))
+	}
+
+	absPath, err = validateAndResolvePath(cfg.FilePath, log)
 	if err != nil {
-		return err
+		return "", "", err
 	}
 
-	if err := ensureElevated(log); err != nil {
+	s.outcome.project = absPath
+
+	// Checked before vtpc or VTPro opens the file, and again by the elevated instance
+	projectOutDir := ""
+	if cfg.fromProjectFile("out-dir") {
+		projectOutDir = cfg.OutDir
+	}
+
+	if err := checkMachinePolicy(absPath, projectOutDir, windows.ProgramDataDir, windows.FinalPath, log); err != nil {
+		return "", "", err
+	}
+
+	// Catch placeholders such as Git LFS pointers before VTPro shows an error dialog
+	if err := vtpfile.Check(absPath); err != nil {
+		log.Error("Project file check failed", slog.Any("error", err))
+		return "", "", err
+	}
+
+	// Waited out before elevating, so nothing is launched until the window opens
+	interrupted, stopInterrupt := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err = enforceSchedule(cfg, settings.maintenance, s.clk, interrupted.Done(), log)
+	stopInterrupt()
+
+	if err != nil {
+		return "", "", err
+	}
+
+	// The elevated instance profiles the run into the same files, so this one's
+	// profiles are written before it is launched rather than when this one exits
+	relaunch := func() error {
+		s.stopProfiles()
+		return startup.relaunch()
+	}
+
+	if err := ensureElevatedWithDeps(log, startup.isElevated, relaunch, os.Exit); err != nil {
+		return "", "", err
+	}
+
+	// Resolved once elevated, since a relaunched instance has no piped stdin
+	deployPassword, err = resolveDeployPassword(cfg, s.secrets)
+	if err != nil {
+		return "", "", err
+	}
+
+	// A full disk makes VTPro write an empty .vtz rather than fail, so check before launching it
+	if err := runPreflight(cfg, absPath, windows.DiskSpace{}, log); err != nil {
+		return "", "", err
+	}
+
+	return absPath, deployPassword, nil
+}
+
+// compile launches VTPro on the project, compiles it and closes VTPro again,
+// then hands the result to deliver. Everything it starts is stopped by the
+// time it returns, before finish reports the run. A daemon compile uses the
+// VTPro the daemon keeps open instead, see compileWarm.
+func (s *runState) compile(absPath, deployPassword string, settings runSettings) (err error) {
+	cfg, log, clk, outcome := s.cfg, s.log, s.clk, &s.outcome
+
+	// With --isolate, VTPro compiles a copy of the project in a temp directory
+	compilePath := absPath
+
+	var ws *workspace.Workspace
+	if cfg.Isolate {
+		ws, err = workspace.New(absPath, cfg.Sidecars, log)
+		if err != nil {
+			return err
+		}
+
+		compilePath = ws.Project
+
+		defer func() { ws.Cleanup(!s.succeeded && cfg.KeepTempOnFailure) }()
+	}
+
+	// Checked beside the copy VTPro compiles, so sidecars an isolated copy left behind are caught too
+	if err := checkSidecars(cfg, compilePath, absPath, log); err != nil {
 		return err
 	}
 
-	vtproClient := vtpro.NewClient(log)
-	_, pid, cleanup, err := launchVTPro(vtproClient, absPath, log)
+	// Started before VTPro so the recording covers the whole run
+	var recorder *recording.Recorder
+	if cfg.RecordEvents != "" {
+		recorder, err = recording.Create(cfg.RecordEvents, clk)
+		if err != nil {
+			return err
+		}
+
+		windows.SetEventRecorder(recorder)
+
+		defer func() {
+			windows.SetEventRecorder(nil)
+
+			if cerr := recorder.Close(); cerr != nil {
+				log.Warn("Event recording is incomplete", slog.String("path", cfg.RecordEvents), slog.Any("error", cerr))
+				return
+			}
+
+			log.Info("Window events recorded", slog.String("path", cfg.RecordEvents))
+		}()
+	}
+
+	// Trace is file-only, so however many calls there are the console is unaffected
+	if cfg.TraceWin32 {
+		windows.SetWin32Trace(log)
+		defer windows.SetWin32Trace(nil)
+	}
+
+	// The daemon keeps VTPro open on its last project between compiles. --isolate
+	// compiles a new copy each time, so there is nothing to keep open for it.
+	if s.warm != nil && ws == nil {
+		return s.compileWarm(compilePath, absPath, deployPassword, settings, recorder)
+	}
+
+	vtproClient := vtpro.NewClient(log).
+		WithProjectFile(compilePath).
+		WithExpectTitle(cfg.ExpectTitle).
+		WithWindowIdentity(settings.window).
+		WithLaunchMinimized(cfg.LaunchMinimized).
+		WithSleepPolicy(settings.sleep).
+		WithNeverTerminate(cfg.NeverTerminate)
+	if !cfg.ForceCleanup && isInteractive(os.Stdin, os.Getenv) {
+		vtproClient.WithConfirmTerminate(func(pid uint32, project string) bool {
+			question := fmt.Sprintf("Force terminate VTPro (PID %d, '%s')?", pid, project)
+			return confirm(os.Stdin, consoleOut, clk, question, confirmTimeout)
+		})
+	}
+	// Registered first so it runs after every cleanup path has had its chance to close VTPro
+	defer warnLeftRunning(vtproClient, log)
+
+	pid, _, cleanup, err := launchVTPro(vtproClient, compilePath, cfg.LaunchMinimized, log)
 	if err != nil {
 		return err
 	}
 
-	defer cleanup()
+	// The monitor's stats and dialog times are read once cleanup has stopped
+	// it, so they cover VTPro closing too
+	defer func() {
+		outcome.vtproCPU = cleanup()
+		s.recordMonitor(vtproClient, time.Time{})
+	}()
+
+	s.timer.begin(report.PhaseWaits)
 
 	// Create execution context to hold state for signal handlers
-	ctx := &ExecutionContext{
-		vtproPid:    pid,
-		log:         log,
-		vtproClient: vtproClient,
-		exitFunc:    os.Exit,
+	execCtx := &ExecutionContext{
+		vtproPid:     pid,
+		log:          log,
+		vtproClient:  vtproClient,
+		exitFunc:     os.Exit,
+		stopProfiles: s.stopProfiles,
 	}
+	s.execCtx = execCtx
 
-	setupSignalHandlers(ctx)
+	execCtx.onAbort = func() {
+		if ws != nil {
+			ws.Cleanup(cfg.KeepTempOnFailure)
+		}
+
+		// abort exits without returning, so the result line is written here
+		run := &report.Run{Project: absPath, Summary: report.Summary{Code: string(errcode.Interrupted), Duration: clk.Now().Sub(s.start)}}
+		writeResultLine(consoleOut, report.StatusInterrupted, run)
+	}
+
+	setupSignalHandlers(execCtx)
+
+	// The orchestrator cannot signal an elevated process, so it can drop a file instead
+	if cfg.CancelFile != "" {
+		stopWatching := watchCancelFile(cfg.CancelFile, cfg.CancelPollInterval, clock.New(), log, func() {
+			log.Info("Cancel file received, starting cleanup")
+			execCtx.abort()
+		})
+
+		defer stopWatching()
+	}
 
 	hwnd, err := waitForWindowReady(vtproClient, pid, log)
 	if err != nil {
@@ -369,23 +1355,137 @@ func Execute(cmd *cobra.Command, args []string) error {
 	}
 
 	// Store hwnd in context for signal handlers and cleanup
-	ctx.vtproHwnd = hwnd
+	execCtx.vtproHwnd = hwnd
 	log.Debug("Stored hwnd in execution context", slog.Uint64("hwnd", uint64(hwnd)))
 
-	defer vtproClient.Cleanup(hwnd, pid)
+	sess := &vtproSession{client: vtproClient, pid: pid, hwnd: hwnd}
+
+	defer func() {
+		// --strict-dialogs leaves VTPro and the dialog on screen for inspection
+		if errors.Is(err, compiler.ErrUnexpectedDialog) {
+			log.Warn("Leaving VTPro open at the unexpected dialog", slog.Uint64("pid", uint64(pid)))
+			return
+		}
+
+		// A panic during the compile has already closed VTPro
+		if errors.Is(err, ErrPanic) {
+			return
+		}
+
+		// Closing a main window VTPro has since replaced would post to nothing
+		hwnd := sess.hwnd
+		if resolved, found := vtproClient.ResolveMainWindow(pid, hwnd); found {
+			hwnd = resolved
+		}
+
+		vtproClient.Cleanup(hwnd, pid)
+	}()
+
+	return s.compileOn(sess, compilePath, absPath, deployPassword, ws, settings, recorder)
+}
+
+// compileWarm compiles on the VTPro the daemon keeps open, which launches it
+// first unless it already has the project loaded, and hands VTPro back
+// afterwards so the next compile can use it too
+func (s *runState) compileWarm(compilePath, absPath, deployPassword string, settings runSettings, recorder *recording.Recorder) (err error) {
+	cfg, log, outcome := s.cfg, s.log, &s.outcome
+
+	s.timer.begin(report.PhaseWaits)
+
+	sess, err := s.warm.open(compilePath, newLaunchOptions(cfg, settings), log)
+	if err != nil {
+		return err
+	}
+
+	// VTPro and the monitor outlive the run, so only what happened since it
+	// started is counted, and the monitor is read while it is still running
+	cpuBefore := sess.cpuUsed
+
+	defer func() {
+		outcome.vtproCPU = sess.cpu() - cpuBefore
+		s.recordMonitor(sess.client, s.start)
+		s.warm.release(sess, err)
+	}()
+
+	// Nothing here handles Ctrl+C: the daemon lets the compile finish and then closes VTPro
+	s.execCtx = &ExecutionContext{
+		vtproHwnd:    sess.hwnd,
+		vtproPid:     sess.pid,
+		log:          log,
+		vtproClient:  sess.client,
+		exitFunc:     os.Exit,
+		stopProfiles: s.stopProfiles,
+	}
+
+	return s.compileOn(sess, compilePath, absPath, deployPassword, nil, settings, recorder)
+}
+
+// compileOn compiles the project VTPro has loaded in sess, then hands the
+// result to deliver. Closing VTPro is left to the caller.
+func (s *runState) compileOn(sess *vtproSession, compilePath, absPath, deployPassword string, ws *workspace.Workspace, settings runSettings, recorder *recording.Recorder) (err error) {
+	cfg, log, clk, outcome, execCtx := s.cfg, s.log, s.clk, &s.outcome, s.execCtx
+
+	// A compile over its time budget still produces its artifact, so the budget only
+	// decides the exit code once everything else has run, and any other failure wins
+	var budgetErr error
+	defer func() { err = mostSevere(err, budgetErr) }()
 
-	result, err := runCompilation(CompilationParams{
-		FilePath: absPath,
-		Hwnd:     hwnd,
-		Pid:      pid,
-		PidPtr:   &ctx.vtproPid,
+	delays := inputDelays(windows.ReadVirtualIndicators(), cfg.SlowInputMultiplier, !cfg.NoInputAdapt, log)
+
+	// VTPro can replace its main window while the post-load dialogs are handled
+	resolved, found := sess.client.ResolveMainWindow(sess.pid, sess.hwnd)
+	if !found {
+		return fmt.Errorf("%w: VTPro's main window closed before the compile started", errVTProNotReady)
+	}
+
+	sess.hwnd = resolved
+	execCtx.vtproHwnd = resolved
+
+	s.timer.begin(report.PhaseCompile)
+	compileStart := time.Now()
+
+	result, err := runGuarded(runCompilation, CompilationParams{
+		FilePath: compilePath,
+		Hwnd:     sess.hwnd,
+		Pid:      sess.pid,
+		PidPtr:   &execCtx.vtproPid,
 		Config:   cfg,
+		Parser:   settings.parser,
+		Order:    settings.order,
+		Format:   settings.format,
 		Logger:   log,
-	})
+		Recorder: recorder,
+		Sleep:    settings.sleep,
+		Input:    settings.input,
+		Delays:   delays,
+		OnStep: func(o compiler.StepOutcome) {
+			// Closing VTPro is cleanup, not compiling
+			if o.Step == compiler.StepHarvestResults {
+				s.timer.begin(report.PhaseCleanup)
+			}
+		},
+	}, execCtx.forceCleanup)
+	s.timer.begin(report.PhaseCleanup)
+	outcome.result = result
+	if result != nil {
+		result.AttachMonitorStats(sess.client.MonitorStats())
+		trackKeystroke(result.Keystroke, keystrokeHistoryPath(), clk.Now(), log)
+	}
+
 	if err != nil {
 		return err
 	}
 
+	// VTPro is back at its main window, whatever the result
+	sess.compiled = true
+
+	if budgetErr = checkCompileBudget(result.CompileTime, cfg.MaxCompileTime); budgetErr != nil {
+		log.Warn("Compile took longer than its time budget",
+			slog.Duration("compileTime", result.CompileTime),
+			slog.Duration("budget", cfg.MaxCompileTime),
+		)
+	}
+
 	displayCompilationResults(result, log)
 
 	if result.HasErrors {
@@ -393,5 +1493,186 @@ func Execute(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("compilation failed with %d error(s)", result.Errors)
 	}
 
+	if err := s.deliver(absPath, deployPassword, ws, compileStart, result); err != nil {
+		return err
+	}
+
+	s.succeeded = true
+
 	return nil
 }
+
+// recordMonitor keeps the window monitor's stats and how long each dialog
+// VTPro showed stayed open, leaving out dialogs opened before since
+func (s *runState) recordMonitor(vtproClient *vtpro.Client, since time.Time) {
+	cfg, log, outcome := s.cfg, s.log, &s.outcome
+
+	stats := vtproClient.MonitorStats()
+	outcome.monitor = &stats
+	outcome.selection = vtproClient.Selection()
+
+	chosen, _ := outcome.selection.Chosen()
+	spans := dialogsSince(vtproClient.DialogSpans(s.clk.Now()), since)
+	dialogs := dialogTimes(spans, chosen.Hwnd)
+	warnSlowDialogs(log, dialogs, cfg.SlowDialog)
+	outcome.dialogs = dialogs[:min(len(dialogs), maxDialogTimes)]
+	outcome.dialogCounts = dialogCounts(spans, chosen.Hwnd, dialog.Default())
+}
+
+// deliver finds the artifacts a successful compile wrote, then verifies,
+// records the provenance of and deploys them as the flags ask
+func (s *runState) deliver(absPath, deployPassword string, ws *workspace.Workspace, compileStart time.Time, result *compiler.CompileResult) error {
+	cfg, log, outcome := s.cfg, s.log, &s.outcome
+
+	var err error
+
+	if ws != nil {
+		outcome.artifacts, err = ws.CollectArtifacts(artifactDir(cfg, absPath))
+		if err != nil {
+			return err
+		}
+	} else {
+		builtin := func() (artifact.File, bool) {
+			return locateArtifact(vtpro.NewPreferenceReader(), absPath, compileStart, log)
+		}
+
+		found, ok, err := findArtifact(context.Background(), cfg.locator, builtin, absPath, result, log)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			outcome.artifacts = []string{found.Path}
+			outcome.artifactFrom = found.Source
+		}
+	}
+
+	if cfg.VerifyArtifact {
+		outcome.checks, err = verifyArtifacts(outcome.artifacts, result.ProjectBytes, log)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.Provenance {
+		outcome.provenance, err = recordProvenance(newProvenance(absPath, s.runID, result, log), outcome.artifacts, log)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.Deploy != "" {
+		target, err := deployArtifacts(context.Background(), cfg.Deploy, deployPassword, outcome.artifacts, deploy.NewUploader(log), log)
+		if err != nil {
+			return err
+		}
+
+		outcome.deployedTo = target.String()
+	}
+
+	return nil
+}
+
+// runPreflight checks the drives the compile writes to have free space and accept new files.
+// A read-only project directory turns on isolation rather than failing, see stageIfReadOnly.
+func runPreflight(cfg *Config, projectPath string, space preflight.SpaceReporter, log logger.LoggerInterface) error {
+	if err := stageIfReadOnly(cfg, projectPath, preflight.CheckWritable, log); err != nil {
+		return err
+	}
+
+	checker := preflight.Checker{Space: space, MinFreeMB: uint64(cfg.MinFreeMB)}
+
+	// An isolated compile writes intermediates to the temp directory, not next to the project
+	dirs := []string{filepath.Dir(projectPath), cfg.OutDir}
+	if cfg.Isolate {
+		dirs = []string{os.TempDir(), artifactDir(cfg, projectPath)}
+	}
+
+	if err := checker.Check(dirs...); err != nil {
+		log.Error("Pre-flight check failed", slog.Any("error", err))
+		return &ExitError{Code: ExitEnvironment, Err: err}
+	}
+
+	log.Debug("Pre-flight check passed", slog.Uint64("minFreeMB", uint64(cfg.MinFreeMB)))
+
+	return nil
+}
+
+// checkSidecars warns about each sidecar missing beside the project VTPro
+// compiles, which would otherwise surface as a flood of missing resource errors.
+// With --require-sidecars the run fails instead.
+func checkSidecars(cfg *Config, compilePath, projectPath string, log logger.LoggerInterface) error {
+	missing := preflight.MissingSidecars(preflight.DefaultSidecarRules, compilePath, projectPath)
+	if len(missing) == 0 {
+		log.Debug("Sidecar check passed")
+		return nil
+	}
+
+	for _, path := range missing {
+		log.Warn("Project sidecar is missing, VTPro will report missing resources", slog.String("path", path))
+	}
+
+	if cfg.Isolate {
+		log.Warn("Pass --sidecar for each file the isolated copy needs")
+	}
+
+	if !cfg.RequireSidecars {
+		return nil
+	}
+
+	err := fmt.Errorf("%w: %s", preflight.ErrMissingSidecars, strings.Join(missing, ", "))
+	log.Error("Pre-flight check failed", slog.Any("error", err))
+
+	return err
+}
+
+// stageIfReadOnly turns on isolation when VTPro could not write its intermediate
+// files next to the project, such as on a read-only network share. The staged copy's
+// artifact cannot go back next to the project either, so --out-dir is then required.
+// The sidecars beside the project are staged with it, as the user asked for no copy
+// and so passed no --sidecar.
+func stageIfReadOnly(cfg *Config, projectPath string, writable func(string) error, log logger.LoggerInterface) error {
+	if cfg.Isolate {
+		return nil
+	}
+
+	projectDir := filepath.Dir(projectPath)
+
+	probeErr := writable(projectDir)
+	if probeErr == nil {
+		return nil
+	}
+
+	if cfg.OutDir == "" {
+		err := fmt.Errorf("%w: %s: pass --out-dir for the compiled artifact and vtpc will compile a copy in a temporary directory",
+			preflight.ErrReadOnlyProject, projectDir)
+		log.Error("Pre-flight check failed", slog.Any("error", err), slog.Any("probe", probeErr))
+
+		return &ExitError{Code: ExitEnvironment, Err: err}
+	}
+
+	log.Warn("Project directory is read-only, compiling a copy in a temporary directory",
+		slog.String("dir", projectDir),
+		slog.String("outDir", cfg.OutDir),
+	)
+
+	cfg.Isolate = true
+
+	for _, sidecar := range preflight.PresentSidecars(preflight.DefaultSidecarRules, projectPath) {
+		if !slices.Contains(cfg.Sidecars, sidecar) {
+			cfg.Sidecars = append(cfg.Sidecars, sidecar)
+		}
+	}
+
+	return nil
+}
+
+// artifactDir returns where compiled artifacts are copied: --out-dir if set,
+// otherwise next to the original project
+func artifactDir(cfg *Config, projectPath string) string {
+	if cfg.OutDir != "" {
+		return cfg.OutDir
+	}
+
+	return filepath.Dir(projectPath)
+}
@@ -9,9 +9,15 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/config"
 	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
 	"github.com/Norgate-AV/vtpc/internal/version"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
 )
 
 // resetFlags resets all flags to their default values between tests
@@ -40,6 +46,23 @@ func TestValidateArgs_ValidFile(t *testing.T) {
 	assert.NoError(t, err, "Valid .vtp file should pass validation")
 }
 
+// TestValidateArgs_PastedPaths tests that quoted paths and file URIs are checked after normalization
+func TestValidateArgs_PastedPaths(t *testing.T) {
+	t.Parallel()
+
+	for _, file := range []string{
+		`"C:\Projects\lobby.vtp"`,
+		`'C:\Projects\lobby.vtp'`,
+		"file:///C:/My%20Projects/lobby.vtp",
+	} {
+		t.Run(file, func(t *testing.T) {
+			t.Parallel()
+
+			assert.NoError(t, validateArgs(&cobra.Command{}, []string{file}))
+		})
+	}
+}
+
 // TestValidateArgs_InvalidExtension tests argument validation with non-.vtp file
 func TestValidateArgs_InvalidExtension(t *testing.T) {
 	t.Parallel()
@@ -102,18 +125,32 @@ func TestValidateArgs_MissingArgument(t *testing.T) {
 	assert.NoError(t, err, "validateArgs should allow 0 args for --logs flag")
 }
 
-// TestValidateArgs_TooManyArguments tests validation with multiple arguments
-func TestValidateArgs_TooManyArguments(t *testing.T) {
+// TestValidateArgs_SeveralProjects tests validation with several file arguments
+func TestValidateArgs_SeveralProjects(t *testing.T) {
 	t.Parallel()
 
-	resetFlags()
-
 	cmd := &cobra.Command{}
-	args := []string{"file1.vtp", "file2.vtp"}
 
-	err := validateArgs(cmd, args)
-	assert.Error(t, err, "Should return error when multiple files provided")
-	assert.Contains(t, err.Error(), "accepts 1 arg(s), received 2")
+	assert.NoError(t, validateArgs(cmd, []string{"file1.vtp", "file2.vtp", `panels\*.vtp`}))
+
+	err := validateArgs(cmd, []string{"file1.vtp", "notes.txt"})
+	assert.EqualError(t, err, "notes.txt: file must have .vtp extension")
+
+	assert.NoError(t, validateArgs(cmd, []string{t.TempDir()}), "a directory stands for the projects under it")
+}
+
+// TestCompilesAsBatch tests which arguments are compiled as a batch
+func TestCompilesAsBatch(t *testing.T) {
+	t.Parallel()
+
+	dir := projectTree(t, "Lobby.vtp", "Panel [v2].vtp")
+
+	assert.False(t, compilesAsBatch(nil))
+	assert.False(t, compilesAsBatch([]string{filepath.Join(dir, "Lobby.vtp")}))
+	assert.False(t, compilesAsBatch([]string{filepath.Join(dir, "Panel [v2].vtp")}), "a file whose name looks like a pattern")
+	assert.True(t, compilesAsBatch([]string{filepath.Join(dir, "*.vtp")}))
+	assert.True(t, compilesAsBatch([]string{filepath.Join(dir, "Lobby.vtp"), filepath.Join(dir, "Lobby.vtp")}))
+	assert.True(t, compilesAsBatch([]string{dir}), "a directory")
 }
 
 // TestValidateArgs_LogsFlag tests the --logs flag functionality
@@ -554,3 +591,176 @@ func TestEnsureElevated_NotElevated_RelaunchFails(t *testing.T) {
 	assert.Contains(t, err.Error(), "error relaunching as admin", "Error should mention relaunch failure")
 	assert.ErrorIs(t, err, relaunchErr, "Should wrap the relaunch error")
 }
+
+func TestBuildParserOptions(t *testing.T) {
+	t.Parallel()
+
+	opts, err := buildParserOptions(&config.File{
+		Parser: config.ParserConfig{
+			SummaryPatterns: []config.SummaryPatternConfig{
+				{Name: "german", Pattern: `(?P<warnings>\d+) Warnung\(en\), (?P<errors>\d+) Fehler`},
+				{Pattern: `(?P<errors>\d+) E / (?P<warnings>\d+) W`},
+			},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, opts.SummaryPatterns, 2)
+	assert.Equal(t, "german", opts.SummaryPatterns[0].Name)
+	assert.Equal(t, "custom-2", opts.SummaryPatterns[1].Name)
+}
+
+func TestBuildParserOptions_InvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	_, err := buildParserOptions(&config.File{
+		Parser: config.ParserConfig{
+			SummaryPatterns: []config.SummaryPatternConfig{
+				{Name: "broken", Pattern: `(?P<warnings>\d+) warnings`},
+			},
+		},
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "parser.summaryPatterns[0]")
+}
+
+func TestBuildParserOptions_MaxContinuations(t *testing.T) {
+	t.Parallel()
+
+	opts, err := buildParserOptions(&config.File{Parser: config.ParserConfig{MaxContinuations: 12}})
+	assert.NoError(t, err)
+	assert.Equal(t, 12, opts.MaxContinuations)
+
+	_, err = buildParserOptions(&config.File{Parser: config.ParserConfig{MaxContinuations: -1}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "maxContinuations")
+}
+
+func TestBuildLinkTemplate(t *testing.T) {
+	t.Parallel()
+
+	message := report.Message{Page: "Main", Object: "Volume", RuleID: "missing-join"}
+	file := &config.File{Report: config.ReportConfig{MessageLinkTemplate: "vtpro://open?page={page}&object={object}"}}
+
+	links, err := buildLinkTemplate(&Config{}, file)
+	require.NoError(t, err)
+	link, _ := links.Expand("", message)
+	assert.Equal(t, "vtpro://open?page=Main&object=Volume", link, "the config file is used without the flag")
+
+	links, err = buildLinkTemplate(&Config{MessageLinkTemplate: "https://wiki/{rule}"}, file)
+	require.NoError(t, err)
+	link, _ = links.Expand("", message)
+	assert.Equal(t, "https://wiki/missing-join", link, "the flag takes precedence")
+
+	links, err = buildLinkTemplate(&Config{}, &config.File{})
+	require.NoError(t, err)
+	assert.True(t, links.IsZero())
+
+	_, err = buildLinkTemplate(&Config{}, &config.File{Report: config.ReportConfig{MessageLinkTemplate: "{page"}})
+	assert.ErrorContains(t, err, "config report.messageLinkTemplate")
+}
+
+func TestBuildWindowIdentity(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, vtpro.DefaultWindowIdentity(), buildWindowIdentity(&Config{}, &config.File{}))
+
+	file := &config.File{VTPro: config.VTProConfig{MainWindowClass: "VWT64AppClass", SplashTitle: "VT Pro-e", SplashClass: "VTSplash"}}
+	assert.Equal(t, vtpro.WindowIdentity{MainClass: "VWT64AppClass", SplashTitle: "VT Pro-e", SplashClass: "VTSplash"},
+		buildWindowIdentity(&Config{}, file), "the config file is used without the flag")
+
+	id := buildWindowIdentity(&Config{MainWindowClass: "Afx:VTPro"}, file)
+	assert.Equal(t, "Afx:VTPro", id.MainClass, "the flag takes precedence")
+	assert.Equal(t, "VT Pro-e", id.SplashTitle)
+}
+
+func TestBuildPageFilter(t *testing.T) {
+	t.Parallel()
+
+	scratch := compiler.Message{Text: `Object "Mute" on Page "zz_scratch" has an invalid join number.`}
+	debug := compiler.Message{Text: `Object "Meter" on Page "Debug_Audio" has an unassigned Smart Object ID.`}
+	file := &config.File{Parser: config.ParserConfig{IgnorePages: []string{"ZZ_*"}}}
+
+	filter, err := buildPageFilter(&Config{IgnorePages: []string{"Debug*"}}, file)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ZZ_*", "Debug*"}, filter.Patterns(), "the flag adds to the config file")
+	assert.True(t, filter.Suppresses(scratch))
+	assert.True(t, filter.Suppresses(debug))
+
+	filter, err = buildPageFilter(&Config{}, &config.File{})
+	require.NoError(t, err)
+	assert.Nil(t, filter, "nothing is ignored by default")
+
+	_, err = buildPageFilter(&Config{}, &config.File{Parser: config.ParserConfig{IgnorePages: []string{"ZZ_["}}})
+	assert.ErrorContains(t, err, "config parser.ignorePages")
+}
+
+func TestBuildParserOptions_Rules(t *testing.T) {
+	t.Parallel()
+
+	opts, err := buildParserOptions(&config.File{
+		Rules: map[string]config.RulePolicies{
+			"path-length-warning":        {{Action: "error"}},
+			"unassigned-smart-object-id": {{Action: "ignore", Pages: []string{"Debug*"}}},
+		},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, opts.Policy)
+
+	entry, ok := opts.Policy.Decide(compiler.Message{
+		RuleID: "unassigned-smart-object-id",
+		Text:   `Object "Meter" on Page "Debug_Audio" has an unassigned Smart Object ID.`,
+	})
+	require.True(t, ok)
+	assert.Equal(t, compiler.ActionIgnore, entry.Action)
+
+	opts, err = buildParserOptions(&config.File{})
+	require.NoError(t, err)
+	assert.Nil(t, opts.Policy, "no rules section means no policy")
+}
+
+func TestBuildParserOptions_InvalidRules(t *testing.T) {
+	t.Parallel()
+
+	_, err := buildParserOptions(&config.File{
+		Rules: map[string]config.RulePolicies{"path-lenght-warning": {{Action: "error"}}},
+	})
+	assert.ErrorContains(t, err, `unknown rule "path-lenght-warning"`)
+
+	_, err = buildParserOptions(&config.File{
+		Rules: map[string]config.RulePolicies{"missing-join": {{Action: "fatal"}}},
+	})
+	assert.ErrorContains(t, err, "config rules")
+}
+
+func TestArtifactDir(t *testing.T) {
+	t.Parallel()
+
+	project := filepath.Join("projects", "lobby", "lobby.vtp")
+
+	assert.Equal(t, filepath.Join("projects", "lobby"), artifactDir(&Config{}, project))
+	assert.Equal(t, "out", artifactDir(&Config{OutDir: "out"}, project))
+}
+
+// TestExecutionContext_AbortNeverTerminates tests that an interrupt under
+// --never-terminate leaves VTPro running and says so rather than killing it
+func TestExecutionContext_AbortNeverTerminates(t *testing.T) {
+	t.Setenv("VTPRO_PATH", "")
+
+	log := testutil.NewMockLogger()
+	var exitCode int
+
+	ctx := &ExecutionContext{
+		vtproPid:    1234,
+		log:         log,
+		vtproClient: vtpro.NewClient(log).WithNeverTerminate(true),
+		exitFunc:    func(code int) { exitCode = code },
+	}
+
+	ctx.abort()
+
+	assert.Equal(t, ExitInterrupted, exitCode)
+	assert.Equal(t, uint32(1234), ctx.vtproClient.LeftRunning())
+	assert.Contains(t, log.Messages(), "Cleanup incomplete: VTPro was left running")
+}
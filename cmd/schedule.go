@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/config"
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/schedule"
+)
+
+// scheduleCheckInterval is how often --wait-for-window checks whether the window has opened
+const scheduleCheckInterval = 30 * time.Second
+
+// errScheduleWaitInterrupted is returned when the run is interrupted while waiting for the window
+var errScheduleWaitInterrupted = errcode.New(errcode.Interrupted, "interrupted while waiting for the maintenance window")
+
+// buildSchedule converts the schedule section of the config file, returning nil
+// when it has no windows and compiles may run at any time
+func buildSchedule(file *config.File) (*schedule.Schedule, error) {
+	if len(file.Schedule.Windows) == 0 {
+		if file.Schedule.Timezone != "" {
+			return nil, fmt.Errorf("schedule has a timezone but no windows")
+		}
+
+		return nil, nil
+	}
+
+	return schedule.New(file.Schedule.Timezone, file.Schedule.Windows)
+}
+
+// enforceSchedule refuses to compile outside the maintenance window, or with
+// --wait-for-window waits for it to open. Waiting logs when the window opens
+// every heartbeat and stops early if interrupted is closed.
+func enforceSchedule(cfg *Config, s *schedule.Schedule, clk clock.Clock, interrupted <-chan struct{}, log logger.LoggerInterface) error {
+	if s == nil {
+		return nil
+	}
+
+	if cfg.IgnoreSchedule {
+		log.Debug("Maintenance window not checked (--ignore-schedule)")
+		return nil
+	}
+
+	err := s.Check(clk.Now())
+	if err == nil {
+		log.Debug("Inside the maintenance window")
+		return nil
+	}
+
+	if !cfg.WaitForWindow {
+		log.Error("Refusing to compile", slog.Any("error", err))
+		return &ExitError{Code: ExitEnvironment, Err: fmt.Errorf("%w; pass --wait-for-window to wait for it or --ignore-schedule to compile now", err)}
+	}
+
+	next, ok := s.Next(clk.Now())
+	if !ok {
+		return &ExitError{Code: ExitEnvironment, Err: err}
+	}
+
+	log.Info("Waiting for the maintenance window",
+		slog.String("opens", schedule.FormatOpening(next)),
+		slog.String("in", schedule.FormatWait(next.Sub(clk.Now()))),
+	)
+
+	ticker := clk.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+
+	lastLog := clk.Now()
+
+	for {
+		select {
+		case <-interrupted:
+			log.Info("Interrupted while waiting for the maintenance window")
+			return &ExitError{Code: ExitInterrupted, Err: errScheduleWaitInterrupted}
+
+		case <-ticker.C():
+			now := clk.Now()
+
+			// Checked against the wall clock rather than counting ticks, so a machine that
+			// slept through the opening still compiles when it wakes
+			if s.Allows(now) {
+				log.Info("Maintenance window open, continuing")
+				return nil
+			}
+
+			if cfg.Heartbeat > 0 && now.Sub(lastLog) >= cfg.Heartbeat {
+				lastLog = now
+
+				if next, ok := s.Next(now); ok {
+					log.Info("Still waiting for the maintenance window",
+						slog.String("opens", schedule.FormatOpening(next)),
+						slog.String("in", schedule.FormatWait(next.Sub(now))),
+					)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/config"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/schedule"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+)
+
+// nightly allows compiles from 19:00 to 07:00 UTC
+func nightly(t *testing.T) *schedule.Schedule {
+	t.Helper()
+
+	s, err := schedule.New("UTC", map[string][]string{schedule.Daily: {"19:00-07:00"}})
+	require.NoError(t, err)
+
+	return s
+}
+
+// afternoon is 15:00 UTC on a Monday, four hours before the window opens
+var afternoon = time.Date(2025, time.March, 3, 15, 0, 0, 0, time.UTC)
+
+// runEnforceSchedule runs enforceSchedule in the background and returns its result channel
+func runEnforceSchedule(cfg *Config, s *schedule.Schedule, clk clock.Clock, interrupted <-chan struct{}, log logger.LoggerInterface) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- enforceSchedule(cfg, s, clk, interrupted, log) }()
+
+	return done
+}
+
+// advanceUntilDone moves the fake clock a check at a time until enforceSchedule returns
+func advanceUntilDone(t *testing.T, clk *clock.Fake, done <-chan error) error {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-done:
+			return err
+		default:
+			clk.Advance(scheduleCheckInterval)
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	t.Fatal("enforceSchedule did not return")
+
+	return nil
+}
+
+func TestBuildSchedule(t *testing.T) {
+	t.Parallel()
+
+	s, err := buildSchedule(&config.File{})
+	require.NoError(t, err)
+	assert.Nil(t, s, "no windows means no schedule")
+
+	_, err = buildSchedule(&config.File{Schedule: config.ScheduleConfig{Timezone: "UTC"}})
+	assert.EqualError(t, err, "schedule has a timezone but no windows")
+
+	s, err = buildSchedule(&config.File{Schedule: config.ScheduleConfig{Windows: map[string][]string{"monday": {"19:00-07:00"}}}})
+	require.NoError(t, err)
+	assert.NotNil(t, s)
+}
+
+func TestEnforceSchedule_InsideWindow(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(afternoon.Add(5 * time.Hour))
+	assert.NoError(t, enforceSchedule(&Config{}, nightly(t), clk, nil, logger.NewNoOpLogger()))
+	assert.NoError(t, enforceSchedule(&Config{}, nil, clk, nil, logger.NewNoOpLogger()), "no schedule")
+}
+
+func TestEnforceSchedule_OutsideWindowFails(t *testing.T) {
+	t.Parallel()
+
+	err := enforceSchedule(&Config{}, nightly(t), clock.NewFake(afternoon), nil, logger.NewNoOpLogger())
+
+	require.ErrorIs(t, err, schedule.ErrOutsideWindow)
+	assert.Equal(t, ExitEnvironment, ExitCode(err))
+	assert.Contains(t, err.Error(), "the next window opens Mon 19:00 UTC (in 4h0m)")
+	assert.Contains(t, err.Error(), "--wait-for-window")
+}
+
+func TestEnforceSchedule_IgnoreSchedule(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{IgnoreSchedule: true}
+	assert.NoError(t, enforceSchedule(cfg, nightly(t), clock.NewFake(afternoon), nil, logger.NewNoOpLogger()))
+}
+
+func TestEnforceSchedule_WaitsForWindow(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(afternoon)
+	log := testutil.NewMockLogger()
+	cfg := &Config{WaitForWindow: true, Heartbeat: time.Hour}
+
+	err := advanceUntilDone(t, clk, runEnforceSchedule(cfg, nightly(t), clk, nil, log))
+
+	require.NoError(t, err)
+	assert.False(t, clk.Now().Before(afternoon.Add(4*time.Hour)), "returned only once the window opened")
+	assert.Less(t, clk.Now().Sub(afternoon), 4*time.Hour+2*scheduleCheckInterval)
+
+	messages := log.Messages()
+	assert.Contains(t, messages, "Waiting for the maintenance window")
+	assert.Contains(t, messages, "Still waiting for the maintenance window")
+	assert.Contains(t, messages, "Maintenance window open, continuing")
+}
+
+func TestEnforceSchedule_WaitSurvivesSleep(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(afternoon)
+	done := runEnforceSchedule(&Config{WaitForWindow: true}, nightly(t), clk, nil, logger.NewNoOpLogger())
+
+	// The machine sleeps through the opening; the first check after waking sees the window open
+	time.Sleep(10 * time.Millisecond)
+	clk.Advance(6 * time.Hour)
+
+	assert.NoError(t, advanceUntilDone(t, clk, done))
+}
+
+func TestEnforceSchedule_InterruptStopsWait(t *testing.T) {
+	t.Parallel()
+
+	interrupted := make(chan struct{})
+	done := runEnforceSchedule(&Config{WaitForWindow: true}, nightly(t), clock.NewFake(afternoon), interrupted, logger.NewNoOpLogger())
+
+	close(interrupted)
+
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, errScheduleWaitInterrupted)
+		assert.Equal(t, ExitInterrupted, ExitCode(err))
+	case <-time.After(2 * time.Second):
+		t.Fatal("the wait ignored the interrupt")
+	}
+}
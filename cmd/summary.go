@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/artifact"
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/config"
+	"github.com/Norgate-AV/vtpc/internal/deploy"
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/policy"
+	"github.com/Norgate-AV/vtpc/internal/preflight"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/schedule"
+	"github.com/Norgate-AV/vtpc/internal/vtpfile"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// classifyFailure maps the error a run ended with to the cause shown in the exit banner
+func classifyFailure(err error, result *compiler.CompileResult) report.Cause {
+	switch {
+	case err == nil && (result == nil || !result.HasErrors):
+		return report.CauseNone
+	case errors.Is(err, ErrPanic):
+		return report.CauseInternalError
+	case errors.Is(err, compiler.ErrCompileCancelled):
+		return report.CauseCancelled
+	case errors.Is(err, compiler.ErrUnexpectedDialog):
+		return report.CauseUnknownDialog
+	case result != nil && result.HasErrors:
+		return report.CauseCompileErrors
+	case errors.Is(err, compiler.ErrInputBlocked):
+		return report.CauseInputBlocked
+	case errors.Is(err, compiler.ErrCompileTimeout):
+		return report.CauseCompileTimeout
+	case errors.Is(err, compiler.ErrSaveFailed):
+		return report.CauseSaveFailed
+	case errors.Is(err, artifact.ErrCorrupt):
+		return report.CauseBadArtifact
+	case errors.Is(err, deploy.ErrDeployFailed), errors.Is(err, deploy.ErrInvalidTarget):
+		return report.CauseDeployFailed
+	case errors.Is(err, policy.ErrViolation), errors.Is(err, policy.ErrInvalid):
+		return report.CausePolicy
+	case errors.Is(err, ErrBudgetExceeded):
+		return report.CauseBudgetExceeded
+	case errors.Is(err, vtpro.ErrVTProNotFound):
+		return report.CauseVTProNotFound
+	case errors.Is(err, vtpfile.ErrNotProject), errors.Is(err, preflight.ErrMissingSidecars), errors.Is(err, config.ErrInvalidProjectFile):
+		return report.CauseInvalidProject
+	case errors.Is(err, preflight.ErrLowDiskSpace), errors.Is(err, preflight.ErrNotWritable), errors.Is(err, preflight.ErrReadOnlyProject),
+		errors.Is(err, schedule.ErrOutsideWindow):
+		return report.CauseEnvironment
+	case errors.Is(err, errVTProNotReady):
+		return report.CauseVTProNotReady
+	default:
+		return report.CauseUnknown
+	}
+}
+
+// failureCode returns the error catalog code of the failure a run ended with,
+// "" if it succeeded. Compile errors and failures whose error has no code of
+// its own still get one, so a failed run always has a code.
+func failureCode(err error, result *compiler.CompileResult) errcode.Code {
+	switch classifyFailure(err, result) {
+	case report.CauseNone:
+		return ""
+	case report.CauseCompileErrors:
+		return errcode.CompileErrors
+	}
+
+	if code := errcode.Of(err); code != "" {
+		return code
+	}
+
+	return errcode.Unknown
+}
+
+// runOutcome is what a run produced, as far as it got
+type runOutcome struct {
+	project      string // Project file, once its path was validated
+	result       *compiler.CompileResult
+	artifacts    []string
+	artifactFrom artifact.Source       // Where the artifacts were found, if they were looked for
+	monitor      *windows.MonitorStats // Final window monitor stats, once VTPro was launched
+	selection    vtpro.Selection       // How the VTPro main window was chosen
+	vtproCPU     time.Duration         // CPU time VTPro used, read as it exited
+	timing       report.Timing         // Where the run's time went
+	dialogs      []report.DialogTime   // The dialogs open longest, for the timing
+	dialogCounts map[string]int        // Dialogs seen, by the name of the known dialog they were, for telemetry
+	checks       []artifact.Report     // What --verify-artifact found, if it ran
+	provenance   []report.Provenance   // What --provenance wrote, one per artifact
+	deployedTo   string                // Where --deploy uploaded the artifacts, without the password
+}
+
+// buildSummary collects what the exit banner shows about a run that ran from started to finished
+func buildSummary(err error, outcome runOutcome, logPath string, started, finished time.Time) report.Summary {
+	s := report.Summary{
+		Cause:        classifyFailure(err, outcome.result),
+		Code:         string(failureCode(err, outcome.result)),
+		Err:          err,
+		LogPath:      logPath,
+		Artifacts:    outcome.artifacts,
+		ArtifactFrom: string(outcome.artifactFrom),
+		DeployedTo:   outcome.deployedTo,
+		Duration:     finished.Sub(started),
+		StartedAt:    started,
+		FinishedAt:   finished,
+	}
+
+	if outcome.result != nil {
+		s.ErrorMessages = outcome.result.ErrorMessages
+		s.Size = outcome.result.Size
+		s.Concurrent = outcome.result.ConcurrentCompileDetected
+		s.Targets = reportTargets(outcome.result.Sections)
+	}
+
+	if outcome.monitor != nil {
+		s.Monitor = outcome.monitor.String()
+	}
+
+	if outcome.selection.Pid != 0 {
+		s.Window = outcome.selection.Rationale()
+	}
+
+	return s
+}
+
+// buildRun collects what report writers are given about a finished run
+func buildRun(summary report.Summary, outcome runOutcome) report.Run {
+	run := report.Run{
+		Project:    outcome.project,
+		Summary:    summary,
+		StartedAt:  report.Timestamp{Time: summary.StartedAt},
+		FinishedAt: report.Timestamp{Time: summary.FinishedAt},
+		Timing:     outcome.timing,
+		Provenance: outcome.provenance,
+	}
+
+	for _, c := range outcome.checks {
+		run.ArtifactChecks = append(run.ArtifactChecks, report.ArtifactCheck{
+			Path:              c.Path,
+			Entries:           c.Entries,
+			UncompressedBytes: c.UncompressedBytes,
+			Problems:          c.Problems,
+			Notes:             c.Notes,
+		})
+	}
+
+	if outcome.result != nil {
+		run.Warnings = outcome.result.Warnings
+		run.Errors = outcome.result.Errors
+		run.SizeBytes = outcome.result.SizeBytes
+		run.ProjectSize = outcome.result.ProjectSize
+		run.ProjectBytes = outcome.result.ProjectBytes
+		run.Keystroke = reportKeystroke(outcome.result.Keystroke)
+
+		for _, m := range outcome.result.Messages {
+			run.Messages = append(run.Messages, reportMessage(m))
+		}
+
+		run.Targets = reportTargets(outcome.result.Sections)
+
+		for _, m := range outcome.result.SuppressedByPage {
+			run.SuppressedByPage = append(run.SuppressedByPage, reportMessage(m))
+		}
+
+		for _, r := range outcome.result.Reclassified {
+			run.Reclassified = append(run.Reclassified, report.Reclassification{
+				RuleID: r.Message.RuleID,
+				Text:   r.Message.Text,
+				Target: r.Message.Target,
+				From:   r.Message.Severity.String(),
+				Action: string(r.Action),
+				Policy: r.Policy.String(),
+			})
+		}
+	}
+
+	return run
+}
+
+// reportTargets converts the per-target results for the banner and the reports
+func reportTargets(sections []compiler.TargetResult) []report.Target {
+	var targets []report.Target
+
+	for _, t := range sections {
+		targets = append(targets, report.Target{
+			Name:      t.Target,
+			Warnings:  t.Warnings,
+			Errors:    t.Errors,
+			Size:      t.Size,
+			SizeBytes: t.SizeBytes,
+		})
+	}
+
+	return targets
+}
+
+// reportKeystroke converts how the compile keystroke was sent for the reports
+func reportKeystroke(k compiler.KeystrokeReport) report.Keystroke {
+	rk := report.Keystroke{Method: string(k.Method), Acknowledged: k.Acknowledged, Watched: k.Watched}
+
+	for _, m := range k.Failed {
+		rk.Failed = append(rk.Failed, string(m))
+	}
+
+	return rk
+}
+
+// reportMessage converts a parsed message for the report writers
+func reportMessage(m compiler.Message) report.Message {
+	return report.Message{
+		Severity: m.Severity.String(),
+		Text:     m.Text,
+		Target:   m.Target,
+		RuleID:   m.RuleID,
+		Page:     m.Page(),
+		Object:   m.Object(),
+	}
+}
@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/artifact"
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/deploy"
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/policy"
+	"github.com/Norgate-AV/vtpc/internal/preflight"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/vtpfile"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	t.Parallel()
+
+	failed := &compiler.CompileResult{HasErrors: true, Errors: 2}
+
+	tests := []struct {
+		name   string
+		err    error
+		result *compiler.CompileResult
+		want   report.Cause
+	}{
+		{"success", nil, &compiler.CompileResult{}, report.CauseNone},
+		{"compile errors", errors.New("compilation failed with 2 error(s)"), failed, report.CauseCompileErrors},
+		{"cancelled", &ExitError{Code: ExitCancelled, Err: compiler.ErrCompileCancelled}, nil, report.CauseCancelled},
+		{"compile timeout", fmt.Errorf("%w: compilation did not complete", compiler.ErrCompileTimeout), nil, report.CauseCompileTimeout},
+		{"input blocked", fmt.Errorf("%w 'Setup' (setup.exe)", compiler.ErrInputBlocked), nil, report.CauseInputBlocked},
+		{"save failed", fmt.Errorf("%w: Access is denied", compiler.ErrSaveFailed), nil, report.CauseSaveFailed},
+		{"panic", &PanicError{Value: "index out of range"}, nil, report.CauseInternalError},
+		{"deploy failed", &ExitError{Code: ExitDeploy, Err: fmt.Errorf("%w: lobby.vtz: login rejected", deploy.ErrDeployFailed)}, nil, report.CauseDeployFailed},
+		{"over budget", &ExitError{Code: ExitBudget, Err: fmt.Errorf("%w: compiling took 4m0s", ErrBudgetExceeded)}, &compiler.CompileResult{}, report.CauseBudgetExceeded},
+		{"outside allowed roots", &ExitError{Code: ExitPolicy, Err: fmt.Errorf("%w: D:\\lobby.vtp", policy.ErrViolation)}, nil, report.CausePolicy},
+		{"broken policy", &ExitError{Code: ExitPolicy, Err: fmt.Errorf("%w: failed to parse", policy.ErrInvalid)}, nil, report.CausePolicy},
+		{"corrupt artifact", fmt.Errorf("%w: lobby.vtz: no pages entry", artifact.ErrCorrupt), nil, report.CauseBadArtifact},
+		{"unknown dialog", fmt.Errorf("%w \"Trial expired\"", compiler.ErrUnexpectedDialog), failed, report.CauseUnknownDialog},
+		{"vtpro not found", fmt.Errorf("%w at default path: x", vtpro.ErrVTProNotFound), nil, report.CauseVTProNotFound},
+		{"vtpro not ready", fmt.Errorf("%w: window appeared but is not responding properly", errVTProNotReady), nil, report.CauseVTProNotReady},
+		{"invalid project", fmt.Errorf("%w: lobby.vtp is empty", vtpfile.ErrNotProject), nil, report.CauseInvalidProject},
+		{"missing sidecars", fmt.Errorf("%w: lobby.vta", preflight.ErrMissingSidecars), nil, report.CauseInvalidProject},
+		{"low disk space", &ExitError{Code: ExitEnvironment, Err: fmt.Errorf("%w on C:", preflight.ErrLowDiskSpace)}, nil, report.CauseEnvironment},
+		{"output not writable", &ExitError{Code: ExitEnvironment, Err: fmt.Errorf("%w: D:", preflight.ErrNotWritable)}, nil, report.CauseEnvironment},
+		{"read-only project", &ExitError{Code: ExitEnvironment, Err: fmt.Errorf("%w: \\\\nas\\lobby", preflight.ErrReadOnlyProject)}, nil, report.CauseEnvironment},
+		{"unknown", errors.New("file does not exist: lobby.vtp"), nil, report.CauseUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyFailure(tt.err, tt.result))
+		})
+	}
+}
+
+func TestFailureCode(t *testing.T) {
+	t.Parallel()
+
+	failed := &compiler.CompileResult{HasErrors: true, Errors: 2}
+
+	tests := []struct {
+		name   string
+		err    error
+		result *compiler.CompileResult
+		want   errcode.Code
+	}{
+		{"success", nil, &compiler.CompileResult{}, ""},
+		{"compile errors", errors.New("compilation failed with 2 error(s)"), failed, errcode.CompileErrors},
+		{"unknown dialog outranks compile errors", fmt.Errorf("%w \"Trial expired\"", compiler.ErrUnexpectedDialog), failed, errcode.UnexpectedDialog},
+		{"wrapped sentinel", &ExitError{Code: ExitEnvironment, Err: fmt.Errorf("%w on C:", preflight.ErrLowDiskSpace)}, nil, errcode.DiskSpace},
+		{"focus", fmt.Errorf("compile: %w", compiler.ErrForegroundDenied), nil, errcode.Focus},
+		{"error without a code", errors.New("file does not exist: lobby.vtp"), nil, errcode.Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, failureCode(tt.err, tt.result))
+		})
+	}
+}
+
+// summaryStart is when the runs in these tests started
+var summaryStart = time.Date(2025, 2, 14, 16, 30, 0, 0, time.FixedZone("", -8*60*60))
+
+func TestBuildSummary(t *testing.T) {
+	t.Parallel()
+
+	result := &compiler.CompileResult{
+		HasErrors:     true,
+		Errors:        1,
+		ErrorMessages: []string{"Join 12 is undefined"},
+		Size:          "1,024 bytes",
+	}
+
+	s := buildSummary(errors.New("compilation failed with 1 error(s)"), runOutcome{result: result}, `C:\vtpc.log`, summaryStart, summaryStart.Add(time.Minute))
+
+	assert.Equal(t, report.CauseCompileErrors, s.Cause)
+	assert.Equal(t, string(errcode.CompileErrors), s.Code)
+	assert.Equal(t, []string{"Join 12 is undefined"}, s.ErrorMessages)
+	assert.Equal(t, "1,024 bytes", s.Size)
+	assert.Equal(t, `C:\vtpc.log`, s.LogPath)
+	assert.Equal(t, time.Minute, s.Duration)
+	assert.Equal(t, summaryStart, s.StartedAt)
+	assert.Equal(t, summaryStart.Add(time.Minute), s.FinishedAt)
+}
+
+func TestBuildSummary_ConcurrentCompile(t *testing.T) {
+	t.Parallel()
+
+	s := buildSummary(nil, runOutcome{result: &compiler.CompileResult{ConcurrentCompileDetected: true}}, "", summaryStart, summaryStart.Add(time.Second))
+	assert.True(t, s.Concurrent)
+
+	s = buildSummary(nil, runOutcome{result: &compiler.CompileResult{}}, "", summaryStart, summaryStart.Add(time.Second))
+	assert.False(t, s.Concurrent)
+}
+
+func TestBuildSummary_ArtifactSource(t *testing.T) {
+	t.Parallel()
+
+	s := buildSummary(nil, runOutcome{
+		result:       &compiler.CompileResult{Size: "2,048 bytes"},
+		artifacts:    []string{`D:\Builds\lobby.vtz`},
+		artifactFrom: artifact.SourcePreferences,
+	}, "", summaryStart, summaryStart.Add(time.Second))
+
+	assert.Equal(t, report.CauseNone, s.Cause)
+	assert.Equal(t, []string{`D:\Builds\lobby.vtz`}, s.Artifacts)
+	assert.Equal(t, "VTPro preferences", s.ArtifactFrom)
+}
+
+func TestBuildSummary_MonitorStats(t *testing.T) {
+	t.Parallel()
+
+	stats := windows.MonitorStats{Polls: 4, EventsDropped: 1}
+	s := buildSummary(compiler.ErrCompileTimeout, runOutcome{monitor: &stats}, "", summaryStart, summaryStart.Add(time.Minute))
+
+	assert.Equal(t, stats.String(), s.Monitor)
+
+	s = buildSummary(compiler.ErrCompileTimeout, runOutcome{}, "", summaryStart, summaryStart.Add(time.Minute))
+	assert.Empty(t, s.Monitor, "no stats before VTPro was launched")
+}
+
+func TestBuildSummary_WindowSelection(t *testing.T) {
+	t.Parallel()
+
+	sel := vtpro.Selection{Pid: 1234}
+	s := buildSummary(errVTProNotReady, runOutcome{selection: sel}, "", summaryStart, summaryStart.Add(time.Minute))
+
+	assert.Equal(t, sel.Rationale(), s.Window)
+
+	s = buildSummary(errVTProNotReady, runOutcome{}, "", summaryStart, summaryStart.Add(time.Minute))
+	assert.Empty(t, s.Window, "no selection before VTPro was launched")
+}
+
+func TestBuildRun(t *testing.T) {
+	t.Parallel()
+
+	result := &compiler.CompileResult{
+		Warnings: 1,
+		Errors:   1,
+		Messages: []compiler.Message{
+			{Severity: compiler.SeverityWarning, Text: `Object "Volume" on Page "Main" Unassigned Smart Object ID`, Target: "TSW-770", RuleID: "unassigned-smart-object-id"},
+			{Severity: compiler.SeverityError, Text: "Join 12 is undefined", Target: "TSW-770", RuleID: "missing-join"},
+		},
+	}
+
+	summary := report.Summary{
+		Cause:      report.CauseCompileErrors,
+		StartedAt:  summaryStart,
+		FinishedAt: summaryStart.Add(time.Minute),
+	}
+	run := buildRun(summary, runOutcome{project: `C:\lobby.vtp`, result: result})
+
+	assert.Equal(t, `C:\lobby.vtp`, run.Project)
+	assert.Equal(t, summary, run.Summary)
+	assert.Equal(t, summaryStart, run.StartedAt.Time)
+	assert.Equal(t, summaryStart.Add(time.Minute), run.FinishedAt.Time)
+	assert.Equal(t, 1, run.Warnings)
+	assert.Equal(t, 1, run.Errors)
+	assert.Equal(t, []report.Message{
+		{Severity: "warning", Text: `Object "Volume" on Page "Main" Unassigned Smart Object ID`, Target: "TSW-770", RuleID: "unassigned-smart-object-id", Page: "Main", Object: "Volume"},
+		{Severity: "error", Text: "Join 12 is undefined", Target: "TSW-770", RuleID: "missing-join"},
+	}, run.Messages)
+}
+
+func TestBuildRun_Keystroke(t *testing.T) {
+	t.Parallel()
+
+	run := buildRun(report.Summary{}, runOutcome{result: &compiler.CompileResult{
+		Keystroke: compiler.KeystrokeReport{
+			Method:       compiler.InputKeybdEvent,
+			Failed:       []compiler.InputMethod{compiler.InputSendInput},
+			Acknowledged: true,
+			Watched:      true,
+		},
+	}})
+
+	assert.Equal(t, report.Keystroke{Method: "keybd_event", Failed: []string{"sendinput"}, Acknowledged: true, Watched: true}, run.Keystroke)
+}
+
+func TestBuildRun_Targets(t *testing.T) {
+	t.Parallel()
+
+	run := buildRun(report.Summary{}, runOutcome{result: &compiler.CompileResult{
+		SizeBytes:    2048,
+		ProjectSize:  "12 Kb",
+		ProjectBytes: 12288,
+		Sections: []compiler.TargetResult{
+			{Target: "TSW-770", Warnings: 2, Size: "1,024 bytes", SizeBytes: 1024},
+			{Target: "TSW-1070", Errors: 1, Size: "1,024 bytes", SizeBytes: 1024},
+		},
+	}})
+
+	assert.Equal(t, int64(2048), run.SizeBytes)
+	assert.Equal(t, "12 Kb", run.ProjectSize)
+	assert.Equal(t, int64(12288), run.ProjectBytes)
+	assert.Equal(t, []report.Target{
+		{Name: "TSW-770", Warnings: 2, Size: "1,024 bytes", SizeBytes: 1024},
+		{Name: "TSW-1070", Errors: 1, Size: "1,024 bytes", SizeBytes: 1024},
+	}, run.Targets)
+}
+
+func TestBuildRun_SuppressedByPage(t *testing.T) {
+	t.Parallel()
+
+	run := buildRun(report.Summary{}, runOutcome{result: &compiler.CompileResult{
+		SuppressedByPage: []compiler.Message{
+			{Severity: compiler.SeverityError, Text: `Object "Mute" on Page "ZZ_Scratch" has an invalid join number.`, Target: "TSW-770", RuleID: "invalid-join"},
+		},
+	}})
+
+	assert.Empty(t, run.Messages)
+	assert.Equal(t, []report.Message{
+		{Severity: "error", Text: `Object "Mute" on Page "ZZ_Scratch" has an invalid join number.`, Target: "TSW-770", RuleID: "invalid-join", Page: "ZZ_Scratch", Object: "Mute"},
+	}, run.SuppressedByPage)
+}
+
+func TestBuildRun_Reclassified(t *testing.T) {
+	t.Parallel()
+
+	original := compiler.Message{
+		Severity: compiler.SeverityWarning,
+		Text:     `The file path C:\x.png exceeds the windows path limitations`,
+		Target:   "TSW-770",
+		RuleID:   "path-length-warning",
+	}
+	entry := compiler.PolicyEntry{RuleID: "path-length-warning", Action: compiler.ActionError}
+
+	run := buildRun(report.Summary{}, runOutcome{result: &compiler.CompileResult{
+		Reclassified: []compiler.Reclassification{{Message: original, Action: compiler.ActionError, Policy: entry}},
+	}})
+
+	assert.Equal(t, []report.Reclassification{{
+		RuleID: "path-length-warning",
+		Text:   original.Text,
+		Target: "TSW-770",
+		From:   "warning",
+		Action: "error",
+		Policy: "path-length-warning: error",
+	}}, run.Reclassified)
+}
+
+func TestBuildRun_BeforeCompile(t *testing.T) {
+	t.Parallel()
+
+	run := buildRun(report.Summary{Cause: report.CauseVTProNotFound}, runOutcome{})
+
+	assert.Empty(t, run.Messages)
+	assert.Equal(t, report.CauseVTProNotFound, run.Summary.Cause)
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fatih/color"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// tableWidth returns the console width, or compiler.DefaultTableWidth when
+// stdout is not a console
+func tableWidth() int {
+	if w := windows.ConsoleWidth(); w > 0 {
+		return w
+	}
+
+	return compiler.DefaultTableWidth
+}
+
+// printMessageTable writes the Message Log's warnings and errors to w as a table
+// for --format table, and the same table without color to the log file
+func printMessageTable(w io.Writer, result *compiler.CompileResult, order compiler.MessageOrder, width int, colored bool, log logger.LoggerInterface) {
+	// Only a compile whose Message Log was read has messages worth tabulating
+	if result == nil || len(result.Sections) == 0 || len(result.Messages) == 0 {
+		return
+	}
+
+	messages := compiler.OrderMessages(result.Messages, order)
+
+	fmt.Fprintln(w)
+	for _, line := range compiler.FormatMessageTable(messages, compiler.TableOptions{Width: width, Color: colored}) {
+		fmt.Fprintln(w, line)
+	}
+
+	for _, line := range compiler.FormatMessageTable(messages, compiler.TableOptions{Width: width}) {
+		log.Trace(line)
+	}
+}
+
+// colorEnabled reports whether console output should be colored
+func colorEnabled() bool {
+	return !color.NoColor
+}
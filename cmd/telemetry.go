@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Norgate-AV/vtpc/internal/config"
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/telemetry"
+	"github.com/Norgate-AV/vtpc/internal/timeouts"
+	"github.com/Norgate-AV/vtpc/internal/version"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// telemetryCmd groups the commands that manage the opt-in telemetry
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Turn the anonymous usage and reliability telemetry on or off, or show whether it is on",
+}
+
+var telemetryOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Send an anonymous summary of each run to the configured endpoint",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return setTelemetry(cmd.OutOrStdout(), configFilePath(NewConfigFromFlags(cmd)), telemetryIDPath(), true)
+	},
+}
+
+var telemetryOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Stop sending telemetry and forget this install's ID",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return setTelemetry(cmd.OutOrStdout(), configFilePath(NewConfigFromFlags(cmd)), telemetryIDPath(), false)
+	},
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether telemetry is on and where it is sent",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return writeTelemetryStatus(cmd.OutOrStdout(), configFilePath(NewConfigFromFlags(cmd)), telemetryIDPath())
+	},
+}
+
+func init() {
+	telemetryCmd.AddCommand(telemetryOnCmd, telemetryOffCmd, telemetryStatusCmd)
+	RootCmd.AddCommand(telemetryCmd)
+}
+
+// telemetryIDPath returns where the telemetry install ID is kept, next to the log file
+func telemetryIDPath() string {
+	return filepath.Join(filepath.Dir(logger.GetLogPath(logger.LoggerOptions{})), telemetry.InstallIDFile)
+}
+
+// setTelemetry turns telemetry on or off in the config file at path and shows
+// the result. Turning it off also forgets the install ID at idPath.
+func setTelemetry(w io.Writer, path, idPath string, enabled bool) error {
+	if err := config.SetTelemetry(path, enabled); err != nil {
+		return err
+	}
+
+	if !enabled {
+		if err := telemetry.ForgetInstallID(idPath); err != nil {
+			return err
+		}
+	}
+
+	return writeTelemetryStatus(w, path, idPath)
+}
+
+// writeTelemetryStatus shows whether the config file at path turns telemetry
+// on, where it is sent and the install ID at idPath
+func writeTelemetryStatus(w io.Writer, path, idPath string) error {
+	file, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	settings, err := buildTelemetry(file)
+	if err != nil {
+		return err
+	}
+
+	state := config.TelemetryDisabled
+	if settings.Enabled() {
+		state = config.TelemetryEnabled
+	}
+
+	endpoint := settings.Endpoint
+	if endpoint == "" {
+		endpoint = "(none; nothing is sent until telemetry.endpoint is set)"
+	}
+
+	id := telemetry.LoadInstallID(idPath)
+	if id == "" {
+		id = "(none yet)"
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Telemetry:\t%s\n", state)
+	fmt.Fprintf(tw, "Endpoint:\t%s\n", endpoint)
+	fmt.Fprintf(tw, "Install ID:\t%s\n", id)
+	fmt.Fprintf(tw, "Config file:\t%s\n", path)
+
+	return tw.Flush()
+}
+
+// buildTelemetry checks the telemetry section of the config file
+func buildTelemetry(file *config.File) (config.TelemetryConfig, error) {
+	t := file.Telemetry
+
+	switch t.State {
+	case "", config.TelemetryEnabled, config.TelemetryDisabled:
+	default:
+		return t, fmt.Errorf("config telemetry must be %q or %q, got %q", config.TelemetryEnabled, config.TelemetryDisabled, t.State)
+	}
+
+	if t.Endpoint != "" {
+		if err := telemetry.ValidateEndpoint(t.Endpoint); err != nil {
+			return t, fmt.Errorf("config telemetry.endpoint: %w", err)
+		}
+	}
+
+	return t, nil
+}
+
+// dialogCounts counts the dialogs in the window monitor's spans by the name of
+// the known dialog each one was, so telemetry never carries a window title.
+// VTPro's main window and untitled windows vtpc does not know are left out;
+// other unknown dialogs are counted as "unknown".
+func dialogCounts(spans []windows.DialogSpan, main uintptr, registry *dialog.Registry) map[string]int {
+	counts := make(map[string]int)
+
+	for _, s := range spans {
+		if s.Hwnd == main {
+			continue
+		}
+
+		name := ""
+		for _, d := range registry.All() {
+			if d.Matches(s.Title, s.Class) {
+				name = d.Name
+				break
+			}
+		}
+
+		if name == "" {
+			if windows.IsUntitled(s.Title) {
+				continue
+			}
+
+			name = "unknown"
+		}
+
+		counts[name]++
+	}
+
+	return counts
+}
+
+// telemetryEnvironment collects what telemetry sends beyond the run's report
+func telemetryEnvironment(dialogs map[string]int, log logger.LoggerInterface) telemetry.Environment {
+	env := telemetry.Environment{
+		VtpcVersion: version.GetVersion(),
+		OSBuild:     windows.OSBuild(),
+		Dialogs:     dialogs,
+	}
+
+	var err error
+	if env.VTProVersion, err = windows.FileVersion(vtpro.GetVTProPath()); err != nil {
+		log.Debug("Could not read the VTPro version for telemetry", slog.Any("error", err))
+	}
+
+	return env
+}
+
+// sendTelemetry posts the run's anonymous summary to the configured endpoint,
+// if telemetry is on and has one. Telemetry must never get in the way of a
+// run, so a failure to send is only logged at debug level.
+func sendTelemetry(settings config.TelemetryConfig, run *report.Run, env telemetry.Environment, idPath string, client *http.Client, log logger.LoggerInterface) {
+	if !settings.Enabled() {
+		return
+	}
+
+	if settings.Endpoint == "" {
+		log.Debug("Telemetry is enabled but has no endpoint, so nothing was sent")
+		return
+	}
+
+	id, err := telemetry.InstallID(idPath, rand.Reader)
+	if err != nil {
+		log.Debug("Could not send telemetry", slog.Any("error", err))
+		return
+	}
+
+	env.InstallID = id
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.TelemetrySendTimeout)
+	defer cancel()
+
+	if err := telemetry.Send(ctx, client, settings.Endpoint, telemetry.NewPayload(run, env)); err != nil {
+		log.Debug("Could not send telemetry", slog.Any("error", err))
+		return
+	}
+
+	log.Debug("Sent telemetry", slog.String("endpoint", settings.Endpoint))
+}
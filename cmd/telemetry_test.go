@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/config"
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/telemetry"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestBuildTelemetry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		file    config.TelemetryConfig
+		wantErr string
+	}{
+		{name: "not set"},
+		{name: "enabled", file: config.TelemetryConfig{State: config.TelemetryEnabled, Endpoint: "https://telemetry.example.com/v1"}},
+		{name: "disabled", file: config.TelemetryConfig{State: config.TelemetryDisabled}},
+		{name: "unknown state", file: config.TelemetryConfig{State: "yes"}, wantErr: `config telemetry must be "enabled" or "disabled", got "yes"`},
+		{name: "bad endpoint", file: config.TelemetryConfig{State: config.TelemetryEnabled, Endpoint: "telemetry.example.com"}, wantErr: "config telemetry.endpoint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := buildTelemetry(&config.File{Telemetry: tt.file})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.file, got)
+		})
+	}
+}
+
+func TestDialogCounts(t *testing.T) {
+	t.Parallel()
+
+	counts := dialogCounts([]windows.DialogSpan{
+		{Hwnd: 0x9999, Title: "VisionTools Pro-e - [Boardroom.vtp]"},
+		{Hwnd: 0x1111, Title: dialog.Compiling.Title, Class: windows.DialogClass},
+		{Hwnd: 0x2222, Title: dialog.AddressBook.Title, Class: windows.DialogClass},
+		{Hwnd: 0x3333, Title: dialog.AddressBook.Title, Class: windows.DialogClass},
+		{Hwnd: 0x4444, Title: `C:\Users\jsmith\Boardroom.vtp - Notepad`},
+		{Hwnd: 0x5555, Title: ""},
+	}, 0x9999, dialog.Default())
+
+	assert.Equal(t, map[string]int{"compiling": 1, "address-book": 2, "unknown": 1}, counts,
+		"titles are replaced by dialog names, and the main window and untitled windows are left out")
+}
+
+func TestSendTelemetry(t *testing.T) {
+	t.Parallel()
+
+	var got telemetry.Payload
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	idPath := filepath.Join(t.TempDir(), telemetry.InstallIDFile)
+	settings := config.TelemetryConfig{State: config.TelemetryEnabled, Endpoint: srv.URL}
+	run := &report.Run{Project: `C:\Users\jsmith\Boardroom.vtp`, Summary: report.Summary{Duration: 40 * time.Second}}
+	env := telemetry.Environment{VtpcVersion: "1.4.0", Dialogs: map[string]int{"address-book": 1}}
+
+	log := testutil.NewMockLogger()
+	sendTelemetry(settings, run, env, idPath, srv.Client(), log)
+
+	assert.Equal(t, telemetry.Payload{
+		InstallID:   telemetry.LoadInstallID(idPath),
+		VtpcVersion: "1.4.0",
+		Outcome:     report.StatusOK,
+		Duration:    "30s-1m",
+		Dialogs:     map[string]int{"address-book": 1},
+	}, got)
+	assert.NotEmpty(t, got.InstallID)
+	assert.Equal(t, []string{"Sent telemetry"}, log.Messages())
+}
+
+func TestSendTelemetry_NotSent(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("telemetry was sent")
+	}))
+	defer srv.Close()
+
+	for _, settings := range []config.TelemetryConfig{
+		{Endpoint: srv.URL},
+		{State: config.TelemetryDisabled, Endpoint: srv.URL},
+		{State: config.TelemetryEnabled},
+	} {
+		idPath := filepath.Join(t.TempDir(), telemetry.InstallIDFile)
+
+		sendTelemetry(settings, &report.Run{}, telemetry.Environment{}, idPath, srv.Client(), testutil.NewMockLogger())
+
+		assert.NoFileExists(t, idPath, "no install ID is created while telemetry is off")
+	}
+}
+
+func TestSendTelemetry_FailsSilently(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	endpoint := srv.URL
+	srv.Close()
+
+	log := testutil.NewMockLogger()
+	settings := config.TelemetryConfig{State: config.TelemetryEnabled, Endpoint: endpoint}
+
+	sendTelemetry(settings, &report.Run{}, telemetry.Environment{}, filepath.Join(t.TempDir(), telemetry.InstallIDFile), http.DefaultClient, log)
+
+	require.NotEmpty(t, log.Entries)
+	for _, e := range log.Entries {
+		assert.Equal(t, "DEBUG", e.Level, e.Message)
+	}
+}
+
+func TestSetTelemetry(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, config.FileName)
+	idPath := filepath.Join(dir, telemetry.InstallIDFile)
+
+	var out bytes.Buffer
+	require.NoError(t, setTelemetry(&out, path, idPath, true))
+	assert.Contains(t, out.String(), "Telemetry:    enabled")
+	assert.Contains(t, out.String(), "nothing is sent until telemetry.endpoint is set")
+
+	_, err := telemetry.InstallID(idPath, bytes.NewReader(bytes.Repeat([]byte{1}, 16)))
+	require.NoError(t, err)
+
+	out.Reset()
+	require.NoError(t, setTelemetry(&out, path, idPath, false))
+	assert.Contains(t, out.String(), "Telemetry:    disabled")
+	assert.Contains(t, out.String(), "Install ID:   (none yet)")
+	assert.NoFileExists(t, idPath, "turning telemetry off forgets the install ID")
+}
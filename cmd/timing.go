@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// maxDialogTimes is how many of the longest-open dialogs a run's timing lists
+const maxDialogTimes = 5
+
+// phaseTimer splits a run's wall time into the phases it went through
+type phaseTimer struct {
+	clk     clock.Clock
+	started time.Time // When the run started
+	current string    // Phase in progress
+	since   time.Time // When the phase in progress started
+	phases  []report.Phase
+}
+
+// newPhaseTimer starts timing a run that started at start, in the launch phase
+func newPhaseTimer(clk clock.Clock, start time.Time) *phaseTimer {
+	return &phaseTimer{clk: clk, started: start, current: report.PhaseLaunch, since: start}
+}
+
+// begin ends the phase in progress and starts the named one
+func (p *phaseTimer) begin(name string) {
+	if name == p.current {
+		return
+	}
+
+	now := p.clk.Now()
+	p.phases = append(p.phases, report.Phase{Name: p.current, Duration: now.Sub(p.since)})
+	p.current, p.since = name, now
+}
+
+// finish ends the phase in progress and returns the run's timing up to now
+func (p *phaseTimer) finish() report.Timing {
+	now := p.clk.Now()
+	phases := append(p.phases, report.Phase{Name: p.current, Duration: now.Sub(p.since)})
+
+	return report.Timing{Wall: now.Sub(p.started), Phases: phases}
+}
+
+// dialogTimes returns how long each VTPro dialog stayed open, longest first,
+// from the window monitor's spans. VTPro's main window, untitled windows and
+// the Compiling dialog, which is open for the whole compile, are left out.
+func dialogTimes(spans []windows.DialogSpan, main uintptr) []report.DialogTime {
+	var times []report.DialogTime
+
+	for _, s := range spans {
+		if s.Hwnd == main || windows.IsUntitled(s.Title) || dialog.Compiling.Matches(s.Title, s.Class) {
+			continue
+		}
+
+		times = append(times, report.DialogTime{Title: s.Title, Duration: s.Duration, Open: s.Open})
+	}
+
+	return times
+}
+
+// dialogsSince returns the spans of the windows opened at or after since. A
+// VTPro the daemon keeps open has one monitor run for many compiles, so each
+// compile leaves out the dialogs the ones before it saw. A zero since keeps all.
+func dialogsSince(spans []windows.DialogSpan, since time.Time) []windows.DialogSpan {
+	if since.IsZero() {
+		return spans
+	}
+
+	var kept []windows.DialogSpan
+
+	for _, s := range spans {
+		if !s.Opened.Before(since) {
+			kept = append(kept, s)
+		}
+	}
+
+	return kept
+}
+
+// warnSlowDialogs logs a warning for each dialog that stayed open longer than
+// threshold, if it is set. times are longest first.
+func warnSlowDialogs(log logger.LoggerInterface, times []report.DialogTime, threshold time.Duration) {
+	if threshold <= 0 {
+		return
+	}
+
+	for _, d := range times {
+		if d.Duration <= threshold {
+			return
+		}
+
+		log.Warn("Dialog stayed open longer than expected",
+			slog.String("title", d.Title),
+			slog.Duration("open", d.Duration.Round(time.Millisecond)),
+			slog.Duration("threshold", threshold),
+			slog.Bool("stillOpen", d.Open),
+		)
+	}
+}
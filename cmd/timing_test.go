@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestPhaseTimer(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC))
+	timer := newPhaseTimer(clk, clk.Now())
+
+	clk.Advance(4 * time.Second)
+	timer.begin(report.PhaseWaits)
+	clk.Advance(3 * time.Second)
+	timer.begin(report.PhaseCompile)
+	clk.Advance(12 * time.Second)
+	timer.begin(report.PhaseCleanup)
+	timer.begin(report.PhaseCleanup)
+	clk.Advance(time.Second)
+
+	timing := timer.finish()
+
+	assert.Equal(t, 20*time.Second, timing.Wall)
+	assert.Equal(t, []report.Phase{
+		{Name: report.PhaseLaunch, Duration: 4 * time.Second},
+		{Name: report.PhaseWaits, Duration: 3 * time.Second},
+		{Name: report.PhaseCompile, Duration: 12 * time.Second},
+		{Name: report.PhaseCleanup, Duration: time.Second},
+	}, timing.Phases)
+	assert.Equal(t, 8*time.Second, timing.Overhead())
+}
+
+func TestPhaseTimer_FailedDuringLaunch(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC))
+	timer := newPhaseTimer(clk, clk.Now())
+
+	clk.Advance(2 * time.Second)
+
+	timing := timer.finish()
+
+	assert.Equal(t, []report.Phase{{Name: report.PhaseLaunch, Duration: 2 * time.Second}}, timing.Phases)
+	assert.Equal(t, "automation overhead: 2.0s (launch 2.0s)", timing.OverheadLine())
+}
+
+func TestDialogTimes(t *testing.T) {
+	t.Parallel()
+
+	times := dialogTimes([]windows.DialogSpan{
+		{Hwnd: 0x9999, Title: "VisionTools Pro-e - [lobby.vtp]", Duration: 3 * time.Minute, Open: true},
+		{Hwnd: 0x1111, Title: dialog.Compiling.Title, Class: windows.DialogClass, Duration: 2 * time.Minute},
+		{Hwnd: 0x2222, Title: "Progress [99%]", Class: windows.DialogClass, Duration: 62 * time.Second},
+		{Hwnd: 0x3333, Title: "", Duration: 10 * time.Second},
+		{Hwnd: 0x4444, Title: dialog.AddressBook.Title, Class: windows.DialogClass, Duration: time.Second, Open: true},
+	}, 0x9999)
+
+	assert.Equal(t, []report.DialogTime{
+		{Title: "Progress [99%]", Duration: 62 * time.Second},
+		{Title: dialog.AddressBook.Title, Duration: time.Second, Open: true},
+	}, times, "the main window, the Compiling dialog and untitled windows are left out")
+}
+
+func TestDialogsSince(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	spans := []windows.DialogSpan{
+		{Hwnd: 0x1111, Title: "Progress [40%]", Opened: start.Add(-time.Minute)},
+		{Hwnd: 0x2222, Title: dialog.AddressBook.Title, Opened: start},
+		{Hwnd: 0x3333, Title: "Progress [99%]", Opened: start.Add(time.Second), Open: true},
+	}
+
+	assert.Equal(t, spans[1:], dialogsSince(spans, start), "a dialog from an earlier compile is left out")
+	assert.Equal(t, spans, dialogsSince(spans, time.Time{}))
+}
+
+func TestWarnSlowDialogs(t *testing.T) {
+	t.Parallel()
+
+	times := []report.DialogTime{
+		{Title: "Progress [99%]", Duration: 62 * time.Second},
+		{Title: "Progress [12%]", Duration: 31 * time.Second},
+		{Title: dialog.AddressBook.Title, Duration: 30 * time.Second},
+	}
+
+	log := testutil.NewMockLogger()
+	warnSlowDialogs(log, times, 30*time.Second)
+	assert.Equal(t, []string{"Dialog stayed open longer than expected", "Dialog stayed open longer than expected"}, log.Messages(),
+		"only dialogs open longer than the threshold")
+
+	log = testutil.NewMockLogger()
+	warnSlowDialogs(log, times, 0)
+	assert.Empty(t, log.Messages(), "0 disables the warning")
+}
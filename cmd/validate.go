@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/deploy"
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/profiling"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/secret"
+)
+
+// ErrInvalidFlags is returned when flags are missing a flag they depend on,
+// contradict each other or have values out of range
+var ErrInvalidFlags = errcode.New(errcode.InvalidFlags, "invalid flags")
+
+// Validate checks the flags make sense together, returning every problem found.
+// Each error names the flags involved so the fix is obvious.
+func (c *Config) Validate() error {
+	var errs []error
+
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidFlags}, args...)...))
+	}
+
+	if c.ShowLogs && c.FilePath != "" {
+		fail("--logs only prints the log file and cannot be combined with a file to compile (%s)", c.FilePath)
+	}
+
+	if len(c.Sidecars) > 0 && !c.Isolate {
+		fail("--sidecar requires --isolate")
+	}
+
+	if c.KeepTempOnFailure && !c.Isolate {
+		fail("--keep-temp-on-failure requires --isolate")
+	}
+
+	if c.isSet("cancel-poll-interval") {
+		if c.CancelFile == "" {
+			fail("--cancel-poll-interval requires --cancel-file")
+		}
+
+		if c.CancelPollInterval <= 0 {
+			fail("--cancel-poll-interval must be positive, got %s", c.CancelPollInterval)
+		}
+	}
+
+	if c.Heartbeat < 0 {
+		fail("--heartbeat must not be negative, got %s (use 0 to disable)", c.Heartbeat)
+	}
+
+	if c.MaxCompileTime < 0 {
+		fail("--max-compile-time must not be negative, got %s (use 0 to disable)", c.MaxCompileTime)
+	}
+
+	if c.SlowDialog < 0 {
+		fail("--slow-dialog must not be negative, got %s (use 0 to disable)", c.SlowDialog)
+	}
+
+	if c.isSet("slow-input-multiplier") && c.SlowInputMultiplier < 1 {
+		fail("--slow-input-multiplier must be at least 1, got %g", c.SlowInputMultiplier)
+	}
+
+	if _, err := clock.ParseSleepPolicy(c.OnSleep); err != nil {
+		fail("--on-sleep: %v", err)
+	}
+
+	if _, err := compiler.ParseMessageOrder(c.MessageOrder); err != nil {
+		fail("--message-order: %v", err)
+	}
+
+	if _, err := compiler.ParseMessageFormat(c.Format); err != nil {
+		fail("--format: %v", err)
+	}
+
+	if c.Output != "" && c.Output != outputText && c.Output != outputJSON {
+		fail("--output: invalid format %q: must be \"text\" or \"json\"", c.Output)
+	}
+
+	if _, err := compiler.NewPageFilter(c.IgnorePages); err != nil {
+		fail("--ignore-pages: %v", err)
+	}
+
+	if _, err := report.ParseLinkTemplate(c.MessageLinkTemplate); err != nil {
+		fail("--message-link-template: %v", err)
+	}
+
+	if c.Deploy != "" {
+		// The password may come from the environment, so only the URL itself is checked here
+		if _, err := deploy.ParseTarget(c.Deploy, func(string) string { return "" }); err != nil {
+			fail("--deploy: %v", err)
+		}
+	}
+
+	if _, err := profiling.ParseSpecs(c.Profiles); err != nil {
+		fail("--profile: %v", err)
+	}
+
+	if c.WaitForWindow && c.IgnoreSchedule {
+		fail("--wait-for-window and --ignore-schedule contradict each other")
+	}
+
+	if c.ForceCleanup && c.NeverTerminate {
+		fail("--force-cleanup and --never-terminate contradict each other")
+	}
+
+	if c.DeployPassword != "" {
+		if c.Deploy == "" {
+			fail("--deploy-password requires --deploy")
+		}
+
+		// A literal password would be visible in the process list, which is what the flag avoids
+		if !secret.IsReference(c.DeployPassword) {
+			fail("--deploy-password must be env:NAME, file:PATH or stdin:, not the password itself")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// isSet reports whether a flag was given on the command line
+func (c *Config) isSet(name string) bool {
+	return c.setFlags[name]
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr []string // Substrings of the error; nil means valid
+	}{
+		{
+			name: "defaults",
+			cfg:  Config{FilePath: "lobby.vtp", MessageOrder: "severity"},
+		},
+		{
+			name: "logs alone",
+			cfg:  Config{ShowLogs: true},
+		},
+		{
+			name:    "logs with a file",
+			cfg:     Config{ShowLogs: true, FilePath: "lobby.vtp"},
+			wantErr: []string{"--logs", "file to compile (lobby.vtp)"},
+		},
+		{
+			name:    "sidecar without isolate",
+			cfg:     Config{Sidecars: []string{"lobby.vta"}},
+			wantErr: []string{"--sidecar requires --isolate"},
+		},
+		{
+			name: "sidecar with isolate",
+			cfg:  Config{Sidecars: []string{"lobby.vta"}, Isolate: true},
+		},
+		{
+			name:    "keep temp without isolate",
+			cfg:     Config{KeepTempOnFailure: true},
+			wantErr: []string{"--keep-temp-on-failure requires --isolate"},
+		},
+		{
+			name:    "poll interval without cancel file",
+			cfg:     Config{CancelPollInterval: time.Second, setFlags: map[string]bool{"cancel-poll-interval": true}},
+			wantErr: []string{"--cancel-poll-interval requires --cancel-file"},
+		},
+		{
+			name: "default poll interval without cancel file",
+			cfg:  Config{CancelPollInterval: defaultCancelPollInterval},
+		},
+		{
+			name:    "zero poll interval",
+			cfg:     Config{CancelFile: "cancel", setFlags: map[string]bool{"cancel-poll-interval": true}},
+			wantErr: []string{"--cancel-poll-interval must be positive"},
+		},
+		{
+			name:    "negative heartbeat",
+			cfg:     Config{Heartbeat: -time.Second},
+			wantErr: []string{"--heartbeat must not be negative"},
+		},
+		{
+			name: "heartbeat disabled",
+			cfg:  Config{Heartbeat: 0},
+		},
+		{
+			name:    "unknown message order",
+			cfg:     Config{MessageOrder: "random"},
+			wantErr: []string{"--message-order", `"random"`},
+		},
+		{
+			name:    "unknown format",
+			cfg:     Config{Format: "grid"},
+			wantErr: []string{"--format", `"grid"`},
+		},
+		{
+			name: "json output",
+			cfg:  Config{Output: "json"},
+		},
+		{
+			name:    "unknown output",
+			cfg:     Config{Output: "yaml"},
+			wantErr: []string{"--output", `"yaml"`},
+		},
+		{
+			name: "deploy password reference",
+			cfg:  Config{Deploy: "sftp://admin@10.0.0.5/display", DeployPassword: "env:PANEL_PASSWORD"},
+		},
+		{
+			name:    "profile kind given twice",
+			cfg:     Config{Profiles: []string{"cpu=a.pprof", "cpu=b.pprof"}},
+			wantErr: []string{"--profile: cpu profile given more than once"},
+		},
+		{
+			name:    "wait for window with ignore schedule",
+			cfg:     Config{WaitForWindow: true, IgnoreSchedule: true},
+			wantErr: []string{"--wait-for-window and --ignore-schedule contradict each other"},
+		},
+		{
+			name:    "force cleanup with never terminate",
+			cfg:     Config{ForceCleanup: true, NeverTerminate: true},
+			wantErr: []string{"--force-cleanup and --never-terminate contradict each other"},
+		},
+		{
+			name:    "deploy password without deploy",
+			cfg:     Config{DeployPassword: "stdin:"},
+			wantErr: []string{"--deploy-password requires --deploy"},
+		},
+		{
+			name:    "literal deploy password",
+			cfg:     Config{Deploy: "sftp://admin@10.0.0.5/display", DeployPassword: "hunter2"},
+			wantErr: []string{"--deploy-password must be env:NAME, file:PATH or stdin:"},
+		},
+		{
+			name:    "negative compile time budget",
+			cfg:     Config{MaxCompileTime: -time.Minute},
+			wantErr: []string{"--max-compile-time must not be negative, got -1m0s (use 0 to disable)"},
+		},
+		{
+			name:    "negative slow dialog threshold",
+			cfg:     Config{SlowDialog: -time.Second},
+			wantErr: []string{"--slow-dialog must not be negative, got -1s (use 0 to disable)"},
+		},
+		{
+			name:    "unknown link template field",
+			cfg:     Config{MessageLinkTemplate: "vtpro://open?page={pg}"},
+			wantErr: []string{"--message-link-template: link template \"vtpro://open?page={pg}\" uses unknown field {pg}"},
+		},
+		{
+			name:    "slow input multiplier below one",
+			cfg:     Config{SlowInputMultiplier: 0.5, setFlags: map[string]bool{"slow-input-multiplier": true}},
+			wantErr: []string{"--slow-input-multiplier must be at least 1, got 0.5"},
+		},
+		{
+			name: "slow input multiplier of one",
+			cfg:  Config{SlowInputMultiplier: 1, setFlags: map[string]bool{"slow-input-multiplier": true}},
+		},
+		{
+			name:    "unknown sleep policy",
+			cfg:     Config{OnSleep: "wait"},
+			wantErr: []string{`--on-sleep: invalid sleep policy "wait": must be "extend", "fail" or "ignore"`},
+		},
+		{
+			name:    "invalid ignored page glob",
+			cfg:     Config{IgnorePages: []string{"ZZ_["}},
+			wantErr: []string{"--ignore-pages: invalid page pattern \"ZZ_[\": syntax error in pattern"},
+		},
+		{
+			name:    "every problem is reported",
+			cfg:     Config{Sidecars: []string{"x"}, KeepTempOnFailure: true},
+			wantErr: []string{"--sidecar requires --isolate", "--keep-temp-on-failure requires --isolate"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.cfg.Validate()
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrInvalidFlags)
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestNewConfigFromFlags_RecordsSetFlags(t *testing.T) {
+	t.Parallel()
+
+	c := &cobra.Command{Use: "test"}
+	c.PersistentFlags().Duration("cancel-poll-interval", defaultCancelPollInterval, "")
+	c.PersistentFlags().String("cancel-file", "", "")
+	require.NoError(t, c.ParseFlags([]string{"--cancel-poll-interval", "5s"}))
+
+	cfg := NewConfigFromFlags(c)
+
+	assert.True(t, cfg.isSet("cancel-poll-interval"))
+	assert.False(t, cfg.isSet("cancel-file"))
+	assert.ErrorContains(t, cfg.Validate(), "--cancel-poll-interval requires --cancel-file")
+}
+
+func TestRootCmd_HelpGroupsFlags(t *testing.T) {
+	resetFlags()
+
+	output := captureCommandOutput(t, []string{"--help"})
+
+	sections := []string{"Input Flags:", "Output Flags:", "Automation Flags:", "Logging Flags:", "Flags:"}
+	last := -1
+
+	for _, section := range sections {
+		idx := strings.Index(output, "\n"+section)
+		require.NotEqual(t, -1, idx, "help should have a %q section", section)
+		assert.Greater(t, idx, last, "%q should come after the previous section", section)
+		last = idx
+	}
+
+	// Flags are listed in their own section
+	input := output[strings.Index(output, "Input Flags:"):strings.Index(output, "Output Flags:")]
+	assert.Contains(t, input, "--expect-title")
+	assert.NotContains(t, input, "--verbose")
+}
+
+func TestRootCmd_EveryFlagHasAGroup(t *testing.T) {
+	t.Parallel()
+
+	RootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		assert.NotEmpty(t, flagGroup(f), "--%s is not in a help section", f.Name)
+	})
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/Norgate-AV/vtpc/internal/artifact"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// verifyArtifacts checks that each compiled artifact is a well-formed archive,
+// returning what was found for the report and an error if any is corrupt
+func verifyArtifacts(paths []string, projectBytes int64, log logger.LoggerInterface) ([]artifact.Report, error) {
+	if len(paths) == 0 {
+		log.Warn("No compiled artifact was found to verify")
+		return nil, nil
+	}
+
+	var (
+		reports []artifact.Report
+		errs    []error
+	)
+
+	for _, path := range paths {
+		r := artifact.Verify(path, projectBytes, artifact.DefaultRequirements)
+		reports = append(reports, r)
+
+		for _, note := range r.Notes {
+			log.Warn("Artifact check: "+note, slog.String("path", path))
+		}
+
+		if err := r.Err(); err != nil {
+			log.Error("Artifact is corrupt", slog.String("path", path), slog.Any("problems", r.Problems))
+			errs = append(errs, err)
+			continue
+		}
+
+		log.Info("Artifact verified",
+			slog.String("path", path),
+			slog.Int("entries", r.Entries),
+			slog.Uint64("uncompressedBytes", r.UncompressedBytes),
+		)
+	}
+
+	return reports, errors.Join(errs...)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/artifact"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+func TestVerifyArtifacts_NoArtifact(t *testing.T) {
+	t.Parallel()
+
+	reports, err := verifyArtifacts(nil, 0, logger.NewNoOpLogger())
+
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+}
+
+func TestVerifyArtifacts_CorruptArtifactFailsTheRun(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "lobby.vtz")
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+
+	reports, err := verifyArtifacts([]string{path}, 0, logger.NewNoOpLogger())
+
+	require.ErrorIs(t, err, artifact.ErrCorrupt)
+	assert.Equal(t, ExitFailure, ExitCode(err))
+	require.Len(t, reports, 1)
+	assert.False(t, reports[0].OK())
+}
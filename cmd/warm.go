@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+)
+
+// vtproSession is a VTPro with the project loaded, ready for the compile keystroke
+type vtproSession struct {
+	client   *vtpro.Client
+	pid      uint32
+	hwnd     uintptr
+	compiled bool // The compile ran to the end, so VTPro is back at its main window
+
+	// Only set for a VTPro the daemon keeps open
+	project string
+	options launchOptions
+	loaded  fileStamp            // The project file as VTPro last saw it
+	cpu     func() time.Duration // CPU time VTPro has used so far
+	cpuUsed time.Duration        // CPU time VTPro had used when the current compile took it
+	stop    func() time.Duration // Stops the window monitor and releases the process handle
+}
+
+// launchOptions are the settings VTPro and its client are launched with. A
+// VTPro launched with other options is not used for a compile.
+type launchOptions struct {
+	expectTitle    string
+	window         vtpro.WindowIdentity
+	minimized      bool
+	sleep          clock.SleepPolicy
+	neverTerminate bool
+}
+
+// newLaunchOptions returns the launch options a run's flags and config file ask for
+func newLaunchOptions(cfg *Config, settings runSettings) launchOptions {
+	return launchOptions{
+		expectTitle:    cfg.ExpectTitle,
+		window:         settings.window,
+		minimized:      cfg.LaunchMinimized,
+		sleep:          settings.sleep,
+		neverTerminate: cfg.NeverTerminate,
+	}
+}
+
+// fileStamp identifies a version of a file by its size and modification time
+type fileStamp struct {
+	size    int64
+	modTime int64
+}
+
+// statProject returns the project file's current stamp
+func statProject(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+
+	return fileStamp{size: info.Size(), modTime: info.ModTime().UnixNano()}, nil
+}
+
+// warmVTPro keeps VTPro open between the daemon's compiles, with the window
+// monitor still watching it, so compiling the same project again skips
+// launching VTPro and waiting for the project to load. The daemon compiles
+// one project at a time, so it keeps one VTPro.
+type warmVTPro struct {
+	log  *logger.SwitchingLogger // What the kept VTPro's client logs to
+	idle logger.LoggerInterface  // The daemon's log, used between compiles
+
+	// Replaced in tests
+	start   func(project string, opts launchOptions, log logger.LoggerInterface) (*vtproSession, error)
+	resolve func(sess *vtproSession) (uintptr, bool)
+	close   func(sess *vtproSession, log logger.LoggerInterface)
+	stat    func(path string) (fileStamp, error)
+
+	mu   sync.Mutex
+	sess *vtproSession
+}
+
+// newWarmVTPro creates a warmVTPro that logs to log between compiles
+func newWarmVTPro(log logger.LoggerInterface) *warmVTPro {
+	return &warmVTPro{
+		log:     logger.NewSwitching(log),
+		idle:    log,
+		start:   startSession,
+		resolve: resolveSession,
+		close:   closeSession,
+		stat:    statProject,
+	}
+}
+
+// open returns a VTPro with project loaded, the one kept open if it still
+// fits, or a new one. Until release, its client logs to log.
+func (w *warmVTPro) open(project string, opts launchOptions, log logger.LoggerInterface) (*vtproSession, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.log.Use(log)
+
+	if sess := w.sess; sess != nil {
+		reason := w.stale(sess, project, opts)
+		if reason == "" {
+			log.Info("Compiling on the VTPro kept open", slog.Uint64("pid", uint64(sess.pid)))
+			sess.compiled = false
+			sess.cpuUsed = sess.cpu()
+
+			return sess, nil
+		}
+
+		log.Info("Closing the VTPro kept open", slog.Uint64("pid", uint64(sess.pid)), slog.String("reason", reason))
+		w.sess = nil
+		w.close(sess, w.log)
+	}
+
+	// Taken before launching, so a change made while VTPro loads is seen next time
+	loaded, err := w.stat(project)
+	if err != nil {
+		w.log.Use(w.idle)
+		return nil, err
+	}
+
+	sess, err := w.start(project, opts, w.log)
+	if err != nil {
+		w.log.Use(w.idle)
+		return nil, err
+	}
+
+	sess.project = project
+	sess.options = opts
+	sess.loaded = loaded
+	w.sess = sess
+
+	return sess, nil
+}
+
+// stale returns why sess cannot be used to compile project with opts, or ""
+// if it can. It updates sess with VTPro's current main window.
+func (w *warmVTPro) stale(sess *vtproSession, project string, opts launchOptions) string {
+	if !strings.EqualFold(sess.project, project) {
+		return "another project was asked for"
+	}
+
+	if sess.options != opts {
+		return "the launch options changed"
+	}
+
+	// VTPro would compile what it loaded, not what is on disk now
+	if stamp, err := w.stat(project); err != nil || stamp != sess.loaded {
+		return "the project changed on disk"
+	}
+
+	hwnd, ok := w.resolve(sess)
+	if !ok {
+		return "its main window is gone"
+	}
+
+	sess.hwnd = hwnd
+
+	return ""
+}
+
+// release takes back the VTPro open returned once the compile is done with
+// it, err being the run's error. A compile that ran to the end leaves VTPro
+// open for the next one; otherwise VTPro is in an unknown state and closed.
+func (w *warmVTPro) release(sess *vtproSession, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	defer w.log.Use(w.idle)
+
+	switch {
+	case errors.Is(err, compiler.ErrUnexpectedDialog):
+		// --strict-dialogs leaves VTPro and the dialog on screen for inspection
+		w.log.Warn("Leaving VTPro open at the unexpected dialog", slog.Uint64("pid", uint64(sess.pid)))
+		w.forget(sess)
+
+	case errors.Is(err, ErrPanic):
+		// A panic during the compile has already closed VTPro
+		w.forget(sess)
+
+	case !sess.compiled:
+		w.log.Info("Closing VTPro, as the compile did not finish", slog.Uint64("pid", uint64(sess.pid)))
+		w.sess = nil
+		w.close(sess, w.log)
+
+	default:
+		// --save-first, for one, changes the project after VTPro loaded it
+		stamp, serr := w.stat(sess.project)
+		if serr != nil {
+			w.log.Info("Closing VTPro, as the project can no longer be read", slog.Any("error", serr))
+			w.sess = nil
+			w.close(sess, w.log)
+
+			return
+		}
+
+		sess.loaded = stamp
+		w.log.Debug("Keeping VTPro open for the next compile", slog.Uint64("pid", uint64(sess.pid)))
+	}
+}
+
+// forget stops watching a VTPro that is left as it is
+func (w *warmVTPro) forget(sess *vtproSession) {
+	w.sess = nil
+	sess.stop()
+}
+
+// Close closes the VTPro kept open, reporting whether there was one. The next
+// compile launches it again.
+func (w *warmVTPro) Close() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	sess := w.sess
+	if sess == nil {
+		return false
+	}
+
+	w.idle.Info("Closing the VTPro kept open", slog.Uint64("pid", uint64(sess.pid)))
+	w.sess = nil
+	w.close(sess, w.idle)
+
+	return true
+}
+
+// startSession launches VTPro on project and waits until it is ready to compile
+func startSession(project string, opts launchOptions, log logger.LoggerInterface) (*vtproSession, error) {
+	vtproClient := vtpro.NewClient(log).
+		WithProjectFile(project).
+		WithExpectTitle(opts.expectTitle).
+		WithWindowIdentity(opts.window).
+		WithLaunchMinimized(opts.minimized).
+		WithSleepPolicy(opts.sleep).
+		WithNeverTerminate(opts.neverTerminate)
+
+	pid, cpu, stop, err := launchVTPro(vtproClient, project, opts.minimized, log)
+	if err != nil {
+		return nil, err
+	}
+
+	hwnd, err := waitForWindowReady(vtproClient, pid, log)
+	if err != nil {
+		// The daemon would otherwise leave it running with nothing to close it
+		vtproClient.ForceCleanup(0, pid)
+		stop()
+		warnLeftRunning(vtproClient, log)
+
+		return nil, err
+	}
+
+	return &vtproSession{client: vtproClient, pid: pid, hwnd: hwnd, cpu: cpu, stop: stop}, nil
+}
+
+// resolveSession returns VTPro's current main window, if it still has one
+func resolveSession(sess *vtproSession) (uintptr, bool) {
+	return sess.client.ResolveMainWindow(sess.pid, sess.hwnd)
+}
+
+// closeSession closes VTPro, then stops the window monitor watching it
+func closeSession(sess *vtproSession, log logger.LoggerInterface) {
+	hwnd := sess.hwnd
+	if resolved, found := resolveSession(sess); found {
+		hwnd = resolved
+	}
+
+	sess.client.Cleanup(hwnd, sess.pid)
+	sess.stop()
+	warnLeftRunning(sess.client, log)
+}
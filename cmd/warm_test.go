@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+)
+
+// fakeVTPros stands in for launching, finding and closing VTPro
+type fakeVTPros struct {
+	launched []string // Project of each launch
+	closed   []uint32 // PID of each VTPro closed
+	stopped  []uint32 // PID of each VTPro whose monitor was stopped
+	gone     bool     // The main window can no longer be found
+	stamps   map[string]fileStamp
+	startErr error
+}
+
+func newFakeWarm(t *testing.T) (*warmVTPro, *fakeVTPros, *testutil.MockLogger) {
+	t.Helper()
+
+	fake := &fakeVTPros{stamps: map[string]fileStamp{}}
+	idle := testutil.NewMockLogger()
+
+	w := newWarmVTPro(idle)
+	w.start = func(project string, _ launchOptions, _ logger.LoggerInterface) (*vtproSession, error) {
+		if fake.startErr != nil {
+			return nil, fake.startErr
+		}
+
+		fake.launched = append(fake.launched, project)
+		pid := uint32(1000 + len(fake.launched))
+
+		return &vtproSession{
+			pid:  pid,
+			hwnd: uintptr(pid),
+			cpu:  func() time.Duration { return time.Second },
+			stop: func() time.Duration {
+				fake.stopped = append(fake.stopped, pid)
+				return time.Second
+			},
+		}, nil
+	}
+	w.resolve = func(sess *vtproSession) (uintptr, bool) { return sess.hwnd, !fake.gone }
+	w.close = func(sess *vtproSession, _ logger.LoggerInterface) {
+		fake.closed = append(fake.closed, sess.pid)
+		sess.stop()
+	}
+	w.stat = func(path string) (fileStamp, error) {
+		// Paths are not case sensitive on Windows
+		for name, stamp := range fake.stamps {
+			if strings.EqualFold(name, path) {
+				return stamp, nil
+			}
+		}
+
+		return fileStamp{}, fmt.Errorf("open %s: file not found", path)
+	}
+
+	fake.stamps[`C:\Projects\lobby.vtp`] = fileStamp{size: 100, modTime: 1}
+	fake.stamps[`C:\Projects\boardroom.vtp`] = fileStamp{size: 200, modTime: 1}
+
+	return w, fake, idle
+}
+
+// compileOn opens VTPro on project and releases it, as a compile that ends with err does
+func compileOn(t *testing.T, w *warmVTPro, project string, opts launchOptions, compiled bool, err error) *vtproSession {
+	t.Helper()
+
+	sess, openErr := w.open(project, opts, testutil.NewMockLogger())
+	require.NoError(t, openErr)
+
+	sess.compiled = compiled
+	w.release(sess, err)
+
+	return sess
+}
+
+func TestWarmVTPro_ReusesVTProForTheSameProject(t *testing.T) {
+	t.Parallel()
+
+	w, fake, _ := newFakeWarm(t)
+
+	first := compileOn(t, w, `C:\Projects\lobby.vtp`, launchOptions{}, true, nil)
+	second := compileOn(t, w, `c:\projects\LOBBY.vtp`, launchOptions{}, true, errors.New("compilation failed with 2 error(s)"))
+	third := compileOn(t, w, `C:\Projects\lobby.vtp`, launchOptions{}, true, nil)
+
+	assert.Equal(t, []string{`C:\Projects\lobby.vtp`}, fake.launched, "VTPro is launched once")
+	assert.Same(t, first, second)
+	assert.Same(t, first, third)
+	assert.Empty(t, fake.closed)
+	assert.Equal(t, time.Second, third.cpuUsed, "the next compile only counts VTPro's CPU time from when it took VTPro")
+}
+
+func TestWarmVTPro_Relaunches(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		change func(fake *fakeVTPros)
+		next   string
+		opts   launchOptions
+	}{
+		{
+			name: "another project",
+			next: `C:\Projects\boardroom.vtp`,
+		},
+		{
+			name:   "project changed on disk",
+			change: func(fake *fakeVTPros) { fake.stamps[`C:\Projects\lobby.vtp`] = fileStamp{size: 100, modTime: 2} },
+			next:   `C:\Projects\lobby.vtp`,
+		},
+		{
+			name: "other launch options",
+			next: `C:\Projects\lobby.vtp`,
+			opts: launchOptions{minimized: true},
+		},
+		{
+			name:   "main window gone",
+			change: func(fake *fakeVTPros) { fake.gone = true },
+			next:   `C:\Projects\lobby.vtp`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w, fake, _ := newFakeWarm(t)
+
+			first := compileOn(t, w, `C:\Projects\lobby.vtp`, launchOptions{}, true, nil)
+			if tt.change != nil {
+				tt.change(fake)
+			}
+
+			second := compileOn(t, w, tt.next, tt.opts, true, nil)
+
+			assert.NotSame(t, first, second)
+			assert.Equal(t, []string{`C:\Projects\lobby.vtp`, tt.next}, fake.launched)
+			assert.Equal(t, []uint32{first.pid}, fake.closed, "the VTPro kept open is closed first")
+		})
+	}
+}
+
+func TestWarmVTPro_SeesItsOwnSave(t *testing.T) {
+	t.Parallel()
+
+	w, fake, _ := newFakeWarm(t)
+
+	sess, err := w.open(`C:\Projects\lobby.vtp`, launchOptions{}, testutil.NewMockLogger())
+	require.NoError(t, err)
+
+	// --save-first writes the project during the compile
+	fake.stamps[`C:\Projects\lobby.vtp`] = fileStamp{size: 120, modTime: 2}
+	sess.compiled = true
+	w.release(sess, nil)
+
+	compileOn(t, w, `C:\Projects\lobby.vtp`, launchOptions{}, true, nil)
+
+	assert.Len(t, fake.launched, 1, "a save made by the compile does not relaunch VTPro")
+}
+
+func TestWarmVTPro_ClosesAfterAnUnfinishedCompile(t *testing.T) {
+	t.Parallel()
+
+	w, fake, _ := newFakeWarm(t)
+
+	first := compileOn(t, w, `C:\Projects\lobby.vtp`, launchOptions{}, false, errors.New("compile dialog did not close"))
+	compileOn(t, w, `C:\Projects\lobby.vtp`, launchOptions{}, true, nil)
+
+	assert.Equal(t, []uint32{first.pid}, fake.closed)
+	assert.Len(t, fake.launched, 2)
+}
+
+func TestWarmVTPro_LeavesVTProAtAnUnexpectedDialog(t *testing.T) {
+	t.Parallel()
+
+	w, fake, _ := newFakeWarm(t)
+
+	first := compileOn(t, w, `C:\Projects\lobby.vtp`, launchOptions{}, false, fmt.Errorf("%w: Save As", compiler.ErrUnexpectedDialog))
+	compileOn(t, w, `C:\Projects\lobby.vtp`, launchOptions{}, true, nil)
+
+	assert.Empty(t, fake.closed, "VTPro is left open for inspection")
+	assert.Equal(t, []uint32{first.pid}, fake.stopped, "but no longer watched")
+	assert.Len(t, fake.launched, 2, "and not used again")
+}
+
+func TestWarmVTPro_LaunchFails(t *testing.T) {
+	t.Parallel()
+
+	w, fake, idle := newFakeWarm(t)
+	fake.startErr = errVTProNotReady
+
+	run := testutil.NewMockLogger()
+	_, err := w.open(`C:\Projects\lobby.vtp`, launchOptions{}, run)
+	require.ErrorIs(t, err, errVTProNotReady)
+
+	// Nothing is kept, and the client's log goes back to the daemon's
+	w.log.Info("Between compiles")
+	assert.Equal(t, []string{"Between compiles"}, idle.Messages())
+
+	_, err = w.open(`C:\Projects\missing.vtp`, launchOptions{}, run)
+	require.Error(t, err)
+
+	// The same goes for a project that cannot be read
+	w.log.Info("After the missing project")
+	assert.Equal(t, []string{"Between compiles", "After the missing project"}, idle.Messages())
+
+	w.Close()
+	assert.Empty(t, fake.closed)
+}
+
+func TestWarmVTPro_LogsToTheCompileUsingIt(t *testing.T) {
+	t.Parallel()
+
+	w, _, idle := newFakeWarm(t)
+
+	run := testutil.NewMockLogger()
+	sess, err := w.open(`C:\Projects\lobby.vtp`, launchOptions{}, run)
+	require.NoError(t, err)
+
+	w.log.Info("During the compile")
+
+	sess.compiled = true
+	w.release(sess, nil)
+	w.log.Info("Between compiles")
+
+	assert.Contains(t, run.Messages(), "During the compile")
+	assert.NotContains(t, run.Messages(), "Between compiles")
+	assert.Contains(t, idle.Messages(), "Between compiles")
+}
+
+func TestWarmVTPro_Close(t *testing.T) {
+	t.Parallel()
+
+	w, fake, _ := newFakeWarm(t)
+
+	sess := compileOn(t, w, `C:\Projects\lobby.vtp`, launchOptions{}, true, nil)
+	assert.True(t, w.Close())
+	assert.False(t, w.Close(), "nothing is left to close")
+
+	assert.Equal(t, []uint32{sess.pid}, fake.closed, "closed once")
+
+	compileOn(t, w, `C:\Projects\lobby.vtp`, launchOptions{}, true, nil)
+	assert.Len(t, fake.launched, 2, "the next compile launches VTPro again")
+}
@@ -0,0 +1,144 @@
+// Package artifact finds the .vtz VTPro writes when it compiles a project,
+// records its provenance and checks that it is a well-formed archive, so a
+// corrupt artifact fails the build instead of being rejected by a panel at load.
+package artifact
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// ErrCorrupt is returned for an artifact with structural errors
+var ErrCorrupt = errcode.New(errcode.ArtifactCorrupt, "artifact is corrupt")
+
+// Size ratios outside which the uncompressed contents are reported as implausible
+// for the project size VTPro reported. They are noted, not treated as corruption.
+const (
+	minSizeRatio = 0.1
+	maxSizeRatio = 100.0
+)
+
+// Requirement is an entry every artifact must contain
+type Requirement struct {
+	Name  string                 // What the entry is, for findings, e.g. "manifest"
+	Match func(name string) bool // Reports whether an archive entry satisfies the requirement
+}
+
+// DefaultRequirements are the entries a .vtz needs for a panel to load it:
+// a manifest and at least one page
+var DefaultRequirements = []Requirement{
+	{Name: "manifest", Match: func(name string) bool {
+		return strings.HasPrefix(strings.ToLower(path.Base(name)), "manifest")
+	}},
+	{Name: "pages", Match: func(name string) bool {
+		return strings.Contains("/"+strings.ToLower(name), "/pages/")
+	}},
+}
+
+// Report is what Verify found about an artifact
+type Report struct {
+	Path              string
+	Entries           int
+	UncompressedBytes uint64
+	Problems          []string // Structural errors; the artifact should not be shipped
+	Notes             []string // Findings that do not fail the run
+}
+
+// OK reports whether the artifact has no structural errors
+func (r Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Err returns ErrCorrupt with the first problem, or nil if the artifact is OK
+func (r Report) Err() error {
+	if r.OK() {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s: %s", ErrCorrupt, r.Path, r.Problems[0])
+}
+
+// Verify opens the artifact at path as a zip archive and checks that its central
+// directory parses, every entry reads back with a matching checksum, the required
+// entries exist and the uncompressed size is plausible for projectBytes, the project
+// size VTPro reported (0 if unknown).
+func Verify(path string, projectBytes int64, required []Requirement) Report {
+	r := Report{Path: path}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("cannot read the archive: %v", err))
+		return r
+	}
+
+	defer func() { _ = zr.Close() }()
+
+	r.Entries = len(zr.File)
+	if r.Entries == 0 {
+		r.Problems = append(r.Problems, "archive is empty")
+		return r
+	}
+
+	found := make([]bool, len(required))
+
+	for _, f := range zr.File {
+		r.UncompressedBytes += f.UncompressedSize64
+
+		if err := readEntry(f); err != nil {
+			r.Problems = append(r.Problems, fmt.Sprintf("entry %s: %v", f.Name, err))
+		}
+
+		for i, req := range required {
+			found[i] = found[i] || req.Match(f.Name)
+		}
+	}
+
+	for i, req := range required {
+		if !found[i] {
+			r.Problems = append(r.Problems, fmt.Sprintf("no %s entry", req.Name))
+		}
+	}
+
+	if note := sizeNote(r.UncompressedBytes, projectBytes); note != "" {
+		r.Notes = append(r.Notes, note)
+	}
+
+	return r
+}
+
+// readEntry reads an entry to the end, which makes archive/zip check its checksum
+func readEntry(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = rc.Close() }()
+
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
+
+// sizeNote describes an uncompressed size that is implausible for the project size,
+// or returns "" if it is plausible or the project size is unknown
+func sizeNote(uncompressed uint64, projectBytes int64) string {
+	if projectBytes <= 0 {
+		return ""
+	}
+
+	ratio := float64(uncompressed) / float64(projectBytes)
+
+	switch {
+	case ratio < minSizeRatio:
+		return fmt.Sprintf("contents are %d bytes, much smaller than the %d-byte project", uncompressed, projectBytes)
+	case ratio > maxSizeRatio:
+		return fmt.Sprintf("contents are %d bytes, much larger than the %d-byte project", uncompressed, projectBytes)
+	default:
+		return ""
+	}
+}
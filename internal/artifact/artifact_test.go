@@ -0,0 +1,165 @@
+package artifact
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// entry is a file to put in a crafted archive
+type entry struct {
+	name string
+	data string
+}
+
+// goodEntries are the contents of a well-formed artifact
+var goodEntries = []entry{
+	{"Manifest.xml", "<manifest><page>Main</page></manifest>"},
+	{"Pages/Main.xml", "<page name=\"Main\"/>"},
+	{"Images/logo.png", "not really a png"},
+}
+
+// writeArchive writes entries to a zip archive in a temp directory, stored
+// uncompressed so tests can find and corrupt their bytes, and returns its path
+func writeArchive(t *testing.T, entries []entry) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for _, e := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Store})
+		require.NoError(t, err)
+		_, err = w.Write([]byte(e.data))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+
+	path := filepath.Join(t.TempDir(), "lobby.vtz")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+
+	return path
+}
+
+// rewrite applies change to the bytes of the file at path
+func rewrite(t *testing.T, path string, change func([]byte) []byte) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, change(data), 0o644))
+}
+
+func TestVerify_GoodArchive(t *testing.T) {
+	t.Parallel()
+
+	path := writeArchive(t, goodEntries)
+
+	r := Verify(path, 100, DefaultRequirements)
+
+	assert.True(t, r.OK(), r.Problems)
+	assert.NoError(t, r.Err())
+	assert.Equal(t, 3, r.Entries)
+	assert.Equal(t, uint64(len(goodEntries[0].data)+len(goodEntries[1].data)+len(goodEntries[2].data)), r.UncompressedBytes)
+	assert.Empty(t, r.Notes)
+}
+
+func TestVerify_Problems(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		archive func(t *testing.T) string
+		want    string
+	}{
+		{
+			name: "not a zip",
+			archive: func(t *testing.T) string {
+				path := filepath.Join(t.TempDir(), "lobby.vtz")
+				require.NoError(t, os.WriteFile(path, []byte("VTPro wrote nothing useful"), 0o644))
+				return path
+			},
+			want: "cannot read the archive",
+		},
+		{
+			name: "truncated central directory",
+			archive: func(t *testing.T) string {
+				path := writeArchive(t, goodEntries)
+				rewrite(t, path, func(b []byte) []byte { return b[:len(b)-30] })
+				return path
+			},
+			want: "cannot read the archive",
+		},
+		{
+			name: "empty archive",
+			archive: func(t *testing.T) string {
+				return writeArchive(t, nil)
+			},
+			want: "archive is empty",
+		},
+		{
+			name: "corrupt entry data",
+			archive: func(t *testing.T) string {
+				path := writeArchive(t, goodEntries)
+				rewrite(t, path, func(b []byte) []byte {
+					return bytes.Replace(b, []byte("<page name="), []byte("<page NAME="), 1)
+				})
+				return path
+			},
+			want: "entry Pages/Main.xml: zip: checksum error",
+		},
+		{
+			name: "missing manifest",
+			archive: func(t *testing.T) string {
+				return writeArchive(t, goodEntries[1:])
+			},
+			want: "no manifest entry",
+		},
+		{
+			name: "missing pages",
+			archive: func(t *testing.T) string {
+				return writeArchive(t, []entry{goodEntries[0], goodEntries[2]})
+			},
+			want: "no pages entry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := Verify(tt.archive(t), 0, DefaultRequirements)
+
+			require.False(t, r.OK())
+			assert.Contains(t, r.Problems[0], tt.want)
+			assert.ErrorIs(t, r.Err(), ErrCorrupt)
+		})
+	}
+}
+
+func TestVerify_ImplausibleSizeIsNoted(t *testing.T) {
+	t.Parallel()
+
+	path := writeArchive(t, goodEntries)
+
+	r := Verify(path, 1_000_000, DefaultRequirements)
+
+	assert.True(t, r.OK())
+	require.Len(t, r.Notes, 1)
+	assert.Contains(t, r.Notes[0], "much smaller than the 1000000-byte project")
+}
+
+func TestSizeNote(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, sizeNote(500, 0), "unknown project size")
+	assert.Empty(t, sizeNote(500, 1000))
+	assert.Contains(t, sizeNote(50, 1000), "much smaller")
+	assert.Contains(t, sizeNote(200_000, 1000), "much larger")
+}
@@ -0,0 +1,78 @@
+package artifact
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// INIPreferences reads the output directory from a key in a VTPro ini file
+type INIPreferences struct {
+	Path    string
+	Section string
+	Keys    []string // Candidate key names, tried in order
+}
+
+// OutputDir returns the value of the first candidate key present in the ini file.
+// A missing file means no directory is configured.
+func (p INIPreferences) OutputDir() (string, bool, error) {
+	f, err := os.Open(p.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, fmt.Errorf("error reading VTPro preferences %s: %w", p.Path, err)
+	}
+	defer f.Close()
+
+	values, err := parseINISection(f, p.Section)
+	if err != nil {
+		return "", false, fmt.Errorf("error reading VTPro preferences %s: %w", p.Path, err)
+	}
+
+	for _, key := range p.Keys {
+		if v, ok := values[strings.ToLower(key)]; ok && v != "" {
+			return v, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// parseINISection returns the key/value pairs of one section of an ini file.
+// Section and key names are matched case-insensitively; keys are returned lowercased.
+func parseINISection(r io.Reader, section string) (map[string]string, error) {
+	values := make(map[string]string)
+	inSection := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\uFEFF"))
+
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inSection = strings.EqualFold(strings.TrimSpace(line[1:len(line)-1]), section)
+			continue
+		}
+
+		if !inSection {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		values[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+
+	return values, scanner.Err()
+}
@@ -0,0 +1,121 @@
+package artifact
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Extension is the extension of the compiled panel file
+const Extension = ".vtz"
+
+// Source names where an artifact search location came from
+type Source string
+
+const (
+	SourcePreferences Source = "VTPro preferences" // The output directory set in Edit > Preferences
+	SourceProjectDir  Source = "project directory" // The directory containing the .vtp
+	SourceLocator     Source = "artifact locator"  // A locator supplied in place of the built-in search
+)
+
+// Location is a directory searched for compiled artifacts
+type Location struct {
+	Dir    string
+	Source Source
+}
+
+// PreferenceReader reads the compile output directory configured in VTPro.
+// It reports false when no directory is configured.
+type PreferenceReader interface {
+	OutputDir() (string, bool, error)
+}
+
+// Readers tries several preference readers in order and returns the first configured directory
+type Readers []PreferenceReader
+
+// OutputDir returns the first directory any reader reports. Errors from readers
+// are only returned if no reader finds a directory.
+func (r Readers) OutputDir() (string, bool, error) {
+	var errs []error
+
+	for _, reader := range r {
+		dir, ok, err := reader.OutputDir()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if ok {
+			return dir, true, nil
+		}
+	}
+
+	return "", false, errors.Join(errs...)
+}
+
+// SearchLocations returns where to look for a project's artifacts, in priority order:
+// the output directory from VTPro's preferences, then the project's own directory.
+// A relative preference is resolved against the project directory. The error from
+// reading preferences is returned alongside the locations so it can be logged.
+func SearchLocations(reader PreferenceReader, projectPath string) ([]Location, error) {
+	projectDir := filepath.Dir(projectPath)
+	locations := make([]Location, 0, 2)
+
+	var readErr error
+	if reader != nil {
+		dir, ok, err := reader.OutputDir()
+		readErr = err
+
+		if dir = strings.TrimSpace(dir); ok && dir != "" {
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(projectDir, dir)
+			}
+
+			if filepath.Clean(dir) != filepath.Clean(projectDir) {
+				locations = append(locations, Location{Dir: dir, Source: SourcePreferences})
+			}
+		}
+	}
+
+	locations = append(locations, Location{Dir: projectDir, Source: SourceProjectDir})
+
+	return locations, readErr
+}
+
+// File is a compiled file and the location it was found in
+type File struct {
+	Path   string
+	Source Source
+}
+
+// Find looks in each location for the project's artifact written at or
+// after since, and returns the first one found
+func Find(locations []Location, projectPath string, since time.Time) (File, error) {
+	base := strings.TrimSuffix(filepath.Base(projectPath), filepath.Ext(projectPath))
+	name := base + Extension
+
+	for _, loc := range locations {
+		path := filepath.Join(loc.Dir, name)
+
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		if info.ModTime().Before(since) {
+			continue // Left over from an earlier compile
+		}
+
+		return File{Path: path, Source: loc.Source}, nil
+	}
+
+	dirs := make([]string, 0, len(locations))
+	for _, loc := range locations {
+		dirs = append(dirs, loc.Dir)
+	}
+
+	return File{}, fmt.Errorf("no new %s found in %s", name, strings.Join(dirs, ", "))
+}
@@ -0,0 +1,184 @@
+package artifact
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedReader is a PreferenceReader with a canned answer
+type fixedReader struct {
+	dir string
+	ok  bool
+	err error
+}
+
+func (f fixedReader) OutputDir() (string, bool, error) { return f.dir, f.ok, f.err }
+
+func TestINIPreferences(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		fixture string
+		want    string
+		wantOK  bool
+	}{
+		{"quoted value in named section", "vtpro.ini", `D:\Builds\Panels`, true},
+		{"case-insensitive section and key", "lowercase.ini", "Output", true},
+		{"key not set", "no_output.ini", "", false},
+		{"missing file", "missing.ini", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := INIPreferences{
+				Path:    filepath.Join("testdata", tt.fixture),
+				Section: "Preferences",
+				Keys:    []string{"OutputDirectory", "CompileOutputPath"},
+			}
+
+			dir, ok, err := p.OutputDir()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, dir)
+		})
+	}
+}
+
+func TestReaders_FirstConfiguredWins(t *testing.T) {
+	t.Parallel()
+
+	readers := Readers{
+		fixedReader{err: errors.New("access denied")},
+		fixedReader{},
+		fixedReader{dir: `D:\Builds`, ok: true},
+		fixedReader{dir: `E:\Other`, ok: true},
+	}
+
+	dir, ok, err := readers.OutputDir()
+	require.NoError(t, err, "errors are dropped once a reader finds a directory")
+	assert.True(t, ok)
+	assert.Equal(t, `D:\Builds`, dir)
+}
+
+func TestReaders_ReportsErrorsWhenNothingFound(t *testing.T) {
+	t.Parallel()
+
+	_, ok, err := Readers{fixedReader{err: errors.New("access denied")}, fixedReader{}}.OutputDir()
+
+	assert.False(t, ok)
+	assert.ErrorContains(t, err, "access denied")
+}
+
+func TestSearchLocations(t *testing.T) {
+	t.Parallel()
+
+	project := filepath.Join("projects", "lobby", "lobby.vtp")
+	projectDir := filepath.Dir(project)
+	builds := filepath.Join(t.TempDir(), "builds")
+
+	tests := []struct {
+		name   string
+		reader PreferenceReader
+		want   []Location
+	}{
+		{"preference first, project dir as fallback", fixedReader{dir: builds, ok: true}, []Location{
+			{Dir: builds, Source: SourcePreferences},
+			{Dir: projectDir, Source: SourceProjectDir},
+		}},
+		{"relative preference resolved against the project", fixedReader{dir: "out", ok: true}, []Location{
+			{Dir: filepath.Join(projectDir, "out"), Source: SourcePreferences},
+			{Dir: projectDir, Source: SourceProjectDir},
+		}},
+		{"preference equal to project dir is not repeated", fixedReader{dir: ".", ok: true}, []Location{
+			{Dir: projectDir, Source: SourceProjectDir},
+		}},
+		{"no preference", fixedReader{}, []Location{
+			{Dir: projectDir, Source: SourceProjectDir},
+		}},
+		{"no reader", nil, []Location{
+			{Dir: projectDir, Source: SourceProjectDir},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := SearchLocations(tt.reader, project)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSearchLocations_ReturnsReadErrorWithFallback(t *testing.T) {
+	t.Parallel()
+
+	got, err := SearchLocations(fixedReader{err: errors.New("access denied")}, filepath.Join("p", "lobby.vtp"))
+
+	assert.Error(t, err)
+	assert.Equal(t, []Location{{Dir: "p", Source: SourceProjectDir}}, got)
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	prefDir := filepath.Join(root, "builds")
+	projectDir := filepath.Join(root, "project")
+	require.NoError(t, os.MkdirAll(prefDir, 0o755))
+	require.NoError(t, os.MkdirAll(projectDir, 0o755))
+
+	project := filepath.Join(projectDir, "lobby.vtp")
+	since := time.Now().Add(-time.Minute)
+	stale := since.Add(-time.Hour)
+
+	writeArtifact := func(t *testing.T, path string, modTime time.Time) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(path, []byte("vtz"), 0o644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	locations := []Location{
+		{Dir: prefDir, Source: SourcePreferences},
+		{Dir: projectDir, Source: SourceProjectDir},
+	}
+
+	t.Run("falls back to the project directory", func(t *testing.T) {
+		writeArtifact(t, filepath.Join(projectDir, "lobby.vtz"), time.Now())
+
+		got, err := Find(locations, project, since)
+		require.NoError(t, err)
+		assert.Equal(t, File{Path: filepath.Join(projectDir, "lobby.vtz"), Source: SourceProjectDir}, got)
+	})
+
+	t.Run("stale artifact in the preferred directory is skipped", func(t *testing.T) {
+		writeArtifact(t, filepath.Join(prefDir, "lobby.vtz"), stale)
+
+		got, err := Find(locations, project, since)
+		require.NoError(t, err)
+		assert.Equal(t, SourceProjectDir, got.Source)
+	})
+
+	t.Run("new artifact in the preferred directory wins", func(t *testing.T) {
+		writeArtifact(t, filepath.Join(prefDir, "lobby.vtz"), time.Now())
+
+		got, err := Find(locations, project, since)
+		require.NoError(t, err)
+		assert.Equal(t, File{Path: filepath.Join(prefDir, "lobby.vtz"), Source: SourcePreferences}, got)
+	})
+
+	t.Run("nothing new anywhere", func(t *testing.T) {
+		_, err := Find(locations, project, time.Now().Add(time.Hour))
+		assert.ErrorContains(t, err, "no new lobby.vtz found")
+	})
+}
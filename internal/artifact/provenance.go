@@ -0,0 +1,54 @@
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Norgate-AV/vtpc/internal/output"
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+// ProvenanceSuffix is added to an artifact's file name to name its provenance sidecar
+const ProvenanceSuffix = ".provenance.json"
+
+// ProvenancePath returns where the provenance of an artifact is written,
+// e.g. lobby.vtz.provenance.json beside lobby.vtz
+func ProvenancePath(artifact string) string {
+	return artifact + ProvenanceSuffix
+}
+
+// WriteProvenance writes p beside its artifact and returns the sidecar's path.
+// The sidecar is replaced atomically, so a reader never sees half of it.
+func WriteProvenance(p report.Provenance) (string, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := ProvenancePath(p.Artifact)
+	if err := output.WriteFileAtomic(path, append(data, '\n')); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// HashFile returns the hex-encoded SHA-256 of a file's contents
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hashing %s: %w", path, err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
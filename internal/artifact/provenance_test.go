@@ -0,0 +1,93 @@
+package artifact
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+func TestWriteProvenance(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	artifact := filepath.Join(dir, "lobby.vtz")
+	require.NoError(t, os.WriteFile(artifact, []byte("panel"), 0o644))
+
+	started := time.Date(2025, 3, 4, 9, 15, 0, 0, time.FixedZone("", 10*60*60))
+	p := report.Provenance{
+		Artifact:       artifact,
+		ArtifactSHA256: "ab12",
+		Source:         `C:\Projects\lobby.vtp`,
+		SourceSHA256:   "cd34",
+		VtpcVersion:    "1.4.0",
+		VTProVersion:   "6.2.3.1",
+		RunID:          "20250304T091500-a1b2c3",
+		StartedAt:      report.Timestamp{Time: started},
+		FinishedAt:     report.Timestamp{Time: started.Add(2 * time.Minute)},
+		Machine:        "BUILD01",
+		User:           `CORP\builder`,
+		Warnings:       3,
+	}
+
+	path, err := WriteProvenance(p)
+	require.NoError(t, err)
+	assert.Equal(t, artifact+".provenance.json", path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"startedAt": "2025-03-04T09:15:00+10:00"`)
+	assert.Contains(t, string(data), `"sourceSha256": "cd34"`)
+
+	var got report.Provenance
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, p.RunID, got.RunID)
+	assert.Equal(t, p.Warnings, got.Warnings)
+	assert.True(t, p.FinishedAt.Equal(got.FinishedAt.Time))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "no temporary file is left behind")
+}
+
+func TestWriteProvenance_ReplacesExistingSidecar(t *testing.T) {
+	t.Parallel()
+
+	artifact := filepath.Join(t.TempDir(), "lobby.vtz")
+	require.NoError(t, os.WriteFile(ProvenancePath(artifact), []byte(`{"runId": "old"}`), 0o644))
+
+	_, err := WriteProvenance(report.Provenance{Artifact: artifact, RunID: "new"})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(ProvenancePath(artifact))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"runId": "new"`)
+	assert.NotContains(t, string(data), "old")
+}
+
+func TestWriteProvenance_MissingDirectory(t *testing.T) {
+	t.Parallel()
+
+	_, err := WriteProvenance(report.Provenance{Artifact: filepath.Join(t.TempDir(), "missing", "lobby.vtz")})
+	assert.Error(t, err)
+}
+
+func TestHashFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "lobby.vtp")
+	require.NoError(t, os.WriteFile(path, []byte("abc"), 0o644))
+
+	sum, err := HashFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", sum)
+
+	_, err = HashFile(filepath.Join(t.TempDir(), "missing.vtp"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,206 @@
+// Package batch records the progress of a batch of compiles, so a batch that
+// was interrupted can resume where it stopped rather than start again.
+package batch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/output"
+)
+
+// Status is how far a project in a batch got
+type Status string
+
+const (
+	StatusPending   Status = "pending"   // Not compiled yet, or interrupted while compiling
+	StatusSucceeded Status = "succeeded" // Compiled with exit code 0
+	StatusFailed    Status = "failed"    // Compiled with any other exit code
+	StatusSkipped   Status = "skipped"   // Not compiled, as the input could not be; Reason says why
+)
+
+// Entry is one project in a batch
+type Entry struct {
+	Path       string    `json:"path"`
+	Status     Status    `json:"status"`
+	SHA256     string    `json:"sha256,omitempty"` // The project's contents when it was last compiled
+	ExitCode   int       `json:"exitCode"`
+	FinishedAt time.Time `json:"finishedAt,omitzero"`
+	Reason     string    `json:"reason,omitempty"` // Why the input was skipped, e.g. "not found"
+}
+
+// State is the progress of a batch, saved after every project
+type State struct {
+	Fingerprint string  `json:"fingerprint"` // Identifies the inputs and flags the batch was started with
+	Entries     []Entry `json:"entries"`     // One per input, in the order they are compiled
+}
+
+// New returns the state of a batch of inputs that has not started
+func New(inputs []string, fingerprint string) *State {
+	s := &State{Fingerprint: fingerprint, Entries: make([]Entry, len(inputs))}
+
+	for i, path := range inputs {
+		s.Entries[i] = Entry{Path: path, Status: StatusPending}
+	}
+
+	return s
+}
+
+// Inputs returns the projects of the batch, in order
+func (s *State) Inputs() []string {
+	inputs := make([]string, len(s.Entries))
+	for i, e := range s.Entries {
+		inputs[i] = e.Path
+	}
+
+	return inputs
+}
+
+// Load reads the state saved at path
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch state %s: %w", path, err)
+	}
+
+	s := &State{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse batch state %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// Save writes the state to path atomically, so a power cut leaves either the
+// previous state or this one
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return output.WriteFileAtomic(path, append(data, '\n'))
+}
+
+// Resume returns prior if it was saved by a batch of the same inputs and
+// flags. Otherwise prior is invalid, and a new state is returned in its place
+// with invalidated set.
+func Resume(prior *State, inputs []string, fingerprint string) (s *State, invalidated bool) {
+	if prior.Fingerprint == fingerprint && slices.Equal(prior.Inputs(), inputs) {
+		return prior, false
+	}
+
+	return New(inputs, fingerprint), true
+}
+
+// Record sets the outcome of compiling entry i, whose contents hashed to sha256
+func (s *State) Record(i, exitCode int, sha256 string, at time.Time) {
+	e := &s.Entries[i]
+	e.ExitCode = exitCode
+	e.SHA256 = sha256
+	e.FinishedAt = at
+
+	e.Reason = ""
+
+	e.Status = StatusFailed
+	if exitCode == 0 {
+		e.Status = StatusSucceeded
+	}
+}
+
+// Skip records that entry i was not compiled because its input could not be,
+// for the given reason
+func (s *State) Skip(i int, reason string, at time.Time) {
+	s.Entries[i] = Entry{Path: s.Entries[i].Path, Status: StatusSkipped, FinishedAt: at, Reason: reason}
+}
+
+// Counts returns how many entries have each status
+func (s *State) Counts() map[Status]int {
+	counts := make(map[Status]int)
+	for _, e := range s.Entries {
+		counts[e.Status]++
+	}
+
+	return counts
+}
+
+// immaterialFlags do not change what a compile produces, so changing them
+// between runs does not invalidate a batch's progress
+var immaterialFlags = []string{"verbose", "live-log", "trace-win32", "heartbeat", "bell", "pause", "absolute-times"}
+
+// Fingerprint identifies a batch by its inputs, in order, and the flags its
+// compiles are run with, given as --name=value. The order of the flags and
+// flags in immaterialFlags do not change it.
+func Fingerprint(inputs, flags []string) string {
+	var material []string
+
+	for _, f := range flags {
+		name, _, _ := strings.Cut(strings.TrimLeft(f, "-"), "=")
+		if !slices.Contains(immaterialFlags, name) {
+			material = append(material, f)
+		}
+	}
+
+	slices.Sort(material)
+
+	h := sha256.New()
+	for _, in := range inputs {
+		fmt.Fprintf(h, "input=%q\n", in)
+	}
+
+	for _, f := range material {
+		fmt.Fprintf(h, "flag=%q\n", f)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// Decision is whether a project in a batch is compiled this time, and why
+type Decision struct {
+	Path   string
+	Run    bool
+	Reason string
+	SHA256 string // The project's contents now, "" if they could not be read
+}
+
+// Plan decides which projects are compiled: every one except those that
+// succeeded last time and have not changed since. Skipped inputs are tried again. hash returns a project's
+// SHA-256.
+func (s *State) Plan(hash func(path string) (string, error)) []Decision {
+	decisions := make([]Decision, len(s.Entries))
+
+	for i, e := range s.Entries {
+		d := Decision{Path: e.Path, Run: true}
+
+		sum, err := hash(e.Path)
+		if err == nil {
+			d.SHA256 = sum
+		}
+
+		switch {
+		case e.Status == StatusPending:
+			d.Reason = "not compiled yet"
+		case e.Status == StatusFailed:
+			d.Reason = fmt.Sprintf("failed last time with exit code %d", e.ExitCode)
+		case e.Status == StatusSkipped:
+			d.Reason = fmt.Sprintf("skipped last time: %s", e.Reason)
+		case err != nil:
+			d.Reason = fmt.Sprintf("could not check for changes: %v", err)
+		case e.SHA256 != sum:
+			d.Reason = "changed since it compiled"
+		default:
+			d.Run = false
+			d.Reason = "compiled and unchanged"
+		}
+
+		decisions[i] = d
+	}
+
+	return decisions
+}
@@ -0,0 +1,222 @@
+package batch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{`C:\a.vtp`, `C:\b.vtp`}
+	flags := []string{"--timeout=5m", "--save-first=true"}
+	base := Fingerprint(inputs, flags)
+
+	tests := []struct {
+		name     string
+		inputs   []string
+		flags    []string
+		wantSame bool
+	}{
+		{name: "identical", inputs: inputs, flags: flags, wantSame: true},
+		{name: "flags reordered", inputs: inputs, flags: []string{"--save-first=true", "--timeout=5m"}, wantSame: true},
+		{name: "immaterial flag added", inputs: inputs, flags: append([]string{"--verbose=true", "--live-log=true"}, flags...), wantSame: true},
+		{name: "input added", inputs: append(inputs, `C:\c.vtp`), flags: flags},
+		{name: "inputs reordered", inputs: []string{`C:\b.vtp`, `C:\a.vtp`}, flags: flags},
+		{name: "material flag changed", inputs: inputs, flags: []string{"--timeout=10m", "--save-first=true"}},
+		{name: "material flag removed", inputs: inputs, flags: []string{"--timeout=5m"}},
+		{name: "flag value looks like another flag", inputs: inputs, flags: []string{"--timeout=5m--save-first=true"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := Fingerprint(tt.inputs, tt.flags)
+			assert.Len(t, got, 12)
+			assert.Equal(t, tt.wantSame, got == base)
+		})
+	}
+}
+
+func TestResume(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{`C:\a.vtp`, `C:\b.vtp`}
+
+	prior := New(inputs, "abc123")
+	prior.Record(0, 0, "sum-a", time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name            string
+		inputs          []string
+		fingerprint     string
+		wantInvalidated bool
+	}{
+		{name: "same batch", inputs: inputs, fingerprint: "abc123"},
+		{name: "fingerprint changed", inputs: inputs, fingerprint: "def456", wantInvalidated: true},
+		{name: "input list changed", inputs: inputs[:1], fingerprint: "abc123", wantInvalidated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, invalidated := Resume(prior, tt.inputs, tt.fingerprint)
+			assert.Equal(t, tt.wantInvalidated, invalidated)
+
+			if tt.wantInvalidated {
+				assert.Equal(t, New(tt.inputs, tt.fingerprint), s, "an invalid state starts again")
+				return
+			}
+
+			assert.Same(t, prior, s)
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	errUnreadable := errors.New("access is denied")
+
+	tests := []struct {
+		name       string
+		entry      Entry
+		sum        string
+		hashErr    error
+		wantRun    bool
+		wantReason string
+	}{
+		{
+			name:       "pending",
+			entry:      Entry{Status: StatusPending},
+			sum:        "sum",
+			wantRun:    true,
+			wantReason: "not compiled yet",
+		},
+		{
+			name:       "failed",
+			entry:      Entry{Status: StatusFailed, ExitCode: 1, SHA256: "sum", FinishedAt: at},
+			sum:        "sum",
+			wantRun:    true,
+			wantReason: "failed last time with exit code 1",
+		},
+		{
+			name:       "skipped",
+			entry:      Entry{Status: StatusSkipped, Reason: "not found", FinishedAt: at},
+			hashErr:    errUnreadable,
+			wantRun:    true,
+			wantReason: "skipped last time: not found",
+		},
+		{
+			name:       "succeeded and unchanged",
+			entry:      Entry{Status: StatusSucceeded, SHA256: "sum", FinishedAt: at},
+			sum:        "sum",
+			wantReason: "compiled and unchanged",
+		},
+		{
+			name:       "succeeded but changed",
+			entry:      Entry{Status: StatusSucceeded, SHA256: "old", FinishedAt: at},
+			sum:        "new",
+			wantRun:    true,
+			wantReason: "changed since it compiled",
+		},
+		{
+			name:       "succeeded without a recorded hash",
+			entry:      Entry{Status: StatusSucceeded, FinishedAt: at},
+			sum:        "sum",
+			wantRun:    true,
+			wantReason: "changed since it compiled",
+		},
+		{
+			name:       "succeeded but unreadable now",
+			entry:      Entry{Status: StatusSucceeded, SHA256: "sum", FinishedAt: at},
+			hashErr:    errUnreadable,
+			wantRun:    true,
+			wantReason: "could not check for changes: access is denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.entry.Path = `C:\a.vtp`
+			s := &State{Entries: []Entry{tt.entry}}
+
+			decisions := s.Plan(func(path string) (string, error) {
+				assert.Equal(t, `C:\a.vtp`, path)
+				return tt.sum, tt.hashErr
+			})
+
+			require.Len(t, decisions, 1)
+			assert.Equal(t, tt.wantRun, decisions[0].Run)
+			assert.Equal(t, tt.wantReason, decisions[0].Reason)
+			assert.Equal(t, tt.sum, decisions[0].SHA256)
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	s := New([]string{"a.vtp", "b.vtp", "c.vtp"}, "fp")
+
+	s.Record(0, 0, "sum-a", at)
+	s.Record(1, 1, "sum-b", at)
+
+	assert.Equal(t, Entry{Path: "a.vtp", Status: StatusSucceeded, SHA256: "sum-a", FinishedAt: at}, s.Entries[0])
+	assert.Equal(t, Entry{Path: "b.vtp", Status: StatusFailed, SHA256: "sum-b", ExitCode: 1, FinishedAt: at}, s.Entries[1])
+	assert.Equal(t, map[Status]int{StatusSucceeded: 1, StatusFailed: 1, StatusPending: 1}, s.Counts())
+}
+
+func TestSkip(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	s := New([]string{"a.vtp", "b.vtp"}, "fp")
+
+	s.Record(0, 1, "sum-a", at)
+	s.Skip(0, "unreadable: access is denied", at)
+	s.Skip(1, "not found", at)
+
+	assert.Equal(t, Entry{Path: "a.vtp", Status: StatusSkipped, FinishedAt: at, Reason: "unreadable: access is denied"}, s.Entries[0], "a skip replaces the last compile's outcome")
+	assert.Equal(t, map[Status]int{StatusSkipped: 2}, s.Counts())
+
+	s.Record(1, 0, "sum-b", at)
+	assert.Empty(t, s.Entries[1].Reason, "a compile clears the reason it was skipped")
+}
+
+func TestState_SaveAndLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "batch.json")
+
+	s := New([]string{"a.vtp", "b.vtp"}, "fp")
+	s.Record(0, 0, "sum-a", time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC))
+	require.NoError(t, s.Save(path))
+
+	s.Record(1, 2, "sum-b", time.Date(2025, 6, 1, 8, 5, 0, 0, time.UTC))
+	require.NoError(t, s.Save(path), "saving again replaces the state")
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, s, loaded)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+
+	_, err = Load(filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "failed to read batch state")
+}
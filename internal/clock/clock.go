@@ -0,0 +1,38 @@
+// Package clock abstracts time so polling loops can be driven by a fake clock in tests.
+package clock
+
+import "time"
+
+// Clock provides the current time and tickers
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks at intervals, like time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the Clock backed by the time package
+type Real struct{}
+
+// New returns the real clock
+func New() Clock {
+	return Real{}
+}
+
+func (Real) Now() time.Time { return time.Now() }
+
+func (Real) NewTicker(d time.Duration) Ticker {
+	return realTicker{t: time.NewTicker(d)}
+}
+
+// realTicker adapts time.Ticker to the Ticker interface
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (r realTicker) C() <-chan time.Time { return r.t.C }
+func (r realTicker) Stop()               { r.t.Stop() }
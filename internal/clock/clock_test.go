@@ -0,0 +1,72 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFake_Advance(t *testing.T) {
+	t.Parallel()
+
+	f := NewFake(epoch)
+	assert.Equal(t, epoch, f.Now())
+
+	f.Advance(3 * time.Second)
+	assert.Equal(t, epoch.Add(3*time.Second), f.Now())
+}
+
+func TestFake_TickerFiresOnPeriod(t *testing.T) {
+	t.Parallel()
+
+	f := NewFake(epoch)
+	ticker := f.NewTicker(2 * time.Second)
+
+	f.Advance(time.Second)
+	assert.Empty(t, ticker.C(), "no tick before the first period")
+
+	f.Advance(time.Second)
+	select {
+	case tick := <-ticker.C():
+		assert.Equal(t, epoch.Add(2*time.Second), tick)
+	default:
+		t.Fatal("expected a tick after one period")
+	}
+}
+
+func TestFake_TickerDropsUnreadTicks(t *testing.T) {
+	t.Parallel()
+
+	f := NewFake(epoch)
+	ticker := f.NewTicker(time.Second)
+
+	f.Advance(5 * time.Second)
+	assert.Len(t, ticker.C(), 1)
+}
+
+func TestFake_StoppedTickerDoesNotFire(t *testing.T) {
+	t.Parallel()
+
+	f := NewFake(epoch)
+	ticker := f.NewTicker(time.Second)
+	ticker.Stop()
+
+	f.Advance(2 * time.Second)
+	assert.Empty(t, ticker.C())
+}
+
+func TestReal_Ticker(t *testing.T) {
+	t.Parallel()
+
+	ticker := New().NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	select {
+	case <-ticker.C():
+	case <-time.After(time.Second):
+		t.Fatal("real ticker did not fire")
+	}
+}
@@ -0,0 +1,124 @@
+package clock
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// SleepThreshold is the gap between two polls of a wait loop over which the
+// system is taken to have slept. Wait loops poll every second or less, so a
+// minute with no poll means the process was not running at all.
+const SleepThreshold = time.Minute
+
+// ErrSlept is returned by Deadline.Poll under SleepFail when the system slept
+var ErrSlept = errcode.New(errcode.Slept, "system slept during the wait")
+
+// SleepPolicy is what a Deadline does when the system sleeps during a wait
+type SleepPolicy string
+
+const (
+	SleepExtend SleepPolicy = "extend" // Do not count the sleep against the deadline
+	SleepFail   SleepPolicy = "fail"   // Stop waiting with ErrSlept
+	SleepIgnore SleepPolicy = "ignore" // Count the sleep against the deadline like any other wait
+)
+
+// ParseSleepPolicy converts a --on-sleep value to a SleepPolicy. An empty
+// string is SleepExtend.
+func ParseSleepPolicy(s string) (SleepPolicy, error) {
+	switch p := SleepPolicy(s); p {
+	case "":
+		return SleepExtend, nil
+	case SleepExtend, SleepFail, SleepIgnore:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid sleep policy %q: must be \"extend\", \"fail\" or \"ignore\"", s)
+	}
+}
+
+// Deadline is a wait loop's time limit that survives the system sleeping.
+// Rather than comparing the time against a fixed instant, each Poll charges the
+// time since the previous one against the budget, so a wall clock that jumps
+// cannot expire it early. A gap between polls longer than SleepThreshold is
+// treated as sleep and handled by the policy.
+type Deadline struct {
+	clk       Clock
+	policy    SleepPolicy
+	remaining time.Duration
+	last      time.Time
+	slept     time.Duration
+}
+
+// NewDeadline starts a deadline of timeout from now
+func NewDeadline(clk Clock, timeout time.Duration, policy SleepPolicy) *Deadline {
+	return &Deadline{clk: clk, policy: policy, remaining: timeout, last: clk.Now()}
+}
+
+// Poll charges the time since the previous poll against the deadline. It
+// returns how long the system appears to have slept since then, or 0, and
+// ErrSlept under SleepFail if it did.
+func (d *Deadline) Poll() (time.Duration, error) {
+	now := d.clk.Now()
+	gap := max(now.Sub(d.last), 0)
+	d.last = now
+
+	if gap <= SleepThreshold {
+		d.remaining -= gap
+		return 0, nil
+	}
+
+	d.slept += gap
+
+	switch d.policy {
+	case SleepFail:
+		d.remaining -= gap
+		return gap, fmt.Errorf("%w for %s", ErrSlept, FormatSleep(gap))
+	case SleepIgnore:
+		d.remaining -= gap
+	}
+
+	return gap, nil
+}
+
+// Expired reports whether the budget has run out, as of the last Poll
+func (d *Deadline) Expired() bool {
+	return d.remaining <= 0
+}
+
+// Remaining returns what is left of the budget, as of the last Poll
+func (d *Deadline) Remaining() time.Duration {
+	return max(d.remaining, 0)
+}
+
+// Slept returns the total sleep seen by Poll
+func (d *Deadline) Slept() time.Duration {
+	return d.slept
+}
+
+// SleepMessage describes a sleep Poll returned and what the policy did about
+// it for the log, e.g. "System appears to have slept for 42m; extending deadline"
+func (d *Deadline) SleepMessage(slept time.Duration) string {
+	action := "extending deadline"
+
+	switch d.policy {
+	case SleepFail:
+		action = "failing the wait"
+	case SleepIgnore:
+		action = "not extending deadline"
+	}
+
+	return fmt.Sprintf("System appears to have slept for %s; %s", FormatSleep(slept), action)
+}
+
+// FormatSleep rounds a sleep to the second and drops a trailing "0s" from
+// whole minutes, so 42 minutes reads "42m" rather than "42m0s"
+func FormatSleep(d time.Duration) string {
+	s := d.Round(time.Second).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+
+	return s
+}
@@ -0,0 +1,134 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pollEvery polls d every interval until total has passed
+func pollEvery(f *Fake, d *Deadline, interval, total time.Duration) {
+	for elapsed := time.Duration(0); elapsed < total; elapsed += interval {
+		f.Advance(interval)
+		_, _ = d.Poll()
+	}
+}
+
+func TestDeadline_ExpiresAfterTimeout(t *testing.T) {
+	t.Parallel()
+
+	f := NewFake(epoch)
+	d := NewDeadline(f, 5*time.Second, SleepExtend)
+
+	pollEvery(f, d, 100*time.Millisecond, 4900*time.Millisecond)
+	assert.False(t, d.Expired())
+	assert.Equal(t, 100*time.Millisecond, d.Remaining())
+
+	pollEvery(f, d, 100*time.Millisecond, 100*time.Millisecond)
+	assert.True(t, d.Expired())
+	assert.Zero(t, d.Remaining())
+	assert.Zero(t, d.Slept())
+}
+
+func TestDeadline_ExtendsOverSleep(t *testing.T) {
+	t.Parallel()
+
+	f := NewFake(epoch)
+	d := NewDeadline(f, 5*time.Minute, SleepExtend)
+
+	pollEvery(f, d, time.Second, time.Minute)
+
+	f.Advance(42 * time.Minute)
+	slept, err := d.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, 42*time.Minute, slept)
+	assert.Equal(t, "System appears to have slept for 42m; extending deadline", d.SleepMessage(slept))
+
+	assert.False(t, d.Expired(), "the sleep is not charged")
+	assert.Equal(t, 4*time.Minute, d.Remaining())
+	assert.Equal(t, 42*time.Minute, d.Slept())
+
+	pollEvery(f, d, time.Second, 4*time.Minute)
+	assert.True(t, d.Expired(), "waiting after the sleep still counts")
+}
+
+func TestDeadline_FailsOnSleep(t *testing.T) {
+	t.Parallel()
+
+	f := NewFake(epoch)
+	d := NewDeadline(f, 5*time.Minute, SleepFail)
+
+	f.Advance(10 * time.Minute)
+	slept, err := d.Poll()
+	require.ErrorIs(t, err, ErrSlept)
+	assert.EqualError(t, err, "system slept during the wait for 10m")
+	assert.Equal(t, 10*time.Minute, slept)
+	assert.Equal(t, "System appears to have slept for 10m; failing the wait", d.SleepMessage(slept))
+}
+
+func TestDeadline_IgnoreChargesSleep(t *testing.T) {
+	t.Parallel()
+
+	f := NewFake(epoch)
+	d := NewDeadline(f, 5*time.Minute, SleepIgnore)
+
+	f.Advance(10 * time.Minute)
+	slept, err := d.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, slept, "the sleep is still reported")
+	assert.True(t, d.Expired())
+	assert.Equal(t, "System appears to have slept for 10m; not extending deadline", d.SleepMessage(slept))
+}
+
+func TestDeadline_GapAtThresholdIsNotSleep(t *testing.T) {
+	t.Parallel()
+
+	f := NewFake(epoch)
+	d := NewDeadline(f, 5*time.Minute, SleepFail)
+
+	f.Advance(SleepThreshold)
+	slept, err := d.Poll()
+	require.NoError(t, err)
+	assert.Zero(t, slept)
+	assert.Equal(t, 4*time.Minute, d.Remaining())
+}
+
+func TestDeadline_ClockGoingBackwardsCostsNothing(t *testing.T) {
+	t.Parallel()
+
+	f := NewFake(epoch)
+	d := NewDeadline(f, 5*time.Second, SleepExtend)
+
+	f.Advance(-time.Hour)
+	slept, err := d.Poll()
+	require.NoError(t, err)
+	assert.Zero(t, slept)
+	assert.Equal(t, 5*time.Second, d.Remaining())
+
+	pollEvery(f, d, time.Second, 5*time.Second)
+	assert.True(t, d.Expired(), "polls after the jump are measured from it")
+}
+
+func TestParseSleepPolicy(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]SleepPolicy{"": SleepExtend, "extend": SleepExtend, "fail": SleepFail, "ignore": SleepIgnore} {
+		got, err := ParseSleepPolicy(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := ParseSleepPolicy("Extend")
+	assert.EqualError(t, err, `invalid sleep policy "Extend": must be "extend", "fail" or "ignore"`)
+}
+
+func TestFormatSleep(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "42m", FormatSleep(42*time.Minute+200*time.Millisecond))
+	assert.Equal(t, "1h5m", FormatSleep(65*time.Minute))
+	assert.Equal(t, "1m30s", FormatSleep(90*time.Second))
+	assert.Equal(t, "2h0m", FormatSleep(2*time.Hour), "hours keep their minutes")
+}
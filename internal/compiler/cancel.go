@@ -0,0 +1,76 @@
+package compiler
+
+import (
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// ErrCompileCancelled is returned when the compile was cancelled from the Compiling dialog
+var ErrCompileCancelled = errcode.New(errcode.Cancelled, "compilation was cancelled before it finished")
+
+// cancelledMarkers are the phrases VTPro writes to the Message Log when a compile is cancelled
+var cancelledMarkers = []string{
+	"compile cancelled",
+	"compile canceled",
+	"compilation cancelled",
+	"compilation canceled",
+}
+
+// isCancelledLog reports whether a Message Log read after the Compiling dialog closed
+// belongs to a cancelled compile. A log is cancelled if it contains VTPro's cancellation
+// text, or if any target section ends without both a Successful/Failed banner and a
+// summary line.
+func isCancelledLog(text string, patterns []SummaryPattern) bool {
+	if hasCancelledMarker(text) {
+		return true
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	for _, section := range splitSections(lines) {
+		if !sectionCompleted(section, patterns) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasCancelledMarker reports whether a Message Log contains VTPro's cancellation text
+func hasCancelledMarker(text string) bool {
+	lower := strings.ToLower(text)
+	for _, marker := range cancelledMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sectionCompleted reports whether a section has a result banner or a summary line
+func sectionCompleted(section logSection, patterns []SummaryPattern) bool {
+	for _, line := range section.lines {
+		if isResultBanner(line) {
+			return true
+		}
+
+		if _, ok := matchSummary(line, patterns); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isResultBanner reports whether a line is a "---------- Successful ---------" or
+// "---------- Failed ---------" banner
+func isResultBanner(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "----------") {
+		return false
+	}
+
+	return strings.Contains(trimmed, "Successful") || strings.Contains(trimmed, "Failed")
+}
@@ -0,0 +1,91 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestIsCancelledLog_Fixtures(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		cancelled bool
+	}{
+		{"cancelled_partial.log", true},
+		{"cancelled_second_target.log", true},
+		{"cancelled_message.log", true},
+		{"two_targets.log", false},
+		{"three_targets.log", false},
+		{"long_path_warning.log", false},
+		{"separated_counts.log", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			got := isCancelledLog(readFixture(t, tt.fixture), DefaultSummaryPatterns)
+			assert.Equal(t, tt.cancelled, got)
+		})
+	}
+}
+
+func TestIsCancelledLog_Inline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		text      string
+		cancelled bool
+	}{
+		{"banner without summary", "Boot\n---------- Successful ---------", false},
+		{"summary without banner", "Boot\n0 warning(s), 0 error(s)", false},
+		{"summary variant without banner", "Boot\n0 warnings, 0 errors", false},
+		{"neither banner nor summary", "---------- Compiling for TSW-770: [a.vtp] ---------\nBoot\nMain", true},
+		{"american spelling", "Compilation canceled\n---------- Failed ---------", true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.cancelled, isCancelledLog(tt.text, DefaultSummaryPatterns), tt.name)
+	}
+}
+
+func TestCompiler_CancelledCompile(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	// The Compiling dialog disappears, but the Message Log stops mid-compile
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(0x9999,
+			windows.ChildInfo{ClassName: "Edit", Text: readFixture(t, "cancelled_partial.log")},
+		).
+		WithWindowValid(0x1111, false)
+
+	c := NewCompiler(logger.NewNoOpLogger(),
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(mockWin),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(testutil.NewMockControlReader()),
+	)
+
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: "VisionTools Pro-e Compiling..."},
+	)
+
+	result, err := c.Compile(CompileOptions{
+		Hwnd:                          0x9999,
+		VTProPid:                      1234,
+		SkipPreCompilationDialogCheck: true,
+	})
+
+	require.ErrorIs(t, err, ErrCompileCancelled)
+	require.NotNil(t, result)
+	assert.True(t, result.Cancelled)
+	assert.Equal(t, 0, result.Errors)
+
+	// VTPro is still closed after a cancelled compile
+	assert.Len(t, mockWin.CloseWindowCalls, 1)
+}
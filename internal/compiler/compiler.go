@@ -4,12 +4,16 @@ package compiler
 import (
 	"fmt"
 	"log/slog"
-	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/errcode"
 	"github.com/Norgate-AV/vtpc/internal/interfaces"
 	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/textutil"
 	"github.com/Norgate-AV/vtpc/internal/timeouts"
 	"github.com/Norgate-AV/vtpc/internal/vtpro"
 	"github.com/Norgate-AV/vtpc/internal/windows"
@@ -52,35 +56,71 @@ import (
 // Compile sequence (visually)
 // 1. User triggers compile via F12 keystroke.
 
-const (
-	// Dialog title constants
-	dialogCompiling    = "VisionTools Pro-e Compiling..."
-	dialogVTProWarning = "VisionTools(R) Pro-e" // Warning dialog with OK button
-	dialogAddressBook  = "Address Book"
-)
+// ErrCompileTimeout is returned when the Compiling dialog does not close in time
+var ErrCompileTimeout = errcode.New(errcode.TimeoutCompile, "compilation timeout")
 
 // CompileResult holds the results of a compilation
 type CompileResult struct {
-	Warnings        int
-	Errors          int
-	ErrorMessages   []string
-	WarningMessages []string
-	HasErrors       bool
-	Size            string // Output file size (e.g., "18,588,092 bytes")
-	ProjectSize     string // Project size (e.g., "0 Kb")
+	Warnings                  int
+	Errors                    int
+	Messages                  []Message // All warnings and errors in Message Log order
+	ErrorMessages             []string  // Error texts derived from Messages
+	WarningMessages           []string  // Warning texts derived from Messages
+	HasErrors                 bool
+	Cancelled                 bool                 // The compile was cancelled before VTPro finished
+	LogTruncated              bool                 // The Message Log looked cut off, so counts may be incomplete
+	MisdirectedKeystrokes     int                  // Keystrokes that landed in another window and were retried
+	Keystroke                 KeystrokeReport      // How the compile keystroke was sent and whether VTPro acted on it
+	ConcurrentCompileDetected bool                 // A second Compiling dialog opened, so the log may mix two compiles
+	Size                      string               // Output file size (e.g., "18,588,092 bytes"), "" when several targets report one
+	SizeBytes                 int64                // Size in bytes, 0 if it could not be parsed
+	ProjectSize               string               // Project size (e.g., "0 Kb")
+	ProjectBytes              int64                // ProjectSize in bytes, 0 if it could not be parsed
+	Sections                  []TargetResult       // Per-target results, one per "Compiling for" section
+	SuppressedByPage          []Message            // Messages dropped by ParserOptions.IgnorePages, in log order
+	Reclassified              []Reclassification   // Messages the parser policy changed, in log order
+	Reported                  Counts               // Summary line counts as VTPro printed them, summed over targets
+	MessageCounts             Counts               // Warnings and errors parsed from the Message Log
+	CountMismatches           []string             // Ways the counts broke the invariant in counts.go, if any
+	Monitor                   windows.MonitorStats // Window monitor stats for the run
+	CompileTime               time.Duration        // How long the Compiling dialog was open, 0 if it was never seen
+	Statistics                *CompileStatistics   // What VTPro's statistics dialog showed, nil if it did not appear
+	StartedAt                 time.Time            // When Compile started, from the compiler's clock
+	FinishedAt                time.Time            // When Compile returned, from the compiler's clock
+	Steps                     []StepOutcome        // The steps the compile reached, in order
+}
+
+// AttachMonitorStats records the window monitor's stats on the result and warns
+// when the monitor dropped dialog events or fell behind
+func (r *CompileResult) AttachMonitorStats(stats windows.MonitorStats) {
+	r.Monitor = stats
+
+	for _, w := range stats.Warnings() {
+		addWarning(r, w)
+	}
 }
 
 // CompileOptions holds options for the compilation
 type CompileOptions struct {
 	FilePath                      string
 	Hwnd                          uintptr
-	VTProPid                      uint32        // Known PID from ShellExecuteEx (preferred over searching)
+	VTProPid                      uint32        // Known PID from CreateProcess (preferred over searching)
 	VTProPidPtr                   *uint32       // Pointer to store PID for signal handlers
 	SkipPreCompilationDialogCheck bool          // For testing - skip the pre-compilation dialog check
 	CompilationTimeout            time.Duration // Override default timeout (0 = use default 5 minutes)
+	MessageOrder                  MessageOrder  // How warnings and errors are grouped when logged
+	MessageFormat                 MessageFormat // List messages as they are found, or leave a table to the caller
+	SaveFirst                     bool          // Save the project with Ctrl+S before compiling
+	LaunchMinimized               bool          // VTPro was launched minimized: restore it for the keystroke, then minimize it again
+	Heartbeat                     time.Duration // Interval between "still compiling" messages (0 = disabled)
+	StrictDialogs                 bool          // Fail on any dialog not registered for the compile phase instead of ignoring it
+	LiveLog                       bool          // Echo lines as VTPro appends them to the Message Log while compiling
+	InputMethod                   InputMethod   // How the compile keystroke is sent ("" = InputAuto)
 }
 
 // CompileDependencies holds all external dependencies for testing
+//
+// Deprecated: pass the dependencies to NewCompiler as options instead.
 type CompileDependencies struct {
 	ProcessMgr    interfaces.ProcessManager
 	WindowMgr     interfaces.WindowManager
@@ -90,57 +130,97 @@ type CompileDependencies struct {
 
 // Compiler orchestrates the compilation process with injected dependencies
 type Compiler struct {
-	log           logger.LoggerInterface
-	processMgr    interfaces.ProcessManager
-	windowMgr     interfaces.WindowManager
-	keyboard      interfaces.KeyboardInjector
-	controlReader interfaces.ControlReader
+	log            logger.LoggerInterface
+	processMgr     interfaces.ProcessManager
+	windowMgr      interfaces.WindowManager
+	keyboard       interfaces.KeyboardInjector
+	controlReader  interfaces.ControlReader
+	parser         ParserOptions
+	clock          clock.Clock
+	sleepPolicy    clock.SleepPolicy
+	monitor        func() <-chan windows.WindowEvent
+	foregroundLock func() (windows.ForegroundLock, error)
+	dialogs        *dialog.Registry
+	input          timeouts.Input
+	onStep         func(StepOutcome) // Called as each step of a compile ends, may be nil
 }
 
-// NewCompiler creates a new Compiler with the provided logger and default dependencies
-func NewCompiler(log logger.LoggerInterface) *Compiler {
+// NewCompiler creates a new Compiler with the provided logger. Dependencies default
+// to the production implementations; opts override them one at a time.
+func NewCompiler(log logger.LoggerInterface, opts ...Option) *Compiler {
 	windowsAPI := windows.NewWindowsAPI(log)
-	vtproAPI := vtpro.VTProProcessAPI{}
 
-	return &Compiler{
-		log:           log,
-		processMgr:    vtproAPI,
-		windowMgr:     windowsAPI,
-		keyboard:      windowsAPI,
-		controlReader: windowsAPI,
+	c := &Compiler{
+		log:            log,
+		processMgr:     vtpro.VTProProcessAPI{},
+		windowMgr:      windowsAPI,
+		keyboard:       windowsAPI,
+		controlReader:  windowsAPI,
+		clock:          clock.New(),
+		sleepPolicy:    clock.SleepExtend,
+		monitor:        windowMonitorEvents,
+		foregroundLock: windows.ReadForegroundLock,
+		dialogs:        dialog.Default(),
+		input:          timeouts.DefaultInput(),
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	windowsAPI.SetKeystrokeDelay(c.input.Keystroke)
+
+	return c
 }
 
-// NewCompilerWithDeps creates a new Compiler with custom dependencies for testing
+// NewCompilerWithDeps creates a new Compiler with custom dependencies for testing.
+// Dependencies left nil use the production implementations.
+//
+// Deprecated: use NewCompiler with WithProcessManager, WithWindowManager,
+// WithKeyboard and WithControlReader.
 func NewCompilerWithDeps(log logger.LoggerInterface, deps *CompileDependencies) *Compiler {
-	return &Compiler{
-		log:           log,
-		processMgr:    deps.ProcessMgr,
-		windowMgr:     deps.WindowMgr,
-		keyboard:      deps.Keyboard,
-		controlReader: deps.ControlReader,
+	var opts []Option
+
+	if deps.ProcessMgr != nil {
+		opts = append(opts, WithProcessManager(deps.ProcessMgr))
 	}
+
+	if deps.WindowMgr != nil {
+		opts = append(opts, WithWindowManager(deps.WindowMgr))
+	}
+
+	if deps.Keyboard != nil {
+		opts = append(opts, WithKeyboard(deps.Keyboard))
+	}
+
+	if deps.ControlReader != nil {
+		opts = append(opts, WithControlReader(deps.ControlReader))
+	}
+
+	return NewCompiler(log, opts...)
 }
 
-// Compile orchestrates the compilation process for a VTPro file
-// This includes:
-// - Handling pre-compilation dialogs
-// - Triggering the compile
-// - Monitoring compilation progress
-// - Parsing results
-// - Closing dialogs
-func (c *Compiler) Compile(opts CompileOptions) (*CompileResult, error) {
-	result := &CompileResult{}
-
-	// Use the exact PID from ShellExecuteEx - no searching, no guessing
-	pid := opts.VTProPid
-	if pid == 0 {
-		c.log.Warn("No PID provided - dialog monitoring will be disabled")
+// Compile orchestrates the compilation process for a VTPro file, running each
+// Step in turn and recording their outcomes in the result's Steps
+func (c *Compiler) Compile(opts CompileOptions) (result *CompileResult, err error) {
+	startedAt := c.clock.Now()
+	defer func() {
+		if result != nil {
+			result.StartedAt = startedAt
+			result.FinishedAt = c.clock.Now()
+		}
+	}()
+
+	run := &compileRun{opts: opts, pid: opts.VTProPid, result: &CompileResult{}}
+
+	// Use the exact PID from CreateProcess - no searching, no guessing
+	if run.pid == 0 {
+		c.log.Warn("No PID provided - compiling with keystrokes only, dialog handling disabled")
 		c.log.Info("Warning: Could not determine VTPro process PID; dialog detection may be limited")
 	} else {
-		c.log.Debug("Using VTPro PID from launch", slog.Uint64("pid", uint64(pid)))
+		c.log.Debug("Using VTPro PID from launch", slog.Uint64("pid", uint64(run.pid)))
 		if opts.VTProPidPtr != nil {
-			*opts.VTProPidPtr = pid // Store for signal handler
+			*opts.VTProPidPtr = run.pid // Store for signal handler
 		}
 	}
 
@@ -151,434 +231,521 @@ func (c *Compiler) Compile(opts CompileOptions) (*CompileResult, error) {
 		c.log.Warn("Process is NOT elevated, keystroke injection may fail")
 	}
 
-	// Bring window to foreground and send compile keystroke
-	c.log.Debug("Bringing window to foreground")
-	focusSuccess := c.windowMgr.SetForeground(opts.Hwnd)
-	if !focusSuccess {
-		c.log.Warn("SetForeground failed on first attempt, retrying...")
-		time.Sleep(500 * time.Millisecond)
+	err = c.runSteps(run, c.steps())
 
-		focusSuccess = c.windowMgr.SetForeground(opts.Hwnd)
-		if !focusSuccess {
-			c.log.Error("Failed to bring window to foreground after retry")
-			return &CompileResult{
-				Errors:        1,
-				HasErrors:     true,
-				ErrorMessages: []string{"Failed to bring VTPro to foreground - cannot send keystrokes"},
-			}, fmt.Errorf("failed to bring VTPro to foreground - cannot send keystrokes")
-		}
+	if run.watch != nil {
+		run.watch.stop()
+
+		// Whatever the outcome, the Compiling dialog appearing shows the keystroke reached VTPro
+		run.result.Keystroke.Acknowledged = run.watch.started
+		run.result.Keystroke.Watched = true
 	}
 
-	time.Sleep(timeouts.FocusVerificationDelay)
+	result = run.result
+	result.MisdirectedKeystrokes = run.misdirected
+	result.Steps = run.steps
+	attachKeystroke(result, run.sent)
 
-	// Verify the window is in the foreground before sending keystrokes
-	c.log.Debug("Verifying foreground window")
-	verified := c.windowMgr.VerifyForegroundWindow(opts.Hwnd, pid)
-	if !verified {
-		c.log.Error("Could not verify correct window is in foreground")
-		return &CompileResult{
-			Errors:        1,
-			HasErrors:     true,
-			ErrorMessages: []string{"Wrong window in foreground - cannot safely send keystrokes"},
-		}, fmt.Errorf("wrong window in foreground - cannot safely send keystrokes")
+	if err != nil {
+		return result, err
+	}
+
+	if result.Cancelled {
+		return result, ErrCompileCancelled
+	}
+
+	if result.HasErrors {
+		return result, fmt.Errorf("compilation failed with %d error(s)", result.Errors)
+	}
+
+	return result, nil
+}
+
+// acquireFocus gets VTPro ready for the compile keystroke: shown, with the
+// project saved if asked to, and with no stale window events left to misread
+func (c *Compiler) acquireFocus(run *compileRun) error {
+	opts := run.opts
+
+	// A VTPro launched minimized is only shown for as long as it needs focus
+	if opts.LaunchMinimized && opts.Hwnd != 0 {
+		c.log.Debug("Restoring minimized VTPro for the compile keystroke")
+		c.windowMgr.RestoreWindow(opts.Hwnd)
+	}
+
+	// Save what is on screen so VTPro does not compile the last-saved state
+	if opts.SaveFirst {
+		if err := c.saveProject(opts, &run.misdirected); err != nil {
+			c.log.Error("Failed to save project before compiling", slog.Any("error", err))
+			run.result = newErrorResult(err.Error())
+
+			return err
+		}
 	}
 
 	// Drain any stale events from pre-compilation phase BEFORE triggering compilation
 	// This ensures we start with a clean channel and don't miss the Compiling dialog
 	// Skip this in test mode since tests send all events upfront
-	if pid != 0 && !opts.SkipPreCompilationDialogCheck {
+	if run.pid != 0 && !opts.SkipPreCompilationDialogCheck {
 		c.drainMonitorChannel()
 	}
 
-	// Try SendInput first (modern API, atomic operation)
-	success := c.keyboard.SendF12WithSendInput()
-	if !success {
-		c.log.Warn("SendF12WithSendInput failed, falling back to keybd_event")
-		c.keyboard.SendF12()
+	// A long Message Log is cut off at the control's text limit, losing the summary line
+	if opts.Hwnd != 0 {
+		c.raiseMessageLogLimit(opts.Hwnd)
+	}
+
+	return nil
+}
+
+// triggerCompile sends the compile keystroke
+func (c *Compiler) triggerCompile(run *compileRun) error {
+	hwnd := run.opts.Hwnd
+	send := c.compileKeystroke(run.opts.InputMethod, hwnd, &run.sent)
+
+	var err error
+	if run.opts.InputMethod == InputPostMessage {
+		// Posted key messages go straight to VTPro's window, so VTPro is not
+		// brought to the foreground, which Windows may refuse
+		if !send() {
+			err = fmt.Errorf("failed to send F12 to VTPro")
+		}
 	} else {
-		c.log.Debug("SendF12WithSendInput succeeded")
+		err = c.sendKeystroke(hwnd, run.pid, "F12", send, &run.misdirected)
+	}
+
+	if err != nil {
+		run.result = newErrorResult(err.Error())
+		return err
 	}
 
 	c.log.Debug("Starting compile monitoring")
 
-	// Only attempt dialog handling if we have a valid PID
-	if pid != 0 {
-		// Use event-driven dialog handling
-		var err error
-		var eventResult *CompileResult
+	// Without a PID no dialogs are handled, so the Message Log is never read
+	if run.pid == 0 {
+		addWarning(run.result, keystrokeOnlyWarning)
+	}
 
-		eventResult, err = c.handleCompilationEvents(opts)
-		if err != nil {
-			// Return the result even on error so caller can see what happened
-			return eventResult, err
-		}
+	return nil
+}
+
+// awaitCompileStart waits for the Compiling dialog the keystroke opens,
+// handling any other dialog that appears first
+func (c *Compiler) awaitCompileStart(run *compileRun) error {
+	run.watch = c.watchCompile(run.opts)
+	w := run.watch
+
+	c.log.Debug("Entering event-driven dialog monitoring loop")
+
+	for !w.started {
+		select {
+		case ev := <-w.events:
+			if err := c.compileEvent(run, ev); err != nil {
+				return err
+			}
+
+		case <-w.ticker.C:
+			if err := c.checkCompileDeadline(run); err != nil {
+				return err
+			}
+
+		case <-w.beat.C():
+			c.log.Info(w.beat.Message())
 
-		// Copy event result into our result
-		result = eventResult
+		case <-w.live.C():
+			// Nothing is written to the Message Log until the compile starts
+		}
 	}
 
-	// Close dialogs and handle post-compilation events
-	c.log.Debug("Closing dialogs and VTPro...")
+	return nil
+}
 
-	// Close main window and handle any confirmation dialogs via events
-	if opts.Hwnd != 0 {
-		c.windowMgr.CloseWindow(opts.Hwnd, "VTPro")
+// awaitCompileEnd waits for the Compiling dialog, and any other compile started
+// alongside it, to close
+func (c *Compiler) awaitCompileEnd(run *compileRun) error {
+	w := run.watch
+
+	for {
+		select {
+		case ev := <-w.events:
+			if err := c.compileEvent(run, ev); err != nil {
+				return err
+			}
+
+		case <-w.ticker.C:
+			// Periodically check if compiling dialog has disappeared (VTPro-specific)
+			if c.compileEnded(run) {
+				return nil
+			}
 
-		// Handle confirmation dialog that may appear when closing
-		if pid != 0 {
-			if err := c.handlePostCompilationEvents(); err != nil {
-				// Return the result we have so far, even if cleanup failed
-				return result, err
+			if err := c.checkCompileDeadline(run); err != nil {
+				return err
+			}
+
+		case <-w.beat.C():
+			c.log.Info(w.beat.Message())
+
+		case <-w.live.C():
+			for _, line := range w.live.Update(c.peekMessageLog(run.opts.Hwnd)) {
+				c.log.Info(line)
 			}
 		}
+	}
+}
+
+// compileEvent responds to a window that opened while VTPro compiles
+func (c *Compiler) compileEvent(run *compileRun, ev windows.WindowEvent) error {
+	w := run.watch
 
-		if !opts.SkipPreCompilationDialogCheck {
-			time.Sleep(timeouts.CleanupDelay)
+	c.log.Debug("Received window event",
+		slog.String("title", ev.DisplayTitle()),
+		slog.Uint64("hwnd", uint64(ev.Hwnd)),
+	)
+
+	w.beat.Observe(ev.Title)
+
+	route, known := c.routeDialog(ev)
+	if !known {
+		if !run.opts.StrictDialogs {
+			return nil
+		}
+
+		// Strict mode fails rather than guess, and leaves the dialog for inspection
+		if err := c.unexpectedDialog(ev); err != nil {
+			run.result = newErrorResult(err.Error())
+			return err
 		}
+
+		return nil
 	}
 
-	if result.HasErrors {
-		return result, fmt.Errorf("compilation failed with %d error(s)", result.Errors)
+	switch route.Action {
+	case dialog.ActionInspect:
+		// Some VTPro configurations show the counts in a dialog when the compile ends
+		if stats, ok := c.readStatisticsDialog(ev); ok {
+			w.statistics = &stats
+		}
+
+	case dialog.ActionTrack:
+		if !w.started {
+			c.log.Debug("Detected 'VisionTools Pro-e Compiling...' dialog")
+			c.log.Info("Compiling program...")
+			w.started = true
+			w.dialog = ev.Hwnd
+			w.since = c.clock.Now()
+
+			// The keystroke has landed, so VTPro can go back out of the way
+			if run.opts.LaunchMinimized && run.opts.Hwnd != 0 {
+				c.log.Debug("Minimizing VTPro again now the compile has started")
+				c.windowMgr.MinimizeWindow(run.opts.Hwnd)
+			}
+		} else if ev.Hwnd != w.dialog && !slices.Contains(w.concurrent, ev.Hwnd) {
+			// Someone else started a compile too; wait for it so the log is not read mid-compile
+			w.concurrent = append(w.concurrent, ev.Hwnd)
+			run.result.ConcurrentCompileDetected = true
+			c.log.Warn("A second compile started while vtpc was compiling - was F12 pressed in VTPro? Waiting for both to finish; results may include that compile",
+				slog.Uint64("hwnd", uint64(ev.Hwnd)),
+				slog.Uint64("trackedHwnd", uint64(w.dialog)),
+			)
+		}
 	}
 
-	return result, nil
+	return nil
 }
 
-// handleCompilationEvents uses an event-driven approach to respond to dialogs as they appear
-func (c *Compiler) handleCompilationEvents(opts CompileOptions) (*CompileResult, error) {
-	// Maximum time to wait for compilation to complete
-	// Use custom timeout if specified, otherwise use default 5 minutes
-	compilationTimeout := timeouts.CompilationCompleteTimeout
-	if opts.CompilationTimeout > 0 {
-		compilationTimeout = opts.CompilationTimeout
+// compileEnded reports whether every Compiling dialog has closed, timing the
+// compile when they have
+func (c *Compiler) compileEnded(run *compileRun) bool {
+	w := run.watch
+	if w.dialog == 0 {
+		return false
 	}
 
-	timeout := time.NewTimer(compilationTimeout)
-	defer timeout.Stop()
+	// Poll to see if the compiling dialogs still exist
+	w.concurrent = slices.DeleteFunc(w.concurrent, func(hwnd uintptr) bool {
+		return !c.windowMgr.IsWindowValid(hwnd)
+	})
 
-	result := &CompileResult{}
+	if c.windowMgr.IsWindowValid(w.dialog) || len(w.concurrent) > 0 {
+		return false
+	}
 
-	// Track what we've seen and what we're waiting for
-	var (
-		compilingDetected       bool
-		compileCompleteDetected bool
-		compilingDialogHwnd     uintptr
-	)
+	run.result.CompileTime = c.clock.Now().Sub(w.since)
+	c.log.Debug("Compiling dialog disappeared - compilation complete", slog.Duration("compileTime", run.result.CompileTime))
 
-	// Create a ticker to periodically check if compiling dialog has disappeared
-	ticker := time.NewTicker(500 * time.Millisecond)
-	defer ticker.Stop()
+	return true
+}
 
-	c.log.Debug("Entering event-driven dialog monitoring loop")
+// checkCompileDeadline fails the compile once it has run out of time, or when
+// the system slept through it under clock.SleepFail
+func (c *Compiler) checkCompileDeadline(run *compileRun) error {
+	expired, err := c.pollDeadline(run.watch.deadline)
+	if err != nil {
+		c.log.Error("Compilation stopped: the system slept during the compile", slog.Any("error", err))
+		run.result = newErrorResult(err.Error())
 
-	// Event loop - respond to dialogs as they appear in real-time
-	for {
-		select {
-		case ev := <-windows.MonitorCh:
-			c.log.Debug("Received window event",
-				slog.String("title", ev.Title),
-				slog.Uint64("hwnd", uint64(ev.Hwnd)),
-			)
+		return fmt.Errorf("%w: %w", ErrCompileTimeout, err)
+	}
 
-			// Handle each dialog type as it appears
-			if ev.Title == dialogCompiling {
-				// Compilation in progress
-				if !compilingDetected {
-					c.log.Debug("Detected 'VisionTools Pro-e Compiling...' dialog")
-					c.log.Info("Compiling program...")
-					compilingDetected = true
-					compilingDialogHwnd = ev.Hwnd
-				}
-			}
+	if !expired {
+		return nil
+	}
 
-		case <-ticker.C:
-			// Periodically check if compiling dialog has disappeared (VTPro-specific)
-			if compilingDetected && !compileCompleteDetected && compilingDialogHwnd != 0 {
-				// Poll to see if the compiling dialog still exists
-				if !c.windowMgr.IsWindowValid(compilingDialogHwnd) {
-					c.log.Debug("Compiling dialog disappeared - compilation complete")
-					c.log.Info("Gathering details...")
-
-					// Give UI a moment to update (skip in test mode for speed)
-					if !opts.SkipPreCompilationDialogCheck {
-						time.Sleep(500 * time.Millisecond)
-					}
-
-					// Read Message Log from main window
-					logText := c.readMessageLog(opts.Hwnd)
-					if logText != "" {
-						c.parseVTProOutput(logText, result)
-
-						// Log any warning/error messages
-						if len(result.ErrorMessages) > 0 || len(result.WarningMessages) > 0 {
-							c.logCompilationMessages(result.ErrorMessages, result.WarningMessages)
-						}
-					} else {
-						c.log.Warn("Could not read Message Log contents")
-					}
-
-					compileCompleteDetected = true
-				}
-			}
+	// A compile that never started may have had its keystroke swallowed
+	if !run.watch.started {
+		if err := c.diagnoseBlockedInput(run.opts.Hwnd); err != nil {
+			c.log.Error("Compile keystroke was blocked", slog.Any("error", err))
+			run.result = newErrorResult(err.Error())
 
-			// If we have detected compilation complete, we're done
-			if compileCompleteDetected {
-				// Set HasErrors flag
-				result.HasErrors = result.Errors > 0 || len(result.ErrorMessages) > 0
+			return err
+		}
+	}
 
-				// Compilation complete
-				return result, nil
-			}
+	c.log.Error("Compilation timeout: compilation did not complete within 5 minutes")
+	run.result = newErrorResult("Compilation timeout: compilation did not complete within 5 minutes")
 
-		case <-timeout.C:
-			c.log.Error("Compilation timeout: compilation did not complete within 5 minutes")
-			return &CompileResult{
-				Errors:    1,
-				HasErrors: true,
-				ErrorMessages: []string{
-					"Compilation timeout: compilation did not complete within 5 minutes",
-				},
-			}, fmt.Errorf("compilation timeout: compilation did not complete within 5 minutes")
-		}
+	return fmt.Errorf("%w: compilation did not complete within 5 minutes", ErrCompileTimeout)
+}
+
+// harvestResults reads the Message Log and any statistics dialog into the result
+func (c *Compiler) harvestResults(run *compileRun) error {
+	result := run.result
+
+	c.log.Info("Gathering details...")
+
+	// Give UI a moment to update (skip in test mode for speed)
+	if !run.opts.SkipPreCompilationDialogCheck {
+		time.Sleep(500 * time.Millisecond)
 	}
+
+	// Read Message Log from main window
+	if !c.readResults(run.opts.Hwnd, run.opts.MessageOrder, run.opts.MessageFormat, result) {
+		c.log.Warn("Could not read Message Log contents")
+	}
+
+	c.mergeStatistics(result, run.watch.statistics)
+
+	// Set HasErrors flag (any failed target section also fails the run)
+	result.HasErrors = result.HasErrors || result.Errors > 0 || len(result.ErrorMessages) > 0
+
+	return nil
 }
 
-// logCompilationMessages logs error/warning/notice messages with proper formatting
-func (c *Compiler) logCompilationMessages(errorMsgs, warningMsgs []string) {
-	if len(errorMsgs) > 0 {
-		c.log.Info("")
-		c.log.Info("Error messages:")
-		for i, msg := range errorMsgs {
-			c.log.Info(fmt.Sprintf("  %d. %s", i+1, msg),
-				slog.Int("number", i+1),
-				slog.String("type", "error"),
-				slog.String("message", msg),
-			)
+// closeVTPro closes VTPro's main window and any dialog closing it raises
+func (c *Compiler) closeVTPro(run *compileRun) error {
+	c.log.Debug("Closing dialogs and VTPro...")
+
+	if run.opts.Hwnd == 0 {
+		return nil
+	}
+
+	c.windowMgr.CloseWindow(run.opts.Hwnd, "VTPro")
+
+	// Handle confirmation dialog that may appear when closing
+	if run.pid != 0 {
+		if err := c.handlePostCompilationEvents(); err != nil {
+			return err
 		}
 	}
 
-	if len(warningMsgs) > 0 {
+	if !run.opts.SkipPreCompilationDialogCheck {
+		time.Sleep(timeouts.CleanupDelay)
+	}
+
+	return nil
+}
+
+// pollDeadline polls a wait loop's deadline, logging any sleep it detects, and
+// reports whether the wait has run out of time
+func (c *Compiler) pollDeadline(d *clock.Deadline) (bool, error) {
+	slept, err := d.Poll()
+	if slept > 0 {
+		c.log.Warn(d.SleepMessage(slept))
+	}
+
+	return d.Expired(), err
+}
+
+// logCompilationMessages logs the messages grouped by severity or in Message Log order
+func (c *Compiler) logCompilationMessages(messages []Message, order MessageOrder) {
+	if len(messages) == 0 {
+		return
+	}
+
+	if order == MessageOrderLog {
 		c.log.Info("")
-		c.log.Info("Warning messages:")
-		for i, msg := range warningMsgs {
-			c.log.Info(fmt.Sprintf("  %d. %s", i+1, msg),
-				slog.Int("number", i+1),
-				slog.String("type", "warning"),
-				slog.String("message", msg),
-			)
+		c.log.Info("Messages:")
+		for i, m := range messages {
+			c.logMessage(i+1, fmt.Sprintf("[%s] %s", m.Severity, m.Text), m)
 		}
+	} else {
+		c.logMessageGroup("Error messages:", messages, SeverityError)
+		c.logMessageGroup("Warning messages:", messages, SeverityWarning)
 	}
 
-	// Add trailing blank line if any messages were displayed
-	if len(errorMsgs) > 0 || len(warningMsgs) > 0 {
-		c.log.Info("")
+	// Add trailing blank line after the messages
+	c.log.Info("")
+}
+
+// logMessageGroup logs the messages of one severity under a heading, numbered from 1
+func (c *Compiler) logMessageGroup(heading string, messages []Message, severity Severity) {
+	number := 0
+
+	for _, m := range messages {
+		if m.Severity != severity {
+			continue
+		}
+
+		if number == 0 {
+			c.log.Info("")
+			c.log.Info(heading)
+		}
+
+		number++
+		c.logMessage(number, m.Text, m)
 	}
 }
 
-// handlePostCompilationEvents waits for and handles any post-compilation dialogs (like Address Book)
+// logMessage logs a single numbered message line
+func (c *Compiler) logMessage(number int, text string, m Message) {
+	c.log.Info(fmt.Sprintf("  %d. %s", number, text),
+		slog.Int("number", number),
+		slog.String("type", m.Severity.String()),
+		slog.String("message", m.Text),
+	)
+}
+
+// handlePostCompilationEvents closes the dialogs VTPro shows once the compile is
+// over, like the Address Book, until no more appear. WaitOnMonitor also finds
+// those that appeared before it was called.
 func (c *Compiler) handlePostCompilationEvents() error {
-	// Short timeout - if no confirmation dialog appears, that's fine
-	timeout := time.NewTimer(timeouts.DialogConfirmationTimeout)
-	defer timeout.Stop()
+	handled := make(map[uintptr]bool)
+
+	for {
+		// Short timeout - if no further dialog appears, that's fine
+		ev, ok := c.windowMgr.WaitOnMonitor(timeouts.DialogConfirmationTimeout, func(ev windows.WindowEvent) bool {
+			return !handled[ev.Hwnd] && c.isPostCompilationDialog(ev)
+		})
+		if !ok {
+			return nil
+		}
+
+		handled[ev.Hwnd] = true
 
-	select {
-	case ev := <-windows.MonitorCh:
 		c.log.Trace("Received post-compilation event",
-			slog.String("title", ev.Title),
+			slog.String("title", ev.DisplayTitle()),
 			slog.Uint64("hwnd", uint64(ev.Hwnd)))
 
-		// Handle Address Book dialog if it appears
-		if ev.Title == dialogAddressBook {
-			c.log.Trace("Detected 'Address Book' dialog - closing")
-			c.log.Debug("Handling Address Book dialog")
-			c.windowMgr.CloseWindow(ev.Hwnd, dialogAddressBook)
+		dialog.LogControls(c.log, c.windowMgr, ev.Hwnd, ev.DisplayTitle())
+
+		if route, _ := c.routeDialog(ev); route.Action == dialog.ActionInspect {
+			// A statistics dialog that opened after the Message Log was read would block closing VTPro
+			c.log.Debug("Closing statistics dialog", slog.String("title", ev.DisplayTitle()))
+		} else {
+			c.log.Debug("Closing post-compilation dialog", slog.String("title", ev.DisplayTitle()))
 		}
 
-	case <-timeout.C:
-		// Timeout is fine - dialog may not appear
+		c.windowMgr.CloseWindow(ev.Hwnd, ev.DisplayTitle())
 	}
+}
 
-	return nil
+// isPostCompilationDialog matches the open dialogs handlePostCompilationEvents
+// closes: those the registry closes, and the statistics dialog
+func (c *Compiler) isPostCompilationDialog(ev windows.WindowEvent) bool {
+	route, ok := c.routeDialog(ev)
+	if !ok || (route.Action != dialog.ActionClose && route.Action != dialog.ActionInspect) {
+		return false
+	}
+
+	// Dialogs handled during the compile stay in the monitor's recent events after closing
+	return c.windowMgr.IsWindowValid(ev.Hwnd)
+}
+
+// readResults reads and parses the Message Log of VTPro's main window into
+// result, logging the messages when format is MessageFormatList. It reports
+// false when no Message Log could be found.
+func (c *Compiler) readResults(mainHwnd uintptr, order MessageOrder, format MessageFormat, result *CompileResult) bool {
+	logText, logHwnd := c.readMessageLog(mainHwnd)
+	if logText == "" {
+		return false
+	}
+
+	c.parseVTProOutput(logText, result)
+
+	// A log cut off by the control's text limit also lacks its banner and summary
+	switch {
+	case hasCancelledMarker(logText):
+		c.log.Warn("Message Log reports the compile was cancelled")
+		result.Cancelled = true
+	case c.checkTruncation(logText, logHwnd, result):
+	case isCancelledLog(logText, c.parser.summaryPatterns()):
+		c.log.Warn("Message Log has no result banner or summary line - compile was cancelled")
+		result.Cancelled = true
+	}
+
+	// Log any warning/error messages
+	// A table is rendered by the caller with FormatMessageTable
+	if len(result.Messages) > 0 && format == MessageFormatList {
+		c.logCompilationMessages(result.Messages, order)
+	}
+
+	return true
 }
 
-// readMessageLog finds and reads the Message Log child window in VTPro
-func (c *Compiler) readMessageLog(mainHwnd uintptr) string {
+// readMessageLog finds and reads the Message Log child window in VTPro,
+// returning its text and handle
+func (c *Compiler) readMessageLog(mainHwnd uintptr) (string, uintptr) {
 	c.log.Trace("Reading Message Log from main window")
 
 	childInfos := c.windowMgr.CollectChildInfos(mainHwnd)
 
 	// Look for a child control with "Message Log" text or that contains compilation output
 	for _, ci := range childInfos {
+		text := textutil.Normalize(ci.Text)
+
 		c.log.Trace("Checking child control",
 			slog.String("class", ci.ClassName),
-			slog.String("text", ci.Text),
+			slog.String("text", text),
 		)
 
 		// Look for compilation output markers
-		if strings.Contains(ci.Text, "Compiling for") ||
-			strings.Contains(ci.Text, "Successful") ||
-			strings.Contains(ci.Text, "error(s)") {
+		if isMessageLogText(text) {
 			c.log.Trace("Found Message Log content",
 				slog.String("className", ci.ClassName),
-				slog.Int("textLength", len(ci.Text)),
+				slog.Int("textLength", len(text)),
 			)
-			return ci.Text
+			return text, ci.Hwnd
 		}
 	}
 
 	c.log.Warn("Could not find Message Log control")
-	return ""
+	return "", 0
 }
 
-// parseVTProOutput parses VTPro compilation output format
-// Example format:
-// ---------- Compiling for TSW-770: [...] ---------
-// Boot
-// ~DummyFlashPage - [ not compiled ]
-// somepage
-//
-//	[ warning ]: Object "..." on Page "..." has an unassigned Smart Object ID.
-//	[ error ]: Some error message
-//
-// ...
-// ---------- Successful ---------
-// 1 warning(s), 0 error(s)
-func (c *Compiler) parseVTProOutput(text string, result *CompileResult) {
-	c.log.Trace("Parsing VTPro output", slog.Int("textLength", len(text)))
-
-	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
-
-	// Process lines, handling multi-line messages (VTPro wraps long lines)
-	for i := 0; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
-		if line == "" {
-			continue
-		}
-
-		// Look for warning messages: [ warning ]: ...
-		if strings.Contains(line, "[ warning ]") {
-			// Extract the warning message after "[ warning ]:"
-			if idx := strings.Index(line, "[ warning ]:"); idx != -1 {
-				msg := strings.TrimSpace(line[idx+len("[ warning ]:"):])
-
-				// Check if next lines are continuations (indented or part of wrapped message)
-				// Limit continuation to prevent unbounded growth
-				maxContinuations := 5
-				continuations := 0
-				for i+1 < len(lines) && continuations < maxContinuations {
-					nextLine := lines[i+1]
-					// If next line doesn't start with a marker, it's a continuation
-					if !strings.Contains(nextLine, "[ error ]") &&
-						!strings.Contains(nextLine, "[ warning ]") &&
-						!strings.Contains(nextLine, "----------") &&
-						!strings.Contains(nextLine, "warning(s)") &&
-						strings.TrimSpace(nextLine) != "" {
-						i++
-						continuations++
-						msg += " " + strings.TrimSpace(nextLine)
-					} else {
-						break
-					}
-				}
-
-				if msg != "" {
-					result.WarningMessages = append(result.WarningMessages, msg)
-					c.log.Trace("Found warning message", slog.String("message", msg))
-				}
-			}
-		}
-
-		// Look for error messages: [ error ]: ...
-		if strings.Contains(line, "[ error ]") {
-			// Extract the error message after "[ error ]:"
-			if idx := strings.Index(line, "[ error ]:"); idx != -1 {
-				msg := strings.TrimSpace(line[idx+len("[ error ]:"):])
-
-				// Check if next lines are continuations (indented or part of wrapped message)
-				// Limit continuation to prevent unbounded growth
-				maxContinuations := 5
-				continuations := 0
-				for i+1 < len(lines) && continuations < maxContinuations {
-					nextLine := lines[i+1]
-					// If next line doesn't start with a marker, it's a continuation
-					if !strings.Contains(nextLine, "[ error ]") &&
-						!strings.Contains(nextLine, "[ warning ]") &&
-						!strings.Contains(nextLine, "----------") &&
-						!strings.Contains(nextLine, "warning(s)") &&
-						strings.TrimSpace(nextLine) != "" {
-						i++
-						continuations++
-						msg += " " + strings.TrimSpace(nextLine)
-					} else {
-						break
-					}
-				}
-
-				if msg != "" {
-					result.ErrorMessages = append(result.ErrorMessages, msg)
-					c.log.Trace("Found error message", slog.String("message", msg))
-				}
-			}
-		}
-
-		// Look for size: [ size ]: 18,588,092 bytes
-		if strings.Contains(line, "[ size ]") {
-			if idx := strings.Index(line, "[ size ]:"); idx != -1 {
-				size := strings.TrimSpace(line[idx+len("[ size ]:"):])
-				if size != "" {
-					result.Size = size
-					c.log.Trace("Found size", slog.String("size", size))
-				}
-			}
-		}
-
-		// Look for project size: [ project size ]: 0 Kb
-		if strings.Contains(line, "[ project size ]") {
-			if idx := strings.Index(line, "[ project size ]:"); idx != -1 {
-				projectSize := strings.TrimSpace(line[idx+len("[ project size ]:"):])
-				if projectSize != "" {
-					result.ProjectSize = projectSize
-					c.log.Trace("Found project size", slog.String("projectSize", projectSize))
-				}
-			}
-		}
-
-		// Look for the summary line: "0 warning(s), 0 error(s)"
-		// or "X warning(s), Y error(s)"
-		if strings.Contains(line, "warning(s)") && strings.Contains(line, "error(s)") {
-			c.log.Trace("Found summary line", slog.String("line", line))
-
-			// Parse: "0 warning(s), 0 error(s)"
-			pattern := regexp.MustCompile(`(\d+)\s+warning\(s\),\s+(\d+)\s+error\(s\)`)
-			matches := pattern.FindStringSubmatch(line)
-
-			if len(matches) >= 3 {
-				if warnings, err := fmt.Sscanf(matches[1], "%d", &result.Warnings); err == nil {
-					c.log.Trace("Parsed warnings", slog.Int("warnings", result.Warnings))
-					_ = warnings
-				}
-
-				if errors, err := fmt.Sscanf(matches[2], "%d", &result.Errors); err == nil {
-					c.log.Trace("Parsed errors", slog.Int("errors", result.Errors))
-					_ = errors
-				}
-			}
+// peekMessageLog reads the Message Log like readMessageLog, but quietly, as it
+// is read repeatedly while VTPro is still writing to it
+func (c *Compiler) peekMessageLog(mainHwnd uintptr) string {
+	for _, ci := range c.windowMgr.CollectChildInfos(mainHwnd) {
+		if text := textutil.Normalize(ci.Text); isMessageLogText(text) {
+			return text
 		}
 	}
 
-	c.log.Trace("Parse complete",
-		slog.Int("warnings", result.Warnings),
-		slog.Int("errors", result.Errors),
-		slog.String("size", result.Size),
-		slog.String("projectSize", result.ProjectSize),
-	)
+	return ""
+}
+
+// isMessageLogText reports whether a control's text is compilation output
+func isMessageLogText(text string) bool {
+	return strings.Contains(text, "Compiling for") ||
+		strings.Contains(text, "Successful") ||
+		strings.Contains(text, "error(s)")
 }
 
 // drainMonitorChannel drains any pending events from the monitor channel
 // to ensure we don't miss critical events during compilation monitoring.
 // This clears any stale pre-compilation events that may have accumulated.
 func (c *Compiler) drainMonitorChannel() {
-	if windows.MonitorCh == nil {
+	events := c.monitor()
+	if events == nil {
 		return
 	}
 
@@ -587,10 +754,10 @@ func (c *Compiler) drainMonitorChannel() {
 	draining := true
 	for draining {
 		select {
-		case ev := <-windows.MonitorCh:
+		case ev := <-events:
 			drained++
 			c.log.Trace("Drained pre-compilation event",
-				slog.String("title", ev.Title),
+				slog.String("title", ev.DisplayTitle()),
 				slog.Uint64("hwnd", uint64(ev.Hwnd)))
 		default:
 			// Channel empty, ready to start monitoring
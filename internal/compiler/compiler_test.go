@@ -6,6 +6,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/dialog"
 	"github.com/Norgate-AV/vtpc/internal/logger"
 	"github.com/Norgate-AV/vtpc/internal/testutil"
 	"github.com/Norgate-AV/vtpc/internal/windows"
@@ -30,14 +32,12 @@ func TestCompiler_SuccessfulCompilation(t *testing.T) {
 	mockProc := testutil.NewMockProcessManager().WithPid(1234)
 
 	log := logger.NewNoOpLogger()
-	deps := &CompileDependencies{
-		ProcessMgr:    mockProc,
-		WindowMgr:     mockWin,
-		Keyboard:      mockKbd,
-		ControlReader: mockCtrl,
-	}
-
-	compiler := NewCompilerWithDeps(log, deps)
+	compiler := NewCompiler(log,
+		WithProcessManager(mockProc),
+		WithWindowManager(mockWin),
+		WithKeyboard(mockKbd),
+		WithControlReader(mockCtrl),
+	)
 	opts := CompileOptions{
 		Hwnd:                          0x9999,
 		VTProPid:                      1234,
@@ -56,8 +56,9 @@ func TestCompiler_SuccessfulCompilation(t *testing.T) {
 	assert.Equal(t, 0, result.Errors)
 	assert.Equal(t, 0, result.Warnings)
 
-	// Verify F12 was sent
+	// Verify F12 was sent, and VTPro acted on it
 	assert.True(t, mockKbd.SendF12WithSendInputCalled)
+	assert.Equal(t, KeystrokeReport{Method: InputSendInput, Acknowledged: true, Watched: true}, result.Keystroke)
 
 	// Verify window was set to foreground
 	assert.Len(t, mockWin.SetForegroundCalls, 1)
@@ -86,14 +87,12 @@ func TestCompiler_WithWarnings(t *testing.T) {
 	mockProc := testutil.NewMockProcessManager().WithPid(1234)
 
 	log := logger.NewNoOpLogger()
-	deps := &CompileDependencies{
-		ProcessMgr:    mockProc,
-		WindowMgr:     mockWin,
-		Keyboard:      mockKbd,
-		ControlReader: mockCtrl,
-	}
-
-	compiler := NewCompilerWithDeps(log, deps)
+	compiler := NewCompiler(log,
+		WithProcessManager(mockProc),
+		WithWindowManager(mockWin),
+		WithKeyboard(mockKbd),
+		WithControlReader(mockCtrl),
+	)
 
 	opts := CompileOptions{
 		Hwnd:                          0x9999,
@@ -131,14 +130,12 @@ func TestCompiler_WithErrors(t *testing.T) {
 	mockProc := testutil.NewMockProcessManager().WithPid(1234)
 
 	log := logger.NewNoOpLogger()
-	deps := &CompileDependencies{
-		ProcessMgr:    mockProc,
-		WindowMgr:     mockWin,
-		Keyboard:      mockKbd,
-		ControlReader: mockCtrl,
-	}
-
-	compiler := NewCompilerWithDeps(log, deps)
+	compiler := NewCompiler(log,
+		WithProcessManager(mockProc),
+		WithWindowManager(mockWin),
+		WithKeyboard(mockKbd),
+		WithControlReader(mockCtrl),
+	)
 
 	opts := CompileOptions{
 		Hwnd:                          0x9999,
@@ -172,14 +169,12 @@ func TestCompiler_CompileDialogTimeout(t *testing.T) {
 	mockProc := testutil.NewMockProcessManager().WithPid(1234)
 
 	log := logger.NewNoOpLogger()
-	deps := &CompileDependencies{
-		ProcessMgr:    mockProc,
-		WindowMgr:     mockWin,
-		Keyboard:      mockKbd,
-		ControlReader: mockCtrl,
-	}
-
-	compiler := NewCompilerWithDeps(log, deps)
+	compiler := NewCompiler(log,
+		WithProcessManager(mockProc),
+		WithWindowManager(mockWin),
+		WithKeyboard(mockKbd),
+		WithControlReader(mockCtrl),
+	)
 
 	opts := CompileOptions{
 		Hwnd:                          0x9999,
@@ -198,6 +193,7 @@ func TestCompiler_CompileDialogTimeout(t *testing.T) {
 	assert.True(t, result.HasErrors)
 	assert.Equal(t, 1, result.Errors)
 	assert.Len(t, result.ErrorMessages, 1)
+	assert.Equal(t, KeystrokeReport{Method: InputSendInput, Watched: true}, result.Keystroke, "SendInput succeeded but VTPro never started compiling")
 }
 
 func TestCompiler_NoPid(t *testing.T) {
@@ -217,14 +213,12 @@ func TestCompiler_NoPid(t *testing.T) {
 	mockProc := testutil.NewMockProcessManager().WithPid(0) // PID not available
 
 	log := logger.NewNoOpLogger()
-	deps := &CompileDependencies{
-		ProcessMgr:    mockProc,
-		WindowMgr:     mockWin,
-		Keyboard:      mockKbd,
-		ControlReader: mockCtrl,
-	}
-
-	compiler := NewCompilerWithDeps(log, deps)
+	compiler := NewCompiler(log,
+		WithProcessManager(mockProc),
+		WithWindowManager(mockWin),
+		WithKeyboard(mockKbd),
+		WithControlReader(mockCtrl),
+	)
 
 	opts := CompileOptions{
 		Hwnd:                          0x9999,
@@ -245,6 +239,157 @@ func TestCompiler_NoPid(t *testing.T) {
 
 	// Verify F12 was still sent even without PID (new SendInput method should be called)
 	assert.True(t, mockKbd.SendF12WithSendInputCalled)
+	assert.False(t, result.Keystroke.Watched, "without a PID nothing watches for the Compiling dialog")
+}
+
+// steppingClock is a fake clock that moves a second forward every time it is read
+type steppingClock struct {
+	*clock.Fake
+}
+
+func (c steppingClock) Now() time.Time {
+	now := c.Fake.Now()
+	c.Advance(time.Second)
+
+	return now
+}
+
+func TestCompiler_RecordsStartAndFinishTimes(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	start := time.Date(2025, 6, 1, 8, 0, 0, 0, time.FixedZone("", 2*60*60))
+	clk := steppingClock{clock.NewFake(start)}
+
+	compiler := NewCompiler(logger.NewNoOpLogger(),
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(0)),
+		WithWindowManager(testutil.NewMockWindowManager()),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(testutil.NewMockControlReader()),
+		WithClock(clk),
+	)
+
+	result, err := compiler.Compile(CompileOptions{
+		Hwnd:                          0x9999,
+		SkipPreCompilationDialogCheck: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, start, result.StartedAt, "the start time comes from the injected clock")
+	assert.True(t, result.FinishedAt.After(result.StartedAt))
+}
+
+func TestCompiler_MeasuresCompileTime(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	clk := steppingClock{clock.NewFake(time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC))}
+
+	compiler := NewCompiler(logger.NewNoOpLogger(),
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(testutil.NewMockWindowManager().
+			WithChildInfosForHwnd(0x9999, windows.ChildInfo{ClassName: "ListBox", Text: "---------- Successful ---------\n0 warning(s), 0 error(s)"}).
+			WithWindowValid(0x1111, false)),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(testutil.NewMockControlReader()),
+		WithClock(clk),
+	)
+
+	testutil.SendEventsToMonitor(windows.WindowEvent{Hwnd: 0x1111, Title: dialog.Compiling.Title})
+
+	result, err := compiler.Compile(CompileOptions{
+		Hwnd:                          0x9999,
+		VTProPid:                      1234,
+		SkipPreCompilationDialogCheck: true,
+	})
+	assert.NoError(t, err)
+
+	// Timed by the injected clock, from the Compiling dialog opening to it closing
+	assert.GreaterOrEqual(t, result.CompileTime, time.Second)
+	assert.Less(t, result.CompileTime, result.FinishedAt.Sub(result.StartedAt))
+}
+
+// sleepingClock is a fake clock the system sleeps 42 minutes on every time it is read
+type sleepingClock struct {
+	*clock.Fake
+}
+
+func (c sleepingClock) Now() time.Time {
+	now := c.Fake.Now()
+	c.Advance(42 * time.Minute)
+
+	return now
+}
+
+// compileThroughSleep compiles with the system sleeping between every poll of the
+// compile loop, while the Compiling dialog stays open for the first two polls
+func compileThroughSleep(t *testing.T, policy clock.SleepPolicy) (*testutil.MockLogger, error) {
+	t.Helper()
+
+	testutil.SetupMonitorChannel()
+	t.Cleanup(testutil.CleanupMonitorChannel)
+
+	log := testutil.NewMockLogger()
+	compiler := NewCompiler(log,
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(testutil.NewMockWindowManager().
+			WithChildInfosForHwnd(0x9999, windows.ChildInfo{ClassName: "ListBox", Text: "---------- Successful ---------\n0 warning(s), 0 error(s)"}).
+			WithWindowValidSequence(0x1111, true, true, false)),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(testutil.NewMockControlReader()),
+		WithClock(sleepingClock{clock.NewFake(time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC))}),
+		WithSleepPolicy(policy),
+	)
+
+	testutil.SendEventsToMonitor(windows.WindowEvent{Hwnd: 0x1111, Title: dialog.Compiling.Title})
+
+	_, err := compiler.Compile(CompileOptions{
+		Hwnd:                          0x9999,
+		VTProPid:                      1234,
+		SkipPreCompilationDialogCheck: true,
+		CompilationTimeout:            time.Second,
+	})
+
+	return log, err
+}
+
+func TestCompiler_ExtendsTimeoutOverSleep(t *testing.T) {
+	log, err := compileThroughSleep(t, clock.SleepExtend)
+
+	// Hours passed on the clock, but none of it was spent waiting
+	assert.NoError(t, err)
+	assert.True(t, hasMessage(log, "System appears to have slept for"))
+	assert.True(t, hasMessage(log, "; extending deadline"))
+}
+
+func TestCompiler_FailsOnSleep(t *testing.T) {
+	_, err := compileThroughSleep(t, clock.SleepFail)
+
+	assert.ErrorIs(t, err, ErrCompileTimeout)
+	assert.ErrorIs(t, err, clock.ErrSlept)
+}
+
+func TestCompiler_IgnoresSleep(t *testing.T) {
+	log, err := compileThroughSleep(t, clock.SleepIgnore)
+
+	assert.ErrorIs(t, err, ErrCompileTimeout, "the sleep counts against the timeout")
+	assert.NotErrorIs(t, err, clock.ErrSlept)
+	assert.True(t, hasMessage(log, "; not extending deadline"))
+}
+
+func TestCompiler_NoCompileTimeWithoutCompilingDialog(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	compiler := NewCompiler(logger.NewNoOpLogger(),
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(0)),
+		WithWindowManager(testutil.NewMockWindowManager()),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(testutil.NewMockControlReader()),
+	)
+
+	result, err := compiler.Compile(CompileOptions{Hwnd: 0x9999, SkipPreCompilationDialogCheck: true})
+	assert.NoError(t, err)
+	assert.Zero(t, result.CompileTime)
 }
 
 func TestCompiler_WithSavePrompts(t *testing.T) {
@@ -263,14 +408,12 @@ func TestCompiler_WithSavePrompts(t *testing.T) {
 	mockProc := testutil.NewMockProcessManager().WithPid(1234)
 
 	log := logger.NewNoOpLogger()
-	deps := &CompileDependencies{
-		ProcessMgr:    mockProc,
-		WindowMgr:     mockWin,
-		Keyboard:      mockKbd,
-		ControlReader: mockCtrl,
-	}
-
-	compiler := NewCompilerWithDeps(log, deps)
+	compiler := NewCompiler(log,
+		WithProcessManager(mockProc),
+		WithWindowManager(mockWin),
+		WithKeyboard(mockKbd),
+		WithControlReader(mockCtrl),
+	)
 
 	opts := CompileOptions{
 		Hwnd:                          0x9999,
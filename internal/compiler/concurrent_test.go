@@ -0,0 +1,121 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+const concurrentWarning = "A second compile started while vtpc was compiling - was F12 pressed in VTPro? Waiting for both to finish; results may include that compile"
+
+func newConcurrentCompiler(log logger.LoggerInterface, mockWin *testutil.MockWindowManager) *Compiler {
+	return NewCompiler(log,
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(vtproPid)),
+		WithWindowManager(mockWin),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(testutil.NewMockControlReader()),
+	)
+}
+
+func countWarnings(log *testutil.MockLogger, msg string) int {
+	n := 0
+	for _, e := range log.Entries {
+		if e.Level == "WARN" && e.Message == msg {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestCompiler_WaitsForConcurrentCompile(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	// The user's compile outlasts ours: it is still open on the first poll
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(vtproHwnd, windows.ChildInfo{ClassName: "Edit", Text: successfulLog}).
+		WithWindowValid(0x1111, false).
+		WithWindowValidSequence(0x2222, true, false)
+	log := testutil.NewMockLogger()
+	c := newConcurrentCompiler(log, mockWin)
+
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: dialog.Compiling.Title},
+		windows.WindowEvent{Hwnd: 0x2222, Title: dialog.Compiling.Title},
+	)
+
+	result, err := c.Compile(CompileOptions{
+		Hwnd:                          vtproHwnd,
+		VTProPid:                      vtproPid,
+		SkipPreCompilationDialogCheck: true,
+	})
+
+	require.NoError(t, err)
+	assert.True(t, result.ConcurrentCompileDetected)
+	assert.Equal(t, 1, countWarnings(log, concurrentWarning))
+
+	// The log was only read once the second dialog was seen to close
+	assert.Equal(t, []bool{false}, mockWin.WindowValiditySequences[0x2222])
+}
+
+func TestCompiler_ConcurrentCompileWarnsOnce(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(vtproHwnd, windows.ChildInfo{ClassName: "Edit", Text: successfulLog}).
+		WithWindowValid(0x1111, false).
+		WithWindowValid(0x2222, false)
+	log := testutil.NewMockLogger()
+	c := newConcurrentCompiler(log, mockWin)
+
+	// The monitor reports a dialog again when its title or state changes
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: dialog.Compiling.Title},
+		windows.WindowEvent{Hwnd: 0x2222, Title: dialog.Compiling.Title},
+		windows.WindowEvent{Hwnd: 0x2222, Title: dialog.Compiling.Title},
+	)
+
+	result, err := c.Compile(CompileOptions{
+		Hwnd:                          vtproHwnd,
+		VTProPid:                      vtproPid,
+		SkipPreCompilationDialogCheck: true,
+	})
+
+	require.NoError(t, err)
+	assert.True(t, result.ConcurrentCompileDetected)
+	assert.Equal(t, 1, countWarnings(log, concurrentWarning))
+}
+
+func TestCompiler_RepeatedCompilingEventIsNotConcurrent(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(vtproHwnd, windows.ChildInfo{ClassName: "Edit", Text: successfulLog}).
+		WithWindowValid(0x1111, false)
+	log := testutil.NewMockLogger()
+	c := newConcurrentCompiler(log, mockWin)
+
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: dialog.Compiling.Title},
+		windows.WindowEvent{Hwnd: 0x1111, Title: dialog.Compiling.Title},
+	)
+
+	result, err := c.Compile(CompileOptions{
+		Hwnd:                          vtproHwnd,
+		VTProPid:                      vtproPid,
+		SkipPreCompilationDialogCheck: true,
+	})
+
+	require.NoError(t, err)
+	assert.False(t, result.ConcurrentCompileDetected)
+	assert.Zero(t, countWarnings(log, concurrentWarning))
+}
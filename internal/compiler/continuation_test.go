@@ -0,0 +1,103 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+const longPathWarning = "The file path " +
+	"C:\\Users\\Technician\\Documents\\Crestron\\Projects\\2026\\Client Sites\\ " +
+	"Headquarters Building\\Level 3\\Boardroom\\Touch Panels\\ " +
+	"TSW-770 Wall Mounted\\Graphics\\Backgrounds\\Source Selection\\ " +
+	"High Resolution Assets\\Approved By Client\\Revision 12\\ " +
+	"boardroom_background_1280x800_final_v12_approved.png " +
+	"exceeds the windows path limitations and may cause issues " +
+	"during compilation or deployment."
+
+func TestParseVTProOutput_LongWarningTruncatedByDefault(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	result := &CompileResult{}
+	c.parseVTProOutput(readFixture(t, "long_path_warning.log"), result)
+
+	require.Len(t, result.WarningMessages, 1)
+	assert.NotEqual(t, longPathWarning, result.WarningMessages[0])
+	assert.NotContains(t, result.WarningMessages[0], "deployment.")
+	assert.Equal(t, 1, result.Warnings)
+}
+
+func TestParseVTProOutput_LongWarningWithRaisedLimit(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger(), WithParser(ParserOptions{MaxContinuations: 10}))
+
+	result := &CompileResult{}
+	c.parseVTProOutput(readFixture(t, "long_path_warning.log"), result)
+
+	require.Len(t, result.WarningMessages, 1)
+	assert.Equal(t, longPathWarning, result.WarningMessages[0])
+	assert.Equal(t, "18,588,092 bytes", result.Size)
+}
+
+func TestParseVTProOutput_PageNamesAreNotContinuations(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	result := &CompileResult{}
+	c.parseVTProOutput(readFixture(t, "page_names_after_message.log"), result)
+
+	assert.Equal(t, []string{
+		"Object \"Volume\" on Page \"Main\" has an unassigned Smart Object ID.",
+	}, result.WarningMessages)
+	assert.Equal(t, []string{
+		"Object \"Mute\" on Page \"Audio_Presets\" has an invalid join number.",
+	}, result.ErrorMessages)
+	assert.Equal(t, 1, result.Warnings)
+	assert.Equal(t, 1, result.Errors)
+}
+
+func TestContinuationRules_IsContinuation(t *testing.T) {
+	t.Parallel()
+
+	rules := continuationRules{max: DefaultMaxContinuations, patterns: DefaultSummaryPatterns}
+
+	tests := []struct {
+		name string
+		line string
+		want bool
+	}{
+		{"tab indented text", "\tand continues here", true},
+		{"space indented text", "    and continues here", true},
+		{"wrapped path segment", "\tC:\\Users\\Technician\\", true},
+		{"unindented page name", "Settings", false},
+		{"unindented text", "and continues here", false},
+		{"indented page name", "\tAudio_Presets", false},
+		{"indented not compiled page", "\t~DummyFlashPage - [ not compiled ]", false},
+		{"blank line", "\t", false},
+		{"next marker", "\t[ error ]: Something", false},
+		{"summary variant", "\t1 warning, 0 errors", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, rules.isContinuation(tt.line), tt.name)
+	}
+}
+
+func TestContinuationRules_IndentedPageNames(t *testing.T) {
+	t.Parallel()
+
+	// When page names are themselves indented, continuations must go deeper still
+	rules := continuationRules{max: DefaultMaxContinuations, pageIndent: 2}
+
+	assert.False(t, rules.isContinuation("  wrapped at page depth"))
+	assert.True(t, rules.isContinuation("\twrapped deeper than pages"))
+}
+
+func TestParserOptions_MaxContinuations(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, DefaultMaxContinuations, ParserOptions{}.maxContinuations())
+	assert.Equal(t, DefaultMaxContinuations, ParserOptions{MaxContinuations: -1}.maxContinuations())
+	assert.Equal(t, 12, ParserOptions{MaxContinuations: 12}.maxContinuations())
+}
@@ -0,0 +1,134 @@
+package compiler
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// Counts is a number of warnings and errors
+type Counts struct {
+	Warnings int
+	Errors   int
+}
+
+// String formats the counts the way VTPro's summary line does
+func (c Counts) String() string {
+	return fmt.Sprintf("%d warning(s), %d error(s)", c.Warnings, c.Errors)
+}
+
+// The counts invariant, checked by finalizeCounts once a Message Log is parsed:
+//
+//   - Warnings and Errors are what VTPro's summary lines reported (Reported),
+//     changed only by --ignore-pages, one count per suppressed message, and
+//     the rule policy, one count per reclassified message.
+//   - MessageCounts counts the structured messages parsed from the log, which
+//     can be fewer than VTPro counted but never more.
+//   - Presentation (ordering, the message table) works on copies of Messages
+//     and never changes either.
+//
+// Messages vtpc adds itself, such as the truncation warning, are not counted.
+
+// countMismatchWarning prefixes the warning added when the invariant does not hold
+const countMismatchWarning = "vtpc: warning and error counts are inconsistent"
+
+// finalizeCounts fills in MessageCounts and checks the counts invariant. The
+// summary check only applies when every target reported a summary line; a log
+// without one was cut off or cancelled and is already flagged as such.
+func (c *Compiler) finalizeCounts(result *CompileResult) {
+	result.MessageCounts = parsedCounts(result.Messages)
+
+	if !hasSummary(result) {
+		return
+	}
+
+	var problems []string
+
+	expected := policyAdjusted(suppressionAdjusted(result.Reported, result.SuppressedByPage), result.Reclassified)
+	if got := (Counts{Warnings: result.Warnings, Errors: result.Errors}); got != expected {
+		problems = append(problems, fmt.Sprintf("counted %s but VTPro reported %s (%s after --ignore-pages and the rule policy)",
+			got, result.Reported, expected))
+	}
+
+	if result.MessageCounts.Warnings > result.Warnings || result.MessageCounts.Errors > result.Errors {
+		problems = append(problems, fmt.Sprintf("parsed %s from the Message Log but counted only %s",
+			result.MessageCounts, Counts{Warnings: result.Warnings, Errors: result.Errors}))
+	}
+
+	for _, p := range problems {
+		c.log.Warn("Warning and error counts are inconsistent", slog.String("problem", p))
+		result.CountMismatches = append(result.CountMismatches, p)
+		addWarning(result, countMismatchWarning+": "+p)
+	}
+}
+
+// hasSummary reports whether every target in the Message Log reported a summary line
+func hasSummary(result *CompileResult) bool {
+	if len(result.Sections) == 0 {
+		return false
+	}
+
+	for _, s := range result.Sections {
+		if s.SummaryPattern == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parsedCounts counts the messages parsed from the Message Log by severity
+func parsedCounts(messages []Message) Counts {
+	var counts Counts
+
+	for _, m := range messages {
+		if m.Index < 0 {
+			continue
+		}
+
+		if m.Severity == SeverityError {
+			counts.Errors++
+		} else {
+			counts.Warnings++
+		}
+	}
+
+	return counts
+}
+
+// suppressionAdjusted takes one count away per message PageFilter.Apply removed
+func suppressionAdjusted(reported Counts, suppressed []Message) Counts {
+	counts := reported
+
+	for _, m := range suppressed {
+		if m.Severity == SeverityError {
+			counts.Errors = max(counts.Errors-1, 0)
+		} else {
+			counts.Warnings = max(counts.Warnings-1, 0)
+		}
+	}
+
+	return counts
+}
+
+// policyAdjusted applies the reclassifications to the reported counts, moving
+// or removing one count per message as Policy.Apply does
+func policyAdjusted(reported Counts, changes []Reclassification) Counts {
+	counts := reported
+
+	for _, r := range changes {
+		if r.Message.Severity == SeverityError {
+			counts.Errors = max(counts.Errors-1, 0)
+		} else {
+			counts.Warnings = max(counts.Warnings-1, 0)
+		}
+
+		switch r.Action {
+		case ActionError:
+			counts.Errors++
+		case ActionWarning:
+			counts.Warnings++
+		}
+	}
+
+	return counts
+}
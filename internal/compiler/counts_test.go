@@ -0,0 +1,178 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// countsLog has a repeated message, a message wrapped past a small continuation
+// cap and a summary line that agrees with the messages
+const countsLog = `---------- Compiling for TSW-770: [test.vtp] ---------
+Main
+	[ warning ]: Object "Logo" on Page "Main" references a missing image.
+	[ warning ]: Object "Logo" on Page "Main" references a missing image.
+	[ warning ]: The file path 'C:\Projects\Site\Images\background.png' exceeds the
+	windows path limitations
+	and may
+	not be
+	transferred
+	to the panel.
+	[ error ]: Object "Mute" on Page "Main" has an invalid join number.
+---------- Failed ---------
+3 warning(s), 1 error(s)`
+
+func parseCounts(t *testing.T, opts ParserOptions, text string) *CompileResult {
+	t.Helper()
+
+	c := NewCompiler(logger.NewNoOpLogger(), WithParser(opts))
+	result := &CompileResult{}
+	c.parseVTProOutput(text, result)
+
+	return result
+}
+
+func TestFinalizeCounts_MirrorsSummary(t *testing.T) {
+	t.Parallel()
+
+	result := parseCounts(t, ParserOptions{MaxContinuations: 1}, countsLog)
+
+	assert.Equal(t, Counts{Warnings: 3, Errors: 1}, result.Reported)
+	assert.Equal(t, 3, result.Warnings)
+	assert.Equal(t, 1, result.Errors)
+	assert.Equal(t, Counts{Warnings: 3, Errors: 1}, result.MessageCounts, "repeated messages are each counted")
+	assert.Empty(t, result.CountMismatches)
+}
+
+func TestFinalizeCounts_PolicyMovesCounts(t *testing.T) {
+	t.Parallel()
+
+	policy := mustPolicy(t,
+		PolicyEntry{RuleID: "path-length-warning", Action: ActionError},
+		PolicyEntry{RuleID: "missing-join", Action: ActionIgnore},
+	)
+	result := parseCounts(t, ParserOptions{MaxContinuations: 1, Policy: policy}, countsLog)
+
+	assert.Equal(t, Counts{Warnings: 3, Errors: 1}, result.Reported, "Reported is what VTPro printed")
+	assert.Equal(t, 2, result.Warnings)
+	assert.Equal(t, 1, result.Errors)
+	assert.Equal(t, Counts{Warnings: 2, Errors: 1}, result.MessageCounts)
+	assert.Empty(t, result.CountMismatches)
+}
+
+func TestFinalizeCounts_PresentationLeavesCountsAlone(t *testing.T) {
+	t.Parallel()
+
+	result := parseCounts(t, ParserOptions{MaxContinuations: 1}, countsLog)
+	before := append([]Message(nil), result.Messages...)
+
+	lines := FormatMessageTable(OrderMessages(result.Messages, MessageOrderSeverity), TableOptions{Width: 60})
+
+	assert.Equal(t, "3 warning(s), 1 error(s)", lines[len(lines)-1])
+	assert.Equal(t, before, result.Messages, "ordering and rendering work on copies")
+	assert.Equal(t, 3, result.Warnings)
+	assert.Equal(t, 1, result.Errors)
+}
+
+func TestFinalizeCounts_FewerMessagesThanReported(t *testing.T) {
+	t.Parallel()
+
+	// VTPro may count problems it does not list; the summary still wins
+	result := parseCounts(t, ParserOptions{}, `---------- Compiling for TSW-770: [test.vtp] ---------
+	[ warning ]: Object "Logo" on Page "Main" references a missing image.
+---------- Successful ---------
+5 warning(s), 0 error(s)`)
+
+	assert.Equal(t, 5, result.Warnings)
+	assert.Equal(t, Counts{Warnings: 1}, result.MessageCounts)
+	assert.Empty(t, result.CountMismatches)
+}
+
+func TestFinalizeCounts_FlagsMoreMessagesThanReported(t *testing.T) {
+	t.Parallel()
+
+	result := parseCounts(t, ParserOptions{}, `---------- Compiling for TSW-770: [test.vtp] ---------
+	[ error ]: Object "Mute" on Page "Main" has an invalid join number.
+	[ error ]: Object "Volume" on Page "Main" has an invalid join number.
+---------- Failed ---------
+0 warning(s), 1 error(s)`)
+
+	assert.Equal(t, 1, result.Errors, "the summary line is still reported as-is")
+	require.Len(t, result.CountMismatches, 1)
+	assert.Contains(t, result.CountMismatches[0], "parsed 0 warning(s), 2 error(s)")
+
+	last := result.Messages[len(result.Messages)-1]
+	assert.Equal(t, -1, last.Index, "the mismatch is flagged as a vtpc warning")
+	assert.True(t, strings.HasPrefix(last.Text, countMismatchWarning))
+	assert.Equal(t, 0, result.Warnings, "the vtpc warning is not counted")
+}
+
+func TestFinalizeCounts_FlagsSummaryThatDisagrees(t *testing.T) {
+	t.Parallel()
+
+	// A message VTPro lists but leaves out of its summary line
+	result := parseCounts(t, ParserOptions{}, `---------- Compiling for TSW-770: [test.vtp] ---------
+Boot
+	[ warning ]: Object "Logo" on Page "Main" references a missing image.
+0 warning(s), 0 error(s)`)
+
+	assert.Equal(t, 0, result.Warnings, "the summary line is still reported as-is")
+	assert.Equal(t, Counts{Warnings: 1}, result.MessageCounts)
+	require.Len(t, result.CountMismatches, 1)
+	assert.Equal(t, "parsed 1 warning(s), 0 error(s) from the Message Log but counted only 0 warning(s), 0 error(s)", result.CountMismatches[0])
+	assert.Equal(t, []string{
+		`Object "Logo" on Page "Main" references a missing image.`,
+		countMismatchWarning + ": " + result.CountMismatches[0],
+	}, result.WarningMessages)
+}
+
+func TestFinalizeCounts_SkipsLogWithoutSummary(t *testing.T) {
+	t.Parallel()
+
+	result := parseCounts(t, ParserOptions{}, `---------- Compiling for TSW-770: [test.vtp] ---------
+	[ warning ]: Object "Logo" on Page "Main" references a missing image.`)
+
+	assert.Equal(t, Counts{Warnings: 1}, result.MessageCounts)
+	assert.Empty(t, result.CountMismatches, "a cut-off log is flagged by the truncation check instead")
+}
+
+func TestFinalizeCounts_SumsTargets(t *testing.T) {
+	t.Parallel()
+
+	result := parseCounts(t, ParserOptions{}, `---------- Compiling for TSW-770: [test.vtp] ---------
+	[ warning ]: Object "Logo" on Page "Main" references a missing image.
+---------- Successful ---------
+1 warning(s), 0 error(s)
+---------- Compiling for TSW-1070: [test.vtp] ---------
+	[ warning ]: Object "Logo" on Page "Main" references a missing image.
+	[ error ]: Object "Mute" on Page "Main" has an invalid join number.
+---------- Failed ---------
+1 warning(s), 1 error(s)`)
+
+	assert.Equal(t, Counts{Warnings: 2, Errors: 1}, result.Reported)
+	assert.Equal(t, Counts{Warnings: 2, Errors: 1}, result.MessageCounts)
+	assert.Empty(t, result.CountMismatches)
+}
+
+func TestPolicyAdjusted(t *testing.T) {
+	t.Parallel()
+
+	changes := []Reclassification{
+		{Message: Message{Severity: SeverityWarning}, Action: ActionError},
+		{Message: Message{Severity: SeverityError}, Action: ActionIgnore},
+		{Message: Message{Severity: SeverityError}, Action: ActionWarning},
+	}
+
+	assert.Equal(t, Counts{Warnings: 2, Errors: 1}, policyAdjusted(Counts{Warnings: 2, Errors: 2}, changes))
+	assert.Equal(t, Counts{Warnings: 1}, policyAdjusted(Counts{}, changes), "counts never go below zero")
+}
+
+func TestCounts_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "2 warning(s), 1 error(s)", Counts{Warnings: 2, Errors: 1}.String())
+}
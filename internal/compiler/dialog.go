@@ -0,0 +1,52 @@
+package compiler
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// ErrUnexpectedDialog is returned with --strict-dialogs when VTPro shows a dialog
+// the compile does not know how to handle
+var ErrUnexpectedDialog = errcode.New(errcode.UnexpectedDialog, "unexpected dialog")
+
+// routeDialog returns the compile-phase descriptor for a window, if it is a
+// known dialog. With --strict-dialogs, any other dialog fails the run.
+func (c *Compiler) routeDialog(ev windows.WindowEvent) (dialog.Descriptor, bool) {
+	return c.dialogs.Match(dialog.PhaseCompile, ev.Title, ev.Class)
+}
+
+// unexpectedDialog returns the error for a dialog routeDialog does not know, with the
+// text it showed, or nil if the window is not a standard dialog. Other windows, such
+// as tooltips, are not judged.
+func (c *Compiler) unexpectedDialog(ev windows.WindowEvent) error {
+	if ev.Class != windows.DialogClass {
+		return nil
+	}
+
+	controls := dialog.LogControls(c.log, c.windowMgr, ev.Hwnd, ev.DisplayTitle())
+
+	var text []string
+	for _, ci := range controls {
+		if ci.Text != "" && ci.ClassName != "Button" {
+			text = append(text, ci.Text)
+		}
+	}
+
+	c.log.Error("Unexpected dialog during compile, leaving it open for inspection",
+		slog.String("title", ev.DisplayTitle()),
+		slog.String("class", ev.Class),
+		slog.Uint64("hwnd", uint64(ev.Hwnd)),
+		slog.Any("text", text),
+	)
+
+	if len(text) == 0 {
+		return fmt.Errorf("%w %q", ErrUnexpectedDialog, ev.DisplayTitle())
+	}
+
+	return fmt.Errorf("%w %q: %s", ErrUnexpectedDialog, ev.DisplayTitle(), strings.Join(text, " "))
+}
@@ -0,0 +1,241 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// postCompilationCompiler returns a compiler whose window manager's monitor
+// has seen events, and its window manager
+func postCompilationCompiler(log *testutil.MockLogger, events ...windows.WindowEvent) (*Compiler, *testutil.MockWindowManager) {
+	mockWin := testutil.NewMockWindowManager()
+	for _, ev := range events {
+		mockWin.WaitOnMonitorResults = append(mockWin.WaitOnMonitorResults, testutil.WaitOnMonitorResult{Event: ev, OK: true})
+	}
+
+	c := NewCompiler(log,
+		WithProcessManager(testutil.NewMockProcessManager()),
+		WithWindowManager(mockWin),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(testutil.NewMockControlReader()),
+	)
+
+	return c, mockWin
+}
+
+func TestCompiler_PostCompilationDialogControlsAreLogged(t *testing.T) {
+	log := testutil.NewMockLogger()
+
+	c, mockWin := postCompilationCompiler(log, windows.WindowEvent{Hwnd: 0x4444, Title: dialog.AddressBook.Title})
+	mockWin.
+		WithWindowText(0x4444, "Address Book").
+		WithChildInfosForHwnd(0x4444,
+			windows.ChildInfo{Hwnd: 0x4401, ClassName: "Static", Text: "Save changes to the address book?"},
+			windows.ChildInfo{Hwnd: 0x4402, ClassName: "Button", Text: "OK"},
+		)
+
+	assert.NoError(t, c.handlePostCompilationEvents())
+
+	// The compiler logs the dialog exactly as the shared helper does
+	want := testutil.NewMockLogger()
+	dialog.LogControls(want, mockWin, 0x4444, dialog.AddressBook.Title)
+
+	assert.Equal(t, want.Entries, dialogEntries(log, len(want.Entries)))
+	assert.Equal(t, []testutil.CloseWindowCall{{Hwnd: 0x4444, Title: dialog.AddressBook.Title}}, mockWin.CloseWindowCalls)
+}
+
+func TestCompiler_PostCompilationDialogSeenEarly(t *testing.T) {
+	// The monitor channel is empty: the Address Book appeared while the compile
+	// was still watching, so only the monitor's recent events have it
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	c, mockWin := postCompilationCompiler(testutil.NewMockLogger(),
+		windows.WindowEvent{Hwnd: 0x4444, Title: dialog.AddressBook.Title, Class: windows.DialogClass},
+	)
+
+	assert.NoError(t, c.handlePostCompilationEvents())
+	assert.Equal(t, []testutil.CloseWindowCall{{Hwnd: 0x4444, Title: dialog.AddressBook.Title}}, mockWin.CloseWindowCalls)
+}
+
+func TestCompiler_PostCompilationDialogs(t *testing.T) {
+	t.Parallel()
+
+	c, mockWin := postCompilationCompiler(testutil.NewMockLogger(),
+		windows.WindowEvent{Hwnd: 0x5555, Title: dialog.CompileStatistics.Title, Class: windows.DialogClass},
+		windows.WindowEvent{Hwnd: 0x6666, Title: dialog.CompileStatistics.Title, Class: windows.DialogClass},
+		windows.WindowEvent{Hwnd: 0x1111, Title: dialog.Compiling.Title, Class: windows.DialogClass},
+		windows.WindowEvent{Hwnd: 0x7777, Title: "Trial expired", Class: windows.DialogClass},
+		windows.WindowEvent{Hwnd: 0x4444, Title: dialog.AddressBook.Title, Class: windows.DialogClass},
+		windows.WindowEvent{Hwnd: 0x4444, Title: dialog.AddressBook.Title, Class: windows.DialogClass},
+	)
+	mockWin.WithWindowValid(0x5555, false)
+
+	assert.NoError(t, c.handlePostCompilationEvents())
+
+	// Every open dialog is closed once; the statistics dialog read during the
+	// compile has gone, and tracked and unknown dialogs are left alone
+	assert.Equal(t, []testutil.CloseWindowCall{
+		{Hwnd: 0x6666, Title: dialog.CompileStatistics.Title},
+		{Hwnd: 0x4444, Title: dialog.AddressBook.Title},
+	}, mockWin.CloseWindowCalls)
+}
+
+// dialogEntries returns the n log entries starting where dialog control enumeration begins
+func dialogEntries(log *testutil.MockLogger, n int) []testutil.LogEntry {
+	for i, msg := range log.Messages() {
+		if msg == "Enumerating dialog controls" && i+n <= len(log.Entries) {
+			return log.Entries[i : i+n]
+		}
+	}
+
+	return nil
+}
+
+func TestRouteDialog(t *testing.T) {
+	t.Parallel()
+
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	tests := []struct {
+		title  string
+		known  bool
+		action dialog.Action
+	}{
+		{dialog.Compiling.Title, true, dialog.ActionTrack},
+		{"Progress [58%]", true, dialog.ActionIgnore},
+		{dialog.CompileStatistics.Title, true, dialog.ActionInspect},
+		{dialog.AddressBook.Title, true, dialog.ActionClose},
+		{"Trial expired", false, ""},
+		{"Address Book (2)", false, ""},
+		{dialog.PostLoadWarning.Title, false, ""},
+		{"", false, ""},
+		{"  ", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			t.Parallel()
+
+			route, ok := c.routeDialog(windows.WindowEvent{Title: tt.title, Class: windows.DialogClass})
+			assert.Equal(t, tt.known, ok)
+			assert.Equal(t, tt.action, route.Action)
+		})
+	}
+}
+
+func TestRouteDialog_UsesInjectedRegistry(t *testing.T) {
+	t.Parallel()
+
+	// A localized VTPro titles its dialogs differently
+	compiling := dialog.Compiling
+	compiling.Title = "VisionTools Pro-e Kompilieren..."
+
+	c := NewCompiler(logger.NewNoOpLogger(), WithDialogs(dialog.NewRegistry(compiling)))
+
+	route, ok := c.routeDialog(windows.WindowEvent{Title: "VisionTools Pro-e Kompilieren..."})
+	assert.True(t, ok)
+	assert.Equal(t, "compiling", route.Name)
+
+	_, ok = c.routeDialog(windows.WindowEvent{Title: dialog.Compiling.Title})
+	assert.False(t, ok)
+}
+
+// strictDialogCompiler returns a compiler whose compile finishes cleanly once the
+// Compiling dialog 0x1111 closes, and its window manager
+func strictDialogCompiler() (*Compiler, *testutil.MockWindowManager) {
+	vtproOutput := "---------- Compiling for TSW-770: [test.vtp] ---------\nBoot\nMain\n---------- Successful ---------\n0 warning(s), 0 error(s)"
+
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(0x9999, windows.ChildInfo{ClassName: "ListBox", Text: vtproOutput}).
+		WithChildInfosForHwnd(0x7777,
+			windows.ChildInfo{Hwnd: 0x7701, ClassName: "Static", Text: "Your evaluation period has ended."},
+			windows.ChildInfo{Hwnd: 0x7702, ClassName: "Button", Text: "OK"},
+		).
+		WithWindowValid(0x1111, false)
+
+	c := NewCompiler(logger.NewNoOpLogger(),
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(mockWin),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(testutil.NewMockControlReader()),
+	)
+
+	return c, mockWin
+}
+
+func TestCompiler_StrictDialogs(t *testing.T) {
+	benign := []windows.WindowEvent{
+		{Hwnd: 0x1111, Title: dialog.Compiling.Title, Class: windows.DialogClass},
+		{Hwnd: 0x2222, Title: "Progress [40%]", Class: windows.DialogClass},
+		{Hwnd: 0x3333, Class: "tooltips_class32"},
+	}
+	unknown := windows.WindowEvent{Hwnd: 0x7777, Title: "Trial expired", Class: windows.DialogClass}
+	untitled := windows.WindowEvent{Hwnd: 0x7777, Title: "", Class: windows.DialogClass}
+
+	tests := []struct {
+		name    string
+		strict  bool
+		events  []windows.WindowEvent
+		wantErr bool
+	}{
+		{"benign dialogs, strict", true, benign, false},
+		{"benign dialogs, not strict", false, benign, false},
+		{"unknown dialog, strict", true, append([]windows.WindowEvent{benign[0], unknown}, benign[1:]...), true},
+		{"unknown dialog, not strict", false, append([]windows.WindowEvent{benign[0], unknown}, benign[1:]...), false},
+		{"untitled dialog, not strict", false, append([]windows.WindowEvent{benign[0], untitled}, benign[1:]...), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.SetupMonitorChannel()
+			defer testutil.CleanupMonitorChannel()
+
+			c, mockWin := strictDialogCompiler()
+			testutil.SendEventsToMonitor(tt.events...)
+
+			result, err := c.Compile(CompileOptions{
+				Hwnd:                          0x9999,
+				VTProPid:                      1234,
+				SkipPreCompilationDialogCheck: true,
+				StrictDialogs:                 tt.strict,
+			})
+
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				assert.False(t, result.HasErrors)
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrUnexpectedDialog)
+			assert.Equal(t, `unexpected dialog "Trial expired": Your evaluation period has ended.`, err.Error())
+			assert.True(t, result.HasErrors)
+
+			// The dialog and VTPro are left on screen for inspection
+			assert.Empty(t, mockWin.CloseWindowCalls)
+		})
+	}
+}
+
+func TestCompiler_StrictDialogsNameUntitledDialogsByClass(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	c, mockWin := strictDialogCompiler()
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: dialog.Compiling.Title, Class: windows.DialogClass},
+		windows.WindowEvent{Hwnd: 0x7777, Title: " ", Class: windows.DialogClass},
+	)
+
+	_, err := c.Compile(CompileOptions{Hwnd: 0x9999, VTProPid: 1234, SkipPreCompilationDialogCheck: true, StrictDialogs: true})
+
+	assert.ErrorIs(t, err, ErrUnexpectedDialog)
+	assert.Equal(t, `unexpected dialog "Untitled (#32770)": Your evaluation period has ended.`, err.Error())
+	assert.Empty(t, mockWin.CloseWindowCalls, "an untitled dialog is never closed by a title rule")
+}
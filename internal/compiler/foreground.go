@@ -0,0 +1,56 @@
+package compiler
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// ErrForegroundDenied is returned when Windows would not let vtpc bring VTPro to
+// the foreground, so keystrokes could not be sent
+var ErrForegroundDenied = errcode.New(errcode.Focus, "failed to bring VTPro to foreground - cannot send keystrokes")
+
+// diagnoseForegroundDenied explains a SetForeground that kept failing. On
+// hardened images the usual cause is a long foreground lock timeout, which
+// stops even elevated processes taking the foreground, so the timeout in effect
+// and where it is configured are read and named in the error.
+func (c *Compiler) diagnoseForegroundDenied() error {
+	lock, err := c.foregroundLock()
+	if err != nil {
+		c.log.Debug("Could not read the foreground lock timeout", slog.Any("error", err))
+	} else {
+		c.log.Debug("Foreground lock timeout",
+			slog.Duration("timeout", lock.Timeout),
+			slog.String("source", lock.Source),
+			slog.Bool("policy", lock.Policy),
+		)
+	}
+
+	return foregroundDeniedError(lock, err)
+}
+
+// postMessageHint ends ErrForegroundDenied's text, as posted key messages reach
+// VTPro's window whether or not it has the foreground
+const postMessageHint = "use --input-method postmessage, which sends the compile keystroke without taking the foreground"
+
+// foregroundDeniedError builds the error for a SetForeground that kept failing,
+// given the foreground lock reading and the error reading it, if any
+func foregroundDeniedError(lock windows.ForegroundLock, readErr error) error {
+	if readErr != nil || lock.Timeout == 0 {
+		return fmt.Errorf("%w; %s", ErrForegroundDenied, postMessageHint)
+	}
+
+	source := "the session's default"
+	if lock.Source != "" {
+		source = lock.Source
+		if lock.Policy {
+			source = "policy " + source
+		}
+	}
+
+	return fmt.Errorf("%w: the foreground lock timeout is %s (set by %s), so Windows keeps the foreground "+
+		"for the window the user last used; set ForegroundLockTimeout to 0 for the account vtpc runs as, or %s",
+		ErrForegroundDenied, lock.Timeout, source, postMessageHint)
+}
@@ -0,0 +1,94 @@
+package compiler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestForegroundDeniedError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		lock    windows.ForegroundLock
+		readErr error
+		want    string
+	}{
+		{
+			name: "policy",
+			lock: windows.ForegroundLock{
+				Timeout: 200 * time.Second,
+				Source:  `HKCU\Software\Policies\Microsoft\Windows\Control Panel\Desktop\ForegroundLockTimeout`,
+				Policy:  true,
+			},
+			want: `failed to bring VTPro to foreground - cannot send keystrokes: the foreground lock timeout is 3m20s ` +
+				`(set by policy HKCU\Software\Policies\Microsoft\Windows\Control Panel\Desktop\ForegroundLockTimeout), ` +
+				`so Windows keeps the foreground for the window the user last used; set ForegroundLockTimeout to 0 for the account vtpc runs as, ` +
+				`or use --input-method postmessage, which sends the compile keystroke without taking the foreground`,
+		},
+		{
+			name: "user setting",
+			lock: windows.ForegroundLock{Timeout: 200 * time.Millisecond, Source: `HKCU\Control Panel\Desktop\ForegroundLockTimeout`},
+			want: `failed to bring VTPro to foreground - cannot send keystrokes: the foreground lock timeout is 200ms ` +
+				`(set by HKCU\Control Panel\Desktop\ForegroundLockTimeout), ` +
+				`so Windows keeps the foreground for the window the user last used; set ForegroundLockTimeout to 0 for the account vtpc runs as, ` +
+				`or use --input-method postmessage, which sends the compile keystroke without taking the foreground`,
+		},
+		{
+			name: "not configured",
+			lock: windows.ForegroundLock{Timeout: 200 * time.Millisecond},
+			want: `failed to bring VTPro to foreground - cannot send keystrokes: the foreground lock timeout is 200ms ` +
+				`(set by the session's default), ` +
+				`so Windows keeps the foreground for the window the user last used; set ForegroundLockTimeout to 0 for the account vtpc runs as, ` +
+				`or use --input-method postmessage, which sends the compile keystroke without taking the foreground`,
+		},
+		{
+			name: "no timeout",
+			lock: windows.ForegroundLock{Source: `HKCU\Control Panel\Desktop\ForegroundLockTimeout`},
+			want: "failed to bring VTPro to foreground - cannot send keystrokes; " +
+				"use --input-method postmessage, which sends the compile keystroke without taking the foreground",
+		},
+		{
+			name:    "unreadable",
+			readErr: errors.New("access is denied"),
+			want: "failed to bring VTPro to foreground - cannot send keystrokes; " +
+				"use --input-method postmessage, which sends the compile keystroke without taking the foreground",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := foregroundDeniedError(tt.lock, tt.readErr)
+			require.ErrorIs(t, err, ErrForegroundDenied)
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestFocusWindow_ForegroundDeniedNamesTheLockTimeout(t *testing.T) {
+	t.Parallel()
+
+	mockWin := testutil.NewMockWindowManager()
+	mockWin.SetForegroundResult = false
+
+	lock := windows.ForegroundLock{Timeout: 200 * time.Second, Source: `HKCU\Control Panel\Desktop\ForegroundLockTimeout`}
+	c := NewCompiler(logger.NewNoOpLogger(),
+		WithWindowManager(mockWin),
+		WithForegroundLock(func() (windows.ForegroundLock, error) { return lock, nil }),
+	)
+
+	err := c.focusWindow(vtproTestHwnd, 1234)
+	require.ErrorIs(t, err, ErrForegroundDenied)
+	assert.Contains(t, err.Error(), "foreground lock timeout is 3m20s")
+	assert.Len(t, mockWin.SetForegroundCalls, 2, "SetForeground is retried once before giving up")
+}
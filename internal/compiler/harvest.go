@@ -0,0 +1,49 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// ErrNoMessageLog is returned by Harvest when VTPro's main window has no
+// Message Log with compile output, e.g. because nothing has been compiled yet
+var ErrNoMessageLog = errcode.New(errcode.NoMessageLog, "no compile output found in the Message Log")
+
+// HarvestOptions holds the options for reading a compile already run in VTPro
+type HarvestOptions struct {
+	Hwnd          uintptr       // VTPro's main window
+	MessageOrder  MessageOrder  // How warnings and errors are grouped when logged
+	MessageFormat MessageFormat // List messages as they are found, or leave a table to the caller
+}
+
+// Harvest reads and parses the Message Log of a VTPro that is already open, as
+// Compile does once the Compiling dialog closes. It sends no keystrokes and
+// closes nothing, so it can report on a compile someone ran by hand.
+func (c *Compiler) Harvest(opts HarvestOptions) (result *CompileResult, err error) {
+	startedAt := c.clock.Now()
+	defer func() {
+		if result != nil {
+			result.StartedAt = startedAt
+			result.FinishedAt = c.clock.Now()
+		}
+	}()
+
+	result = &CompileResult{}
+
+	if !c.readResults(opts.Hwnd, opts.MessageOrder, opts.MessageFormat, result) {
+		return newErrorResult(ErrNoMessageLog.Error()), ErrNoMessageLog
+	}
+
+	result.HasErrors = result.HasErrors || result.Errors > 0 || len(result.ErrorMessages) > 0
+
+	if result.Cancelled {
+		return result, ErrCompileCancelled
+	}
+
+	if result.HasErrors {
+		return result, fmt.Errorf("compilation failed with %d error(s)", result.Errors)
+	}
+
+	return result, nil
+}
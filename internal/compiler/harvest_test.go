@@ -0,0 +1,81 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestCompiler_Harvest(t *testing.T) {
+	tests := []struct {
+		name         string
+		log          string
+		wantErr      string
+		wantWarnings int
+		wantErrors   int
+	}{
+		{
+			name:         "successful compile with warnings",
+			log:          "---------- Compiling for TSW-770: [test.vtp] ---------\nBoot\nMain\n---------- Successful ---------\n2 warning(s), 0 error(s)",
+			wantWarnings: 2,
+		},
+		{
+			name:       "failed compile",
+			log:        "---------- Compiling for TSW-770: [test.vtp] ---------\nBoot\nMain\n---------- Failed ---------\n0 warning(s), 3 error(s)",
+			wantErr:    "compilation failed with 3 error(s)",
+			wantErrors: 3,
+		},
+		{
+			name:    "cancelled compile",
+			log:     "---------- Compiling for TSW-770: [test.vtp] ---------\nBoot\nMain",
+			wantErr: ErrCompileCancelled.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockWin := testutil.NewMockWindowManager().
+				WithChildInfosForHwnd(0x9999, windows.ChildInfo{ClassName: "ListBox", Text: tt.log})
+			mockKbd := testutil.NewMockKeyboardInjector()
+
+			compiler := NewCompiler(logger.NewNoOpLogger(),
+				WithWindowManager(mockWin),
+				WithKeyboard(mockKbd),
+				WithControlReader(testutil.NewMockControlReader()),
+			)
+
+			result, err := compiler.Harvest(HarvestOptions{Hwnd: 0x9999})
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantWarnings, result.Warnings)
+			assert.Equal(t, tt.wantErrors, result.Errors)
+			assert.Equal(t, tt.wantErrors > 0, result.HasErrors)
+
+			// Harvesting only reads: VTPro is left exactly as it was
+			assert.Empty(t, mockKbd.Keystrokes)
+			assert.Empty(t, mockWin.CloseWindowCalls)
+			assert.Empty(t, mockWin.SetForegroundCalls)
+		})
+	}
+}
+
+func TestCompiler_HarvestWithoutMessageLog(t *testing.T) {
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(0x9999, windows.ChildInfo{ClassName: "ListBox", Text: "Ready"})
+
+	compiler := NewCompiler(logger.NewNoOpLogger(), WithWindowManager(mockWin))
+
+	result, err := compiler.Harvest(HarvestOptions{Hwnd: 0x9999})
+	assert.ErrorIs(t, err, ErrNoMessageLog)
+	assert.True(t, result.HasErrors)
+	assert.Empty(t, mockWin.CloseWindowCalls)
+}
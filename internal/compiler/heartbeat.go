@@ -0,0 +1,93 @@
+package compiler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+)
+
+// DefaultHeartbeatInterval is how often "still compiling" is logged when no interval is given
+const DefaultHeartbeatInterval = 30 * time.Second
+
+// progressPercentRe matches the percentage in a "Progress [xx%]" dialog title
+var progressPercentRe = regexp.MustCompile(`\[\s*(\d{1,3})\s*%\s*\]`)
+
+// heartbeat logs periodic "still compiling" messages so CI systems with
+// output-inactivity timeouts do not kill a long compile.
+// A nil heartbeat is disabled and never ticks.
+type heartbeat struct {
+	clk      clock.Clock
+	ticker   clock.Ticker
+	start    time.Time
+	progress int // Latest progress percentage, -1 if unknown
+}
+
+// startHeartbeat starts a heartbeat that ticks every interval, or returns nil if interval is not positive
+func startHeartbeat(clk clock.Clock, interval time.Duration) *heartbeat {
+	if interval <= 0 {
+		return nil
+	}
+
+	return &heartbeat{
+		clk:      clk,
+		ticker:   clk.NewTicker(interval),
+		start:    clk.Now(),
+		progress: -1,
+	}
+}
+
+// C returns the tick channel, or nil for a disabled heartbeat so a select never picks it
+func (h *heartbeat) C() <-chan time.Time {
+	if h == nil {
+		return nil
+	}
+
+	return h.ticker.C()
+}
+
+// Stop stops the heartbeat's ticker
+func (h *heartbeat) Stop() {
+	if h != nil {
+		h.ticker.Stop()
+	}
+}
+
+// Observe records the progress percentage from a window title, if it has one
+func (h *heartbeat) Observe(title string) {
+	if h == nil {
+		return
+	}
+
+	if percent, ok := parseProgressPercent(title); ok {
+		h.progress = percent
+	}
+}
+
+// Message returns the "still compiling" line for the current time
+func (h *heartbeat) Message() string {
+	elapsed := h.clk.Now().Sub(h.start).Round(time.Second)
+
+	if h.progress >= 0 {
+		return fmt.Sprintf("still compiling... (%s elapsed, progress %d%%)", elapsed, h.progress)
+	}
+
+	return fmt.Sprintf("still compiling... (%s elapsed)", elapsed)
+}
+
+// parseProgressPercent extracts the percentage from a title like "Progress [58%]"
+func parseProgressPercent(title string) (int, bool) {
+	m := progressPercentRe.FindStringSubmatch(title)
+	if m == nil {
+		return 0, false
+	}
+
+	percent, err := strconv.Atoi(m[1])
+	if err != nil || percent > 100 {
+		return 0, false
+	}
+
+	return percent, true
+}
@@ -0,0 +1,182 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+var heartbeatEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestHeartbeat_TicksOnInterval(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(heartbeatEpoch)
+	beat := startHeartbeat(clk, 30*time.Second)
+	require.NotNil(t, beat)
+	defer beat.Stop()
+
+	clk.Advance(29 * time.Second)
+	assert.Empty(t, beat.C(), "no heartbeat before the interval")
+
+	clk.Advance(time.Second)
+	select {
+	case <-beat.C():
+	default:
+		t.Fatal("expected a heartbeat after one interval")
+	}
+
+	assert.Equal(t, "still compiling... (30s elapsed)", beat.Message())
+}
+
+func TestHeartbeat_MessageIncludesLatestProgress(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(heartbeatEpoch)
+	beat := startHeartbeat(clk, 30*time.Second)
+	defer beat.Stop()
+
+	beat.Observe("Progress [42%]")
+	beat.Observe("VisionTools Pro-e Compiling...")
+	beat.Observe("Progress [58%]")
+	clk.Advance(2*time.Minute + 10*time.Second)
+
+	assert.Equal(t, "still compiling... (2m10s elapsed, progress 58%)", beat.Message())
+}
+
+func TestHeartbeat_DisabledNeverTicks(t *testing.T) {
+	t.Parallel()
+
+	beat := startHeartbeat(clock.NewFake(heartbeatEpoch), 0)
+	assert.Nil(t, beat)
+	assert.Nil(t, beat.C(), "a nil channel is never selected")
+
+	// A disabled heartbeat is safe to use
+	beat.Observe("Progress [10%]")
+	beat.Stop()
+}
+
+func TestParseProgressPercent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		title   string
+		percent int
+		ok      bool
+	}{
+		{"Progress [58%]", 58, true},
+		{"Progress [ 7 % ]", 7, true},
+		{"Progress [100%]", 100, true},
+		{"Progress [250%]", 0, false},
+		{"VisionTools Pro-e Compiling...", 0, false},
+	}
+
+	for _, tt := range tests {
+		percent, ok := parseProgressPercent(tt.title)
+		assert.Equal(t, tt.ok, ok, tt.title)
+		assert.Equal(t, tt.percent, percent, tt.title)
+	}
+}
+
+func TestCompiler_LogsHeartbeatWhileCompiling(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	// The Compiling dialog never closes, so the compile runs until the timeout
+	mockWin := testutil.NewMockWindowManager()
+	log := &testutil.MockLogger{}
+	clk := clock.NewFake(heartbeatEpoch)
+
+	comp := NewCompiler(log,
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(mockWin),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(testutil.NewMockControlReader()),
+		WithClock(clk),
+		// The clock jumps far further between polls than any wait, which would look like sleep
+		WithSleepPolicy(clock.SleepIgnore),
+	)
+
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: "VisionTools Pro-e Compiling..."},
+		windows.WindowEvent{Hwnd: 0x2222, Title: "Progress [58%]"},
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := comp.Compile(CompileOptions{
+			Hwnd:                          0x9999,
+			VTProPid:                      1234,
+			SkipPreCompilationDialogCheck: true,
+			CompilationTimeout:            time.Second,
+			Heartbeat:                     30 * time.Second,
+		})
+		done <- err
+	}()
+
+	// Keep advancing the clock until the compile loop has seen the progress and logged a heartbeat
+	assert.Eventually(t, func() bool {
+		clk.Advance(30 * time.Second)
+		return hasMessage(log, "progress 58%")
+	}, 2*time.Second, 10*time.Millisecond)
+
+	err := <-done
+	assert.ErrorIs(t, err, ErrCompileTimeout)
+
+	// The heartbeat stops with the compile loop
+	count := countMessages(log, "still compiling")
+	clk.Advance(5 * time.Minute)
+	assert.Equal(t, count, countMessages(log, "still compiling"))
+}
+
+func TestCompiler_NoHeartbeatWhenIntervalZero(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	log := &testutil.MockLogger{}
+	clk := clock.NewFake(heartbeatEpoch)
+
+	comp := NewCompiler(log,
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(testutil.NewMockWindowManager().WithWindowValid(0x1111, false)),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(testutil.NewMockControlReader()),
+		WithClock(clk),
+	)
+
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: "VisionTools Pro-e Compiling..."},
+	)
+
+	_, err := comp.Compile(CompileOptions{
+		Hwnd:                          0x9999,
+		VTProPid:                      1234,
+		SkipPreCompilationDialogCheck: true,
+	})
+	assert.NoError(t, err)
+	assert.Zero(t, countMessages(log, "still compiling"))
+}
+
+// countMessages counts the logged messages containing substr
+func countMessages(log *testutil.MockLogger, substr string) int {
+	n := 0
+	for _, msg := range log.Messages() {
+		if strings.Contains(msg, substr) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// hasMessage reports whether any logged message contains substr
+func hasMessage(log *testutil.MockLogger, substr string) bool {
+	return countMessages(log, substr) > 0
+}
@@ -0,0 +1,164 @@
+package compiler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// InputMethod is how the compile keystroke is sent to VTPro
+type InputMethod string
+
+const (
+	InputAuto        InputMethod = "auto"        // SendInput, falling back to keybd_event if it fails
+	InputSendInput   InputMethod = "sendinput"   // SendInput only
+	InputKeybdEvent  InputMethod = "keybd_event" // keybd_event only
+	InputPostMessage InputMethod = "postmessage" // Key messages sent straight to the VTPro window
+)
+
+// ParseInputMethod converts an --input-method value to an InputMethod. An
+// empty string is InputAuto.
+func ParseInputMethod(s string) (InputMethod, error) {
+	switch m := InputMethod(s); m {
+	case "":
+		return InputAuto, nil
+	case InputAuto, InputSendInput, InputKeybdEvent, InputPostMessage:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid input method %q: must be \"auto\", \"sendinput\", \"keybd_event\" or \"postmessage\"", s)
+	}
+}
+
+// chain returns the methods tried in turn until one reports success
+func (m InputMethod) chain() []InputMethod {
+	switch m {
+	case "", InputAuto:
+		return []InputMethod{InputSendInput, InputKeybdEvent}
+	default:
+		return []InputMethod{m}
+	}
+}
+
+// KeystrokeReport records how the compile keystroke was sent and whether VTPro acted on it
+type KeystrokeReport struct {
+	Method       InputMethod   // Method whose call succeeded, "" if none did
+	Failed       []InputMethod // Methods whose call failed before Method, in the order tried
+	Acknowledged bool          // The Compiling dialog appeared, so the keystroke reached VTPro
+	Watched      bool          // The compile was watched for the Compiling dialog, so Acknowledged is known
+}
+
+// Tried reports whether any method was tried
+func (k KeystrokeReport) Tried() bool {
+	return k.Method != "" || len(k.Failed) > 0
+}
+
+// First returns the method tried first, "" if none was
+func (k KeystrokeReport) First() InputMethod {
+	if len(k.Failed) > 0 {
+		return k.Failed[0]
+	}
+
+	return k.Method
+}
+
+// attachKeystroke records on result how the keystroke was sent, keeping what
+// the compile loop saw of its acknowledgement
+func attachKeystroke(result *CompileResult, sent KeystrokeReport) {
+	sent.Acknowledged = result.Keystroke.Acknowledged
+	sent.Watched = result.Keystroke.Watched
+	result.Keystroke = sent
+}
+
+const (
+	// keystrokeHistorySize is how many runs the keystroke history keeps
+	keystrokeHistorySize = 20
+
+	// PersistentFailureRuns is how many runs in a row the first input method
+	// must fail on a machine before it is reported as persistently failing
+	PersistentFailureRuns = 3
+)
+
+// KeystrokeRun is one run's compile keystroke in the keystroke history
+type KeystrokeRun struct {
+	At           time.Time   `json:"at"`
+	First        InputMethod `json:"first"`  // Method tried first
+	Method       InputMethod `json:"method"` // Method that sent it, "" if none did
+	Acknowledged bool        `json:"acknowledged"`
+}
+
+// worked reports whether the run's first method sent a keystroke VTPro acted on
+func (r KeystrokeRun) worked() bool {
+	return r.Method == r.First && r.Acknowledged
+}
+
+// KeystrokeHistory is the compile keystroke of the most recent runs on this
+// machine, oldest first, for noticing an input method that never works here
+type KeystrokeHistory struct {
+	Runs []KeystrokeRun `json:"runs"`
+}
+
+// LoadKeystrokeHistory reads the history at path. A missing file is an empty history.
+func LoadKeystrokeHistory(path string) (*KeystrokeHistory, error) {
+	h := &KeystrokeHistory{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystroke history %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("failed to parse keystroke history %s: %w", path, err)
+	}
+
+	return h, nil
+}
+
+// Save writes the history to path
+func (h *KeystrokeHistory) Save(path string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Record adds a run's keystroke, dropping the oldest runs past the history's size.
+// A run that never tried to send the keystroke, or did not watch for VTPro
+// acting on it, says nothing about whether its method works and is not recorded.
+func (h *KeystrokeHistory) Record(at time.Time, k KeystrokeReport) {
+	if !k.Tried() || !k.Watched {
+		return
+	}
+
+	h.Runs = append(h.Runs, KeystrokeRun{At: at, First: k.First(), Method: k.Method, Acknowledged: k.Acknowledged})
+
+	if len(h.Runs) > keystrokeHistorySize {
+		h.Runs = h.Runs[len(h.Runs)-keystrokeHistorySize:]
+	}
+}
+
+// PersistentFailure returns the input method that was tried first and did not
+// work in each of the last PersistentFailureRuns runs, if there is one
+func (h *KeystrokeHistory) PersistentFailure() (InputMethod, bool) {
+	if len(h.Runs) < PersistentFailureRuns {
+		return "", false
+	}
+
+	recent := h.Runs[len(h.Runs)-PersistentFailureRuns:]
+	first := recent[0].First
+
+	for _, r := range recent {
+		if r.First != first || r.worked() {
+			return "", false
+		}
+	}
+
+	return first, true
+}
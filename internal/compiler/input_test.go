@@ -0,0 +1,273 @@
+package compiler
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+)
+
+func TestParseInputMethod(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]InputMethod{
+		"":            InputAuto,
+		"auto":        InputAuto,
+		"sendinput":   InputSendInput,
+		"keybd_event": InputKeybdEvent,
+		"postmessage": InputPostMessage,
+	} {
+		got, err := ParseInputMethod(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := ParseInputMethod("menu")
+	assert.ErrorContains(t, err, `invalid input method "menu"`)
+}
+
+func TestCompileKeystroke_FallbackChain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		method        InputMethod
+		sendInputOK   bool
+		postMessageOK bool
+		wantSent      bool
+		wantReport    KeystrokeReport
+		wantKeys      []string
+	}{
+		{
+			name:        "auto, SendInput works",
+			method:      InputAuto,
+			sendInputOK: true,
+			wantSent:    true,
+			wantReport:  KeystrokeReport{Method: InputSendInput},
+			wantKeys:    []string{"F12"},
+		},
+		{
+			name:       "auto, SendInput fails so keybd_event is used",
+			method:     InputAuto,
+			wantSent:   true,
+			wantReport: KeystrokeReport{Method: InputKeybdEvent, Failed: []InputMethod{InputSendInput}},
+			wantKeys:   []string{"F12", "F12"},
+		},
+		{
+			name:       "pinned SendInput does not fall back",
+			method:     InputSendInput,
+			wantSent:   false,
+			wantReport: KeystrokeReport{Failed: []InputMethod{InputSendInput}},
+			wantKeys:   []string{"F12"},
+		},
+		{
+			name:        "pinned keybd_event skips SendInput",
+			method:      InputKeybdEvent,
+			sendInputOK: true,
+			wantSent:    true,
+			wantReport:  KeystrokeReport{Method: InputKeybdEvent},
+			wantKeys:    []string{"F12"},
+		},
+		{
+			name:          "pinned postmessage",
+			method:        InputPostMessage,
+			postMessageOK: true,
+			wantSent:      true,
+			wantReport:    KeystrokeReport{Method: InputPostMessage},
+			wantKeys:      []string{"F12"},
+		},
+		{
+			name:       "pinned postmessage fails",
+			method:     InputPostMessage,
+			wantSent:   false,
+			wantReport: KeystrokeReport{Failed: []InputMethod{InputPostMessage}},
+			wantKeys:   []string{"F12"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, mockKbd := newKeystrokeCompiler(testutil.NewMockWindowManager())
+			mockKbd.SendInputResult = tt.sendInputOK
+			mockKbd.SendToWindowResult = tt.postMessageOK
+
+			var sent KeystrokeReport
+			assert.Equal(t, tt.wantSent, c.compileKeystroke(tt.method, vtproHwnd, &sent)())
+			assert.Equal(t, tt.wantReport, sent)
+			assert.Equal(t, tt.wantKeys, mockKbd.Keystrokes)
+			assert.Equal(t, tt.method == InputPostMessage, mockKbd.SendF12ToWindowCalled)
+		})
+	}
+}
+
+func TestCompileKeystroke_RetryStartsRecordAgain(t *testing.T) {
+	t.Parallel()
+
+	c, mockKbd := newKeystrokeCompiler(testutil.NewMockWindowManager())
+	mockKbd.SendInputResult = false
+
+	var sent KeystrokeReport
+	send := c.compileKeystroke(InputAuto, vtproHwnd, &sent)
+	require.True(t, send())
+
+	mockKbd.SendInputResult = true
+	require.True(t, send())
+
+	assert.Equal(t, KeystrokeReport{Method: InputSendInput}, sent, "only the attempt that was kept is reported")
+}
+
+func TestCompiler_KeystrokeFailureIsReported(t *testing.T) {
+	mockWin := testutil.NewMockWindowManager().WithForegroundSequence(vtproHwnd)
+	c, mockKbd := newKeystrokeCompiler(mockWin)
+	mockKbd.SendToWindowResult = false
+
+	result, err := c.Compile(CompileOptions{
+		Hwnd:                          vtproHwnd,
+		VTProPid:                      vtproPid,
+		SkipPreCompilationDialogCheck: true,
+		InputMethod:                   InputPostMessage,
+	})
+
+	require.ErrorContains(t, err, "failed to send F12")
+	assert.Equal(t, KeystrokeReport{Failed: []InputMethod{InputPostMessage}}, result.Keystroke)
+}
+
+func TestCompiler_PostMessageDoesNotNeedForeground(t *testing.T) {
+	mockWin := testutil.NewMockWindowManager()
+	mockWin.SetForegroundResult = false
+	c, mockKbd := newKeystrokeCompiler(mockWin)
+	mockKbd.SendToWindowResult = false
+
+	_, err := c.Compile(CompileOptions{
+		Hwnd:                          vtproHwnd,
+		VTProPid:                      vtproPid,
+		SkipPreCompilationDialogCheck: true,
+		InputMethod:                   InputPostMessage,
+	})
+
+	require.ErrorContains(t, err, "failed to send F12")
+	assert.NotErrorIs(t, err, ErrForegroundDenied)
+	assert.Empty(t, mockWin.SetForegroundCalls, "VTPro is not brought to the foreground")
+}
+
+// keystrokeRun is a watched run of the compile keystroke
+func keystrokeRun(method InputMethod, acknowledged bool, failed ...InputMethod) KeystrokeReport {
+	return KeystrokeReport{Method: method, Failed: failed, Acknowledged: acknowledged, Watched: true}
+}
+
+func TestKeystrokeHistory_PersistentFailure(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		runs   []KeystrokeReport
+		want   InputMethod
+		wantOK bool
+	}{
+		{
+			name: "too few runs",
+			runs: []KeystrokeReport{keystrokeRun(InputKeybdEvent, true, InputSendInput), keystrokeRun(InputKeybdEvent, true, InputSendInput)},
+		},
+		{
+			name: "SendInput call failed every time",
+			runs: []KeystrokeReport{
+				keystrokeRun(InputSendInput, true),
+				keystrokeRun(InputKeybdEvent, true, InputSendInput),
+				keystrokeRun(InputKeybdEvent, true, InputSendInput),
+				keystrokeRun(InputKeybdEvent, true, InputSendInput),
+			},
+			want:   InputSendInput,
+			wantOK: true,
+		},
+		{
+			name: "SendInput succeeded but VTPro never compiled",
+			runs: []KeystrokeReport{
+				keystrokeRun(InputSendInput, false),
+				keystrokeRun(InputSendInput, false),
+				keystrokeRun(InputSendInput, false),
+			},
+			want:   InputSendInput,
+			wantOK: true,
+		},
+		{
+			name: "worked in the last run",
+			runs: []KeystrokeReport{
+				keystrokeRun(InputKeybdEvent, true, InputSendInput),
+				keystrokeRun(InputKeybdEvent, true, InputSendInput),
+				keystrokeRun(InputSendInput, true),
+			},
+		},
+		{
+			name: "different methods pinned",
+			runs: []KeystrokeReport{
+				keystrokeRun(InputSendInput, false),
+				keystrokeRun(InputPostMessage, false),
+				keystrokeRun(InputSendInput, false),
+			},
+		},
+		{
+			name: "unwatched and untried runs are not recorded",
+			runs: []KeystrokeReport{
+				keystrokeRun(InputSendInput, false),
+				keystrokeRun(InputSendInput, false),
+				{Method: InputSendInput},
+				{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := &KeystrokeHistory{}
+			for i, r := range tt.runs {
+				h.Record(at.Add(time.Duration(i)*time.Hour), r)
+			}
+
+			got, ok := h.PersistentFailure()
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestKeystrokeHistory_KeepsRecentRuns(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	h := &KeystrokeHistory{}
+
+	for i := range keystrokeHistorySize + 5 {
+		h.Record(at.Add(time.Duration(i)*time.Hour), keystrokeRun(InputSendInput, true))
+	}
+
+	require.Len(t, h.Runs, keystrokeHistorySize)
+	assert.Equal(t, at.Add(5*time.Hour), h.Runs[0].At, "the oldest runs are dropped")
+}
+
+func TestKeystrokeHistory_SaveAndLoad(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "keystrokes.json")
+
+	empty, err := LoadKeystrokeHistory(path)
+	require.NoError(t, err, "a missing history is empty")
+	assert.Empty(t, empty.Runs)
+
+	h := &KeystrokeHistory{}
+	h.Record(time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC), keystrokeRun(InputKeybdEvent, true, InputSendInput))
+	require.NoError(t, h.Save(path))
+
+	loaded, err := LoadKeystrokeHistory(path)
+	require.NoError(t, err)
+	assert.Equal(t, h.Runs, loaded.Runs)
+}
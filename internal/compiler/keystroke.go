@@ -0,0 +1,174 @@
+package compiler
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// maxKeystrokeAttempts bounds how many times the focus and keystroke sequence is
+// tried when focus moves to another window as the keystroke is sent
+const maxKeystrokeAttempts = 3
+
+// focusWindow brings VTPro to the foreground and verifies it is there before keystrokes are sent
+func (c *Compiler) focusWindow(hwnd uintptr, pid uint32) error {
+	c.log.Debug("Bringing window to foreground")
+	if !c.windowMgr.SetForeground(hwnd) {
+		c.log.Warn("SetForeground failed on first attempt, retrying...")
+		time.Sleep(500 * time.Millisecond)
+
+		if !c.windowMgr.SetForeground(hwnd) {
+			c.log.Error("Failed to bring window to foreground after retry")
+			return c.diagnoseForegroundDenied()
+		}
+	}
+
+	c.keepOnScreen(hwnd)
+
+	time.Sleep(c.input.FocusVerification)
+
+	// Verify the window is in the foreground before sending keystrokes
+	c.log.Debug("Verifying foreground window")
+	if !c.windowMgr.VerifyForegroundWindow(hwnd, pid) {
+		c.log.Error("Could not verify correct window is in foreground")
+		return fmt.Errorf("wrong window in foreground - cannot safely send keystrokes")
+	}
+
+	return nil
+}
+
+// keepOnScreen moves VTPro onto the primary monitor if it lies entirely off the
+// screen, as it does when it last ran on a monitor that has since been unplugged.
+// SetForeground succeeds on such a window, but some interactions with it do not.
+func (c *Compiler) keepOnScreen(hwnd uintptr) {
+	rect, ok := c.windowMgr.GetWindowRect(hwnd)
+	if !ok {
+		return
+	}
+
+	virtual, primary := c.windowMgr.GetScreenBounds()
+	if !windows.OffScreen(rect, virtual) {
+		return
+	}
+
+	x, y := windows.OnScreenPosition(rect, primary)
+	if !c.windowMgr.MoveWindow(hwnd, x, y) {
+		c.log.Warn("VTPro is off-screen and could not be moved onto the primary monitor",
+			slog.String("window", rect.String()),
+			slog.String("screen", virtual.String()),
+		)
+		return
+	}
+
+	c.log.Warn("VTPro was off-screen, moved it onto the primary monitor",
+		slog.String("from", rect.String()),
+		slog.String("screen", virtual.String()),
+		slog.Int("x", int(x)),
+		slog.Int("y", int(y)),
+	)
+}
+
+// sendKeystroke focuses VTPro, sends a keystroke, and checks VTPro still had focus
+// straight afterwards. If another application took focus, the keystroke went to
+// it, so the whole sequence is retried. A VTPro window in the foreground is not
+// a misdirected keystroke: it is usually the dialog the keystroke opened, such
+// as Compiling after F12 or Save As after Ctrl+S, and must be left alone. Each
+// misdirected keystroke is added to misdirected.
+func (c *Compiler) sendKeystroke(hwnd uintptr, pid uint32, name string, send func() bool, misdirected *int) error {
+	for attempt := 1; attempt <= maxKeystrokeAttempts; attempt++ {
+		if err := c.focusWindow(hwnd, pid); err != nil {
+			return err
+		}
+
+		if !send() {
+			return fmt.Errorf("failed to send %s to VTPro", name)
+		}
+
+		fg := c.windowMgr.GetForegroundWindow()
+		if fg == hwnd {
+			return nil
+		}
+
+		fgPid := c.windowMgr.GetWindowPid(fg)
+		if fg != 0 && pid != 0 && fgPid == pid {
+			c.log.Debug("VTPro window in the foreground after keystroke",
+				slog.String("key", name),
+				slog.Uint64("hwnd", uint64(fg)),
+				slog.String("title", c.windowMgr.GetWindowText(fg)),
+			)
+
+			return nil
+		}
+
+		*misdirected++
+
+		c.log.Warn("Keystroke landed in another window",
+			slog.String("key", name),
+			slog.Uint64("expected_hwnd", uint64(hwnd)),
+			slog.Uint64("actual_hwnd", uint64(fg)),
+			slog.Uint64("actual_pid", uint64(fgPid)),
+			slog.String("actual_title", c.windowMgr.GetWindowText(fg)),
+			slog.Int("attempt", attempt),
+		)
+	}
+
+	return fmt.Errorf("%s did not reach VTPro after %d attempts - focus kept moving to another window", name, maxKeystrokeAttempts)
+}
+
+// compileKeystroke returns the send function for sendKeystroke that sends F12
+// with each of m's methods in turn until one reports success, recording what
+// was tried in sent. A retry after a misdirected keystroke starts the record again.
+func (c *Compiler) compileKeystroke(m InputMethod, hwnd uintptr, sent *KeystrokeReport) func() bool {
+	return func() bool {
+		*sent = KeystrokeReport{}
+		chain := m.chain()
+
+		for i, method := range chain {
+			if c.sendF12With(method, hwnd) {
+				c.log.Debug("Compile keystroke sent", slog.String("method", string(method)))
+				sent.Method = method
+
+				return true
+			}
+
+			sent.Failed = append(sent.Failed, method)
+
+			if i+1 < len(chain) {
+				c.log.Warn("Compile keystroke failed, falling back",
+					slog.String("method", string(method)),
+					slog.String("next", string(chain[i+1])),
+				)
+			} else {
+				c.log.Warn("Compile keystroke failed", slog.String("method", string(method)))
+			}
+		}
+
+		return false
+	}
+}
+
+// sendF12With sends F12 to VTPro with one input method, reporting whether the call succeeded
+func (c *Compiler) sendF12With(method InputMethod, hwnd uintptr) bool {
+	switch method {
+	case InputSendInput:
+		return c.keyboard.SendF12WithSendInput()
+	case InputKeybdEvent:
+		// keybd_event returns nothing, so it cannot report a failure
+		c.keyboard.SendF12()
+		return true
+	case InputPostMessage:
+		return c.keyboard.SendF12ToWindow(hwnd)
+	default:
+		return false
+	}
+}
+
+// newKeystrokeErrorResult builds the result for a run that stopped before or while sending a keystroke
+func newKeystrokeErrorResult(err error, misdirected int) *CompileResult {
+	result := newErrorResult(err.Error())
+	result.MisdirectedKeystrokes = misdirected
+
+	return result
+}
@@ -0,0 +1,244 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+const (
+	vtproHwnd  = 0x9999
+	vtproPid   = 1234
+	dialogHwnd = 0x7777 // A VTPro dialog that takes focus
+	otherHwnd  = 0x8888 // A window from another application
+)
+
+// newKeystrokeCompiler builds a compiler whose keystrokes are recorded by the returned mocks
+func newKeystrokeCompiler(mockWin *testutil.MockWindowManager) (*Compiler, *testutil.MockKeyboardInjector) {
+	mockKbd := testutil.NewMockKeyboardInjector()
+
+	return NewCompiler(logger.NewNoOpLogger(),
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(vtproPid)),
+		WithWindowManager(mockWin),
+		WithKeyboard(mockKbd),
+		WithControlReader(testutil.NewMockControlReader()),
+	), mockKbd
+}
+
+func TestSendKeystroke_ReachesVTPro(t *testing.T) {
+	t.Parallel()
+
+	mockWin := testutil.NewMockWindowManager().WithForegroundSequence(vtproHwnd)
+	c, mockKbd := newKeystrokeCompiler(mockWin)
+
+	misdirected := 0
+	err := c.sendKeystroke(vtproHwnd, vtproPid, "F12", c.compileKeystroke(InputAuto, vtproHwnd, &KeystrokeReport{}), &misdirected)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, misdirected)
+	assert.Equal(t, []string{"F12"}, mockKbd.Keystrokes)
+	assert.Len(t, mockWin.SetForegroundCalls, 1)
+}
+
+func TestFocusWindow_MovesOffScreenWindowOntoPrimaryMonitor(t *testing.T) {
+	t.Parallel()
+
+	// VTPro last ran on a monitor left of the laptop screen, which is now unplugged
+	primary := windows.RECT{Right: 1920, Bottom: 1080}
+	mockWin := testutil.NewMockWindowManager().
+		WithScreen(primary, primary).
+		WithWindowRect(vtproHwnd, windows.RECT{Left: -2000, Top: 100, Right: -1200, Bottom: 700})
+	log := testutil.NewMockLogger()
+	c := NewCompiler(log, WithWindowManager(mockWin))
+
+	require.NoError(t, c.focusWindow(vtproHwnd, vtproPid))
+
+	assert.Equal(t, []testutil.MoveWindowCall{{Hwnd: vtproHwnd, X: 560, Y: 240}}, mockWin.MoveWindowCalls)
+	assert.Equal(t, []string{"SetForeground", "MoveWindow"}, mockWin.WindowOps, "moved once it is in the foreground")
+	assert.True(t, hasMessage(log, "VTPro was off-screen, moved it onto the primary monitor"))
+}
+
+func TestFocusWindow_LeavesOnScreenWindowAlone(t *testing.T) {
+	t.Parallel()
+
+	// On the secondary monitor, which is still connected
+	virtual := windows.RECT{Left: -2560, Right: 1920, Bottom: 1440}
+	mockWin := testutil.NewMockWindowManager().
+		WithScreen(virtual, windows.RECT{Right: 1920, Bottom: 1080}).
+		WithWindowRect(vtproHwnd, windows.RECT{Left: -2000, Top: 100, Right: -1200, Bottom: 700})
+	c, _ := newKeystrokeCompiler(mockWin)
+
+	require.NoError(t, c.focusWindow(vtproHwnd, vtproPid))
+	assert.Empty(t, mockWin.MoveWindowCalls)
+}
+
+func TestSendKeystroke_VTProDialogIsNotMisdirection(t *testing.T) {
+	t.Parallel()
+
+	// F12 opens VTPro's Compiling dialog, which is in the foreground straight away
+	mockWin := testutil.NewMockWindowManager().
+		WithForegroundSequence(dialogHwnd, vtproHwnd).
+		WithWindowPid(dialogHwnd, vtproPid)
+	c, mockKbd := newKeystrokeCompiler(mockWin)
+
+	misdirected := 0
+	err := c.sendKeystroke(vtproHwnd, vtproPid, "F12", c.compileKeystroke(InputAuto, vtproHwnd, &KeystrokeReport{}), &misdirected)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, misdirected)
+	assert.Empty(t, mockKbd.SendEscCalls, "the dialog the keystroke opened must not be dismissed")
+	assert.Equal(t, []string{"F12"}, mockKbd.Keystrokes, "the keystroke is not sent again")
+	assert.Len(t, mockWin.SetForegroundCalls, 1)
+}
+
+func TestSendKeystroke_RetriesWhenAnotherApplicationStealsFocus(t *testing.T) {
+	t.Parallel()
+
+	mockWin := testutil.NewMockWindowManager().
+		WithForegroundSequence(otherHwnd, vtproHwnd).
+		WithWindowPid(otherHwnd, 5678)
+	c, mockKbd := newKeystrokeCompiler(mockWin)
+
+	misdirected := 0
+	err := c.sendKeystroke(vtproHwnd, vtproPid, "F12", c.compileKeystroke(InputAuto, vtproHwnd, &KeystrokeReport{}), &misdirected)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, misdirected)
+	assert.Equal(t, []string{"F12", "F12"}, mockKbd.Keystrokes)
+	assert.Empty(t, mockKbd.SendEscCalls, "another application is never sent Escape")
+	assert.Len(t, mockWin.SetForegroundCalls, 2, "focus is re-established before retrying")
+}
+
+func TestSendKeystroke_GivesUpAfterMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	mockWin := testutil.NewMockWindowManager().
+		WithForegroundSequence(otherHwnd, otherHwnd, otherHwnd, otherHwnd)
+	c, mockKbd := newKeystrokeCompiler(mockWin)
+
+	misdirected := 0
+	err := c.sendKeystroke(vtproHwnd, vtproPid, "F12", c.compileKeystroke(InputAuto, vtproHwnd, &KeystrokeReport{}), &misdirected)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did not reach VTPro")
+	assert.Equal(t, maxKeystrokeAttempts, misdirected)
+	assert.Len(t, mockKbd.Keystrokes, maxKeystrokeAttempts)
+}
+
+func TestCompile_ReportsMisdirectedKeystrokes(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	mockWin := testutil.NewMockWindowManager().
+		WithForegroundSequence(otherHwnd, vtproHwnd).
+		WithChildInfosForHwnd(vtproHwnd, windows.ChildInfo{ClassName: "Edit", Text: successfulLog}).
+		WithWindowValid(0x1111, false)
+	c, _ := newKeystrokeCompiler(mockWin)
+
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: "VisionTools Pro-e Compiling..."},
+	)
+
+	result, err := c.Compile(CompileOptions{
+		Hwnd:                          vtproHwnd,
+		VTProPid:                      vtproPid,
+		SkipPreCompilationDialogCheck: true,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.MisdirectedKeystrokes)
+}
+
+func TestCompile_WithoutPidWarnsKeystrokeOnly(t *testing.T) {
+	t.Parallel()
+
+	c, mockKbd := newKeystrokeCompiler(testutil.NewMockWindowManager())
+
+	result, err := c.Compile(CompileOptions{
+		Hwnd:                          vtproHwnd,
+		SkipPreCompilationDialogCheck: true,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"F12"}, mockKbd.Keystrokes, "the compile keystroke is still sent")
+	assert.Equal(t, []string{keystrokeOnlyWarning}, result.WarningMessages)
+	assert.Equal(t, 0, result.Warnings, "vtpc's own warning is not counted as a VTPro warning")
+	require.Len(t, result.Messages, 1)
+	assert.Equal(t, -1, result.Messages[0].Index)
+	assert.False(t, result.HasErrors)
+}
+
+func TestCompile_LaunchMinimizedRestoresThenMinimizes(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(vtproHwnd, windows.ChildInfo{ClassName: "Edit", Text: successfulLog}).
+		WithWindowValid(0x1111, false)
+	c, mockKbd := newKeystrokeCompiler(mockWin)
+
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: "VisionTools Pro-e Compiling..."},
+	)
+
+	_, err := c.Compile(CompileOptions{
+		Hwnd:                          vtproHwnd,
+		VTProPid:                      vtproPid,
+		LaunchMinimized:               true,
+		SkipPreCompilationDialogCheck: true,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"RestoreWindow", "SetForeground", "MinimizeWindow"}, mockWin.WindowOps,
+		"VTPro is shown for the keystroke and minimized once the Compiling dialog confirms it")
+	assert.Equal(t, []uintptr{vtproHwnd}, mockWin.RestoreWindowCalls)
+	assert.Equal(t, []uintptr{vtproHwnd}, mockWin.MinimizeWindowCalls)
+	assert.Equal(t, []string{"F12"}, mockKbd.Keystrokes)
+}
+
+func TestCompile_NotMinimizedLeavesWindowAlone(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(vtproHwnd, windows.ChildInfo{ClassName: "Edit", Text: successfulLog}).
+		WithWindowValid(0x1111, false)
+	c, _ := newKeystrokeCompiler(mockWin)
+
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: "VisionTools Pro-e Compiling..."},
+	)
+
+	_, err := c.Compile(CompileOptions{
+		Hwnd:                          vtproHwnd,
+		VTProPid:                      vtproPid,
+		SkipPreCompilationDialogCheck: true,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SetForeground"}, mockWin.WindowOps)
+}
+
+func TestCompile_LaunchMinimizedStaysShownWhenCompileNeverStarts(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	mockWin := testutil.NewMockWindowManager()
+	c, _ := newKeystrokeCompiler(mockWin)
+
+	// Keystroke-only mode never sees the Compiling dialog, so nothing confirms the keystroke
+	_, err := c.Compile(CompileOptions{
+		Hwnd:                          vtproHwnd,
+		LaunchMinimized:               true,
+		SkipPreCompilationDialogCheck: true,
+	})
+
+	require.NoError(t, err)
+	assert.Empty(t, mockWin.MinimizeWindowCalls)
+	assert.Equal(t, []uintptr{vtproHwnd}, mockWin.RestoreWindowCalls)
+}
@@ -0,0 +1,77 @@
+package compiler
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+)
+
+// liveLogInterval bounds how often the Message Log is read for --live-log
+const liveLogInterval = time.Second
+
+// liveLog echoes lines as VTPro appends them to the Message Log during a compile.
+// It only reads: the result is still parsed from the log read once the compile ends.
+// A nil liveLog is disabled and never ticks.
+type liveLog struct {
+	ticker clock.Ticker
+	seen   []string // Lines of the log as last read, all of which have been echoed
+}
+
+// startLiveLog starts reading the Message Log every liveLogInterval, or returns nil if not enabled
+func startLiveLog(clk clock.Clock, enabled bool) *liveLog {
+	if !enabled {
+		return nil
+	}
+
+	return &liveLog{ticker: clk.NewTicker(liveLogInterval)}
+}
+
+// C returns the tick channel, or nil for a disabled live log so a select never picks it
+func (l *liveLog) C() <-chan time.Time {
+	if l == nil {
+		return nil
+	}
+
+	return l.ticker.C()
+}
+
+// Stop stops the live log's ticker
+func (l *liveLog) Stop() {
+	if l != nil {
+		l.ticker.Stop()
+	}
+}
+
+// Update takes the latest Message Log text and returns the non-blank lines not yet echoed.
+// The control sometimes clears and re-renders itself from scratch: while the new
+// text is still a prefix of what was seen nothing is returned, and once it grows
+// past it only the lines after the point where they differ are.
+func (l *liveLog) Update(text string) []string {
+	if l == nil || text == "" {
+		return nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	common := 0
+	for common < len(lines) && common < len(l.seen) && lines[common] == l.seen[common] {
+		common++
+	}
+
+	// Re-rendering, or cut back: everything on screen has already been echoed
+	if common == len(lines) {
+		return nil
+	}
+
+	var fresh []string
+	for _, line := range lines[common:] {
+		if strings.TrimSpace(line) != "" {
+			fresh = append(fresh, line)
+		}
+	}
+
+	l.seen = lines
+
+	return fresh
+}
@@ -0,0 +1,135 @@
+package compiler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// liveLogStep is a snapshot of the Message Log and the lines it should echo
+type liveLogStep struct {
+	text string
+	want []string
+}
+
+func TestLiveLog_Update(t *testing.T) {
+	t.Parallel()
+
+	header := "---------- Compiling for TSW-770: [test.vtp] ---------"
+
+	tests := []struct {
+		name  string
+		steps []liveLogStep
+	}{
+		{
+			name: "log grows page by page",
+			steps: []liveLogStep{
+				{"", nil},
+				{header, []string{header}},
+				{header + "\nCompiling page: Main", []string{"Compiling page: Main"}},
+				{header + "\nCompiling page: Main", nil},
+				{header + "\nCompiling page: Main\nCompiling page: Settings\nCompiling page: Sources", []string{"Compiling page: Settings", "Compiling page: Sources"}},
+			},
+		},
+		{
+			name: "control re-renders from scratch",
+			steps: []liveLogStep{
+				{header + "\nCompiling page: Main\nCompiling page: Settings", []string{header, "Compiling page: Main", "Compiling page: Settings"}},
+				{"", nil},
+				{header, nil},
+				{header + "\nCompiling page: Main", nil},
+				{header + "\nCompiling page: Main\nCompiling page: Settings\nCompiling page: Sources", []string{"Compiling page: Sources"}},
+			},
+		},
+		{
+			name: "log replaced by different text",
+			steps: []liveLogStep{
+				{header + "\nCompiling page: Main", []string{header, "Compiling page: Main"}},
+				{header + "\nCompiling page: Lobby", []string{"Compiling page: Lobby"}},
+			},
+		},
+		{
+			name: "blank lines and CRLF",
+			steps: []liveLogStep{
+				{header + "\r\n\r\nCompiling page: Main\r\n", []string{header, "Compiling page: Main"}},
+				{header + "\r\n\r\nCompiling page: Main\r\nCompiling page: Settings", []string{"Compiling page: Settings"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			live := startLiveLog(clock.NewFake(heartbeatEpoch), true)
+			defer live.Stop()
+
+			for i, step := range tt.steps {
+				assert.Equal(t, step.want, live.Update(step.text), "snapshot %d", i)
+			}
+		})
+	}
+}
+
+func TestLiveLog_Disabled(t *testing.T) {
+	t.Parallel()
+
+	live := startLiveLog(clock.NewFake(heartbeatEpoch), false)
+	assert.Nil(t, live)
+	assert.Nil(t, live.C())
+	assert.Nil(t, live.Update("---------- Compiling for TSW-770: [test.vtp] ---------"))
+	live.Stop()
+}
+
+func TestCompiler_EchoesMessageLogWhileCompiling(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	// The Compiling dialog never closes, so the compile runs until the timeout
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(0x9999, windows.ChildInfo{
+			ClassName: "ListBox",
+			Text:      "---------- Compiling for TSW-770: [test.vtp] ---------\nCompiling page: Settings",
+		})
+	log := &testutil.MockLogger{}
+	clk := clock.NewFake(heartbeatEpoch)
+
+	comp := NewCompiler(log,
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(mockWin),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(testutil.NewMockControlReader()),
+		WithClock(clk),
+		// The clock jumps far further between polls than any wait, which would look like sleep
+		WithSleepPolicy(clock.SleepIgnore),
+	)
+
+	testutil.SendEventsToMonitor(windows.WindowEvent{Hwnd: 0x1111, Title: "VisionTools Pro-e Compiling..."})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := comp.Compile(CompileOptions{
+			Hwnd:                          0x9999,
+			VTProPid:                      1234,
+			SkipPreCompilationDialogCheck: true,
+			CompilationTimeout:            time.Second,
+			LiveLog:                       true,
+		})
+		done <- err
+	}()
+
+	assert.Eventually(t, func() bool {
+		clk.Advance(liveLogInterval)
+		return hasMessage(log, "Compiling page: Settings")
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.ErrorIs(t, <-done, ErrCompileTimeout)
+
+	// Each line is echoed once however often the log is read
+	assert.Equal(t, 1, countMessages(log, "Compiling page: Settings"))
+}
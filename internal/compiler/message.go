@@ -0,0 +1,140 @@
+package compiler
+
+import "fmt"
+
+// Severity is the level of a Message Log entry
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+// String returns the tag used when rendering a message, e.g. "warning"
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	default:
+		return "warning"
+	}
+}
+
+// Message is a single warning or error from the Message Log
+type Message struct {
+	Index    int      // Position in the Message Log, counted across all targets
+	Severity Severity // Warning or error
+	Text     string   // Message text with wrapped lines joined
+	Target   string   // Panel model of the section the message appeared in
+	RuleID   string   // Kind of message, e.g. "unassigned-smart-object-id", or RuleUnknown
+}
+
+// Page returns the name of the page the message is about, or "" if it names none
+func (m Message) Page() string {
+	return captured(messagePageRe, m.Text)
+}
+
+// Object returns the name of the object the message is about, or "" if it names none
+func (m Message) Object() string {
+	return captured(messageObjectRe, m.Text)
+}
+
+// messageTexts returns the text of every message with the given severity, in log order
+func messageTexts(messages []Message, severity Severity) []string {
+	var texts []string
+
+	for _, m := range messages {
+		if m.Severity == severity {
+			texts = append(texts, m.Text)
+		}
+	}
+
+	return texts
+}
+
+// MessageOrder controls how messages are grouped when they are printed
+type MessageOrder int
+
+const (
+	// MessageOrderSeverity prints all errors, then all warnings
+	MessageOrderSeverity MessageOrder = iota
+
+	// MessageOrderLog prints messages in Message Log order with a severity tag
+	MessageOrderLog
+)
+
+// ParseMessageOrder converts a flag value ("severity" or "log") to a MessageOrder
+func ParseMessageOrder(s string) (MessageOrder, error) {
+	switch s {
+	case "", "severity":
+		return MessageOrderSeverity, nil
+	case "log":
+		return MessageOrderLog, nil
+	default:
+		return MessageOrderSeverity, fmt.Errorf("invalid message order %q: must be \"severity\" or \"log\"", s)
+	}
+}
+
+// OrderMessages returns the messages in the given order: errors then warnings, each
+// in log order, for MessageOrderSeverity, or unchanged for MessageOrderLog
+func OrderMessages(messages []Message, order MessageOrder) []Message {
+	if order == MessageOrderLog {
+		return messages
+	}
+
+	ordered := make([]Message, 0, len(messages))
+
+	for _, severity := range []Severity{SeverityError, SeverityWarning} {
+		for _, m := range messages {
+			if m.Severity == severity {
+				ordered = append(ordered, m)
+			}
+		}
+	}
+
+	return ordered
+}
+
+// MessageFormat controls how messages are rendered on the console
+type MessageFormat int
+
+const (
+	// MessageFormatList prints numbered messages as they are found
+	MessageFormatList MessageFormat = iota
+
+	// MessageFormatTable prints an aligned table once the compile is complete
+	MessageFormatTable
+)
+
+// ParseMessageFormat converts a flag value ("list" or "table") to a MessageFormat
+func ParseMessageFormat(s string) (MessageFormat, error) {
+	switch s {
+	case "", "list":
+		return MessageFormatList, nil
+	case "table":
+		return MessageFormatTable, nil
+	default:
+		return MessageFormatList, fmt.Errorf("invalid format %q: must be \"list\" or \"table\"", s)
+	}
+}
+
+// keystrokeOnlyWarning is added to the result of a compile run without a VTPro PID
+const keystrokeOnlyWarning = "vtpc: VTPro PID unknown - compiled with keystrokes only; dialogs were not handled and the Message Log was not read"
+
+// addWarning adds a warning raised by vtpc itself, rather than by VTPro, to a result.
+// It is listed with the other warnings but not counted in Warnings, which is VTPro's count,
+// and its Index is -1 because it is not in the Message Log.
+func addWarning(result *CompileResult, text string) {
+	result.Messages = append(result.Messages, Message{Index: -1, Severity: SeverityWarning, Text: text, RuleID: RuleUnknown})
+	result.WarningMessages = append(result.WarningMessages, text)
+}
+
+// newErrorResult builds a failed CompileResult carrying a single error message
+func newErrorResult(text string) *CompileResult {
+	return &CompileResult{
+		Errors:        1,
+		HasErrors:     true,
+		Messages:      []Message{{Severity: SeverityError, Text: text, RuleID: RuleUnknown}},
+		ErrorMessages: []string{text},
+	}
+}
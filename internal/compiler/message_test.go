@@ -0,0 +1,148 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+func TestParseVTProOutput_MessagesKeepLogOrder(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	output := `---------- Compiling for TSW-770: [test.vtp] ---------
+Boot
+	[ warning ]: Object "Source" on Page "Main" has an unassigned Smart Object ID.
+	[ error ]: Object "Source" on Page "Main" references an undefined Smart Object.
+	[ warning ]: Object "Logo" on Page "Main" references a missing image.
+	[ error ]: Object "Mute" on Page "Audio" has an invalid join number.
+---------- Failed ---------
+2 warning(s), 2 error(s)`
+
+	result := &CompileResult{}
+	c.parseVTProOutput(output, result)
+
+	require.Len(t, result.Messages, 4)
+
+	severities := make([]Severity, 0, len(result.Messages))
+	for i, m := range result.Messages {
+		severities = append(severities, m.Severity)
+		assert.Equal(t, i, m.Index)
+		assert.Equal(t, "TSW-770", m.Target)
+	}
+
+	assert.Equal(t, []Severity{SeverityWarning, SeverityError, SeverityWarning, SeverityError}, severities)
+	assert.Equal(t, "Object \"Source\" on Page \"Main\" references an undefined Smart Object.", result.Messages[1].Text)
+
+	// Per-severity slices are derived views of Messages
+	assert.Equal(t, []string{
+		"Object \"Source\" on Page \"Main\" references an undefined Smart Object.",
+		"Object \"Mute\" on Page \"Audio\" has an invalid join number.",
+	}, result.ErrorMessages)
+	assert.Equal(t, []string{
+		"Object \"Source\" on Page \"Main\" has an unassigned Smart Object ID.",
+		"Object \"Logo\" on Page \"Main\" references a missing image.",
+	}, result.WarningMessages)
+}
+
+func TestParseVTProOutput_MessageIndexSpansTargets(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	result := &CompileResult{}
+	c.parseVTProOutput(readFixture(t, "two_targets.log"), result)
+
+	require.Len(t, result.Messages, 4)
+
+	for i, m := range result.Messages {
+		assert.Equal(t, i, m.Index)
+	}
+
+	assert.Equal(t, "TSW-770", result.Messages[0].Target)
+	assert.Equal(t, "TSW-1070", result.Messages[1].Target)
+	assert.Equal(t, SeverityError, result.Messages[2].Severity)
+
+	// Section messages carry the same log-wide indexes
+	require.Len(t, result.Sections, 2)
+	assert.Equal(t, result.Messages[1:], result.Sections[1].Messages)
+}
+
+func TestSeverity_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "warning", SeverityWarning.String())
+	assert.Equal(t, "error", SeverityError.String())
+}
+
+func TestParseMessageOrder(t *testing.T) {
+	t.Parallel()
+
+	order, err := ParseMessageOrder("")
+	assert.NoError(t, err)
+	assert.Equal(t, MessageOrderSeverity, order)
+
+	order, err = ParseMessageOrder("severity")
+	assert.NoError(t, err)
+	assert.Equal(t, MessageOrderSeverity, order)
+
+	order, err = ParseMessageOrder("log")
+	assert.NoError(t, err)
+	assert.Equal(t, MessageOrderLog, order)
+
+	_, err = ParseMessageOrder("alphabetical")
+	assert.Error(t, err)
+}
+
+func TestParseMessageFormat(t *testing.T) {
+	t.Parallel()
+
+	format, err := ParseMessageFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, MessageFormatList, format)
+
+	format, err = ParseMessageFormat("list")
+	assert.NoError(t, err)
+	assert.Equal(t, MessageFormatList, format)
+
+	format, err = ParseMessageFormat("table")
+	assert.NoError(t, err)
+	assert.Equal(t, MessageFormatTable, format)
+
+	_, err = ParseMessageFormat("grid")
+	assert.Error(t, err)
+}
+
+func TestOrderMessages(t *testing.T) {
+	t.Parallel()
+
+	messages := []Message{
+		{Index: 0, Severity: SeverityWarning, Text: "w1"},
+		{Index: 1, Severity: SeverityError, Text: "e1"},
+		{Index: 2, Severity: SeverityWarning, Text: "w2"},
+		{Index: 3, Severity: SeverityError, Text: "e2"},
+	}
+
+	texts := func(ms []Message) []string {
+		out := make([]string, 0, len(ms))
+		for _, m := range ms {
+			out = append(out, m.Text)
+		}
+
+		return out
+	}
+
+	assert.Equal(t, []string{"e1", "e2", "w1", "w2"}, texts(OrderMessages(messages, MessageOrderSeverity)))
+	assert.Equal(t, []string{"w1", "e1", "w2", "e2"}, texts(OrderMessages(messages, MessageOrderLog)))
+}
+
+func TestNewErrorResult(t *testing.T) {
+	t.Parallel()
+
+	result := newErrorResult("Compilation timeout")
+
+	assert.True(t, result.HasErrors)
+	assert.Equal(t, 1, result.Errors)
+	assert.Equal(t, []Message{{Severity: SeverityError, Text: "Compilation timeout", RuleID: RuleUnknown}}, result.Messages)
+	assert.Equal(t, []string{"Compilation timeout"}, result.ErrorMessages)
+}
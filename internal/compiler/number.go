@@ -0,0 +1,150 @@
+package compiler
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// thousandsSeparators are the digit-group separators VTPro uses across locales:
+// comma, period, space, no-break space, thin space and narrow no-break space
+const thousandsSeparators = ",. \u00a0\u2009\u202f"
+
+// countExpr matches a count in a summary line, either plain digits or digits
+// grouped in threes by one of thousandsSeparators, e.g. "1,024" or "1 024"
+const countExpr = `\d{1,3}(?:[,. \x{00A0}\x{2009}\x{202F}]\d{3})+|\d+`
+
+// parseCount parses a count that may contain thousands separators
+func parseCount(s string) (int, error) {
+	digits, ok := groupedDigits(strings.TrimSpace(s))
+	if !ok {
+		return 0, fmt.Errorf("invalid count %q", s)
+	}
+
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid count %q", s)
+	}
+
+	return n, nil
+}
+
+// groupedDigits returns s without its thousands separators if it is plain
+// digits or digits grouped in threes, e.g. "1,024" or "18 588 092". A period
+// or comma not followed by exactly three digits is a decimal point rather than
+// a separator, so "1.5" is not a grouped number.
+func groupedDigits(s string) (string, bool) {
+	var groups []string
+
+	start := 0
+	for i, r := range s {
+		if strings.ContainsRune(thousandsSeparators, r) {
+			groups = append(groups, s[start:i])
+			start = i + utf8.RuneLen(r)
+		}
+	}
+
+	groups = append(groups, s[start:])
+
+	for i, g := range groups {
+		if g == "" || strings.ContainsFunc(g, notDigit) {
+			return "", false
+		}
+
+		if len(groups) > 1 && (len(g) > 3 || (i > 0 && len(g) != 3)) {
+			return "", false
+		}
+	}
+
+	return strings.Join(groups, ""), true
+}
+
+// parseDecimal parses a number with one period or comma as its decimal point, e.g. "1.5" or "1,5"
+func parseDecimal(s string) (float64, bool) {
+	if strings.Count(s, ".")+strings.Count(s, ",") != 1 {
+		return 0, false
+	}
+
+	whole, frac, _ := strings.Cut(strings.ReplaceAll(s, ",", "."), ".")
+
+	digits, ok := groupedDigits(whole)
+	if !ok || frac == "" || strings.ContainsFunc(frac, notDigit) {
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(digits+"."+frac, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return f, true
+}
+
+// notDigit reports whether r is not an ASCII digit
+func notDigit(r rune) bool {
+	return r < '0' || r > '9'
+}
+
+// isEnglishNumber reports whether s is digits optionally grouped with commas,
+// and for sizes a decimal point, the form English VTPro prints. Strict parsing
+// flags numbers that are not. A period before three digits is read as a
+// thousands separator, so it is flagged too.
+func isEnglishNumber(s string) bool {
+	whole, frac, decimal := strings.Cut(s, ".")
+	if whole == "" || decimal && (frac == "" || len(frac) == 3 || strings.ContainsFunc(frac, notDigit)) {
+		return false
+	}
+
+	for _, r := range whole {
+		if notDigit(r) && r != ',' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sizeUnits maps the units VTPro prints after a size to their multiplier in bytes
+var sizeUnits = map[string]int64{
+	"":      1,
+	"b":     1,
+	"byte":  1,
+	"bytes": 1,
+	"kb":    1024,
+	"mb":    1024 * 1024,
+}
+
+// parseSize converts a size as VTPro prints it, e.g. "18,588,092 bytes", "1 024 Kb"
+// or "1.5 MB", to bytes. A size in Kb or MB may have a decimal point.
+// It also returns the number as printed, so strict parsing can check its separators.
+func parseSize(s string) (bytes int64, number string, err error) {
+	s = strings.TrimSpace(s)
+
+	// The number runs up to the first letter; the rest is the unit
+	end := strings.IndexFunc(s, unicode.IsLetter)
+	if end == -1 {
+		end = len(s)
+	}
+
+	number = strings.TrimSpace(s[:end])
+	unit := strings.ToLower(strings.TrimSpace(s[end:]))
+
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return 0, number, fmt.Errorf("unknown size unit %q in %q", unit, s)
+	}
+
+	n, err := parseCount(number)
+	if err == nil {
+		return int64(n) * multiplier, number, nil
+	}
+
+	if f, ok := parseDecimal(number); ok && multiplier > 1 {
+		return int64(math.Round(f * float64(multiplier))), number, nil
+	}
+
+	return 0, number, fmt.Errorf("invalid size %q: %w", s, err)
+}
@@ -0,0 +1,182 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+)
+
+func TestParseCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{"plain", "1024", 1024},
+		{"comma", "1,024", 1024},
+		{"period", "1.024", 1024},
+		{"space", "1 024", 1024},
+		{"no-break space", "1\u00a0024", 1024},
+		{"thin space", "1\u2009024", 1024},
+		{"narrow no-break space", "1\u202f024", 1024},
+		{"millions", "18,588,092", 18588092},
+		{"zero", "0", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseCount(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseCount_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{"", "1O24", "twelve", "1_024", "1.5", "1,02", "1,,024", "1024,000", ",024"} {
+		_, err := parseCount(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestIsEnglishNumber(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isEnglishNumber("1024"))
+	assert.True(t, isEnglishNumber("18,588,092"))
+	assert.True(t, isEnglishNumber("1.5"))
+	assert.False(t, isEnglishNumber("1,5.2.1"))
+	assert.False(t, isEnglishNumber("1.024"))
+	assert.False(t, isEnglishNumber("1\u2009024"))
+	assert.False(t, isEnglishNumber(""))
+}
+
+func TestParseSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input  string
+		want   int64
+		number string
+	}{
+		{"18,588,092 bytes", 18588092, "18,588,092"},
+		{"18\u00a0588\u00a0092 bytes", 18588092, "18\u00a0588\u00a0092"},
+		{"0 Kb", 0, "0"},
+		{"1.024 Kb", 1024 * 1024, "1.024"},
+		{"1.5 MB", 1536 * 1024, "1.5"},
+		{"1,5 MB", 1536 * 1024, "1,5"},
+		{"0.25 Kb", 256, "0.25"},
+		{"2 MB", 2 * 1024 * 1024, "2"},
+		{"512", 512, "512"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, number, err := parseSize(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.number, number)
+		})
+	}
+}
+
+func TestParseSize_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := parseSize("18,588,092 octets")
+	assert.ErrorContains(t, err, "unknown size unit")
+
+	_, _, err = parseSize("lots of bytes")
+	assert.Error(t, err)
+
+	_, _, err = parseSize("1.5 bytes")
+	assert.Error(t, err, "there is no such thing as half a byte")
+}
+
+func TestParseVTProOutput_SeparatedCounts(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	result := &CompileResult{}
+	c.parseVTProOutput(readFixture(t, "separated_counts.log"), result)
+
+	require.Len(t, result.Sections, 2)
+
+	first := result.Sections[0]
+	assert.Equal(t, 1024, first.Warnings, "the thin space is a separator, not the end of the count")
+	assert.Equal(t, 0, first.Errors)
+	assert.Equal(t, "strict", first.SummaryPattern)
+	assert.Equal(t, int64(18588092), first.SizeBytes)
+	assert.Equal(t, int64(2048*1024), first.ProjectBytes)
+
+	second := result.Sections[1]
+	assert.Equal(t, 1250, second.Warnings)
+	assert.Equal(t, 2, second.Errors)
+	assert.Equal(t, int64(24101330), second.SizeBytes)
+
+	assert.Equal(t, 2274, result.Warnings)
+	assert.Equal(t, 2, result.Errors)
+	assert.Zero(t, result.SizeBytes, "each target's output has its own size")
+	assert.Equal(t, int64(1024*1024), result.ProjectBytes)
+}
+
+func TestParseVTProOutput_EnglishSizes(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	result := &CompileResult{}
+	c.parseVTProOutput(readFixture(t, "two_targets.log"), result)
+
+	assert.Equal(t, int64(18588092), result.Sections[0].SizeBytes)
+	assert.Equal(t, int64(24101330), result.Sections[1].SizeBytes)
+	assert.Zero(t, result.ProjectBytes)
+}
+
+func TestParseVTProOutput_StrictFlagsSeparators(t *testing.T) {
+	log := testutil.NewMockLogger()
+	c := NewCompiler(log, WithParser(ParserOptions{Strict: true}))
+
+	result := &CompileResult{}
+	c.parseVTProOutput(readFixture(t, "separated_counts.log"), result)
+
+	// Counts are still parsed, but every non-English number is flagged
+	assert.Equal(t, 2274, result.Warnings)
+
+	flagged := 0
+	for _, msg := range log.Messages() {
+		if msg == "Unexpected characters in number" {
+			flagged++
+		}
+	}
+
+	// Both summary lines' warning counts and all four sizes
+	assert.Equal(t, 6, flagged)
+}
+
+func TestParseVTProOutput_StrictAcceptsEnglish(t *testing.T) {
+	log := testutil.NewMockLogger()
+	c := NewCompiler(log, WithParser(ParserOptions{Strict: true}))
+
+	c.parseVTProOutput(readFixture(t, "two_targets.log"), &CompileResult{})
+
+	assert.NotContains(t, log.Messages(), "Unexpected characters in number")
+}
+
+func TestParseVTProOutput_LenientDoesNotFlag(t *testing.T) {
+	log := testutil.NewMockLogger()
+	c := NewCompiler(log)
+
+	c.parseVTProOutput(readFixture(t, "separated_counts.log"), &CompileResult{})
+
+	assert.NotContains(t, log.Messages(), "Unexpected characters in number")
+}
@@ -0,0 +1,82 @@
+package compiler
+
+import (
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/interfaces"
+	"github.com/Norgate-AV/vtpc/internal/timeouts"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// Option overrides one of a Compiler's dependencies or settings
+type Option func(*Compiler)
+
+// WithProcessManager sets the process manager used to find VTPro's windows
+func WithProcessManager(p interfaces.ProcessManager) Option {
+	return func(c *Compiler) { c.processMgr = p }
+}
+
+// WithWindowManager sets the window manager used to inspect and close windows
+func WithWindowManager(w interfaces.WindowManager) Option {
+	return func(c *Compiler) { c.windowMgr = w }
+}
+
+// WithKeyboard sets the keyboard injector used to send F12 and Ctrl+S
+func WithKeyboard(k interfaces.KeyboardInjector) Option {
+	return func(c *Compiler) { c.keyboard = k }
+}
+
+// WithControlReader sets the control reader used to read the Message Log
+func WithControlReader(r interfaces.ControlReader) Option {
+	return func(c *Compiler) { c.controlReader = r }
+}
+
+// WithClock sets the clock that drives the compile heartbeat
+func WithClock(clk clock.Clock) Option {
+	return func(c *Compiler) { c.clock = clk }
+}
+
+// WithSleepPolicy sets what wait loops do when the system sleeps part way
+// through a wait; the default extends their deadlines by the sleep
+func WithSleepPolicy(policy clock.SleepPolicy) Option {
+	return func(c *Compiler) { c.sleepPolicy = policy }
+}
+
+// WithParser sets the options used to parse the Message Log
+func WithParser(opts ParserOptions) Option {
+	return func(c *Compiler) { c.parser = opts }
+}
+
+// WithMonitorSource sets where window events are read from. The source is called
+// each time events are needed, because the monitor replaces its channel on restart.
+func WithMonitorSource(source func() <-chan windows.WindowEvent) Option {
+	return func(c *Compiler) { c.monitor = source }
+}
+
+// WithForegroundLock sets how the foreground lock timeout is read when VTPro
+// cannot be brought to the foreground
+func WithForegroundLock(read func() (windows.ForegroundLock, error)) Option {
+	return func(c *Compiler) { c.foregroundLock = read }
+}
+
+// WithDialogs sets the registry dialogs are recognised by
+func WithDialogs(r *dialog.Registry) Option {
+	return func(c *Compiler) { c.dialogs = r }
+}
+
+// WithInputDelays sets the delays around injected keystrokes, including the
+// default keyboard's delay between key down and key up
+func WithInputDelays(in timeouts.Input) Option {
+	return func(c *Compiler) { c.input = in }
+}
+
+// WithStepObserver sets a function called with each step's outcome as the step
+// ends, so a caller can follow a compile step by step
+func WithStepObserver(observe func(StepOutcome)) Option {
+	return func(c *Compiler) { c.onStep = observe }
+}
+
+// windowMonitorEvents is the default monitor source, the running window monitor's channel
+func windowMonitorEvents() <-chan windows.WindowEvent {
+	return windows.MonitorCh
+}
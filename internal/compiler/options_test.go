@@ -0,0 +1,79 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestNewCompiler_DefaultsToProduction(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	assert.IsType(t, &windows.WindowsAPI{}, c.windowMgr)
+	assert.IsType(t, &windows.WindowsAPI{}, c.keyboard)
+	assert.IsType(t, &windows.WindowsAPI{}, c.controlReader)
+	assert.IsType(t, vtpro.VTProProcessAPI{}, c.processMgr)
+	assert.IsType(t, clock.Real{}, c.clock)
+	require.NotNil(t, c.monitor)
+}
+
+func TestNewCompiler_SingleDependencyOverride(t *testing.T) {
+	// Only the window manager is mocked; the parser and everything else are real
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(0x9999,
+			windows.ChildInfo{Hwnd: 0x5555, ClassName: "Edit", Text: "---------- Compiling for TSW-770: [test.vtp] ---------\nWARNING: Unused join\n---------- Successful ---------\n1 warning(s), 0 error(s)"},
+		)
+
+	c := NewCompiler(logger.NewNoOpLogger(), WithWindowManager(mockWin))
+
+	assert.Same(t, mockWin, c.windowMgr)
+	assert.IsType(t, &windows.WindowsAPI{}, c.keyboard, "dependencies that were not overridden keep their defaults")
+
+	text, hwnd := c.readMessageLog(0x9999)
+	assert.Equal(t, uintptr(0x5555), hwnd)
+
+	result := &CompileResult{}
+	c.parseVTProOutput(text, result)
+	assert.Equal(t, 1, result.Warnings)
+}
+
+func TestNewCompiler_OptionsApplyInOrder(t *testing.T) {
+	first := testutil.NewMockKeyboardInjector()
+	second := testutil.NewMockKeyboardInjector()
+
+	c := NewCompiler(logger.NewNoOpLogger(),
+		WithKeyboard(first),
+		WithParser(ParserOptions{MaxContinuations: 7}),
+		WithKeyboard(second),
+	)
+
+	assert.Same(t, second, c.keyboard)
+	assert.Equal(t, 7, c.parser.MaxContinuations)
+}
+
+func TestNewCompiler_WithMonitorSource(t *testing.T) {
+	events := make(chan windows.WindowEvent, 2)
+	events <- windows.WindowEvent{Hwnd: 0x1111, Title: "Progress [10%]"}
+	events <- windows.WindowEvent{Hwnd: 0x2222, Title: "Progress [20%]"}
+
+	c := NewCompiler(logger.NewNoOpLogger(), WithMonitorSource(func() <-chan windows.WindowEvent { return events }))
+	c.drainMonitorChannel()
+
+	assert.Empty(t, events, "stale events are drained from the injected source")
+}
+
+func TestNewCompilerWithDeps_NilDependenciesUseDefaults(t *testing.T) {
+	mockWin := testutil.NewMockWindowManager()
+
+	c := NewCompilerWithDeps(logger.NewNoOpLogger(), &CompileDependencies{WindowMgr: mockWin})
+
+	assert.Same(t, mockWin, c.windowMgr)
+	assert.IsType(t, &windows.WindowsAPI{}, c.controlReader)
+}
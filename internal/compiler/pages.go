@@ -0,0 +1,94 @@
+package compiler
+
+import (
+	"fmt"
+	"path"
+)
+
+// PageFilter suppresses every message about pages matching its patterns, for
+// pages nobody gates on such as scratch or work-in-progress pages. Patterns
+// are case-insensitive globs, e.g. "ZZ_*".
+type PageFilter struct {
+	patterns []string
+}
+
+// NewPageFilter validates the patterns and builds a PageFilter from them
+func NewPageFilter(patterns []string) (*PageFilter, error) {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return nil, fmt.Errorf("empty page pattern")
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid page pattern %q: %w", pattern, err)
+		}
+	}
+
+	return &PageFilter{patterns: patterns}, nil
+}
+
+// Patterns returns the globs the filter matches pages against
+func (f *PageFilter) Patterns() []string {
+	if f == nil {
+		return nil
+	}
+
+	return f.patterns
+}
+
+// Suppresses reports whether the filter drops a message
+func (f *PageFilter) Suppresses(m Message) bool {
+	return f != nil && m.Index >= 0 && matchAnyGlob(f.patterns, m.Page())
+}
+
+// Apply removes the messages on matching pages from a parsed result and takes
+// one count away for each, as an ignore policy would. Messages vtpc raised
+// itself are left alone. It returns the removed messages, in log order.
+func (f *PageFilter) Apply(result *CompileResult) []Message {
+	if f == nil || len(f.patterns) == 0 {
+		return nil
+	}
+
+	var suppressed []Message
+
+	for i := range result.Sections {
+		suppressed = append(suppressed, f.applySection(&result.Sections[i])...)
+	}
+
+	if len(suppressed) == 0 {
+		return nil
+	}
+
+	result.sumSections()
+
+	return suppressed
+}
+
+// applySection removes the suppressed messages from one target's messages and counts
+func (f *PageFilter) applySection(s *TargetResult) []Message {
+	var (
+		suppressed []Message
+		kept       = make([]Message, 0, len(s.Messages))
+	)
+
+	for _, m := range s.Messages {
+		if !f.Suppresses(m) {
+			kept = append(kept, m)
+			continue
+		}
+
+		suppressed = append(suppressed, m)
+		s.uncount(m.Severity)
+	}
+
+	if len(suppressed) == 0 {
+		return nil
+	}
+
+	s.Messages = kept
+	s.ErrorMessages = messageTexts(kept, SeverityError)
+	s.WarningMessages = messageTexts(kept, SeverityWarning)
+	s.HasErrors = s.Errors > 0 || len(s.ErrorMessages) > 0
+
+	return suppressed
+}
@@ -0,0 +1,121 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+func parseIgnoringPages(t *testing.T, patterns []string, entries ...PolicyEntry) *CompileResult {
+	t.Helper()
+
+	filter, err := NewPageFilter(patterns)
+	require.NoError(t, err)
+
+	opts := ParserOptions{IgnorePages: filter}
+	if len(entries) > 0 {
+		opts.Policy = mustPolicy(t, entries...)
+	}
+
+	c := NewCompiler(logger.NewNoOpLogger(), WithParser(opts))
+
+	result := &CompileResult{}
+	c.parseVTProOutput(policyLog, result)
+
+	return result
+}
+
+func TestPageFilter_ApplySuppressesMatchingPages(t *testing.T) {
+	t.Parallel()
+
+	result := parseIgnoringPages(t, []string{"debug_*"})
+
+	assert.Equal(t, 2, result.Warnings)
+	assert.Equal(t, 0, result.Errors)
+	assert.False(t, result.HasErrors, "the only error was on an ignored page")
+	assert.Empty(t, result.ErrorMessages)
+	assert.Len(t, result.Messages, 2)
+
+	require.Len(t, result.SuppressedByPage, 2)
+	assert.Equal(t, "Debug_Audio", result.SuppressedByPage[0].Page())
+	assert.Equal(t, SeverityWarning, result.SuppressedByPage[0].Severity)
+	assert.Equal(t, SeverityError, result.SuppressedByPage[1].Severity)
+
+	require.Len(t, result.Sections, 1)
+	assert.Equal(t, 2, result.Sections[0].Warnings)
+	assert.Equal(t, 0, result.Sections[0].Errors)
+	assert.False(t, result.Sections[0].HasErrors)
+
+	assert.Equal(t, Counts{Warnings: 3, Errors: 1}, result.Reported, "what VTPro printed is kept")
+	assert.Empty(t, result.CountMismatches, "suppressed counts are part of the invariant")
+}
+
+func TestPageFilter_ApplyKeepsMessagesWithoutAPage(t *testing.T) {
+	t.Parallel()
+
+	result := parseIgnoringPages(t, []string{"*"})
+
+	require.Len(t, result.Messages, 1, "the path warning names no page")
+	assert.Contains(t, result.Messages[0].Text, "exceeds the windows path limitations")
+	assert.Len(t, result.SuppressedByPage, 3)
+	assert.Empty(t, result.CountMismatches)
+}
+
+func TestPageFilter_ApplyNoMatch(t *testing.T) {
+	t.Parallel()
+
+	result := parseIgnoringPages(t, []string{"ZZ_*"})
+
+	assert.Equal(t, 3, result.Warnings)
+	assert.Equal(t, 1, result.Errors)
+	assert.Nil(t, result.SuppressedByPage)
+	assert.Len(t, result.Messages, 4)
+}
+
+func TestPageFilter_ApplyRunsBeforePolicy(t *testing.T) {
+	t.Parallel()
+
+	// Promoting every Smart Object warning to an error must not pull the one on
+	// an ignored page back into the counts
+	result := parseIgnoringPages(t, []string{"Debug_*"},
+		PolicyEntry{RuleID: "unassigned-smart-object-id", Action: ActionError})
+
+	assert.Equal(t, 1, result.Warnings)
+	assert.Equal(t, 1, result.Errors)
+	require.Len(t, result.ErrorMessages, 1)
+	assert.Contains(t, result.ErrorMessages[0], `Page "Main"`)
+
+	require.Len(t, result.Reclassified, 1)
+	assert.Equal(t, "Main", result.Reclassified[0].Message.Page())
+	assert.Len(t, result.SuppressedByPage, 2)
+	assert.Empty(t, result.CountMismatches)
+}
+
+func TestPageFilter_SuppressesSkipsVtpcMessages(t *testing.T) {
+	t.Parallel()
+
+	filter, err := NewPageFilter([]string{"*"})
+	require.NoError(t, err)
+
+	m := policyMessage(SeverityWarning, "", "Volume", "Main")
+	assert.True(t, filter.Suppresses(m))
+
+	m.Index = -1
+	assert.False(t, filter.Suppresses(m))
+
+	var none *PageFilter
+	assert.False(t, none.Suppresses(policyMessage(SeverityWarning, "", "Volume", "Main")))
+	assert.Nil(t, none.Patterns())
+}
+
+func TestNewPageFilter_RejectsInvalidPatterns(t *testing.T) {
+	t.Parallel()
+
+	for _, pattern := range []string{"", "ZZ_[", "[a-"} {
+		_, err := NewPageFilter([]string{pattern})
+		assert.Error(t, err, pattern)
+	}
+}
@@ -0,0 +1,451 @@
+package compiler
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// DefaultMaxContinuations is the number of wrapped lines joined onto a message
+// when ParserOptions.MaxContinuations is not set
+const DefaultMaxContinuations = 5
+
+// tabWidth is the number of columns a leading tab counts for when comparing indentation
+const tabWidth = 8
+
+// pageNameRegex matches a bare page identifier as VTPro prints it while compiling,
+// e.g. "Main", "~DummyFlashPage" or "~DummyFlashPage - [ not compiled ]"
+var pageNameRegex = regexp.MustCompile(`^~?[A-Za-z0-9_][\w-]*(?:\s+-\s+\[ not compiled \])?$`)
+
+// ParserOptions configures Message Log parsing
+type ParserOptions struct {
+	// SummaryPatterns are tried after DefaultSummaryPatterns, in order
+	SummaryPatterns []SummaryPattern
+
+	// MaxContinuations caps how many wrapped lines are joined onto one message.
+	// Zero or less uses DefaultMaxContinuations.
+	MaxContinuations int
+
+	// Strict flags counts and sizes that are not plain English numbers, such as
+	// "1 024" or "1.024", instead of silently removing their thousands separators
+	Strict bool
+
+	// IgnorePages suppresses messages on matching pages before Policy runs, so a
+	// rule the policy promotes cannot fail the run from an ignored page; nil keeps every page
+	IgnorePages *PageFilter
+
+	// Policy re-buckets messages by rule after parsing; nil leaves them as VTPro reported them
+	Policy *Policy
+}
+
+// summaryPatterns returns the built-in patterns followed by any configured extras
+func (o ParserOptions) summaryPatterns() []SummaryPattern {
+	patterns := make([]SummaryPattern, 0, len(DefaultSummaryPatterns)+len(o.SummaryPatterns))
+	patterns = append(patterns, DefaultSummaryPatterns...)
+	patterns = append(patterns, o.SummaryPatterns...)
+
+	return patterns
+}
+
+// maxContinuations returns the configured continuation limit or the default
+func (o ParserOptions) maxContinuations() int {
+	if o.MaxContinuations <= 0 {
+		return DefaultMaxContinuations
+	}
+
+	return o.MaxContinuations
+}
+
+// TargetResult holds the results for a single "Compiling for" section of the Message Log.
+// Projects that compile for several panel models produce one section per target.
+type TargetResult struct {
+	Target          string // Panel model from the section header (e.g., "TSW-770")
+	Warnings        int
+	Errors          int
+	Messages        []Message // Warnings and errors in log order
+	ErrorMessages   []string  // Error texts derived from Messages
+	WarningMessages []string  // Warning texts derived from Messages
+	HasErrors       bool
+	Size            string
+	SizeBytes       int64 // Size in bytes, 0 if it could not be parsed
+	ProjectSize     string
+	ProjectBytes    int64  // ProjectSize in bytes, 0 if it could not be parsed
+	SummaryPattern  string // Name of the summary pattern that matched, empty if none did
+	Reported        Counts // The summary line as VTPro printed it, before the rule policy
+}
+
+// parseVTProOutput parses VTPro compilation output format
+// Example format:
+// ---------- Compiling for TSW-770: [...] ---------
+// Boot
+// ~DummyFlashPage - [ not compiled ]
+// somepage
+//
+//	[ warning ]: Object "..." on Page "..." has an unassigned Smart Object ID.
+//	[ error ]: Some error message
+//
+// ...
+// ---------- Successful ---------
+// 1 warning(s), 0 error(s)
+//
+// The log may contain several "Compiling for" sections when a project targets more
+// than one panel. Each section is parsed into its own TargetResult and the top-level
+// counts are the sums across all sections. The top-level size is only set when a
+// single section reports one.
+func (c *Compiler) parseVTProOutput(text string, result *CompileResult) {
+	c.log.Trace("Parsing VTPro output", slog.Int("textLength", len(text)))
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	sized := 0
+
+	for _, section := range splitSections(lines) {
+		target := c.parseSection(section, len(result.Messages))
+		result.Sections = append(result.Sections, target)
+
+		result.Warnings += target.Warnings
+		result.Errors += target.Errors
+		result.Reported.Warnings += target.Reported.Warnings
+		result.Reported.Errors += target.Reported.Errors
+		result.Messages = append(result.Messages, target.Messages...)
+
+		if target.HasErrors {
+			result.HasErrors = true
+		}
+
+		if target.Size != "" {
+			sized++
+			result.Size = target.Size
+			result.SizeBytes = target.SizeBytes
+		}
+
+		if target.ProjectSize != "" {
+			result.ProjectSize = target.ProjectSize
+			result.ProjectBytes = target.ProjectBytes
+		}
+	}
+
+	// Each target's output is a file of its own, so their sizes are not added
+	// up. The sections keep them.
+	if sized > 1 {
+		result.Size = ""
+		result.SizeBytes = 0
+	}
+
+	result.ErrorMessages = messageTexts(result.Messages, SeverityError)
+	result.WarningMessages = messageTexts(result.Messages, SeverityWarning)
+
+	for _, m := range c.parser.IgnorePages.Apply(result) {
+		result.SuppressedByPage = append(result.SuppressedByPage, m)
+		c.log.Info("Message suppressed by --ignore-pages",
+			slog.String("page", m.Page()),
+			slog.String("severity", m.Severity.String()),
+			slog.String("message", m.Text),
+		)
+	}
+
+	for _, r := range c.parser.Policy.Apply(result) {
+		result.Reclassified = append(result.Reclassified, r)
+		c.log.Info("Message reclassified by policy",
+			slog.String("rule", r.Message.RuleID),
+			slog.String("from", r.Message.Severity.String()),
+			slog.String("action", string(r.Action)),
+			slog.String("policy", r.Policy.String()),
+			slog.String("message", r.Message.Text),
+		)
+	}
+
+	c.finalizeCounts(result)
+
+	c.log.Trace("Parse complete",
+		slog.Int("sections", len(result.Sections)),
+		slog.Int("warnings", result.Warnings),
+		slog.Int("errors", result.Errors),
+		slog.String("size", result.Size),
+		slog.String("projectSize", result.ProjectSize),
+	)
+}
+
+// logSection is a slice of Message Log lines belonging to one compile target
+type logSection struct {
+	target string
+	lines  []string
+}
+
+// splitSections splits the Message Log into one section per "Compiling for" header.
+// Lines before the first header (or the whole log, if there is no header) form a
+// section with an empty target. Sections without any content are dropped.
+func splitSections(lines []string) []logSection {
+	var sections []logSection
+	current := logSection{}
+
+	for _, line := range lines {
+		if target, ok := parseSectionHeader(line); ok {
+			if current.target != "" || hasContent(current.lines) {
+				sections = append(sections, current)
+			}
+
+			current = logSection{target: target}
+			continue
+		}
+
+		current.lines = append(current.lines, line)
+	}
+
+	if current.target != "" || hasContent(current.lines) {
+		sections = append(sections, current)
+	}
+
+	return sections
+}
+
+// parseSectionHeader extracts the target from a header line such as
+// "---------- Compiling for TSW-770: [test.vtp] ---------"
+func parseSectionHeader(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "----------") {
+		return "", false
+	}
+
+	idx := strings.Index(trimmed, "Compiling for")
+	if idx == -1 {
+		return "", false
+	}
+
+	target := strings.TrimSpace(trimmed[idx+len("Compiling for"):])
+	if colon := strings.Index(target, ":"); colon != -1 {
+		target = target[:colon]
+	}
+
+	target = strings.TrimSpace(strings.TrimRight(target, "-"))
+
+	return target, true
+}
+
+// hasContent reports whether any of the lines contain non-whitespace text
+func hasContent(lines []string) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseSection parses the messages, sizes and summary line of a single target section.
+// Message indexes start at firstIndex so they stay unique across sections.
+func (c *Compiler) parseSection(section logSection, firstIndex int) TargetResult {
+	result := TargetResult{Target: section.target}
+	lines := section.lines
+	cont := continuationRules{
+		max:      c.parser.maxContinuations(),
+		patterns: c.parser.summaryPatterns(),
+	}
+
+	// Process lines, handling multi-line messages (VTPro wraps long lines)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+
+		// Look for warning messages: [ warning ]: ...
+		if idx := strings.Index(line, "[ warning ]:"); idx != -1 {
+			msg := strings.TrimSpace(line[idx+len("[ warning ]:"):])
+			msg, i = collectContinuations(lines, i, msg, cont)
+
+			if msg != "" {
+				result.Messages = append(result.Messages, Message{
+					Index:    firstIndex + len(result.Messages),
+					Severity: SeverityWarning,
+					Text:     msg,
+					Target:   section.target,
+					RuleID:   defaultRules.Classify(msg).ID,
+				})
+				c.log.Trace("Found warning message", slog.String("message", msg))
+			}
+
+			continue
+		}
+
+		// Look for error messages: [ error ]: ...
+		if idx := strings.Index(line, "[ error ]:"); idx != -1 {
+			msg := strings.TrimSpace(line[idx+len("[ error ]:"):])
+			msg, i = collectContinuations(lines, i, msg, cont)
+
+			if msg != "" {
+				result.Messages = append(result.Messages, Message{
+					Index:    firstIndex + len(result.Messages),
+					Severity: SeverityError,
+					Text:     msg,
+					Target:   section.target,
+					RuleID:   defaultRules.Classify(msg).ID,
+				})
+				c.log.Trace("Found error message", slog.String("message", msg))
+			}
+
+			continue
+		}
+
+		// Look for size: [ size ]: 18,588,092 bytes
+		if idx := strings.Index(line, "[ size ]:"); idx != -1 {
+			if size := strings.TrimSpace(line[idx+len("[ size ]:"):]); size != "" {
+				result.Size = size
+				result.SizeBytes = c.parseSizeField("size", size)
+				c.log.Trace("Found size", slog.String("size", size))
+			}
+
+			continue
+		}
+
+		// Look for project size: [ project size ]: 0 Kb
+		if idx := strings.Index(line, "[ project size ]:"); idx != -1 {
+			if projectSize := strings.TrimSpace(line[idx+len("[ project size ]:"):]); projectSize != "" {
+				result.ProjectSize = projectSize
+				result.ProjectBytes = c.parseSizeField("project size", projectSize)
+				c.log.Trace("Found project size", slog.String("projectSize", projectSize))
+			}
+
+			continue
+		}
+
+		// Look for the summary line: "0 warning(s), 0 error(s)" or one of its variants
+		if match, ok := matchSummary(line, cont.patterns); ok {
+			result.Warnings = match.Warnings
+			result.Errors = match.Errors
+			result.Reported = Counts{Warnings: match.Warnings, Errors: match.Errors}
+			result.SummaryPattern = match.Pattern
+
+			for _, count := range match.Counts {
+				c.checkStrictNumber("summary count", count, line)
+			}
+
+			c.log.Trace("Found summary line",
+				slog.String("target", section.target),
+				slog.String("line", line),
+				slog.String("pattern", match.Pattern),
+				slog.Int("warnings", match.Warnings),
+				slog.Int("errors", match.Errors),
+			)
+
+			continue
+		}
+
+		if looksLikeSummary(line) {
+			c.log.Debug("Summary-like line did not match any summary pattern", slog.String("line", line))
+			continue
+		}
+
+		// Page names set the indentation continuation lines must exceed
+		if isPageName(line) {
+			cont.pageIndent = indentWidth(lines[i])
+		}
+	}
+
+	result.ErrorMessages = messageTexts(result.Messages, SeverityError)
+	result.WarningMessages = messageTexts(result.Messages, SeverityWarning)
+	result.HasErrors = result.Errors > 0 || len(result.ErrorMessages) > 0
+
+	return result
+}
+
+// parseSizeField converts a size to bytes, logging sizes that cannot be parsed
+func (c *Compiler) parseSizeField(field, value string) int64 {
+	bytes, number, err := parseSize(value)
+	if err != nil {
+		c.log.Debug("Could not parse size", slog.String("field", field), slog.Any("error", err))
+		return 0
+	}
+
+	c.checkStrictNumber(field, number, value)
+
+	return bytes
+}
+
+// checkStrictNumber warns about a number that is not a plain English number when parsing is strict
+func (c *Compiler) checkStrictNumber(field, number, line string) {
+	if !c.parser.Strict || isEnglishNumber(number) {
+		return
+	}
+
+	c.log.Warn("Unexpected characters in number",
+		slog.String("field", field),
+		slog.String("number", number),
+		slog.String("line", line),
+	)
+}
+
+// continuationRules decides which lines following a message are wrapped parts of it
+type continuationRules struct {
+	max        int
+	pageIndent int // Indentation of the most recent page-name line
+	patterns   []SummaryPattern
+}
+
+// collectContinuations appends wrapped continuation lines following lines[i] to msg.
+// It returns the completed message and the index of the last line consumed.
+func collectContinuations(lines []string, i int, msg string, rules continuationRules) (string, int) {
+	continuations := 0
+
+	for i+1 < len(lines) && continuations < rules.max {
+		if !rules.isContinuation(lines[i+1]) {
+			break
+		}
+
+		i++
+		continuations++
+		msg += " " + strings.TrimSpace(lines[i])
+	}
+
+	return msg, i
+}
+
+// isContinuation reports whether line is a wrapped part of the preceding message.
+// Continuations are indented deeper than page names, carry no marker and are not
+// themselves a page name or summary line.
+func (r continuationRules) isContinuation(line string) bool {
+	if strings.Contains(line, "[ error ]") ||
+		strings.Contains(line, "[ warning ]") ||
+		strings.Contains(line, "[ size ]") ||
+		strings.Contains(line, "[ project size ]") ||
+		strings.Contains(line, "----------") ||
+		strings.Contains(line, "warning(s)") ||
+		strings.TrimSpace(line) == "" {
+		return false
+	}
+
+	if indentWidth(line) <= r.pageIndent {
+		return false
+	}
+
+	if isPageName(strings.TrimSpace(line)) {
+		return false
+	}
+
+	_, ok := matchSummary(line, r.patterns)
+
+	return !ok
+}
+
+// isPageName reports whether a trimmed line is a bare page identifier
+func isPageName(line string) bool {
+	return pageNameRegex.MatchString(line)
+}
+
+// indentWidth returns the width of the leading whitespace, counting tabs as tabWidth columns
+func indentWidth(line string) int {
+	width := 0
+
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += tabWidth
+		default:
+			return width
+		}
+	}
+
+	return width
+}
@@ -495,7 +495,9 @@ Boot
 	result := &CompileResult{}
 	c.parseVTProOutput(output, result)
 
-	assert.Len(t, result.WarningMessages, 1)
+	// The summary line disagrees with the message, so a count mismatch warning follows it
+	assert.Len(t, result.WarningMessages, 2)
+	assert.Contains(t, result.WarningMessages[1], countMismatchWarning)
 	// Should stop at the summary line
 	assert.Equal(t, "Warning message starts continues here", result.WarningMessages[0])
 	assert.NotContains(t, result.WarningMessages[0], "warning(s)")
@@ -0,0 +1,279 @@
+package compiler
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Action is what a policy does to the messages it matches
+type Action string
+
+const (
+	ActionError   Action = "error"   // Report the message as an error
+	ActionWarning Action = "warning" // Report the message as a warning
+	ActionIgnore  Action = "ignore"  // Drop the message and its count
+)
+
+// rank orders actions for equally specific policies: failing the run beats
+// hiding a message, which beats leaving it a warning
+func (a Action) rank() int {
+	switch a {
+	case ActionError:
+		return 3
+	case ActionIgnore:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// messageObjectRe and messagePageRe pick the object and page names out of
+// messages like `Object "Volume" on Page "Main" has ...`
+var (
+	messageObjectRe = regexp.MustCompile(`(?i)\bObject "([^"]*)"`)
+	messagePageRe   = regexp.MustCompile(`(?i)\bPage "([^"]*)"`)
+)
+
+// PolicyEntry changes how the messages of one rule are reported. Pages and
+// Objects are case-insensitive glob patterns; when either is set, a message
+// must name a matching page or object for the entry to apply.
+type PolicyEntry struct {
+	RuleID  string
+	Pages   []string
+	Objects []string
+	Action  Action
+}
+
+// specificity counts the filters an entry has, so narrower entries win
+func (e PolicyEntry) specificity() int {
+	n := 0
+	if len(e.Pages) > 0 {
+		n++
+	}
+
+	if len(e.Objects) > 0 {
+		n++
+	}
+
+	return n
+}
+
+// String describes the entry for the reclassification audit trail,
+// e.g. "unassigned-smart-object-id pages=Debug*: ignore"
+func (e PolicyEntry) String() string {
+	var b strings.Builder
+	b.WriteString(e.RuleID)
+
+	if len(e.Pages) > 0 {
+		fmt.Fprintf(&b, " pages=%s", strings.Join(e.Pages, ","))
+	}
+
+	if len(e.Objects) > 0 {
+		fmt.Fprintf(&b, " objects=%s", strings.Join(e.Objects, ","))
+	}
+
+	fmt.Fprintf(&b, ": %s", e.Action)
+
+	return b.String()
+}
+
+// matches reports whether the entry applies to a message
+func (e PolicyEntry) matches(m Message) bool {
+	if m.RuleID != e.RuleID {
+		return false
+	}
+
+	if len(e.Pages) > 0 && !matchAnyGlob(e.Pages, m.Page()) {
+		return false
+	}
+
+	if len(e.Objects) > 0 && !matchAnyGlob(e.Objects, m.Object()) {
+		return false
+	}
+
+	return true
+}
+
+// Reclassification records a message a policy changed, for the audit trail
+type Reclassification struct {
+	Message Message     // The message as VTPro reported it
+	Action  Action      // What the policy did to it
+	Policy  PolicyEntry // The entry that decided
+}
+
+// Policy re-buckets parsed messages by rule, page and object.
+// When several entries match a message, the most specific one decides, and
+// between equally specific entries error beats ignore beats warning.
+type Policy struct {
+	entries []PolicyEntry
+}
+
+// NewPolicy validates the entries and builds a Policy from them
+func NewPolicy(entries []PolicyEntry) (*Policy, error) {
+	for i, e := range entries {
+		if e.RuleID == "" {
+			return nil, fmt.Errorf("policy %d has no rule", i+1)
+		}
+
+		switch e.Action {
+		case ActionError, ActionWarning, ActionIgnore:
+		default:
+			return nil, fmt.Errorf("rule %q: invalid action %q: must be \"error\", \"warning\" or \"ignore\"", e.RuleID, e.Action)
+		}
+
+		for _, pattern := range append(append([]string(nil), e.Pages...), e.Objects...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("rule %q: invalid glob %q: %w", e.RuleID, pattern, err)
+			}
+		}
+	}
+
+	return &Policy{entries: entries}, nil
+}
+
+// Decide returns the entry that applies to a message, if any
+func (p *Policy) Decide(m Message) (PolicyEntry, bool) {
+	var (
+		best  PolicyEntry
+		found bool
+	)
+
+	for _, e := range p.entries {
+		if !e.matches(m) {
+			continue
+		}
+
+		if !found ||
+			e.specificity() > best.specificity() ||
+			e.specificity() == best.specificity() && e.Action.rank() > best.Action.rank() {
+			best = e
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+// Apply re-buckets the messages of a parsed result and adjusts its counts to
+// match. A message moved between warning and error moves one count with it,
+// and an ignored message takes one count away. Messages vtpc raised itself
+// are left alone. It returns the messages it changed, in log order.
+func (p *Policy) Apply(result *CompileResult) []Reclassification {
+	if p == nil || len(p.entries) == 0 {
+		return nil
+	}
+
+	var changes []Reclassification
+
+	for i := range result.Sections {
+		changes = append(changes, p.applySection(&result.Sections[i])...)
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	result.sumSections()
+
+	return changes
+}
+
+// applySection applies the policy to one target's messages and counts
+func (p *Policy) applySection(s *TargetResult) []Reclassification {
+	var (
+		changes []Reclassification
+		kept    = make([]Message, 0, len(s.Messages))
+	)
+
+	for _, m := range s.Messages {
+		entry, ok := p.Decide(m)
+		if !ok || m.Index < 0 || entry.Action == ActionError && m.Severity == SeverityError ||
+			entry.Action == ActionWarning && m.Severity == SeverityWarning {
+			kept = append(kept, m)
+			continue
+		}
+
+		changes = append(changes, Reclassification{Message: m, Action: entry.Action, Policy: entry})
+		s.uncount(m.Severity)
+
+		switch entry.Action {
+		case ActionError:
+			m.Severity = SeverityError
+			s.Errors++
+		case ActionWarning:
+			m.Severity = SeverityWarning
+			s.Warnings++
+		case ActionIgnore:
+			continue
+		}
+
+		kept = append(kept, m)
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	s.Messages = kept
+	s.ErrorMessages = messageTexts(kept, SeverityError)
+	s.WarningMessages = messageTexts(kept, SeverityWarning)
+	s.HasErrors = s.Errors > 0 || len(s.ErrorMessages) > 0
+
+	return changes
+}
+
+// sumSections rebuilds the result's totals and messages from its sections,
+// as parseVTProOutput does, after a pass has changed them
+func (r *CompileResult) sumSections() {
+	r.Warnings, r.Errors, r.HasErrors = 0, 0, false
+	r.Messages = nil
+
+	for _, s := range r.Sections {
+		r.Warnings += s.Warnings
+		r.Errors += s.Errors
+		r.Messages = append(r.Messages, s.Messages...)
+		r.HasErrors = r.HasErrors || s.HasErrors
+	}
+
+	r.ErrorMessages = messageTexts(r.Messages, SeverityError)
+	r.WarningMessages = messageTexts(r.Messages, SeverityWarning)
+}
+
+// uncount takes one message of the given severity off the target's counts,
+// which may already be lower than the messages found if the log was cut off
+func (t *TargetResult) uncount(severity Severity) {
+	if severity == SeverityError {
+		t.Errors = max(t.Errors-1, 0)
+	} else {
+		t.Warnings = max(t.Warnings-1, 0)
+	}
+}
+
+// captured returns the first group of re in text, or "" if it does not match
+func captured(re *regexp.Regexp, text string) string {
+	if m := re.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+
+	return ""
+}
+
+// matchAnyGlob reports whether name matches any of the case-insensitive globs.
+// An empty name matches nothing, since the message did not say.
+func matchAnyGlob(globs []string, name string) bool {
+	if name == "" {
+		return false
+	}
+
+	name = strings.ToLower(name)
+
+	for _, g := range globs {
+		if ok, _ := path.Match(strings.ToLower(g), name); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,332 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// policyMessage builds a parsed message about an object on a page
+func policyMessage(severity Severity, ruleID, object, page string) Message {
+	return Message{
+		Index:    0,
+		Severity: severity,
+		Text:     `Object "` + object + `" on Page "` + page + `" has a problem.`,
+		Target:   "TSW-770",
+		RuleID:   ruleID,
+	}
+}
+
+func mustPolicy(t *testing.T, entries ...PolicyEntry) *Policy {
+	t.Helper()
+
+	p, err := NewPolicy(entries)
+	require.NoError(t, err)
+
+	return p
+}
+
+func TestPolicy_Decide(t *testing.T) {
+	t.Parallel()
+
+	const soid = "unassigned-smart-object-id"
+
+	tests := []struct {
+		name    string
+		entries []PolicyEntry
+		msg     Message
+		want    Action // "" means no entry applies
+	}{
+		{
+			name:    "rule only",
+			entries: []PolicyEntry{{RuleID: "path-length-warning", Action: ActionError}},
+			msg:     Message{RuleID: "path-length-warning", Text: `The file path C:\x.png exceeds the windows path limitations`},
+			want:    ActionError,
+		},
+		{
+			name:    "other rule",
+			entries: []PolicyEntry{{RuleID: "path-length-warning", Action: ActionError}},
+			msg:     policyMessage(SeverityWarning, soid, "Volume", "Main"),
+		},
+		{
+			name:    "page glob matches",
+			entries: []PolicyEntry{{RuleID: soid, Pages: []string{"Debug*"}, Action: ActionIgnore}},
+			msg:     policyMessage(SeverityWarning, soid, "Volume", "Debug_Audio"),
+			want:    ActionIgnore,
+		},
+		{
+			name:    "page glob is case-insensitive",
+			entries: []PolicyEntry{{RuleID: soid, Pages: []string{"debug*"}, Action: ActionIgnore}},
+			msg:     policyMessage(SeverityWarning, soid, "Volume", "DEBUG_Audio"),
+			want:    ActionIgnore,
+		},
+		{
+			name:    "page glob does not match",
+			entries: []PolicyEntry{{RuleID: soid, Pages: []string{"Debug*"}, Action: ActionIgnore}},
+			msg:     policyMessage(SeverityWarning, soid, "Volume", "Main"),
+		},
+		{
+			name:    "any of several page globs",
+			entries: []PolicyEntry{{RuleID: soid, Pages: []string{"Debug*", "Test?"}, Action: ActionIgnore}},
+			msg:     policyMessage(SeverityWarning, soid, "Volume", "Test1"),
+			want:    ActionIgnore,
+		},
+		{
+			name:    "page filter needs a page",
+			entries: []PolicyEntry{{RuleID: "path-length-warning", Pages: []string{"*"}, Action: ActionIgnore}},
+			msg:     Message{RuleID: "path-length-warning", Text: `The file path C:\x.png exceeds the windows path limitations`},
+		},
+		{
+			name:    "object glob matches",
+			entries: []PolicyEntry{{RuleID: soid, Objects: []string{"Spare *"}, Action: ActionIgnore}},
+			msg:     policyMessage(SeverityWarning, soid, "Spare Button 3", "Main"),
+			want:    ActionIgnore,
+		},
+		{
+			name:    "object glob does not match",
+			entries: []PolicyEntry{{RuleID: soid, Objects: []string{"Spare *"}, Action: ActionIgnore}},
+			msg:     policyMessage(SeverityWarning, soid, "Volume", "Main"),
+		},
+		{
+			name:    "page and object must both match",
+			entries: []PolicyEntry{{RuleID: soid, Pages: []string{"Debug*"}, Objects: []string{"Spare *"}, Action: ActionIgnore}},
+			msg:     policyMessage(SeverityWarning, soid, "Spare 1", "Main"),
+		},
+		{
+			name:    "page and object both match",
+			entries: []PolicyEntry{{RuleID: soid, Pages: []string{"Debug*"}, Objects: []string{"Spare *"}, Action: ActionIgnore}},
+			msg:     policyMessage(SeverityWarning, soid, "Spare 1", "Debug"),
+			want:    ActionIgnore,
+		},
+		{
+			name: "page-specific entry beats rule-wide entry",
+			entries: []PolicyEntry{
+				{RuleID: soid, Action: ActionError},
+				{RuleID: soid, Pages: []string{"Debug*"}, Action: ActionIgnore},
+			},
+			msg:  policyMessage(SeverityWarning, soid, "Volume", "Debug"),
+			want: ActionIgnore,
+		},
+		{
+			name: "rule-wide entry applies off the filtered pages",
+			entries: []PolicyEntry{
+				{RuleID: soid, Action: ActionError},
+				{RuleID: soid, Pages: []string{"Debug*"}, Action: ActionIgnore},
+			},
+			msg:  policyMessage(SeverityWarning, soid, "Volume", "Main"),
+			want: ActionError,
+		},
+		{
+			name: "page and object entry beats page entry",
+			entries: []PolicyEntry{
+				{RuleID: soid, Pages: []string{"*"}, Action: ActionError},
+				{RuleID: soid, Pages: []string{"*"}, Objects: []string{"Spare*"}, Action: ActionWarning},
+			},
+			msg:  policyMessage(SeverityWarning, soid, "Spare", "Main"),
+			want: ActionWarning,
+		},
+		{
+			name: "error beats ignore at equal specificity",
+			entries: []PolicyEntry{
+				{RuleID: soid, Pages: []string{"Main"}, Action: ActionIgnore},
+				{RuleID: soid, Objects: []string{"Volume"}, Action: ActionError},
+			},
+			msg:  policyMessage(SeverityWarning, soid, "Volume", "Main"),
+			want: ActionError,
+		},
+		{
+			name: "ignore beats warning at equal specificity",
+			entries: []PolicyEntry{
+				{RuleID: soid, Action: ActionWarning},
+				{RuleID: soid, Action: ActionIgnore},
+			},
+			msg:  policyMessage(SeverityError, soid, "Volume", "Main"),
+			want: ActionIgnore,
+		},
+		{
+			name:    "unknown messages can be targeted",
+			entries: []PolicyEntry{{RuleID: RuleUnknown, Pages: []string{"Debug"}, Action: ActionIgnore}},
+			msg:     policyMessage(SeverityWarning, RuleUnknown, "Logo", "Debug"),
+			want:    ActionIgnore,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			entry, ok := mustPolicy(t, tt.entries...).Decide(tt.msg)
+			if tt.want == "" {
+				assert.False(t, ok, "no entry should apply, got %s", entry)
+				return
+			}
+
+			require.True(t, ok)
+			assert.Equal(t, tt.want, entry.Action)
+		})
+	}
+}
+
+func TestNewPolicy_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]PolicyEntry{
+		"no rule":           {Action: ActionIgnore},
+		"no action":         {RuleID: "missing-join"},
+		"unknown action":    {RuleID: "missing-join", Action: "fatal"},
+		"bad page glob":     {RuleID: "missing-join", Pages: []string{"Debug["}, Action: ActionIgnore},
+		"bad object glob":   {RuleID: "missing-join", Objects: []string{"[a-"}, Action: ActionIgnore},
+		"action wrong case": {RuleID: "missing-join", Action: "Error"},
+	}
+
+	for name, entry := range tests {
+		_, err := NewPolicy([]PolicyEntry{entry})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestPolicyEntry_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "path-length-warning: error", PolicyEntry{RuleID: "path-length-warning", Action: ActionError}.String())
+	assert.Equal(t, "unassigned-smart-object-id pages=Debug*,Test objects=Spare*: ignore", PolicyEntry{
+		RuleID:  "unassigned-smart-object-id",
+		Pages:   []string{"Debug*", "Test"},
+		Objects: []string{"Spare*"},
+		Action:  ActionIgnore,
+	}.String())
+}
+
+// policyLog has one target with two Smart Object warnings (one on a debug
+// page), a long-path warning and a join error
+const policyLog = `---------- Compiling for TSW-770: [C:\Projects\Lobby\lobby.vtp] ---------
+Main
+	[ warning ]: Object "Volume" on Page "Main" has an unassigned Smart Object ID.
+	[ warning ]: The file path C:\Projects\Lobby\Graphics\background.png exceeds the windows path limitations and may cause issues during compilation or deployment.
+Debug_Audio
+	[ warning ]: Object "Meter" on Page "Debug_Audio" has an unassigned Smart Object ID.
+	[ error ]: Object "Mute" on Page "Debug_Audio" has an invalid join number.
+---------- Failed ---------
+3 warning(s), 1 error(s)`
+
+func parseWithPolicy(t *testing.T, entries ...PolicyEntry) *CompileResult {
+	t.Helper()
+
+	c := NewCompiler(logger.NewNoOpLogger(), WithParser(ParserOptions{Policy: mustPolicy(t, entries...)}))
+
+	result := &CompileResult{}
+	c.parseVTProOutput(policyLog, result)
+
+	return result
+}
+
+func TestPolicy_ApplyPromotesWarningToError(t *testing.T) {
+	t.Parallel()
+
+	result := parseWithPolicy(t, PolicyEntry{RuleID: "path-length-warning", Action: ActionError})
+
+	assert.Equal(t, 2, result.Warnings)
+	assert.Equal(t, 2, result.Errors)
+	assert.True(t, result.HasErrors)
+	assert.Len(t, result.ErrorMessages, 2)
+	assert.Contains(t, result.ErrorMessages[0], "exceeds the windows path limitations")
+	assert.Equal(t, SeverityError, result.Messages[1].Severity, "the message stays in log order")
+
+	require.Len(t, result.Sections, 1)
+	assert.Equal(t, 2, result.Sections[0].Warnings)
+	assert.Equal(t, 2, result.Sections[0].Errors)
+
+	require.Len(t, result.Reclassified, 1)
+	r := result.Reclassified[0]
+	assert.Equal(t, SeverityWarning, r.Message.Severity, "the audit trail keeps the original severity")
+	assert.Equal(t, ActionError, r.Action)
+	assert.Equal(t, "path-length-warning: error", r.Policy.String())
+}
+
+func TestPolicy_ApplyIgnoresOnMatchingPages(t *testing.T) {
+	t.Parallel()
+
+	result := parseWithPolicy(t, PolicyEntry{
+		RuleID: "unassigned-smart-object-id",
+		Pages:  []string{"Debug*"},
+		Action: ActionIgnore,
+	})
+
+	assert.Equal(t, 2, result.Warnings)
+	assert.Equal(t, 1, result.Errors)
+	assert.Len(t, result.Messages, 3)
+	assert.NotContains(t, result.WarningMessages, `Object "Meter" on Page "Debug_Audio" has an unassigned Smart Object ID.`)
+	assert.Contains(t, result.WarningMessages, `Object "Volume" on Page "Main" has an unassigned Smart Object ID.`)
+	require.Len(t, result.Reclassified, 1)
+	assert.Equal(t, ActionIgnore, result.Reclassified[0].Action)
+}
+
+func TestPolicy_ApplyIgnoringOnlyErrorPassesTheRun(t *testing.T) {
+	t.Parallel()
+
+	result := parseWithPolicy(t, PolicyEntry{RuleID: "missing-join", Pages: []string{"Debug*"}, Action: ActionIgnore})
+
+	assert.Equal(t, 0, result.Errors)
+	assert.Empty(t, result.ErrorMessages)
+	assert.False(t, result.HasErrors)
+	assert.False(t, result.Sections[0].HasErrors)
+}
+
+func TestPolicy_ApplyDemotesErrorToWarning(t *testing.T) {
+	t.Parallel()
+
+	result := parseWithPolicy(t, PolicyEntry{RuleID: "missing-join", Action: ActionWarning})
+
+	assert.Equal(t, 4, result.Warnings)
+	assert.Equal(t, 0, result.Errors)
+	assert.False(t, result.HasErrors)
+	assert.Len(t, result.WarningMessages, 4)
+}
+
+func TestPolicy_ApplyLeavesMatchingSeverityAlone(t *testing.T) {
+	t.Parallel()
+
+	// Warnings that are already warnings are not reclassified
+	result := parseWithPolicy(t, PolicyEntry{RuleID: "unassigned-smart-object-id", Action: ActionWarning})
+
+	assert.Equal(t, 3, result.Warnings)
+	assert.Equal(t, 1, result.Errors)
+	assert.Empty(t, result.Reclassified)
+}
+
+func TestPolicy_ApplySkipsVtpcWarnings(t *testing.T) {
+	t.Parallel()
+
+	result := &CompileResult{}
+	addWarning(result, "vtpc: something vtpc noticed")
+	result.Sections = []TargetResult{{Messages: result.Messages}}
+
+	changes := mustPolicy(t, PolicyEntry{RuleID: RuleUnknown, Action: ActionError}).Apply(result)
+	assert.Empty(t, changes)
+	assert.False(t, result.HasErrors)
+}
+
+func TestPolicy_ApplyNeverCountsBelowZero(t *testing.T) {
+	t.Parallel()
+
+	// A cut-off log can list more messages than the summary counted
+	result := &CompileResult{Sections: []TargetResult{{
+		Messages: []Message{policyMessage(SeverityWarning, "missing-join", "Mute", "Main")},
+	}}}
+
+	mustPolicy(t, PolicyEntry{RuleID: "missing-join", Action: ActionIgnore}).Apply(result)
+	assert.Equal(t, 0, result.Warnings)
+	assert.Empty(t, result.Messages)
+}
+
+func TestPolicy_NilAppliesNothing(t *testing.T) {
+	t.Parallel()
+
+	var p *Policy
+	result := &CompileResult{Warnings: 1}
+	assert.Empty(t, p.Apply(result))
+	assert.Equal(t, 1, result.Warnings)
+}
@@ -2,10 +2,12 @@ package compiler_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/windows"
 )
 
 func TestCompileResult_HasErrors(t *testing.T) {
@@ -116,3 +118,27 @@ func TestCompileOptions_VTProPidPtr(t *testing.T) {
 	*opts.VTProPidPtr = 12345
 	assert.Equal(t, uint32(12345), pid)
 }
+
+func TestCompileResult_AttachMonitorStats(t *testing.T) {
+	healthy := windows.MonitorStats{Interval: 100 * time.Millisecond, Polls: 20, EventsDropped: 1}
+
+	result := &compiler.CompileResult{}
+	result.AttachMonitorStats(healthy)
+
+	assert.Equal(t, healthy, result.Monitor)
+	assert.Empty(t, result.WarningMessages, "dropping a non-dialog event is not worth a warning")
+
+	result = &compiler.CompileResult{Warnings: 2}
+	result.AttachMonitorStats(windows.MonitorStats{
+		Interval:        100 * time.Millisecond,
+		DroppedCritical: 1,
+		SlowPolls:       3,
+		MaxPollDuration: 400 * time.Millisecond,
+	})
+
+	assert.Len(t, result.WarningMessages, 2)
+	assert.Equal(t, 2, result.Warnings, "vtpc warnings are not added to VTPro's count")
+	for _, msg := range result.Messages {
+		assert.Equal(t, -1, msg.Index)
+	}
+}
@@ -0,0 +1,209 @@
+package compiler
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleUnknown is the rule ID of a message no rule matches
+const RuleUnknown = "unknown"
+
+// unknownRule is what Classify returns for a message no rule matches
+var unknownRule = Rule{
+	ID:          RuleUnknown,
+	Title:       "Unrecognized message",
+	Description: "The message did not match any of vtpc's rules, so it has no specific guidance.",
+	Cause:       "VTPro reported something vtpc has not been taught to recognize yet.",
+	Remediation: []string{
+		"Read the message text in the Message Log for the object and page it names.",
+		"If it comes up often, add a rule for it to rules.yaml so it gets its own ID.",
+	},
+}
+
+//go:embed rules.yaml
+var defaultRulesYAML []byte
+
+// defaultRules classifies every parsed message
+var defaultRules = mustLoadRules(defaultRulesYAML)
+
+// Rule is a kind of Message Log warning or error, such as an unassigned Smart Object ID
+type Rule struct {
+	ID          string   `yaml:"id"`
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description"` // What the message means
+	Cause       string   `yaml:"cause"`       // What typically leads to it
+	Remediation []string `yaml:"remediation"` // Steps to fix it, in order
+	Patterns    []string `yaml:"patterns"`
+
+	compiled []*regexp.Regexp
+}
+
+// RuleSet maps message text to rules, trying each rule in order
+type RuleSet struct {
+	rules []Rule
+}
+
+// LoadRules parses a rule table in the format of the embedded rules.yaml
+func LoadRules(data []byte) (*RuleSet, error) {
+	var table struct {
+		Rules []Rule `yaml:"rules"`
+	}
+
+	if err := yaml.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("invalid rule table: %w", err)
+	}
+
+	seen := make(map[string]bool)
+
+	for i := range table.Rules {
+		r := &table.Rules[i]
+
+		switch {
+		case r.ID == "":
+			return nil, fmt.Errorf("rule %d has no id", i+1)
+		case r.ID == RuleUnknown:
+			return nil, fmt.Errorf("rule id %q is reserved", RuleUnknown)
+		case seen[r.ID]:
+			return nil, fmt.Errorf("rule id %q is used more than once", r.ID)
+		case len(r.Patterns) == 0:
+			return nil, fmt.Errorf("rule %q has no patterns", r.ID)
+		}
+
+		seen[r.ID] = true
+
+		for _, p := range r.Patterns {
+			re, err := regexp.Compile("(?i)" + p)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: invalid pattern %q: %w", r.ID, p, err)
+			}
+
+			r.compiled = append(r.compiled, re)
+		}
+	}
+
+	if len(table.Rules) == 0 {
+		return nil, errors.New("rule table has no rules")
+	}
+
+	return &RuleSet{rules: table.Rules}, nil
+}
+
+// mustLoadRules is LoadRules for the embedded table, which is covered by tests
+func mustLoadRules(data []byte) *RuleSet {
+	rules, err := LoadRules(data)
+	if err != nil {
+		panic(err)
+	}
+
+	return rules
+}
+
+// DefaultRules returns the rule set built from the embedded rules.yaml
+func DefaultRules() *RuleSet {
+	return defaultRules
+}
+
+// Classify returns the first rule matching text, or the unknown rule
+func (s *RuleSet) Classify(text string) Rule {
+	for _, r := range s.rules {
+		for _, re := range r.compiled {
+			if re.MatchString(text) {
+				return r
+			}
+		}
+	}
+
+	return unknownRule
+}
+
+// Rules returns the rules in the order they are tried
+func (s *RuleSet) Rules() []Rule {
+	return append([]Rule(nil), s.rules...)
+}
+
+// Lookup returns the rule with the given ID, including the unknown rule
+func (s *RuleSet) Lookup(id string) (Rule, bool) {
+	if id == RuleUnknown {
+		return unknownRule, true
+	}
+
+	for _, r := range s.rules {
+		if r.ID == id {
+			return r, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// maxSuggestions caps how many close matches Suggest returns
+const maxSuggestions = 3
+
+// Suggest returns the rule IDs closest to id by edit distance, nearest first,
+// for an ID that does not exist. IDs more than a third of id's length away
+// (and at least two edits) are too different to be what was meant.
+func (s *RuleSet) Suggest(id string) []string {
+	id = strings.ToLower(id)
+	limit := max(len(id)/3, 2)
+
+	type candidate struct {
+		id       string
+		distance int
+	}
+
+	var candidates []candidate
+
+	for _, r := range append(s.Rules(), unknownRule) {
+		if d := editDistance(id, r.ID); d <= limit {
+			candidates = append(candidates, candidate{id: r.ID, distance: d})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+
+		return candidates[i].id < candidates[j].id
+	})
+
+	var ids []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		ids = append(ids, candidates[i].id)
+	}
+
+	return ids
+}
+
+// editDistance returns the Levenshtein distance between a and b, counted in bytes
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,225 @@
+package compiler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+func TestDefaultRules_Classify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`Object "Volume" on Page "Main" has an unassigned Smart Object ID.`, "unassigned-smart-object-id"},
+		{`Object "Volume" on Page "Main" HAS AN UNASSIGNED SMART OBJECT ID.`, "unassigned-smart-object-id"},
+		{`The file path C:\Projects\boardroom_background.png exceeds the windows path limitations and may cause issues during compilation or deployment.`, "path-length-warning"},
+		{`The path name is too long: C:\Projects\x.png`, "path-length-warning"},
+		{`Object "Mute" on Page "Audio_Presets" has an invalid join number.`, "missing-join"},
+		{`Join 12 is undefined`, "missing-join"},
+		{`Object "Power" on Page "Main" has no digital join.`, "missing-join"},
+		{`Duplicate digital join 21 on Page "Main".`, "duplicate-join"},
+		{`Join number 40 is used more than once on Page "Lighting".`, "duplicate-join"},
+		{`Image "background.png" is too large for the panel.`, "oversized-image"},
+		{`The image splash.png exceeds the maximum size of 4096x4096.`, "oversized-image"},
+		{`Object "Logo" on Page "Main" references a missing image.`, RuleUnknown},
+		{`Something VTPro has never said before.`, RuleUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, DefaultRules().Classify(tt.text).ID, tt.text)
+		})
+	}
+}
+
+func TestDefaultRules_EveryRuleHasATitle(t *testing.T) {
+	t.Parallel()
+
+	ids := []string{}
+	for _, r := range DefaultRules().Rules() {
+		assert.NotEmpty(t, r.Title, r.ID)
+		ids = append(ids, r.ID)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"unassigned-smart-object-id",
+		"path-length-warning",
+		"missing-join",
+		"duplicate-join",
+		"oversized-image",
+	}, ids)
+
+	assert.Equal(t, "Unrecognized message", DefaultRules().Classify("").Title)
+}
+
+func TestDefaultRules_EveryRuleHasGuidance(t *testing.T) {
+	t.Parallel()
+
+	for _, r := range append(DefaultRules().Rules(), unknownRule) {
+		assert.NotEmpty(t, r.Description, r.ID)
+		assert.NotEmpty(t, r.Cause, r.ID)
+		assert.NotEmpty(t, r.Remediation, r.ID)
+	}
+}
+
+func TestRuleSet_Lookup(t *testing.T) {
+	t.Parallel()
+
+	rule, ok := DefaultRules().Lookup("duplicate-join")
+	require.True(t, ok)
+	assert.Equal(t, "Duplicate join number", rule.Title)
+
+	rule, ok = DefaultRules().Lookup(RuleUnknown)
+	require.True(t, ok)
+	assert.Equal(t, "Unrecognized message", rule.Title)
+
+	_, ok = DefaultRules().Lookup("no-such-rule")
+	assert.False(t, ok)
+}
+
+func TestRuleSet_Suggest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		id   string
+		want []string
+	}{
+		{id: "dupliate-join", want: []string{"duplicate-join"}},
+		{id: "MISING-JOIN", want: []string{"missing-join"}},
+		{id: "unassigned-smart-object", want: []string{"unassigned-smart-object-id"}},
+		{id: "unknwn", want: []string{"unknown"}},
+		{id: "frobnicate", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, DefaultRules().Suggest(tt.id))
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, editDistance("join", "join"))
+	assert.Equal(t, 1, editDistance("join", "joins"))
+	assert.Equal(t, 2, editDistance("join", "jion"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+	assert.Equal(t, 4, editDistance("", "join"))
+}
+
+func TestLoadRules_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"not yaml":          "rules: [",
+		"no rules":          "rules: []",
+		"missing id":        "rules:\n  - title: x\n    patterns: ['x']",
+		"reserved id":       "rules:\n  - id: unknown\n    patterns: ['x']",
+		"duplicate id":      "rules:\n  - id: a\n    patterns: ['x']\n  - id: a\n    patterns: ['y']",
+		"no patterns":       "rules:\n  - id: a",
+		"bad regexp":        "rules:\n  - id: a\n    patterns: ['(']",
+		"patterns not list": "rules:\n  - id: a\n    patterns: 3",
+	}
+
+	for name, data := range tests {
+		_, err := LoadRules([]byte(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoadRules_FirstMatchWins(t *testing.T) {
+	t.Parallel()
+
+	rules, err := LoadRules([]byte(`
+rules:
+  - id: specific
+    title: Specific
+    patterns: ['join 12']
+  - id: general
+    title: General
+    patterns: ['join']
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "specific", rules.Classify("Join 12 is undefined").ID)
+	assert.Equal(t, "general", rules.Classify("Join 13 is undefined").ID)
+}
+
+func TestParseVTProOutput_SetsRuleIDs(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	result := &CompileResult{}
+	c.parseVTProOutput(readFixture(t, "page_names_after_message.log"), result)
+
+	require.Len(t, result.Messages, 2)
+	assert.Equal(t, "unassigned-smart-object-id", result.Messages[0].RuleID, "wrapped lines are joined before classifying")
+	assert.Equal(t, "missing-join", result.Messages[1].RuleID)
+}
+
+// minCorpusCoverage is the fraction of fixture messages that must match a
+// rule; lower it only together with a reason in the rule table
+const minCorpusCoverage = 0.9
+
+func TestDefaultRules_CorpusCoverage(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.log"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	c := NewCompiler(logger.NewNoOpLogger())
+	counts := make(map[string]int)
+	unknown := make(map[string]bool)
+	total := 0
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		result := &CompileResult{}
+		c.parseVTProOutput(string(data), result)
+
+		for _, m := range result.Messages {
+			total++
+			counts[m.RuleID]++
+
+			if m.RuleID == RuleUnknown {
+				unknown[m.Text] = true
+			}
+		}
+	}
+
+	require.Positive(t, total)
+
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var stats strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&stats, "\n  %s: %d", id, counts[id])
+	}
+
+	coverage := float64(total-counts[RuleUnknown]) / float64(total)
+	t.Logf("%d corpus messages, %.1f%% classified:%s", total, coverage*100, stats.String())
+
+	for text := range unknown {
+		t.Logf("unclassified: %s", text)
+	}
+
+	assert.GreaterOrEqual(t, coverage, minCorpusCoverage)
+}
@@ -0,0 +1,167 @@
+package compiler
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/timeouts"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// ErrSaveFailed is returned when --save-first could not save the project
+var ErrSaveFailed = errcode.New(errcode.SaveFailed, "save failed")
+
+// saveFailureMarkers are phrases in a save dialog's text that mean the save did not succeed
+var saveFailureMarkers = []string{
+	"unable to save",
+	"could not save",
+	"cannot save",
+	"failed to save",
+	"save failed",
+	"access is denied",
+	"read-only",
+	"disk full",
+}
+
+// overwriteMarkers are phrases in a save dialog's text that ask to overwrite an existing file
+var overwriteMarkers = []string{
+	"overwrite",
+	"replace",
+	"already exists",
+}
+
+// confirmButtons are the buttons tried, in order, to confirm an overwrite prompt
+var confirmButtons = []string{"&Yes", "Yes", "OK"}
+
+// saveProject sends Ctrl+S to VTPro and handles any save dialog that appears.
+// It returns an error if the keystroke could not be sent or the save dialog reports a failure.
+func (c *Compiler) saveProject(opts CompileOptions, misdirected *int) error {
+	c.log.Info("Saving project before compiling...")
+
+	if err := c.sendKeystroke(opts.Hwnd, opts.VTProPid, "Ctrl+S", c.keyboard.SendCtrlS, misdirected); err != nil {
+		return fmt.Errorf("%w: %w", ErrSaveFailed, err)
+	}
+
+	// A quick save shows no dialog at all, so a timeout here means the save went through
+	ev, ok := c.windowMgr.WaitOnMonitor(timeouts.SaveDialogTimeout, c.isSaveDialog)
+	if !ok {
+		c.log.Debug("No save dialog appeared - project saved")
+		return nil
+	}
+
+	text := c.eventText(ev)
+	c.log.Debug("Save dialog appeared",
+		slog.String("title", ev.DisplayTitle()),
+		slog.Uint64("hwnd", uint64(ev.Hwnd)),
+		slog.String("text", text),
+	)
+
+	lower := strings.ToLower(text)
+
+	if containsAny(lower, saveFailureMarkers) {
+		c.windowMgr.CloseWindow(ev.Hwnd, ev.DisplayTitle())
+		return fmt.Errorf("%w: %s", ErrSaveFailed, text)
+	}
+
+	if containsAny(lower, overwriteMarkers) {
+		c.log.Debug("Confirming overwrite prompt")
+		if !c.clickFirstButton(ev.Hwnd, confirmButtons) {
+			c.windowMgr.CloseWindow(ev.Hwnd, ev.DisplayTitle())
+			return fmt.Errorf("%w: could not confirm overwrite prompt %q", ErrSaveFailed, text)
+		}
+	}
+
+	// Wait for the dialog (overwrite prompt or save progress) to go away
+	if !c.waitForWindowClosed(ev.Hwnd, timeouts.SaveDialogTimeout) {
+		return fmt.Errorf("%w: %q dialog did not close within %s", ErrSaveFailed, ev.DisplayTitle(), timeouts.SaveDialogTimeout)
+	}
+
+	// The dialog took focus away from the main window
+	c.windowMgr.SetForeground(opts.Hwnd)
+
+	c.log.Debug("Project saved")
+	return nil
+}
+
+// isSaveDialog matches dialogs VTPro shows while saving: save prompts, save
+// progress, and its generic message box when it is still open
+func (c *Compiler) isSaveDialog(ev windows.WindowEvent) bool {
+	lower := strings.ToLower(ev.Title)
+	if strings.Contains(lower, "save") || strings.Contains(lower, "saving") {
+		return true
+	}
+
+	_, known := c.dialogs.Match(dialog.PhaseSave, ev.Title, ev.Class)
+
+	return known && c.windowMgr.IsWindowValid(ev.Hwnd)
+}
+
+// eventText returns the text of a dialog's controls other than buttons, from
+// the monitor's snapshot when it took one, so a dialog that has closed since
+// is still read
+func (c *Compiler) eventText(ev windows.WindowEvent) string {
+	if len(ev.ChildTextSnapshot) > 0 {
+		return strings.Join(ev.ChildTextSnapshot, " ")
+	}
+
+	return c.dialogText(ev.Hwnd)
+}
+
+// dialogText joins the non-empty text of a dialog's child controls, skipping buttons
+func (c *Compiler) dialogText(hwnd uintptr) string {
+	var parts []string
+
+	for _, ci := range c.windowMgr.CollectChildInfos(hwnd) {
+		if ci.ClassName == "Button" {
+			continue
+		}
+
+		if text := strings.TrimSpace(ci.Text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// clickFirstButton clicks the first of the given buttons found in the dialog
+func (c *Compiler) clickFirstButton(hwnd uintptr, buttons []string) bool {
+	for _, b := range buttons {
+		if c.controlReader.FindAndClickButton(hwnd, b) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// waitForWindowClosed polls until hwnd is no longer a valid window or the timeout expires
+func (c *Compiler) waitForWindowClosed(hwnd uintptr, timeout time.Duration) bool {
+	deadline := clock.NewDeadline(c.clock, timeout, c.sleepPolicy)
+
+	for c.windowMgr.IsWindowValid(hwnd) {
+		if expired, err := c.pollDeadline(deadline); err != nil || expired {
+			return false
+		}
+
+		time.Sleep(timeouts.StatePollingInterval)
+	}
+
+	return true
+}
+
+// containsAny reports whether s contains any of the substrings
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+
+	return false
+}
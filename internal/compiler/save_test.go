@@ -0,0 +1,141 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+const successfulLog = "---------- Compiling for TSW-770: [test.vtp] ---------\nBoot\nMain\n---------- Successful ---------\n0 warning(s), 0 error(s)"
+
+// saveFirstOptions are the compile options used by the save-first tests
+var saveFirstOptions = CompileOptions{
+	Hwnd:                          0x9999,
+	VTProPid:                      1234,
+	SkipPreCompilationDialogCheck: true,
+	SaveFirst:                     true,
+}
+
+func TestCompiler_SaveFirst_SavesBeforeCompiling(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(0x9999, windows.ChildInfo{ClassName: "Edit", Text: successfulLog}).
+		WithWindowValid(0x1111, false)
+	mockKbd := testutil.NewMockKeyboardInjector()
+
+	c := NewCompiler(logger.NewNoOpLogger(),
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(mockWin),
+		WithKeyboard(mockKbd),
+		WithControlReader(testutil.NewMockControlReader()),
+	)
+
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: "VisionTools Pro-e Compiling..."},
+	)
+
+	result, err := c.Compile(saveFirstOptions)
+	require.NoError(t, err)
+	assert.False(t, result.HasErrors)
+
+	assert.Equal(t, []string{"Ctrl+S", "F12"}, mockKbd.Keystrokes)
+}
+
+func TestCompiler_SaveFirst_ConfirmsOverwritePrompt(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	mockWin := testutil.NewMockWindowManager().
+		WithWaitResult("Save As", 0x2222, true).
+		WithChildInfosForHwnd(0x2222,
+			windows.ChildInfo{ClassName: "Static", Text: "lobby.vtp already exists. Do you want to replace it?"},
+			windows.ChildInfo{ClassName: "Button", Text: "&Yes"},
+		).
+		WithWindowValid(0x2222, false).
+		WithChildInfosForHwnd(0x9999, windows.ChildInfo{ClassName: "Edit", Text: successfulLog}).
+		WithWindowValid(0x1111, false)
+	mockKbd := testutil.NewMockKeyboardInjector()
+	mockCtrl := testutil.NewMockControlReader().WithFindAndClickButtonResult(true)
+
+	c := NewCompiler(logger.NewNoOpLogger(),
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(mockWin),
+		WithKeyboard(mockKbd),
+		WithControlReader(mockCtrl),
+	)
+
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: "VisionTools Pro-e Compiling..."},
+	)
+
+	_, err := c.Compile(saveFirstOptions)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, mockCtrl.FindButtonCalls)
+	assert.Equal(t, "&Yes", mockCtrl.FindButtonCalls[0])
+	assert.Equal(t, []string{"Ctrl+S", "F12"}, mockKbd.Keystrokes)
+}
+
+func TestCompiler_SaveFirst_AbortsOnSaveFailure(t *testing.T) {
+	mockWin := testutil.NewMockWindowManager().
+		WithWaitResult("VisionTools(R) Pro-e", 0x3333, true).
+		WithChildInfosForHwnd(0x3333,
+			windows.ChildInfo{ClassName: "Static", Text: "Unable to save lobby.vtp. Access is denied."},
+			windows.ChildInfo{ClassName: "Button", Text: "OK"},
+		)
+	mockKbd := testutil.NewMockKeyboardInjector()
+
+	c := NewCompiler(logger.NewNoOpLogger(),
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(mockWin),
+		WithKeyboard(mockKbd),
+		WithControlReader(testutil.NewMockControlReader()),
+	)
+
+	result, err := c.Compile(saveFirstOptions)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Unable to save lobby.vtp. Access is denied.")
+
+	require.NotNil(t, result)
+	assert.True(t, result.HasErrors)
+	assert.Equal(t, []string{err.Error()}, result.ErrorMessages)
+
+	// The compile keystroke is never sent, and the failure dialog is dismissed
+	assert.Equal(t, []string{"Ctrl+S"}, mockKbd.Keystrokes)
+	require.Len(t, mockWin.CloseWindowCalls, 1)
+	assert.Equal(t, uintptr(0x3333), mockWin.CloseWindowCalls[0].Hwnd)
+}
+
+func TestCompiler_SaveFirst_AbortsWhenCtrlSFails(t *testing.T) {
+	mockKbd := testutil.NewMockKeyboardInjector().WithSendCtrlSResult(false)
+
+	c := NewCompiler(logger.NewNoOpLogger(),
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(testutil.NewMockWindowManager()),
+		WithKeyboard(mockKbd),
+		WithControlReader(testutil.NewMockControlReader()),
+	)
+
+	_, err := c.Compile(saveFirstOptions)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Ctrl+S")
+	assert.False(t, mockKbd.SendF12WithSendInputCalled)
+}
+
+func TestCompiler_IsSaveDialog(t *testing.T) {
+	mockWin := testutil.NewMockWindowManager().WithWindowValid(0x4444, false)
+	c := NewCompiler(logger.NewNoOpLogger(), WithWindowManager(mockWin))
+
+	assert.True(t, c.isSaveDialog(windows.WindowEvent{Hwnd: 0x1, Title: "Save As"}))
+	assert.True(t, c.isSaveDialog(windows.WindowEvent{Hwnd: 0x2, Title: "Saving Project..."}))
+	assert.True(t, c.isSaveDialog(windows.WindowEvent{Hwnd: 0x3, Title: "VisionTools(R) Pro-e"}))
+	assert.False(t, c.isSaveDialog(windows.WindowEvent{Hwnd: 0x4444, Title: "VisionTools(R) Pro-e"}), "closed message box is stale")
+	assert.False(t, c.isSaveDialog(windows.WindowEvent{Hwnd: 0x5, Title: "Address Book"}))
+}
@@ -0,0 +1,139 @@
+package compiler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// readFixture loads a Message Log fixture from testdata
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestParseVTProOutput_TwoTargets(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	result := &CompileResult{}
+	c.parseVTProOutput(readFixture(t, "two_targets.log"), result)
+
+	require.Len(t, result.Sections, 2)
+
+	first := result.Sections[0]
+	assert.Equal(t, "TSW-770", first.Target)
+	assert.Equal(t, 1, first.Warnings)
+	assert.Equal(t, 0, first.Errors)
+	assert.False(t, first.HasErrors)
+	assert.Equal(t, "18,588,092 bytes", first.Size)
+	assert.Len(t, first.WarningMessages, 1)
+	assert.Empty(t, first.ErrorMessages)
+
+	second := result.Sections[1]
+	assert.Equal(t, "TSW-1070", second.Target)
+	assert.Equal(t, 2, second.Warnings)
+	assert.Equal(t, 1, second.Errors)
+	assert.True(t, second.HasErrors)
+	assert.Equal(t, "24,101,330 bytes", second.Size)
+	assert.Equal(t, []string{
+		"Object \"Source List\" on Page \"Main\" does not fit the 1280x800 panel resolution.",
+	}, second.ErrorMessages)
+
+	// Top-level totals sum across sections
+	assert.Equal(t, 3, result.Warnings)
+	assert.Equal(t, 1, result.Errors)
+	assert.Len(t, result.WarningMessages, 3)
+	assert.Len(t, result.ErrorMessages, 1)
+	assert.True(t, result.HasErrors, "A failed target fails the whole run")
+
+	assert.Empty(t, result.Size, "each target's output has its own size")
+	assert.Zero(t, result.SizeBytes)
+}
+
+func TestParseVTProOutput_ThreeTargets(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	result := &CompileResult{}
+	c.parseVTProOutput(readFixture(t, "three_targets.log"), result)
+
+	require.Len(t, result.Sections, 3)
+
+	targets := make([]string, 0, len(result.Sections))
+	for _, s := range result.Sections {
+		targets = append(targets, s.Target)
+		assert.False(t, s.HasErrors)
+	}
+
+	assert.Equal(t, []string{"TSW-570", "TSW-770", "TSW-1070"}, targets)
+	assert.Equal(t, 0, result.Sections[0].Warnings)
+	assert.Equal(t, 1, result.Sections[1].Warnings)
+	assert.Equal(t, 2, result.Sections[2].Warnings)
+
+	assert.Equal(t, 3, result.Warnings)
+	assert.Equal(t, 0, result.Errors)
+	assert.Len(t, result.WarningMessages, 3)
+	assert.False(t, result.HasErrors)
+}
+
+func TestParseVTProOutput_SingleTargetHasOneSection(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	output := `---------- Compiling for TSW-770: [test.vtp] ---------
+Boot
+[ size ]: 1,024 bytes
+---------- Successful ---------
+2 warning(s), 0 error(s)`
+
+	result := &CompileResult{}
+	c.parseVTProOutput(output, result)
+
+	require.Len(t, result.Sections, 1)
+	assert.Equal(t, "TSW-770", result.Sections[0].Target)
+	assert.Equal(t, 2, result.Sections[0].Warnings)
+	assert.Equal(t, 2, result.Warnings)
+	assert.Equal(t, "1,024 bytes", result.Size)
+}
+
+func TestParseVTProOutput_NoHeaderIsUntargetedSection(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	result := &CompileResult{}
+	c.parseVTProOutput("0 warning(s), 4 error(s)", result)
+
+	require.Len(t, result.Sections, 1)
+	assert.Equal(t, "", result.Sections[0].Target)
+	assert.Equal(t, 4, result.Errors)
+	assert.True(t, result.HasErrors)
+}
+
+func TestParseSectionHeader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line   string
+		target string
+		ok     bool
+	}{
+		{"---------- Compiling for TSW-770: [test.vtp] ---------", "TSW-770", true},
+		{"  ---------- Compiling for TSW-1070: [C:\\a b\\c.vtp] ---------", "TSW-1070", true},
+		{"---------- Compiling for TST-902 ---------", "TST-902", true},
+		{"---------- Successful ---------", "", false},
+		{"Compiling for TSW-770 without dashes", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		target, ok := parseSectionHeader(tt.line)
+		assert.Equal(t, tt.ok, ok, tt.line)
+		assert.Equal(t, tt.target, target, tt.line)
+	}
+}
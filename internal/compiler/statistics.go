@@ -0,0 +1,149 @@
+package compiler
+
+import (
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// CompileStatistics is what VTPro's statistics dialog showed when the compile ended
+type CompileStatistics struct {
+	Counts      Counts
+	CompileTime time.Duration // 0 if the dialog did not show one
+}
+
+// statLineRegex matches a count in the statistics dialog, e.g. "Program Errors: 2"
+var statLineRegex = regexp.MustCompile(`(?i)^(?:program\s+)?(errors|warnings)\s*:\s*(` + countExpr + `)$`)
+
+// compileTimeLineRegex matches the compile time in the statistics dialog, e.g. "Compile Time: 12.5 seconds"
+var compileTimeLineRegex = regexp.MustCompile(`(?i)^compile\s+time\s*:\s*(\d+(?:\.\d+)?)\s*(?:seconds?|secs?|s)?$`)
+
+// parseStatLine parses one count from the statistics dialog, returning
+// "errors" or "warnings" and the count
+func parseStatLine(line string) (field string, count int, ok bool) {
+	m := statLineRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", 0, false
+	}
+
+	count, err := parseCount(m[2])
+	if err != nil {
+		return "", 0, false
+	}
+
+	return strings.ToLower(m[1]), count, true
+}
+
+// parseCompileTimeLine parses the compile time from the statistics dialog
+func parseCompileTimeLine(line string) (time.Duration, bool) {
+	m := compileTimeLineRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// parseStatistics parses the statistics dialog's text. Its fields may be on
+// separate lines or on one line separated by slashes. It reports false unless
+// both counts were found.
+func parseStatistics(text string) (CompileStatistics, bool) {
+	var (
+		stats                    CompileStatistics
+		haveErrors, haveWarnings bool
+	)
+
+	fields := strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == '\r' || r == '/' })
+
+	for _, f := range fields {
+		if field, count, ok := parseStatLine(f); ok {
+			if field == "errors" {
+				stats.Counts.Errors, haveErrors = count, true
+			} else {
+				stats.Counts.Warnings, haveWarnings = count, true
+			}
+
+			continue
+		}
+
+		if d, ok := parseCompileTimeLine(f); ok {
+			stats.CompileTime = d
+		}
+	}
+
+	return stats, haveErrors && haveWarnings
+}
+
+// readStatisticsDialog reads the counts and compile time from VTPro's statistics
+// dialog and closes it. The text is taken from the monitor's snapshot of the
+// dialog, which outlives it; without one it is read from the dialog's Edit
+// control, or from its other controls if it has none.
+func (c *Compiler) readStatisticsDialog(ev windows.WindowEvent) (CompileStatistics, bool) {
+	defer c.windowMgr.CloseWindow(ev.Hwnd, ev.DisplayTitle())
+
+	text := strings.Join(ev.ChildTextSnapshot, "\n")
+
+	if text == "" {
+		for _, ci := range c.windowMgr.CollectChildInfos(ev.Hwnd) {
+			if ci.ClassName == "Edit" {
+				text = c.controlReader.GetEditText(ci.Hwnd)
+				break
+			}
+		}
+	}
+
+	if text == "" {
+		text = c.dialogText(ev.Hwnd)
+	}
+
+	stats, ok := parseStatistics(text)
+	if !ok {
+		c.log.Warn("Could not read the counts from the statistics dialog", slog.String("text", text))
+		return CompileStatistics{}, false
+	}
+
+	c.log.Debug("Read statistics dialog",
+		slog.String("counts", stats.Counts.String()),
+		slog.Duration("compileTime", stats.CompileTime),
+	)
+
+	return stats, true
+}
+
+// mergeStatistics records the statistics dialog on the result. The Message Log
+// is preferred when it has a summary line; otherwise the dialog's counts are used.
+func (c *Compiler) mergeStatistics(result *CompileResult, stats *CompileStatistics) {
+	if stats == nil {
+		return
+	}
+
+	result.Statistics = stats
+
+	if hasSummary(result) {
+		if stats.Counts != result.Reported {
+			c.log.Warn("Statistics dialog disagrees with the Message Log, using the Message Log",
+				slog.String("dialog", stats.Counts.String()),
+				slog.String("messageLog", result.Reported.String()),
+			)
+		}
+
+		return
+	}
+
+	c.log.Info("Message Log has no summary line, using the counts from the statistics dialog",
+		slog.String("counts", stats.Counts.String()),
+	)
+
+	result.Reported = stats.Counts
+	result.Warnings = stats.Counts.Warnings
+	result.Errors = stats.Counts.Errors
+}
@@ -0,0 +1,227 @@
+package compiler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestParseStatLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line  string
+		field string
+		count int
+		ok    bool
+	}{
+		{"Program Errors: 2", "errors", 2, true},
+		{"  Warnings: 1,024 ", "warnings", 1024, true},
+		{"errors:0", "errors", 0, true},
+		{"Compile Time: 12 seconds", "", 0, false},
+		{"Program Errors: many", "", 0, false},
+		{"", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			t.Parallel()
+
+			field, count, ok := parseStatLine(tt.line)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.field, field)
+			assert.Equal(t, tt.count, count)
+		})
+	}
+}
+
+func TestParseCompileTimeLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line string
+		want time.Duration
+		ok   bool
+	}{
+		{"Compile Time: 12 seconds", 12 * time.Second, true},
+		{"Compile Time: 1.5 seconds", 1500 * time.Millisecond, true},
+		{"compile time:3s", 3 * time.Second, true},
+		{"Compile Time: 1 second", time.Second, true},
+		{"Compile Time: soon", 0, false},
+		{"Warnings: 3", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := parseCompileTimeLine(tt.line)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseStatistics(t *testing.T) {
+	t.Parallel()
+
+	stats, ok := parseStatistics("Program Errors: 1 / Warnings: 3 / Compile Time: 42 seconds")
+	assert.True(t, ok)
+	assert.Equal(t, CompileStatistics{Counts: Counts{Warnings: 3, Errors: 1}, CompileTime: 42 * time.Second}, stats)
+
+	stats, ok = parseStatistics("Program Errors: 0\r\nWarnings: 2\r\n")
+	assert.True(t, ok, "fields can be on separate lines, and the compile time is optional")
+	assert.Equal(t, CompileStatistics{Counts: Counts{Warnings: 2}}, stats)
+
+	_, ok = parseStatistics("Program Errors: 0 / Compile Time: 42 seconds")
+	assert.False(t, ok, "both counts are needed")
+}
+
+// statisticsCompiler returns a compiler whose Compiling dialog 0x1111 has closed,
+// VTPro's Message Log holds logText, and whose statistics dialog 0x5555 shows
+// statsText in an Edit control
+func statisticsCompiler(logText, statsText string) (*Compiler, *testutil.MockWindowManager, *testutil.MockLogger) {
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(0x9999, windows.ChildInfo{ClassName: "ListBox", Text: logText}).
+		WithChildInfosForHwnd(0x5555,
+			windows.ChildInfo{Hwnd: 0x5501, ClassName: "Edit", Text: statsText},
+			windows.ChildInfo{Hwnd: 0x5502, ClassName: "Button", Text: "OK"},
+		).
+		WithWindowValid(0x1111, false)
+
+	log := testutil.NewMockLogger()
+
+	c := NewCompiler(log,
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(mockWin),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(testutil.NewMockControlReader().WithEditText(statsText)),
+	)
+
+	return c, mockWin, log
+}
+
+func TestCompiler_StatisticsDialog(t *testing.T) {
+	tests := []struct {
+		name         string
+		logText      string
+		statsText    string
+		wantCounts   Counts
+		wantHasError bool
+		wantWarning  string // A message logged at warn level, empty for none
+	}{
+		{
+			name:       "agrees with the Message Log",
+			logText:    "---------- Compiling for TSW-770: [test.vtp] ---------\nBoot\nMain\n---------- Successful ---------\n2 warning(s), 0 error(s)",
+			statsText:  "Program Errors: 0 / Warnings: 2 / Compile Time: 12 seconds",
+			wantCounts: Counts{Warnings: 2},
+		},
+		{
+			name:        "disagrees with the Message Log",
+			logText:     "---------- Compiling for TSW-770: [test.vtp] ---------\nBoot\nMain\n---------- Successful ---------\n2 warning(s), 0 error(s)",
+			statsText:   "Program Errors: 1 / Warnings: 5 / Compile Time: 12 seconds",
+			wantCounts:  Counts{Warnings: 2},
+			wantWarning: "Statistics dialog disagrees with the Message Log, using the Message Log",
+		},
+		{
+			name:         "no Message Log",
+			statsText:    "Program Errors: 1\r\nWarnings: 5\r\nCompile Time: 12 seconds",
+			wantCounts:   Counts{Warnings: 5, Errors: 1},
+			wantHasError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.SetupMonitorChannel()
+			defer testutil.CleanupMonitorChannel()
+
+			c, mockWin, log := statisticsCompiler(tt.logText, tt.statsText)
+
+			testutil.SendEventsToMonitor(
+				windows.WindowEvent{Hwnd: 0x1111, Title: dialog.Compiling.Title, Class: windows.DialogClass},
+				windows.WindowEvent{Hwnd: 0x5555, Title: dialog.CompileStatistics.Title, Class: windows.DialogClass},
+			)
+
+			result, _ := c.Compile(CompileOptions{
+				Hwnd:                          0x9999,
+				VTProPid:                      1234,
+				SkipPreCompilationDialogCheck: true,
+			})
+
+			assert.Equal(t, tt.wantCounts, Counts{Warnings: result.Warnings, Errors: result.Errors})
+			assert.Equal(t, tt.wantHasError, result.HasErrors)
+
+			if assert.NotNil(t, result.Statistics) {
+				assert.Equal(t, 12*time.Second, result.Statistics.CompileTime)
+			}
+
+			assert.Contains(t, mockWin.CloseWindowCalls, testutil.CloseWindowCall{Hwnd: 0x5555, Title: dialog.CompileStatistics.Title},
+				"the statistics dialog is closed")
+
+			var warnings []string
+			for _, e := range log.Entries {
+				if e.Level == "WARN" {
+					warnings = append(warnings, e.Message)
+				}
+			}
+
+			if tt.wantWarning != "" {
+				assert.Contains(t, warnings, tt.wantWarning)
+			} else {
+				assert.NotContains(t, warnings, "Statistics dialog disagrees with the Message Log, using the Message Log")
+			}
+		})
+	}
+}
+
+func TestCompiler_UnreadableStatisticsDialogIsClosed(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	c, mockWin, _ := statisticsCompiler(
+		"---------- Compiling for TSW-770: [test.vtp] ---------\nBoot\nMain\n---------- Successful ---------\n0 warning(s), 0 error(s)",
+		"Compile finished.",
+	)
+
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: dialog.Compiling.Title, Class: windows.DialogClass},
+		windows.WindowEvent{Hwnd: 0x5555, Title: dialog.CompileStatistics.Title, Class: windows.DialogClass},
+	)
+
+	result, err := c.Compile(CompileOptions{Hwnd: 0x9999, VTProPid: 1234, SkipPreCompilationDialogCheck: true})
+
+	assert.NoError(t, err)
+	assert.Nil(t, result.Statistics)
+	assert.Contains(t, mockWin.CloseWindowCalls, testutil.CloseWindowCall{Hwnd: 0x5555, Title: dialog.CompileStatistics.Title})
+}
+
+func TestCompiler_StatisticsDialogGoneBeforeRead(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	c, mockWin, _ := statisticsCompiler("", "")
+
+	// The dialog closed itself before the compile handled its event; only the monitor's snapshot has its text
+	mockWin.WithChildInfosForHwnd(0x5555).WithWindowValid(0x5555, false)
+
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: dialog.Compiling.Title, Class: windows.DialogClass},
+		windows.WindowEvent{
+			Hwnd: 0x5555, Title: dialog.CompileStatistics.Title, Class: windows.DialogClass,
+			ChildTextSnapshot: []string{"Program Errors: 1\r\nWarnings: 5\r\nCompile Time: 12 seconds"},
+		},
+	)
+
+	result, _ := c.Compile(CompileOptions{Hwnd: 0x9999, VTProPid: 1234, SkipPreCompilationDialogCheck: true})
+
+	if assert.NotNil(t, result.Statistics) {
+		assert.Equal(t, Counts{Warnings: 5, Errors: 1}, result.Statistics.Counts)
+		assert.Equal(t, 12*time.Second, result.Statistics.CompileTime)
+	}
+}
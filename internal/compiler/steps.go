@@ -0,0 +1,158 @@
+package compiler
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/timeouts"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// Step is one stage of a compile. Compile runs them in the order they are declared.
+type Step string
+
+const (
+	StepAcquireFocus      Step = "acquire-focus"       // Show VTPro, save the project and clear stale window events
+	StepTriggerCompile    Step = "trigger-compile"     // Send the compile keystroke
+	StepAwaitCompileStart Step = "await-compile-start" // Wait for the Compiling dialog to open
+	StepAwaitCompileEnd   Step = "await-compile-end"   // Wait for every Compiling dialog to close
+	StepHarvestResults    Step = "harvest-results"     // Read and parse the Message Log
+	StepClose             Step = "close"               // Close VTPro and any dialog closing it raises
+)
+
+// StepOutcome is how one step of a compile ended
+type StepOutcome struct {
+	Step     Step
+	Skipped  bool          // The step did not apply, e.g. the await steps without VTPro's PID
+	Duration time.Duration // How long the step ran, by the compiler's clock
+	Err      error         // Why the step failed, nil if it succeeded or was skipped
+}
+
+// FailedStep returns the step the compile failed in. It reports false if every
+// step it reached succeeded, even if the compile found errors.
+func (r *CompileResult) FailedStep() (StepOutcome, bool) {
+	for _, s := range r.Steps {
+		if s.Err != nil {
+			return s, true
+		}
+	}
+
+	return StepOutcome{}, false
+}
+
+// compileRun is the state a compile's steps share
+type compileRun struct {
+	opts        CompileOptions
+	pid         uint32          // VTPro's PID, 0 if unknown, in which case nothing watches the compile
+	result      *CompileResult  // What Compile returns; a step that fails replaces it with its error
+	misdirected int             // Keystrokes that focus stole on the way to VTPro
+	sent        KeystrokeReport // How the compile keystroke was sent
+	watch       *compileWatch   // Set by StepAwaitCompileStart
+	steps       []StepOutcome   // The outcome of each step reached, in order
+}
+
+// compileStep is one step of a compile and the function that runs it
+type compileStep struct {
+	name    Step
+	applies func(*compileRun) bool // nil if the step always runs
+	run     func(*compileRun) error
+}
+
+// steps returns the steps of a compile, in order
+func (c *Compiler) steps() []compileStep {
+	watched := func(run *compileRun) bool { return run.pid != 0 }
+
+	return []compileStep{
+		{name: StepAcquireFocus, run: c.acquireFocus},
+		{name: StepTriggerCompile, run: c.triggerCompile},
+		{name: StepAwaitCompileStart, applies: watched, run: c.awaitCompileStart},
+		{name: StepAwaitCompileEnd, applies: watched, run: c.awaitCompileEnd},
+		{name: StepHarvestResults, applies: watched, run: c.harvestResults},
+		{name: StepClose, run: c.closeVTPro},
+	}
+}
+
+// runSteps runs each step in turn, recording its outcome, and stops at the
+// first that fails
+func (c *Compiler) runSteps(run *compileRun, steps []compileStep) error {
+	for _, s := range steps {
+		if s.applies != nil && !s.applies(run) {
+			c.recordStep(run, StepOutcome{Step: s.name, Skipped: true})
+			continue
+		}
+
+		start := c.clock.Now()
+		err := s.run(run)
+		c.recordStep(run, StepOutcome{Step: s.name, Duration: c.clock.Now().Sub(start), Err: err})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordStep adds a step's outcome to the run and passes it to the step observer
+func (c *Compiler) recordStep(run *compileRun, o StepOutcome) {
+	run.steps = append(run.steps, o)
+
+	attrs := []any{slog.String("step", string(o.Step)), slog.Duration("duration", o.Duration)}
+	switch {
+	case o.Skipped:
+		attrs = append(attrs, slog.Bool("skipped", true))
+	case o.Err != nil:
+		attrs = append(attrs, slog.Any("error", o.Err))
+	}
+
+	c.log.Debug("Compile step finished", attrs...)
+
+	if c.onStep != nil {
+		c.onStep(o)
+	}
+}
+
+// compileWatch is what the await and harvest steps share while VTPro compiles
+type compileWatch struct {
+	deadline *clock.Deadline
+	ticker   *time.Ticker // Polls whether the Compiling dialogs have closed
+	beat     *heartbeat
+	live     *liveLog
+	events   <-chan windows.WindowEvent
+
+	started    bool               // The Compiling dialog opened, so the keystroke reached VTPro
+	dialog     uintptr            // The Compiling dialog the keystroke opened
+	since      time.Time          // When that dialog opened
+	concurrent []uintptr          // Compiling dialogs vtpc did not start, e.g. the user pressed F12 too
+	statistics *CompileStatistics // What a statistics dialog showed, nil until one is read
+}
+
+// watchCompile starts watching for the compile to start and end
+func (c *Compiler) watchCompile(opts CompileOptions) *compileWatch {
+	// Use custom timeout if specified, otherwise use default 5 minutes
+	compilationTimeout := timeouts.CompilationCompleteTimeout
+	if opts.CompilationTimeout > 0 {
+		compilationTimeout = opts.CompilationTimeout
+	}
+
+	return &compileWatch{
+		deadline: clock.NewDeadline(c.clock, compilationTimeout, c.sleepPolicy),
+		ticker:   time.NewTicker(500 * time.Millisecond),
+
+		// Keep CI output alive during long compiles
+		beat: startHeartbeat(c.clock, opts.Heartbeat),
+
+		// Echo the Message Log as VTPro writes it, if asked to
+		live: startLiveLog(c.clock, opts.LiveLog),
+
+		events: c.monitor(),
+	}
+}
+
+// stop stops the watch's timers
+func (w *compileWatch) stop() {
+	w.ticker.Stop()
+	w.beat.Stop()
+	w.live.Stop()
+}
@@ -0,0 +1,234 @@
+package compiler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// newStepCompiler returns a compiler reading window events from its own
+// channel, so the steps can be run one at a time
+func newStepCompiler(mockWin *testutil.MockWindowManager, events ...windows.WindowEvent) (*Compiler, *testutil.MockKeyboardInjector) {
+	ch := make(chan windows.WindowEvent, len(events))
+	for _, ev := range events {
+		ch <- ev
+	}
+
+	kbd := testutil.NewMockKeyboardInjector()
+
+	return NewCompiler(logger.NewNoOpLogger(),
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(vtproPid)),
+		WithWindowManager(mockWin),
+		WithKeyboard(kbd),
+		WithControlReader(testutil.NewMockControlReader()),
+		WithMonitorSource(func() <-chan windows.WindowEvent { return ch }),
+	), kbd
+}
+
+// newStepRun returns the state of a compile of VTPro's main window
+func newStepRun(opts CompileOptions) *compileRun {
+	opts.Hwnd = vtproHwnd
+	opts.SkipPreCompilationDialogCheck = true
+
+	return &compileRun{opts: opts, pid: opts.VTProPid, result: &CompileResult{}}
+}
+
+// stepNames returns the steps the outcomes are for, in order
+func stepNames(outcomes []StepOutcome) []Step {
+	names := make([]Step, len(outcomes))
+	for i, o := range outcomes {
+		names[i] = o.Step
+	}
+
+	return names
+}
+
+func TestAcquireFocus(t *testing.T) {
+	t.Parallel()
+
+	mockWin := testutil.NewMockWindowManager().WithForegroundSequence(vtproHwnd)
+	c, kbd := newStepCompiler(mockWin)
+	run := newStepRun(CompileOptions{VTProPid: vtproPid, LaunchMinimized: true, SaveFirst: true})
+
+	require.NoError(t, c.acquireFocus(run))
+	assert.Equal(t, []uintptr{vtproHwnd}, mockWin.RestoreWindowCalls, "a minimized VTPro is shown for the keystroke")
+	assert.True(t, kbd.SendCtrlSCalled)
+}
+
+func TestAcquireFocus_SaveFails(t *testing.T) {
+	t.Parallel()
+
+	mockWin := testutil.NewMockWindowManager().WithSetForegroundResult(false)
+	c, _ := newStepCompiler(mockWin)
+	c.foregroundLock = func() (windows.ForegroundLock, error) { return windows.ForegroundLock{}, nil }
+	run := newStepRun(CompileOptions{VTProPid: vtproPid, SaveFirst: true})
+
+	err := c.acquireFocus(run)
+	require.Error(t, err)
+	assert.True(t, run.result.HasErrors, "the failure replaces the result")
+	assert.Equal(t, []string{err.Error()}, run.result.ErrorMessages)
+}
+
+func TestTriggerCompile(t *testing.T) {
+	t.Parallel()
+
+	c, kbd := newStepCompiler(testutil.NewMockWindowManager())
+	run := newStepRun(CompileOptions{VTProPid: vtproPid})
+
+	require.NoError(t, c.triggerCompile(run))
+	assert.True(t, kbd.SendF12WithSendInputCalled)
+	assert.Equal(t, InputSendInput, run.sent.Method)
+	assert.Empty(t, run.result.WarningMessages)
+}
+
+func TestTriggerCompile_WithoutPid(t *testing.T) {
+	t.Parallel()
+
+	c, _ := newStepCompiler(testutil.NewMockWindowManager())
+	run := newStepRun(CompileOptions{})
+
+	require.NoError(t, c.triggerCompile(run))
+	assert.Equal(t, []string{keystrokeOnlyWarning}, run.result.WarningMessages)
+}
+
+func TestAwaitCompileStart(t *testing.T) {
+	t.Parallel()
+
+	mockWin := testutil.NewMockWindowManager()
+	c, _ := newStepCompiler(mockWin,
+		windows.WindowEvent{Hwnd: 0x5555, Title: "Untitled - Notepad"},
+		windows.WindowEvent{Hwnd: 0x1111, Title: dialog.Compiling.Title},
+	)
+	run := newStepRun(CompileOptions{VTProPid: vtproPid, LaunchMinimized: true})
+
+	require.NoError(t, c.awaitCompileStart(run))
+	defer run.watch.stop()
+
+	assert.True(t, run.watch.started)
+	assert.Equal(t, uintptr(0x1111), run.watch.dialog)
+	assert.Equal(t, []uintptr{vtproHwnd}, mockWin.MinimizeWindowCalls, "VTPro is minimized again once the compile starts")
+}
+
+func TestAwaitCompileStart_Timeout(t *testing.T) {
+	t.Parallel()
+
+	c, _ := newStepCompiler(testutil.NewMockWindowManager())
+	run := newStepRun(CompileOptions{VTProPid: vtproPid, CompilationTimeout: time.Second})
+
+	err := c.awaitCompileStart(run)
+	defer run.watch.stop()
+
+	assert.ErrorIs(t, err, ErrCompileTimeout)
+	assert.False(t, run.watch.started)
+	assert.True(t, run.result.HasErrors)
+}
+
+func TestAwaitCompileEnd(t *testing.T) {
+	t.Parallel()
+
+	mockWin := testutil.NewMockWindowManager().WithWindowValidSequence(0x1111, true, false)
+	c, _ := newStepCompiler(mockWin)
+	run := newStepRun(CompileOptions{VTProPid: vtproPid})
+	run.watch = c.watchCompile(run.opts)
+	defer run.watch.stop()
+
+	run.watch.started = true
+	run.watch.dialog = 0x1111
+	run.watch.since = c.clock.Now()
+
+	require.NoError(t, c.awaitCompileEnd(run))
+	assert.Equal(t, []bool{false}, mockWin.WindowValiditySequences[0x1111], "the step ends once the dialog has closed")
+	assert.Positive(t, run.result.CompileTime)
+}
+
+func TestHarvestResults(t *testing.T) {
+	t.Parallel()
+
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(vtproHwnd, windows.ChildInfo{ClassName: "Edit", Text: "---------- Failed ---------\n2 warning(s), 3 error(s)"})
+	c, _ := newStepCompiler(mockWin)
+	run := newStepRun(CompileOptions{VTProPid: vtproPid})
+	run.watch = &compileWatch{}
+
+	require.NoError(t, c.harvestResults(run))
+	assert.Equal(t, 2, run.result.Warnings)
+	assert.Equal(t, 3, run.result.Errors)
+	assert.True(t, run.result.HasErrors)
+}
+
+func TestCloseVTPro(t *testing.T) {
+	t.Parallel()
+
+	mockWin := testutil.NewMockWindowManager()
+	c, _ := newStepCompiler(mockWin)
+
+	require.NoError(t, c.closeVTPro(newStepRun(CompileOptions{})))
+	require.Len(t, mockWin.CloseWindowCalls, 1)
+	assert.Equal(t, uintptr(vtproHwnd), mockWin.CloseWindowCalls[0].Hwnd)
+}
+
+func TestCompile_RecordsSteps(t *testing.T) {
+	t.Parallel()
+
+	var observed []StepOutcome
+
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(vtproHwnd, windows.ChildInfo{ClassName: "Edit", Text: successfulLog}).
+		WithWindowValid(0x1111, false)
+	c, _ := newStepCompiler(mockWin, windows.WindowEvent{Hwnd: 0x1111, Title: dialog.Compiling.Title})
+	WithStepObserver(func(o StepOutcome) { observed = append(observed, o) })(c)
+
+	result, err := c.Compile(CompileOptions{Hwnd: vtproHwnd, VTProPid: vtproPid, SkipPreCompilationDialogCheck: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Step{
+		StepAcquireFocus, StepTriggerCompile, StepAwaitCompileStart, StepAwaitCompileEnd, StepHarvestResults, StepClose,
+	}, stepNames(result.Steps))
+	assert.Equal(t, result.Steps, observed, "the observer sees each step as it ends")
+
+	_, failed := result.FailedStep()
+	assert.False(t, failed)
+}
+
+func TestCompile_SkipsWatchingWithoutPid(t *testing.T) {
+	t.Parallel()
+
+	c, _ := newStepCompiler(testutil.NewMockWindowManager())
+
+	result, err := c.Compile(CompileOptions{Hwnd: vtproHwnd, SkipPreCompilationDialogCheck: true})
+	require.NoError(t, err)
+
+	for _, o := range result.Steps {
+		watched := o.Step == StepAwaitCompileStart || o.Step == StepAwaitCompileEnd || o.Step == StepHarvestResults
+		assert.Equal(t, watched, o.Skipped, o.Step)
+	}
+}
+
+func TestCompile_ReportsFailedStep(t *testing.T) {
+	t.Parallel()
+
+	mockWin := testutil.NewMockWindowManager()
+	c, _ := newStepCompiler(mockWin)
+
+	result, err := c.Compile(CompileOptions{
+		Hwnd:                          vtproHwnd,
+		VTProPid:                      vtproPid,
+		SkipPreCompilationDialogCheck: true,
+		CompilationTimeout:            time.Second,
+	})
+	require.ErrorIs(t, err, ErrCompileTimeout)
+
+	failed, ok := result.FailedStep()
+	require.True(t, ok)
+	assert.Equal(t, StepAwaitCompileStart, failed.Step)
+	assert.Equal(t, err, failed.Err)
+	assert.Equal(t, []Step{StepAcquireFocus, StepTriggerCompile, StepAwaitCompileStart}, stepNames(result.Steps), "no step runs after one fails")
+	assert.Empty(t, mockWin.CloseWindowCalls)
+}
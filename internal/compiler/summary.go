@@ -0,0 +1,101 @@
+package compiler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SummaryPattern is a named regular expression that extracts the warning and
+// error counts from a Message Log summary line. The expression must contain the
+// named groups "warnings" and "errors".
+type SummaryPattern struct {
+	Name string
+	re   *regexp.Regexp
+}
+
+// NewSummaryPattern compiles a summary pattern and checks it has the required groups
+func NewSummaryPattern(name, expr string) (SummaryPattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return SummaryPattern{}, fmt.Errorf("invalid summary pattern %q: %w", name, err)
+	}
+
+	if re.SubexpIndex("warnings") == -1 || re.SubexpIndex("errors") == -1 {
+		return SummaryPattern{}, fmt.Errorf("summary pattern %q must contain named groups \"warnings\" and \"errors\"", name)
+	}
+
+	return SummaryPattern{Name: name, re: re}, nil
+}
+
+// mustSummaryPattern is NewSummaryPattern for the built-in table
+func mustSummaryPattern(name, expr string) SummaryPattern {
+	p, err := NewSummaryPattern(name, expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// DefaultSummaryPatterns are the built-in summary-line patterns, tried in order.
+// The strict English form VTPro normally prints always comes first.
+var DefaultSummaryPatterns = []SummaryPattern{
+	mustSummaryPattern("strict",
+		`(?P<warnings>`+countExpr+`)\s+warning\(s\),\s+(?P<errors>`+countExpr+`)\s+error\(s\)`),
+	mustSummaryPattern("reversed",
+		`(?P<errors>`+countExpr+`)\s+error\(s\),\s+(?P<warnings>`+countExpr+`)\s+warning\(s\)`),
+	mustSummaryPattern("plural",
+		`(?i)^(?P<warnings>`+countExpr+`)\s+warnings?(?:\(s\))?\s*[,;]?\s*(?P<errors>`+countExpr+`)\s+errors?(?:\(s\))?\.?$`),
+	mustSummaryPattern("plural-reversed",
+		`(?i)^(?P<errors>`+countExpr+`)\s+errors?(?:\(s\))?\s*[,;]?\s*(?P<warnings>`+countExpr+`)\s+warnings?(?:\(s\))?\.?$`),
+}
+
+// summaryMatch is the outcome of matching a line against the summary patterns
+type summaryMatch struct {
+	Pattern  string
+	Warnings int
+	Errors   int
+	Counts   []string // The counts as printed, before thousands separators were removed
+}
+
+// matchSummary tries each pattern in order and returns the counts from the first match.
+// Counts may contain thousands separators, e.g. "1 024 warning(s)".
+func matchSummary(line string, patterns []SummaryPattern) (summaryMatch, bool) {
+	line = strings.TrimSpace(line)
+
+	for _, p := range patterns {
+		m := p.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		rawWarnings := m[p.re.SubexpIndex("warnings")]
+		warnings, err := parseCount(rawWarnings)
+		if err != nil {
+			continue
+		}
+
+		rawErrors := m[p.re.SubexpIndex("errors")]
+		errs, err := parseCount(rawErrors)
+		if err != nil {
+			continue
+		}
+
+		return summaryMatch{
+			Pattern:  p.Name,
+			Warnings: warnings,
+			Errors:   errs,
+			Counts:   []string{rawWarnings, rawErrors},
+		}, true
+	}
+
+	return summaryMatch{}, false
+}
+
+// looksLikeSummary reports whether a line mentions both warnings and errors,
+// which is used to flag summary lines that no pattern recognized
+func looksLikeSummary(line string) bool {
+	lower := strings.ToLower(line)
+	return strings.Contains(lower, "warning") && strings.Contains(lower, "error")
+}
@@ -0,0 +1,120 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+func TestParseVTProOutput_SummaryVariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		summary  string
+		warnings int
+		errors   int
+		pattern  string
+	}{
+		{"strict", "2 warning(s), 1 error(s)", 2, 1, "strict"},
+		{"reversed order", "1 error(s), 2 warning(s)", 2, 1, "reversed"},
+		{"without (s)", "2 warnings, 1 error", 2, 1, "plural"},
+		{"singular", "1 warning, 0 errors", 1, 0, "plural"},
+		{"without (s) reversed", "0 errors, 3 warnings", 3, 0, "plural-reversed"},
+		{"capitalized", "4 Warnings, 2 Errors", 4, 2, "plural"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCompiler(logger.NewNoOpLogger())
+
+			output := "---------- Compiling for TSW-770: [test.vtp] ---------\nBoot\n---------- Successful ---------\n" + tt.summary
+
+			result := &CompileResult{}
+			c.parseVTProOutput(output, result)
+
+			assert.Equal(t, tt.warnings, result.Warnings)
+			assert.Equal(t, tt.errors, result.Errors)
+			require.Len(t, result.Sections, 1)
+			assert.Equal(t, tt.pattern, result.Sections[0].SummaryPattern)
+		})
+	}
+}
+
+func TestParseVTProOutput_SummaryMismatch(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	output := "---------- Compiling for TSW-770: [test.vtp] ---------\nBoot\n---------- Successful ---------\n2 Warnung(en), 1 Fehler"
+
+	result := &CompileResult{}
+	c.parseVTProOutput(output, result)
+
+	assert.Equal(t, 0, result.Warnings)
+	assert.Equal(t, 0, result.Errors)
+	require.Len(t, result.Sections, 1)
+	assert.Empty(t, result.Sections[0].SummaryPattern)
+}
+
+func TestParseVTProOutput_CustomSummaryPattern(t *testing.T) {
+	pattern, err := NewSummaryPattern("german", `(?P<warnings>\d+) Warnung\(en\), (?P<errors>\d+) Fehler`)
+	require.NoError(t, err)
+
+	c := NewCompiler(logger.NewNoOpLogger(), WithParser(ParserOptions{
+		SummaryPatterns: []SummaryPattern{pattern},
+	}))
+
+	output := "---------- Compiling for TSW-770: [test.vtp] ---------\nBoot\n---------- Fehlgeschlagen ---------\n2 Warnung(en), 1 Fehler"
+
+	result := &CompileResult{}
+	c.parseVTProOutput(output, result)
+
+	assert.Equal(t, 2, result.Warnings)
+	assert.Equal(t, 1, result.Errors)
+	assert.Equal(t, "german", result.Sections[0].SummaryPattern)
+}
+
+func TestParseVTProOutput_StrictPatternTriedFirst(t *testing.T) {
+	// A custom pattern that would also match must not win over the strict one
+	pattern, err := NewSummaryPattern("greedy", `(?P<errors>\d+)\D+(?P<warnings>\d+)`)
+	require.NoError(t, err)
+
+	c := NewCompiler(logger.NewNoOpLogger(), WithParser(ParserOptions{
+		SummaryPatterns: []SummaryPattern{pattern},
+	}))
+
+	result := &CompileResult{}
+	c.parseVTProOutput("5 warning(s), 1 error(s)", result)
+
+	assert.Equal(t, 5, result.Warnings)
+	assert.Equal(t, 1, result.Errors)
+	assert.Equal(t, "strict", result.Sections[0].SummaryPattern)
+}
+
+func TestParseVTProOutput_VariantSummaryEndsContinuation(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	output := "Boot\n\t[ warning ]: Object \"A\" has an unassigned Smart Object ID.\n1 warning, 0 errors"
+
+	result := &CompileResult{}
+	c.parseVTProOutput(output, result)
+
+	assert.Equal(t, []string{"Object \"A\" has an unassigned Smart Object ID."}, result.WarningMessages)
+	assert.Equal(t, 1, result.Warnings)
+}
+
+func TestNewSummaryPattern_Validation(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewSummaryPattern("bad", `(`)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bad")
+
+	_, err = NewSummaryPattern("missing", `(?P<warnings>\d+) warnings`)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "errors")
+
+	p, err := NewSummaryPattern("ok", `(?P<warnings>\d+)/(?P<errors>\d+)`)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", p.Name)
+}
@@ -0,0 +1,259 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/width"
+)
+
+// DefaultTableWidth is the table width used when the terminal width is unknown
+const DefaultTableWidth = 120
+
+const (
+	maxPageWidth    = 20 // Longer page names are truncated with an ellipsis
+	maxObjectWidth  = 24 // Longer object names are truncated with an ellipsis
+	minMessageWidth = 20 // The MESSAGE column never gets narrower than this
+	tableSeparator  = " | "
+)
+
+// ANSI colors for the SEV column
+const (
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiReset  = "\x1b[0m"
+)
+
+// TableOptions configures FormatMessageTable
+type TableOptions struct {
+	Width int  // Total width to fit, DefaultTableWidth if not positive
+	Color bool // Color the severity column with ANSI escapes
+}
+
+// tableRow is one message split into the table's columns
+type tableRow struct {
+	severity Severity
+	page     string
+	object   string
+	message  string
+}
+
+// FormatMessageTable renders messages as an aligned SEV | PAGE | OBJECT | MESSAGE
+// table with a header, followed by totals, and returns its lines. Page and object
+// names are truncated with an ellipsis to fit their columns and messages wrap
+// within the MESSAGE column, so the table fits opts.Width where it can.
+func FormatMessageTable(messages []Message, opts TableOptions) []string {
+	total := opts.Width
+	if total <= 0 {
+		total = DefaultTableWidth
+	}
+
+	rows := make([]tableRow, 0, len(messages))
+	sevWidth, pageWidth, objectWidth := len("SEV"), len("PAGE"), len("OBJECT")
+
+	for _, m := range messages {
+		row := splitTableRow(m)
+		rows = append(rows, row)
+
+		sevWidth = max(sevWidth, displayWidth(row.severity.String()))
+		pageWidth = max(pageWidth, min(displayWidth(row.page), maxPageWidth))
+		objectWidth = max(objectWidth, min(displayWidth(row.object), maxObjectWidth))
+	}
+
+	messageWidth := max(total-sevWidth-pageWidth-objectWidth-3*len(tableSeparator), minMessageWidth)
+
+	lines := []string{
+		strings.Join([]string{pad("SEV", sevWidth), pad("PAGE", pageWidth), pad("OBJECT", objectWidth), "MESSAGE"}, tableSeparator),
+		strings.Join([]string{
+			strings.Repeat("-", sevWidth),
+			strings.Repeat("-", pageWidth),
+			strings.Repeat("-", objectWidth),
+			strings.Repeat("-", messageWidth),
+		}, "-+-"),
+	}
+
+	warnings, errors := 0, 0
+
+	for _, row := range rows {
+		if row.severity == SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+
+		sev := pad(row.severity.String(), sevWidth)
+		if opts.Color {
+			sev = colorSeverity(row.severity, sev)
+		}
+
+		page := pad(truncate(row.page, pageWidth), pageWidth)
+		object := pad(truncate(row.object, objectWidth), objectWidth)
+
+		for i, text := range wrap(row.message, messageWidth) {
+			if i > 0 {
+				sev, page, object = pad("", sevWidth), pad("", pageWidth), pad("", objectWidth)
+			}
+
+			lines = append(lines, strings.TrimRight(strings.Join([]string{sev, page, object, text}, tableSeparator), " "))
+		}
+	}
+
+	lines = append(lines, "", fmt.Sprintf("%d warning(s), %d error(s)", warnings, errors))
+
+	return lines
+}
+
+// splitTableRow picks the page and object out of a message. The leading
+// `Object "x" on Page "y"` is dropped from the text since it has its own columns.
+func splitTableRow(m Message) tableRow {
+	row := tableRow{
+		severity: m.Severity,
+		page:     m.Page(),
+		object:   m.Object(),
+		message:  m.Text,
+	}
+
+	if row.page != "" && row.object != "" {
+		prefix := fmt.Sprintf(`Object "%s" on Page "%s" `, row.object, row.page)
+		if rest, ok := strings.CutPrefix(m.Text, prefix); ok && rest != "" {
+			row.message = rest
+		}
+	}
+
+	if row.page == "" {
+		row.page = "-"
+	}
+
+	if row.object == "" {
+		row.object = "-"
+	}
+
+	return row
+}
+
+// colorSeverity wraps an already padded severity cell in its ANSI color
+func colorSeverity(severity Severity, cell string) string {
+	if severity == SeverityError {
+		return ansiRed + cell + ansiReset
+	}
+
+	return ansiYellow + cell + ansiReset
+}
+
+// displayWidth returns how many terminal columns s takes, counting East Asian
+// wide and fullwidth characters as two
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+
+	return n
+}
+
+// runeWidth returns how many terminal columns r takes
+func runeWidth(r rune) int {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// pad right-pads s with spaces to w columns
+func pad(s string, w int) string {
+	if n := w - displayWidth(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+
+	return s
+}
+
+// truncate shortens s to at most w columns, ending it with an ellipsis if it was cut
+func truncate(s string, w int) string {
+	if displayWidth(s) <= w {
+		return s
+	}
+
+	var b strings.Builder
+	used := 0
+
+	for _, r := range s {
+		rw := runeWidth(r)
+		if used+rw > w-1 {
+			break
+		}
+
+		b.WriteRune(r)
+		used += rw
+	}
+
+	b.WriteString("…")
+
+	return b.String()
+}
+
+// wrap breaks s into lines of at most w columns at spaces, splitting words
+// that are longer than a whole line
+func wrap(s string, w int) []string {
+	var (
+		lines []string
+		line  strings.Builder
+		used  int
+	)
+
+	flush := func() {
+		lines = append(lines, line.String())
+		line.Reset()
+		used = 0
+	}
+
+	for _, word := range strings.Fields(s) {
+		ww := displayWidth(word)
+
+		if used > 0 && used+1+ww > w {
+			flush()
+		}
+
+		// A word wider than the column is split across lines
+		for ww > w {
+			head := truncateExact(word, w-used)
+			line.WriteString(head)
+			flush()
+
+			word = word[len(head):]
+			ww = displayWidth(word)
+		}
+
+		if used > 0 {
+			line.WriteByte(' ')
+			used++
+		}
+
+		line.WriteString(word)
+		used += ww
+	}
+
+	if used > 0 || len(lines) == 0 {
+		flush()
+	}
+
+	return lines
+}
+
+// truncateExact returns the longest prefix of s that fits in w columns, at least one rune
+func truncateExact(s string, w int) string {
+	used := 0
+
+	for i, r := range s {
+		rw := runeWidth(r)
+		if used+rw > w && i > 0 {
+			return s[:i]
+		}
+
+		used += rw
+	}
+
+	return s
+}
@@ -0,0 +1,116 @@
+package compiler
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the table golden files in testdata")
+
+// assertGolden compares lines with testdata/<name>.golden, rewriting it with -update
+func assertGolden(t *testing.T, name string, lines []string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	got := strings.Join(lines, "\n") + "\n"
+
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got)
+}
+
+var tableMessages = []Message{
+	{Index: 0, Severity: SeverityError, Text: `Object "Mute" on Page "Audio" has an invalid join number.`},
+	{Index: 1, Severity: SeverityWarning, Text: `Object "Source Select Button With A Very Long Name" on Page "Main" has an unassigned Smart Object ID.`},
+	{Index: 2, Severity: SeverityWarning, Text: `The file path 'C:\Projects\Customer\Site\Building\Floor\Room\Panels\Touch\Images\background.png' is longer than 128 characters and may not be transferred to the panel.`},
+}
+
+func TestFormatMessageTable_Golden(t *testing.T) {
+	t.Parallel()
+
+	assertGolden(t, "table_default", FormatMessageTable(tableMessages, TableOptions{}))
+}
+
+func TestFormatMessageTable_NarrowWraps(t *testing.T) {
+	t.Parallel()
+
+	lines := FormatMessageTable(tableMessages, TableOptions{Width: 80})
+	assertGolden(t, "table_narrow", lines)
+
+	for _, line := range lines {
+		assert.LessOrEqual(t, displayWidth(line), 80, line)
+	}
+}
+
+func TestFormatMessageTable_WideCharacters(t *testing.T) {
+	t.Parallel()
+
+	messages := []Message{
+		{Index: 0, Severity: SeverityWarning, Text: `Object "音量" on Page "メイン画面" references a missing image.`},
+	}
+
+	lines := FormatMessageTable(messages, TableOptions{Width: 60})
+	assertGolden(t, "table_wide", lines)
+
+	// The separator after OBJECT lines up on the header and the row
+	assert.Equal(t, displayWidth(strings.Split(lines[0], " | MESSAGE")[0]), displayWidth(strings.Split(lines[2], " | references")[0]))
+}
+
+func TestFormatMessageTable_Color(t *testing.T) {
+	t.Parallel()
+
+	lines := FormatMessageTable(tableMessages[:2], TableOptions{Color: true})
+
+	assert.True(t, strings.HasPrefix(lines[2], ansiRed+"error  "+ansiReset))
+	assert.True(t, strings.HasPrefix(lines[3], ansiYellow+"warning"+ansiReset))
+	assert.NotContains(t, lines[0], "\x1b[")
+}
+
+func TestFormatMessageTable_Empty(t *testing.T) {
+	t.Parallel()
+
+	lines := FormatMessageTable(nil, TableOptions{})
+
+	require.Len(t, lines, 4)
+	assert.Equal(t, "0 warning(s), 0 error(s)", lines[3])
+}
+
+func TestSplitTableRow(t *testing.T) {
+	t.Parallel()
+
+	row := splitTableRow(Message{Severity: SeverityWarning, Text: `Object "Logo" on Page "Main" references a missing image.`})
+	assert.Equal(t, "Main", row.page)
+	assert.Equal(t, "Logo", row.object)
+	assert.Equal(t, "references a missing image.", row.message)
+
+	row = splitTableRow(Message{Severity: SeverityError, Text: "Compilation timeout"})
+	assert.Equal(t, "-", row.page)
+	assert.Equal(t, "-", row.object)
+	assert.Equal(t, "Compilation timeout", row.message)
+}
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Main", truncate("Main", 4))
+	assert.Equal(t, "Mai…", truncate("Main Page", 4))
+	assert.Equal(t, "メ…", truncate("メイン", 4))
+}
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"one two", "three"}, wrap("one two three", 8))
+	assert.Equal(t, []string{"abcde", "fgh"}, wrap("abcdefgh", 5))
+	assert.Equal(t, []string{""}, wrap("", 5))
+}
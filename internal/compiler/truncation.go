@@ -0,0 +1,91 @@
+package compiler
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// truncationMessageThreshold is how many messages a log section without a result banner
+// or summary line must have before it is treated as truncated rather than cancelled
+const truncationMessageThreshold = 50
+
+// messageLogTextLimit is the text limit set on the Message Log before compiling, the
+// largest an Edit control allows, so long logs are not cut off
+const messageLogTextLimit = 0x7FFFFFFE
+
+const truncatedLogWarning = "vtpc: the Message Log appears truncated; warning and error counts may be incomplete"
+
+// detectTruncation reports whether a Message Log looks cut off by the control's text limit,
+// and why. length and limit are the control's text length and limit; zero means unknown.
+func detectTruncation(text string, messages, length, limit int, patterns []SummaryPattern) (bool, string) {
+	if limit > 0 && length >= limit {
+		return true, fmt.Sprintf("text length %d reached the control's limit of %d", length, limit)
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	sections := splitSections(lines)
+	if len(sections) == 0 {
+		return false, ""
+	}
+
+	last := sections[len(sections)-1]
+	if !sectionCompleted(last, patterns) && messages >= truncationMessageThreshold {
+		return true, fmt.Sprintf("the last section has %d messages but no result banner or summary line", messages)
+	}
+
+	return false, ""
+}
+
+// checkTruncation marks the result when the Message Log read from logHwnd looks truncated
+func (c *Compiler) checkTruncation(text string, logHwnd uintptr, result *CompileResult) bool {
+	messages := 0
+	if n := len(result.Sections); n > 0 {
+		messages = len(result.Sections[n-1].Messages)
+	}
+
+	length, limit := 0, 0
+	if logHwnd != 0 {
+		length = c.controlReader.GetTextLength(logHwnd)
+		limit = c.controlReader.GetTextLimit(logHwnd)
+	}
+
+	truncated, reason := detectTruncation(text, messages, length, limit, c.parser.summaryPatterns())
+	if !truncated {
+		return false
+	}
+
+	c.log.Warn("Message Log appears truncated", slog.String("reason", reason))
+	result.LogTruncated = true
+	addWarning(result, truncatedLogWarning)
+
+	return true
+}
+
+// isEditClass reports whether a window class is an Edit or RichEdit control
+func isEditClass(className string) bool {
+	lower := strings.ToLower(className)
+	return lower == "edit" || strings.HasPrefix(lower, "richedit")
+}
+
+// raiseMessageLogLimit raises the text limit of the edit controls in the main window,
+// which include the Message Log, so a long log is not cut off during the compile
+func (c *Compiler) raiseMessageLogLimit(mainHwnd uintptr) {
+	raised := 0
+
+	for _, ci := range c.windowMgr.CollectChildInfos(mainHwnd) {
+		if !isEditClass(ci.ClassName) || c.controlReader.GetTextLimit(ci.Hwnd) >= messageLogTextLimit {
+			continue
+		}
+
+		if !c.controlReader.SetTextLimit(ci.Hwnd, messageLogTextLimit) {
+			c.log.Debug("Could not raise edit control text limit", slog.Uint64("hwnd", uint64(ci.Hwnd)))
+			continue
+		}
+
+		raised++
+	}
+
+	c.log.Debug("Raised edit control text limits", slog.Int("controls", raised))
+}
@@ -0,0 +1,181 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+const messageLogHwnd = 0x5555
+
+func TestDetectTruncation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		fixture   string
+		messages  int
+		length    int
+		limit     int
+		truncated bool
+		reason    string
+	}{
+		{"complete log", "two_targets.log", 3, 900, 30000, false, ""},
+		{"many messages without banner or summary", "truncated.log", 60, 0, 0, true, "60 messages but no result banner"},
+		{"few messages without banner is a cancel", "cancelled_partial.log", 1, 0, 0, false, ""},
+		{"length at the limit", "two_targets.log", 3, 30000, 30000, true, "reached the control's limit of 30000"},
+		{"unknown limit", "two_targets.log", 3, 30000, 0, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			truncated, reason := detectTruncation(readFixture(t, tt.fixture), tt.messages, tt.length, tt.limit, DefaultSummaryPatterns)
+
+			assert.Equal(t, tt.truncated, truncated)
+			if tt.reason != "" {
+				assert.Contains(t, reason, tt.reason)
+			} else {
+				assert.Empty(t, reason)
+			}
+		})
+	}
+}
+
+func TestDetectTruncation_OnlyLastSectionMatters(t *testing.T) {
+	t.Parallel()
+
+	// The first target finished; the log was cut off part way through the second
+	text := readFixture(t, "two_targets.log") + "\n" + readFixture(t, "truncated.log")
+
+	truncated, _ := detectTruncation(text, 60, 0, 0, DefaultSummaryPatterns)
+	assert.True(t, truncated)
+}
+
+func TestDetectTruncation_EmptyLog(t *testing.T) {
+	t.Parallel()
+
+	truncated, _ := detectTruncation("", 0, 0, 0, DefaultSummaryPatterns)
+	assert.False(t, truncated)
+}
+
+func TestIsEditClass(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isEditClass("Edit"))
+	assert.True(t, isEditClass("RichEdit20W"))
+	assert.True(t, isEditClass("RICHEDIT50W"))
+	assert.False(t, isEditClass("Button"))
+	assert.False(t, isEditClass("EditBox"))
+}
+
+func TestCompiler_RaiseMessageLogLimit(t *testing.T) {
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(0x9999,
+			windows.ChildInfo{Hwnd: messageLogHwnd, ClassName: "Edit"},
+			windows.ChildInfo{Hwnd: 0x6666, ClassName: "Button", Text: "OK"},
+			windows.ChildInfo{Hwnd: 0x7777, ClassName: "RichEdit20W"},
+			windows.ChildInfo{Hwnd: 0x8888, ClassName: "Edit"},
+		)
+	mockCtrl := testutil.NewMockControlReader().
+		WithTextLimit(messageLogHwnd, 30000).
+		WithTextLimit(0x7777, 64000).
+		WithTextLimit(0x8888, messageLogTextLimit)
+
+	c := NewCompiler(logger.NewNoOpLogger(), WithWindowManager(mockWin), WithControlReader(mockCtrl))
+	c.raiseMessageLogLimit(0x9999)
+
+	assert.Equal(t, []testutil.SetTextLimitCall{
+		{Hwnd: messageLogHwnd, Limit: messageLogTextLimit},
+		{Hwnd: 0x7777, Limit: messageLogTextLimit},
+	}, mockCtrl.SetTextLimitCalls, "buttons and controls already at the maximum are left alone")
+}
+
+func TestCompiler_RaiseMessageLogLimitFailureIsNotFatal(t *testing.T) {
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(0x9999, windows.ChildInfo{Hwnd: messageLogHwnd, ClassName: "Edit"})
+	mockCtrl := testutil.NewMockControlReader().WithSetTextLimitFails()
+
+	c := NewCompiler(logger.NewNoOpLogger(), WithWindowManager(mockWin), WithControlReader(mockCtrl))
+	c.raiseMessageLogLimit(0x9999)
+
+	assert.Len(t, mockCtrl.SetTextLimitCalls, 1)
+	assert.Zero(t, mockCtrl.TextLimits[messageLogHwnd])
+}
+
+// compileWithMessageLog runs a compile whose Message Log control holds text
+func compileWithMessageLog(t *testing.T, text string, mockCtrl *testutil.MockControlReader) (*CompileResult, error) {
+	t.Helper()
+
+	testutil.SetupMonitorChannel()
+	t.Cleanup(testutil.CleanupMonitorChannel)
+
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(0x9999, windows.ChildInfo{Hwnd: messageLogHwnd, ClassName: "Edit", Text: text}).
+		WithWindowValid(0x1111, false)
+
+	c := NewCompiler(logger.NewNoOpLogger(),
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(mockWin),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(mockCtrl),
+	)
+
+	testutil.SendEventsToMonitor(
+		windows.WindowEvent{Hwnd: 0x1111, Title: "VisionTools Pro-e Compiling..."},
+	)
+
+	return c.Compile(CompileOptions{
+		Hwnd:                          0x9999,
+		VTProPid:                      1234,
+		SkipPreCompilationDialogCheck: true,
+	})
+}
+
+func TestCompiler_TruncatedLogIsNotCancelled(t *testing.T) {
+	result, err := compileWithMessageLog(t, readFixture(t, "truncated.log"), testutil.NewMockControlReader())
+
+	require.NoError(t, err)
+	assert.True(t, result.LogTruncated)
+	assert.False(t, result.Cancelled)
+	assert.Len(t, result.Messages, 62, "61 VTPro warnings, the last one cut off, plus the truncation warning")
+	assert.Contains(t, result.WarningMessages, truncatedLogWarning)
+}
+
+func TestCompiler_LogAtTextLimitIsTruncated(t *testing.T) {
+	text := readFixture(t, "two_targets.log")
+	mockCtrl := testutil.NewMockControlReader().
+		WithTextLength(messageLogHwnd, len(text)).
+		WithTextLimit(messageLogHwnd, messageLogTextLimit).
+		WithSetTextLimitFails()
+
+	// The limit could not be raised and the log filled the control
+	mockCtrl.TextLimits[messageLogHwnd] = len(text)
+
+	result, err := compileWithMessageLog(t, text, mockCtrl)
+
+	require.Error(t, err, "the second target failed")
+	assert.True(t, result.LogTruncated)
+	assert.Equal(t, 1, strings.Count(strings.Join(result.WarningMessages, "\n"), truncatedLogWarning))
+}
+
+func TestCompiler_CompleteLogIsNotTruncated(t *testing.T) {
+	mockCtrl := testutil.NewMockControlReader()
+
+	result, err := compileWithMessageLog(t, readFixture(t, "two_targets.log"), mockCtrl)
+
+	require.Error(t, err)
+	assert.False(t, result.LogTruncated)
+	assert.NotContains(t, result.WarningMessages, truncatedLogWarning)
+
+	// The limit was raised before F12
+	require.Len(t, mockCtrl.SetTextLimitCalls, 1)
+	assert.Equal(t, messageLogTextLimit, mockCtrl.SetTextLimitCalls[0].Limit)
+}
@@ -0,0 +1,67 @@
+package compiler
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// ErrInputBlocked is returned when the compile keystroke never reached VTPro
+// because a window at a higher integrity level had the foreground
+var ErrInputBlocked = errcode.New(errcode.InputBlocked, "input blocked by elevated window")
+
+// diagnoseBlockedInput explains a Compiling dialog that never appeared. When an
+// elevated window (a UAC-level installer, say) is in the foreground, UIPI drops
+// SendInput without reporting an error, so the compile would otherwise look
+// like a plain timeout. It returns nil when input was not blocked, or when the
+// foreground window's integrity level cannot be read.
+func (c *Compiler) diagnoseBlockedInput(vtproHwnd uintptr) error {
+	fg := c.windowMgr.GetForegroundWindow()
+	if fg == 0 || fg == vtproHwnd {
+		return nil
+	}
+
+	fgPid := c.windowMgr.GetWindowPid(fg)
+	if fgPid == 0 {
+		return nil
+	}
+
+	own, err := c.windowMgr.GetProcessIntegrity(uint32(os.Getpid()))
+	if err != nil {
+		c.log.Debug("Could not read vtpc's integrity level", slog.Any("error", err))
+		return nil
+	}
+
+	target, err := c.windowMgr.GetProcessIntegrity(fgPid)
+	if err != nil {
+		c.log.Debug("Could not read the foreground window's integrity level",
+			slog.Uint64("pid", uint64(fgPid)),
+			slog.Any("error", err),
+		)
+
+		return nil
+	}
+
+	title := c.windowMgr.GetWindowText(fg)
+	process := c.windowMgr.GetProcessName(fgPid)
+
+	c.log.Debug("Foreground window after compile keystroke",
+		slog.Uint64("hwnd", uint64(fg)),
+		slog.String("title", title),
+		slog.String("process", process),
+		slog.String("integrity", target.String()),
+		slog.String("own_integrity", own.String()),
+	)
+
+	if own.CanSendInputTo(target) {
+		return nil
+	}
+
+	if process == "" {
+		process = fmt.Sprintf("pid %d", fgPid)
+	}
+
+	return fmt.Errorf("%w '%s' (%s)", ErrInputBlocked, title, process)
+}
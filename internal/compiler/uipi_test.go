@@ -0,0 +1,124 @@
+package compiler
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+const (
+	vtproTestHwnd   = uintptr(0x9999)
+	elevatedHwnd    = uintptr(0x5555)
+	elevatedPid     = uint32(4321)
+	elevatedTitle   = "Setup - Crestron Toolbox"
+	elevatedProcess = "toolbox_setup.exe"
+)
+
+// ownPid is the PID diagnoseBlockedInput compares the foreground window against
+var ownPid = uint32(os.Getpid())
+
+// newUIPICompiler creates a compiler whose foreground window is an installer at the given integrity level
+func newUIPICompiler(own, installer windows.IntegrityLevel) *Compiler {
+	mockWin := testutil.NewMockWindowManager().
+		WithForegroundSequence(elevatedHwnd).
+		WithWindowPid(elevatedHwnd, elevatedPid).
+		WithWindowText(elevatedHwnd, elevatedTitle).
+		WithProcess(ownPid, "vtpc.exe", own).
+		WithProcess(elevatedPid, elevatedProcess, installer)
+
+	return NewCompiler(logger.NewNoOpLogger(), WithWindowManager(mockWin))
+}
+
+func TestDiagnoseBlockedInput_ElevatedForeground(t *testing.T) {
+	t.Parallel()
+
+	c := newUIPICompiler(windows.IntegrityMedium, windows.IntegrityHigh)
+
+	err := c.diagnoseBlockedInput(vtproTestHwnd)
+	require.ErrorIs(t, err, ErrInputBlocked)
+	assert.EqualError(t, err, "input blocked by elevated window 'Setup - Crestron Toolbox' (toolbox_setup.exe)")
+}
+
+func TestDiagnoseBlockedInput_SameIntegrityIsNotBlocked(t *testing.T) {
+	t.Parallel()
+
+	c := newUIPICompiler(windows.IntegrityHigh, windows.IntegrityHigh)
+	assert.NoError(t, c.diagnoseBlockedInput(vtproTestHwnd))
+}
+
+func TestDiagnoseBlockedInput_VTProInForeground(t *testing.T) {
+	t.Parallel()
+
+	mockWin := testutil.NewMockWindowManager().WithForegroundSequence(vtproTestHwnd)
+	c := NewCompiler(logger.NewNoOpLogger(), WithWindowManager(mockWin))
+
+	assert.NoError(t, c.diagnoseBlockedInput(vtproTestHwnd))
+}
+
+func TestDiagnoseBlockedInput_UnreadableIntegrity(t *testing.T) {
+	t.Parallel()
+
+	// A process vtpc cannot open gives no evidence either way, so the timeout stands
+	mockWin := testutil.NewMockWindowManager().
+		WithForegroundSequence(elevatedHwnd).
+		WithWindowPid(elevatedHwnd, elevatedPid).
+		WithProcess(ownPid, "vtpc.exe", windows.IntegrityMedium)
+	c := NewCompiler(logger.NewNoOpLogger(), WithWindowManager(mockWin))
+
+	assert.NoError(t, c.diagnoseBlockedInput(vtproTestHwnd))
+}
+
+func TestDiagnoseBlockedInput_UnknownProcessName(t *testing.T) {
+	t.Parallel()
+
+	mockWin := testutil.NewMockWindowManager().
+		WithForegroundSequence(elevatedHwnd).
+		WithWindowPid(elevatedHwnd, elevatedPid).
+		WithWindowText(elevatedHwnd, elevatedTitle).
+		WithProcess(ownPid, "vtpc.exe", windows.IntegrityHigh).
+		WithProcess(elevatedPid, "", windows.IntegritySystem)
+	c := NewCompiler(logger.NewNoOpLogger(), WithWindowManager(mockWin))
+
+	assert.EqualError(t, c.diagnoseBlockedInput(vtproTestHwnd), "input blocked by elevated window 'Setup - Crestron Toolbox' (pid 4321)")
+}
+
+func TestCompiler_BlockedInputReplacesTimeout(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	// F12 appears to reach VTPro, but by the time the compile times out an
+	// elevated installer is in the foreground and no Compiling dialog ever showed
+	mockWin := testutil.NewMockWindowManager().
+		WithForegroundSequence(vtproTestHwnd, elevatedHwnd).
+		WithWindowPid(elevatedHwnd, elevatedPid).
+		WithWindowText(elevatedHwnd, elevatedTitle).
+		WithProcess(ownPid, "vtpc.exe", windows.IntegrityMedium).
+		WithProcess(elevatedPid, elevatedProcess, windows.IntegrityHigh)
+
+	comp := NewCompiler(logger.NewNoOpLogger(),
+		WithProcessManager(testutil.NewMockProcessManager().WithPid(1234)),
+		WithWindowManager(mockWin),
+		WithKeyboard(testutil.NewMockKeyboardInjector()),
+		WithControlReader(testutil.NewMockControlReader()),
+	)
+
+	result, err := comp.Compile(CompileOptions{
+		Hwnd:                          vtproTestHwnd,
+		VTProPid:                      1234,
+		SkipPreCompilationDialogCheck: true,
+		CompilationTimeout:            100 * time.Millisecond,
+	})
+
+	require.ErrorIs(t, err, ErrInputBlocked)
+	assert.NotErrorIs(t, err, ErrCompileTimeout)
+	assert.Contains(t, err.Error(), elevatedProcess)
+	require.NotNil(t, result)
+	assert.True(t, result.HasErrors)
+}
@@ -0,0 +1,61 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestReadMessageLog_NormalizesText(t *testing.T) {
+	// Smart quotes, a no-break space and a decomposed accent as VTPro may render them
+	raw := "---------- Compiling for TSW-770: [Maison.vtp] ---------\n" +
+		"Chambre a\u0300\u00a0coucher\n" +
+		"\t[ warning ]: Object \u201cVolume\u201d on Page \u201cChambre a\u0300 coucher\u201d has an unassigned Smart Object ID.\n" +
+		"---------- Successful ---------\n" +
+		"1\u00a0warning(s), 0\u00a0error(s)"
+
+	mockWin := testutil.NewMockWindowManager().
+		WithChildInfosForHwnd(0x9999, windows.ChildInfo{ClassName: "Edit", Text: raw})
+
+	c := NewCompiler(logger.NewNoOpLogger(), WithWindowManager(mockWin))
+
+	text, _ := c.readMessageLog(0x9999)
+	assert.Contains(t, text, "Chambre à coucher\n")
+
+	result := &CompileResult{}
+	c.parseVTProOutput(text, result)
+
+	assert.Equal(t, 1, result.Warnings)
+	assert.Equal(t, 0, result.Errors)
+	require.Len(t, result.WarningMessages, 1)
+	assert.Equal(t, "Object \"Volume\" on Page \"Chambre à coucher\" has an unassigned Smart Object ID.", result.WarningMessages[0])
+}
+
+func TestParseVTProOutput_AccentedAndCJKNames(t *testing.T) {
+	c := NewCompiler(logger.NewNoOpLogger())
+
+	output := `---------- Compiling for TSW-1070: [C:\Projects\会議室.vtp] ---------
+Boot
+Chambre_à_coucher
+会議室
+	[ error ]: Object "Écran" on Page "会議室" has an invalid join number.
+	[ warning ]: Object "Lumière" on Page "Chambre_à_coucher" references a missing image.
+Salle_de_séjour
+---------- Failed ---------
+1 warning(s), 1 error(s)`
+
+	result := &CompileResult{}
+	c.parseVTProOutput(output, result)
+
+	require.Len(t, result.Sections, 1)
+	assert.Equal(t, "TSW-1070", result.Sections[0].Target)
+	assert.Equal(t, []string{"Object \"Écran\" on Page \"会議室\" has an invalid join number."}, result.ErrorMessages)
+	assert.Equal(t, []string{"Object \"Lumière\" on Page \"Chambre_à_coucher\" references a missing image."}, result.WarningMessages)
+	assert.Equal(t, 1, result.Warnings)
+	assert.Equal(t, 1, result.Errors)
+}
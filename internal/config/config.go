@@ -0,0 +1,204 @@
+// Package config loads the optional vtpc configuration file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// FileName is the name of the configuration file in the vtpc data directory
+const FileName = "config.yaml"
+
+// File is the on-disk configuration file format
+type File struct {
+	Parser      ParserConfig               `yaml:"parser"`
+	Rules       map[string]RulePolicies    `yaml:"rules"`       // Policy per message rule ID, e.g. "path-length-warning"
+	Diagnostics map[string]RetentionConfig `yaml:"diagnostics"` // Limits per diagnostics category, e.g. "screenshots"
+	Report      ReportConfig               `yaml:"report"`
+	Schedule    ScheduleConfig             `yaml:"schedule"`
+	Notify      NotifyConfig               `yaml:"notify"`
+	VTPro       VTProConfig                `yaml:"vtpro"`
+	Telemetry   TelemetryConfig            `yaml:"telemetry"`
+}
+
+// Telemetry states, as the config file and vtpc telemetry on and off write them
+const (
+	TelemetryEnabled  = "enabled"
+	TelemetryDisabled = "disabled"
+)
+
+// TelemetryConfig opts in to sending an anonymous summary of each run. In YAML
+// it is a bare state ("enabled"), or a mapping with state and endpoint.
+type TelemetryConfig struct {
+	// State is "enabled" or "disabled". Empty is disabled; telemetry is never on by default.
+	State string `yaml:"state"`
+
+	// Endpoint is the http or https URL each run's summary is posted to.
+	// Nothing is sent without one.
+	Endpoint string `yaml:"endpoint"`
+}
+
+// Enabled reports whether the user has opted in
+func (t TelemetryConfig) Enabled() bool {
+	return t.State == TelemetryEnabled
+}
+
+// UnmarshalYAML accepts a bare state as shorthand for a mapping with no endpoint
+func (t *TelemetryConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&t.State)
+	}
+
+	type plain TelemetryConfig
+
+	return node.Decode((*plain)(t))
+}
+
+// VTProConfig describes the VTPro build, for builds whose windows differ from
+// the ones vtpc recognises by default
+type VTProConfig struct {
+	// MainWindowClass is the window class of VTPro's main window, as
+	// --main-window-class sets. The flag takes precedence. Empty is "VWT32AppClass".
+	MainWindowClass string `yaml:"mainWindowClass"`
+
+	// SplashTitle is the title of VTPro's splash screen. Empty is "VTPro".
+	SplashTitle string `yaml:"splashTitle"`
+
+	// SplashClass is the window class of VTPro's splash screen. Empty
+	// recognises the splash screen by its title and size alone.
+	SplashClass string `yaml:"splashClass"`
+}
+
+// NotifyConfig configures how vtpc lets the user know a run has finished
+type NotifyConfig struct {
+	// Bell plays a sound when a run finishes, as --bell does. The flag takes precedence.
+	Bell bool `yaml:"bell"`
+}
+
+// ScheduleConfig restricts compiles to maintenance windows. With no windows,
+// compiles may run at any time.
+type ScheduleConfig struct {
+	// Timezone is the IANA name the windows are in, e.g. "Europe/London".
+	// Empty is the machine's local time.
+	Timezone string `yaml:"timezone"`
+
+	// Windows are the allowed "HH:MM-HH:MM" periods per lower-case weekday
+	// name, or "daily" for every day. A window past midnight, e.g.
+	// "19:00-07:00", runs into the next morning.
+	Windows map[string][]string `yaml:"windows"`
+}
+
+// ReportConfig configures the reports written with --out
+type ReportConfig struct {
+	// MessageLinkTemplate links each message to its source, as --message-link-template
+	// does. The flag takes precedence.
+	MessageLinkTemplate string `yaml:"messageLinkTemplate"`
+}
+
+// RetentionConfig limits how many diagnostic files of a category are kept.
+// A limit left out keeps its default; 0 removes it.
+type RetentionConfig struct {
+	MaxFiles *int   `yaml:"maxFiles"`
+	MaxMB    *int64 `yaml:"maxMB"`
+}
+
+// ParserConfig configures how the VTPro Message Log is parsed
+type ParserConfig struct {
+	// SummaryPatterns are extra summary-line patterns tried after the built-in ones.
+	// Useful for localized VTPro builds that word the summary line differently.
+	SummaryPatterns []SummaryPatternConfig `yaml:"summaryPatterns"`
+
+	// MaxContinuations caps how many wrapped lines are joined onto one message.
+	// Zero uses the built-in default.
+	MaxContinuations int `yaml:"maxContinuations"`
+
+	// IgnorePages are case-insensitive globs of pages whose messages are left
+	// out of the results, added to any given with --ignore-pages
+	IgnorePages []string `yaml:"ignorePages"`
+}
+
+// SummaryPatternConfig is a user-supplied summary-line regular expression.
+// The pattern must contain the named groups "warnings" and "errors".
+type SummaryPatternConfig struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+}
+
+// RulePolicies is the policy for one message rule. In YAML it is an action
+// ("error"), a mapping with pages, objects and action, or a list of either.
+type RulePolicies []RulePolicyConfig
+
+// UnmarshalYAML accepts a single policy as well as a list of policies
+func (p *RulePolicies) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		var policies []RulePolicyConfig
+		if err := node.Decode(&policies); err != nil {
+			return err
+		}
+
+		*p = policies
+
+		return nil
+	}
+
+	var policy RulePolicyConfig
+	if err := node.Decode(&policy); err != nil {
+		return err
+	}
+
+	*p = RulePolicies{policy}
+
+	return nil
+}
+
+// RulePolicyConfig changes how matching messages of a rule are reported.
+// Pages and Objects are glob patterns; when set, a message must name a
+// matching page or object for the policy to apply.
+type RulePolicyConfig struct {
+	Action  string   `yaml:"action"` // "error", "warning" or "ignore"
+	Pages   []string `yaml:"pages"`
+	Objects []string `yaml:"objects"`
+}
+
+// UnmarshalYAML accepts a bare action as shorthand for a policy with no filters
+func (c *RulePolicyConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&c.Action)
+	}
+
+	type plain RulePolicyConfig
+
+	return node.Decode((*plain)(c))
+}
+
+// DefaultPath returns the default config file location, next to the log file
+func DefaultPath() string {
+	return filepath.Join(filepath.Dir(logger.GetLogPath(logger.LoggerOptions{})), FileName)
+}
+
+// Load reads the configuration file at path.
+// A missing file is not an error and yields an empty configuration.
+func Load(path string) (*File, error) {
+	cfg := &File{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,202 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/config"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.NoError(t, err)
+	assert.NotNil(t, cfg)
+	assert.Empty(t, cfg.Parser.SummaryPatterns)
+}
+
+func TestLoad_SummaryPatterns(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `parser:
+  summaryPatterns:
+    - name: german
+      pattern: '(?P<warnings>\d+) Warnung\(en\), (?P<errors>\d+) Fehler'
+  maxContinuations: 12
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Parser.SummaryPatterns, 1)
+	assert.Equal(t, "german", cfg.Parser.SummaryPatterns[0].Name)
+	assert.Equal(t, `(?P<warnings>\d+) Warnung\(en\), (?P<errors>\d+) Fehler`, cfg.Parser.SummaryPatterns[0].Pattern)
+	assert.Equal(t, 12, cfg.Parser.MaxContinuations)
+}
+
+func TestLoad_InvalidYAML(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("parser: [unclosed"), 0o644))
+
+	_, err := config.Load(path)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), path)
+}
+
+func TestDefaultPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("LOCALAPPDATA", tmpDir)
+
+	assert.Equal(t, filepath.Join(tmpDir, "vtpc", config.FileName), config.DefaultPath())
+}
+
+func TestLoad_Rules(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `rules:
+  path-length-warning: error
+  unassigned-smart-object-id:
+    pages: ["Debug*"]
+    action: ignore
+  missing-join:
+    - warning
+    - objects: ["Spare *"]
+      pages: ["Service"]
+      action: ignore
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, config.RulePolicies{{Action: "error"}}, cfg.Rules["path-length-warning"])
+	assert.Equal(t, config.RulePolicies{{Action: "ignore", Pages: []string{"Debug*"}}}, cfg.Rules["unassigned-smart-object-id"])
+	assert.Equal(t, config.RulePolicies{
+		{Action: "warning"},
+		{Action: "ignore", Pages: []string{"Service"}, Objects: []string{"Spare *"}},
+	}, cfg.Rules["missing-join"])
+}
+
+func TestLoad_InvalidRules(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("rules:\n  missing-join:\n    pages: Debug\n"), 0o644))
+
+	_, err := config.Load(path)
+	assert.Error(t, err, "pages must be a list")
+}
+
+func TestLoad_Schedule(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `schedule:
+  timezone: Europe/London
+  windows:
+    daily: ["19:00-07:00"]
+    saturday: ["00:00-24:00"]
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Europe/London", cfg.Schedule.Timezone)
+	assert.Equal(t, map[string][]string{"daily": {"19:00-07:00"}, "saturday": {"00:00-24:00"}}, cfg.Schedule.Windows)
+}
+
+func TestLoad_Notify(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("notify:\n  bell: true\n"), 0o644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.True(t, cfg.Notify.Bell)
+}
+
+func TestLoad_VTPro(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "vtpro:\n  mainWindowClass: VWT64AppClass\n  splashTitle: VT Pro-e\n  splashClass: VTSplash\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, config.VTProConfig{MainWindowClass: "VWT64AppClass", SplashTitle: "VT Pro-e", SplashClass: "VTSplash"}, cfg.VTPro)
+}
+
+func TestLoad_Telemetry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    config.TelemetryConfig
+	}{
+		{"absent", "notify:\n  bell: true\n", config.TelemetryConfig{}},
+		{"bare state", "telemetry: enabled\n", config.TelemetryConfig{State: config.TelemetryEnabled}},
+		{
+			"mapping",
+			"telemetry:\n  state: enabled\n  endpoint: https://telemetry.example.com/v1\n",
+			config.TelemetryConfig{State: config.TelemetryEnabled, Endpoint: "https://telemetry.example.com/v1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+
+			cfg, err := config.Load(path)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, cfg.Telemetry)
+			assert.Equal(t, tt.want.State == config.TelemetryEnabled, cfg.Telemetry.Enabled())
+		})
+	}
+}
+
+func TestLoad_Diagnostics(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `diagnostics:
+  screenshots:
+    maxFiles: 10
+  dumps:
+    maxFiles: 0
+    maxMB: 250
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	screenshots := cfg.Diagnostics["screenshots"]
+	require.NotNil(t, screenshots.MaxFiles)
+	assert.Equal(t, 10, *screenshots.MaxFiles)
+	assert.Nil(t, screenshots.MaxMB, "a limit left out keeps its default")
+
+	dumps := cfg.Diagnostics["dumps"]
+	require.NotNil(t, dumps.MaxFiles)
+	assert.Equal(t, 0, *dumps.MaxFiles)
+	require.NotNil(t, dumps.MaxMB)
+	assert.Equal(t, int64(250), *dumps.MaxMB)
+}
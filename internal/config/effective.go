@@ -0,0 +1,117 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Source is where the final value of a setting came from
+type Source string
+
+const (
+	SourceFlag    Source = "flag"         // Given on the command line
+	SourceEnv     Source = "env"          // Read from an environment variable
+	SourceProject Source = "project-file" // Read from the project file next to the project
+	SourceFile    Source = "config-file"  // Read from the config file
+	SourceDefault Source = "default"      // Left at the built-in default
+)
+
+// Candidate is the value one source gives a setting. Set is false when the
+// source leaves the setting alone.
+type Candidate[T any] struct {
+	Source Source
+	Value  T
+	Set    bool
+}
+
+// Resolve returns the value of the first candidate that is set and its source,
+// or def when none is. Candidates are given highest precedence first.
+func Resolve[T any](def T, candidates ...Candidate[T]) (T, Source) {
+	for _, c := range candidates {
+		if c.Set {
+			return c.Value, c.Source
+		}
+	}
+
+	return def, SourceDefault
+}
+
+// ResolveAll joins the values of every candidate that is set, in the order
+// given, for list settings that add up across sources rather than override.
+// The source names each contributing source, e.g. "config-file+flag".
+func ResolveAll[T any](candidates ...Candidate[[]T]) ([]T, Source) {
+	var (
+		values  []T
+		sources []string
+	)
+
+	for _, c := range candidates {
+		if c.Set {
+			values = append(values, c.Value...)
+			sources = append(sources, string(c.Source))
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil, SourceDefault
+	}
+
+	return values, Source(strings.Join(sources, "+"))
+}
+
+// Setting is the final value of one option and where it came from
+type Setting struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source Source `json:"source"`
+}
+
+// Effective is the configuration a run ends up with once flags, environment
+// variables, the project file, the config file and defaults are merged
+type Effective struct {
+	settings map[string]Setting
+}
+
+// NewEffective creates an empty effective configuration
+func NewEffective() *Effective {
+	return &Effective{settings: make(map[string]Setting)}
+}
+
+// Set records the final value of a setting, replacing any recorded before
+func (e *Effective) Set(name, value string, source Source) {
+	e.settings[name] = Setting{Name: name, Value: value, Source: source}
+}
+
+// Get returns the setting with the given name
+func (e *Effective) Get(name string) (Setting, bool) {
+	s, ok := e.settings[name]
+	return s, ok
+}
+
+// Settings returns every setting, sorted by name
+func (e *Effective) Settings() []Setting {
+	settings := make([]Setting, 0, len(e.settings))
+	for _, s := range e.settings {
+		settings = append(settings, s)
+	}
+
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Name < settings[j].Name })
+
+	return settings
+}
+
+// Fingerprint hashes every setting's name and value, so runs with the same
+// configuration share a fingerprint however it was given. Sources are left
+// out: --bell and bell: true in the config file configure the same run.
+func (e *Effective) Fingerprint() string {
+	h := sha256.New()
+
+	for _, s := range e.Settings() {
+		fmt.Fprintf(h, "%s=%q\n", s.Name, s.Value)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
@@ -0,0 +1,124 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/config"
+)
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	flag := config.Candidate[string]{Source: config.SourceFlag, Value: "from-flag", Set: true}
+	env := config.Candidate[string]{Source: config.SourceEnv, Value: "from-env", Set: true}
+	file := config.Candidate[string]{Source: config.SourceFile, Value: "from-file", Set: true}
+	unset := func(c config.Candidate[string]) config.Candidate[string] { c.Set = false; return c }
+
+	tests := []struct {
+		name       string
+		candidates []config.Candidate[string]
+		wantValue  string
+		wantSource config.Source
+	}{
+		{"flag wins", []config.Candidate[string]{flag, env, file}, "from-flag", config.SourceFlag},
+		{"env over file", []config.Candidate[string]{unset(flag), env, file}, "from-env", config.SourceEnv},
+		{"file", []config.Candidate[string]{unset(flag), unset(env), file}, "from-file", config.SourceFile},
+		{"default", []config.Candidate[string]{unset(flag), unset(env), unset(file)}, "default", config.SourceDefault},
+		{"no candidates", nil, "default", config.SourceDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			value, source := config.Resolve("default", tt.candidates...)
+			assert.Equal(t, tt.wantValue, value)
+			assert.Equal(t, tt.wantSource, source)
+		})
+	}
+}
+
+func TestResolve_SetToZeroValue(t *testing.T) {
+	t.Parallel()
+
+	// --bell=false given on the command line still beats the config file
+	value, source := config.Resolve(false,
+		config.Candidate[bool]{Source: config.SourceFlag, Value: false, Set: true},
+		config.Candidate[bool]{Source: config.SourceFile, Value: true, Set: true},
+	)
+
+	assert.False(t, value)
+	assert.Equal(t, config.SourceFlag, source)
+}
+
+func TestResolveAll(t *testing.T) {
+	t.Parallel()
+
+	file := config.Candidate[[]string]{Source: config.SourceFile, Value: []string{"ZZ_*"}, Set: true}
+	flag := config.Candidate[[]string]{Source: config.SourceFlag, Value: []string{"Test*", "Old*"}, Set: true}
+
+	values, source := config.ResolveAll(file, flag)
+	assert.Equal(t, []string{"ZZ_*", "Test*", "Old*"}, values)
+	assert.Equal(t, config.Source("config-file+flag"), source)
+
+	flag.Set = false
+	values, source = config.ResolveAll(file, flag)
+	assert.Equal(t, []string{"ZZ_*"}, values)
+	assert.Equal(t, config.SourceFile, source)
+
+	file.Set = false
+	values, source = config.ResolveAll(file, flag)
+	assert.Nil(t, values)
+	assert.Equal(t, config.SourceDefault, source)
+}
+
+func TestEffective_Settings(t *testing.T) {
+	t.Parallel()
+
+	e := config.NewEffective()
+	e.Set("timeout", "5m0s", config.SourceDefault)
+	e.Set("bell", "true", config.SourceFile)
+	e.Set("timeout", "10m0s", config.SourceFlag)
+
+	assert.Equal(t, []config.Setting{
+		{Name: "bell", Value: "true", Source: config.SourceFile},
+		{Name: "timeout", Value: "10m0s", Source: config.SourceFlag},
+	}, e.Settings(), "sorted by name, the last value recorded for a name wins")
+
+	s, ok := e.Get("timeout")
+	assert.True(t, ok)
+	assert.Equal(t, "10m0s", s.Value)
+
+	_, ok = e.Get("missing")
+	assert.False(t, ok)
+}
+
+func TestEffective_Fingerprint(t *testing.T) {
+	t.Parallel()
+
+	a := config.NewEffective()
+	a.Set("bell", "true", config.SourceFlag)
+	a.Set("format", "list", config.SourceDefault)
+
+	b := config.NewEffective()
+	b.Set("format", "list", config.SourceDefault)
+	b.Set("bell", "true", config.SourceFile)
+
+	assert.Len(t, a.Fingerprint(), 12)
+	assert.Equal(t, a.Fingerprint(), b.Fingerprint(), "order and sources do not change the fingerprint")
+
+	b.Set("format", "table", config.SourceFlag)
+	assert.NotEqual(t, a.Fingerprint(), b.Fingerprint(), "a different value changes the fingerprint")
+
+	// Quoting keeps a value containing the separator from colliding with two settings
+	c := config.NewEffective()
+	c.Set("a", "1\nb=2", config.SourceFlag)
+
+	d := config.NewEffective()
+	d.Set("a", "1", config.SourceFlag)
+	d.Set("b", "2", config.SourceFlag)
+
+	assert.NotEqual(t, c.Fingerprint(), d.Fingerprint())
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// ProjectFileSuffix is added to a project's file name to name its project
+// file, e.g. "Panel.vtp.vtpc.yaml" next to "Panel.vtp"
+const ProjectFileSuffix = ".vtpc.yaml"
+
+// ErrInvalidProjectFile is returned when a project file cannot be read or
+// sets a key to a value it cannot have
+var ErrInvalidProjectFile = errcode.New(errcode.ProjectFile, "invalid project file")
+
+// ProjectFile is the optional file next to a project for the settings that
+// travel with it. Each setting is one a flag also gives: the flag takes
+// precedence over the project file, and the project file over the config file.
+type ProjectFile struct {
+	// Path is where the project file was read from, "" if the project has none
+	Path string `yaml:"-"`
+
+	ExpectTitle    string        `yaml:"expectTitle"`    // As --expect-title
+	OutDir         string        `yaml:"outDir"`         // As --out-dir; a relative path is from the project's directory
+	MaxCompileTime time.Duration `yaml:"maxCompileTime"` // As --max-compile-time, e.g. "90s"
+	IgnorePages    []string      `yaml:"ignorePages"`    // Added to the config file's and those given with --ignore-pages
+	Deploy         string        `yaml:"deploy"`         // As --deploy
+}
+
+// ProjectFilePath returns where the project file of the project at path is
+func ProjectFilePath(project string) string {
+	return project + ProjectFileSuffix
+}
+
+// LoadProject reads the project file next to the project at path. A project
+// without one is not an error and yields an empty project file.
+func LoadProject(project string) (*ProjectFile, error) {
+	path := ProjectFilePath(project)
+	p := &ProjectFile{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProjectFile, err)
+	}
+
+	p.Path = path
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidProjectFile, path, yamlError(err))
+	}
+
+	// An empty file sets nothing
+	if len(doc.Content) == 0 {
+		return p, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%w: %s: line %d: expected a mapping of settings", ErrInvalidProjectFile, path, root.Line)
+	}
+
+	fields := map[string]any{
+		"expectTitle":    &p.ExpectTitle,
+		"outDir":         &p.OutDir,
+		"maxCompileTime": &p.MaxCompileTime,
+		"ignorePages":    &p.IgnorePages,
+		"deploy":         &p.Deploy,
+	}
+
+	// Decoded a key at a time so every error names the key it is about
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+
+		field, ok := fields[key.Value]
+		if !ok {
+			return nil, p.KeyError(key.Value, fmt.Errorf("line %d: unknown key", key.Line))
+		}
+
+		if err := value.Decode(field); err != nil {
+			return nil, p.KeyError(key.Value, errors.New(yamlError(err)))
+		}
+	}
+
+	if p.OutDir != "" && !filepath.IsAbs(p.OutDir) {
+		p.OutDir = filepath.Join(filepath.Dir(project), p.OutDir)
+	}
+
+	return p, nil
+}
+
+// KeyError returns err as a problem with the project file's key, naming the
+// file and the key
+func (p *ProjectFile) KeyError(key string, err error) error {
+	return fmt.Errorf("%w: %s: %s: %w", ErrInvalidProjectFile, p.Path, key, err)
+}
+
+// yamlError returns a YAML error's message on one line, without the "yaml:"
+// prefix every message has
+func yamlError(err error) string {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		return strings.Join(typeErr.Errors, "; ")
+	}
+
+	return strings.TrimPrefix(err.Error(), "yaml: ")
+}
@@ -0,0 +1,125 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/config"
+)
+
+// writeProject creates an empty project at rel under root, with a project file
+// holding content unless content is ""
+func writeProject(t *testing.T, root, rel, content string) string {
+	t.Helper()
+
+	project := filepath.Join(root, rel)
+	require.NoError(t, os.MkdirAll(filepath.Dir(project), 0o755))
+	require.NoError(t, os.WriteFile(project, nil, 0o644))
+
+	if content != "" {
+		require.NoError(t, os.WriteFile(config.ProjectFilePath(project), []byte(content), 0o644))
+	}
+
+	return project
+}
+
+func TestProjectFilePath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, filepath.Join("panels", "Lobby.vtp.vtpc.yaml"), config.ProjectFilePath(filepath.Join("panels", "Lobby.vtp")))
+}
+
+func TestLoadProject_Missing(t *testing.T) {
+	t.Parallel()
+
+	project := writeProject(t, t.TempDir(), "Lobby.vtp", "")
+
+	p, err := config.LoadProject(project)
+	require.NoError(t, err)
+	assert.Equal(t, &config.ProjectFile{}, p)
+}
+
+func TestLoadProject(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	project := writeProject(t, root, filepath.Join("panels", "Lobby.vtp"), `expectTitle: Lobby
+outDir: build
+maxCompileTime: 90s
+ignorePages: [ZZ_*, Debug*]
+deploy: sftp://admin@10.0.0.5/display
+`)
+
+	p, err := config.LoadProject(project)
+	require.NoError(t, err)
+	assert.Equal(t, &config.ProjectFile{
+		Path:           config.ProjectFilePath(project),
+		ExpectTitle:    "Lobby",
+		OutDir:         filepath.Join(root, "panels", "build"),
+		MaxCompileTime: 90 * time.Second,
+		IgnorePages:    []string{"ZZ_*", "Debug*"},
+		Deploy:         "sftp://admin@10.0.0.5/display",
+	}, p)
+}
+
+func TestLoadProject_Empty(t *testing.T) {
+	t.Parallel()
+
+	project := writeProject(t, t.TempDir(), "Lobby.vtp", "# nothing to override yet\n")
+
+	p, err := config.LoadProject(project)
+	require.NoError(t, err)
+	assert.Equal(t, config.ProjectFilePath(project), p.Path)
+	assert.Empty(t, p.ExpectTitle)
+}
+
+func TestLoadProject_DiscoveredPerProject(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	lobby := writeProject(t, root, filepath.Join("site", "Lobby.vtp"), "expectTitle: Lobby\n")
+	board := writeProject(t, root, filepath.Join("site", "Boardroom.vtp"), "expectTitle: Boardroom\n")
+	bare := writeProject(t, root, filepath.Join("site", "nested", "Lobby.vtp"), "")
+
+	// A directory-wide file is not a project file
+	require.NoError(t, os.WriteFile(filepath.Join(root, "site", ".vtpc.yaml"), []byte("expectTitle: Site\n"), 0o644))
+
+	for project, want := range map[string]string{lobby: "Lobby", board: "Boardroom", bare: ""} {
+		p, err := config.LoadProject(project)
+		require.NoError(t, err)
+		assert.Equal(t, want, p.ExpectTitle, project)
+	}
+}
+
+func TestLoadProject_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    string // What the error says after the file's path
+	}{
+		{name: "unknown key", content: "expectTitle: Lobby\ntimeout: 90s\n", want: "timeout: line 2: unknown key"},
+		{name: "bad duration", content: "maxCompileTime: soon\n", want: "maxCompileTime: line 1: cannot unmarshal !!str `soon` into time.Duration"},
+		{name: "wrong type", content: "ignorePages:\n  pages: ZZ_*\n", want: "ignorePages: line 2: cannot unmarshal !!map into []string"},
+		{name: "not a mapping", content: "- expectTitle\n", want: "line 1: expected a mapping of settings"},
+		{name: "not YAML", content: "expectTitle: [unclosed\n", want: "line 1: did not find expected ',' or ']'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			project := writeProject(t, t.TempDir(), "Lobby.vtp", tt.content)
+
+			_, err := config.LoadProject(project)
+			require.ErrorIs(t, err, config.ErrInvalidProjectFile)
+			assert.Contains(t, err.Error(), config.ProjectFilePath(project)+": "+tt.want)
+		})
+	}
+}
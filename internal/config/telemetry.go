@@ -0,0 +1,96 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SetTelemetry turns telemetry on or off in the configuration file at path,
+// creating the file if needed. Only the telemetry state changes; the rest of
+// the file, including its comments and the telemetry endpoint, is kept.
+func SetTelemetry(path string, enabled bool) error {
+	state := TelemetryDisabled
+	if enabled {
+		state = TelemetryEnabled
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	doc := yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("failed to update config file %s: it is not a mapping", path)
+	}
+
+	// A telemetry mapping keeps its endpoint; anything else is replaced by the bare state
+	if telemetry := mappingValue(root, "telemetry"); telemetry != nil && telemetry.Kind == yaml.MappingNode {
+		setMappingValue(telemetry, "state", state)
+	} else {
+		setMappingValue(root, "telemetry", state)
+	}
+
+	var out bytes.Buffer
+
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to update config file %s: %w", path, err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to update config file %s: %w", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to update config file %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to update config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// mappingValue returns the value of key in a YAML mapping, nil if it is not there
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// setMappingValue sets key in a YAML mapping to a plain string, adding the key if needed
+func setMappingValue(m *yaml.Node, key, value string) {
+	scalar := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			// Comments on the old value stay with the key
+			scalar.LineComment = m.Content[i+1].LineComment
+			m.Content[i+1] = scalar
+
+			return
+		}
+	}
+
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, scalar)
+}
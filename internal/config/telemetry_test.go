@@ -0,0 +1,74 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/config"
+)
+
+func TestSetTelemetry_CreatesFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "vtpc", config.FileName)
+
+	require.NoError(t, config.SetTelemetry(path, true))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.True(t, cfg.Telemetry.Enabled())
+}
+
+func TestSetTelemetry_KeepsTheRestOfTheFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), config.FileName)
+	content := `# Build machine settings
+notify:
+  bell: true # ring when done
+telemetry:
+  state: enabled
+  endpoint: https://telemetry.example.com/v1
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	require.NoError(t, config.SetTelemetry(path, false))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Build machine settings")
+	assert.Contains(t, string(data), "# ring when done")
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.True(t, cfg.Notify.Bell)
+	assert.Equal(t, config.TelemetryConfig{State: config.TelemetryDisabled, Endpoint: "https://telemetry.example.com/v1"}, cfg.Telemetry)
+}
+
+func TestSetTelemetry_ReplacesBareState(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), config.FileName)
+	require.NoError(t, os.WriteFile(path, []byte("telemetry: disabled\n"), 0o644))
+
+	require.NoError(t, config.SetTelemetry(path, true))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "telemetry: enabled\n", string(data))
+}
+
+func TestSetTelemetry_InvalidFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), config.FileName)
+	require.NoError(t, os.WriteFile(path, []byte("- a list\n"), 0o644))
+
+	err := config.SetTelemetry(path, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), path)
+}
@@ -0,0 +1,35 @@
+// Package cpuid reads the hypervisor a program runs under from the CPUID
+// instruction, which a guest cannot hide from without the hypervisor's help.
+package cpuid
+
+import "strings"
+
+// hypervisorPresent is CPUID leaf 1's ECX bit set by every hypervisor that
+// admits to being one
+const hypervisorPresent = 1 << 31
+
+// hypervisorLeaf is the CPUID leaf whose EBX, ECX and EDX spell the hypervisor's vendor
+const hypervisorLeaf = 0x40000000
+
+// Hypervisor returns the vendor signature of the hypervisor the CPU reports,
+// such as "VMwareVMware" or "Microsoft Hv", and false on bare metal or a CPU
+// without CPUID
+func Hypervisor() (string, bool) {
+	return hypervisor(cpuid)
+}
+
+// hypervisor decodes the vendor from the CPUID leaves query returns
+func hypervisor(query func(leaf, subleaf uint32) (eax, ebx, ecx, edx uint32)) (string, bool) {
+	if _, _, ecx, _ := query(1, 0); ecx&hypervisorPresent == 0 {
+		return "", false
+	}
+
+	_, ebx, ecx, edx := query(hypervisorLeaf, 0)
+
+	var vendor []byte
+	for _, reg := range []uint32{ebx, ecx, edx} {
+		vendor = append(vendor, byte(reg), byte(reg>>8), byte(reg>>16), byte(reg>>24))
+	}
+
+	return strings.TrimRight(string(vendor), "\x00 "), true
+}
@@ -0,0 +1,4 @@
+package cpuid
+
+// cpuid executes the CPUID instruction, implemented in cpuid_amd64.s
+func cpuid(leaf, subleaf uint32) (eax, ebx, ecx, edx uint32)
@@ -0,0 +1,8 @@
+//go:build !amd64
+
+package cpuid
+
+// cpuid reports no hypervisor on architectures vtpc has no CPUID helper for
+func cpuid(_, _ uint32) (eax, ebx, ecx, edx uint32) {
+	return 0, 0, 0, 0
+}
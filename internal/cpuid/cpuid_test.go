@@ -0,0 +1,64 @@
+package cpuid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCPU answers CPUID with the registers of a CPU reporting vendor, or no
+// hypervisor if vendor is ""
+func fakeCPU(vendor string) func(leaf, subleaf uint32) (eax, ebx, ecx, edx uint32) {
+	sig := []byte(vendor + "\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00")[:12]
+	reg := func(b []byte) uint32 {
+		return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+	}
+
+	return func(leaf, _ uint32) (eax, ebx, ecx, edx uint32) {
+		switch {
+		case leaf == 1 && vendor != "":
+			return 0, 0, hypervisorPresent, 0
+		case leaf == hypervisorLeaf && vendor != "":
+			return hypervisorLeaf + 6, reg(sig[0:4]), reg(sig[4:8]), reg(sig[8:12])
+		default:
+			return 0, 0, 0, 0
+		}
+	}
+}
+
+func TestHypervisor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		vendor    string
+		want      string
+		wantFound bool
+	}{
+		{name: "bare metal"},
+		{name: "VMware", vendor: "VMwareVMware", want: "VMwareVMware", wantFound: true},
+		{name: "Hyper-V", vendor: "Microsoft Hv", want: "Microsoft Hv", wantFound: true},
+		{name: "padding is trimmed", vendor: "KVMKVMKVM", want: "KVMKVMKVM", wantFound: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, found := hypervisor(fakeCPU(tt.vendor))
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHypervisor_RealCPU(t *testing.T) {
+	t.Parallel()
+
+	// Whatever the machine, reading it must not crash and a vendor is only
+	// reported alongside the hypervisor bit
+	vendor, found := Hypervisor()
+	if !found {
+		assert.Empty(t, vendor)
+	}
+}
@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+var (
+	// ErrNoDaemon is returned when no daemon is listening
+	ErrNoDaemon = errcode.New(errcode.DaemonNotRunning, "no vtpc daemon is running")
+
+	// ErrIncompleteResponse is returned when the daemon hangs up before its final event
+	ErrIncompleteResponse = errcode.New(errcode.DaemonIncomplete, "daemon closed the connection before replying")
+
+	// ErrRequestFailed wraps an error event sent by the daemon
+	ErrRequestFailed = errcode.New(errcode.DaemonRequest, "daemon request failed")
+)
+
+// Dialer connects to the daemon
+type Dialer func() (io.ReadWriteCloser, error)
+
+// Send sends req to the daemon and passes each intermediate event to onEvent,
+// which may be nil. It returns the final result or status event.
+func Send(dial Dialer, req Request, onEvent func(Event)) (Event, error) {
+	conn, err := dial()
+	if err != nil {
+		return Event{}, fmt.Errorf("%w: %w", ErrNoDaemon, err)
+	}
+	defer conn.Close()
+
+	if err := WriteFrame(conn, req); err != nil {
+		return Event{}, err
+	}
+
+	dec := json.NewDecoder(conn)
+
+	for {
+		var ev Event
+		if err := dec.Decode(&ev); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return Event{}, ErrIncompleteResponse
+			}
+
+			return Event{}, fmt.Errorf("failed to read event: %w", err)
+		}
+
+		if ev.Type == EventError {
+			return ev, fmt.Errorf("%w: %s", ErrRequestFailed, ev.Message)
+		}
+
+		if ev.final() {
+			return ev, nil
+		}
+
+		if onEvent != nil {
+			onEvent(ev)
+		}
+	}
+}
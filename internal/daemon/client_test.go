@@ -0,0 +1,65 @@
+package daemon
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSend_NoDaemon(t *testing.T) {
+	t.Parallel()
+
+	dial := func() (io.ReadWriteCloser, error) {
+		return nil, errors.New("The system cannot find the file specified.")
+	}
+
+	_, err := Send(dial, Request{Type: RequestStatus}, nil)
+	assert.ErrorIs(t, err, ErrNoDaemon)
+}
+
+func TestSend_DaemonHangsUp(t *testing.T) {
+	t.Parallel()
+
+	client, server := net.Pipe()
+
+	go func() {
+		var req Request
+		_ = ReadFrame(server, &req)
+		_, _ = server.Write([]byte(`{"type":"log","message":"Compiling program..."}` + "\n"))
+		server.Close()
+	}()
+
+	var logs []string
+	_, err := Send(func() (io.ReadWriteCloser, error) { return client, nil },
+		Request{Type: RequestCompile, File: "lobby.vtp"},
+		func(ev Event) { logs = append(logs, ev.Message) })
+
+	assert.ErrorIs(t, err, ErrIncompleteResponse)
+	assert.Equal(t, []string{"Compiling program..."}, logs)
+}
+
+func TestSend_WritesFramedRequest(t *testing.T) {
+	t.Parallel()
+
+	client, server := net.Pipe()
+	received := make(chan Request, 1)
+
+	go func() {
+		var req Request
+		_ = ReadFrame(server, &req)
+		received <- req
+		_, _ = server.Write([]byte(`{"type":"result","exitCode":0}` + "\n"))
+		server.Close()
+	}()
+
+	final, err := Send(func() (io.ReadWriteCloser, error) { return client, nil },
+		Request{Type: RequestCompile, File: "lobby.vtp", Args: []string{"--save-first"}}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, Request{Type: RequestCompile, File: "lobby.vtp", Args: []string{"--save-first"}}, <-received)
+	assert.Equal(t, EventResult, final.Type)
+}
@@ -0,0 +1,114 @@
+// Package daemon implements the protocol between a long-running vtpc daemon
+// and its clients: length-prefixed JSON requests in, NDJSON events out.
+package daemon
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// PipeName is the Windows named pipe the daemon listens on
+const PipeName = `\\.\pipe\vtpc`
+
+// maxFrameSize bounds a request frame so a bad length prefix cannot exhaust memory
+const maxFrameSize = 1 << 20
+
+// ErrFrameTooLarge is returned when a frame's length prefix exceeds maxFrameSize
+var ErrFrameTooLarge = errcode.New(errcode.DaemonFrameTooBig, "frame too large")
+
+// Request types
+const (
+	RequestCompile  = "compile"
+	RequestStatus   = "status"
+	RequestShutdown = "shutdown"
+)
+
+// Request is sent by a client, one per connection
+type Request struct {
+	Type string   `json:"type"`
+	File string   `json:"file,omitempty"` // Full path of the project to compile, for compile requests
+	Args []string `json:"args,omitempty"` // Extra vtpc flags for compile requests, as --name=value
+}
+
+// Event types. A connection ends with exactly one result, status or error event.
+const (
+	EventLog      = "log"      // A line of compile output
+	EventProgress = "progress" // The compile's progress percentage changed
+	EventResult   = "result"   // The compile finished; ExitCode is vtpc's exit code
+	EventStatus   = "status"   // The daemon's state, in reply to status and shutdown
+	EventError    = "error"    // The request failed
+)
+
+// Event is streamed back to the client as one JSON object per line
+type Event struct {
+	Type     string  `json:"type"`
+	Message  string  `json:"message,omitempty"`
+	Percent  int     `json:"percent,omitempty"`
+	ExitCode int     `json:"exitCode"`
+	Status   *Status `json:"status,omitempty"`
+}
+
+// final reports whether the event ends a connection
+func (e Event) final() bool {
+	return e.Type == EventResult || e.Type == EventStatus || e.Type == EventError
+}
+
+// Status describes what the daemon is doing
+type Status struct {
+	Busy       bool   `json:"busy"`
+	File       string `json:"file,omitempty"` // Project being compiled when Busy
+	Compiles   int    `json:"compiles"`       // Compiles run since the daemon started
+	Uptime     string `json:"uptime"`
+	LastUsed   string `json:"lastUsed,omitempty"` // When the last compile finished, RFC 3339
+	IdleCloses int    `json:"idleCloses"`         // Times the warm VTPro was closed for being idle
+}
+
+// WriteFrame writes v as JSON preceded by its length as a 4-byte little-endian integer
+func WriteFrame(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode frame: %w", err)
+	}
+
+	if len(data) > maxFrameSize {
+		return fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, len(data))
+	}
+
+	buf := make([]byte, 4+len(data))
+	binary.LittleEndian.PutUint32(buf, uint32(len(data)))
+	copy(buf[4:], data)
+
+	if _, err := w.Write(buf); err != nil {
+		return fmt.Errorf("failed to write frame: %w", err)
+	}
+
+	return nil
+}
+
+// ReadFrame reads one frame written by WriteFrame and decodes it into v
+func ReadFrame(r io.Reader, v any) error {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return fmt.Errorf("failed to read frame length: %w", err)
+	}
+
+	size := binary.LittleEndian.Uint32(header[:])
+	if size > maxFrameSize {
+		return fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return fmt.Errorf("failed to read frame: %w", err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode frame: %w", err)
+	}
+
+	return nil
+}
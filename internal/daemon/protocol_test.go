@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrame_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteFrame(&buf, Request{Type: RequestCompile, File: `C:\Projects\lobby.vtp`}))
+	require.NoError(t, WriteFrame(&buf, Request{Type: RequestStatus}))
+
+	var first, second Request
+	require.NoError(t, ReadFrame(&buf, &first))
+	require.NoError(t, ReadFrame(&buf, &second))
+
+	assert.Equal(t, Request{Type: RequestCompile, File: `C:\Projects\lobby.vtp`}, first)
+	assert.Equal(t, Request{Type: RequestStatus}, second)
+}
+
+func TestFrame_LengthPrefixIsLittleEndian(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteFrame(&buf, Request{Type: RequestStatus}))
+
+	body := `{"type":"status"}`
+	assert.Equal(t, uint32(len(body)), binary.LittleEndian.Uint32(buf.Bytes()[:4]))
+	assert.Equal(t, body, buf.String()[4:])
+}
+
+func TestReadFrame_RejectsOversizedLength(t *testing.T) {
+	t.Parallel()
+
+	var header [4]byte
+	binary.LittleEndian.PutUint32(header[:], maxFrameSize+1)
+
+	var req Request
+	err := ReadFrame(bytes.NewReader(header[:]), &req)
+	assert.ErrorIs(t, err, ErrFrameTooLarge)
+}
+
+func TestReadFrame_TruncatedBody(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteFrame(&buf, Request{Type: RequestStatus}))
+
+	var req Request
+	err := ReadFrame(bytes.NewReader(buf.Bytes()[:buf.Len()-3]), &req)
+	assert.Error(t, err)
+}
+
+func TestReadFrame_InvalidJSON(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("nope")
+	frame := binary.LittleEndian.AppendUint32(nil, uint32(len(data)))
+	frame = append(frame, data...)
+
+	var req Request
+	err := ReadFrame(bytes.NewReader(frame), &req)
+	assert.ErrorContains(t, err, "failed to decode frame")
+}
@@ -0,0 +1,307 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// DefaultIdleTimeout is how long a warm VTPro is kept open without a compile request
+const DefaultIdleTimeout = 15 * time.Minute
+
+// maxIdleCheckInterval bounds how often the idle policy is checked
+const maxIdleCheckInterval = time.Minute
+
+// Compiler runs one compile for the daemon, streaming output through emit
+type Compiler interface {
+	Compile(ctx context.Context, req Request, emit func(Event)) (exitCode int, err error)
+}
+
+// IdleCloser is implemented by compilers that keep VTPro open between compiles.
+// CloseIdle closes it, reporting whether one was open; the next Compile
+// launches it again.
+type IdleCloser interface {
+	CloseIdle() bool
+}
+
+// Listener accepts client connections, like net.Listener for a named pipe
+type Listener interface {
+	Accept() (io.ReadWriteCloser, error)
+	Close() error
+}
+
+// Server answers client requests, running at most one compile at a time
+type Server struct {
+	compiler    Compiler
+	log         logger.LoggerInterface
+	clock       clock.Clock
+	started     time.Time
+	idleTimeout time.Duration
+
+	mu         sync.Mutex
+	busy       bool
+	file       string
+	compiles   int
+	lastUsed   time.Time // When the last compile finished
+	warm       bool      // A compile may have left VTPro open, and the idle policy has not closed it since
+	idleClosed bool      // The idle policy closed VTPro, so the next compile relaunches it
+	idleCloses int
+
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+// NewServer creates a Server that compiles with c
+func NewServer(c Compiler, log logger.LoggerInterface) *Server {
+	clk := clock.New()
+
+	return &Server{
+		compiler:    c,
+		log:         log,
+		clock:       clk,
+		started:     clk.Now(),
+		idleTimeout: DefaultIdleTimeout,
+		shutdown:    make(chan struct{}),
+	}
+}
+
+// WithClock sets the clock the idle policy and uptime are measured by
+func (s *Server) WithClock(clk clock.Clock) *Server {
+	s.clock = clk
+	s.started = clk.Now()
+
+	return s
+}
+
+// WithIdleTimeout sets how long a warm VTPro is kept open without a compile
+// request before it is closed. Zero keeps it open until the daemon stops.
+func (s *Server) WithIdleTimeout(d time.Duration) *Server {
+	s.idleTimeout = d
+	return s
+}
+
+// Serve accepts connections until a shutdown request arrives or ctx is done,
+// then waits for open connections to finish. A compile in progress is allowed
+// to complete so VTPro is not left running.
+func (s *Server) Serve(ctx context.Context, l Listener) error {
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Shutdown()
+		case <-s.shutdown:
+		}
+
+		_ = l.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	if closer, ok := s.compiler.(IdleCloser); ok && s.idleTimeout > 0 {
+		stop := make(chan struct{})
+		defer close(stop)
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			s.watchIdle(closer, stop)
+		}()
+	}
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-s.shutdown:
+				return nil
+			default:
+				return fmt.Errorf("failed to accept connection: %w", err)
+			}
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			s.Handle(ctx, conn)
+		}()
+	}
+}
+
+// Shutdown stops Serve from accepting further connections
+func (s *Server) Shutdown() {
+	s.shutdownOnce.Do(func() { close(s.shutdown) })
+}
+
+// Status returns the daemon's current state
+func (s *Server) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := Status{
+		Busy:       s.busy,
+		File:       s.file,
+		Compiles:   s.compiles,
+		Uptime:     s.clock.Now().Sub(s.started).Round(time.Second).String(),
+		IdleCloses: s.idleCloses,
+	}
+
+	if !s.lastUsed.IsZero() {
+		status.LastUsed = s.lastUsed.Format(time.RFC3339)
+	}
+
+	return status
+}
+
+// watchIdle closes the warm VTPro whenever it has gone unused for the idle
+// timeout, until stop is closed
+func (s *Server) watchIdle(closer IdleCloser, stop <-chan struct{}) {
+	ticker := s.clock.NewTicker(min(s.idleTimeout, maxIdleCheckInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			s.closeIfIdle(closer)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// closeIfIdle closes the warm VTPro if no compile has used it for the idle
+// timeout. Only compiles count as use; status requests do not keep VTPro open.
+// The lock is held while closing so a compile cannot start on a closing VTPro.
+func (s *Server) closeIfIdle(closer IdleCloser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idle := s.clock.Now().Sub(s.lastUsed)
+	if s.busy || !s.warm || idle < s.idleTimeout {
+		return
+	}
+
+	s.warm = false
+
+	// A compile that failed, or ran with --isolate, closed VTPro itself
+	if !closer.CloseIdle() {
+		return
+	}
+
+	s.log.Info("Closed idle VTPro", slog.Duration("idle", idle.Round(time.Second)))
+
+	s.idleClosed = true
+	s.idleCloses++
+}
+
+// Handle reads one request from conn, streams its events back and closes conn
+func (s *Server) Handle(ctx context.Context, conn io.ReadWriteCloser) {
+	defer conn.Close()
+
+	var (
+		encMu sync.Mutex
+		enc   = json.NewEncoder(conn)
+	)
+
+	// Compilers may emit from several goroutines, e.g. one per output stream
+	emit := func(ev Event) {
+		encMu.Lock()
+		defer encMu.Unlock()
+
+		if err := enc.Encode(ev); err != nil {
+			s.log.Debug("Could not send event to client", slog.Any("error", err))
+		}
+	}
+
+	var req Request
+	if err := ReadFrame(conn, &req); err != nil {
+		s.log.Warn("Could not read request", slog.Any("error", err))
+		emit(Event{Type: EventError, Message: err.Error()})
+
+		return
+	}
+
+	s.log.Debug("Received request", slog.String("type", req.Type), slog.String("file", req.File))
+
+	switch req.Type {
+	case RequestCompile:
+		s.compile(ctx, req, emit)
+
+	case RequestStatus:
+		status := s.Status()
+		emit(Event{Type: EventStatus, Status: &status})
+
+	case RequestShutdown:
+		s.log.Info("Shutdown requested")
+		status := s.Status()
+		emit(Event{Type: EventStatus, Status: &status})
+		s.Shutdown()
+
+	default:
+		emit(Event{Type: EventError, Message: fmt.Sprintf("unknown request type %q", req.Type)})
+	}
+}
+
+// compile runs a compile request unless another compile is already running
+func (s *Server) compile(ctx context.Context, req Request, emit func(Event)) {
+	if req.File == "" {
+		emit(Event{Type: EventError, Message: "compile request has no file"})
+		return
+	}
+
+	s.mu.Lock()
+	if s.busy {
+		file := s.file
+		s.mu.Unlock()
+		emit(Event{Type: EventError, Message: fmt.Sprintf("daemon is busy compiling %s", file)})
+
+		return
+	}
+
+	s.busy = true
+	s.file = req.File
+
+	if s.idleClosed {
+		s.idleClosed = false
+		s.log.Info("Relaunching VTPro closed while idle")
+	}
+
+	s.mu.Unlock()
+
+	s.log.Info("Compiling for client", slog.String("file", req.File))
+
+	// Shutting down must not cancel the compile, or VTPro would be left running
+	code, err := s.compiler.Compile(context.WithoutCancel(ctx), req, emit)
+
+	// Finish before the final event, so a client that saw it can compile again
+	s.finishCompile()
+
+	if err != nil {
+		s.log.Error("Compile could not run", slog.Any("error", err))
+		emit(Event{Type: EventError, Message: err.Error()})
+
+		return
+	}
+
+	emit(Event{Type: EventResult, ExitCode: code})
+}
+
+// finishCompile marks the server idle after a compile, starting the idle timer
+func (s *Server) finishCompile() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.busy = false
+	s.file = ""
+	s.compiles++
+	s.lastUsed = s.clock.Now()
+	_, s.warm = s.compiler.(IdleCloser)
+}
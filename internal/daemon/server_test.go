@@ -0,0 +1,438 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// memListener is an in-memory Listener; its Dial method is the matching Dialer
+type memListener struct {
+	conns     chan net.Conn
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newMemListener() *memListener {
+	return &memListener{conns: make(chan net.Conn), closed: make(chan struct{})}
+}
+
+func (l *memListener) Accept() (io.ReadWriteCloser, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, errors.New("listener closed")
+	}
+}
+
+func (l *memListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *memListener) Dial() (io.ReadWriteCloser, error) {
+	client, server := net.Pipe()
+
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.closed:
+		return nil, errors.New("pipe not found")
+	}
+}
+
+// fakeCompiler emits a fixed set of events, optionally blocking until released
+type fakeCompiler struct {
+	events  []Event
+	code    int
+	err     error
+	started chan struct{}
+	release chan struct{}
+	ctxErr  error // The compile context's error once released
+}
+
+func (f *fakeCompiler) Compile(ctx context.Context, _ Request, emit func(Event)) (int, error) {
+	if f.started != nil {
+		close(f.started)
+	}
+
+	if f.release != nil {
+		<-f.release
+	}
+
+	f.ctxErr = ctx.Err()
+
+	for _, ev := range f.events {
+		emit(ev)
+	}
+
+	return f.code, f.err
+}
+
+// idleCompiler is a fakeCompiler that keeps a warm VTPro, counting idle closes
+type idleCompiler struct {
+	fakeCompiler
+
+	mu     sync.Mutex
+	closes int
+	cold   bool // Compiles close VTPro themselves, so there is never one to close
+}
+
+func (f *idleCompiler) CloseIdle() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.cold {
+		return false
+	}
+
+	f.closes++
+
+	return true
+}
+
+func (f *idleCompiler) closeCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.closes
+}
+
+// startServer serves on an in-memory listener until the test ends
+func startServer(t *testing.T, c Compiler) (*Server, *memListener, <-chan error) {
+	t.Helper()
+
+	return serve(t, NewServer(c, logger.NewNoOpLogger()))
+}
+
+// serve runs srv on an in-memory listener until the test ends
+func serve(t *testing.T, srv *Server) (*Server, *memListener, <-chan error) {
+	t.Helper()
+
+	l := newMemListener()
+	done := make(chan error, 1)
+
+	go func() { done <- srv.Serve(context.Background(), l) }()
+
+	t.Cleanup(srv.Shutdown)
+
+	return srv, l, done
+}
+
+func TestServer_CompileStreamsEvents(t *testing.T) {
+	t.Parallel()
+
+	_, l, _ := startServer(t, &fakeCompiler{
+		events: []Event{
+			{Type: EventLog, Message: "Compiling program..."},
+			{Type: EventProgress, Percent: 58},
+		},
+		code: 1,
+	})
+
+	var got []Event
+	final, err := Send(l.Dial, Request{Type: RequestCompile, File: "lobby.vtp"}, func(ev Event) {
+		got = append(got, ev)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Event{
+		{Type: EventLog, Message: "Compiling program..."},
+		{Type: EventProgress, Percent: 58},
+	}, got)
+	assert.Equal(t, Event{Type: EventResult, ExitCode: 1}, final)
+}
+
+func TestServer_Status(t *testing.T) {
+	t.Parallel()
+
+	_, l, _ := startServer(t, &fakeCompiler{})
+
+	_, err := Send(l.Dial, Request{Type: RequestCompile, File: "lobby.vtp"}, nil)
+	require.NoError(t, err)
+
+	final, err := Send(l.Dial, Request{Type: RequestStatus}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, final.Status)
+	assert.False(t, final.Status.Busy)
+	assert.Equal(t, 1, final.Status.Compiles)
+}
+
+func TestServer_RejectsCompileWhileBusy(t *testing.T) {
+	t.Parallel()
+
+	fc := &fakeCompiler{started: make(chan struct{}), release: make(chan struct{})}
+	_, l, _ := startServer(t, fc)
+
+	firstDone := make(chan error, 1)
+	go func() {
+		_, err := Send(l.Dial, Request{Type: RequestCompile, File: "lobby.vtp"}, nil)
+		firstDone <- err
+	}()
+
+	<-fc.started
+
+	status, err := Send(l.Dial, Request{Type: RequestStatus}, nil)
+	require.NoError(t, err)
+	assert.True(t, status.Status.Busy)
+	assert.Equal(t, "lobby.vtp", status.Status.File)
+
+	_, err = Send(l.Dial, Request{Type: RequestCompile, File: "other.vtp"}, nil)
+	assert.ErrorIs(t, err, ErrRequestFailed)
+	assert.ErrorContains(t, err, "busy compiling lobby.vtp")
+
+	close(fc.release)
+	assert.NoError(t, <-firstDone)
+}
+
+func TestServer_ShutdownLetsCompileFinish(t *testing.T) {
+	t.Parallel()
+
+	fc := &fakeCompiler{started: make(chan struct{}), release: make(chan struct{}), code: 1}
+	srv := NewServer(fc, logger.NewNoOpLogger())
+	l := newMemListener()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() { done <- srv.Serve(ctx, l) }()
+
+	compiled := make(chan Event, 1)
+	go func() {
+		final, _ := Send(l.Dial, Request{Type: RequestCompile, File: "lobby.vtp"}, nil)
+		compiled <- final
+	}()
+
+	<-fc.started
+
+	// Ctrl+C on the daemon
+	cancel()
+	close(fc.release)
+
+	assert.Equal(t, Event{Type: EventResult, ExitCode: 1}, <-compiled)
+	require.NoError(t, <-done)
+	assert.NoError(t, fc.ctxErr, "the compile's context must not be cancelled by the daemon stopping")
+}
+
+func TestServer_CompileErrors(t *testing.T) {
+	t.Parallel()
+
+	_, l, _ := startServer(t, &fakeCompiler{err: errors.New("vtpc.exe not found")})
+
+	_, err := Send(l.Dial, Request{Type: RequestCompile, File: "lobby.vtp"}, nil)
+	assert.ErrorIs(t, err, ErrRequestFailed)
+	assert.ErrorContains(t, err, "vtpc.exe not found")
+
+	_, err = Send(l.Dial, Request{Type: RequestCompile}, nil)
+	assert.ErrorContains(t, err, "no file")
+}
+
+func TestServer_UnknownRequest(t *testing.T) {
+	t.Parallel()
+
+	_, l, _ := startServer(t, &fakeCompiler{})
+
+	_, err := Send(l.Dial, Request{Type: "reboot"}, nil)
+	assert.ErrorIs(t, err, ErrRequestFailed)
+	assert.ErrorContains(t, err, `unknown request type "reboot"`)
+}
+
+func TestServer_ShutdownStopsServe(t *testing.T) {
+	t.Parallel()
+
+	_, l, done := startServer(t, &fakeCompiler{})
+
+	final, err := Send(l.Dial, Request{Type: RequestShutdown}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, EventStatus, final.Type)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not return after shutdown")
+	}
+
+	_, err = Send(l.Dial, Request{Type: RequestStatus}, nil)
+	assert.ErrorIs(t, err, ErrNoDaemon)
+}
+
+func TestServer_BadFrame(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(&fakeCompiler{}, logger.NewNoOpLogger())
+	client, server := net.Pipe()
+
+	go srv.Handle(context.Background(), server)
+
+	// A length prefix far beyond the limit
+	_, err := client.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	require.NoError(t, err)
+
+	buf, err := io.ReadAll(client)
+	require.NoError(t, err)
+	assert.Contains(t, string(buf), `"type":"error"`)
+	assert.Contains(t, string(buf), ErrFrameTooLarge.Error())
+}
+
+var idleEpoch = time.Date(2025, 6, 1, 22, 0, 0, 0, time.UTC)
+
+func TestServer_ClosesIdleVTPro(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(idleEpoch)
+	fc := &idleCompiler{}
+	srv, l, _ := serve(t, NewServer(fc, logger.NewNoOpLogger()).WithClock(clk).WithIdleTimeout(15*time.Minute))
+
+	_, err := Send(l.Dial, Request{Type: RequestCompile, File: "lobby.vtp"}, nil)
+	require.NoError(t, err)
+
+	clk.Advance(14 * time.Minute)
+	srv.closeIfIdle(fc)
+	assert.Zero(t, fc.closeCount(), "not idle for long enough")
+
+	clk.Advance(time.Minute)
+	srv.closeIfIdle(fc)
+	assert.Equal(t, 1, fc.closeCount())
+
+	clk.Advance(time.Hour)
+	srv.closeIfIdle(fc)
+	assert.Equal(t, 1, fc.closeCount(), "an instance already closed is not closed again")
+
+	status := srv.Status()
+	assert.Equal(t, 1, status.IdleCloses)
+	assert.Equal(t, "2025-06-01T22:00:00Z", status.LastUsed)
+
+	// The next compile relaunches VTPro, which can go idle again
+	_, err = Send(l.Dial, Request{Type: RequestCompile, File: "lobby.vtp"}, nil)
+	require.NoError(t, err)
+
+	clk.Advance(15 * time.Minute)
+	srv.closeIfIdle(fc)
+	assert.Equal(t, 2, fc.closeCount())
+}
+
+func TestServer_StatusRequestsDoNotResetIdleTimer(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(idleEpoch)
+	fc := &idleCompiler{}
+	srv, l, _ := serve(t, NewServer(fc, logger.NewNoOpLogger()).WithClock(clk).WithIdleTimeout(15*time.Minute))
+
+	_, err := Send(l.Dial, Request{Type: RequestCompile, File: "lobby.vtp"}, nil)
+	require.NoError(t, err)
+
+	// Editors poll status while the daemon sits idle
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range 5 {
+				clk.Advance(10 * time.Second)
+				_, err := Send(l.Dial, Request{Type: RequestStatus}, nil)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	clk.Advance(15*time.Minute - 40*10*time.Second)
+	srv.closeIfIdle(fc)
+	assert.Equal(t, 1, fc.closeCount())
+}
+
+func TestServer_DoesNotCloseWhileCompiling(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(idleEpoch)
+	fc := &idleCompiler{}
+	srv, l, _ := serve(t, NewServer(fc, logger.NewNoOpLogger()).WithClock(clk).WithIdleTimeout(15*time.Minute))
+
+	_, err := Send(l.Dial, Request{Type: RequestCompile, File: "lobby.vtp"}, nil)
+	require.NoError(t, err)
+
+	fc.started = make(chan struct{})
+	fc.release = make(chan struct{})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := Send(l.Dial, Request{Type: RequestCompile, File: "lobby.vtp"}, nil)
+		done <- err
+	}()
+
+	<-fc.started
+
+	clk.Advance(time.Hour)
+	srv.closeIfIdle(fc)
+	assert.Zero(t, fc.closeCount())
+
+	close(fc.release)
+	require.NoError(t, <-done)
+
+	// The compile that just finished counts as use
+	srv.closeIfIdle(fc)
+	assert.Zero(t, fc.closeCount())
+}
+
+func TestServer_IdlePolicyRunsOnClock(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(idleEpoch)
+	fc := &idleCompiler{}
+	_, l, _ := serve(t, NewServer(fc, logger.NewNoOpLogger()).WithClock(clk).WithIdleTimeout(15*time.Minute))
+
+	_, err := Send(l.Dial, Request{Type: RequestCompile, File: "lobby.vtp"}, nil)
+	require.NoError(t, err)
+
+	// The policy checks once a minute; advance until a check finds VTPro idle
+	assert.Eventually(t, func() bool {
+		clk.Advance(time.Minute)
+		return fc.closeCount() == 1
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestServer_IdleCloseNotCountedWhenNothingWasOpen(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(idleEpoch)
+	fc := &idleCompiler{cold: true}
+	srv, l, _ := serve(t, NewServer(fc, logger.NewNoOpLogger()).WithClock(clk).WithIdleTimeout(15*time.Minute))
+
+	_, err := Send(l.Dial, Request{Type: RequestCompile, File: "lobby.vtp"}, nil)
+	require.NoError(t, err)
+
+	clk.Advance(time.Hour)
+	srv.closeIfIdle(fc)
+
+	assert.Zero(t, srv.Status().IdleCloses)
+}
+
+func TestServer_NoIdleCloseWithoutWarmInstance(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(idleEpoch)
+	srv, l, _ := serve(t, NewServer(&fakeCompiler{}, logger.NewNoOpLogger()).WithClock(clk))
+
+	_, err := Send(l.Dial, Request{Type: RequestCompile, File: "lobby.vtp"}, nil)
+	require.NoError(t, err)
+
+	clk.Advance(time.Hour)
+
+	status := srv.Status()
+	assert.Zero(t, status.IdleCloses)
+	assert.Equal(t, "1h0m0s", status.Uptime)
+}
@@ -0,0 +1,159 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"regexp"
+	"strconv"
+)
+
+// ftpLoginRejected is the FTP reply code for a rejected login
+const ftpLoginRejected = 530
+
+// pasvRe matches the address in a 227 reply, e.g. "Entering Passive Mode (10,0,0,5,195,80)"
+var pasvRe = regexp.MustCompile(`(\d+),(\d+),(\d+),(\d+),(\d+),(\d+)`)
+
+// ftpConn is an FTP control connection
+type ftpConn struct {
+	*textproto.Conn
+}
+
+// cmd sends a command and reads its reply, which must have code expect
+// (a single digit matches a class of codes, 0 matches any)
+func (c ftpConn) cmd(expect int, format string, args ...any) (int, string, error) {
+	if err := c.PrintfLine(format, args...); err != nil {
+		return 0, "", err
+	}
+
+	return c.ReadResponse(expect)
+}
+
+// uploadFTP uploads r as name in passive binary mode
+func uploadFTP(ctx context.Context, t Target, name string, r io.Reader) error {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", t.Host)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnreachable, err)
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	// Closing the connection unblocks any read or write when the attempt times out
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	c := ftpConn{textproto.NewConn(conn)}
+
+	if _, _, err := c.ReadResponse(2); err != nil {
+		return ftpError("greeting", err)
+	}
+
+	if err := ftpLogin(c, t); err != nil {
+		return err
+	}
+
+	if _, _, err := c.cmd(2, "TYPE I"); err != nil {
+		return ftpError("TYPE", err)
+	}
+
+	if t.Dir != "" {
+		if _, _, err := c.cmd(2, "CWD %s", t.Dir); err != nil {
+			return ftpError("CWD "+t.Dir, err)
+		}
+	}
+
+	_, msg, err := c.cmd(227, "PASV")
+	if err != nil {
+		return ftpError("PASV", err)
+	}
+
+	port, err := parsePASV(msg)
+	if err != nil {
+		return ftpError("PASV", err)
+	}
+
+	// The address in the reply is ignored, as it is often wrong behind NAT
+	host, _, _ := net.SplitHostPort(t.Host)
+
+	data, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("%w: data connection: %w", ErrUnreachable, err)
+	}
+
+	defer func() { _ = data.Close() }()
+
+	if _, _, err := c.cmd(1, "STOR %s", name); err != nil {
+		return ftpError("STOR", err)
+	}
+
+	if _, err := io.Copy(data, r); err != nil {
+		return fmt.Errorf("FTP upload: %w", err)
+	}
+
+	if err := data.Close(); err != nil {
+		return fmt.Errorf("FTP upload: %w", err)
+	}
+
+	if _, _, err := c.ReadResponse(2); err != nil {
+		return ftpError("STOR", err)
+	}
+
+	_, _, _ = c.cmd(0, "QUIT")
+
+	return nil
+}
+
+// ftpLogin logs in with the target's user and password
+func ftpLogin(c ftpConn, t Target) error {
+	code, _, err := c.cmd(0, "USER %s", t.User)
+	if err != nil {
+		return ftpError("USER", err)
+	}
+
+	switch code {
+	case 230:
+		return nil // No password needed
+	case 331:
+	case ftpLoginRejected:
+		return fmt.Errorf("%w: user %s", ErrAuth, t.User)
+	default:
+		return fmt.Errorf("FTP USER: unexpected reply %d", code)
+	}
+
+	if _, _, err := c.cmd(2, "PASS %s", t.Password); err != nil {
+		return ftpError("PASS", err)
+	}
+
+	return nil
+}
+
+// ftpError describes a failed FTP step, mapping a rejected login to ErrAuth
+func ftpError(step string, err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code == ftpLoginRejected {
+		return fmt.Errorf("%w: %s", ErrAuth, protoErr.Msg)
+	}
+
+	return fmt.Errorf("FTP %s: %w", step, err)
+}
+
+// parsePASV returns the data port from a 227 reply
+func parsePASV(msg string) (int, error) {
+	m := pasvRe.FindStringSubmatch(msg)
+	if m == nil {
+		return 0, fmt.Errorf("no address in reply %q", msg)
+	}
+
+	hi, _ := strconv.Atoi(m[5])
+	lo, _ := strconv.Atoi(m[6])
+	if hi > 255 || lo > 255 {
+		return 0, fmt.Errorf("bad port in reply %q", msg)
+	}
+
+	return hi<<8 | lo, nil
+}
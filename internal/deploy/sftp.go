@@ -0,0 +1,300 @@
+package deploy
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTP version 3 packet types and flags used for an upload
+const (
+	sftpInit    = 1
+	sftpVersion = 2
+	sftpOpen    = 3
+	sftpClose   = 4
+	sftpWrite   = 6
+	sftpStatus  = 101
+	sftpHandle  = 102
+
+	sftpWriteFlags = 0x02 | 0x08 | 0x10 // SSH_FXF_WRITE | SSH_FXF_CREAT | SSH_FXF_TRUNC
+	sftpStatusOK   = 0
+
+	// sftpChunk is how much is written per request, well under the 34000-byte packet limit servers must accept
+	sftpChunk = 32 * 1024
+)
+
+// knownHostsPath returns the OpenSSH known_hosts file SFTP host keys are checked against
+func knownHostsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".ssh", "known_hosts"), nil
+}
+
+// uploadSFTP uploads r as name over SFTP, trusting only host keys in known_hosts
+func uploadSFTP(ctx context.Context, t Target, name string, r io.Reader) error {
+	hostsFile, err := knownHostsPath()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrHostKey, err)
+	}
+
+	hostKeys, err := knownhosts.New(hostsFile)
+	if err != nil {
+		return fmt.Errorf("%w: cannot read %s (add the panel with ssh-keyscan): %w", ErrHostKey, hostsFile, err)
+	}
+
+	config := &ssh.ClientConfig{
+		User: t.User,
+		Auth: []ssh.AuthMethod{
+			ssh.Password(t.Password),
+			ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = t.Password
+				}
+				return answers, nil
+			}),
+		},
+		HostKeyCallback: hostKeys,
+	}
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", t.Host)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnreachable, err)
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, t.Host, config)
+	if err != nil {
+		return sshError(err)
+	}
+
+	client := ssh.NewClient(sshConn, chans, reqs)
+	defer func() { _ = client.Close() }()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("SFTP session: %w", err)
+	}
+
+	defer func() { _ = session.Close() }()
+
+	w, err := session.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("SFTP session: %w", err)
+	}
+
+	out, err := session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("SFTP session: %w", err)
+	}
+
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return fmt.Errorf("SFTP subsystem: %w", err)
+	}
+
+	s := &sftpClient{w: w, r: bufio.NewReader(out)}
+
+	return s.upload(t.remotePath(name), r)
+}
+
+// sshError describes a failed SSH handshake, mapping rejected logins and host keys
+func sshError(err error) error {
+	var keyErr *knownhosts.KeyError
+	if errors.As(err, &keyErr) {
+		if len(keyErr.Want) > 0 {
+			return fmt.Errorf("%w: the panel's host key has changed: %w", ErrHostKey, err)
+		}
+
+		return fmt.Errorf("%w: the panel is not in known_hosts (add it with ssh-keyscan): %w", ErrHostKey, err)
+	}
+
+	if strings.Contains(err.Error(), "unable to authenticate") {
+		return fmt.Errorf("%w: %w", ErrAuth, err)
+	}
+
+	return fmt.Errorf("SSH handshake: %w", err)
+}
+
+// sftpClient speaks just enough SFTP version 3 to upload one file, one request at a time
+type sftpClient struct {
+	w      io.Writer
+	r      io.Reader
+	nextID uint32
+}
+
+// upload writes r to remotePath, replacing any existing file
+func (s *sftpClient) upload(remotePath string, r io.Reader) error {
+	if err := s.send(sftpInit, binary.BigEndian.AppendUint32(nil, 3)); err != nil {
+		return fmt.Errorf("SFTP init: %w", err)
+	}
+
+	if typ, _, err := s.recv(); err != nil || typ != sftpVersion {
+		return fmt.Errorf("SFTP init: %w", orUnexpected(err, typ))
+	}
+
+	// OPEN: id, filename, pflags, empty attrs
+	payload := appendString(s.id(), remotePath)
+	payload = binary.BigEndian.AppendUint32(payload, sftpWriteFlags)
+	payload = binary.BigEndian.AppendUint32(payload, 0)
+
+	handle, err := s.request("open "+remotePath, sftpOpen, payload)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, sftpChunk)
+	var offset uint64
+
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			payload := appendString(s.id(), string(handle))
+			payload = binary.BigEndian.AppendUint64(payload, offset)
+			payload = appendString(payload, string(buf[:n]))
+
+			if _, err := s.request("write", sftpWrite, payload); err != nil {
+				return err
+			}
+
+			offset += uint64(n)
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+
+		if readErr != nil {
+			return fmt.Errorf("SFTP upload: %w", readErr)
+		}
+	}
+
+	_, err = s.request("close", sftpClose, appendString(s.id(), string(handle)))
+	return err
+}
+
+// request sends a request and waits for its reply, returning the handle of a
+// HANDLE reply or an error for a failed STATUS
+func (s *sftpClient) request(what string, typ byte, payload []byte) ([]byte, error) {
+	if err := s.send(typ, payload); err != nil {
+		return nil, fmt.Errorf("SFTP %s: %w", what, err)
+	}
+
+	replyType, reply, err := s.recv()
+	if err != nil {
+		return nil, fmt.Errorf("SFTP %s: %w", what, err)
+	}
+
+	// Every reply starts with the request id, which is not checked as requests are not pipelined
+	if len(reply) < 4 {
+		return nil, fmt.Errorf("SFTP %s: short reply", what)
+	}
+
+	reply = reply[4:]
+
+	switch replyType {
+	case sftpHandle:
+		handle, _, ok := readString(reply)
+		if !ok {
+			return nil, fmt.Errorf("SFTP %s: bad handle reply", what)
+		}
+		return handle, nil
+	case sftpStatus:
+		if len(reply) < 4 {
+			return nil, fmt.Errorf("SFTP %s: short status reply", what)
+		}
+
+		code := binary.BigEndian.Uint32(reply)
+		if code == sftpStatusOK {
+			return nil, nil
+		}
+
+		msg, _, _ := readString(reply[4:])
+		return nil, fmt.Errorf("SFTP %s: %s (status %d)", what, msg, code)
+	default:
+		return nil, fmt.Errorf("SFTP %s: %w", what, orUnexpected(nil, replyType))
+	}
+}
+
+// id returns a new request id, encoded as the start of a payload
+func (s *sftpClient) id() []byte {
+	s.nextID++
+	return binary.BigEndian.AppendUint32(nil, s.nextID)
+}
+
+// send writes one packet: length, type, payload
+func (s *sftpClient) send(typ byte, payload []byte) error {
+	packet := binary.BigEndian.AppendUint32(nil, uint32(len(payload)+1))
+	packet = append(packet, typ)
+	packet = append(packet, payload...)
+
+	_, err := s.w.Write(packet)
+	return err
+}
+
+// recv reads one packet and returns its type and payload
+func (s *sftpClient) recv() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(s.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 1 || length > 256*1024 {
+		return 0, nil, fmt.Errorf("bad packet length %d", length)
+	}
+
+	payload := make([]byte, length-1)
+	if _, err := io.ReadFull(s.r, payload); err != nil {
+		return 0, nil, err
+	}
+
+	return header[4], payload, nil
+}
+
+// appendString appends an SFTP string: its length, then its bytes
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// readString reads an SFTP string from the start of b, returning it and the rest of b
+func readString(b []byte) ([]byte, []byte, bool) {
+	if len(b) < 4 {
+		return nil, nil, false
+	}
+
+	n := binary.BigEndian.Uint32(b)
+	if uint64(len(b)-4) < uint64(n) {
+		return nil, nil, false
+	}
+
+	return b[4 : 4+n], b[4+n:], true
+}
+
+// orUnexpected returns err, or an error for an unexpected packet type if err is nil
+func orUnexpected(err error, typ byte) error {
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("unexpected packet type %d", typ)
+}
@@ -0,0 +1,191 @@
+// Package diagfiles keeps the diagnostic files vtpc writes under its data
+// directory, such as screenshots, debug dumps and raw logs, within per-category
+// limits, so they do not grow without bound on build machines.
+package diagfiles
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// DirName is the directory under the vtpc data directory that holds a
+// subdirectory of diagnostic files per category
+const DirName = "diagnostics"
+
+// Categories of diagnostic files
+const (
+	Screenshots = "screenshots" // Captures of VTPro and its dialogs
+	Dumps       = "dumps"       // Debug dumps of windows and controls
+	RawLogs     = "raw-logs"    // Message Log text as VTPro showed it
+)
+
+var (
+	// ErrOutsideRoot is returned for a path that is not inside the vtpc data directory.
+	// Nothing outside it is ever deleted.
+	ErrOutsideRoot = errcode.New(errcode.DiagOutsideRoot, "path is outside the vtpc data directory")
+
+	// ErrUnknownCategory is returned for a category the manager has no limits for
+	ErrUnknownCategory = errcode.New(errcode.DiagCategory, "unknown diagnostics category")
+)
+
+// Category is a kind of diagnostic file and how many of them are kept
+type Category struct {
+	Name     string // Subdirectory of DirName the files are written to
+	MaxFiles int    // Newest files kept, 0 for no limit
+	MaxBytes int64  // Total size of the files kept, 0 for no limit
+}
+
+// DefaultCategories returns the categories with their default limits
+func DefaultCategories() []Category {
+	return []Category{
+		{Name: Screenshots, MaxFiles: 50, MaxBytes: 200 << 20},
+		{Name: Dumps, MaxFiles: 20, MaxBytes: 500 << 20},
+		{Name: RawLogs, MaxFiles: 50, MaxBytes: 100 << 20},
+	}
+}
+
+// Manager hands out the directories diagnostic writers write to and deletes
+// the oldest files of each category beyond its limits
+type Manager struct {
+	root       string // vtpc data directory
+	categories []Category
+	log        logger.LoggerInterface
+	remove     func(string) error // Deletes a file; replaced in tests to simulate a locked file
+}
+
+// New creates a Manager for the diagnostic files under root, the vtpc data directory
+func New(root string, categories []Category, log logger.LoggerInterface) *Manager {
+	return &Manager{root: root, categories: categories, log: log, remove: os.Remove}
+}
+
+// Dir returns the directory the files of a category are written to, creating it.
+// Every diagnostic writer gets its directory here, so Enforce sees its files.
+func (m *Manager) Dir(category string) (string, error) {
+	if _, ok := m.category(category); !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownCategory, category)
+	}
+
+	dir := m.categoryDir(category)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("could not create %s diagnostics directory: %w", category, err)
+	}
+
+	if err := contained(m.root, dir); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// Result is what Enforce deleted, and what it could not
+type Result struct {
+	Deleted []string // Files deleted, oldest first
+	Freed   int64    // Total size of the deleted files
+	Locked  []string // Files that were due for deletion but could not be deleted, e.g. open in a viewer
+}
+
+// Enforce deletes the oldest files of each category until it is within its limits.
+// A file that cannot be deleted is skipped and left for the next run; a category
+// whose directory resolves outside the data directory is not touched.
+func (m *Manager) Enforce() (Result, error) {
+	var (
+		result Result
+		errs   []error
+	)
+
+	for _, c := range m.categories {
+		if err := m.enforce(c, &result); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, err))
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// enforce applies the limits of one category
+func (m *Manager) enforce(c Category, result *Result) error {
+	dir := m.categoryDir(c.Name)
+
+	files, err := list(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, f := range Expired(files, c) {
+		if err := contained(m.root, f.Path); err != nil {
+			return err
+		}
+
+		if err := m.remove(f.Path); err != nil {
+			m.log.Debug("Could not delete diagnostic file, leaving it for the next run",
+				slog.String("path", f.Path),
+				slog.Any("error", err),
+			)
+			result.Locked = append(result.Locked, f.Path)
+
+			continue
+		}
+
+		result.Deleted = append(result.Deleted, f.Path)
+		result.Freed += f.Size
+	}
+
+	return nil
+}
+
+// category returns the limits for a category by name
+func (m *Manager) category(name string) (Category, bool) {
+	for _, c := range m.categories {
+		if c.Name == name {
+			return c, true
+		}
+	}
+
+	return Category{}, false
+}
+
+// categoryDir returns the directory of a category
+func (m *Manager) categoryDir(name string) string {
+	return filepath.Join(m.root, DirName, name)
+}
+
+// contained returns ErrOutsideRoot unless path, with symlinks resolved, is inside root
+func contained(root, path string) error {
+	resolvedRoot, err := resolve(root)
+	if err != nil {
+		return err
+	}
+
+	resolved, err := resolve(path)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(resolvedRoot, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return fmt.Errorf("%w: %s", ErrOutsideRoot, path)
+	}
+
+	return nil
+}
+
+// resolve returns the absolute path with symlinks resolved
+func resolve(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(abs)
+}
@@ -0,0 +1,240 @@
+package diagfiles
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// writeFile creates a file of size bytes under root, last modified age before epoch
+func writeFile(t *testing.T, root, rel string, size int, age time.Duration) string {
+	t.Helper()
+
+	path := filepath.Join(root, rel)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, make([]byte, size), 0o644))
+	require.NoError(t, os.Chtimes(path, epoch.Add(-age), epoch.Add(-age)))
+
+	return path
+}
+
+// names returns the base names of paths
+func names(paths []string) []string {
+	var out []string
+	for _, p := range paths {
+		out = append(out, filepath.Base(p))
+	}
+
+	return out
+}
+
+func TestExpired(t *testing.T) {
+	t.Parallel()
+
+	files := []File{
+		{Path: "b.png", Size: 30, ModTime: epoch.Add(-2 * time.Hour)},
+		{Path: "a.png", Size: 10, ModTime: epoch.Add(-1 * time.Hour)},
+		{Path: "d.png", Size: 40, ModTime: epoch.Add(-4 * time.Hour)},
+		{Path: "c.png", Size: 20, ModTime: epoch.Add(-3 * time.Hour)},
+	}
+
+	tests := []struct {
+		name     string
+		category Category
+		want     []string
+	}{
+		{name: "no limits", category: Category{}},
+		{name: "within both limits", category: Category{MaxFiles: 4, MaxBytes: 100}},
+		{name: "count limit", category: Category{MaxFiles: 2}, want: []string{"d.png", "c.png"}},
+		{name: "size limit", category: Category{MaxBytes: 45}, want: []string{"d.png", "c.png"}},
+		{name: "size limit on the boundary", category: Category{MaxBytes: 60}, want: []string{"d.png"}},
+		{name: "tighter of both limits", category: Category{MaxFiles: 3, MaxBytes: 40}, want: []string{"d.png", "c.png"}},
+		{name: "newest file alone is too big", category: Category{MaxBytes: 5}, want: []string{"d.png", "c.png", "b.png", "a.png"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			for _, f := range Expired(files, tt.category) {
+				got = append(got, f.Path)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestManager_Enforce(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		categories []Category
+		files      map[string]time.Duration // Path under the diagnostics directory, and age
+		locked     string                   // Base name of a file that cannot be deleted
+		wantDelete []string
+		wantLocked []string
+		wantKeep   []string
+	}{
+		{
+			name:       "oldest beyond the count are deleted",
+			categories: []Category{{Name: Screenshots, MaxFiles: 2}},
+			files:      map[string]time.Duration{"screenshots/1.png": 3 * time.Hour, "screenshots/2.png": 2 * time.Hour, "screenshots/3.png": time.Hour},
+			wantDelete: []string{"1.png"},
+			wantKeep:   []string{"screenshots/2.png", "screenshots/3.png"},
+		},
+		{
+			name:       "each category has its own limits",
+			categories: []Category{{Name: Screenshots, MaxFiles: 1}, {Name: Dumps, MaxFiles: 5}},
+			files:      map[string]time.Duration{"screenshots/1.png": 2 * time.Hour, "screenshots/2.png": time.Hour, "dumps/1.dmp": 3 * time.Hour, "dumps/2.dmp": 2 * time.Hour},
+			wantDelete: []string{"1.png"},
+			wantKeep:   []string{"screenshots/2.png", "dumps/1.dmp", "dumps/2.dmp"},
+		},
+		{
+			name:       "files in subdirectories count",
+			categories: []Category{{Name: RawLogs, MaxFiles: 1}},
+			files:      map[string]time.Duration{"raw-logs/run1/log.txt": 2 * time.Hour, "raw-logs/run2/log.txt": time.Hour},
+			wantDelete: []string{"log.txt"},
+			wantKeep:   []string{"raw-logs/run2/log.txt"},
+		},
+		{
+			name:       "locked file is skipped",
+			categories: []Category{{Name: Screenshots, MaxFiles: 1}},
+			files:      map[string]time.Duration{"screenshots/1.png": 3 * time.Hour, "screenshots/2.png": 2 * time.Hour, "screenshots/3.png": time.Hour},
+			locked:     "1.png",
+			wantDelete: []string{"2.png"},
+			wantLocked: []string{"1.png"},
+			wantKeep:   []string{"screenshots/1.png", "screenshots/3.png"},
+		},
+		{
+			name:       "category without a directory",
+			categories: []Category{{Name: Dumps, MaxFiles: 1}},
+		},
+		{
+			name:       "other categories and other files are not touched",
+			categories: []Category{{Name: Screenshots, MaxFiles: 1}},
+			files:      map[string]time.Duration{"other/1.png": 3 * time.Hour, "../vtpc.log": 3 * time.Hour, "screenshots/1.png": time.Hour},
+			wantKeep:   []string{"other/1.png", "../vtpc.log", "screenshots/1.png"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+			for rel, age := range tt.files {
+				writeFile(t, filepath.Join(root, DirName), rel, 10, age)
+			}
+
+			m := New(root, tt.categories, logger.NewNoOpLogger())
+			m.remove = func(path string) error {
+				if filepath.Base(path) == tt.locked {
+					return errors.New("the process cannot access the file because it is being used by another process")
+				}
+
+				return os.Remove(path)
+			}
+
+			result, err := m.Enforce()
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantDelete, names(result.Deleted))
+			assert.Equal(t, int64(10*len(tt.wantDelete)), result.Freed)
+			assert.Equal(t, tt.wantLocked, names(result.Locked))
+
+			for _, rel := range tt.wantKeep {
+				assert.FileExists(t, filepath.Join(root, DirName, rel))
+			}
+		})
+	}
+}
+
+func TestContained(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	root := filepath.Join(base, "vtpc")
+	inside := writeFile(t, root, "diagnostics/dumps/1.dmp", 1, 0)
+	outside := writeFile(t, base, "project/lobby.vtp", 1, 0)
+
+	link := filepath.Join(root, "diagnostics", "screenshots")
+	symlinked := os.Symlink(filepath.Join(base, "project"), link) == nil
+
+	tests := []struct {
+		name    string
+		path    string
+		symlink bool
+		wantErr error
+	}{
+		{name: "file inside", path: inside},
+		{name: "directory inside", path: filepath.Join(root, "diagnostics")},
+		{name: "root itself", path: root, wantErr: ErrOutsideRoot},
+		{name: "file outside", path: outside, wantErr: ErrOutsideRoot},
+		{name: "dot-dot escape", path: filepath.Join(root, "diagnostics", "..", "..", "project", "lobby.vtp"), wantErr: ErrOutsideRoot},
+		{name: "symlink out of the root", path: filepath.Join(link, "lobby.vtp"), symlink: true, wantErr: ErrOutsideRoot},
+		{name: "missing file", path: filepath.Join(root, "diagnostics", "missing.dmp"), wantErr: os.ErrNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if tt.symlink && !symlinked {
+				t.Skip("symlinks are not available")
+			}
+
+			err := contained(root, tt.path)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestManager_EnforceNeverLeavesTheRoot(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	root := filepath.Join(base, "vtpc")
+	project := writeFile(t, base, "project/lobby.vtp", 10, time.Hour)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, DirName), 0o755))
+
+	// A category directory that points out of the data directory is never followed
+	if err := os.Symlink(filepath.Dir(project), filepath.Join(root, DirName, Screenshots)); err != nil {
+		t.Skip("symlinks are not available")
+	}
+
+	result, err := New(root, []Category{{Name: Screenshots, MaxFiles: 0, MaxBytes: 1}}, logger.NewNoOpLogger()).Enforce()
+	require.NoError(t, err)
+	assert.Empty(t, result.Deleted)
+	assert.FileExists(t, project)
+}
+
+func TestManager_Dir(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	m := New(root, DefaultCategories(), logger.NewNoOpLogger())
+
+	dir, err := m.Dir(Dumps)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, DirName, Dumps), dir)
+	assert.DirExists(t, dir)
+
+	_, err = m.Dir("../../elsewhere")
+	assert.ErrorIs(t, err, ErrUnknownCategory)
+}
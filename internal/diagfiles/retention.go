@@ -0,0 +1,70 @@
+package diagfiles
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// File is a diagnostic file found in a category directory
+type File struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Expired returns the files that must be deleted for a category to be within its
+// limits, oldest first. The newest files are kept while both the count and the
+// total size stay within the limits; everything older is expired.
+func Expired(files []File, c Category) []File {
+	newest := append([]File(nil), files...)
+	sort.SliceStable(newest, func(i, j int) bool {
+		return newest[i].ModTime.After(newest[j].ModTime)
+	})
+
+	var total int64
+
+	for i, f := range newest {
+		total += f.Size
+
+		if (c.MaxFiles > 0 && i+1 > c.MaxFiles) || (c.MaxBytes > 0 && total > c.MaxBytes) {
+			expired := newest[i:]
+
+			// Oldest first, so an interrupted run deletes the files least worth keeping
+			for l, r := 0, len(expired)-1; l < r; l, r = l+1, r-1 {
+				expired[l], expired[r] = expired[r], expired[l]
+			}
+
+			return expired
+		}
+	}
+
+	return nil
+}
+
+// list returns the regular files under dir. Symlinks are not followed or returned.
+func list(dir string) ([]File, error) {
+	var files []File
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil // Deleted since the directory was read
+		}
+
+		files = append(files, File{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+
+		return nil
+	})
+
+	return files, err
+}
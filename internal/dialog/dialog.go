@@ -0,0 +1,67 @@
+// Package dialog provides helpers shared by code that inspects VTPro dialogs.
+package dialog
+
+import (
+	"log/slog"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// ControlSource reads a dialog's text and child controls.
+// interfaces.WindowManager and windows.WindowsAPI both satisfy it.
+type ControlSource interface {
+	GetWindowText(hwnd uintptr) string
+	CollectChildInfos(hwnd uintptr) []windows.ChildInfo
+}
+
+// LogControls logs a dialog's text and every child control at trace level,
+// and returns the controls it collected
+func LogControls(log logger.LoggerInterface, src ControlSource, hwnd uintptr, title string) []windows.ChildInfo {
+	log.Trace("Enumerating dialog controls",
+		slog.String("title", title),
+		slog.Uint64("hwnd", uint64(hwnd)))
+
+	// Get the main window text (dialog body text, if any)
+	windowText := src.GetWindowText(hwnd)
+	if windowText != "" {
+		log.Trace("Dialog window text",
+			slog.String("title", title),
+			slog.String("text", windowText))
+	}
+
+	// Collect all child controls
+	childInfos := src.CollectChildInfos(hwnd)
+
+	if len(childInfos) == 0 {
+		log.Trace("No child controls found in dialog",
+			slog.String("title", title))
+		return nil
+	}
+
+	log.Trace("Found child controls in dialog",
+		slog.String("title", title),
+		slog.Int("count", len(childInfos)))
+
+	// Log details for each child control
+	for i, ci := range childInfos {
+		logAttrs := []any{
+			slog.String("title", title),
+			slog.Int("index", i),
+			slog.Uint64("childHwnd", uint64(ci.Hwnd)),
+			slog.String("className", ci.ClassName),
+		}
+
+		if ci.Text != "" {
+			logAttrs = append(logAttrs, slog.String("text", ci.Text))
+		}
+
+		if len(ci.Items) > 0 {
+			logAttrs = append(logAttrs, slog.Any("items", ci.Items))
+		}
+
+		log.Trace("Child control", logAttrs...)
+	}
+
+	return childInfos
+}
@@ -0,0 +1,57 @@
+package dialog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestLogControls(t *testing.T) {
+	t.Parallel()
+
+	src := testutil.NewMockWindowManager().
+		WithWindowText(0x2222, "Path too long").
+		WithChildInfosForHwnd(0x2222,
+			windows.ChildInfo{Hwnd: 0x3001, ClassName: "Static", Text: "Some paths exceed 260 characters"},
+			windows.ChildInfo{Hwnd: 0x3002, ClassName: "ListBox", Items: []string{"a", "b"}},
+			windows.ChildInfo{Hwnd: 0x3003, ClassName: "Button"},
+		)
+	log := testutil.NewMockLogger()
+
+	controls := LogControls(log, src, 0x2222, "VisionTools(R) Pro-e")
+
+	assert.Len(t, controls, 3)
+	assert.Equal(t, []string{
+		"Enumerating dialog controls",
+		"Dialog window text",
+		"Found child controls in dialog",
+		"Child control",
+		"Child control",
+		"Child control",
+	}, log.Messages())
+
+	for _, e := range log.Entries {
+		assert.Equal(t, "TRACE", e.Level, "dialog controls are only logged to file")
+	}
+
+	assert.Contains(t, log.Entries[3].String(), "Some paths exceed 260 characters")
+	assert.Contains(t, log.Entries[4].String(), "items")
+	assert.NotContains(t, log.Entries[5].String(), "text=", "empty control text is not logged")
+}
+
+func TestLogControls_NoChildren(t *testing.T) {
+	t.Parallel()
+
+	log := testutil.NewMockLogger()
+
+	controls := LogControls(log, testutil.NewMockWindowManager(), 0x2222, "Address Book")
+
+	assert.Nil(t, controls)
+	assert.Equal(t, []string{
+		"Enumerating dialog controls",
+		"No child controls found in dialog",
+	}, log.Messages())
+}
@@ -0,0 +1,194 @@
+package dialog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// Phase is the part of a run in which VTPro shows a dialog
+type Phase string
+
+const (
+	PhaseLoad     Phase = "load"      // While VTPro opens the project
+	PhasePostLoad Phase = "post-load" // After the project has loaded, before compiling
+	PhaseSave     Phase = "save"      // While --save-first saves the project
+	PhaseCompile  Phase = "compile"   // While compiling and closing VTPro
+)
+
+// Action is what vtpc does when a known dialog appears
+type Action string
+
+const (
+	ActionTrack   Action = "track"   // Watch the dialog; its closing ends the phase
+	ActionIgnore  Action = "ignore"  // Leave the dialog alone, e.g. progress dialogs that close themselves
+	ActionClose   Action = "close"   // Close the dialog
+	ActionInspect Action = "inspect" // Read the dialog's text to decide what happened
+)
+
+// Descriptor describes a dialog VTPro is known to show: how to recognise it and
+// what vtpc does with it
+type Descriptor struct {
+	Name   string `json:"name"`
+	Title  string `json:"title"`            // Exact title, or its start when Prefix is set
+	Prefix bool   `json:"prefix,omitempty"` // Match titles starting with Title, e.g. "Progress [42%]"
+	Class  string `json:"class,omitempty"`  // Window class the dialog must have, if set
+	Phase  Phase  `json:"phase"`
+	Action Action `json:"action"`
+	Note   string `json:"note,omitempty"` // What the dialog is, for troubleshooting
+}
+
+// Matches reports whether a window is this dialog, by its title and class.
+// An untitled window is never matched by title, however broad the rule; only
+// a descriptor with a class and no title matches it, and only untitled windows.
+func (d Descriptor) Matches(title, class string) bool {
+	if d.Class != "" && class != d.Class {
+		return false
+	}
+
+	// Untitled windows are matched by class alone, and class-only descriptors match nothing else
+	if windows.IsUntitled(title) || d.Title == "" {
+		return d.Title == "" && d.Class != "" && windows.IsUntitled(title)
+	}
+
+	if d.Prefix {
+		return strings.HasPrefix(title, d.Title)
+	}
+
+	return title == d.Title
+}
+
+// The dialogs vtpc knows about, in the order a run meets them
+var (
+	FileLoading = Descriptor{
+		Name: "file-loading", Title: "VisionTools Pro-e", Phase: PhaseLoad, Action: ActionTrack,
+		Note: "Background dialog shown while the project loads",
+	}
+	LoadProgress = Descriptor{
+		Name: "load-progress", Title: "Progress", Prefix: true, Phase: PhaseLoad, Action: ActionTrack,
+		Note: "Progress bar shown while themes and components load",
+	}
+	PostLoadWarning = Descriptor{
+		Name: "post-load-warning", Title: "VisionTools(R) Pro-e", Phase: PhasePostLoad, Action: ActionClose,
+		Note: "Warning with an OK button, e.g. about path length limits",
+	}
+	SaveMessage = Descriptor{
+		Name: "save-message", Title: "VisionTools(R) Pro-e", Phase: PhaseSave, Action: ActionInspect,
+		Note: "Message box reporting a failed save or asking to overwrite",
+	}
+	Compiling = Descriptor{
+		Name: "compiling", Title: "VisionTools Pro-e Compiling...", Phase: PhaseCompile, Action: ActionTrack,
+		Note: "Open for as long as the compile runs",
+	}
+	CompileProgress = Descriptor{
+		Name: "compile-progress", Title: "Progress", Prefix: true, Phase: PhaseCompile, Action: ActionIgnore,
+		Note: "Progress bar shown during the compile",
+	}
+	CompileStatistics = Descriptor{
+		Name: "compile-statistics", Title: "Compile Complete", Phase: PhaseCompile, Action: ActionInspect,
+		Note: "Error and warning counts and compile time, shown by some VTPro configurations when the compile ends",
+	}
+	AddressBook = Descriptor{
+		Name: "address-book", Title: "Address Book", Phase: PhaseCompile, Action: ActionClose,
+		Note: "May appear after the compile finishes",
+	}
+)
+
+// Registry is a set of dialog descriptors, looked up by name or by phase and title
+type Registry struct {
+	descriptors []Descriptor
+}
+
+// NewRegistry creates a registry of the given descriptors
+func NewRegistry(descriptors ...Descriptor) *Registry {
+	return &Registry{descriptors: descriptors}
+}
+
+// Default returns a registry of the dialogs vtpc knows about
+func Default() *Registry {
+	return NewRegistry(FileLoading, LoadProgress, PostLoadWarning, SaveMessage, Compiling, CompileProgress, CompileStatistics, AddressBook)
+}
+
+// All returns every descriptor in the registry, in order
+func (r *Registry) All() []Descriptor {
+	return append([]Descriptor(nil), r.descriptors...)
+}
+
+// Lookup returns the descriptor with the given name
+func (r *Registry) Lookup(name string) (Descriptor, bool) {
+	for _, d := range r.descriptors {
+		if d.Name == name {
+			return d, true
+		}
+	}
+
+	return Descriptor{}, false
+}
+
+// Match returns the first descriptor in phase that matches a window's title and class, if any
+func (r *Registry) Match(phase Phase, title, class string) (Descriptor, bool) {
+	for _, d := range r.descriptors {
+		if d.Phase == phase && d.Matches(title, class) {
+			return d, true
+		}
+	}
+
+	return Descriptor{}, false
+}
+
+// Critical reports whether a window is a dialog, in any phase, whose text vtpc
+// reads: one it inspects or closes. Such a dialog may be gone by the time its
+// event is handled, so its text is best read as soon as it appears.
+func (r *Registry) Critical(title, class string) bool {
+	for _, d := range r.descriptors {
+		if (d.Action == ActionInspect || d.Action == ActionClose) && d.Matches(title, class) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks that names are unique and that no two descriptors in a phase
+// share a title, or for untitled dialogs a class, so Match is never ambiguous
+func (r *Registry) Validate() error {
+	var errs []error
+
+	names := make(map[string]bool)
+	titles := make(map[Phase]map[string]string)
+
+	for _, d := range r.descriptors {
+		if d.Name == "" || (windows.IsUntitled(d.Title) && d.Class == "") {
+			errs = append(errs, fmt.Errorf("dialog %q: name and a title or class are required", d.Name))
+			continue
+		}
+
+		if d.Title != "" && windows.IsUntitled(d.Title) {
+			errs = append(errs, fmt.Errorf("dialog %q: title must not be only whitespace", d.Name))
+			continue
+		}
+
+		if names[d.Name] {
+			errs = append(errs, fmt.Errorf("dialog %q is registered twice", d.Name))
+		}
+		names[d.Name] = true
+
+		if titles[d.Phase] == nil {
+			titles[d.Phase] = make(map[string]string)
+		}
+
+		key := d.Title
+		if key == "" {
+			key = windows.DisplayTitle("", d.Class)
+		}
+
+		if other, ok := titles[d.Phase][key]; ok {
+			errs = append(errs, fmt.Errorf("dialogs %q and %q share the title %q in the %s phase", other, d.Name, key, d.Phase))
+		}
+		titles[d.Phase][key] = d.Name
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,171 @@
+package dialog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestDefault_IsConsistent(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, Default().Validate())
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	t.Parallel()
+
+	clash := Compiling
+	clash.Name = "compiling-again"
+
+	err := NewRegistry(Compiling, clash, Compiling, Descriptor{Name: "untitled"}).Validate()
+	assert.ErrorContains(t, err, `dialogs "compiling" and "compiling-again" share the title "VisionTools Pro-e Compiling..." in the compile phase`)
+	assert.ErrorContains(t, err, `dialog "compiling" is registered twice`)
+	assert.ErrorContains(t, err, `dialog "untitled": name and a title or class are required`)
+
+	// The same title in different phases is not ambiguous
+	assert.NoError(t, NewRegistry(PostLoadWarning, SaveMessage).Validate())
+}
+
+func TestRegistry_Match(t *testing.T) {
+	t.Parallel()
+
+	r := Default()
+
+	tests := []struct {
+		phase Phase
+		title string
+		want  string // Name of the matching descriptor, empty for none
+	}{
+		{PhaseLoad, "VisionTools Pro-e", "file-loading"},
+		{PhaseLoad, "Progress [42%]", "load-progress"},
+		{PhaseLoad, "VisionTools Pro-e Compiling...", ""},
+		{PhasePostLoad, "VisionTools(R) Pro-e", "post-load-warning"},
+		{PhaseSave, "VisionTools(R) Pro-e", "save-message"},
+		{PhaseCompile, "VisionTools Pro-e Compiling...", "compiling"},
+		{PhaseCompile, "Progress [58%]", "compile-progress"},
+		{PhaseCompile, "Compile Complete", "compile-statistics"},
+		{PhaseCompile, "Address Book", "address-book"},
+		{PhaseCompile, "Address Book (2)", ""},
+		{PhaseCompile, "VisionTools(R) Pro-e", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.phase)+"/"+tt.title, func(t *testing.T) {
+			t.Parallel()
+
+			d, ok := r.Match(tt.phase, tt.title, "#32770")
+			assert.Equal(t, tt.want != "", ok)
+			assert.Equal(t, tt.want, d.Name)
+		})
+	}
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	t.Parallel()
+
+	d, ok := Default().Lookup("address-book")
+	assert.True(t, ok)
+	assert.Equal(t, AddressBook, d)
+
+	_, ok = Default().Lookup("trial-expired")
+	assert.False(t, ok)
+}
+
+func TestRegistry_Critical(t *testing.T) {
+	t.Parallel()
+
+	r := Default()
+
+	assert.True(t, r.Critical("Compile Complete", windows.DialogClass), "inspected")
+	assert.True(t, r.Critical("VisionTools(R) Pro-e", windows.DialogClass), "inspected while saving, closed after loading")
+	assert.True(t, r.Critical("Address Book", ""), "closed")
+	assert.False(t, r.Critical("VisionTools Pro-e Compiling...", windows.DialogClass), "only tracked")
+	assert.False(t, r.Critical("Progress [42%]", windows.DialogClass), "ignored")
+	assert.False(t, r.Critical("Trial Expired", windows.DialogClass), "unknown")
+}
+
+func TestRegistry_AllReturnsACopy(t *testing.T) {
+	t.Parallel()
+
+	r := Default()
+	all := r.All()
+	all[0].Title = "changed"
+
+	assert.Equal(t, FileLoading, r.All()[0])
+}
+
+func TestDescriptor_MatchesUntitledWindows(t *testing.T) {
+	t.Parallel()
+
+	exact := Descriptor{Name: "exact", Title: "Address Book"}
+	prefix := Descriptor{Name: "prefix", Title: "Progress", Prefix: true}
+	catchAll := Descriptor{Name: "catch-all", Title: "", Prefix: true} // Rejected by Validate, but must still be safe
+	byClass := Descriptor{Name: "splash", Class: "Afx:400000"}
+	titledClass := Descriptor{Name: "titled-class", Title: "Progress", Prefix: true, Class: "#32770"}
+
+	tests := []struct {
+		name  string
+		d     Descriptor
+		title string
+		class string
+		want  bool
+	}{
+		{"exact title, untitled window", exact, "", "#32770", false},
+		{"prefix title, untitled window", prefix, "", "#32770", false},
+		{"prefix title, whitespace title", prefix, "   ", "#32770", false},
+		{"empty prefix, untitled window", catchAll, "", "#32770", false},
+		{"empty prefix, titled window", catchAll, "Anything", "#32770", false},
+		{"class only, untitled window of the class", byClass, "", "Afx:400000", true},
+		{"class only, whitespace title of the class", byClass, " \t", "Afx:400000", true},
+		{"class only, untitled window of another class", byClass, "", "#32770", false},
+		{"class only, titled window of the class", byClass, "VTPro", "Afx:400000", false},
+		{"title and class, untitled window of the class", titledClass, "", "#32770", false},
+		{"title and class, both match", titledClass, "Progress [42%]", "#32770", true},
+		{"title and class, wrong class", titledClass, "Progress [42%]", "Afx:400000", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.d.Matches(tt.title, tt.class))
+		})
+	}
+}
+
+func TestRegistry_MatchUntitled(t *testing.T) {
+	t.Parallel()
+
+	splash := Descriptor{Name: "untitled-progress", Class: "Afx:400000", Phase: PhaseLoad, Action: ActionIgnore}
+	r := NewRegistry(append(Default().All(), splash)...)
+	require.NoError(t, r.Validate())
+
+	for _, phase := range []Phase{PhaseLoad, PhasePostLoad, PhaseSave, PhaseCompile} {
+		_, ok := r.Match(phase, "", "#32770")
+		assert.False(t, ok, "no built-in dialog matches an untitled %s dialog", phase)
+	}
+
+	d, ok := r.Match(PhaseLoad, "", "Afx:400000")
+	assert.True(t, ok)
+	assert.Equal(t, "untitled-progress", d.Name)
+}
+
+func TestRegistry_ValidateUntitled(t *testing.T) {
+	t.Parallel()
+
+	err := NewRegistry(
+		Descriptor{Name: "neither", Phase: PhaseLoad},
+		Descriptor{Name: "blank", Title: "  ", Class: "#32770", Phase: PhaseLoad},
+		Descriptor{Name: "splash", Class: "Afx:400000", Phase: PhaseLoad},
+		Descriptor{Name: "splash-again", Class: "Afx:400000", Phase: PhaseLoad},
+	).Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `dialog "neither": name and a title or class are required`)
+	assert.Contains(t, err.Error(), `dialog "blank": title must not be only whitespace`)
+	assert.Contains(t, err.Error(), `dialogs "splash" and "splash-again" share the title "Untitled (Afx:400000)" in the load phase`)
+}
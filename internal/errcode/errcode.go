@@ -0,0 +1,194 @@
+// Package errcode gives every way a vtpc run can fail a stable code, such as
+// VTPC_E_TIMEOUT_COMPILE, that scripts can match on instead of message text.
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Code identifies a kind of failure. Codes never change once released; a
+// failure that needs a different meaning gets a new code.
+type Code string
+
+// Codes carried by the errors vtpc's packages define
+const (
+	VTProMissing      Code = "VTPC_E_VTPRO_MISSING"
+	VTProNotReady     Code = "VTPC_E_VTPRO_NOT_READY"
+	VTProNoWindows    Code = "VTPC_E_VTPRO_NO_WINDOWS"
+	VTProNoMainWindow Code = "VTPC_E_VTPRO_NO_MAIN_WINDOW"
+	AttachNotRunning  Code = "VTPC_E_ATTACH_NOT_RUNNING"
+	AttachAmbiguous   Code = "VTPC_E_ATTACH_AMBIGUOUS"
+	TerminateRefused  Code = "VTPC_E_TERMINATE_REFUSED"
+	MonitorNoPid      Code = "VTPC_E_MONITOR_NO_PID"
+	MonitorRunning    Code = "VTPC_E_MONITOR_RUNNING"
+	TimeoutCompile    Code = "VTPC_E_TIMEOUT_COMPILE"
+	Cancelled         Code = "VTPC_E_CANCELLED"
+	Focus             Code = "VTPC_E_FOCUS"
+	InputBlocked      Code = "VTPC_E_INPUT_BLOCKED"
+	UnexpectedDialog  Code = "VTPC_E_UNEXPECTED_DIALOG"
+	SaveFailed        Code = "VTPC_E_SAVE_FAILED"
+	NoMessageLog      Code = "VTPC_E_NO_MESSAGE_LOG"
+	NotProject        Code = "VTPC_E_NOT_PROJECT"
+	ProjectSidecars   Code = "VTPC_E_PROJECT_SIDECARS"
+	ProjectReadOnly   Code = "VTPC_E_PROJECT_READ_ONLY"
+	ProjectFile       Code = "VTPC_E_PROJECT_FILE"
+	DiskSpace         Code = "VTPC_E_DISK_SPACE"
+	OutputNotWritable Code = "VTPC_E_OUTPUT_NOT_WRITABLE"
+	OutsideWindow     Code = "VTPC_E_OUTSIDE_WINDOW"
+	Slept             Code = "VTPC_E_SLEPT"
+	PolicyViolation   Code = "VTPC_E_POLICY_VIOLATION"
+	PolicyInvalid     Code = "VTPC_E_POLICY_INVALID"
+	ArtifactCorrupt   Code = "VTPC_E_ARTIFACT_CORRUPT"
+	ArtifactLocator   Code = "VTPC_E_ARTIFACT_LOCATOR"
+	BudgetExceeded    Code = "VTPC_E_BUDGET_EXCEEDED"
+	DeployFailed      Code = "VTPC_E_DEPLOY_FAILED"
+	DeployTarget      Code = "VTPC_E_DEPLOY_TARGET"
+	DeployAuth        Code = "VTPC_E_DEPLOY_AUTH"
+	DeployHostKey     Code = "VTPC_E_DEPLOY_HOST_KEY"
+	DeployUnreachable Code = "VTPC_E_DEPLOY_UNREACHABLE"
+	TimeoutDeploy     Code = "VTPC_E_TIMEOUT_DEPLOY"
+	SecretReference   Code = "VTPC_E_SECRET_REFERENCE"
+	SecretUnresolved  Code = "VTPC_E_SECRET_UNRESOLVED"
+	InvalidFlags      Code = "VTPC_E_INVALID_FLAGS"
+	LogUnavailable    Code = "VTPC_E_LOG_UNAVAILABLE"
+	Elevation         Code = "VTPC_E_ELEVATION"
+	Interrupted       Code = "VTPC_E_INTERRUPTED"
+	DaemonNotRunning  Code = "VTPC_E_DAEMON_NOT_RUNNING"
+	DaemonIncomplete  Code = "VTPC_E_DAEMON_INCOMPLETE"
+	DaemonRequest     Code = "VTPC_E_DAEMON_REQUEST"
+	DaemonFrameTooBig Code = "VTPC_E_DAEMON_FRAME_TOO_LARGE"
+	PipeInUse         Code = "VTPC_E_PIPE_IN_USE"
+	PipeClosed        Code = "VTPC_E_PIPE_CLOSED"
+	RegistryNotFound  Code = "VTPC_E_REGISTRY_NOT_FOUND"
+	RecordingEmpty    Code = "VTPC_E_RECORDING_EMPTY"
+	RecordingNoWindow Code = "VTPC_E_RECORDING_NO_MAIN_WINDOW"
+	DiagOutsideRoot   Code = "VTPC_E_DIAG_OUTSIDE_ROOT"
+	DiagCategory      Code = "VTPC_E_DIAG_CATEGORY"
+	Internal          Code = "VTPC_E_INTERNAL"
+	CompileErrors     Code = "VTPC_E_COMPILE_ERRORS" // Not carried by an error: VTPro reported errors
+	Unknown           Code = "VTPC_E_UNKNOWN"        // Not carried by an error: the failure had no code
+)
+
+// Entry is one code in the catalog
+type Entry struct {
+	Code        Code   `json:"code"`
+	Description string `json:"description"`
+}
+
+// catalog describes every code. A code must be listed here before an error can carry it.
+var catalog = []Entry{
+	{VTProMissing, "VTPro is not installed where vtpc looks for it"},
+	{VTProNotReady, "VTPro did not start, show its window or load the project in time"},
+	{VTProNoWindows, "VTPro started but never showed a window"},
+	{VTProNoMainWindow, "VTPro showed windows but none was chosen as its main window"},
+	{AttachNotRunning, "No running VTPro has a project open to attach to"},
+	{AttachAmbiguous, "More than one running VTPro has a project open"},
+	{TerminateRefused, "vtpc refused to terminate a process it cannot show is the VTPro it launched"},
+	{MonitorNoPid, "The window monitor was started without a VTPro process"},
+	{MonitorRunning, "The window monitor was started twice"},
+	{TimeoutCompile, "The compile did not finish in time"},
+	{Cancelled, "The compile was cancelled from the Compiling dialog"},
+	{Focus, "VTPro could not be brought to the foreground to receive the compile keystroke"},
+	{InputBlocked, "An elevated window in the foreground swallowed vtpc's keystrokes"},
+	{UnexpectedDialog, "--strict-dialogs stopped at a dialog vtpc does not know"},
+	{SaveFailed, "--save-first could not save the project"},
+	{NoMessageLog, "No compile output was found in the Message Log"},
+	{NotProject, "The file is not a VTPro project"},
+	{ProjectSidecars, "Files the project needs beside it are missing"},
+	{ProjectReadOnly, "The project directory is read-only"},
+	{ProjectFile, "The project's .vtpc.yaml file is not valid"},
+	{DiskSpace, "Not enough free disk space for the compile"},
+	{OutputNotWritable, "The output directory is not writable"},
+	{OutsideWindow, "The run started outside every maintenance window"},
+	{Slept, "The machine slept during a wait and --on-sleep fail was given"},
+	{PolicyViolation, "The machine policy does not allow the project"},
+	{PolicyInvalid, "The machine policy could not be read"},
+	{ArtifactCorrupt, "--verify-artifact found the compiled artifact corrupt"},
+	{ArtifactLocator, "The artifact locator failed or found no artifact"},
+	{BudgetExceeded, "The compile took longer than --max-compile-time"},
+	{DeployFailed, "--deploy could not upload the artifact"},
+	{DeployTarget, "The --deploy URL is not valid"},
+	{DeployAuth, "The panel rejected the --deploy login"},
+	{DeployHostKey, "The panel's SFTP host key is not trusted"},
+	{DeployUnreachable, "The panel could not be reached"},
+	{TimeoutDeploy, "The upload to the panel did not finish in time"},
+	{SecretReference, "A secret reference such as --deploy-password is not valid"},
+	{SecretUnresolved, "A secret reference could not be read"},
+	{InvalidFlags, "The command line is not valid"},
+	{LogUnavailable, "The log file could not be created"},
+	{Elevation, "vtpc could not relaunch itself as administrator"},
+	{Interrupted, "The run was interrupted by Ctrl+C, the console closing or the cancel file"},
+	{DaemonNotRunning, "No vtpc daemon is running"},
+	{DaemonIncomplete, "The daemon closed the connection before replying"},
+	{DaemonRequest, "The daemon could not carry out the request"},
+	{DaemonFrameTooBig, "A daemon message was larger than allowed"},
+	{PipeInUse, "The daemon's named pipe is already in use"},
+	{PipeClosed, "The daemon's named pipe was closed"},
+	{RegistryNotFound, "A registry value vtpc reads is missing"},
+	{RecordingEmpty, "The recording to replay is empty"},
+	{RecordingNoWindow, "The recording to replay has no VTPro main window"},
+	{DiagOutsideRoot, "A diagnostics path is outside the vtpc data directory"},
+	{DiagCategory, "The diagnostics category is not known"},
+	{Internal, "vtpc itself crashed"},
+	{CompileErrors, "VTPro reported compile errors"},
+	{Unknown, "The run failed in a way that has no code of its own"},
+}
+
+// Error is an error with a code. Errors that scripts may need to tell apart
+// are created with New, so none can be declared without one.
+type Error struct {
+	code Code
+	msg  string
+}
+
+// New returns an error with message msg carrying code. It panics if code is
+// not in the catalog, so an uncatalogued code fails as soon as its package loads.
+func New(code Code, msg string) *Error {
+	if _, ok := Describe(code); !ok {
+		panic(fmt.Sprintf("errcode: %q is not in the catalog", code))
+	}
+
+	return &Error{code: code, msg: msg}
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// Code returns the error's code
+func (e *Error) Code() Code {
+	return e.code
+}
+
+// Of returns the code of the first error in err's chain that has one, or "" if none does
+func Of(err error) Code {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+
+	return ""
+}
+
+// Describe returns what a code means, and whether it is in the catalog
+func Describe(code Code) (string, bool) {
+	for _, e := range catalog {
+		if e.Code == code {
+			return e.Description, true
+		}
+	}
+
+	return "", false
+}
+
+// Catalog returns every code and what it means, sorted by code
+func Catalog() []Entry {
+	entries := make([]Entry, len(catalog))
+	copy(entries, catalog)
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Code < entries[j].Code })
+
+	return entries
+}
@@ -0,0 +1,162 @@
+package errcode_test
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+func TestCatalog_CodesAreUniqueAndDescribed(t *testing.T) {
+	t.Parallel()
+
+	seen := make(map[errcode.Code]bool)
+
+	for _, e := range errcode.Catalog() {
+		assert.False(t, seen[e.Code], "%s is listed twice", e.Code)
+		seen[e.Code] = true
+
+		assert.True(t, strings.HasPrefix(string(e.Code), "VTPC_E_"), "%s does not start with VTPC_E_", e.Code)
+		assert.Equal(t, strings.ToUpper(string(e.Code)), string(e.Code), "%s is not upper case", e.Code)
+		assert.NotEmpty(t, e.Description, "%s has no description", e.Code)
+	}
+}
+
+func TestNew_RequiresCataloguedCode(t *testing.T) {
+	t.Parallel()
+
+	assert.PanicsWithValue(t, `errcode: "VTPC_E_NOPE" is not in the catalog`, func() {
+		errcode.New("VTPC_E_NOPE", "nope")
+	})
+}
+
+func TestOf(t *testing.T) {
+	t.Parallel()
+
+	sentinel := errcode.New(errcode.TimeoutCompile, "compilation timeout")
+	wrapped := fmt.Errorf("waiting for VTPro: %w", sentinel)
+
+	assert.Equal(t, errcode.TimeoutCompile, errcode.Of(sentinel))
+	assert.Equal(t, errcode.TimeoutCompile, errcode.Of(wrapped))
+	assert.ErrorIs(t, wrapped, sentinel)
+	assert.Equal(t, "compilation timeout", sentinel.Error())
+
+	assert.Empty(t, errcode.Of(errors.New("plain")))
+	assert.Empty(t, errcode.Of(nil))
+}
+
+// sentinel is a package-level error variable found in the source
+type sentinel struct {
+	pos  string
+	name string
+	code string // Name of the errcode constant it carries, "" if it was not made with errcode.New
+}
+
+// findSentinels returns every package-level variable named Err* or err* that
+// is set to a call, in the module's non-test Go files
+func findSentinels(t *testing.T) []sentinel {
+	t.Helper()
+
+	root := filepath.Join("..", "..")
+	fset := token.NewFileSet()
+
+	var found []sentinel
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() && (d.Name() == "testdata" || strings.HasPrefix(d.Name(), ".")) && path != root {
+			return filepath.SkipDir
+		}
+
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+
+				for i, name := range vs.Names {
+					if !strings.HasPrefix(name.Name, "Err") && !strings.HasPrefix(name.Name, "err") || i >= len(vs.Values) {
+						continue
+					}
+
+					call, ok := vs.Values[i].(*ast.CallExpr)
+					if !ok {
+						continue
+					}
+
+					found = append(found, sentinel{pos: fset.Position(name.Pos()).String(), name: name.Name, code: codeArg(call)})
+				}
+			}
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	return found
+}
+
+// codeArg returns X for a call errcode.New(errcode.X, ...), or "" for any other call
+func codeArg(call *ast.CallExpr) string {
+	fn, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || fn.Sel.Name != "New" || len(call.Args) == 0 {
+		return ""
+	}
+
+	if pkg, ok := fn.X.(*ast.Ident); !ok || pkg.Name != "errcode" {
+		return ""
+	}
+
+	arg, ok := call.Args[0].(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+
+	return arg.Sel.Name
+}
+
+func TestSentinels_EveryOneHasItsOwnCode(t *testing.T) {
+	t.Parallel()
+
+	sentinels := findSentinels(t)
+	require.NotEmpty(t, sentinels, "no sentinel errors found; has the package moved?")
+
+	byCode := make(map[string]string)
+
+	for _, s := range sentinels {
+		if !assert.NotEmpty(t, s.code, "%s: %s must be created with errcode.New and a code from the catalog", s.pos, s.name) {
+			continue
+		}
+
+		if other, ok := byCode[s.code]; ok {
+			t.Errorf("%s: %s has errcode.%s, already carried by %s", s.pos, s.name, s.code, other)
+		}
+
+		byCode[s.code] = s.name
+	}
+}
@@ -0,0 +1,127 @@
+// Package eventlog writes a summary of each run to the Windows Event Log with
+// --eventlog, for build machines that are monitored there rather than through
+// log files.
+package eventlog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+// Source is the event source vtpc registers and writes as
+const Source = "vtpc"
+
+// Event IDs, one per outcome, so monitoring rules can filter on them
+const (
+	EventSucceeded uint32 = 1 // The run succeeded without warnings
+	EventWarnings  uint32 = 2 // The run succeeded with warnings
+	EventFailed    uint32 = 3 // The run failed
+)
+
+// Severity is the level an entry is written at
+type Severity int
+
+const (
+	SeverityInformation Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+// String returns the name Event Viewer shows for the level
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "Warning"
+	case SeverityError:
+		return "Error"
+	default:
+		return "Information"
+	}
+}
+
+// Log is an event source that entries are written to. *eventlog.Log from
+// golang.org/x/sys/windows/svc/eventlog implements it.
+type Log interface {
+	Info(eid uint32, msg string) error
+	Warning(eid uint32, msg string) error
+	Error(eid uint32, msg string) error
+	Close() error
+}
+
+// Opener opens the event source, registering it first if needed
+type Opener func() (Log, error)
+
+// Classify returns the level and event ID of the entry for a run: error for a
+// failed run, warning for a run with warnings, and information otherwise
+func Classify(run *report.Run) (Severity, uint32) {
+	switch {
+	case run.Summary.Cause != report.CauseNone:
+		return SeverityError, EventFailed
+	case run.Warnings > 0:
+		return SeverityWarning, EventWarnings
+	default:
+		return SeverityInformation, EventSucceeded
+	}
+}
+
+// Format returns the message of the entry for a run, one "Key: value" per line
+// so it can be read in Event Viewer and parsed by monitoring tools
+func Format(run *report.Run) string {
+	var b strings.Builder
+
+	result := "succeeded"
+	if run.Summary.Cause != report.CauseNone {
+		result = "failed"
+	}
+
+	fmt.Fprintf(&b, "vtpc run %s\n\n", result)
+	fmt.Fprintf(&b, "Run ID: %s\n", run.ID)
+	fmt.Fprintf(&b, "Project: %s\n", run.Project)
+	fmt.Fprintf(&b, "Warnings: %d\n", run.Warnings)
+	fmt.Fprintf(&b, "Errors: %d\n", run.Errors)
+	fmt.Fprintf(&b, "Duration: %s\n", run.Summary.Duration.Round(time.Millisecond))
+
+	if run.Summary.Cause != report.CauseNone {
+		fmt.Fprintf(&b, "Cause: %s\n", run.Summary.Cause)
+	}
+
+	if run.Summary.Err != nil {
+		fmt.Fprintf(&b, "Error: %v\n", run.Summary.Err)
+	}
+
+	if run.Summary.LogPath != "" {
+		fmt.Fprintf(&b, "Log: %s\n", run.Summary.LogPath)
+	}
+
+	return b.String()
+}
+
+// Write writes the entry for a run to the event source open returns
+func Write(open Opener, run *report.Run) error {
+	l, err := open()
+	if err != nil {
+		return fmt.Errorf("could not open event source %q: %w", Source, err)
+	}
+
+	severity, id := Classify(run)
+	msg := Format(run)
+
+	switch severity {
+	case SeverityError:
+		err = l.Error(id, msg)
+	case SeverityWarning:
+		err = l.Warning(id, msg)
+	default:
+		err = l.Info(id, msg)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("could not write to event source %q: %w", Source, err)
+	}
+
+	return errors.Join(err, l.Close())
+}
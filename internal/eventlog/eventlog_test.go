@@ -0,0 +1,149 @@
+package eventlog
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+// entry is one event written to a fakeLog
+type entry struct {
+	severity string
+	id       uint32
+	msg      string
+}
+
+// fakeLog records the events written to it
+type fakeLog struct {
+	entries  []entry
+	writeErr error
+	closed   bool
+}
+
+func (f *fakeLog) write(severity string, id uint32, msg string) error {
+	f.entries = append(f.entries, entry{severity, id, msg})
+	return f.writeErr
+}
+
+func (f *fakeLog) Info(id uint32, msg string) error    { return f.write("Information", id, msg) }
+func (f *fakeLog) Warning(id uint32, msg string) error { return f.write("Warning", id, msg) }
+func (f *fakeLog) Error(id uint32, msg string) error   { return f.write("Error", id, msg) }
+func (f *fakeLog) Close() error                        { f.closed = true; return nil }
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		run      report.Run
+		severity Severity
+		id       uint32
+	}{
+		{name: "clean success", run: report.Run{}, severity: SeverityInformation, id: EventSucceeded},
+		{name: "success with warnings", run: report.Run{Warnings: 3}, severity: SeverityWarning, id: EventWarnings},
+		{name: "compile errors", run: report.Run{Warnings: 3, Errors: 2, Summary: report.Summary{Cause: report.CauseCompileErrors}}, severity: SeverityError, id: EventFailed},
+		{name: "failure without compiling", run: report.Run{Summary: report.Summary{Cause: report.CauseVTProNotFound}}, severity: SeverityError, id: EventFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			severity, id := Classify(&tt.run)
+			assert.Equal(t, tt.severity, severity)
+			assert.Equal(t, tt.id, id)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		run  report.Run
+		want string
+	}{
+		{
+			name: "success",
+			run: report.Run{
+				ID:       "20250601T083005-0a1bff",
+				Project:  `C:\Projects\lobby.vtp`,
+				Warnings: 1,
+				Summary:  report.Summary{Duration: 83*time.Second + 456789*time.Microsecond, LogPath: `C:\Users\ci\AppData\Local\vtpc\vtpc.log`},
+			},
+			want: "vtpc run succeeded\n\n" +
+				"Run ID: 20250601T083005-0a1bff\n" +
+				"Project: C:\\Projects\\lobby.vtp\n" +
+				"Warnings: 1\n" +
+				"Errors: 0\n" +
+				"Duration: 1m23.457s\n" +
+				"Log: C:\\Users\\ci\\AppData\\Local\\vtpc\\vtpc.log\n",
+		},
+		{
+			name: "failure",
+			run: report.Run{
+				ID:      "20250601T083005-0a1bff",
+				Project: `C:\Projects\lobby.vtp`,
+				Errors:  2,
+				Summary: report.Summary{Cause: report.CauseCompileErrors, Err: errors.New("compilation failed with 2 error(s)"), Duration: 40 * time.Second},
+			},
+			want: "vtpc run failed\n\n" +
+				"Run ID: 20250601T083005-0a1bff\n" +
+				"Project: C:\\Projects\\lobby.vtp\n" +
+				"Warnings: 0\n" +
+				"Errors: 2\n" +
+				"Duration: 40s\n" +
+				"Cause: " + report.CauseCompileErrors.String() + "\n" +
+				"Error: compilation failed with 2 error(s)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, Format(&tt.run))
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	run := &report.Run{ID: "id", Warnings: 2}
+
+	l := &fakeLog{}
+	require.NoError(t, Write(func() (Log, error) { return l, nil }, run))
+
+	require.Len(t, l.entries, 1)
+	assert.Equal(t, entry{"Warning", EventWarnings, Format(run)}, l.entries[0])
+	assert.True(t, l.closed)
+}
+
+func TestWrite_Errors(t *testing.T) {
+	t.Parallel()
+
+	run := &report.Run{}
+
+	err := Write(func() (Log, error) { return nil, errors.New("access is denied") }, run)
+	assert.ErrorContains(t, err, `could not open event source "vtpc": access is denied`)
+
+	l := &fakeLog{writeErr: errors.New("the event log file is full")}
+	err = Write(func() (Log, error) { return l, nil }, run)
+	assert.ErrorContains(t, err, `could not write to event source "vtpc": the event log file is full`)
+	assert.True(t, l.closed, "the source is closed even when the write fails")
+}
+
+func TestSeverity_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Information", SeverityInformation.String())
+	assert.Equal(t, "Warning", SeverityWarning.String())
+	assert.Equal(t, "Error", SeverityError.String())
+}
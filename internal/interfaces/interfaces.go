@@ -11,12 +11,21 @@ import (
 type WindowManager interface {
 	CloseWindow(hwnd uintptr, title string)
 	SetForeground(hwnd uintptr) bool
+	RestoreWindow(hwnd uintptr) bool
+	MinimizeWindow(hwnd uintptr) bool
 	VerifyForegroundWindow(expectedHwnd uintptr, expectedPid uint32) bool
 	IsElevated() bool
 	IsWindowValid(hwnd uintptr) bool
 	CollectChildInfos(hwnd uintptr) []windows.ChildInfo
 	WaitOnMonitor(timeout time.Duration, matchers ...func(windows.WindowEvent) bool) (windows.WindowEvent, bool)
 	GetWindowText(hwnd uintptr) string
+	GetForegroundWindow() uintptr
+	GetWindowPid(hwnd uintptr) uint32
+	GetProcessIntegrity(pid uint32) (windows.IntegrityLevel, error)
+	GetProcessName(pid uint32) string
+	GetWindowRect(hwnd uintptr) (windows.RECT, bool)
+	GetScreenBounds() (virtual, primary windows.RECT)
+	MoveWindow(hwnd uintptr, x, y int32) bool
 }
 
 // KeyboardInjector handles keyboard input
@@ -25,6 +34,8 @@ type KeyboardInjector interface {
 	SendEnter()
 	SendF12ToWindow(hwnd uintptr) bool
 	SendF12WithSendInput() bool
+	SendCtrlS() bool
+	SendEscToWindow(hwnd uintptr) bool
 }
 
 // ProcessManager handles SIMPL process operations
@@ -37,5 +48,8 @@ type ProcessManager interface {
 type ControlReader interface {
 	GetListBoxItems(hwnd uintptr) []string
 	GetEditText(hwnd uintptr) string
+	GetTextLength(hwnd uintptr) int
+	GetTextLimit(hwnd uintptr) int
+	SetTextLimit(hwnd uintptr, limit int) bool
 	FindAndClickButton(parentHwnd uintptr, buttonText string) bool
 }
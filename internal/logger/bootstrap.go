@@ -0,0 +1,27 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+)
+
+// BootstrapLogger logs warnings and errors to a writer until the log file is
+// open, so a run that fails before then still says why
+type BootstrapLogger struct {
+	console *slog.Logger
+}
+
+// NewBootstrapLogger creates a logger that writes warnings and errors to w
+func NewBootstrapLogger(w io.Writer) *BootstrapLogger {
+	return &BootstrapLogger{console: slog.New(&ConsoleHandler{writer: w})}
+}
+
+func (b *BootstrapLogger) Trace(msg string, args ...any) {}
+func (b *BootstrapLogger) Debug(msg string, args ...any) {}
+func (b *BootstrapLogger) Info(msg string, args ...any)  {}
+func (b *BootstrapLogger) Warn(msg string, args ...any)  { b.console.Warn(msg, args...) }
+func (b *BootstrapLogger) Error(msg string, args ...any) { b.console.Error(msg, args...) }
+func (b *BootstrapLogger) Close()                        {}
+
+// GetLogPath returns "", as there is no log file yet
+func (b *BootstrapLogger) GetLogPath() string { return "" }
@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootstrapLogger_WritesWarningsAndErrors(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	log := NewBootstrapLogger(&buf)
+
+	log.Trace("trace")
+	log.Debug("debug")
+	log.Info("info")
+	log.Warn("careful")
+	log.Error("broken")
+	log.Close()
+
+	assert.NotContains(t, buf.String(), "trace")
+	assert.NotContains(t, buf.String(), "debug")
+	assert.NotContains(t, buf.String(), "info")
+	assert.Contains(t, buf.String(), "WARNING: careful")
+	assert.Contains(t, buf.String(), "ERROR: broken")
+	assert.Empty(t, log.GetLogPath())
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// LogDirSource says where the default log directory came from
+type LogDirSource int
+
+const (
+	LogDirLocalAppData LogDirSource = iota // %LOCALAPPDATA%\vtpc
+	LogDirUserProfile                      // %USERPROFILE%\AppData\Local\vtpc
+	LogDirUserCache                        // The user cache directory, when neither variable is set
+	LogDirTemp                             // The temp directory, as a last resort
+)
+
+// String returns where the directory came from, for messages
+func (s LogDirSource) String() string {
+	switch s {
+	case LogDirUserProfile:
+		return "USERPROFILE"
+	case LogDirUserCache:
+		return "user cache directory"
+	case LogDirTemp:
+		return "temp directory"
+	default:
+		return "LOCALAPPDATA"
+	}
+}
+
+// Unusual reports whether the directory is a fallback used because neither
+// LOCALAPPDATA nor USERPROFILE is set, as under some service wrappers
+func (s LogDirSource) Unusual() bool {
+	return s >= LogDirUserCache
+}
+
+// logDirEnv is what DefaultLogDir looks at, so the fallback chain can be tested
+type logDirEnv struct {
+	getenv       func(string) string
+	userCacheDir func() (string, error)
+	tempDir      func() string
+}
+
+// osLogDirEnv is the process's environment
+var osLogDirEnv = logDirEnv{
+	getenv:       os.Getenv,
+	userCacheDir: os.UserCacheDir,
+	tempDir:      os.TempDir,
+}
+
+// DefaultLogDir returns the directory logs are written to when LoggerOptions.LogDir
+// is empty, and where it came from. It tries %LOCALAPPDATA%, %USERPROFILE%\AppData\Local,
+// the user cache directory and then the temp directory, skipping any that is
+// not absolute, so logs never land in whatever the working directory is.
+func DefaultLogDir() (string, LogDirSource) {
+	return defaultLogDir(osLogDirEnv)
+}
+
+func defaultLogDir(env logDirEnv) (string, LogDirSource) {
+	if dir := env.getenv("LOCALAPPDATA"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "vtpc"), LogDirLocalAppData
+	}
+
+	if dir := env.getenv("USERPROFILE"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "AppData", "Local", "vtpc"), LogDirUserProfile
+	}
+
+	if dir, err := env.userCacheDir(); err == nil && filepath.IsAbs(dir) {
+		return filepath.Join(dir, "vtpc"), LogDirUserCache
+	}
+
+	dir := env.tempDir()
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	return filepath.Join(dir, "vtpc"), LogDirTemp
+}
+
+// fallbackWarning makes sure the warning about an unusual log directory is
+// only given once per process, however many loggers are created
+var fallbackWarning sync.Once
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultLogDir_FallbackChain(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	local := filepath.Join(root, "Local")
+	profile := filepath.Join(root, "ci")
+	cache := filepath.Join(root, "cache")
+	temp := filepath.Join(root, "temp")
+
+	tests := []struct {
+		name       string
+		vars       map[string]string
+		cacheErr   error
+		cache      string
+		want       string
+		wantSource LogDirSource
+	}{
+		{
+			name:       "LOCALAPPDATA",
+			vars:       map[string]string{"LOCALAPPDATA": local, "USERPROFILE": profile},
+			want:       filepath.Join(local, "vtpc"),
+			wantSource: LogDirLocalAppData,
+		},
+		{
+			name:       "USERPROFILE",
+			vars:       map[string]string{"USERPROFILE": profile},
+			want:       filepath.Join(profile, "AppData", "Local", "vtpc"),
+			wantSource: LogDirUserProfile,
+		},
+		{
+			name:       "both unset uses the user cache directory",
+			cache:      cache,
+			want:       filepath.Join(cache, "vtpc"),
+			wantSource: LogDirUserCache,
+		},
+		{
+			name:       "no user cache directory uses the temp directory",
+			cacheErr:   errors.New("neither $XDG_CACHE_HOME nor $HOME are defined"),
+			want:       filepath.Join(temp, "vtpc"),
+			wantSource: LogDirTemp,
+		},
+		{
+			name:       "relative variables are skipped",
+			vars:       map[string]string{"LOCALAPPDATA": "Local", "USERPROFILE": "ci"},
+			cache:      "cache",
+			want:       filepath.Join(temp, "vtpc"),
+			wantSource: LogDirTemp,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir, source := defaultLogDir(logDirEnv{
+				getenv:       func(key string) string { return tt.vars[key] },
+				userCacheDir: func() (string, error) { return tt.cache, tt.cacheErr },
+				tempDir:      func() string { return temp },
+			})
+
+			assert.Equal(t, tt.want, dir)
+			assert.Equal(t, tt.wantSource, source)
+		})
+	}
+}
+
+func TestDefaultLogDir_RelativeTempDir(t *testing.T) {
+	t.Parallel()
+
+	dir, source := defaultLogDir(logDirEnv{
+		getenv:       func(string) string { return "" },
+		userCacheDir: func() (string, error) { return "", errors.New("unset") },
+		tempDir:      func() string { return "tmp" },
+	})
+
+	assert.True(t, filepath.IsAbs(dir), "got %s", dir)
+	assert.Equal(t, LogDirTemp, source)
+}
+
+func TestLogDirSource(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, LogDirLocalAppData.Unusual())
+	assert.False(t, LogDirUserProfile.Unusual())
+	assert.True(t, LogDirUserCache.Unusual())
+	assert.True(t, LogDirTemp.Unusual())
+
+	assert.Equal(t, "LOCALAPPDATA", LogDirLocalAppData.String())
+	assert.Equal(t, "temp directory", LogDirTemp.String())
+}
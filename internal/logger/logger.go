@@ -41,11 +41,12 @@ type LoggerInterface interface {
 // LoggerOptions configures the logger
 type LoggerOptions struct {
 	Verbose    bool
-	LogDir     string // If empty, uses %LOCALAPPDATA%\vtpc
-	MaxSize    int    // Max size in megabytes before rotation (default: 10)
-	MaxBackups int    // Max number of old log files to keep (default: 3)
-	MaxAge     int    // Max days to keep old log files (default: 28)
-	Compress   bool   // Whether to compress rotated logs (default: true)
+	LogDir     string    // If empty, uses %LOCALAPPDATA%\vtpc
+	MaxSize    int       // Max size in megabytes before rotation (default: 10)
+	MaxBackups int       // Max number of old log files to keep (default: 3)
+	MaxAge     int       // Max days to keep old log files (default: 28)
+	Compress   bool      // Whether to compress rotated logs (default: true)
+	Console    io.Writer // Where console output goes (default: os.Stdout)
 }
 
 // GetLogPath returns the path where logs will be written based on options
@@ -53,13 +54,7 @@ func GetLogPath(opts LoggerOptions) string {
 	// Determine log directory
 	logDir := opts.LogDir
 	if logDir == "" {
-		localAppData := os.Getenv("LOCALAPPDATA")
-
-		if localAppData == "" {
-			localAppData = filepath.Join(os.Getenv("USERPROFILE"), "AppData", "Local")
-		}
-
-		logDir = filepath.Join(localAppData, "vtpc")
+		logDir, _ = DefaultLogDir()
 	}
 
 	return filepath.Join(logDir, "vtpc.log")
@@ -144,8 +139,13 @@ func NewLogger(opts LoggerOptions) (*Logger, error) {
 	}))
 
 	// Console logger: clean output without timestamps
+	console := opts.Console
+	if console == nil {
+		console = os.Stdout
+	}
+
 	consoleHandler := &ConsoleHandler{
-		writer:  os.Stdout,
+		writer:  console,
 		verbose: opts.Verbose,
 	}
 
@@ -158,6 +158,14 @@ func NewLogger(opts LoggerOptions) (*Logger, error) {
 		logPath:          logPath,
 	}
 
+	if opts.LogDir == "" {
+		if dir, source := DefaultLogDir(); source.Unusual() {
+			fallbackWarning.Do(func() {
+				logger.Warn("Neither LOCALAPPDATA nor USERPROFILE is set, writing logs to the "+source.String(), slog.String("dir", dir))
+			})
+		}
+	}
+
 	return logger, nil
 }
 
@@ -101,6 +101,18 @@ func TestNewLogger_FallbackToUserProfile(t *testing.T) {
 	assert.Equal(t, expectedPath, logPath)
 }
 
+func TestGetLogPath_NoProfileVariables(t *testing.T) {
+	// As under some service wrappers, where the log used to land in the working directory
+	t.Setenv("LOCALAPPDATA", "")
+	t.Setenv("USERPROFILE", "")
+
+	logPath := logger.GetLogPath(logger.LoggerOptions{})
+	assert.True(t, filepath.IsAbs(logPath), "got %s", logPath)
+
+	_, source := logger.DefaultLogDir()
+	assert.True(t, source.Unusual())
+}
+
 func TestNewLogger_WithCompression(t *testing.T) {
 	tmpDir := t.TempDir()
 
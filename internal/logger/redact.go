@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// RedactingLogger passes every message and attribute through a redact function
+// before logging it, so secrets such as deploy passwords never reach the log
+// file or the console
+type RedactingLogger struct {
+	inner  LoggerInterface
+	redact func(string) string
+}
+
+// NewRedacting wraps inner so redact is applied to everything it logs
+func NewRedacting(inner LoggerInterface, redact func(string) string) *RedactingLogger {
+	return &RedactingLogger{inner: inner, redact: redact}
+}
+
+func (l *RedactingLogger) Trace(msg string, args ...any) {
+	msg, args = l.apply(msg, args)
+	l.inner.Trace(msg, args...)
+}
+
+func (l *RedactingLogger) Debug(msg string, args ...any) {
+	msg, args = l.apply(msg, args)
+	l.inner.Debug(msg, args...)
+}
+
+func (l *RedactingLogger) Info(msg string, args ...any) {
+	msg, args = l.apply(msg, args)
+	l.inner.Info(msg, args...)
+}
+
+func (l *RedactingLogger) Warn(msg string, args ...any) {
+	msg, args = l.apply(msg, args)
+	l.inner.Warn(msg, args...)
+}
+
+func (l *RedactingLogger) Error(msg string, args ...any) {
+	msg, args = l.apply(msg, args)
+	l.inner.Error(msg, args...)
+}
+
+func (l *RedactingLogger) Close()             { l.inner.Close() }
+func (l *RedactingLogger) GetLogPath() string { return l.inner.GetLogPath() }
+
+// apply returns msg and args redacted, with args turned into slog.Attrs
+func (l *RedactingLogger) apply(msg string, args []any) (string, []any) {
+	// A record turns key-value pairs into attributes the same way the logger would
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "", 0)
+	r.Add(args...)
+
+	attrs := make([]any, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, l.attr(a))
+		return true
+	})
+
+	return l.redact(msg), attrs
+}
+
+// attr returns a with its value redacted. Numbers, durations and the like are
+// left as they are; anything that formats as text is redacted as that text.
+func (l *RedactingLogger) attr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(l.redact(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, g := range group {
+			redacted[i] = l.attr(g)
+		}
+
+		a.Value = slog.GroupValue(redacted...)
+	case slog.KindAny:
+		text := fmt.Sprint(a.Value.Any())
+		if redacted := l.redact(text); redacted != text {
+			a.Value = slog.StringValue(redacted)
+		}
+	}
+
+	return a
+}
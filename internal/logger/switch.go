@@ -0,0 +1,41 @@
+package logger
+
+import "sync"
+
+// SwitchingLogger forwards to whichever logger it was last given, so something
+// that outlives a single run, such as a VTPro the daemon keeps open between
+// compiles, logs to the run that is using it
+type SwitchingLogger struct {
+	mu    sync.RWMutex
+	inner LoggerInterface
+}
+
+// NewSwitching creates a SwitchingLogger that forwards to inner until Use is called
+func NewSwitching(inner LoggerInterface) *SwitchingLogger {
+	return &SwitchingLogger{inner: inner}
+}
+
+// Use forwards everything logged from now on to inner
+func (l *SwitchingLogger) Use(inner LoggerInterface) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inner = inner
+}
+
+func (l *SwitchingLogger) current() LoggerInterface {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.inner
+}
+
+func (l *SwitchingLogger) Trace(msg string, args ...any) { l.current().Trace(msg, args...) }
+func (l *SwitchingLogger) Debug(msg string, args ...any) { l.current().Debug(msg, args...) }
+func (l *SwitchingLogger) Info(msg string, args ...any)  { l.current().Info(msg, args...) }
+func (l *SwitchingLogger) Warn(msg string, args ...any)  { l.current().Warn(msg, args...) }
+func (l *SwitchingLogger) Error(msg string, args ...any) { l.current().Error(msg, args...) }
+func (l *SwitchingLogger) GetLogPath() string            { return l.current().GetLogPath() }
+
+// Close does nothing: the loggers it forwards to are closed by whoever opened them
+func (l *SwitchingLogger) Close() {}
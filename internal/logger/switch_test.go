@@ -0,0 +1,30 @@
+package logger_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+)
+
+func TestSwitchingLogger(t *testing.T) {
+	t.Parallel()
+
+	daemon := testutil.NewMockLogger()
+	run := testutil.NewMockLogger()
+
+	log := logger.NewSwitching(daemon)
+	log.Info("Before the run")
+
+	log.Use(run)
+	log.Warn("During the run")
+	log.Debug("Still during the run")
+
+	log.Use(daemon)
+	log.Error("After the run")
+
+	assert.Equal(t, []string{"Before the run", "After the run"}, daemon.Messages())
+	assert.Equal(t, []string{"During the run", "Still during the run"}, run.Messages())
+}
@@ -0,0 +1,33 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+// JSONWriter writes the run as a report.Document, for scripts and vtpc diff
+type JSONWriter struct {
+	Path string
+}
+
+// NewJSONWriter creates a JSONWriter for path
+func NewJSONWriter(path string) Writer {
+	return JSONWriter{Path: path}
+}
+
+// Write writes the report to the writer's path, atomically so a build server
+// collecting it never reads half of it
+func (w JSONWriter) Write(ctx context.Context, run *report.Run) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(report.NewDocument(run), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(w.Path, append(data, '\n'))
+}
@@ -0,0 +1,59 @@
+package output
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+func TestJSONWriter_Write(t *testing.T) {
+	t.Parallel()
+
+	started := time.Date(2025, 6, 1, 8, 30, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "report.json")
+
+	err := NewJSONWriter(path).Write(context.Background(), &report.Run{
+		ID:         "20250601T083000-a1b2c3",
+		Project:    `C:\Projects\lobby.vtp`,
+		Summary:    report.Summary{Cause: report.CauseCompileErrors, Duration: 94 * time.Second, Size: "2,048 bytes"},
+		StartedAt:  report.Timestamp{Time: started},
+		FinishedAt: report.Timestamp{Time: started.Add(94 * time.Second)},
+		Warnings:   1,
+		Errors:     1,
+		SizeBytes:  2048,
+		Targets:    []report.Target{{Name: "TSW-770", Warnings: 1, Errors: 1, Size: "2,048 bytes", SizeBytes: 2048}},
+		Messages: []report.Message{
+			{Severity: "error", Text: "Join 12 is undefined", Target: "TSW-770", RuleID: "missing-join"},
+			{Severity: "warning", Text: "Unassigned Smart Object ID", Target: "TSW-770", Page: "Main", Object: "Volume"},
+		},
+	})
+	require.NoError(t, err)
+
+	doc, err := report.ReadDocument(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, &report.Document{
+		Version:         report.DocumentVersion,
+		ID:              "20250601T083000-a1b2c3",
+		Project:         `C:\Projects\lobby.vtp`,
+		Status:          report.StatusFailed,
+		StartedAt:       report.Timestamp{Time: started},
+		FinishedAt:      report.Timestamp{Time: started.Add(94 * time.Second)},
+		DurationSeconds: 94,
+		Warnings:        1,
+		Errors:          1,
+		Size:            "2,048 bytes",
+		SizeBytes:       2048,
+		Targets:         []report.DocumentTarget{{Name: "TSW-770", Warnings: 1, Errors: 1, Size: "2,048 bytes", SizeBytes: 2048}},
+		Messages: []report.DocumentMessage{
+			{Severity: "error", Text: "Join 12 is undefined", Target: "TSW-770", RuleID: "missing-join"},
+			{Severity: "warning", Text: "Unassigned Smart Object ID", Target: "TSW-770", Page: "Main", Object: "Volume"},
+		},
+	}, doc)
+}
@@ -0,0 +1,177 @@
+package output
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+// JUnitWriter writes the run as JUnit XML, for CI servers such as Jenkins that
+// mark builds by test results. The run is one test suite named after the
+// project. Each error is a failed test case and each warning a skipped one.
+// A "compile" test case stands for the run itself. It fails when the run
+// failed for a reason no error message shows, such as a timeout, so the suite
+// is never empty.
+type JUnitWriter struct {
+	Path string
+}
+
+// NewJUnitWriter creates a JUnitWriter for path
+func NewJUnitWriter(path string) Writer {
+	return JUnitWriter{Path: path}
+}
+
+// junitCompileCase names the test case that stands for the run itself
+const junitCompileCase = "compile"
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitOutcome `xml:"failure,omitempty"`
+	Skipped   *junitOutcome `xml:"skipped,omitempty"`
+}
+
+type junitOutcome struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// Write writes the report to the writer's path, atomically so a build server
+// collecting it never reads half of it
+func (w JUnitWriter) Write(ctx context.Context, run *report.Run) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	suite := newJUnitSuite(run)
+
+	data, err := xml.MarshalIndent(junitSuites{
+		Name:     "vtpc",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(w.Path, append([]byte(xml.Header), append(data, '\n')...))
+}
+
+// newJUnitSuite returns the test suite of a run
+func newJUnitSuite(run *report.Run) junitSuite {
+	name := filepath.Base(run.Project)
+
+	suite := junitSuite{
+		Name: name,
+		Time: junitSeconds(run.Summary.Duration.Seconds()),
+	}
+
+	if !run.StartedAt.IsZero() {
+		suite.Timestamp = run.StartedAt.UTC().Format("2006-01-02T15:04:05")
+	}
+
+	for _, p := range []junitProperty{
+		{Name: "runId", Value: run.ID},
+		{Name: "status", Value: report.StatusFor(run.Summary.Cause)},
+		{Name: "code", Value: run.Summary.Code},
+	} {
+		if p.Value != "" {
+			suite.Properties = append(suite.Properties, p)
+		}
+	}
+
+	suite.Cases = append(suite.Cases, junitCase{Name: junitCompileCase, Classname: name, Time: suite.Time})
+
+	for _, m := range run.Messages {
+		c := junitCase{Name: m.Text, Classname: name, Time: junitSeconds(0)}
+		if m.Target != "" {
+			c.Classname = m.Target
+		}
+
+		outcome := &junitOutcome{Message: m.Text, Type: m.RuleID, Text: junitDetails(m)}
+
+		if m.Severity == "error" {
+			c.Failure = outcome
+			suite.Failures++
+		} else {
+			c.Skipped = outcome
+			suite.Skipped++
+		}
+
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	// Compile errors fail the suite through their own test cases, unless none could be read
+	cause := run.Summary.Cause
+	if cause != report.CauseNone && (cause != report.CauseCompileErrors || suite.Failures == 0) {
+		suite.Cases[0].Failure = &junitOutcome{Message: cause.String(), Type: run.Summary.Code, Text: junitFailure(run)}
+		suite.Failures++
+	}
+
+	suite.Tests = len(suite.Cases)
+
+	return suite
+}
+
+// junitFailure describes why the run failed, for the compile test case
+func junitFailure(run *report.Run) string {
+	if run.Summary.Err != nil {
+		return run.Summary.Err.Error()
+	}
+
+	return run.Summary.Cause.String()
+}
+
+// junitDetails lists where a message points to, one "name: value" per line
+func junitDetails(m report.Message) string {
+	var lines []string
+
+	for _, d := range [][2]string{{"target", m.Target}, {"page", m.Page}, {"object", m.Object}, {"link", m.Link}} {
+		if d[1] != "" {
+			lines = append(lines, d[0]+": "+d[1])
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// junitSeconds formats a duration in seconds as JUnit's time attribute
+func junitSeconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
@@ -0,0 +1,115 @@
+package output
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+// writeJUnit writes run with a JUnitWriter and reads the report back
+func writeJUnit(t *testing.T, run *report.Run) (junitSuite, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "results.xml")
+	require.NoError(t, NewJUnitWriter(path).Write(context.Background(), run))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var suites junitSuites
+	require.NoError(t, xml.Unmarshal(data, &suites))
+	require.Len(t, suites.Suites, 1)
+
+	suite := suites.Suites[0]
+	assert.Equal(t, suite.Tests, suites.Tests)
+	assert.Equal(t, suite.Failures, suites.Failures)
+	assert.Equal(t, suite.Skipped, suites.Skipped)
+
+	return suite, string(data)
+}
+
+func TestJUnitWriter_Write(t *testing.T) {
+	t.Parallel()
+
+	started := time.Date(2025, 6, 1, 8, 30, 0, 0, time.UTC)
+
+	suite, data := writeJUnit(t, &report.Run{
+		ID:        "20250601T083000-a1b2c3",
+		Project:   filepath.Join("Projects", "lobby.vtp"),
+		Summary:   report.Summary{Cause: report.CauseCompileErrors, Code: "VTPC_E_COMPILE_ERRORS", Duration: 94 * time.Second},
+		StartedAt: report.Timestamp{Time: started},
+		Warnings:  1,
+		Errors:    1,
+		Messages: []report.Message{
+			{Severity: "error", Text: "Join 12 is undefined", Target: "TSW-770", RuleID: "missing-join"},
+			{Severity: "warning", Text: `Object "Volume" & <Mute> overlap`, Page: "Main", Object: "Volume"},
+		},
+	})
+
+	assert.True(t, strings.HasPrefix(data, xml.Header))
+	assert.Equal(t, "lobby.vtp", suite.Name)
+	assert.Equal(t, "94.000", suite.Time)
+	assert.Equal(t, "2025-06-01T08:30:00", suite.Timestamp)
+	assert.Equal(t, []junitProperty{
+		{Name: "runId", Value: "20250601T083000-a1b2c3"},
+		{Name: "status", Value: report.StatusFailed},
+		{Name: "code", Value: "VTPC_E_COMPILE_ERRORS"},
+	}, suite.Properties)
+
+	assert.Equal(t, 3, suite.Tests)
+	assert.Equal(t, 1, suite.Failures, "compile errors fail only their own test cases")
+	assert.Equal(t, 1, suite.Skipped)
+
+	require.Len(t, suite.Cases, 3)
+	assert.Equal(t, junitCase{Name: "compile", Classname: "lobby.vtp", Time: "94.000"}, suite.Cases[0])
+	assert.Equal(t, junitCase{
+		Name: "Join 12 is undefined", Classname: "TSW-770", Time: "0.000",
+		Failure: &junitOutcome{Message: "Join 12 is undefined", Type: "missing-join", Text: "target: TSW-770"},
+	}, suite.Cases[1])
+	assert.Equal(t, junitCase{
+		Name: `Object "Volume" & <Mute> overlap`, Classname: "lobby.vtp", Time: "0.000",
+		Skipped: &junitOutcome{Message: `Object "Volume" & <Mute> overlap`, Text: "page: Main\nobject: Volume"},
+	}, suite.Cases[2])
+}
+
+func TestJUnitWriter_Timeout(t *testing.T) {
+	t.Parallel()
+
+	suite, _ := writeJUnit(t, &report.Run{
+		Project: "lobby.vtp",
+		Summary: report.Summary{
+			Cause:    report.CauseCompileTimeout,
+			Code:     "VTPC_E_TIMEOUT_COMPILE",
+			Err:      errors.New("compile did not finish within 10m0s"),
+			Duration: 10 * time.Minute,
+		},
+	})
+
+	// A timed-out compile has no messages, but the suite is not empty
+	assert.Equal(t, 1, suite.Tests)
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, []junitCase{{
+		Name: "compile", Classname: "lobby.vtp", Time: "600.000",
+		Failure: &junitOutcome{Message: "compile timed out", Type: "VTPC_E_TIMEOUT_COMPILE", Text: "compile did not finish within 10m0s"},
+	}}, suite.Cases)
+}
+
+func TestJUnitWriter_Success(t *testing.T) {
+	t.Parallel()
+
+	suite, _ := writeJUnit(t, &report.Run{Project: "lobby.vtp", Summary: report.Summary{Duration: time.Second}})
+
+	assert.Equal(t, 1, suite.Tests)
+	assert.Zero(t, suite.Failures)
+	assert.Equal(t, []junitCase{{Name: "compile", Classname: "lobby.vtp", Time: "1.000"}}, suite.Cases)
+}
@@ -0,0 +1,332 @@
+// Package output writes reports of each run in the formats requested with --out.
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+// Writer writes a report of a run in one format
+type Writer interface {
+	Write(ctx context.Context, run *report.Run) error
+}
+
+// Factory creates a Writer that writes to path
+type Factory func(path string) Writer
+
+// Spec is one --out entry: a format and the path to write it to
+type Spec struct {
+	Format string
+	Path   string
+}
+
+// String formats the spec as it is given on the command line
+func (s Spec) String() string {
+	return s.Format + "=" + s.Path
+}
+
+// Registry maps format names to the factories that create their writers
+type Registry struct {
+	factories map[string]Factory
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]Factory)}
+}
+
+// DefaultRegistry holds the built-in formats
+var DefaultRegistry = newDefaultRegistry()
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.mustRegister("text", NewTextWriter)
+	r.mustRegister("json", NewJSONWriter)
+	r.mustRegister("junit", NewJUnitWriter)
+
+	return r
+}
+
+// Register adds a format. Format names are case-insensitive and must be unique.
+func (r *Registry) Register(format string, factory Factory) error {
+	name := strings.ToLower(strings.TrimSpace(format))
+	if name == "" {
+		return errors.New("output format name must not be empty")
+	}
+
+	if _, exists := r.factories[name]; exists {
+		return fmt.Errorf("output format %q is already registered", name)
+	}
+
+	r.factories[name] = factory
+
+	return nil
+}
+
+// mustRegister is Register for the built-in formats
+func (r *Registry) mustRegister(format string, factory Factory) {
+	if err := r.Register(format, factory); err != nil {
+		panic(err)
+	}
+}
+
+// Formats returns the registered format names, sorted
+func (r *Registry) Formats() []string {
+	formats := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		formats = append(formats, name)
+	}
+
+	sort.Strings(formats)
+
+	return formats
+}
+
+// ParseSpecs parses --out entries of the form format=path and checks each format is registered
+func (r *Registry) ParseSpecs(entries []string) ([]Spec, error) {
+	specs := make([]Spec, 0, len(entries))
+
+	for _, entry := range entries {
+		format, path, ok := strings.Cut(entry, "=")
+		format = strings.ToLower(strings.TrimSpace(format))
+		path = strings.TrimSpace(path)
+
+		if !ok || format == "" || path == "" {
+			return nil, fmt.Errorf("invalid --out %q: expected format=path", entry)
+		}
+
+		if _, exists := r.factories[format]; !exists {
+			return nil, fmt.Errorf("invalid --out %q: unknown format %q (available: %s)",
+				entry, format, strings.Join(r.Formats(), ", "))
+		}
+
+		// Resolve now, so the path means the same after an elevated relaunch
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --out %q: %w", entry, err)
+		}
+
+		specs = append(specs, Spec{Format: format, Path: abs})
+	}
+
+	return specs, nil
+}
+
+// WriteAll runs a writer for every spec, even when an earlier one fails, and returns
+// all of their errors joined together
+func (r *Registry) WriteAll(ctx context.Context, specs []Spec, run *report.Run) error {
+	var errs []error
+
+	for _, spec := range specs {
+		factory, ok := r.factories[spec.Format]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown format", spec))
+			continue
+		}
+
+		if err := factory(spec.Path).Write(ctx, run); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", spec, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// TextWriter writes the exit banner and every message as plain text
+type TextWriter struct {
+	Path string
+}
+
+// NewTextWriter creates a TextWriter for path
+func NewTextWriter(path string) Writer {
+	return TextWriter{Path: path}
+}
+
+// Write writes the report to the writer's path
+func (w TextWriter) Write(ctx context.Context, run *report.Run) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if run.ID != "" {
+		fmt.Fprintf(&b, "Run: %s\n", run.ID)
+	}
+
+	if run.Project != "" {
+		fmt.Fprintf(&b, "Project: %s\n", run.Project)
+	}
+
+	if len(run.Args) > 0 {
+		fmt.Fprintf(&b, "Command: vtpc %s\n", strings.Join(run.Args, " "))
+	}
+
+	if run.ConfigFingerprint != "" {
+		fmt.Fprintf(&b, "Config: %s\n", run.ConfigFingerprint)
+	}
+
+	report.WriteBanner(&b, run.Summary)
+
+	if len(run.Messages) > 0 {
+		fmt.Fprintf(&b, "\n%d warning(s), %d error(s)\n", run.Warnings, run.Errors)
+
+		for _, m := range run.Messages {
+			if m.Target != "" {
+				fmt.Fprintf(&b, "[%s] %s: %s\n", m.Severity, m.Target, m.Text)
+			} else {
+				fmt.Fprintf(&b, "[%s] %s\n", m.Severity, m.Text)
+			}
+
+			if m.Link != "" {
+				fmt.Fprintf(&b, "  link: %s\n", m.Link)
+			}
+		}
+	}
+
+	if len(run.SuppressedByPage) > 0 {
+		fmt.Fprintf(&b, "\n%d message(s) suppressed by --ignore-pages\n", len(run.SuppressedByPage))
+
+		for _, m := range run.SuppressedByPage {
+			fmt.Fprintf(&b, "[%s] %s: %s\n", m.Severity, m.Page, m.Text)
+		}
+	}
+
+	if len(run.Reclassified) > 0 {
+		fmt.Fprintf(&b, "\n%d message(s) reclassified by rule policy\n", len(run.Reclassified))
+
+		for _, r := range run.Reclassified {
+			fmt.Fprintf(&b, "[%s -> %s] %s (%s)\n", r.From, r.Action, r.Text, r.Policy)
+		}
+	}
+
+	writeKeystroke(&b, run.Keystroke)
+	writeArtifactChecks(&b, run.ArtifactChecks)
+	writeProvenance(&b, run.Provenance)
+	writeTiming(&b, run.Timing)
+	writePanic(&b, run.PanicStack)
+
+	return os.WriteFile(w.Path, []byte(b.String()), 0o644)
+}
+
+// writeProvenance writes what --provenance recorded about each artifact, if it ran
+func writeProvenance(b *strings.Builder, records []report.Provenance) {
+	for _, p := range records {
+		fmt.Fprintf(b, "\nProvenance: %s\n", p.Artifact)
+		fmt.Fprintf(b, "  artifact sha256: %s\n", p.ArtifactSHA256)
+		fmt.Fprintf(b, "  source: %s\n", p.Source)
+		fmt.Fprintf(b, "  source sha256: %s\n", p.SourceSHA256)
+		fmt.Fprintf(b, "  vtpc: %s\n", p.VtpcVersion)
+
+		if p.VTProVersion != "" {
+			fmt.Fprintf(b, "  VTPro: %s\n", p.VTProVersion)
+		}
+
+		fmt.Fprintf(b, "  run: %s\n", p.RunID)
+		fmt.Fprintf(b, "  compiled: %s to %s\n", report.FormatTimestamp(p.StartedAt.Time), report.FormatTimestamp(p.FinishedAt.Time))
+		fmt.Fprintf(b, "  by: %s on %s\n", p.User, p.Machine)
+		fmt.Fprintf(b, "  counts: %d warning(s), %d error(s)\n", p.Warnings, p.Errors)
+	}
+}
+
+// writeKeystroke writes how the compile keystroke was sent, if it was
+func writeKeystroke(b *strings.Builder, k report.Keystroke) {
+	if k.Method == "" && len(k.Failed) == 0 {
+		return
+	}
+
+	failed := strings.Join(k.Failed, ", ")
+
+	if k.Method == "" {
+		fmt.Fprintf(b, "\nKeystroke: not sent, %s failed\n", failed)
+		return
+	}
+
+	fmt.Fprintf(b, "\nKeystroke: %s", k.Method)
+
+	if failed != "" {
+		fmt.Fprintf(b, " after %s failed", failed)
+	}
+
+	switch {
+	case !k.Watched:
+		b.WriteString(", not watched\n")
+	case k.Acknowledged:
+		b.WriteString(", acknowledged\n")
+	default:
+		b.WriteString(", not acknowledged\n")
+	}
+}
+
+// writeArtifactChecks writes what --verify-artifact found, if it ran
+func writeArtifactChecks(b *strings.Builder, checks []report.ArtifactCheck) {
+	for _, c := range checks {
+		status := "OK"
+		if len(c.Problems) > 0 {
+			status = "CORRUPT"
+		}
+
+		fmt.Fprintf(b, "\nArtifact check: %s %s (%d entries, %d bytes uncompressed)\n", c.Path, status, c.Entries, c.UncompressedBytes)
+
+		for _, p := range c.Problems {
+			fmt.Fprintf(b, "[problem] %s\n", p)
+		}
+
+		for _, n := range c.Notes {
+			fmt.Fprintf(b, "[note] %s\n", n)
+		}
+	}
+}
+
+// writePanic writes the stack of the panic that crashed vtpc, if it did
+func writePanic(b *strings.Builder, stack string) {
+	if stack == "" {
+		return
+	}
+
+	fmt.Fprintf(b, "\nPanic\n%s", stack)
+
+	if !strings.HasSuffix(stack, "\n") {
+		b.WriteString("\n")
+	}
+}
+
+// writeTiming writes where the run's time went, if phases were recorded
+func writeTiming(b *strings.Builder, t report.Timing) {
+	if len(t.Phases) == 0 {
+		return
+	}
+
+	ms := func(d time.Duration) time.Duration { return d.Round(time.Millisecond) }
+
+	fmt.Fprintf(b, "\nTiming\n")
+	fmt.Fprintf(b, "wall: %s\n", ms(t.Wall))
+
+	for _, p := range t.Phases {
+		fmt.Fprintf(b, "%s: %s\n", p.Name, ms(p.Duration))
+	}
+
+	fmt.Fprintf(b, "vtpc cpu: %s\n", ms(t.SelfCPU))
+	fmt.Fprintf(b, "vtpro cpu: %s\n", ms(t.VTProCPU))
+	fmt.Fprintln(b, t.OverheadLine())
+
+	if len(t.Dialogs) > 0 {
+		fmt.Fprintf(b, "longest dialogs:\n")
+	}
+
+	for _, d := range t.Dialogs {
+		still := ""
+		if d.Open {
+			still = " (still open)"
+		}
+
+		fmt.Fprintf(b, "  %s: %s%s\n", d.Title, ms(d.Duration), still)
+	}
+}
@@ -0,0 +1,166 @@
+// Package pathutil cleans up project paths pasted from browsers and shells.
+package pathutil
+
+import (
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Env is what Normalize needs from the environment
+type Env struct {
+	Getenv func(string) string // Looks up %VAR% references
+	Home   string              // Replaces a leading ~, left alone if empty
+}
+
+// OSEnv returns the environment of the current process
+func OSEnv() Env {
+	home, _ := os.UserHomeDir()
+	return Env{Getenv: os.Getenv, Home: home}
+}
+
+// Change is one step Normalize took, with the path as it was afterwards
+type Change struct {
+	Step string
+	Path string
+}
+
+// psProviderPrefix is what Resolve-Path and friends put in front of a file system path
+const psProviderPrefix = "FileSystem::"
+
+// envVarRe matches a cmd-style %VAR% reference, including names like ProgramFiles(x86)
+var envVarRe = regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_()]*)%`)
+
+// Normalize turns a project path as a user pasted it into one vtpc can open. It
+// trims whitespace and matched quotes, converts file:// URIs, drops PowerShell's
+// provider prefix, expands ~ and %VAR%, and collapses doubled separators. It
+// returns the path and each change made, in order; a clean path has no changes.
+func Normalize(raw string, env Env) (string, []Change) {
+	var changes []Change
+
+	path := raw
+	apply := func(step, next string) {
+		if next != path {
+			path = next
+			changes = append(changes, Change{Step: step, Path: path})
+		}
+	}
+
+	apply("trimmed quotes", trimQuotes(path))
+	apply("converted file URI", fromFileURI(path))
+	apply("removed PowerShell provider prefix", trimProvider(path))
+	apply("expanded ~", expandHome(path, env.Home))
+	apply("expanded environment variables", expandEnv(path, env.Getenv))
+	apply("collapsed duplicate separators", collapseSeparators(path))
+
+	return path, changes
+}
+
+// trimQuotes removes surrounding whitespace and any number of matched ' or " pairs
+func trimQuotes(path string) string {
+	for {
+		path = strings.TrimSpace(path)
+		if len(path) < 2 {
+			return path
+		}
+
+		first, last := path[0], path[len(path)-1]
+		if (first != '"' && first != '\'') || first != last {
+			return path
+		}
+
+		path = path[1 : len(path)-1]
+	}
+}
+
+// fromFileURI converts a file:// URI to a Windows path, decoding percent escapes.
+// file:///C:/p.vtp becomes C:\p.vtp and file://server/share/p.vtp a UNC path.
+func fromFileURI(path string) string {
+	if len(path) < 5 || !strings.EqualFold(path[:5], "file:") {
+		return path
+	}
+
+	u, err := url.Parse(path)
+	if err != nil || u.Path == "" {
+		return path
+	}
+
+	p := u.Path
+	if u.Host != "" && !strings.EqualFold(u.Host, "localhost") {
+		p = "//" + u.Host + p
+	} else if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+		p = p[1:] // "/C:/Projects" has a slash before the drive letter
+	}
+
+	return strings.ReplaceAll(p, "/", `\`)
+}
+
+// trimProvider removes a PowerShell provider prefix such as
+// Microsoft.PowerShell.Core\FileSystem::C:\Projects
+func trimProvider(path string) string {
+	if i := strings.Index(path, psProviderPrefix); i >= 0 {
+		return path[i+len(psProviderPrefix):]
+	}
+
+	return path
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path, home string) string {
+	if home == "" || !strings.HasPrefix(path, "~") {
+		return path
+	}
+
+	if len(path) > 1 && !isSeparator(path[1]) {
+		return path // ~user is not supported
+	}
+
+	return home + path[1:]
+}
+
+// expandEnv replaces %VAR% references that are set, leaving the rest as they are
+func expandEnv(path string, getenv func(string) string) string {
+	if getenv == nil {
+		return path
+	}
+
+	return envVarRe.ReplaceAllStringFunc(path, func(ref string) string {
+		if value := getenv(ref[1 : len(ref)-1]); value != "" {
+			return value
+		}
+
+		return ref
+	})
+}
+
+// collapseSeparators reduces each run of \ and / to a single separator, keeping the
+// first of the run. The leading \\ of a UNC path is kept.
+func collapseSeparators(path string) string {
+	var b strings.Builder
+
+	start := 0
+	if len(path) >= 2 && isSeparator(path[0]) && isSeparator(path[1]) {
+		b.WriteString(path[:2])
+		start = 2
+
+		for start < len(path) && isSeparator(path[start]) {
+			start++
+		}
+	}
+
+	for i := start; i < len(path); i++ {
+		if isSeparator(path[i]) && i > start && isSeparator(path[i-1]) {
+			continue
+		}
+
+		b.WriteByte(path[i])
+	}
+
+	return b.String()
+}
+
+// isSeparator reports whether c is a Windows path separator
+func isSeparator(c byte) bool {
+	return c == '\\' || c == '/'
+}
@@ -0,0 +1,101 @@
+package pathutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	vars := map[string]string{
+		"USERPROFILE":       `C:\Users\ci`,
+		"PROJECTS":          `D:\Projects\`,
+		"ProgramFiles(x86)": `C:\Program Files (x86)`,
+	}
+	env := Env{Getenv: func(key string) string { return vars[key] }, Home: `C:\Users\ci`}
+
+	tests := []struct {
+		name  string
+		raw   string
+		want  string
+		steps []string
+	}{
+		{"clean path", `C:\Projects\lobby.vtp`, `C:\Projects\lobby.vtp`, nil},
+		{"relative path", `lobby.vtp`, `lobby.vtp`, nil},
+		{"forward slashes are kept", `C:/Projects/lobby.vtp`, `C:/Projects/lobby.vtp`, nil},
+
+		{"double quotes", `"C:\Projects\lobby.vtp"`, `C:\Projects\lobby.vtp`, []string{"trimmed quotes"}},
+		{"single quotes", `'C:\Projects\lobby.vtp'`, `C:\Projects\lobby.vtp`, []string{"trimmed quotes"}},
+		{"nested quotes and whitespace", ` "'C:\My Projects\lobby.vtp'" `, `C:\My Projects\lobby.vtp`, []string{"trimmed quotes"}},
+		{"unmatched quote is kept", `'C:\Projects\lobby.vtp`, `'C:\Projects\lobby.vtp`, nil},
+		{"apostrophe in name is kept", `C:\Bob's Projects\lobby.vtp`, `C:\Bob's Projects\lobby.vtp`, nil},
+		{"lone quote", `"`, `"`, nil},
+
+		{"file URI", `file:///C:/Projects/lobby.vtp`, `C:\Projects\lobby.vtp`, []string{"converted file URI"}},
+		{"file URI with escapes", `file:///C:/My%20Projects/Lobby%23%C3%A9.vtp`, `C:\My Projects\Lobby#é.vtp`, []string{"converted file URI"}},
+		{"file URI with localhost", `file://localhost/C:/Projects/lobby.vtp`, `C:\Projects\lobby.vtp`, []string{"converted file URI"}},
+		{"file URI to a share", `file://buildserver/share/lobby.vtp`, `\\buildserver\share\lobby.vtp`, []string{"converted file URI"}},
+		{"quoted upper-case URI", `"FILE:///C:/Projects/lobby.vtp"`, `C:\Projects\lobby.vtp`, []string{"trimmed quotes", "converted file URI"}},
+		{"bad escape is left alone", `file:///C:/Projects/lobby%zz.vtp`, `file:/C:/Projects/lobby%zz.vtp`, []string{"collapsed duplicate separators"}},
+
+		{"PowerShell provider path", `Microsoft.PowerShell.Core\FileSystem::C:\Projects\lobby.vtp`, `C:\Projects\lobby.vtp`, []string{"removed PowerShell provider prefix"}},
+		{"short provider path", `FileSystem::\\server\share\lobby.vtp`, `\\server\share\lobby.vtp`, []string{"removed PowerShell provider prefix"}},
+
+		{"home", `~\Projects\lobby.vtp`, `C:\Users\ci\Projects\lobby.vtp`, []string{"expanded ~"}},
+		{"home with slash", `~/Projects/lobby.vtp`, `C:\Users\ci/Projects/lobby.vtp`, []string{"expanded ~"}},
+		{"other user's home is kept", `~ci\lobby.vtp`, `~ci\lobby.vtp`, nil},
+		{"tilde in short name is kept", `C:\PROGRA~1\lobby.vtp`, `C:\PROGRA~1\lobby.vtp`, nil},
+
+		{"environment variable", `%USERPROFILE%\Projects\lobby.vtp`, `C:\Users\ci\Projects\lobby.vtp`, []string{"expanded environment variables"}},
+		{"variable with parentheses", `%ProgramFiles(x86)%\Crestron\lobby.vtp`, `C:\Program Files (x86)\Crestron\lobby.vtp`, []string{"expanded environment variables"}},
+		{"unset variable is kept", `%NOPE%\lobby.vtp`, `%NOPE%\lobby.vtp`, nil},
+		{"variable ending in separator", `%PROJECTS%\lobby.vtp`, `D:\Projects\lobby.vtp`, []string{"expanded environment variables", "collapsed duplicate separators"}},
+
+		{"doubled backslashes", `C:\\Projects\\lobby.vtp`, `C:\Projects\lobby.vtp`, []string{"collapsed duplicate separators"}},
+		{"mixed runs", `C:\/Projects//\lobby.vtp`, `C:\Projects/lobby.vtp`, []string{"collapsed duplicate separators"}},
+		{"UNC prefix is kept", `\\server\\share\lobby.vtp`, `\\server\share\lobby.vtp`, []string{"collapsed duplicate separators"}},
+		{"extra UNC slashes", `\\\\server\share\lobby.vtp`, `\\server\share\lobby.vtp`, []string{"collapsed duplicate separators"}},
+
+		{
+			"everything at once",
+			`  "FileSystem::%USERPROFILE%\\Projects\\lobby.vtp"`,
+			`C:\Users\ci\Projects\lobby.vtp`,
+			[]string{"trimmed quotes", "removed PowerShell provider prefix", "expanded environment variables", "collapsed duplicate separators"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, changes := Normalize(tt.raw, env)
+			assert.Equal(t, tt.want, got)
+
+			var steps []string
+			for _, c := range changes {
+				steps = append(steps, c.Step)
+			}
+
+			assert.Equal(t, tt.steps, steps)
+
+			if len(changes) > 0 {
+				assert.Equal(t, got, changes[len(changes)-1].Path, "the last change holds the final path")
+			}
+
+			again, more := Normalize(got, env)
+			assert.Equal(t, got, again, "normalizing twice changes nothing")
+			assert.Empty(t, more)
+		})
+	}
+}
+
+func TestNormalize_NoEnvironment(t *testing.T) {
+	t.Parallel()
+
+	got, changes := Normalize(`~\%USERPROFILE%\lobby.vtp`, Env{})
+
+	assert.Equal(t, `~\%USERPROFILE%\lobby.vtp`, got)
+	assert.Empty(t, changes)
+}
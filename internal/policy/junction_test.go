@@ -0,0 +1,57 @@
+//go:build windows
+
+package policy
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// junction creates a directory junction at link pointing to target, as mklink /J does
+func junction(t *testing.T, link, target string) {
+	t.Helper()
+
+	out, err := exec.Command("cmd", "/c", "mklink", "/J", link, target).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestCheckProject_Junctions(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	root := filepath.Join(base, "Projects")
+	outside := filepath.Join(base, "Elsewhere")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "Lobby"), 0o755))
+	require.NoError(t, os.MkdirAll(outside, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "Lobby", "lobby.vtp"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.vtp"), nil, 0o644))
+
+	junction(t, filepath.Join(root, "Escape"), outside)
+	junction(t, filepath.Join(base, "Shortcut"), root)
+
+	p := &Policy{AllowedRoots: []string{root}, path: "policy.yaml"}
+
+	// A junction inside the root that leads out of it is caught
+	err := p.CheckProject(filepath.Join(root, "Escape", "secret.vtp"), windows.FinalPath)
+	require.ErrorIs(t, err, ErrViolation)
+	assert.ErrorContains(t, err, "which resolves to")
+
+	// A junction from outside into the root is allowed
+	assert.NoError(t, p.CheckProject(filepath.Join(base, "Shortcut", "Lobby", "lobby.vtp"), windows.FinalPath))
+
+	// Case does not matter on Windows
+	assert.NoError(t, p.CheckProject(strings.ToUpper(filepath.Join(root, "Lobby", "lobby.vtp")), windows.FinalPath))
+
+	// An allowed root given through a junction still counts
+	p.AllowedRoots = []string{filepath.Join(base, "Shortcut")}
+	assert.NoError(t, p.CheckProject(filepath.Join(root, "Lobby", "lobby.vtp"), windows.FinalPath))
+}
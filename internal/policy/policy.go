@@ -0,0 +1,171 @@
+// Package policy loads the machine-wide policy that limits what vtpc may do,
+// set by whoever manages the build machine rather than by the user running vtpc.
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// FileName is the name of the policy file in the vtpc directory under ProgramData
+const FileName = "policy.yaml"
+
+var (
+	// ErrViolation is returned when a project is outside every allowed root
+	ErrViolation = errcode.New(errcode.PolicyViolation, "project is outside the roots allowed by policy")
+
+	// ErrInvalid is returned when the policy file exists but cannot be read or used.
+	// The compile is refused rather than run without the policy.
+	ErrInvalid = errcode.New(errcode.PolicyInvalid, "invalid machine policy")
+)
+
+// Resolver returns the final path of an existing file or directory, with
+// symlinks and junctions resolved, such as windows.FinalPath
+type Resolver func(path string) (string, error)
+
+// Policy is the machine policy file
+type Policy struct {
+	// AllowedRoots are the directories projects must be under. Left out, projects
+	// may be anywhere; given but empty, no project may be compiled.
+	AllowedRoots []string `yaml:"allowedRoots"`
+
+	path string // Where the policy was loaded from, for messages
+}
+
+// DefaultPath returns the policy file location under programData, e.g.
+// C:\ProgramData\vtpc\policy.yaml
+func DefaultPath(programData string) string {
+	return filepath.Join(programData, "vtpc", FileName)
+}
+
+// Load reads the policy file at path. A missing file is not an error and yields
+// a policy without restrictions. Anything else that stops the policy from being
+// read, including unknown keys, returns ErrInvalid.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Policy{path: path}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read %s: %w", ErrInvalid, path, err)
+	}
+
+	p := &Policy{path: path}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: failed to parse %s: %w", ErrInvalid, path, err)
+	}
+
+	for i, root := range p.AllowedRoots {
+		if !filepath.IsAbs(root) {
+			return nil, fmt.Errorf("%w: %s: allowedRoots[%d] %q is not an absolute path", ErrInvalid, path, i, root)
+		}
+	}
+
+	return p, nil
+}
+
+// Path returns where the policy was loaded from
+func (p *Policy) Path() string {
+	return p.path
+}
+
+// Restricted reports whether the policy limits where projects may be
+func (p *Policy) Restricted() bool {
+	return p.AllowedRoots != nil
+}
+
+// CheckProject returns ErrViolation unless project is under one of the allowed
+// roots. Both are resolved first, so a junction or symlink inside an allowed
+// root cannot lead elsewhere, and a root reached through a link still counts.
+// A root that cannot be resolved, such as one that does not exist, allows nothing.
+func (p *Policy) CheckProject(project string, resolve Resolver) error {
+	if !p.Restricted() {
+		return nil
+	}
+
+	final, err := resolve(project)
+	if err != nil {
+		return fmt.Errorf("%w: could not resolve %s: %w", ErrViolation, project, err)
+	}
+
+	for _, root := range p.AllowedRoots {
+		finalRoot, err := resolve(root)
+		if err != nil {
+			continue
+		}
+
+		if within(finalRoot, final) {
+			return nil
+		}
+	}
+
+	if final != project {
+		return fmt.Errorf("%w: %s (which resolves to %s) is not under any allowedRoots in %s", ErrViolation, project, final, p.path)
+	}
+
+	return fmt.Errorf("%w: %s is not under any allowedRoots in %s", ErrViolation, project, p.path)
+}
+
+// CheckDir is CheckProject for a directory vtpc writes to, which may not exist
+// yet. Its nearest existing parent is resolved in its place, so a link on the
+// way to it is still followed.
+func (p *Policy) CheckDir(dir string, resolve Resolver) error {
+	final, err := resolveExisting(filepath.Clean(dir), resolve)
+	if err != nil {
+		return fmt.Errorf("%w: could not resolve %s: %w", ErrViolation, dir, err)
+	}
+
+	return p.CheckProject(dir, func(path string) (string, error) {
+		if path == dir {
+			return final, nil
+		}
+
+		return resolve(path)
+	})
+}
+
+// resolveExisting resolves the longest part of path that exists and adds the
+// rest back on
+func resolveExisting(path string, resolve Resolver) (string, error) {
+	rest := ""
+
+	for {
+		final, err := resolve(path)
+		if err == nil {
+			return filepath.Join(final, rest), nil
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+
+		rest = filepath.Join(filepath.Base(path), rest)
+		path = parent
+	}
+}
+
+// within reports whether path is root or inside it. filepath.Rel compares
+// case-insensitively on Windows, and fails for paths on different volumes.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
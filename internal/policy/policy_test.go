@@ -0,0 +1,225 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePolicy writes a policy file into a temp directory and returns its path
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	return path
+}
+
+// resolved returns path with symlinks resolved, so tests compare like with like
+// where the temp directory is itself reached through a link
+func resolved(t *testing.T, path string) string {
+	t.Helper()
+
+	final, err := filepath.EvalSymlinks(path)
+	require.NoError(t, err)
+
+	return final
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	tests := []struct {
+		name           string
+		content        string
+		wantRestricted bool
+		wantRoots      []string
+	}{
+		{name: "empty file", content: ""},
+		{name: "no allowed roots", content: "allowedRoots:\n"},
+		{name: "allowed roots", content: "allowedRoots:\n  - " + root + "\n", wantRestricted: true, wantRoots: []string{root}},
+		{name: "empty allowed roots allow nothing", content: "allowedRoots: []\n", wantRestricted: true, wantRoots: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := writePolicy(t, tt.content)
+
+			p, err := Load(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRestricted, p.Restricted())
+			assert.Equal(t, tt.wantRoots, p.AllowedRoots)
+			assert.Equal(t, path, p.Path())
+		})
+	}
+}
+
+func TestLoad_MissingFileIsUnrestricted(t *testing.T) {
+	t.Parallel()
+
+	p, err := Load(filepath.Join(t.TempDir(), FileName))
+	require.NoError(t, err)
+	assert.False(t, p.Restricted())
+	assert.NoError(t, p.CheckProject("/anywhere/lobby.vtp", filepath.EvalSymlinks))
+}
+
+func TestLoad_FailsClosed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "not YAML", content: "allowedRoots: [unclosed\n", wantErr: "failed to parse"},
+		{name: "misspelled key", content: "allowedRoot:\n  - /projects\n", wantErr: "field allowedRoot not found"},
+		{name: "roots not a list", content: "allowedRoots: 42\n", wantErr: "failed to parse"},
+		{name: "relative root", content: "allowedRoots:\n  - projects\n", wantErr: `allowedRoots[0] "projects" is not an absolute path`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Load(writePolicy(t, tt.content))
+			require.ErrorIs(t, err, ErrInvalid)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLoad_UnreadableFileFailsClosed(t *testing.T) {
+	t.Parallel()
+
+	// A directory where the file should be cannot be read
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.Mkdir(path, 0o755))
+
+	_, err := Load(path)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestCheckProject(t *testing.T) {
+	t.Parallel()
+
+	base := resolved(t, t.TempDir())
+	root := filepath.Join(base, "Projects")
+	outside := filepath.Join(base, "Elsewhere")
+	sibling := filepath.Join(base, "Projects-Old") // Shares a prefix with root
+
+	for _, dir := range []string{filepath.Join(root, "Lobby"), outside, sibling} {
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+	}
+
+	for _, f := range []string{filepath.Join(root, "Lobby", "lobby.vtp"), filepath.Join(outside, "lobby.vtp"), filepath.Join(sibling, "lobby.vtp")} {
+		require.NoError(t, os.WriteFile(f, nil, 0o644))
+	}
+
+	p := &Policy{AllowedRoots: []string{filepath.Join(base, "Missing"), root}, path: "policy.yaml"}
+
+	assert.NoError(t, p.CheckProject(filepath.Join(root, "Lobby", "lobby.vtp"), filepath.EvalSymlinks))
+	assert.NoError(t, p.CheckProject(filepath.Join(root, "Lobby", "..", "Lobby", "lobby.vtp"), filepath.EvalSymlinks))
+
+	err := p.CheckProject(filepath.Join(outside, "lobby.vtp"), filepath.EvalSymlinks)
+	require.ErrorIs(t, err, ErrViolation)
+	assert.ErrorContains(t, err, "is not under any allowedRoots in policy.yaml")
+
+	assert.ErrorIs(t, p.CheckProject(filepath.Join(sibling, "lobby.vtp"), filepath.EvalSymlinks), ErrViolation)
+	assert.ErrorIs(t, p.CheckProject(filepath.Join(root, "Lobby", "..", "..", "Elsewhere", "lobby.vtp"), filepath.EvalSymlinks), ErrViolation)
+}
+
+func TestCheckDir(t *testing.T) {
+	t.Parallel()
+
+	base := resolved(t, t.TempDir())
+	root := filepath.Join(base, "Projects")
+	outside := filepath.Join(base, "Elsewhere")
+
+	require.NoError(t, os.MkdirAll(root, 0o755))
+	require.NoError(t, os.MkdirAll(outside, 0o755))
+
+	p := &Policy{AllowedRoots: []string{root}, path: "policy.yaml"}
+
+	assert.NoError(t, p.CheckDir(root, filepath.EvalSymlinks))
+	assert.NoError(t, p.CheckDir(filepath.Join(root, "Lobby", "bin"), filepath.EvalSymlinks), "a directory not created yet")
+
+	err := p.CheckDir(filepath.Join(outside, "bin"), filepath.EvalSymlinks)
+	require.ErrorIs(t, err, ErrViolation)
+	assert.ErrorContains(t, err, "is not under any allowedRoots in policy.yaml")
+
+	assert.ErrorIs(t, p.CheckDir(filepath.Join(root, "..", "Elsewhere", "bin"), filepath.EvalSymlinks), ErrViolation)
+
+	// A link inside the allowed root that leads outside it
+	escape := filepath.Join(root, "Escape")
+	if err := os.Symlink(outside, escape); err != nil {
+		t.Skipf("cannot create symlinks here: %v", err)
+	}
+
+	assert.ErrorIs(t, p.CheckDir(filepath.Join(escape, "bin"), filepath.EvalSymlinks), ErrViolation)
+}
+
+func TestCheckProject_ResolvesLinks(t *testing.T) {
+	t.Parallel()
+
+	base := resolved(t, t.TempDir())
+	root := filepath.Join(base, "Projects")
+	outside := filepath.Join(base, "Elsewhere")
+
+	require.NoError(t, os.MkdirAll(root, 0o755))
+	require.NoError(t, os.MkdirAll(outside, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "lobby.vtp"), nil, 0o644))
+
+	// A link inside the allowed root that leads outside it
+	escape := filepath.Join(root, "Escape")
+	if err := os.Symlink(outside, escape); err != nil {
+		t.Skipf("cannot create symlinks here: %v", err)
+	}
+
+	// A link to the allowed root from outside it
+	shortcut := filepath.Join(base, "Shortcut")
+	require.NoError(t, os.Symlink(root, shortcut))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "boardroom.vtp"), nil, 0o644))
+
+	p := &Policy{AllowedRoots: []string{root}, path: "policy.yaml"}
+
+	err := p.CheckProject(filepath.Join(escape, "lobby.vtp"), filepath.EvalSymlinks)
+	require.ErrorIs(t, err, ErrViolation)
+	assert.ErrorContains(t, err, "(which resolves to "+filepath.Join(outside, "lobby.vtp")+")")
+
+	assert.NoError(t, p.CheckProject(filepath.Join(shortcut, "boardroom.vtp"), filepath.EvalSymlinks))
+
+	// An allowed root given through a link still counts
+	p.AllowedRoots = []string{shortcut}
+	assert.NoError(t, p.CheckProject(filepath.Join(root, "boardroom.vtp"), filepath.EvalSymlinks))
+}
+
+func TestCheckProject_UnresolvableProject(t *testing.T) {
+	t.Parallel()
+
+	p := &Policy{AllowedRoots: []string{t.TempDir()}}
+	failing := func(string) (string, error) { return "", errors.New("access is denied") }
+
+	err := p.CheckProject(filepath.Join(p.AllowedRoots[0], "lobby.vtp"), failing)
+	require.ErrorIs(t, err, ErrViolation)
+	assert.ErrorContains(t, err, "access is denied")
+}
+
+func TestCheckProject_EmptyRootsAllowNothing(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	project := filepath.Join(dir, "lobby.vtp")
+	require.NoError(t, os.WriteFile(project, nil, 0o644))
+
+	p := &Policy{AllowedRoots: []string{}}
+	assert.ErrorIs(t, p.CheckProject(project, filepath.EvalSymlinks), ErrViolation)
+}
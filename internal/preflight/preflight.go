@@ -0,0 +1,139 @@
+// Package preflight checks the build machine can hold a compile's output before VTPro is launched.
+package preflight
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// DefaultMinFreeMB is the free space, in megabytes, required by default on each checked drive
+const DefaultMinFreeMB = 500
+
+// probePattern names the file created to check a directory is writable
+const probePattern = ".vtpc-probe-*"
+
+var (
+	// ErrLowDiskSpace is returned when a drive has less free space than the threshold
+	ErrLowDiskSpace = errcode.New(errcode.DiskSpace, "not enough free disk space")
+
+	// ErrNotWritable is returned when a file cannot be created in an output directory
+	ErrNotWritable = errcode.New(errcode.OutputNotWritable, "output directory is not writable")
+
+	// ErrReadOnlyProject is returned when the project's directory is read-only and
+	// there is no --out-dir for the artifact of the staged copy
+	ErrReadOnlyProject = errcode.New(errcode.ProjectReadOnly, "project directory is read-only")
+)
+
+// SpaceReporter reports the bytes available to the caller on the volume containing a path
+type SpaceReporter interface {
+	FreeBytes(path string) (uint64, error)
+}
+
+// Checker checks free space and writability of the directories a compile writes to
+type Checker struct {
+	Space     SpaceReporter
+	MinFreeMB uint64 // Zero disables the free space check
+}
+
+// Check verifies each directory, or its nearest existing parent, has enough free space
+// and accepts new files. Duplicate directories are only checked once.
+func (c Checker) Check(dirs ...string) error {
+	seen := make(map[string]bool)
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+
+		existing, err := nearestExisting(dir)
+		if err != nil {
+			return err
+		}
+
+		if seen[existing] {
+			continue
+		}
+
+		seen[existing] = true
+
+		if err := c.checkFreeSpace(existing); err != nil {
+			return err
+		}
+
+		if err := CheckWritable(existing); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkFreeSpace returns ErrLowDiskSpace if the drive containing dir is below the threshold
+func (c Checker) checkFreeSpace(dir string) error {
+	if c.MinFreeMB == 0 {
+		return nil
+	}
+
+	free, err := c.Space.FreeBytes(dir)
+	if err != nil {
+		return fmt.Errorf("could not read free disk space for %s: %w", dir, err)
+	}
+
+	if minBytes := c.MinFreeMB * 1024 * 1024; free < minBytes {
+		return fmt.Errorf("%w on the drive containing %s: %d MB free, %d MB required",
+			ErrLowDiskSpace, dir, free/(1024*1024), c.MinFreeMB)
+	}
+
+	return nil
+}
+
+// CheckWritable creates and removes a probe file to check dir accepts new files
+func CheckWritable(dir string) error {
+	probe, err := os.CreateTemp(dir, probePattern)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrNotWritable, dir, err)
+	}
+
+	name := probe.Name()
+
+	if err := probe.Close(); err != nil {
+		_ = os.Remove(name)
+		return fmt.Errorf("%w: %s: %w", ErrNotWritable, dir, err)
+	}
+
+	if err := os.Remove(name); err != nil {
+		return fmt.Errorf("%w: could not remove probe file %s: %w", ErrNotWritable, name, err)
+	}
+
+	return nil
+}
+
+// nearestExisting returns dir, or its closest ancestor that exists, since an
+// output directory may only be created once the compile finishes
+func nearestExisting(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		info, err := os.Stat(abs)
+		if err == nil {
+			if !info.IsDir() {
+				return "", fmt.Errorf("%w: %s is not a directory", ErrNotWritable, abs)
+			}
+
+			return abs, nil
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", fmt.Errorf("%w: no existing directory in %s", ErrNotWritable, dir)
+		}
+
+		abs = parent
+	}
+}
@@ -0,0 +1,142 @@
+package preflight
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSpace reports a fixed amount of free space and records the paths it was asked about
+type fakeSpace struct {
+	free  uint64
+	err   error
+	paths []string
+}
+
+func (f *fakeSpace) FreeBytes(path string) (uint64, error) {
+	f.paths = append(f.paths, path)
+	return f.free, f.err
+}
+
+const mb = 1024 * 1024
+
+func TestChecker_FreeSpaceThreshold(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		free    uint64
+		minFree uint64
+		wantErr bool
+	}{
+		{"plenty", 2000 * mb, 500, false},
+		{"exactly the threshold", 500 * mb, 500, false},
+		{"one byte short", 500*mb - 1, 500, true},
+		{"empty drive", 0, 500, true},
+		{"check disabled", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			space := &fakeSpace{free: tt.free}
+			err := Checker{Space: space, MinFreeMB: tt.minFree}.Check(t.TempDir())
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrLowDiskSpace)
+				assert.Contains(t, err.Error(), "MB required")
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestChecker_SpaceReporterError(t *testing.T) {
+	t.Parallel()
+
+	space := &fakeSpace{err: errors.New("device not ready")}
+	err := Checker{Space: space, MinFreeMB: 500}.Check(t.TempDir())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "device not ready")
+	assert.NotErrorIs(t, err, ErrLowDiskSpace)
+}
+
+func TestChecker_ChecksEachDirectoryOnce(t *testing.T) {
+	t.Parallel()
+
+	project := t.TempDir()
+	outDir := t.TempDir()
+	space := &fakeSpace{free: 1000 * mb}
+
+	err := Checker{Space: space, MinFreeMB: 500}.Check(project, "", outDir, project)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{project, outDir}, space.paths)
+}
+
+func TestChecker_MissingOutDirUsesNearestParent(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	space := &fakeSpace{free: 1000 * mb}
+
+	err := Checker{Space: space, MinFreeMB: 500}.Check(filepath.Join(root, "builds", "lobby"))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{root}, space.paths)
+	assert.NoDirExists(t, filepath.Join(root, "builds"), "the check does not create the output directory")
+}
+
+func TestChecker_OutDirIsAFile(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	err := Checker{Space: &fakeSpace{free: 1000 * mb}, MinFreeMB: 500}.Check(file)
+
+	require.ErrorIs(t, err, ErrNotWritable)
+	assert.Contains(t, err.Error(), "is not a directory")
+}
+
+func TestCheckWritable_RemovesProbe(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, CheckWritable(dir))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the probe file is removed")
+}
+
+func TestCheckWritable_MissingDirectory(t *testing.T) {
+	t.Parallel()
+
+	err := CheckWritable(filepath.Join(t.TempDir(), "missing"))
+	require.ErrorIs(t, err, ErrNotWritable)
+}
+
+func TestCheckWritable_ReadOnlyDirectory(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Chmod(dir, 0o500))
+	t.Cleanup(func() { _ = os.Chmod(dir, 0o700) })
+
+	// Administrators and root can write to read-only directories
+	if f, err := os.CreateTemp(dir, "root-check-*"); err == nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		t.Skip("running with privileges that ignore directory permissions")
+	}
+
+	require.ErrorIs(t, CheckWritable(dir), ErrNotWritable)
+}
@@ -0,0 +1,84 @@
+package preflight
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// ErrMissingSidecars is returned when files a project needs are not next to it
+var ErrMissingSidecars = errcode.New(errcode.ProjectSidecars, "project sidecars are missing")
+
+// nameToken stands for the project's file name without its extension in a SidecarRule pattern
+const nameToken = "{name}"
+
+// SidecarRule describes a file or directory VTPro expects beside a project.
+// A project copied without its sidecars still compiles, but fails with dozens
+// of misleading missing resource errors.
+type SidecarRule struct {
+	Pattern string // Path relative to the project's directory, {name} is the project name
+	Dir     bool   // The sidecar is a directory rather than a file
+
+	// IfInOriginal only expects the sidecar in a staged copy when the original
+	// project has it, for sidecars not every project has
+	IfInOriginal bool
+}
+
+// DefaultSidecarRules are the sidecars checked before compiling: the .vta
+// every project is saved with, and the resource directory if it has one
+var DefaultSidecarRules = []SidecarRule{
+	{Pattern: nameToken + ".vta"},
+	{Pattern: nameToken + " Files", Dir: true, IfInOriginal: true},
+}
+
+// Path returns where the rule expects the sidecar of a project
+func (r SidecarRule) Path(project string) string {
+	return filepath.Join(filepath.Dir(project), r.rel(project))
+}
+
+// rel returns the sidecar's path relative to the project's directory
+func (r SidecarRule) rel(project string) string {
+	name := strings.TrimSuffix(filepath.Base(project), filepath.Ext(project))
+	return strings.ReplaceAll(r.Pattern, nameToken, name)
+}
+
+// exists reports whether the sidecar is at path with the right type
+func (r SidecarRule) exists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir() == r.Dir
+}
+
+// MissingSidecars returns the paths of the sidecars expected beside project
+// that are not there. original is the project the user gave, which differs
+// from project when a copy is compiled, and decides IfInOriginal rules.
+func MissingSidecars(rules []SidecarRule, project, original string) []string {
+	var missing []string
+
+	for _, r := range rules {
+		if r.IfInOriginal && !r.exists(r.Path(original)) {
+			continue
+		}
+
+		if path := r.Path(project); !r.exists(path) {
+			missing = append(missing, path)
+		}
+	}
+
+	return missing
+}
+
+// PresentSidecars returns the sidecars beside project that are there, relative
+// to the project's directory as --sidecar takes them
+func PresentSidecars(rules []SidecarRule, project string) []string {
+	var present []string
+
+	for _, r := range rules {
+		if r.exists(r.Path(project)) {
+			present = append(present, r.rel(project))
+		}
+	}
+
+	return present
+}
@@ -0,0 +1,111 @@
+package preflight
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// layout creates a lobby.vtp in a new directory with the given files and
+// directories (names ending in "/") beside it, returning the project path
+func layout(t *testing.T, entries ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	project := filepath.Join(dir, "lobby.vtp")
+	require.NoError(t, os.WriteFile(project, []byte("project"), 0o644))
+
+	for _, e := range entries {
+		path := filepath.Join(dir, e)
+		if strings.HasSuffix(e, "/") {
+			require.NoError(t, os.MkdirAll(path, 0o755))
+			continue
+		}
+
+		require.NoError(t, os.WriteFile(path, []byte("sidecar"), 0o644))
+	}
+
+	return project
+}
+
+func TestSidecarRule_Path(t *testing.T) {
+	t.Parallel()
+
+	project := filepath.Join("projects", "Lobby Panel.vtp")
+
+	assert.Equal(t, filepath.Join("projects", "Lobby Panel.vta"), DefaultSidecarRules[0].Path(project))
+	assert.Equal(t, filepath.Join("projects", "Lobby Panel Files"), DefaultSidecarRules[1].Path(project))
+	assert.Equal(t, filepath.Join("projects", "shared", "icons.vta"), SidecarRule{Pattern: "shared/icons.vta"}.Path(project),
+		"patterns without {name} are fixed paths")
+}
+
+func TestMissingSidecars(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		entries     []string
+		wantMissing []string
+	}{
+		{name: "project with both sidecars", entries: []string{"lobby.vta", "lobby Files/"}},
+		{name: "project without a resource directory", entries: []string{"lobby.vta"}},
+		{name: "project copied without its .vta", entries: []string{"lobby Files/"}, wantMissing: []string{"lobby.vta"}},
+		{name: "bare project", wantMissing: []string{"lobby.vta"}},
+		{name: "directory where the .vta should be", entries: []string{"lobby.vta/"}, wantMissing: []string{"lobby.vta"}},
+		{name: "other project's sidecars", entries: []string{"foyer.vta", "foyer Files/"}, wantMissing: []string{"lobby.vta"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			project := layout(t, tt.entries...)
+
+			var want []string
+			for _, m := range tt.wantMissing {
+				want = append(want, filepath.Join(filepath.Dir(project), m))
+			}
+
+			assert.Equal(t, want, MissingSidecars(DefaultSidecarRules, project, project))
+		})
+	}
+}
+
+func TestMissingSidecars_StagedCopy(t *testing.T) {
+	t.Parallel()
+
+	original := layout(t, "lobby.vta", "lobby Files/")
+
+	// A copy staged with only the .vta has lost the resource directory the original has
+	staged := layout(t, "lobby.vta")
+	assert.Equal(t, []string{filepath.Join(filepath.Dir(staged), "lobby Files")},
+		MissingSidecars(DefaultSidecarRules, staged, original))
+
+	assert.Empty(t, MissingSidecars(DefaultSidecarRules, layout(t, "lobby.vta", "lobby Files/"), original))
+
+	// An original without a resource directory expects none in the copy
+	assert.Empty(t, MissingSidecars(DefaultSidecarRules, staged, layout(t, "lobby.vta")))
+}
+
+func TestMissingSidecars_CustomRules(t *testing.T) {
+	t.Parallel()
+
+	rules := []SidecarRule{{Pattern: "{name}.vtz.meta"}, {Pattern: "assets", Dir: true}}
+	project := layout(t, "lobby.vtz.meta")
+
+	assert.Equal(t, []string{filepath.Join(filepath.Dir(project), "assets")}, MissingSidecars(rules, project, project))
+	assert.Empty(t, MissingSidecars(nil, project, project))
+}
+
+func TestPresentSidecars(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"lobby.vta", "lobby Files"}, PresentSidecars(DefaultSidecarRules, layout(t, "lobby.vta", "lobby Files/")))
+	assert.Equal(t, []string{"lobby Files"}, PresentSidecars(DefaultSidecarRules, layout(t, "lobby.vta/", "lobby Files/")),
+		"a directory where the .vta should be is not the .vta")
+	assert.Empty(t, PresentSidecars(DefaultSidecarRules, layout(t, "foyer.vta")))
+}
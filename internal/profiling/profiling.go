@@ -0,0 +1,160 @@
+// Package profiling writes pprof profiles of vtpc itself, for when vtpc rather
+// than VTPro is the one using the CPU.
+package profiling
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync"
+)
+
+// Kind is a type of profile --profile can write
+type Kind string
+
+const (
+	KindCPU Kind = "cpu" // CPU samples for the whole run
+	KindMem Kind = "mem" // Heap allocations, written when the run ends
+)
+
+// Spec is a profile to write, parsed from a --profile value such as "cpu=vtpc.pprof"
+type Spec struct {
+	Kind Kind
+	Path string
+}
+
+// ParseSpec parses a --profile value written "kind=path"
+func ParseSpec(s string) (Spec, error) {
+	kind, path, ok := strings.Cut(s, "=")
+	if !ok || path == "" {
+		return Spec{}, fmt.Errorf("invalid profile %q: want cpu=path or mem=path", s)
+	}
+
+	switch k := Kind(kind); k {
+	case KindCPU, KindMem:
+		return Spec{Kind: k, Path: path}, nil
+	default:
+		return Spec{}, fmt.Errorf("invalid profile %q: kind must be \"cpu\" or \"mem\"", s)
+	}
+}
+
+// ParseSpecs parses every --profile value, rejecting a kind given twice
+func ParseSpecs(values []string) ([]Spec, error) {
+	specs := make([]Spec, 0, len(values))
+	seen := make(map[Kind]bool)
+
+	for _, v := range values {
+		spec, err := ParseSpec(v)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[spec.Kind] {
+			return nil, fmt.Errorf("%s profile given more than once", spec.Kind)
+		}
+
+		seen[spec.Kind] = true
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
+
+// Session is a set of running profiles
+type Session struct {
+	cpu  *os.File
+	mem  string
+	once sync.Once
+	err  error
+}
+
+// Start creates the profile files and starts the CPU profile, if asked for.
+// Stop must be called to write them out.
+func Start(specs []Spec) (*Session, error) {
+	s := &Session{}
+
+	for _, spec := range specs {
+		switch spec.Kind {
+		case KindCPU:
+			f, err := os.Create(spec.Path)
+			if err != nil {
+				return nil, fmt.Errorf("could not create CPU profile: %w", err)
+			}
+
+			if err := pprof.StartCPUProfile(f); err != nil {
+				_ = f.Close()
+				return nil, fmt.Errorf("could not start CPU profile: %w", err)
+			}
+
+			s.cpu = f
+
+		case KindMem:
+			// Created now so an unwritable path fails the run up front, not at the end
+			f, err := os.Create(spec.Path)
+			if err != nil {
+				s.stop()
+				return nil, fmt.Errorf("could not create memory profile: %w", err)
+			}
+
+			_ = f.Close()
+			s.mem = spec.Path
+		}
+	}
+
+	return s, nil
+}
+
+// Stop flushes and closes every profile. Only the first call does anything,
+// so it can be deferred and also called on the way out of an abort. It is
+// safe on a nil Session.
+func (s *Session) Stop() error {
+	if s == nil {
+		return nil
+	}
+
+	s.once.Do(s.stop)
+
+	return s.err
+}
+
+// stop writes out the profiles, collecting any errors into s.err
+func (s *Session) stop() {
+	var errs []error
+
+	if s.cpu != nil {
+		pprof.StopCPUProfile()
+
+		if err := s.cpu.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("could not write CPU profile: %w", err))
+		}
+	}
+
+	if s.mem != "" {
+		if err := writeHeapProfile(s.mem); err != nil {
+			errs = append(errs, fmt.Errorf("could not write memory profile: %w", err))
+		}
+	}
+
+	s.err = errors.Join(errs...)
+}
+
+// writeHeapProfile writes the heap profile as of the last garbage collection
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	// Collect first so the profile includes everything allocated up to now
+	runtime.GC()
+
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,100 @@
+package profiling
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseProfile checks a profile file is non-empty and pprof can read it
+func parseProfile(t *testing.T, path string) *profile.Profile {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	info, err := f.Stat()
+	require.NoError(t, err)
+	require.NotZero(t, info.Size(), "%s is empty", path)
+
+	p, err := profile.Parse(f)
+	require.NoError(t, err)
+
+	return p
+}
+
+func TestParseSpec(t *testing.T) {
+	t.Parallel()
+
+	spec, err := ParseSpec("cpu=vtpc.pprof")
+	require.NoError(t, err)
+	assert.Equal(t, Spec{Kind: KindCPU, Path: "vtpc.pprof"}, spec)
+
+	spec, err = ParseSpec(`mem=C:\temp\heap=1.pprof`)
+	require.NoError(t, err)
+	assert.Equal(t, Spec{Kind: KindMem, Path: `C:\temp\heap=1.pprof`}, spec, "only the first = separates the kind")
+
+	for _, bad := range []string{"cpu", "cpu=", "=vtpc.pprof", "block=vtpc.pprof", "CPU=vtpc.pprof"} {
+		_, err := ParseSpec(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestParseSpecs_RejectsDuplicateKinds(t *testing.T) {
+	t.Parallel()
+
+	specs, err := ParseSpecs([]string{"cpu=a.pprof", "mem=b.pprof"})
+	require.NoError(t, err)
+	assert.Len(t, specs, 2)
+
+	_, err = ParseSpecs([]string{"cpu=a.pprof", "cpu=b.pprof"})
+	assert.EqualError(t, err, "cpu profile given more than once")
+}
+
+// TestSession_WritesParseableProfiles is not parallel: only one CPU profile can run per process
+func TestSession_WritesParseableProfiles(t *testing.T) {
+	dir := t.TempDir()
+	cpuPath := filepath.Join(dir, "cpu.pprof")
+	memPath := filepath.Join(dir, "mem.pprof")
+
+	s, err := Start([]Spec{{Kind: KindCPU, Path: cpuPath}, {Kind: KindMem, Path: memPath}})
+	require.NoError(t, err)
+
+	busy := 0
+	for i := range 1_000_000 {
+		busy += i % 7
+	}
+
+	require.NoError(t, s.Stop())
+	require.NoError(t, s.Stop(), "a second Stop does nothing")
+	assert.NotZero(t, busy)
+
+	assert.Equal(t, "cpu", parseProfile(t, cpuPath).SampleType[1].Type)
+	assert.NotEmpty(t, parseProfile(t, memPath).SampleType)
+
+	// The CPU profiler is free again once stopped
+	again, err := Start([]Spec{{Kind: KindCPU, Path: filepath.Join(dir, "again.pprof")}})
+	require.NoError(t, err)
+	require.NoError(t, again.Stop())
+}
+
+func TestStart_UnwritablePathFailsUpFront(t *testing.T) {
+	t.Parallel()
+
+	missing := filepath.Join(t.TempDir(), "missing", "mem.pprof")
+
+	_, err := Start([]Spec{{Kind: KindMem, Path: missing}})
+	assert.ErrorContains(t, err, "could not create memory profile")
+}
+
+func TestSession_StopNil(t *testing.T) {
+	t.Parallel()
+
+	var s *Session
+	assert.NoError(t, s.Stop())
+}
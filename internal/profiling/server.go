@@ -0,0 +1,58 @@
+package profiling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"time"
+)
+
+// shutdownTimeout bounds how long stopping the debug server waits for a
+// profile being downloaded
+const shutdownTimeout = 5 * time.Second
+
+// Handler serves the net/http/pprof endpoints under /debug/pprof/. It uses
+// its own mux so nothing else registered on http.DefaultServeMux is exposed.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return mux
+}
+
+// Serve listens for pprof requests on the loopback interface only, so the
+// endpoints cannot be reached from another machine. Port 0 picks a free port.
+// It returns the address listened on and a function that stops the server.
+func Serve(port int) (addr string, stop func(), err error) {
+	ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return "", nil, fmt.Errorf("could not listen for pprof on port %d: %w", port, err)
+	}
+
+	srv := &http.Server{Handler: Handler(), ReadHeaderTimeout: 10 * time.Second}
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			_ = ln.Close()
+		}
+	}()
+
+	return ln.Addr().String(), func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		_ = srv.Shutdown(ctx)
+		<-done
+	}, nil
+}
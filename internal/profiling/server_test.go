@@ -0,0 +1,56 @@
+package profiling
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe_LoopbackOnly(t *testing.T) {
+	t.Parallel()
+
+	addr, stop, err := Serve(0)
+	require.NoError(t, err)
+	defer stop()
+
+	assert.True(t, strings.HasPrefix(addr, "127.0.0.1:"), addr)
+
+	resp, err := http.Get("http://" + addr + "/debug/pprof/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "goroutine")
+}
+
+func TestServe_StopClosesListener(t *testing.T) {
+	t.Parallel()
+
+	addr, stop, err := Serve(0)
+	require.NoError(t, err)
+
+	stop()
+
+	_, err = http.Get("http://" + addr + "/debug/pprof/")
+	assert.Error(t, err)
+}
+
+func TestHandler_OnlyServesPprof(t *testing.T) {
+	t.Parallel()
+
+	addr, stop, err := Serve(0)
+	require.NoError(t, err)
+	defer stop()
+
+	resp, err := http.Get("http://" + addr + "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
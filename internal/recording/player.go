@@ -0,0 +1,474 @@
+package recording
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// ErrNoMainWindow is returned when a recording never saw VTPro's main window
+var ErrNoMainWindow = errcode.New(errcode.RecordingNoWindow, "recording has no VTPro main window")
+
+// Action is something vtpc did to VTPro during a replay
+type Action struct {
+	At   time.Duration // Recording time since the compile keystroke
+	Text string
+}
+
+// Player replays a recording to the compiler in place of VTPro. It is the
+// compiler's window manager, keyboard, control reader and monitor source: window
+// events are sent in their recorded order and spacing once the compile keystroke
+// is sent, and what the compiler does in response is kept as Actions.
+type Player struct {
+	main     Entry         // VTPro's main window
+	timeline []Entry       // Entries replayed after the compile keystroke
+	from     time.Duration // Recording time of the compile keystroke
+	speed    float64
+
+	events  chan windows.WindowEvent
+	started chan struct{}
+	done    chan struct{}
+	start   sync.Once
+	stop    sync.Once
+
+	mu        sync.Mutex
+	startedAt time.Time
+	closed    map[uintptr]bool
+	titles    map[uintptr]string
+	pids      map[uintptr]uint32
+	controls  map[uintptr][]windows.ChildInfo // Latest controls recorded for each window
+	actions   []Action
+}
+
+// NewPlayer prepares entries for replay at speed times the recorded pace.
+// Windows opened before the compile mark are treated as already on screen.
+// id recognises the main window in a recording made before MarkMain was recorded.
+func NewPlayer(entries []Entry, speed float64, id vtpro.WindowIdentity) (*Player, error) {
+	if speed <= 0 {
+		return nil, fmt.Errorf("replay speed must be positive, got %g", speed)
+	}
+
+	p := &Player{
+		speed:    speed,
+		events:   make(chan windows.WindowEvent),
+		started:  make(chan struct{}),
+		done:     make(chan struct{}),
+		closed:   make(map[uintptr]bool),
+		titles:   make(map[uintptr]string),
+		pids:     make(map[uintptr]uint32),
+		controls: make(map[uintptr][]windows.ChildInfo),
+	}
+
+	mainAt := -1
+	compileAt := -1
+	marked := markedMain(entries)
+
+	for i, e := range entries {
+		if e.Controls != nil {
+			p.controls[e.Hwnd] = e.Controls
+		}
+
+		switch {
+		case e.Kind == KindOpen && mainAt < 0 && isMain(e, marked, id):
+			mainAt = i
+			p.main = e
+		case e.Kind == KindMark && e.Mark == MarkCompile && compileAt < 0:
+			compileAt = i
+		}
+	}
+
+	if mainAt < 0 {
+		return nil, ErrNoMainWindow
+	}
+
+	// Without a compile mark, everything after the main window is replayed
+	from := compileAt
+	if from < 0 {
+		from = mainAt
+	}
+
+	for _, e := range entries[:from+1] {
+		p.apply(e)
+	}
+
+	p.timeline = entries[from+1:]
+	p.from = entries[from].At
+
+	go p.play()
+
+	return p, nil
+}
+
+// markedMain returns the main window the recording marks, 0 if it has no MarkMain
+func markedMain(entries []Entry) uintptr {
+	for _, e := range entries {
+		if e.Kind == KindMark && e.Mark == MarkMain {
+			return e.Hwnd
+		}
+	}
+
+	return 0
+}
+
+// isMain reports whether an open entry is VTPro's main window: the window the
+// recording marks, or without a mark, one vtpro classifies as the main window
+// from its recorded title and class
+func isMain(e Entry, marked uintptr, id vtpro.WindowIdentity) bool {
+	if marked != 0 {
+		return e.Hwnd == marked
+	}
+
+	return vtpro.ClassifyRecorded(id, e.Title, e.Class) == vtpro.WindowKindMain
+}
+
+// MainWindow returns the handle and PID of VTPro's main window
+func (p *Player) MainWindow() (uintptr, uint32) {
+	return p.main.Hwnd, p.main.Pid
+}
+
+// Duration returns how long the replay takes from the compile keystroke
+func (p *Player) Duration() time.Duration {
+	if len(p.timeline) == 0 {
+		return 0
+	}
+
+	return p.scale(p.timeline[len(p.timeline)-1].At - p.from)
+}
+
+// Events returns the channel window events are replayed on, for compiler.WithMonitorSource
+func (p *Player) Events() <-chan windows.WindowEvent {
+	return p.events
+}
+
+// Actions returns what the compiler did, in order
+func (p *Player) Actions() []Action {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Action(nil), p.actions...)
+}
+
+// Stop ends the replay
+func (p *Player) Stop() {
+	p.stop.Do(func() { close(p.done) })
+}
+
+// play replays the timeline once the compile keystroke has been sent
+func (p *Player) play() {
+	select {
+	case <-p.started:
+	case <-p.done:
+		return
+	}
+
+	prev := p.from
+	for _, e := range p.timeline {
+		select {
+		case <-time.After(p.scale(e.At - prev)):
+		case <-p.done:
+			return
+		}
+
+		prev = e.At
+		p.apply(e)
+
+		if e.Kind != KindOpen {
+			continue
+		}
+
+		select {
+		case p.events <- e.Event():
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// apply updates the windows on screen for an entry
+func (p *Player) apply(e Entry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch e.Kind {
+	case KindOpen:
+		p.titles[e.Hwnd] = e.Title
+		p.pids[e.Hwnd] = e.Pid
+		delete(p.closed, e.Hwnd)
+	case KindClose:
+		p.closed[e.Hwnd] = true
+	}
+}
+
+// scale converts a recorded duration to replay time
+func (p *Player) scale(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / p.speed)
+}
+
+// begin starts the timeline the first time the compile keystroke is sent
+func (p *Player) begin() {
+	p.start.Do(func() {
+		p.mu.Lock()
+		p.startedAt = time.Now()
+		p.mu.Unlock()
+
+		close(p.started)
+	})
+}
+
+// act records an action at the current recording time
+func (p *Player) act(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var at time.Duration
+	if !p.startedAt.IsZero() {
+		at = time.Duration(float64(time.Since(p.startedAt)) * p.speed)
+	}
+
+	p.actions = append(p.actions, Action{At: at, Text: fmt.Sprintf(format, args...)})
+}
+
+// describe names a window for an action
+func (p *Player) describe(hwnd uintptr) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if title := p.titles[hwnd]; title != "" {
+		return fmt.Sprintf("%q (0x%X)", title, hwnd)
+	}
+
+	return fmt.Sprintf("0x%X", hwnd)
+}
+
+// control finds a recorded child control by its handle
+func (p *Player) control(hwnd uintptr) (windows.ChildInfo, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, controls := range p.controls {
+		for _, ci := range controls {
+			if ci.Hwnd == hwnd {
+				return ci, true
+			}
+		}
+	}
+
+	return windows.ChildInfo{}, false
+}
+
+// CloseWindow records the close and takes the window off screen
+func (p *Player) CloseWindow(hwnd uintptr, title string) {
+	p.act("close %s", p.describe(hwnd))
+
+	p.mu.Lock()
+	p.closed[hwnd] = true
+	p.mu.Unlock()
+}
+
+// SetForeground records bringing a window to the foreground
+func (p *Player) SetForeground(hwnd uintptr) bool {
+	p.act("bring %s to the foreground", p.describe(hwnd))
+	return true
+}
+
+// RestoreWindow records restoring a minimized window
+func (p *Player) RestoreWindow(hwnd uintptr) bool {
+	p.act("restore %s", p.describe(hwnd))
+	return true
+}
+
+// MinimizeWindow records minimizing a window
+func (p *Player) MinimizeWindow(hwnd uintptr) bool {
+	p.act("minimize %s", p.describe(hwnd))
+	return true
+}
+
+// VerifyForegroundWindow reports that VTPro kept focus, as focus is not recorded
+func (p *Player) VerifyForegroundWindow(expectedHwnd uintptr, expectedPid uint32) bool {
+	return true
+}
+
+// IsElevated reports true so the compiler does not warn about replay
+func (p *Player) IsElevated() bool {
+	return true
+}
+
+// IsWindowValid reports whether a window is still on screen at this point of the replay
+func (p *Player) IsWindowValid(hwnd uintptr) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return !p.closed[hwnd]
+}
+
+// CollectChildInfos returns the latest controls recorded for a window
+func (p *Player) CollectChildInfos(hwnd uintptr) []windows.ChildInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.controls[hwnd]
+}
+
+// WaitOnMonitor waits for a replayed event that satisfies all matchers
+func (p *Player) WaitOnMonitor(timeout time.Duration, matchers ...func(windows.WindowEvent) bool) (windows.WindowEvent, bool) {
+	deadline := time.After(timeout)
+
+	for {
+		select {
+		case ev := <-p.events:
+			if matchesAll(ev, matchers) {
+				return ev, true
+			}
+		case <-deadline:
+			return windows.WindowEvent{}, false
+		}
+	}
+}
+
+// matchesAll reports whether ev satisfies every matcher
+func matchesAll(ev windows.WindowEvent, matchers []func(windows.WindowEvent) bool) bool {
+	for _, m := range matchers {
+		if !m(ev) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetWindowText returns the recorded title of a window
+func (p *Player) GetWindowText(hwnd uintptr) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.titles[hwnd]
+}
+
+// GetForegroundWindow returns VTPro's main window, as focus is not recorded
+func (p *Player) GetForegroundWindow() uintptr {
+	return p.main.Hwnd
+}
+
+// GetWindowPid returns the recorded PID of a window
+func (p *Player) GetWindowPid(hwnd uintptr) uint32 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.pids[hwnd]
+}
+
+// GetProcessIntegrity fails, as integrity levels are not recorded
+func (p *Player) GetProcessIntegrity(pid uint32) (windows.IntegrityLevel, error) {
+	return 0, fmt.Errorf("integrity level of process %d was not recorded", pid)
+}
+
+// GetProcessName returns "", as process names are not recorded
+func (p *Player) GetProcessName(pid uint32) string {
+	return ""
+}
+
+// GetWindowRect fails, as window positions are not recorded
+func (p *Player) GetWindowRect(hwnd uintptr) (windows.RECT, bool) {
+	return windows.RECT{}, false
+}
+
+// GetScreenBounds returns empty bounds, as the screen layout is not recorded
+func (p *Player) GetScreenBounds() (virtual, primary windows.RECT) {
+	return windows.RECT{}, windows.RECT{}
+}
+
+// MoveWindow records moving a window
+func (p *Player) MoveWindow(hwnd uintptr, x, y int32) bool {
+	p.act("move %#x to %d,%d", hwnd, x, y)
+	return true
+}
+
+// SendF12 records the compile keystroke and starts the timeline
+func (p *Player) SendF12() {
+	p.begin()
+	p.act("send F12")
+}
+
+// SendEnter records pressing Enter
+func (p *Player) SendEnter() {
+	p.act("send Enter")
+}
+
+// SendF12ToWindow records the compile keystroke and starts the timeline
+func (p *Player) SendF12ToWindow(hwnd uintptr) bool {
+	p.begin()
+	p.act("send F12 to %s", p.describe(hwnd))
+	return true
+}
+
+// SendF12WithSendInput records the compile keystroke and starts the timeline
+func (p *Player) SendF12WithSendInput() bool {
+	p.begin()
+	p.act("send F12")
+	return true
+}
+
+// SendCtrlS records the save keystroke
+func (p *Player) SendCtrlS() bool {
+	p.act("send Ctrl+S")
+	return true
+}
+
+// SendEscToWindow records dismissing a window with Escape
+func (p *Player) SendEscToWindow(hwnd uintptr) bool {
+	p.act("send Escape to %s", p.describe(hwnd))
+	return true
+}
+
+// GetListBoxItems returns the recorded items of a list box
+func (p *Player) GetListBoxItems(hwnd uintptr) []string {
+	ci, _ := p.control(hwnd)
+	return ci.Items
+}
+
+// GetEditText returns the recorded text of an edit control
+func (p *Player) GetEditText(hwnd uintptr) string {
+	ci, _ := p.control(hwnd)
+	return ci.Text
+}
+
+// GetTextLength returns the length of the recorded text of a control
+func (p *Player) GetTextLength(hwnd uintptr) int {
+	ci, _ := p.control(hwnd)
+	return len(ci.Text)
+}
+
+// GetTextLimit reports no limit, as limits are not recorded
+func (p *Player) GetTextLimit(hwnd uintptr) int {
+	return math.MaxInt32
+}
+
+// SetTextLimit does nothing, as limits are not recorded
+func (p *Player) SetTextLimit(hwnd uintptr, limit int) bool {
+	return true
+}
+
+// FindAndClickButton records clicking a recorded button, which takes its dialog off screen
+func (p *Player) FindAndClickButton(parentHwnd uintptr, buttonText string) bool {
+	for _, ci := range p.CollectChildInfos(parentHwnd) {
+		if ci.ClassName != "Button" || !strings.EqualFold(strings.ReplaceAll(ci.Text, "&", ""), buttonText) {
+			continue
+		}
+
+		p.act("click %q in %s", buttonText, p.describe(parentHwnd))
+
+		p.mu.Lock()
+		p.closed[parentHwnd] = true
+		p.mu.Unlock()
+
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,108 @@
+package recording
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/compiler"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/vtpro"
+)
+
+// replayTestGrace is how long past the end of the recording a replayed compile may run
+const replayTestGrace = 5 * time.Second
+
+// actionTexts returns the text of each action, dropping focus changes
+func actionTexts(actions []Action) []string {
+	var texts []string
+	for _, a := range actions {
+		if a.Text != `bring "Lobby.vtp - VisionTools Pro-e" (0x9999) to the foreground` {
+			texts = append(texts, a.Text)
+		}
+	}
+
+	return texts
+}
+
+func TestPlayer_ReplaysRecordedCompile(t *testing.T) {
+	t.Parallel()
+
+	entries, err := Load("testdata/compile.jsonl")
+	require.NoError(t, err)
+
+	player, err := NewPlayer(entries, 1, vtpro.DefaultWindowIdentity())
+	require.NoError(t, err)
+	defer player.Stop()
+
+	hwnd, pid := player.MainWindow()
+	assert.Equal(t, uintptr(0x9999), hwnd)
+	assert.Equal(t, uint32(1234), pid)
+
+	c := compiler.NewCompiler(logger.NewNoOpLogger(),
+		compiler.WithWindowManager(player),
+		compiler.WithKeyboard(player),
+		compiler.WithControlReader(player),
+		compiler.WithMonitorSource(player.Events),
+	)
+
+	result, err := c.Compile(compiler.CompileOptions{
+		Hwnd:                          hwnd,
+		VTProPid:                      pid,
+		SkipPreCompilationDialogCheck: true,
+		CompilationTimeout:            player.Duration() + replayTestGrace,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, result.Warnings)
+	assert.Equal(t, 0, result.Errors)
+	assert.Equal(t, []string{
+		"send F12",
+		`close "Lobby.vtp - VisionTools Pro-e" (0x9999)`,
+		`close "Address Book" (0x3333)`,
+	}, actionTexts(player.Actions()))
+
+	// The Progress dialog closed before the compile, so it is not replayed
+	assert.False(t, player.IsWindowValid(0x2222))
+}
+
+func TestNewPlayer_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewPlayer([]Entry{{Kind: KindOpen, Hwnd: 0x1111, Title: "Address Book"}}, 1, vtpro.DefaultWindowIdentity())
+	assert.ErrorIs(t, err, ErrNoMainWindow)
+
+	_, err = NewPlayer([]Entry{{Kind: KindOpen, Hwnd: 0x9999, Title: "Lobby.vtp"}}, 0, vtpro.DefaultWindowIdentity())
+	assert.ErrorContains(t, err, "speed must be positive")
+}
+
+func TestNewPlayer_MainWindow(t *testing.T) {
+	t.Parallel()
+
+	notepad := Entry{Kind: KindOpen, Hwnd: 0x4444, Title: "Lobby.vtp - Notepad", Pid: 77, Class: "Notepad"}
+	vtproMain := Entry{Kind: KindOpen, Hwnd: 0x9999, Title: "VisionTools Pro-e", Pid: 1234, Class: "VWT64AppClass"}
+	compile := Entry{Kind: KindMark, Mark: MarkCompile}
+
+	// The window vtpc compiled in is marked, so a file named after the project in another application is passed over
+	player, err := NewPlayer([]Entry{notepad, vtproMain, {Kind: KindMark, Mark: MarkMain, Hwnd: 0x9999}, compile}, 1, vtpro.DefaultWindowIdentity())
+	require.NoError(t, err)
+	player.Stop()
+
+	hwnd, pid := player.MainWindow()
+	assert.Equal(t, uintptr(0x9999), hwnd)
+	assert.Equal(t, uint32(1234), pid)
+
+	// Without the mark, the configured main window class is honoured
+	id := vtpro.WindowIdentity{MainClass: "VWT64AppClass", SplashTitle: vtpro.DefaultSplashTitle}
+	player, err = NewPlayer([]Entry{vtproMain, compile}, 1, id)
+	require.NoError(t, err)
+	player.Stop()
+
+	hwnd, _ = player.MainWindow()
+	assert.Equal(t, uintptr(0x9999), hwnd)
+
+	_, err = NewPlayer([]Entry{vtproMain, compile}, 1, vtpro.DefaultWindowIdentity())
+	assert.ErrorIs(t, err, ErrNoMainWindow)
+}
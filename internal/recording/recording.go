@@ -0,0 +1,220 @@
+// Package recording saves the window events of a compile to a JSONL file with
+// --record-events, and replays them into the compiler without VTPro.
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/interfaces"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// Kind is what an Entry records
+type Kind string
+
+const (
+	KindOpen     Kind = "open"     // The monitor published a new window
+	KindClose    Kind = "close"    // A published window went away
+	KindSnapshot Kind = "snapshot" // vtpc read a window's controls, e.g. the Message Log
+	KindMark     Kind = "mark"     // A point in the run, e.g. MarkCompile
+)
+
+// MarkCompile is recorded just before vtpc sends the compile keystroke.
+// Replay starts from here, as earlier events belong to launching VTPro.
+const MarkCompile = "compile"
+
+// MarkMain records, with its handle, the window vtpc took as VTPro's main
+// window, so replay uses the same one rather than classifying windows again
+const MarkMain = "main"
+
+// StepMark returns the mark recorded as the compile step named step ends
+func StepMark(step string) string {
+	return "step:" + step
+}
+
+// ErrEmpty is returned when a recording has no entries
+var ErrEmpty = errcode.New(errcode.RecordingEmpty, "recording is empty")
+
+// Entry is one line of a recording
+type Entry struct {
+	At       time.Duration       `json:"at"` // Time since recording started
+	Kind     Kind                `json:"kind"`
+	Hwnd     uintptr             `json:"hwnd,omitempty"`
+	Title    string              `json:"title,omitempty"`
+	Pid      uint32              `json:"pid,omitempty"`
+	Class    string              `json:"class,omitempty"`
+	Controls []windows.ChildInfo `json:"controls,omitempty"`
+	Mark     string              `json:"mark,omitempty"`
+}
+
+// Event returns the window event an open entry recorded
+func (e Entry) Event() windows.WindowEvent {
+	return windows.WindowEvent{Hwnd: e.Hwnd, Title: e.Title, Pid: e.Pid, Class: e.Class}
+}
+
+// Recorder writes entries to a JSONL file as they happen, so a recording
+// survives vtpc being killed. It is safe for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	w     *bufio.Writer
+	c     io.Closer // nil when the recorder does not own the writer
+	clock clock.Clock
+	start time.Time
+	err   error // First write error; later entries are dropped
+}
+
+// NewRecorder creates a Recorder writing to w, timed by clk
+func NewRecorder(w io.Writer, clk clock.Clock) *Recorder {
+	return &Recorder{w: bufio.NewWriter(w), clock: clk, start: clk.Now()}
+}
+
+// Create creates the file at path, replacing any existing one, and records to it
+func Create(path string, clk clock.Clock) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event recording: %w", err)
+	}
+
+	r := NewRecorder(f, clk)
+	r.c = f
+
+	return r, nil
+}
+
+// RecordOpen records a window the monitor published, with the controls of a dialog
+func (r *Recorder) RecordOpen(ev windows.WindowEvent, controls []windows.ChildInfo) {
+	r.write(Entry{Kind: KindOpen, Hwnd: ev.Hwnd, Title: ev.Title, Pid: ev.Pid, Class: ev.Class, Controls: controls})
+}
+
+// RecordClose records that a window went away
+func (r *Recorder) RecordClose(hwnd uintptr) {
+	r.write(Entry{Kind: KindClose, Hwnd: hwnd})
+}
+
+// RecordSnapshot records the controls vtpc read from a window
+func (r *Recorder) RecordSnapshot(hwnd uintptr, controls []windows.ChildInfo) {
+	r.write(Entry{Kind: KindSnapshot, Hwnd: hwnd, Controls: controls})
+}
+
+// RecordMark records a named point in the run
+func (r *Recorder) RecordMark(mark string) {
+	r.write(Entry{Kind: KindMark, Mark: mark})
+}
+
+// RecordMain records the window vtpc took as VTPro's main window
+func (r *Recorder) RecordMain(hwnd uintptr) {
+	r.write(Entry{Kind: KindMark, Mark: MarkMain, Hwnd: hwnd})
+}
+
+// write timestamps an entry and writes it as one line
+func (r *Recorder) write(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+
+	e.At = r.clock.Now().Sub(r.start)
+
+	line, err := json.Marshal(e)
+	if err == nil {
+		line = append(line, '\n')
+		_, err = r.w.Write(line)
+	}
+
+	if err == nil {
+		err = r.w.Flush()
+	}
+
+	r.err = err
+}
+
+// Close closes the file and returns the first error hit while recording
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.err
+	if err == nil {
+		err = r.w.Flush()
+	}
+
+	if r.c != nil {
+		err = errors.Join(err, r.c.Close())
+	}
+
+	return err
+}
+
+// Read reads the entries of a recording
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // A snapshot holds the whole Message Log
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrEmpty
+	}
+
+	return entries, nil
+}
+
+// Load reads the recording at path
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	entries, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return entries, nil
+}
+
+// WindowManager wraps a window manager to record a snapshot of every window
+// whose controls vtpc reads, so replay sees the same Message Log
+type WindowManager struct {
+	interfaces.WindowManager
+	Recorder *Recorder
+}
+
+// CollectChildInfos reads and records the controls of hwnd
+func (w WindowManager) CollectChildInfos(hwnd uintptr) []windows.ChildInfo {
+	infos := w.WindowManager.CollectChildInfos(hwnd)
+	w.Recorder.RecordSnapshot(hwnd, infos)
+
+	return infos
+}
@@ -0,0 +1,78 @@
+package recording
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestRecorder_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	clk := clock.NewFake(time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC))
+	r := NewRecorder(&buf, clk)
+
+	dialog := windows.WindowEvent{Hwnd: 0x7777, Title: "Trial expired", Pid: 1234, Class: windows.DialogClass}
+	controls := []windows.ChildInfo{
+		{Hwnd: 0x7701, ClassName: "Static", Text: "Your evaluation period has ended."},
+		{Hwnd: 0x7702, ClassName: "ListBox", Items: []string{"TSW-770", "TSW-1070"}},
+	}
+
+	r.RecordMain(0x9999)
+	r.RecordMark(MarkCompile)
+	clk.Advance(250 * time.Millisecond)
+	r.RecordOpen(dialog, controls)
+	clk.Advance(time.Second)
+	r.RecordSnapshot(0x9999, []windows.ChildInfo{{Hwnd: 0x9901, ClassName: "Edit", Text: "line 1\r\nline 2"}})
+	r.RecordClose(0x7777)
+	require.NoError(t, r.Close())
+
+	assert.Equal(t, 5, strings.Count(buf.String(), "\n"), "one line per entry")
+
+	entries, err := Read(&buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Entry{
+		{At: 0, Kind: KindMark, Mark: MarkMain, Hwnd: 0x9999},
+		{At: 0, Kind: KindMark, Mark: MarkCompile},
+		{At: 250 * time.Millisecond, Kind: KindOpen, Hwnd: 0x7777, Title: "Trial expired", Pid: 1234, Class: windows.DialogClass, Controls: controls},
+		{At: 1250 * time.Millisecond, Kind: KindSnapshot, Hwnd: 0x9999, Controls: []windows.ChildInfo{{Hwnd: 0x9901, ClassName: "Edit", Text: "line 1\r\nline 2"}}},
+		{At: 1250 * time.Millisecond, Kind: KindClose, Hwnd: 0x7777},
+	}, entries)
+	assert.Equal(t, dialog, entries[2].Event())
+}
+
+func TestRead_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := Read(strings.NewReader("\n"))
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	_, err = Read(strings.NewReader("{\"at\":0,\"kind\":\"mark\"}\nnot json\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestRecorder_KeepsFirstWriteError(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecorder(failingWriter{}, clock.NewFake(time.Now()))
+	r.RecordClose(0x1111)
+	r.RecordClose(0x2222)
+
+	assert.EqualError(t, r.Close(), "disk full")
+}
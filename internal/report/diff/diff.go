@@ -0,0 +1,214 @@
+// Package diff compares the JSON reports of two runs of the same project:
+// which warnings and errors appeared or went away, and how the counts, output
+// size, duration and panel models changed between them.
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+// Target changes
+const (
+	TargetAdded   = "added"
+	TargetRemoved = "removed"
+	TargetChanged = "changed"
+)
+
+// Count is a count in the old and the new report
+type Count struct {
+	Old int `json:"old"`
+	New int `json:"new"`
+}
+
+// Delta returns how much the count went up, negative if it went down
+func (c Count) Delta() int {
+	return c.New - c.Old
+}
+
+// Bytes is a size in the old and the new report, 0 where it is not known
+type Bytes struct {
+	Old int64 `json:"old"`
+	New int64 `json:"new"`
+}
+
+// Delta returns how much the size went up, negative if it went down
+func (b Bytes) Delta() int64 {
+	return b.New - b.Old
+}
+
+// Percent returns the change as a percentage of the old size, and false if
+// either size is not known
+func (b Bytes) Percent() (float64, bool) {
+	if b.Old == 0 || b.New == 0 {
+		return 0, false
+	}
+
+	return float64(b.Delta()) / float64(b.Old) * 100, true
+}
+
+// Seconds is a duration in the old and the new report
+type Seconds struct {
+	Old float64 `json:"old"`
+	New float64 `json:"new"`
+}
+
+// Delta returns how much longer the new run took, negative if it was quicker
+func (s Seconds) Delta() float64 {
+	return s.New - s.Old
+}
+
+// Run identifies one of the compared reports
+type Run struct {
+	ID        string           `json:"id,omitempty"`
+	StartedAt report.Timestamp `json:"startedAt"`
+	Status    string           `json:"status"`
+}
+
+// TargetChange is a panel model compiled by only one of the runs, or whose
+// counts or size differ between them
+type TargetChange struct {
+	Name     string `json:"name"`
+	Change   string `json:"change"` // TargetAdded, TargetRemoved or TargetChanged
+	Errors   Count  `json:"errors"`
+	Warnings Count  `json:"warnings"`
+	Size     Bytes  `json:"sizeBytes"`
+}
+
+// Result is what changed from the old report to the new one
+type Result struct {
+	Project   string                   `json:"project"` // The project's file name
+	Old       Run                      `json:"old"`
+	New       Run                      `json:"new"`
+	Errors    Count                    `json:"errors"`
+	Warnings  Count                    `json:"warnings"`
+	Size      Bytes                    `json:"sizeBytes"`
+	Duration  Seconds                  `json:"durationSeconds"`
+	Added     []report.DocumentMessage `json:"added"`   // Messages only the new report has, in its order
+	Removed   []report.DocumentMessage `json:"removed"` // Messages only the old report has, in its order
+	Targets   []TargetChange           `json:"targets"` // Added and changed models in the new report's order, then removed ones
+	Regressed bool                     `json:"regressed"`
+}
+
+// Compare compares the report of an earlier run of a project, before, with
+// that of a later one, after. Reports of projects with different file names
+// are refused, since their messages cannot be matched; the directories may
+// differ, as they do between build agents.
+func Compare(before, after *report.Document) (*Result, error) {
+	oldName, newName := projectName(before.Project), projectName(after.Project)
+	if !strings.EqualFold(oldName, newName) {
+		return nil, fmt.Errorf("the reports are of different projects: %s and %s", oldName, newName)
+	}
+
+	r := &Result{
+		Project:  newName,
+		Old:      Run{ID: before.ID, StartedAt: before.StartedAt, Status: before.Status},
+		New:      Run{ID: after.ID, StartedAt: after.StartedAt, Status: after.Status},
+		Errors:   Count{Old: before.Errors, New: after.Errors},
+		Warnings: Count{Old: before.Warnings, New: after.Warnings},
+		Size:     Bytes{Old: before.SizeBytes, New: after.SizeBytes},
+		Duration: Seconds{Old: before.DurationSeconds, New: after.DurationSeconds},
+		Added:    unmatched(after.Messages, before.Messages),
+		Removed:  unmatched(before.Messages, after.Messages),
+		Targets:  compareTargets(before.Targets, after.Targets),
+	}
+
+	r.Regressed = len(r.Added) > 0 || r.Errors.Delta() > 0 || r.Warnings.Delta() > 0
+
+	return r, nil
+}
+
+// projectName returns the file name of a project path from a report, which
+// may have been written on another machine
+func projectName(path string) string {
+	return path[strings.LastIndexAny(path, `\/`)+1:]
+}
+
+// NormalizeMessage returns the form of a message's text that is matched
+// between reports. Case, runs of whitespace and trailing full stops are
+// ignored, since VTPro versions differ in them for the same problem.
+func NormalizeMessage(text string) string {
+	return strings.TrimRight(strings.ToLower(strings.Join(strings.Fields(text), " ")), ".")
+}
+
+// messageKey is what two messages must share to be the same message
+type messageKey struct {
+	severity string
+	target   string
+	text     string
+}
+
+func keyOf(m report.DocumentMessage) messageKey {
+	return messageKey{severity: m.Severity, target: m.Target, text: NormalizeMessage(m.Text)}
+}
+
+// unmatched returns the messages in from that have no match in other. A
+// message repeated in from is matched as many times as it is in other.
+func unmatched(from, other []report.DocumentMessage) []report.DocumentMessage {
+	left := make(map[messageKey]int, len(other))
+	for _, m := range other {
+		left[keyOf(m)]++
+	}
+
+	out := []report.DocumentMessage{}
+
+	for _, m := range from {
+		k := keyOf(m)
+		if left[k] > 0 {
+			left[k]--
+			continue
+		}
+
+		out = append(out, m)
+	}
+
+	return out
+}
+
+// compareTargets returns the panel models that were added, removed or changed
+func compareTargets(before, after []report.DocumentTarget) []TargetChange {
+	byName := make(map[string]report.DocumentTarget, len(before))
+	for _, t := range before {
+		byName[t.Name] = t
+	}
+
+	changes := []TargetChange{}
+
+	for _, n := range after {
+		o, ok := byName[n.Name]
+		delete(byName, n.Name)
+
+		change := TargetChange{
+			Name:     n.Name,
+			Change:   TargetChanged,
+			Errors:   Count{Old: o.Errors, New: n.Errors},
+			Warnings: Count{Old: o.Warnings, New: n.Warnings},
+			Size:     Bytes{Old: o.SizeBytes, New: n.SizeBytes},
+		}
+
+		switch {
+		case !ok:
+			change.Change = TargetAdded
+		case o.Errors == n.Errors && o.Warnings == n.Warnings && o.SizeBytes == n.SizeBytes:
+			continue
+		}
+
+		changes = append(changes, change)
+	}
+
+	for _, o := range before {
+		if _, ok := byName[o.Name]; ok {
+			changes = append(changes, TargetChange{
+				Name:     o.Name,
+				Change:   TargetRemoved,
+				Errors:   Count{Old: o.Errors},
+				Warnings: Count{Old: o.Warnings},
+				Size:     Bytes{Old: o.SizeBytes},
+			})
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,146 @@
+package diff
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the diff golden files in testdata")
+
+// compareFixture compares the old.json and new.json reports in testdata/<name>
+func compareFixture(t *testing.T, name string) *Result {
+	t.Helper()
+
+	before, err := report.ReadDocument(filepath.Join("testdata", name, "old.json"))
+	require.NoError(t, err)
+
+	after, err := report.ReadDocument(filepath.Join("testdata", name, "new.json"))
+	require.NoError(t, err)
+
+	r, err := Compare(before, after)
+	require.NoError(t, err)
+
+	return r
+}
+
+// assertGolden compares got with testdata/<name>, rewriting it with -update
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got)
+}
+
+func TestCompare_Golden(t *testing.T) {
+	t.Parallel()
+
+	renderers := map[string]func(*bytes.Buffer, *Result) error{
+		"want.txt":  func(b *bytes.Buffer, r *Result) error { return WriteText(b, r) },
+		"want.md":   func(b *bytes.Buffer, r *Result) error { return WriteMarkdown(b, r) },
+		"want.json": func(b *bytes.Buffer, r *Result) error { return WriteJSON(b, r) },
+	}
+
+	for _, name := range []string{"regression", "improvement", "unchanged"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := compareFixture(t, name)
+
+			for golden, render := range renderers {
+				var b bytes.Buffer
+				require.NoError(t, render(&b, r))
+				assertGolden(t, filepath.Join(name, golden), b.String())
+			}
+		})
+	}
+}
+
+func TestCompare_Regression(t *testing.T) {
+	t.Parallel()
+
+	r := compareFixture(t, "regression")
+
+	assert.True(t, r.Regressed)
+	assert.Equal(t, "Lobby.vtp", r.Project, "reports from different build directories are of the same project")
+	assert.Equal(t, 1, r.Errors.Delta())
+	assert.Equal(t, 0, r.Warnings.Delta())
+	require.Len(t, r.Added, 2, "a reworded warning is matched by its normalized text")
+	assert.Equal(t, "Join 12 is undefined", r.Added[0].Text)
+	require.Len(t, r.Removed, 1)
+	assert.Equal(t, "path-length-warning", r.Removed[0].RuleID)
+
+	pct, ok := r.Size.Percent()
+	require.True(t, ok)
+	assert.InDelta(t, 0.0695, pct, 0.0001)
+}
+
+func TestCompare_NotRegressed(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"improvement", "unchanged"} {
+		assert.False(t, compareFixture(t, name).Regressed, name)
+	}
+}
+
+func TestCompare_RepeatedMessages(t *testing.T) {
+	t.Parallel()
+
+	join := report.DocumentMessage{Severity: "warning", Text: "Join 27 is already in use", Target: "TSW-770"}
+
+	r, err := Compare(
+		&report.Document{Project: "Lobby.vtp", Warnings: 1, Messages: []report.DocumentMessage{join}},
+		&report.Document{Project: "Lobby.vtp", Warnings: 2, Messages: []report.DocumentMessage{join, join}},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []report.DocumentMessage{join}, r.Added, "a message logged once more than before is added")
+	assert.Empty(t, r.Removed)
+}
+
+func TestCompare_SeverityAndTargetMatter(t *testing.T) {
+	t.Parallel()
+
+	warning := report.DocumentMessage{Severity: "warning", Text: "Join 27 is already in use", Target: "TSW-770"}
+	promoted := report.DocumentMessage{Severity: "error", Text: warning.Text, Target: warning.Target}
+	moved := report.DocumentMessage{Severity: "warning", Text: warning.Text, Target: "TSW-1070"}
+
+	r, err := Compare(
+		&report.Document{Project: "Lobby.vtp", Messages: []report.DocumentMessage{warning}},
+		&report.Document{Project: "Lobby.vtp", Messages: []report.DocumentMessage{promoted, moved}},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []report.DocumentMessage{promoted, moved}, r.Added)
+	assert.Equal(t, []report.DocumentMessage{warning}, r.Removed)
+}
+
+func TestCompare_DifferentProjects(t *testing.T) {
+	t.Parallel()
+
+	_, err := Compare(&report.Document{Project: `C:\panels\Lobby.vtp`}, &report.Document{Project: `C:\panels\Boardroom.vtp`})
+	require.Error(t, err)
+	assert.Equal(t, "the reports are of different projects: Lobby.vtp and Boardroom.vtp", err.Error())
+}
+
+func TestNormalizeMessage(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `object "volume" on page "main" has an unassigned smart object id`,
+		NormalizeMessage("Object \"Volume\" on  Page \"Main\"\thas an unassigned Smart Object ID. "))
+	assert.Equal(t, "join 12 is undefined", NormalizeMessage("Join 12 is undefined..."))
+}
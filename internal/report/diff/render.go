@@ -0,0 +1,177 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+// WriteText writes the comparison as plain text, as shown in a console
+func WriteText(w io.Writer, r *Result) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Project: %s\n", r.Project)
+	fmt.Fprintf(&b, "Old: %s\n", describeRun(r.Old))
+	fmt.Fprintf(&b, "New: %s\n", describeRun(r.New))
+	fmt.Fprintf(&b, "\nErrors: %d -> %d (%s)\n", r.Errors.Old, r.Errors.New, signed(int64(r.Errors.Delta())))
+	fmt.Fprintf(&b, "Warnings: %d -> %d (%s)\n", r.Warnings.Old, r.Warnings.New, signed(int64(r.Warnings.Delta())))
+	fmt.Fprintf(&b, "Size: %s -> %s bytes (%s)\n", sizeOf(r.Size.Old), sizeOf(r.Size.New), sizeChange(r.Size))
+	fmt.Fprintf(&b, "Duration: %.1fs -> %.1fs (%+.1fs)\n", r.Duration.Old, r.Duration.New, r.Duration.Delta())
+
+	if len(r.Added) > 0 {
+		fmt.Fprintf(&b, "\n%d message(s) added\n", len(r.Added))
+		writeTextMessages(&b, r.Added)
+	}
+
+	if len(r.Removed) > 0 {
+		fmt.Fprintf(&b, "\n%d message(s) removed\n", len(r.Removed))
+		writeTextMessages(&b, r.Removed)
+	}
+
+	if len(r.Targets) > 0 {
+		fmt.Fprintf(&b, "\nTargets\n")
+
+		for _, t := range r.Targets {
+			fmt.Fprintf(&b, "%s %s: errors %d -> %d, warnings %d -> %d, size %s -> %s bytes\n",
+				t.Change, t.Name, t.Errors.Old, t.Errors.New, t.Warnings.Old, t.Warnings.New, sizeOf(t.Size.Old), sizeOf(t.Size.New))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%s\n", verdict(r))
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// writeTextMessages writes messages as the text report lists them
+func writeTextMessages(b *strings.Builder, messages []report.DocumentMessage) {
+	for _, m := range messages {
+		if m.Target != "" {
+			fmt.Fprintf(b, "[%s] %s: %s\n", m.Severity, m.Target, m.Text)
+		} else {
+			fmt.Fprintf(b, "[%s] %s\n", m.Severity, m.Text)
+		}
+	}
+}
+
+// WriteMarkdown writes the comparison as Markdown, for a pull request comment
+// or a build summary page
+func WriteMarkdown(w io.Writer, r *Result) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## vtpc diff: %s\n\n", cell(r.Project))
+	fmt.Fprintf(&b, "%s\n\n", verdict(r))
+	fmt.Fprintf(&b, "| | Old | New | Change |\n")
+	fmt.Fprintf(&b, "|---|---|---|---|\n")
+	fmt.Fprintf(&b, "| Run | %s | %s | |\n", cell(describeRun(r.Old)), cell(describeRun(r.New)))
+	fmt.Fprintf(&b, "| Errors | %d | %d | %s |\n", r.Errors.Old, r.Errors.New, signed(int64(r.Errors.Delta())))
+	fmt.Fprintf(&b, "| Warnings | %d | %d | %s |\n", r.Warnings.Old, r.Warnings.New, signed(int64(r.Warnings.Delta())))
+	fmt.Fprintf(&b, "| Size (bytes) | %s | %s | %s |\n", sizeOf(r.Size.Old), sizeOf(r.Size.New), sizeChange(r.Size))
+	fmt.Fprintf(&b, "| Duration | %.1fs | %.1fs | %+.1fs |\n", r.Duration.Old, r.Duration.New, r.Duration.Delta())
+
+	if len(r.Added) > 0 {
+		fmt.Fprintf(&b, "\n### Added (%d)\n\n", len(r.Added))
+		writeMarkdownMessages(&b, r.Added)
+	}
+
+	if len(r.Removed) > 0 {
+		fmt.Fprintf(&b, "\n### Removed (%d)\n\n", len(r.Removed))
+		writeMarkdownMessages(&b, r.Removed)
+	}
+
+	if len(r.Targets) > 0 {
+		fmt.Fprintf(&b, "\n### Targets\n\n")
+		fmt.Fprintf(&b, "| Target | Change | Errors | Warnings | Size (bytes) |\n")
+		fmt.Fprintf(&b, "|---|---|---|---|---|\n")
+
+		for _, t := range r.Targets {
+			fmt.Fprintf(&b, "| %s | %s | %d -> %d | %d -> %d | %s -> %s |\n",
+				cell(t.Name), t.Change, t.Errors.Old, t.Errors.New, t.Warnings.Old, t.Warnings.New, sizeOf(t.Size.Old), sizeOf(t.Size.New))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// writeMarkdownMessages writes messages as a Markdown list
+func writeMarkdownMessages(b *strings.Builder, messages []report.DocumentMessage) {
+	for _, m := range messages {
+		if m.Target != "" {
+			fmt.Fprintf(b, "- **%s** %s: %s\n", m.Severity, m.Target, m.Text)
+		} else {
+			fmt.Fprintf(b, "- **%s** %s\n", m.Severity, m.Text)
+		}
+	}
+}
+
+// WriteJSON writes the comparison as indented JSON, for scripts
+func WriteJSON(w io.Writer, r *Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+// verdict says whether the new run regressed
+func verdict(r *Result) string {
+	if r.Regressed {
+		return "Regressed: new errors or warnings since the old run"
+	}
+
+	return "No new errors or warnings since the old run"
+}
+
+// describeRun names a compared run by its ID, start and status
+func describeRun(run Run) string {
+	parts := []string{}
+
+	if run.ID != "" {
+		parts = append(parts, run.ID)
+	}
+
+	if !run.StartedAt.IsZero() {
+		parts = append(parts, report.FormatTimestamp(run.StartedAt.Time))
+	}
+
+	return strings.Join(append(parts, run.Status), ", ")
+}
+
+// sizeOf formats a size in bytes, "?" if it is not known
+func sizeOf(n int64) string {
+	if n == 0 {
+		return "?"
+	}
+
+	return fmt.Sprint(n)
+}
+
+// sizeChange formats how much the size changed, with the percentage when
+// both sizes are known
+func sizeChange(b Bytes) string {
+	pct, ok := b.Percent()
+	if !ok {
+		return "unknown"
+	}
+
+	return fmt.Sprintf("%s, %+.2f%%", signed(b.Delta()), pct)
+}
+
+// signed formats a change with its sign, "0" for none
+func signed(n int64) string {
+	if n == 0 {
+		return "0"
+	}
+
+	return fmt.Sprintf("%+d", n)
+}
+
+// cell escapes text for a Markdown table cell
+func cell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,110 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// DocumentVersion is the version of the JSON report's layout. It goes up when
+// a field is removed or changes meaning, not when one is added.
+const DocumentVersion = 1
+
+// Document is a run as the json report writes it, for tools such as vtpc diff
+// to read back
+type Document struct {
+	Version           int               `json:"version"`
+	ID                string            `json:"id,omitempty"`
+	Project           string            `json:"project"`
+	Status            string            `json:"status"` // As on the result line, e.g. "ok"
+	Code              string            `json:"code,omitempty"`
+	StartedAt         Timestamp         `json:"startedAt"`
+	FinishedAt        Timestamp         `json:"finishedAt"`
+	DurationSeconds   float64           `json:"durationSeconds"`
+	Warnings          int               `json:"warnings"`
+	Errors            int               `json:"errors"`
+	Size              string            `json:"size,omitempty"` // As VTPro reported it, e.g. "18,588,092 bytes"
+	SizeBytes         int64             `json:"sizeBytes"`      // 0 if VTPro's size could not be read
+	ProjectSize       string            `json:"projectSize,omitempty"`
+	ProjectBytes      int64             `json:"projectBytes"`
+	Targets           []DocumentTarget  `json:"targets,omitempty"`
+	Messages          []DocumentMessage `json:"messages,omitempty"`
+	Artifacts         []string          `json:"artifacts,omitempty"`
+	ConfigFingerprint string            `json:"configFingerprint,omitempty"`
+}
+
+// DocumentTarget is the result of compiling for one panel model
+type DocumentTarget struct {
+	Name      string `json:"name"`
+	Warnings  int    `json:"warnings"`
+	Errors    int    `json:"errors"`
+	Size      string `json:"size,omitempty"`
+	SizeBytes int64  `json:"sizeBytes"`
+}
+
+// DocumentMessage is a warning or error from the Message Log
+type DocumentMessage struct {
+	Severity string `json:"severity"`
+	Text     string `json:"text"`
+	Target   string `json:"target,omitempty"`
+	RuleID   string `json:"ruleId,omitempty"`
+	Page     string `json:"page,omitempty"`
+	Object   string `json:"object,omitempty"`
+	Link     string `json:"link,omitempty"`
+}
+
+// NewDocument returns the JSON report of run
+func NewDocument(run *Run) Document {
+	doc := Document{
+		Version:           DocumentVersion,
+		ID:                run.ID,
+		Project:           run.Project,
+		Status:            StatusFor(run.Summary.Cause),
+		Code:              run.Summary.Code,
+		StartedAt:         run.StartedAt,
+		FinishedAt:        run.FinishedAt,
+		DurationSeconds:   run.Summary.Duration.Seconds(),
+		Warnings:          run.Warnings,
+		Errors:            run.Errors,
+		Size:              run.Summary.Size,
+		SizeBytes:         run.SizeBytes,
+		ProjectSize:       run.ProjectSize,
+		ProjectBytes:      run.ProjectBytes,
+		Artifacts:         run.Summary.Artifacts,
+		ConfigFingerprint: run.ConfigFingerprint,
+	}
+
+	for _, t := range run.Targets {
+		doc.Targets = append(doc.Targets, DocumentTarget(t))
+	}
+
+	for _, m := range run.Messages {
+		doc.Messages = append(doc.Messages, DocumentMessage(m))
+	}
+
+	return doc
+}
+
+// ReadDocument reads a JSON report written by vtpc. Reports from a later
+// layout than this vtpc knows are refused rather than misread.
+func ReadDocument(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: not a vtpc JSON report: %w", path, err)
+	}
+
+	if doc.Version < 1 {
+		return nil, fmt.Errorf("%s: not a vtpc JSON report: no version", path)
+	}
+
+	if doc.Version > DocumentVersion {
+		return nil, fmt.Errorf("%s: report version %d is newer than this vtpc reads (%d), update vtpc", path, doc.Version, DocumentVersion)
+	}
+
+	return &doc, nil
+}
@@ -0,0 +1,37 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadDocument_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "not JSON", content: "Run: 20250601T083000\n", want: "not a vtpc JSON report: invalid character"},
+		{name: "no version", content: `{"project": "lobby.vtp"}`, want: "not a vtpc JSON report: no version"},
+		{name: "newer", content: `{"version": 99}`, want: "report version 99 is newer than this vtpc reads (1), update vtpc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "report.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+
+			_, err := ReadDocument(path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), path+": "+tt.want)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package report
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// LinkFields are the placeholders a message link template may use, each
+// written in braces, e.g. vtpro://open?project={project}&page={page}
+var LinkFields = []string{"project", "page", "object", "target", "rule", "severity"}
+
+// LinkTemplate turns a message into a link that opens it at its source, for
+// viewers with a handler for VTPro links. The zero value produces no links.
+type LinkTemplate struct {
+	parts []string // Literal text, with field names at odd indexes
+}
+
+// ParseLinkTemplate parses a template of literal text and {field} placeholders.
+// An empty template is valid and produces no links.
+func ParseLinkTemplate(s string) (LinkTemplate, error) {
+	var t LinkTemplate
+
+	rest := s
+	for rest != "" {
+		literal, after, ok := strings.Cut(rest, "{")
+		if strings.Contains(literal, "}") {
+			return LinkTemplate{}, fmt.Errorf("link template %q has a } without a matching {", s)
+		}
+
+		t.parts = append(t.parts, literal)
+
+		if !ok {
+			break
+		}
+
+		field, tail, ok := strings.Cut(after, "}")
+		if !ok {
+			return LinkTemplate{}, fmt.Errorf("link template %q has an unclosed {", s)
+		}
+
+		if !slices.Contains(LinkFields, field) {
+			return LinkTemplate{}, fmt.Errorf("link template %q uses unknown field {%s}, expected one of %s",
+				s, field, strings.Join(LinkFields, ", "))
+		}
+
+		t.parts = append(t.parts, field)
+		rest = tail
+	}
+
+	return t, nil
+}
+
+// IsZero reports whether the template is empty and produces no links
+func (t LinkTemplate) IsZero() bool {
+	return len(t.parts) == 0
+}
+
+// Expand substitutes the message's fields into the template, URL-escaped. It
+// returns false when the template is empty or uses a field the message lacks,
+// since a link without its page or object would open the wrong place.
+func (t LinkTemplate) Expand(project string, m Message) (string, bool) {
+	if t.IsZero() {
+		return "", false
+	}
+
+	values := map[string]string{
+		"project":  project,
+		"page":     m.Page,
+		"object":   m.Object,
+		"target":   m.Target,
+		"rule":     m.RuleID,
+		"severity": m.Severity,
+	}
+
+	var b strings.Builder
+
+	for i, part := range t.parts {
+		if i%2 == 0 {
+			b.WriteString(part)
+			continue
+		}
+
+		value := values[part]
+		if value == "" {
+			return "", false
+		}
+
+		b.WriteString(escapeLinkValue(value))
+	}
+
+	return b.String(), true
+}
+
+// Apply sets the Link of every message in the run that has the fields the template uses
+func (t LinkTemplate) Apply(run *Run) {
+	if t.IsZero() {
+		return
+	}
+
+	for i := range run.Messages {
+		run.Messages[i].Link, _ = t.Expand(run.Project, run.Messages[i])
+	}
+}
+
+// escapeLinkValue escapes a value for any part of a URL. Spaces become %20
+// rather than +, which only means a space in a query string.
+func escapeLinkValue(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLinkTemplate = "vtpro://open?project={project}&page={page}&object={object}"
+
+func TestParseLinkTemplate_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{"vtpro://open?page={pg}", `uses unknown field {pg}, expected one of project, page, object, target, rule, severity`},
+		{"vtpro://open?page={page", "has an unclosed {"},
+		{"vtpro://open?page=page}", "has a } without a matching {"},
+		{"vtpro://open?page={}", "uses unknown field {}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseLinkTemplate(tt.template)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLinkTemplate_Expand(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := ParseLinkTemplate(testLinkTemplate)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		template LinkTemplate
+		message  Message
+		want     string // Empty when no link is produced
+	}{
+		{
+			name:     "fields are substituted",
+			template: tmpl,
+			message:  Message{Page: "Main", Object: "Button1"},
+			want:     `vtpro://open?project=C%3A%5CProjects%5CLobby.vtp&page=Main&object=Button1`,
+		},
+		{
+			name:     "spaces, ampersands and non-ASCII are escaped",
+			template: tmpl,
+			message:  Message{Page: "Sources & Doc Cam", Object: "Lautstärke+"},
+			want:     `vtpro://open?project=C%3A%5CProjects%5CLobby.vtp&page=Sources%20%26%20Doc%20Cam&object=Lautst%C3%A4rke%2B`,
+		},
+		{
+			name:     "a missing field omits the link",
+			template: tmpl,
+			message:  Message{Page: "Main"},
+		},
+		{
+			name:     "fields the template does not use may be missing",
+			template: mustParseLinkTemplate(t, "https://wiki.example.com/rules/{rule}"),
+			message:  Message{RuleID: "missing-join"},
+			want:     "https://wiki.example.com/rules/missing-join",
+		},
+		{
+			name:     "a template ending in text",
+			template: mustParseLinkTemplate(t, "{severity}.html"),
+			message:  Message{Severity: "error"},
+			want:     "error.html",
+		},
+		{
+			name:    "no template, no link",
+			message: Message{Page: "Main", Object: "Button1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := tt.template.Expand(`C:\Projects\Lobby.vtp`, tt.message)
+			assert.Equal(t, tt.want != "", ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLinkTemplate_Apply(t *testing.T) {
+	t.Parallel()
+
+	run := &Run{
+		Project: `C:\Lobby.vtp`,
+		Messages: []Message{
+			{Severity: "warning", Page: "Main", Object: "Button1"},
+			{Severity: "error", Text: "Out of memory"},
+		},
+	}
+
+	mustParseLinkTemplate(t, testLinkTemplate).Apply(run)
+
+	assert.Equal(t, `vtpro://open?project=C%3A%5CLobby.vtp&page=Main&object=Button1`, run.Messages[0].Link)
+	assert.Empty(t, run.Messages[1].Link)
+}
+
+func TestLinkTemplate_ZeroValueLeavesMessagesAlone(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := ParseLinkTemplate("")
+	require.NoError(t, err)
+	assert.True(t, tmpl.IsZero())
+
+	run := &Run{Messages: []Message{{Page: "Main", Object: "Button1"}}}
+	tmpl.Apply(run)
+	assert.Empty(t, run.Messages[0].Link)
+}
+
+func mustParseLinkTemplate(t *testing.T, s string) LinkTemplate {
+	t.Helper()
+
+	tmpl, err := ParseLinkTemplate(s)
+	require.NoError(t, err)
+
+	return tmpl
+}
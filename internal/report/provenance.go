@@ -0,0 +1,20 @@
+package report
+
+// Provenance traces a compiled artifact back to the source it was built from and
+// the run that built it. It is written beside the artifact by --provenance and
+// included in the run's reports.
+type Provenance struct {
+	Artifact       string    `json:"artifact"`
+	ArtifactSHA256 string    `json:"artifactSha256"`
+	Source         string    `json:"source"`
+	SourceSHA256   string    `json:"sourceSha256"`
+	VtpcVersion    string    `json:"vtpcVersion"`
+	VTProVersion   string    `json:"vtproVersion,omitempty"` // Empty if VTPro's version resource could not be read
+	RunID          string    `json:"runId"`
+	StartedAt      Timestamp `json:"startedAt"`  // When the compile started
+	FinishedAt     Timestamp `json:"finishedAt"` // When the compile finished
+	Machine        string    `json:"machine"`
+	User           string    `json:"user"`
+	Warnings       int       `json:"warnings"`
+	Errors         int       `json:"errors"`
+}
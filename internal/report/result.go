@@ -0,0 +1,65 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ResultPrefix starts the result line, so scripts can find it with grep or findstr
+const ResultPrefix = "vtpc-result"
+
+// Statuses of the result line
+const (
+	StatusOK          = "ok"
+	StatusFailed      = "failed"
+	StatusCancelled   = "cancelled"   // Cancelled from the Compiling dialog
+	StatusTimeout     = "timeout"     // The compile did not finish in time
+	StatusInterrupted = "interrupted" // Ctrl+C, the console closing or the cancel file
+)
+
+// StatusFor returns the result line's status for a run that ended with cause c
+func StatusFor(c Cause) string {
+	switch c {
+	case CauseNone:
+		return StatusOK
+	case CauseCancelled:
+		return StatusCancelled
+	case CauseCompileTimeout:
+		return StatusTimeout
+	default:
+		return StatusFailed
+	}
+}
+
+// ResultLine returns the single line of key=value pairs that ends a run's output
+// for wrapper scripts, such as:
+//
+//	vtpc-result status=ok file="living room.vtp" errors=0 warnings=2 duration=94.2s artifact="C:\\Projects\\living room.vtz" code=""
+//
+// code is the failure's code from the error catalog, empty for a run that
+// succeeded. Every key is always present, in this order. Text values are
+// always quoted, with quotes and backslashes escaped as in a Go or JSON string.
+func ResultLine(status string, run *Run) string {
+	file := ""
+	if run.Project != "" {
+		file = filepath.Base(run.Project)
+	}
+
+	artifact := ""
+	if len(run.Summary.Artifacts) > 0 {
+		artifact = run.Summary.Artifacts[0]
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s status=%s", ResultPrefix, status)
+	fmt.Fprintf(&b, " file=%s", strconv.Quote(file))
+	fmt.Fprintf(&b, " errors=%d warnings=%d", run.Errors, run.Warnings)
+	fmt.Fprintf(&b, " duration=%.1fs", run.Summary.Duration.Seconds())
+	fmt.Fprintf(&b, " artifact=%s", strconv.Quote(artifact))
+	fmt.Fprintf(&b, " code=%s", strconv.Quote(run.Summary.Code))
+
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultLine(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("Projects", "Site A")
+
+	tests := []struct {
+		name   string
+		status string
+		run    Run
+		want   string
+	}{
+		{
+			name:   "success",
+			status: StatusOK,
+			run: Run{
+				Project:  filepath.Join(dir, "living room.vtp"),
+				Warnings: 2,
+				Summary:  Summary{Duration: 94*time.Second + 240*time.Millisecond, Artifacts: []string{`C:\Site A\living room.vtz`, `C:\Site A\other.vtz`}},
+			},
+			want: `vtpc-result status=ok file="living room.vtp" errors=0 warnings=2 duration=94.2s artifact="C:\\Site A\\living room.vtz" code=""`,
+		},
+		{
+			name:   "failure before anything is known",
+			status: StatusFailed,
+			run:    Run{},
+			want:   `vtpc-result status=failed file="" errors=0 warnings=0 duration=0.0s artifact="" code=""`,
+		},
+		{
+			name:   "quotes in the file name",
+			status: StatusFailed,
+			run:    Run{Project: `lobby "v2".vtp`, Errors: 3, Summary: Summary{Duration: 50 * time.Millisecond}},
+			want:   `vtpc-result status=failed file="lobby \"v2\".vtp" errors=3 warnings=0 duration=0.1s artifact="" code=""`,
+		},
+		{
+			name:   "failure with a code",
+			status: StatusTimeout,
+			run:    Run{Project: "lobby.vtp", Summary: Summary{Cause: CauseCompileTimeout, Code: "VTPC_E_TIMEOUT_COMPILE", Duration: 10 * time.Minute}},
+			want:   `vtpc-result status=timeout file="lobby.vtp" errors=0 warnings=0 duration=600.0s artifact="" code="VTPC_E_TIMEOUT_COMPILE"`,
+		},
+		{
+			name:   "non-ASCII is kept",
+			status: StatusInterrupted,
+			run:    Run{Project: "会議室.vtp"},
+			want:   `vtpc-result status=interrupted file="会議室.vtp" errors=0 warnings=0 duration=0.0s artifact="" code=""`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, ResultLine(tt.status, &tt.run))
+		})
+	}
+}
+
+func TestStatusFor(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, StatusOK, StatusFor(CauseNone))
+	assert.Equal(t, StatusCancelled, StatusFor(CauseCancelled))
+	assert.Equal(t, StatusTimeout, StatusFor(CauseCompileTimeout))
+	assert.Equal(t, StatusFailed, StatusFor(CauseCompileErrors))
+	assert.Equal(t, StatusFailed, StatusFor(CauseInternalError))
+	assert.Equal(t, StatusFailed, StatusFor(CauseDeployFailed))
+}
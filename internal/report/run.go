@@ -0,0 +1,96 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Message is a warning or error from the Message Log
+type Message struct {
+	Severity string // "warning" or "error"
+	Text     string
+	Target   string // Panel model the message belongs to, if known
+	RuleID   string // Kind of message, e.g. "missing-join", or "unknown"
+	Page     string // Page the message names, if any
+	Object   string // Object the message names, if any
+	Link     string // Where the message opens in VTPro, from --message-link-template
+}
+
+// Target is the result of compiling for one panel model
+type Target struct {
+	Name      string // Panel model, e.g. "TSW-770"
+	Warnings  int
+	Errors    int
+	Size      string // Output size VTPro reported for the model
+	SizeBytes int64  // Size in bytes, 0 if it could not be parsed
+}
+
+// Reclassification is a message the config file's rule policy changed
+type Reclassification struct {
+	RuleID string
+	Text   string
+	Target string // Panel model the message belongs to, if known
+	From   string // "warning" or "error", as VTPro reported it
+	Action string // "error", "warning" or "ignore"
+	Policy string // The policy entry that decided, e.g. "path-length-warning: error"
+}
+
+// Keystroke is how the compile keystroke reached VTPro
+type Keystroke struct {
+	Method       string   // Input method that sent it, "" if none did
+	Failed       []string // Input methods whose call failed first, in the order tried
+	Acknowledged bool     // VTPro started compiling after it
+	Watched      bool     // Whether VTPro started compiling was watched for, so Acknowledged is known
+}
+
+// ArtifactCheck is what --verify-artifact found about one compiled artifact
+type ArtifactCheck struct {
+	Path              string
+	Entries           int
+	UncompressedBytes uint64
+	Problems          []string // Structural errors that failed the run
+	Notes             []string // Findings that did not fail the run
+}
+
+// Run is everything known about a finished run, as passed to report writers
+type Run struct {
+	ID         string   // Identifies the run across the log, reports and the Event Log
+	Args       []string // Command-line arguments, with secrets masked
+	Project    string   // Project file that was compiled
+	Summary    Summary
+	StartedAt  Timestamp // When the run started
+	FinishedAt Timestamp // When the run finished
+	Warnings   int
+	Errors     int
+	Messages   []Message // Warnings and errors in Message Log order, after the rule policy
+
+	SizeBytes    int64    // Output size in bytes, 0 if it could not be parsed
+	ProjectSize  string   // Project size VTPro reported, e.g. "0 Kb"
+	ProjectBytes int64    // ProjectSize in bytes, 0 if it could not be parsed
+	Targets      []Target // Results per panel model, one per section of the Message Log
+
+	SuppressedByPage []Message          // Messages on pages matched by --ignore-pages, left out of the counts
+	Reclassified     []Reclassification // Messages the rule policy changed, in log order
+
+	Timing    Timing    // Wall and CPU time of the run and its phases
+	Keystroke Keystroke // How the compile keystroke was sent, if it was
+
+	ArtifactChecks []ArtifactCheck // Results of --verify-artifact, one per artifact
+	Provenance     []Provenance    // What --provenance wrote beside each artifact
+
+	ConfigFingerprint string // Hash of the effective configuration, shared by runs configured alike
+
+	PanicStack string // Stack of the panic that crashed vtpc, if it did
+}
+
+// NewRunID returns an ID for a run that started at t: the start time to the
+// second, then six random hex digits read from r so runs in the same second differ
+func NewRunID(t time.Time, r io.Reader) string {
+	suffix := make([]byte, 3)
+	if _, err := io.ReadFull(r, suffix); err != nil {
+		return t.Format("20060102T150405")
+	}
+
+	return fmt.Sprintf("%s-%x", t.Format("20060102T150405"), suffix)
+}
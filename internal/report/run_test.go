@@ -0,0 +1,18 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRunID(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 6, 1, 8, 30, 5, 0, time.UTC)
+
+	assert.Equal(t, "20250601T083005-0a1bff", NewRunID(start, bytes.NewReader([]byte{0x0a, 0x1b, 0xff})))
+	assert.Equal(t, "20250601T083005", NewRunID(start, bytes.NewReader(nil)), "no randomness falls back to the time alone")
+}
@@ -0,0 +1,274 @@
+// Package report renders the end-of-run summary shown to the user.
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Cause classifies why a run failed
+type Cause int
+
+const (
+	CauseNone           Cause = iota // The run succeeded
+	CauseCompileErrors               // VTPro reported compile errors
+	CauseCancelled                   // The compile was cancelled from the Compiling dialog
+	CauseCompileTimeout              // The compile did not finish in time
+	CauseVTProNotReady               // VTPro did not start, show its window or load the file
+	CauseVTProNotFound               // VTPro is not installed where vtpc looks for it
+	CauseSaveFailed                  // --save-first could not save the project
+	CauseInvalidProject              // The file is not a VTPro project
+	CauseEnvironment                 // The build machine failed a pre-flight check, such as free disk space
+	CauseInputBlocked                // An elevated window in the foreground swallowed vtpc's keystrokes
+	CauseUnknownDialog               // --strict-dialogs stopped at a dialog vtpc does not know
+	CauseBadArtifact                 // --verify-artifact found the compiled artifact corrupt
+	CauseDeployFailed                // The compile succeeded but --deploy could not upload the artifact
+	CauseBudgetExceeded              // The compile succeeded but took longer than --max-compile-time
+	CausePolicy                      // The machine policy refused the project, or could not be read
+	CauseInternalError               // vtpc itself crashed
+	CauseUnknown                     // Any other failure
+)
+
+// String returns a short human-readable description of the cause
+func (c Cause) String() string {
+	switch c {
+	case CauseNone:
+		return "success"
+	case CauseCompileErrors:
+		return "compile errors"
+	case CauseCancelled:
+		return "compile cancelled"
+	case CauseCompileTimeout:
+		return "compile timed out"
+	case CauseVTProNotReady:
+		return "VTPro did not become ready"
+	case CauseVTProNotFound:
+		return "VTPro not found"
+	case CauseSaveFailed:
+		return "save failed"
+	case CauseInvalidProject:
+		return "not a VTPro project"
+	case CauseEnvironment:
+		return "build machine not ready"
+	case CauseInputBlocked:
+		return "input blocked by an elevated window"
+	case CauseUnknownDialog:
+		return "unexpected dialog"
+	case CauseBadArtifact:
+		return "corrupt artifact"
+	case CauseDeployFailed:
+		return "deployment failed"
+	case CauseBudgetExceeded:
+		return "compile time budget exceeded"
+	case CausePolicy:
+		return "blocked by machine policy"
+	case CauseInternalError:
+		return "vtpc crashed"
+	default:
+		return "unexpected error"
+	}
+}
+
+// Suggestion returns the next step to recommend for a failure cause
+func Suggestion(c Cause) string {
+	switch c {
+	case CauseNone:
+		return ""
+	case CauseCompileErrors:
+		return "Fix the errors listed above in VTPro, then run vtpc again"
+	case CauseCancelled:
+		return "Someone cancelled the compile in VTPro; run vtpc again when the machine is free"
+	case CauseCompileTimeout:
+		return "Check VTPro for a dialog waiting for input, then review the run with: vtpc --logs"
+	case CauseVTProNotReady:
+		return "Close any running VTPro instances, then review the run with: vtpc --logs"
+	case CauseVTProNotFound:
+		return "Install VTPro or set VTPRO_PATH to the full path of vtpro.exe"
+	case CauseSaveFailed:
+		return "Check the project file is not read-only and its drive has free space"
+	case CauseInvalidProject:
+		return "Check the path points at the real .vtp file; for Git LFS pointers run: git lfs pull"
+	case CauseEnvironment:
+		return "Free up disk space or fix permissions on the output directory, or lower --min-free-mb; for a read-only project, pass --out-dir"
+	case CauseInputBlocked:
+		return "Close or finish the elevated window named above, or run vtpc at the same integrity level, then run vtpc again"
+	case CauseInternalError:
+		return "This is a bug in vtpc; please report it with the log from: vtpc --logs"
+	case CauseDeployFailed:
+		return "The compile succeeded; check the panel is reachable and the --deploy user and password, then deploy again"
+	case CausePolicy:
+		return "Move the project under one of the allowed roots, or ask whoever manages this machine to change its vtpc policy"
+	case CauseBudgetExceeded:
+		return "The artifact is good; find what slowed the compile, such as large images added to the project, or raise --max-compile-time"
+	case CauseBadArtifact:
+		return "Do not ship the artifact; compile again, and check the project in VTPro if the check fails again"
+	case CauseUnknownDialog:
+		return "Inspect the dialog left open in VTPro and fix what raised it, then close VTPro and run vtpc again"
+	default:
+		return "Review the run with: vtpc --logs"
+	}
+}
+
+// maxBannerErrors is how many error messages the failure banner lists
+const maxBannerErrors = 5
+
+// bannerRule separates the banner from the log output above it
+var bannerRule = strings.Repeat("=", 60)
+
+// Summary is the outcome of a run as shown in the exit banner
+type Summary struct {
+	Cause         Cause
+	Code          string   // Stable code of the failure from the error catalog, e.g. "VTPC_E_TIMEOUT_COMPILE"
+	Err           error    // The error the run failed with, if any
+	ErrorMessages []string // Compile error messages from the Message Log
+	LogPath       string
+	Artifacts     []string // Compiled artifacts, when known
+	ArtifactFrom  string   // Where the artifacts were found, e.g. "VTPro preferences"
+	DeployedTo    string   // Where --deploy uploaded the artifacts
+	Size          string   // Output size reported by VTPro
+	Targets       []Target // Each panel model's results, shown when there are several
+	Monitor       string   // Window monitor stats, shown when a run fails
+	Window        string   // Why the VTPro main window was chosen, shown when a run fails
+	Concurrent    bool     // A second compile ran alongside vtpc's, so results may include it
+	Duration      time.Duration
+	StartedAt     time.Time // When the run started, from the run's clock
+	FinishedAt    time.Time // When the run finished, from the run's clock
+	AbsoluteTimes bool      // Show StartedAt and FinishedAt in the banner
+}
+
+// WriteBanner writes the exit banner for a run
+func WriteBanner(w io.Writer, s Summary) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, bannerRule)
+
+	if s.Cause == CauseNone {
+		writeSuccess(w, s)
+	} else {
+		writeFailure(w, s)
+	}
+
+	fmt.Fprintln(w, bannerRule)
+}
+
+// writeSuccess writes the body of the banner for a successful run
+func writeSuccess(w io.Writer, s Summary) {
+	fmt.Fprintf(w, " SUCCESS in %s\n", formatDuration(s.Duration))
+	writeTimes(w, s)
+
+	for _, a := range s.Artifacts {
+		if s.ArtifactFrom != "" {
+			fmt.Fprintf(w, " Artifact: %s (from %s)\n", a, s.ArtifactFrom)
+		} else {
+			fmt.Fprintf(w, " Artifact: %s\n", a)
+		}
+	}
+
+	if s.DeployedTo != "" {
+		fmt.Fprintf(w, " Deployed: %s\n", s.DeployedTo)
+	}
+
+	writeTargets(w, s)
+
+	if s.Size != "" {
+		fmt.Fprintf(w, " Size:     %s\n", s.Size)
+	}
+
+	writeConcurrent(w, s)
+}
+
+// writeFailure writes the body of the banner for a failed run
+func writeFailure(w io.Writer, s Summary) {
+	fmt.Fprintf(w, " FAILED: %s (after %s)\n", s.Cause, formatDuration(s.Duration))
+	writeTimes(w, s)
+
+	if s.Err != nil && s.Cause != CauseCompileErrors {
+		fmt.Fprintf(w, " Reason:   %s\n", firstLine(s.Err.Error()))
+	}
+
+	if s.Code != "" {
+		fmt.Fprintf(w, " Code:     %s\n", s.Code)
+	}
+
+	writeTargets(w, s)
+
+	if len(s.ErrorMessages) > 0 {
+		fmt.Fprintln(w, " Errors:")
+
+		for i, msg := range s.ErrorMessages {
+			if i == maxBannerErrors {
+				fmt.Fprintf(w, "   ... and %d more\n", len(s.ErrorMessages)-maxBannerErrors)
+				break
+			}
+
+			fmt.Fprintf(w, "   %d. %s\n", i+1, msg)
+		}
+	}
+
+	writeConcurrent(w, s)
+
+	if s.Window != "" {
+		fmt.Fprintf(w, " Window:   %s\n", s.Window)
+	}
+
+	if s.Monitor != "" {
+		fmt.Fprintf(w, " Monitor:  %s\n", s.Monitor)
+	}
+
+	if s.LogPath != "" {
+		fmt.Fprintf(w, " Log:      %s\n", s.LogPath)
+	}
+
+	fmt.Fprintf(w, " Next:     %s\n", Suggestion(s.Cause))
+}
+
+// writeTargets writes a line for each panel model a project compiles for, if
+// there are several. One target's counts and size are the run's own.
+func writeTargets(w io.Writer, s Summary) {
+	if len(s.Targets) < 2 {
+		return
+	}
+
+	for _, t := range s.Targets {
+		line := fmt.Sprintf("%s: %d warning(s), %d error(s)", t.Name, t.Warnings, t.Errors)
+		if t.Size != "" {
+			line += ", " + t.Size
+		}
+
+		fmt.Fprintf(w, " Target:   %s\n", line)
+	}
+}
+
+// writeConcurrent warns that the results may not be vtpc's alone, if another compile ran
+func writeConcurrent(w io.Writer, s Summary) {
+	if !s.Concurrent {
+		return
+	}
+
+	fmt.Fprintln(w, " Warning:  a second compile ran alongside vtpc's (was F12 pressed in VTPro?); results may reflect it")
+}
+
+// writeTimes writes when the run started and finished, if absolute times were asked for
+func writeTimes(w io.Writer, s Summary) {
+	if !s.AbsoluteTimes || s.StartedAt.IsZero() {
+		return
+	}
+
+	fmt.Fprintf(w, " Started:  %s\n", FormatTimestamp(s.StartedAt))
+	fmt.Fprintf(w, " Finished: %s\n", FormatTimestamp(s.FinishedAt))
+}
+
+// formatDuration rounds a duration for display
+func formatDuration(d time.Duration) string {
+	return d.Round(100 * time.Millisecond).String()
+}
+
+// firstLine returns the first line of a possibly multi-line message
+func firstLine(s string) string {
+	if idx := strings.IndexByte(s, '\n'); idx != -1 {
+		return s[:idx]
+	}
+
+	return s
+}
@@ -0,0 +1,238 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		cause    Cause
+		contains string
+	}{
+		{CauseNone, ""},
+		{CauseCompileErrors, "Fix the errors"},
+		{CauseCancelled, "cancelled the compile"},
+		{CauseCompileTimeout, "vtpc --logs"},
+		{CauseVTProNotReady, "vtpc --logs"},
+		{CauseVTProNotFound, "VTPRO_PATH"},
+		{CauseSaveFailed, "read-only"},
+		{CauseInvalidProject, "git lfs pull"},
+		{CauseEnvironment, "--min-free-mb"},
+		{CauseInputBlocked, "elevated window"},
+		{CauseUnknownDialog, "dialog left open"},
+		{CauseBadArtifact, "Do not ship"},
+		{CauseDeployFailed, "deploy again"},
+		{CauseInternalError, "bug in vtpc"},
+		{CauseUnknown, "vtpc --logs"},
+		{Cause(99), "vtpc --logs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cause.String(), func(t *testing.T) {
+			got := Suggestion(tt.cause)
+
+			if tt.contains == "" {
+				assert.Empty(t, got)
+				return
+			}
+
+			assert.Contains(t, got, tt.contains)
+		})
+	}
+}
+
+func TestCause_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "success", CauseNone.String())
+	assert.Equal(t, "compile errors", CauseCompileErrors.String())
+	assert.Equal(t, "unexpected error", CauseUnknown.String())
+}
+
+func TestWriteBanner_Success(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	WriteBanner(&buf, Summary{
+		Artifacts: []string{`C:\Projects\lobby.vtz`},
+		Size:      "18,588,092 bytes",
+		Duration:  94*time.Second + 230*time.Millisecond,
+	})
+
+	out := buf.String()
+	assert.Contains(t, out, "SUCCESS in 1m34.2s")
+	assert.Contains(t, out, `Artifact: C:\Projects\lobby.vtz`)
+	assert.Contains(t, out, "Size:     18,588,092 bytes")
+	assert.NotContains(t, out, "Next:")
+}
+
+func TestWriteBanner_ListsEachTarget(t *testing.T) {
+	t.Parallel()
+
+	targets := []Target{
+		{Name: "TSW-770", Warnings: 1, Size: "18,588,092 bytes"},
+		{Name: "TSW-1070", Warnings: 2, Errors: 1},
+	}
+
+	var buf bytes.Buffer
+	WriteBanner(&buf, Summary{Targets: targets})
+	assert.Contains(t, buf.String(), " Target:   TSW-770: 1 warning(s), 0 error(s), 18,588,092 bytes\n")
+	assert.Contains(t, buf.String(), " Target:   TSW-1070: 2 warning(s), 1 error(s)\n")
+	assert.NotContains(t, buf.String(), "Size:")
+
+	buf.Reset()
+	WriteBanner(&buf, Summary{Cause: CauseCompileErrors, Targets: targets, ErrorMessages: []string{"does not fit"}})
+	assert.Contains(t, buf.String(), " Target:   TSW-1070: 2 warning(s), 1 error(s)\n")
+
+	buf.Reset()
+	WriteBanner(&buf, Summary{Targets: targets[:1], Size: "18,588,092 bytes"})
+	assert.NotContains(t, buf.String(), "Target:", "a single target is the run itself")
+}
+
+func TestWriteBanner_SuccessShowsArtifactSource(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	WriteBanner(&buf, Summary{
+		Artifacts:    []string{`D:\Builds\lobby.vtz`},
+		ArtifactFrom: "VTPro preferences",
+	})
+
+	assert.Contains(t, buf.String(), `Artifact: D:\Builds\lobby.vtz (from VTPro preferences)`)
+}
+
+func TestWriteBanner_CompileErrorsListsFirstFew(t *testing.T) {
+	t.Parallel()
+
+	msgs := make([]string, 0, 7)
+	for i := 1; i <= 7; i++ {
+		msgs = append(msgs, fmt.Sprintf("error %d", i))
+	}
+
+	var buf bytes.Buffer
+	WriteBanner(&buf, Summary{
+		Cause:         CauseCompileErrors,
+		Err:           errors.New("compilation failed with 7 error(s)"),
+		ErrorMessages: msgs,
+		LogPath:       `C:\Users\me\AppData\Local\vtpc\vtpc.log`,
+		Duration:      time.Minute,
+	})
+
+	out := buf.String()
+	assert.Contains(t, out, "FAILED: compile errors (after 1m0s)")
+	assert.Contains(t, out, "5. error 5")
+	assert.NotContains(t, out, "error 6")
+	assert.Contains(t, out, "... and 2 more")
+	assert.Contains(t, out, `Log:      C:\Users\me\AppData\Local\vtpc\vtpc.log`)
+	assert.Contains(t, out, "Next:     "+Suggestion(CauseCompileErrors))
+	assert.NotContains(t, out, "Reason:", "the error list already explains compile errors")
+}
+
+func TestWriteBanner_FailureShowsFirstLineOfReason(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	WriteBanner(&buf, Summary{
+		Cause: CauseVTProNotFound,
+		Err:   errors.New("VTPro not found at default path: C:\\vtpro.exe\nPlease install VTPro"),
+	})
+
+	out := buf.String()
+	assert.Contains(t, out, `Reason:   VTPro not found at default path: C:\vtpro.exe`)
+	assert.NotContains(t, out, "Please install VTPro")
+	assert.Contains(t, out, "VTPRO_PATH")
+}
+
+func TestWriteBanner_FailureShowsCode(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	WriteBanner(&buf, Summary{
+		Cause: CauseCompileTimeout,
+		Code:  "VTPC_E_TIMEOUT_COMPILE",
+		Err:   errors.New("compilation timeout"),
+	})
+
+	assert.Contains(t, buf.String(), " Code:     VTPC_E_TIMEOUT_COMPILE\n")
+}
+
+func TestWriteBanner_FailureShowsMonitorStats(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	WriteBanner(&buf, Summary{
+		Cause:   CauseCompileTimeout,
+		Monitor: "12 polls, 3 dropped",
+	})
+
+	assert.Contains(t, buf.String(), "Monitor:  12 polls, 3 dropped")
+}
+
+func TestWriteBanner_SuccessHidesMonitorStats(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	WriteBanner(&buf, Summary{Monitor: "12 polls"})
+
+	assert.NotContains(t, buf.String(), "Monitor:")
+}
+
+func TestWriteBanner_FailureShowsWindowSelection(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	WriteBanner(&buf, Summary{
+		Cause:  CauseVTProNotReady,
+		Window: "no main window among 2 candidate(s) for PID 1234",
+	})
+
+	assert.Contains(t, buf.String(), "Window:   no main window among 2 candidate(s) for PID 1234")
+}
+
+func TestWriteBanner_FlagsConcurrentCompile(t *testing.T) {
+	t.Parallel()
+
+	const warning = "Warning:  a second compile ran alongside vtpc's"
+
+	for _, cause := range []Cause{CauseNone, CauseCompileErrors} {
+		var buf bytes.Buffer
+		WriteBanner(&buf, Summary{Cause: cause, Concurrent: true})
+		assert.Contains(t, buf.String(), warning, cause.String())
+
+		buf.Reset()
+		WriteBanner(&buf, Summary{Cause: cause})
+		assert.NotContains(t, buf.String(), warning, cause.String())
+	}
+}
+
+func TestWriteBanner_AbsoluteTimes(t *testing.T) {
+	t.Parallel()
+
+	zone := time.FixedZone("AEST", 10*60*60)
+	started := time.Date(2025, 3, 4, 9, 15, 0, 500, zone)
+
+	s := Summary{
+		Cause:      CauseCompileTimeout,
+		Duration:   90 * time.Second,
+		StartedAt:  started,
+		FinishedAt: started.Add(90 * time.Second),
+	}
+
+	var buf bytes.Buffer
+	WriteBanner(&buf, s)
+	assert.NotContains(t, buf.String(), "Started:", "absolute times are opt-in")
+
+	buf.Reset()
+	s.AbsoluteTimes = true
+	WriteBanner(&buf, s)
+	assert.Contains(t, buf.String(), "Started:  2025-03-04T09:15:00+10:00")
+	assert.Contains(t, buf.String(), "Finished: 2025-03-04T09:16:30+10:00")
+}
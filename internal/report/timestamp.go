@@ -0,0 +1,54 @@
+package report
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimestampLayout is the ISO 8601 / RFC 3339 layout used for every time vtpc
+// prints or writes to a report: second precision with the UTC offset, so times
+// from different runs and machines compare as text.
+const TimestampLayout = "2006-01-02T15:04:05Z07:00"
+
+// FormatTimestamp formats t with TimestampLayout in t's own location, which
+// is machine-local for times from the real clock
+func FormatTimestamp(t time.Time) string {
+	return t.Format(TimestampLayout)
+}
+
+// Timestamp is a time that marshals to JSON as TimestampLayout rather than
+// Go's default RFC 3339 with nanoseconds. The zero Timestamp marshals as null.
+type Timestamp struct {
+	time.Time
+}
+
+// MarshalJSON implements json.Marshaler
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return []byte(`"` + FormatTimestamp(t.Time) + `"`), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return fmt.Errorf("timestamp must be a JSON string, got %s", s)
+	}
+
+	parsed, err := time.Parse(TimestampLayout, s[1:len(s)-1])
+	if err != nil {
+		return fmt.Errorf("invalid timestamp: %w", err)
+	}
+
+	t.Time = parsed
+
+	return nil
+}
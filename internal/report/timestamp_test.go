@@ -0,0 +1,54 @@
+package report
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestamp_MarshalJSONUsesFixedLayout(t *testing.T) {
+	t.Parallel()
+
+	zone := time.FixedZone("", -5*60*60)
+	ts := Timestamp{time.Date(2025, 11, 2, 14, 3, 7, 123456789, zone)}
+
+	data, err := json.Marshal(ts)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"2025-11-02T14:03:07-05:00"`, string(data), "no fractional seconds, offset kept")
+
+	data, err = json.Marshal(Timestamp{time.Date(2025, 11, 2, 14, 3, 7, 0, time.UTC)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `"2025-11-02T14:03:07Z"`, string(data))
+}
+
+func TestTimestamp_ZeroMarshalsAsNull(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(struct {
+		StartedAt Timestamp `json:"startedAt"`
+	}{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"startedAt":null}`, string(data))
+}
+
+func TestTimestamp_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	want := Timestamp{time.Date(2025, 11, 2, 14, 3, 7, 0, time.FixedZone("", 60*60))}
+
+	data, err := json.Marshal(want)
+	require.NoError(t, err)
+
+	var got Timestamp
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.True(t, want.Equal(got.Time))
+
+	assert.Error(t, json.Unmarshal([]byte(`"yesterday"`), &got))
+	assert.Error(t, json.Unmarshal([]byte(`12`), &got))
+
+	require.NoError(t, json.Unmarshal([]byte(`null`), &got))
+	assert.True(t, got.IsZero())
+}
@@ -0,0 +1,74 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Phases of a run, in the order a run goes through them
+const (
+	PhaseLaunch  = "launch"  // Until VTPro was started
+	PhaseWaits   = "waits"   // Until VTPro's main window was ready
+	PhaseCompile = "compile" // The compile itself, up to reading the results
+	PhaseCleanup = "cleanup" // Closing VTPro and collecting artifacts
+)
+
+// Phase is how long one stretch of a run took
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// DialogTime is how long a VTPro dialog stayed open
+type DialogTime struct {
+	Title    string
+	Duration time.Duration
+	Open     bool // Still open when the window monitor stopped
+}
+
+// Timing is where a run's time went
+type Timing struct {
+	Wall     time.Duration // From start to finish of the run
+	Phases   []Phase       // The phases the run reached, in order
+	SelfCPU  time.Duration // CPU time used by vtpc itself
+	VTProCPU time.Duration // CPU time used by VTPro, read as it exited
+	Dialogs  []DialogTime  // The dialogs open longest, longest first, other than the Compiling dialog
+}
+
+// Overhead returns the wall time not spent compiling
+func (t Timing) Overhead() time.Duration {
+	overhead := t.Wall
+
+	for _, p := range t.Phases {
+		if p.Name == PhaseCompile {
+			overhead -= p.Duration
+		}
+	}
+
+	return max(overhead, 0)
+}
+
+// OverheadLine returns a one-line summary of the automation overhead and the
+// phases it was spent in, e.g. "automation overhead: 7.3s (launch 4.1s, waits 2.8s, cleanup 0.4s)".
+// It returns "" when no phases were recorded.
+func (t Timing) OverheadLine() string {
+	if len(t.Phases) == 0 {
+		return ""
+	}
+
+	var parts []string
+
+	for _, p := range t.Phases {
+		if p.Name != PhaseCompile {
+			parts = append(parts, fmt.Sprintf("%s %s", p.Name, formatSeconds(p.Duration)))
+		}
+	}
+
+	return fmt.Sprintf("automation overhead: %s (%s)", formatSeconds(t.Overhead()), strings.Join(parts, ", "))
+}
+
+// formatSeconds formats a duration as seconds to one decimal place, e.g. "0.4s"
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
@@ -0,0 +1,47 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTiming_Overhead(t *testing.T) {
+	t.Parallel()
+
+	timing := Timing{
+		Wall: 19300 * time.Millisecond,
+		Phases: []Phase{
+			{Name: PhaseLaunch, Duration: 4100 * time.Millisecond},
+			{Name: PhaseWaits, Duration: 2800 * time.Millisecond},
+			{Name: PhaseCompile, Duration: 12 * time.Second},
+			{Name: PhaseCleanup, Duration: 400 * time.Millisecond},
+		},
+	}
+
+	assert.Equal(t, 7300*time.Millisecond, timing.Overhead())
+	assert.Equal(t, "automation overhead: 7.3s (launch 4.1s, waits 2.8s, cleanup 0.4s)", timing.OverheadLine())
+}
+
+func TestTiming_OverheadWithoutCompile(t *testing.T) {
+	t.Parallel()
+
+	// A run that failed before compiling was all overhead
+	timing := Timing{
+		Wall: 3 * time.Second,
+		Phases: []Phase{
+			{Name: PhaseLaunch, Duration: 2 * time.Second},
+			{Name: PhaseCleanup, Duration: time.Second},
+		},
+	}
+
+	assert.Equal(t, 3*time.Second, timing.Overhead())
+	assert.Equal(t, "automation overhead: 3.0s (launch 2.0s, cleanup 1.0s)", timing.OverheadLine())
+}
+
+func TestTiming_OverheadLineWithoutPhases(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, Timing{Wall: time.Second}.OverheadLine())
+}
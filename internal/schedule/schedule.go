@@ -0,0 +1,235 @@
+// Package schedule decides when unattended compiles may run, from the
+// maintenance windows in the config file.
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// Build machines without Go installed have no zoneinfo for LoadLocation
+	_ "time/tzdata"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// ErrOutsideWindow is returned when a compile is started outside every allowed window
+var ErrOutsideWindow = errcode.New(errcode.OutsideWindow, "outside the maintenance window")
+
+// Daily is the key of the windows allowed on every day of the week
+const Daily = "daily"
+
+// minutesPerDay is the end of a window that runs to midnight, written "24:00"
+const minutesPerDay = 24 * 60
+
+// lookAhead is how many days Next searches, so a window found on the same
+// weekday next week is still reached
+const lookAhead = 8
+
+// Window is an allowed period of a day, in minutes since midnight. A window
+// whose end is before its start runs past midnight into the next day.
+type Window struct {
+	Start int
+	End   int
+}
+
+// ParseWindow parses a window written "HH:MM-HH:MM", e.g. "19:00-07:00"
+func ParseWindow(s string) (Window, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid window %q: want HH:MM-HH:MM", s)
+	}
+
+	start, err := parseClock(strings.TrimSpace(from), false)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+
+	end, err := parseClock(strings.TrimSpace(to), true)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+
+	if start == end {
+		return Window{}, fmt.Errorf("invalid window %q: start and end are the same", s)
+	}
+
+	return Window{Start: start, End: end}, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight. "24:00" is only
+// accepted as the end of a window.
+func parseClock(s string, end bool) (int, error) {
+	hh, mm, ok := strings.Cut(s, ":")
+	if !ok || len(hh) == 0 || len(hh) > 2 || len(mm) != 2 {
+		return 0, fmt.Errorf("time %q is not HH:MM", s)
+	}
+
+	h, herr := strconv.Atoi(hh)
+	m, merr := strconv.Atoi(mm)
+
+	if herr != nil || merr != nil || h < 0 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("time %q is not HH:MM", s)
+	}
+
+	minutes := h*60 + m
+	if minutes > minutesPerDay || (minutes == minutesPerDay && !end) {
+		return 0, fmt.Errorf("time %q is past midnight", s)
+	}
+
+	return minutes, nil
+}
+
+// String formats the window as it is written in the config file
+func (w Window) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+// span returns when the window opens and closes on the given day. Times are
+// built from the clock reading, so a window keeps its wall clock times across
+// daylight saving changes.
+func (w Window) span(day time.Time) (open, close time.Time) {
+	y, m, d := day.Date()
+	loc := day.Location()
+
+	open = time.Date(y, m, d, 0, w.Start, 0, 0, loc)
+
+	if w.End < w.Start {
+		return open, time.Date(y, m, d+1, 0, w.End, 0, 0, loc)
+	}
+
+	return open, time.Date(y, m, d, 0, w.End, 0, 0, loc)
+}
+
+// Schedule is the set of windows compiles are allowed in, per weekday. A
+// window belongs to the day it opens, so Friday's "19:00-07:00" allows
+// Saturday morning too.
+type Schedule struct {
+	loc  *time.Location
+	days [7][]Window
+}
+
+// New builds a Schedule from windows keyed by lower-case weekday name, or
+// Daily for every day, in the named IANA timezone. An empty timezone is the
+// machine's local time.
+func New(timezone string, windows map[string][]string) (*Schedule, error) {
+	loc := time.Local
+
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("invalid schedule timezone %q: %w", timezone, err)
+		}
+	}
+
+	s := &Schedule{loc: loc}
+
+	for key, specs := range windows {
+		days, err := parseDays(key)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, spec := range specs {
+			w, err := ParseWindow(spec)
+			if err != nil {
+				return nil, fmt.Errorf("schedule %s: %w", key, err)
+			}
+
+			for _, d := range days {
+				s.days[d] = append(s.days[d], w)
+			}
+		}
+	}
+
+	for d := range s.days {
+		sort.Slice(s.days[d], func(i, j int) bool { return s.days[d][i].Start < s.days[d][j].Start })
+	}
+
+	return s, nil
+}
+
+// parseDays returns the weekdays a schedule key stands for
+func parseDays(key string) ([]time.Weekday, error) {
+	if strings.EqualFold(key, Daily) {
+		return []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}, nil
+	}
+
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(key, d.String()) {
+			return []time.Weekday{d}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid schedule day %q: want a weekday name or %q", key, Daily)
+}
+
+// Location returns the timezone the windows are in
+func (s *Schedule) Location() *time.Location {
+	return s.loc
+}
+
+// Allows reports whether t falls inside an allowed window
+func (s *Schedule) Allows(t time.Time) bool {
+	next, ok := s.Next(t)
+	return ok && next.Equal(t)
+}
+
+// Check returns ErrOutsideWindow, saying when the next window opens, if t is
+// not inside an allowed window
+func (s *Schedule) Check(t time.Time) error {
+	next, ok := s.Next(t)
+	if !ok {
+		return fmt.Errorf("%w: the schedule has no windows", ErrOutsideWindow)
+	}
+
+	if next.Equal(t) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: the next window opens %s (in %s)", ErrOutsideWindow, FormatOpening(next), FormatWait(next.Sub(t)))
+}
+
+// FormatOpening formats when a window opens, e.g. "Mon 19:00 GMT"
+func FormatOpening(t time.Time) string {
+	return t.Format("Mon 15:04 MST")
+}
+
+// FormatWait rounds a wait to the minute, e.g. "3h12m" rather than "3h12m0s"
+func FormatWait(d time.Duration) string {
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
+
+// Next returns the first instant at or after t that an allowed window is
+// open: t itself inside a window, otherwise when the next one opens. It
+// returns false if the schedule has no windows.
+func (s *Schedule) Next(t time.Time) (time.Time, bool) {
+	t = t.In(s.loc)
+
+	var (
+		next  time.Time
+		found bool
+	)
+
+	// Start the day before, whose window may still be open past midnight
+	for offset := -1; offset < lookAhead; offset++ {
+		y, m, d := t.Date()
+		day := time.Date(y, m, d+offset, 0, 0, 0, 0, s.loc)
+
+		for _, w := range s.days[day.Weekday()] {
+			open, close := w.span(day)
+
+			if !t.Before(open) && t.Before(close) {
+				return t, true
+			}
+
+			if open.After(t) && (!found || open.Before(next)) {
+				next, found = open, true
+			}
+		}
+	}
+
+	return next, found
+}
@@ -0,0 +1,220 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// london has daylight saving, which the windows must follow
+var london = mustLocation("Europe/London")
+
+func mustLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return loc
+}
+
+// at returns a time in London; 2025-03-03 is a Monday
+func at(day, hour, minute int) time.Time {
+	return time.Date(2025, time.March, day, hour, minute, 0, 0, london)
+}
+
+func mustSchedule(t *testing.T, windows map[string][]string) *Schedule {
+	t.Helper()
+
+	s, err := New("Europe/London", windows)
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestParseWindow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    Window
+		wantErr string
+	}{
+		{in: "19:00-07:00", want: Window{Start: 19 * 60, End: 7 * 60}},
+		{in: "09:30-17:45", want: Window{Start: 9*60 + 30, End: 17*60 + 45}},
+		{in: " 7:00 - 8:00 ", want: Window{Start: 7 * 60, End: 8 * 60}},
+		{in: "00:00-24:00", want: Window{Start: 0, End: 24 * 60}},
+		{in: "19:00", wantErr: "want HH:MM-HH:MM"},
+		{in: "19:00-19:00", wantErr: "start and end are the same"},
+		{in: "24:00-07:00", wantErr: `time "24:00" is past midnight`},
+		{in: "19:00-24:30", wantErr: `time "24:30" is past midnight`},
+		{in: "19:60-07:00", wantErr: `time "19:60" is not HH:MM`},
+		{in: "7pm-7am", wantErr: `time "7pm" is not HH:MM`},
+		{in: "19:0-07:00", wantErr: `time "19:0" is not HH:MM`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseWindow(tt.in)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWindow_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "19:00-07:05", Window{Start: 19 * 60, End: 7*60 + 5}.String())
+	assert.Equal(t, "00:00-24:00", Window{Start: 0, End: 24 * 60}.String())
+}
+
+func TestNew_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := New("Mars/Olympus_Mons", map[string][]string{Daily: {"19:00-07:00"}})
+	assert.ErrorContains(t, err, `invalid schedule timezone "Mars/Olympus_Mons"`)
+
+	_, err = New("", map[string][]string{"weekdays": {"19:00-07:00"}})
+	assert.ErrorContains(t, err, `invalid schedule day "weekdays"`)
+
+	_, err = New("", map[string][]string{"monday": {"19:00"}})
+	assert.ErrorContains(t, err, "schedule monday: invalid window")
+}
+
+func TestSchedule_Allows(t *testing.T) {
+	t.Parallel()
+
+	s := mustSchedule(t, map[string][]string{Daily: {"19:00-07:00"}})
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"evening", at(3, 20, 0), true},
+		{"opening minute", at(3, 19, 0), true},
+		{"just before opening", at(3, 18, 59), false},
+		{"after midnight", at(4, 3, 0), true},
+		{"closing minute", at(4, 7, 0), false},
+		{"just before closing", at(4, 6, 59), true},
+		{"working day", at(4, 12, 0), false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, s.Allows(tt.t), tt.name)
+	}
+}
+
+func TestSchedule_AllowsInAnotherTimezone(t *testing.T) {
+	t.Parallel()
+
+	s := mustSchedule(t, map[string][]string{Daily: {"19:00-07:00"}})
+
+	// 14:30 in New York is 19:30 in London
+	newYork := mustLocation("America/New_York")
+	assert.True(t, s.Allows(time.Date(2025, time.March, 3, 14, 30, 0, 0, newYork)))
+	assert.False(t, s.Allows(time.Date(2025, time.March, 3, 13, 30, 0, 0, newYork)))
+}
+
+func TestSchedule_WindowBelongsToTheDayItOpens(t *testing.T) {
+	t.Parallel()
+
+	s := mustSchedule(t, map[string][]string{"friday": {"19:00-07:00"}})
+
+	assert.True(t, s.Allows(at(7, 23, 0)), "Friday evening")
+	assert.True(t, s.Allows(at(8, 6, 0)), "Saturday morning, in Friday's window")
+	assert.False(t, s.Allows(at(8, 20, 0)), "Saturday evening")
+	assert.False(t, s.Allows(at(7, 6, 0)), "Friday morning would be Thursday's window")
+}
+
+func TestSchedule_Next(t *testing.T) {
+	t.Parallel()
+
+	s := mustSchedule(t, map[string][]string{
+		"monday":   {"19:00-07:00"},
+		"Saturday": {"00:00-24:00"},
+		"sunday":   {"12:00-14:00", "08:00-10:00"},
+	})
+
+	tests := []struct {
+		name string
+		from time.Time
+		want time.Time
+	}{
+		{"inside a window is now", at(3, 22, 0), at(3, 22, 0)},
+		{"later the same day", at(3, 9, 0), at(3, 19, 0)},
+		{"across midnight into the next window", at(4, 7, 0), at(8, 0, 0)},
+		{"the earlier of two windows", at(9, 7, 0), at(9, 8, 0)},
+		{"between two windows", at(9, 10, 0), at(9, 12, 0)},
+		{"across the week boundary", at(9, 14, 0), at(10, 19, 0)},
+		{"a week ahead from just after closing", at(4, 7, 1), at(8, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		got, ok := s.Next(tt.from)
+		require.True(t, ok, tt.name)
+		assert.True(t, tt.want.Equal(got), "%s: got %s, want %s", tt.name, got, tt.want)
+	}
+}
+
+func TestSchedule_NextSameWeekdayNextWeek(t *testing.T) {
+	t.Parallel()
+
+	s := mustSchedule(t, map[string][]string{"monday": {"19:00-20:00"}})
+
+	got, ok := s.Next(at(3, 20, 0))
+	require.True(t, ok)
+	assert.True(t, at(10, 19, 0).Equal(got), "got %s", got)
+}
+
+func TestSchedule_NextFollowsDaylightSaving(t *testing.T) {
+	t.Parallel()
+
+	// The clocks in London go forward at 01:00 on 30 March 2025
+	s := mustSchedule(t, map[string][]string{Daily: {"19:00-07:00"}})
+
+	got, ok := s.Next(time.Date(2025, time.March, 30, 12, 0, 0, 0, london))
+	require.True(t, ok)
+	assert.Equal(t, "2025-03-30 19:00 BST", got.Format("2006-01-02 15:04 MST"))
+}
+
+func TestSchedule_NoWindows(t *testing.T) {
+	t.Parallel()
+
+	s := mustSchedule(t, nil)
+
+	_, ok := s.Next(at(3, 12, 0))
+	assert.False(t, ok)
+	assert.False(t, s.Allows(at(3, 12, 0)))
+	assert.ErrorIs(t, s.Check(at(3, 12, 0)), ErrOutsideWindow)
+}
+
+func TestSchedule_Check(t *testing.T) {
+	t.Parallel()
+
+	s := mustSchedule(t, map[string][]string{Daily: {"19:00-07:00"}})
+
+	assert.NoError(t, s.Check(at(3, 20, 0)))
+
+	err := s.Check(at(3, 15, 48))
+	require.ErrorIs(t, err, ErrOutsideWindow)
+	assert.EqualError(t, err, "outside the maintenance window: the next window opens Mon 19:00 GMT (in 3h12m)")
+}
+
+func TestFormatWait(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "3h12m", FormatWait(3*time.Hour+12*time.Minute+10*time.Second))
+	assert.Equal(t, "45m", FormatWait(45*time.Minute))
+	assert.Equal(t, "1h0m", FormatWait(time.Hour))
+}
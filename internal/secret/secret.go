@@ -0,0 +1,212 @@
+// Package secret resolves passwords and tokens given as references, such as
+// env:NAME, so their values never appear on the command line, and masks the
+// resolved values wherever vtpc writes text.
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// Masked replaces a secret's value in logs and reports
+const Masked = "****"
+
+var (
+	// ErrInvalidReference is returned for a value that is not env:NAME, file:PATH or stdin:
+	ErrInvalidReference = errcode.New(errcode.SecretReference, "invalid secret reference")
+
+	// ErrUnresolved is returned when a reference is valid but its value cannot be read
+	ErrUnresolved = errcode.New(errcode.SecretUnresolved, "could not resolve secret")
+)
+
+// Sources are where references are resolved from
+type Sources struct {
+	LookupEnv func(key string) (string, bool)
+	ReadFile  func(path string) ([]byte, error)
+	Stdin     io.Reader
+}
+
+// OSSources resolves references from the process's environment, files and stdin
+func OSSources() Sources {
+	return Sources{
+		LookupEnv: os.LookupEnv,
+		ReadFile:  os.ReadFile,
+		Stdin:     pipedStdin{},
+	}
+}
+
+// pipedStdin reads os.Stdin, but only when something is piped into it. Reading
+// a console would wait for input nobody knows to type, such as in the elevated
+// window an auto-elevated relaunch opens.
+type pipedStdin struct{}
+
+func (pipedStdin) Read(p []byte) (int, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return 0, errors.New("stdin is a console, pipe the secret in instead")
+	}
+
+	return os.Stdin.Read(p)
+}
+
+// Store resolves references and remembers every secret it has seen so they can
+// be masked. It is safe for concurrent use.
+type Store struct {
+	src Sources
+
+	mu        sync.Mutex
+	values    []string // Longest first, so a secret containing another is masked whole
+	stdinRead bool
+}
+
+// NewStore creates a store that resolves references from src
+func NewStore(src Sources) *Store {
+	return &Store{src: src}
+}
+
+// IsReference reports whether s has the form of a secret reference
+func IsReference(s string) bool {
+	kind, _, ok := strings.Cut(s, ":")
+	if !ok {
+		return false
+	}
+
+	switch kind {
+	case "env", "file", "stdin":
+		return true
+	default:
+		return false
+	}
+}
+
+// Resolve returns the secret ref refers to and adds it to the values masked:
+//
+//   - env:NAME reads the environment variable NAME
+//   - file:PATH reads the file at PATH
+//   - stdin: reads all of standard input, so it can be used once per run
+//
+// A trailing line ending is removed from files and stdin. An empty secret is an error.
+func (s *Store) Resolve(ref string) (string, error) {
+	kind, arg, _ := strings.Cut(ref, ":")
+
+	var (
+		value string
+		err   error
+	)
+
+	switch {
+	case kind == "env" && arg != "":
+		value, err = s.env(arg)
+	case kind == "file" && arg != "":
+		value, err = s.file(arg)
+	case kind == "stdin" && arg == "":
+		value, err = s.stdin()
+	default:
+		return "", fmt.Errorf("%w %q: must be env:NAME, file:PATH or stdin:", ErrInvalidReference, ref)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if value == "" {
+		return "", fmt.Errorf("%w: %s is empty", ErrUnresolved, ref)
+	}
+
+	s.Add(value)
+
+	return value, nil
+}
+
+// env reads an environment variable
+func (s *Store) env(name string) (string, error) {
+	value, ok := s.src.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%w: environment variable %s is not set", ErrUnresolved, name)
+	}
+
+	return value, nil
+}
+
+// file reads a file
+func (s *Store) file(path string) (string, error) {
+	data, err := s.src.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: could not read %s: %w", ErrUnresolved, path, err)
+	}
+
+	return trimLineEnding(string(data)), nil
+}
+
+// stdin reads all of standard input, which only has one secret to give
+func (s *Store) stdin() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stdinRead {
+		return "", fmt.Errorf("%w: stdin: can only be used once", ErrUnresolved)
+	}
+
+	s.stdinRead = true
+
+	data, err := io.ReadAll(s.src.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("%w: could not read stdin: %w", ErrUnresolved, err)
+	}
+
+	return trimLineEnding(string(data)), nil
+}
+
+// trimLineEnding removes one trailing newline, as left by echo or an editor
+func trimLineEnding(s string) string {
+	s = strings.TrimSuffix(s, "\n")
+	return strings.TrimSuffix(s, "\r")
+}
+
+// Add adds a secret obtained some other way, such as the password in a URL,
+// to the values masked. Empty values are ignored.
+func (s *Store) Add(value string) {
+	if value == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, v := range s.values {
+		if v == value {
+			return
+		}
+	}
+
+	s.values = append(s.values, value)
+	sort.SliceStable(s.values, func(i, j int) bool { return len(s.values[i]) > len(s.values[j]) })
+}
+
+// Mask returns text with every secret in the store replaced by Masked
+func (s *Store) Mask(text string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, v := range s.values {
+		text = strings.ReplaceAll(text, v, Masked)
+	}
+
+	return text
+}
+
+// MaskAll returns a copy of args with Mask applied to each
+func (s *Store) MaskAll(args []string) []string {
+	masked := make([]string, len(args))
+	for i, arg := range args {
+		masked[i] = s.Mask(arg)
+	}
+
+	return masked
+}
@@ -0,0 +1,61 @@
+package telemetry
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InstallIDFile is the name of the file the install ID is kept in, next to the log file
+const InstallIDFile = "telemetry-id"
+
+// InstallID returns the install ID kept at path, creating one from r the first
+// time. The ID is random, so it tells runs of one install apart from another's
+// without saying anything about the machine.
+func InstallID(path string, r io.Reader) (string, error) {
+	if id := LoadInstallID(path); id != "" {
+		return id, nil
+	}
+
+	// A missing or damaged ID is replaced
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", fmt.Errorf("failed to create telemetry install ID: %w", err)
+	}
+
+	id := hex.EncodeToString(b)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to save telemetry install ID: %w", err)
+	}
+
+	if err := os.WriteFile(path, []byte(id+"\n"), 0o644); err != nil {
+		return "", fmt.Errorf("failed to save telemetry install ID: %w", err)
+	}
+
+	return id, nil
+}
+
+// LoadInstallID returns the install ID kept at path, "" if there is none yet
+func LoadInstallID(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	return match(installIDPattern, strings.TrimSpace(string(data)))
+}
+
+// ForgetInstallID removes the install ID at path, so telemetry turned back on
+// starts as a new install. A missing ID is not an error.
+func ForgetInstallID(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove telemetry install ID: %w", err)
+	}
+
+	return nil
+}
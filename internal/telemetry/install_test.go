@@ -0,0 +1,64 @@
+package telemetry
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallID(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "vtpc", InstallIDFile)
+
+	id, err := InstallID(path, bytes.NewReader(bytes.Repeat([]byte{0xab}, 16)))
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("ab", 16), id)
+
+	// Kept, so later runs report the same install
+	again, err := InstallID(path, bytes.NewReader(bytes.Repeat([]byte{0xcd}, 16)))
+	require.NoError(t, err)
+	assert.Equal(t, id, again)
+	assert.Equal(t, id, LoadInstallID(path))
+}
+
+func TestLoadInstallID_None(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, LoadInstallID(filepath.Join(t.TempDir(), InstallIDFile)))
+}
+
+func TestInstallID_ReplacesDamagedID(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), InstallIDFile)
+	require.NoError(t, os.WriteFile(path, []byte(`C:\Users\jsmith`), 0o644))
+
+	id, err := InstallID(path, bytes.NewReader(bytes.Repeat([]byte{0x01}, 16)))
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("01", 16), id)
+}
+
+func TestInstallID_NoRandomness(t *testing.T) {
+	t.Parallel()
+
+	_, err := InstallID(filepath.Join(t.TempDir(), InstallIDFile), bytes.NewReader(nil))
+	assert.Error(t, err)
+}
+
+func TestForgetInstallID(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), InstallIDFile)
+	require.NoError(t, os.WriteFile(path, []byte(installID+"\n"), 0o644))
+
+	require.NoError(t, ForgetInstallID(path))
+	assert.NoFileExists(t, path)
+
+	assert.NoError(t, ForgetInstallID(path), "an ID already gone is not an error")
+}
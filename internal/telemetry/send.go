@@ -0,0 +1,59 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ValidateEndpoint checks that endpoint is an http or https URL with a host
+func ValidateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid telemetry endpoint %q: %w", endpoint, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid telemetry endpoint %q: must be an http or https URL", endpoint)
+	}
+
+	return nil
+}
+
+// Send posts p to endpoint as JSON. ctx bounds how long it may take; the
+// response body is ignored, and any status other than 2xx is an error.
+func Send(ctx context.Context, client *http.Client, endpoint string, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "vtpc/"+p.VtpcVersion)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	// Drained so the connection can be reused, but never read in full
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("telemetry endpoint returned " + resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSend(t *testing.T) {
+	t.Parallel()
+
+	var got Payload
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	p := Payload{InstallID: installID, VtpcVersion: "1.4.0", Outcome: "ok", Duration: "<30s"}
+
+	require.NoError(t, Send(context.Background(), srv.Client(), srv.URL, p))
+	assert.Equal(t, p, got)
+}
+
+func TestSend_ErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "nope", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	err := Send(context.Background(), srv.Client(), srv.URL, Payload{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+}
+
+func TestSend_Unreachable(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	assert.Error(t, Send(context.Background(), http.DefaultClient, url, Payload{}))
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ValidateEndpoint("https://telemetry.example.com/v1/runs"))
+	assert.NoError(t, ValidateEndpoint("http://10.0.0.5:8080/"))
+
+	for _, endpoint := range []string{"", "telemetry.example.com", "ftp://telemetry.example.com", "https://", "://bad"} {
+		assert.Error(t, ValidateEndpoint(endpoint), endpoint)
+	}
+}
@@ -0,0 +1,137 @@
+// Package telemetry builds and sends the anonymous summary of a run that users
+// who opt in with "telemetry: enabled" share, so fixes can be prioritised by
+// the failures seen in the field. A payload only ever holds versions, counts,
+// duration buckets and names from fixed sets; nothing from the project, the
+// machine's files or its users is sent.
+package telemetry
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/report"
+)
+
+// Payload is the summary of one run, as posted to the endpoint
+type Payload struct {
+	InstallID       string         `json:"installId"`                 // Random ID of this vtpc install, not derived from the machine
+	VtpcVersion     string         `json:"vtpcVersion"`               // "dev" for builds without a release or pre-release version
+	VTProVersion    string         `json:"vtproVersion,omitempty"`    // Empty if it could not be read
+	OSBuild         string         `json:"osBuild,omitempty"`         // Windows version and build, e.g. "10.0.22631"
+	Outcome         string         `json:"outcome"`                   // Status of the run, e.g. "ok" or "timeout"
+	Code            string         `json:"code,omitempty"`            // Error catalog code of the failure, empty if the run succeeded
+	Duration        string         `json:"duration"`                  // Bucket of the run's wall time
+	CompileDuration string         `json:"compileDuration,omitempty"` // Bucket of the compile phase, empty if the run never compiled
+	InputMethod     string         `json:"inputMethod,omitempty"`     // Input method that sent the compile keystroke, "none" if every one failed
+	InputFallbacks  int            `json:"inputFallbacks,omitempty"`  // Input methods that failed before it
+	Dialogs         map[string]int `json:"dialogs,omitempty"`         // Dialogs seen, by the name of the known dialog they were
+}
+
+// Environment is what a payload needs that the run's report does not hold
+type Environment struct {
+	InstallID    string
+	VtpcVersion  string
+	VTProVersion string
+	OSBuild      string
+	Dialogs      map[string]int // Counts keyed by dialog registry names, or "unknown"
+}
+
+// inputMethods are the methods the compile keystroke can be sent with, as
+// compiler.InputMethod names them. Anything else is sent as "other".
+var inputMethods = map[string]bool{"sendinput": true, "keybd_event": true, "postmessage": true}
+
+var (
+	installIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	semverPattern    = regexp.MustCompile(`^v?[0-9]+\.[0-9]+\.[0-9]+(-(alpha|beta|rc)(\.?[0-9]+)*)?$`)
+	buildPattern     = regexp.MustCompile(`^[0-9]+(\.[0-9]+){0,3}$`)
+	dialogPattern    = regexp.MustCompile(`^[a-z][a-z0-9-]{0,31}$`)
+)
+
+// durationBuckets are the upper bounds a duration is rounded up to
+var durationBuckets = []struct {
+	limit time.Duration
+	name  string
+}{
+	{30 * time.Second, "<30s"},
+	{time.Minute, "30s-1m"},
+	{2 * time.Minute, "1m-2m"},
+	{5 * time.Minute, "2m-5m"},
+	{10 * time.Minute, "5m-10m"},
+	{30 * time.Minute, "10m-30m"},
+}
+
+// NewPayload returns the summary of run. Every value is checked against the
+// form it must have, so a path, user name or message that found its way into
+// run or env is dropped rather than sent.
+func NewPayload(run *report.Run, env Environment) Payload {
+	p := Payload{
+		InstallID:    match(installIDPattern, env.InstallID),
+		VtpcVersion:  match(semverPattern, env.VtpcVersion),
+		VTProVersion: match(buildPattern, env.VTProVersion),
+		OSBuild:      match(buildPattern, env.OSBuild),
+		Outcome:      report.StatusFor(run.Summary.Cause),
+		Duration:     Bucket(run.Summary.Duration),
+	}
+
+	if p.VtpcVersion == "" {
+		p.VtpcVersion = "dev"
+	}
+
+	if _, ok := errcode.Describe(errcode.Code(run.Summary.Code)); ok {
+		p.Code = run.Summary.Code
+	}
+
+	for _, phase := range run.Timing.Phases {
+		if phase.Name == report.PhaseCompile {
+			p.CompileDuration = Bucket(phase.Duration)
+		}
+	}
+
+	if k := run.Keystroke; k.Method != "" || len(k.Failed) > 0 {
+		switch {
+		case k.Method == "":
+			p.InputMethod = "none"
+		case inputMethods[k.Method]:
+			p.InputMethod = k.Method
+		default:
+			p.InputMethod = "other"
+		}
+
+		p.InputFallbacks = len(k.Failed)
+	}
+
+	for name, n := range env.Dialogs {
+		if n <= 0 || !dialogPattern.MatchString(name) {
+			continue
+		}
+
+		if p.Dialogs == nil {
+			p.Dialogs = make(map[string]int)
+		}
+
+		p.Dialogs[name] = n
+	}
+
+	return p
+}
+
+// Bucket returns the range d falls in, so a payload never carries exact timings
+func Bucket(d time.Duration) string {
+	for _, b := range durationBuckets {
+		if d < b.limit {
+			return b.name
+		}
+	}
+
+	return ">30m"
+}
+
+// match returns s if it matches pattern, and "" otherwise
+func match(pattern *regexp.Regexp, s string) string {
+	if pattern.MatchString(s) {
+		return s
+	}
+
+	return ""
+}
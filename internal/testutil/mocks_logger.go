@@ -0,0 +1,57 @@
+package testutil
+
+import (
+	"fmt"
+	"sync"
+)
+
+// LogEntry is one call recorded by MockLogger
+type LogEntry struct {
+	Level   string
+	Message string
+	Args    []any
+}
+
+// String formats the entry with its arguments so entries can be compared in tests
+func (e LogEntry) String() string {
+	return fmt.Sprintf("%s %s %v", e.Level, e.Message, e.Args)
+}
+
+// MockLogger records every log call so tests can assert on what was logged
+type MockLogger struct {
+	mu      sync.Mutex
+	Entries []LogEntry
+}
+
+// NewMockLogger creates a new MockLogger
+func NewMockLogger() *MockLogger {
+	return &MockLogger{}
+}
+
+func (m *MockLogger) Trace(msg string, args ...any) { m.record("TRACE", msg, args) }
+func (m *MockLogger) Debug(msg string, args ...any) { m.record("DEBUG", msg, args) }
+func (m *MockLogger) Info(msg string, args ...any)  { m.record("INFO", msg, args) }
+func (m *MockLogger) Warn(msg string, args ...any)  { m.record("WARN", msg, args) }
+func (m *MockLogger) Error(msg string, args ...any) { m.record("ERROR", msg, args) }
+func (m *MockLogger) Close()                        {}
+func (m *MockLogger) GetLogPath() string            { return "" }
+
+// Messages returns the recorded messages in order, without their arguments
+func (m *MockLogger) Messages() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	msgs := make([]string, 0, len(m.Entries))
+	for _, e := range m.Entries {
+		msgs = append(msgs, e.Message)
+	}
+
+	return msgs
+}
+
+func (m *MockLogger) record(level, msg string, args []any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Entries = append(m.Entries, LogEntry{Level: level, Message: msg, Args: args})
+}
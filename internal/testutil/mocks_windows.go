@@ -1,6 +1,7 @@
 package testutil
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/Norgate-AV/vtpc/internal/windows"
@@ -11,6 +12,9 @@ type MockWindowManager struct {
 	CloseWindowCalls             []CloseWindowCall
 	SetForegroundCalls           []uintptr
 	SetForegroundResult          bool
+	RestoreWindowCalls           []uintptr
+	MinimizeWindowCalls          []uintptr
+	WindowOps                    []string // SetForeground, RestoreWindow and MinimizeWindow calls, in order
 	VerifyForegroundWindowResult bool
 	IsElevatedResult             bool
 	ChildInfos                   []windows.ChildInfo
@@ -18,7 +22,22 @@ type MockWindowManager struct {
 	WaitOnMonitorResults         []WaitOnMonitorResult
 	currentWaitIndex             int
 	WindowValidityMap            map[uintptr]bool
+	WindowValiditySequences      map[uintptr][]bool // IsWindowValid results per hwnd, in order; the last one repeats
 	WindowTextMap                map[uintptr]string
+	WindowPidMap                 map[uintptr]uint32
+	ForegroundSequence           []uintptr // Windows GetForegroundWindow reports, in order
+	foregroundIndex              int
+	IntegrityMap                 map[uint32]windows.IntegrityLevel // Process integrity levels; others cannot be read
+	ProcessNameMap               map[uint32]string
+	WindowRectMap                map[uintptr]windows.RECT // Window bounds; others cannot be read
+	VirtualScreen                windows.RECT
+	PrimaryScreen                windows.RECT
+	MoveWindowCalls              []MoveWindowCall
+}
+
+type MoveWindowCall struct {
+	Hwnd uintptr
+	X, Y int32
 }
 
 type CloseWindowCall struct {
@@ -42,7 +61,12 @@ func NewMockWindowManager() *MockWindowManager {
 		ChildInfos:                   []windows.ChildInfo{},
 		ChildInfosMap:                make(map[uintptr][]windows.ChildInfo),
 		WindowValidityMap:            make(map[uintptr]bool),
+		WindowValiditySequences:      make(map[uintptr][]bool),
 		WindowTextMap:                make(map[uintptr]string),
+		WindowPidMap:                 make(map[uintptr]uint32),
+		IntegrityMap:                 make(map[uint32]windows.IntegrityLevel),
+		ProcessNameMap:               make(map[uint32]string),
+		WindowRectMap:                make(map[uintptr]windows.RECT),
 	}
 }
 
@@ -52,9 +76,22 @@ func (m *MockWindowManager) CloseWindow(hwnd uintptr, title string) {
 
 func (m *MockWindowManager) SetForeground(hwnd uintptr) bool {
 	m.SetForegroundCalls = append(m.SetForegroundCalls, hwnd)
+	m.WindowOps = append(m.WindowOps, "SetForeground")
 	return m.SetForegroundResult
 }
 
+func (m *MockWindowManager) RestoreWindow(hwnd uintptr) bool {
+	m.RestoreWindowCalls = append(m.RestoreWindowCalls, hwnd)
+	m.WindowOps = append(m.WindowOps, "RestoreWindow")
+	return true
+}
+
+func (m *MockWindowManager) MinimizeWindow(hwnd uintptr) bool {
+	m.MinimizeWindowCalls = append(m.MinimizeWindowCalls, hwnd)
+	m.WindowOps = append(m.WindowOps, "MinimizeWindow")
+	return true
+}
+
 func (m *MockWindowManager) VerifyForegroundWindow(expectedHwnd uintptr, expectedPid uint32) bool {
 	return m.VerifyForegroundWindowResult
 }
@@ -64,6 +101,12 @@ func (m *MockWindowManager) IsElevated() bool {
 }
 
 func (m *MockWindowManager) IsWindowValid(hwnd uintptr) bool {
+	if seq := m.WindowValiditySequences[hwnd]; len(seq) > 0 {
+		if len(seq) > 1 {
+			m.WindowValiditySequences[hwnd] = seq[1:]
+		}
+		return seq[0]
+	}
 	if valid, exists := m.WindowValidityMap[hwnd]; exists {
 		return valid
 	}
@@ -80,14 +123,80 @@ func (m *MockWindowManager) CollectChildInfos(hwnd uintptr) []windows.ChildInfo
 	return m.ChildInfos
 }
 
+// WaitOnMonitor returns the next scripted result. Like the real monitor, it
+// passes over scripted events that no matcher accepts.
 func (m *MockWindowManager) WaitOnMonitor(timeout time.Duration, matchers ...func(windows.WindowEvent) bool) (windows.WindowEvent, bool) {
-	if m.currentWaitIndex >= len(m.WaitOnMonitorResults) {
-		return windows.WindowEvent{}, false
+	for m.currentWaitIndex < len(m.WaitOnMonitorResults) {
+		result := m.WaitOnMonitorResults[m.currentWaitIndex]
+		m.currentWaitIndex++
+
+		if !result.OK || matchesAny(result.Event, matchers) {
+			return result.Event, result.OK
+		}
+	}
+
+	return windows.WindowEvent{}, false
+}
+
+// matchesAny reports whether any of matchers accepts ev, or true if there are none
+func matchesAny(ev windows.WindowEvent, matchers []func(windows.WindowEvent) bool) bool {
+	for _, match := range matchers {
+		if match(ev) {
+			return true
+		}
+	}
+
+	return len(matchers) == 0
+}
+
+// GetForegroundWindow returns the next window from ForegroundSequence. Once the
+// sequence is used up it returns the window last passed to SetForeground.
+func (m *MockWindowManager) GetForegroundWindow() uintptr {
+	if m.foregroundIndex < len(m.ForegroundSequence) {
+		hwnd := m.ForegroundSequence[m.foregroundIndex]
+		m.foregroundIndex++
+		return hwnd
+	}
+
+	if n := len(m.SetForegroundCalls); n > 0 {
+		return m.SetForegroundCalls[n-1]
+	}
+
+	return 0
+}
+
+func (m *MockWindowManager) GetWindowPid(hwnd uintptr) uint32 {
+	return m.WindowPidMap[hwnd]
+}
+
+func (m *MockWindowManager) GetProcessIntegrity(pid uint32) (windows.IntegrityLevel, error) {
+	if level, ok := m.IntegrityMap[pid]; ok {
+		return level, nil
 	}
 
-	result := m.WaitOnMonitorResults[m.currentWaitIndex]
-	m.currentWaitIndex++
-	return result.Event, result.OK
+	return 0, fmt.Errorf("access denied to process %d", pid)
+}
+
+func (m *MockWindowManager) GetProcessName(pid uint32) string {
+	return m.ProcessNameMap[pid]
+}
+
+func (m *MockWindowManager) GetWindowRect(hwnd uintptr) (windows.RECT, bool) {
+	rect, ok := m.WindowRectMap[hwnd]
+	return rect, ok
+}
+
+func (m *MockWindowManager) GetScreenBounds() (virtual, primary windows.RECT) {
+	return m.VirtualScreen, m.PrimaryScreen
+}
+
+func (m *MockWindowManager) MoveWindow(hwnd uintptr, x, y int32) bool {
+	m.MoveWindowCalls = append(m.MoveWindowCalls, MoveWindowCall{Hwnd: hwnd, X: x, Y: y})
+	m.WindowOps = append(m.WindowOps, "MoveWindow")
+	if rect, ok := m.WindowRectMap[hwnd]; ok {
+		m.WindowRectMap[hwnd] = windows.RECT{Left: x, Top: y, Right: x + rect.Width(), Bottom: y + rect.Height()}
+	}
+	return true
 }
 
 func (m *MockWindowManager) GetWindowText(hwnd uintptr) string {
@@ -151,11 +260,52 @@ func (m *MockWindowManager) WithChildInfosForHwnd(hwnd uintptr, infos ...windows
 	return m
 }
 
+func (m *MockWindowManager) WithWindowText(hwnd uintptr, text string) *MockWindowManager {
+	m.WindowTextMap[hwnd] = text
+	return m
+}
+
+func (m *MockWindowManager) WithForegroundSequence(hwnds ...uintptr) *MockWindowManager {
+	m.ForegroundSequence = hwnds
+	m.foregroundIndex = 0
+	return m
+}
+
+func (m *MockWindowManager) WithWindowPid(hwnd uintptr, pid uint32) *MockWindowManager {
+	m.WindowPidMap[hwnd] = pid
+	return m
+}
+
+// WithProcess sets the executable name and integrity level reported for pid
+func (m *MockWindowManager) WithProcess(pid uint32, name string, level windows.IntegrityLevel) *MockWindowManager {
+	m.ProcessNameMap[pid] = name
+	m.IntegrityMap[pid] = level
+	return m
+}
+
+// WithScreen sets the virtual screen and primary monitor bounds
+func (m *MockWindowManager) WithScreen(virtual, primary windows.RECT) *MockWindowManager {
+	m.VirtualScreen = virtual
+	m.PrimaryScreen = primary
+	return m
+}
+
+// WithWindowRect sets the bounds reported for hwnd
+func (m *MockWindowManager) WithWindowRect(hwnd uintptr, rect windows.RECT) *MockWindowManager {
+	m.WindowRectMap[hwnd] = rect
+	return m
+}
+
 func (m *MockWindowManager) WithWindowValid(hwnd uintptr, valid bool) *MockWindowManager {
 	m.WindowValidityMap[hwnd] = valid
 	return m
 }
 
+func (m *MockWindowManager) WithWindowValidSequence(hwnd uintptr, results ...bool) *MockWindowManager {
+	m.WindowValiditySequences[hwnd] = results
+	return m
+}
+
 // SendEventsToMonitor sends a sequence of events to windows.MonitorCh for event-driven testing
 // This simulates the background window monitor sending events in real-time
 // Events are sent synchronously to ensure they're in the channel before Compile() reads them
@@ -190,35 +340,61 @@ type MockKeyboardInjector struct {
 	SendEnterCalled            bool
 	SendF12ToWindowCalled      bool
 	SendF12WithSendInputCalled bool
+	SendCtrlSCalled            bool
+	SendEscCalls               []uintptr // Windows sent Escape with SendEscToWindow
 	SendToWindowResult         bool
 	SendInputResult            bool
+	SendCtrlSResult            bool
+	Keystrokes                 []string // Keys sent, in order (e.g. "Ctrl+S", "F12")
 }
 
 func NewMockKeyboardInjector() *MockKeyboardInjector {
 	return &MockKeyboardInjector{
 		SendToWindowResult: true, // Default to success
 		SendInputResult:    true, // Default to success
+		SendCtrlSResult:    true, // Default to success
 	}
 }
 
 func (m *MockKeyboardInjector) SendF12() {
 	m.SendF12Called = true
+	m.Keystrokes = append(m.Keystrokes, "F12")
 }
 
 func (m *MockKeyboardInjector) SendEnter() {
 	m.SendEnterCalled = true
+	m.Keystrokes = append(m.Keystrokes, "Enter")
 }
 
 func (m *MockKeyboardInjector) SendF12ToWindow(hwnd uintptr) bool {
 	m.SendF12ToWindowCalled = true
+	m.Keystrokes = append(m.Keystrokes, "F12")
 	return m.SendToWindowResult
 }
 
 func (m *MockKeyboardInjector) SendF12WithSendInput() bool {
 	m.SendF12WithSendInputCalled = true
+	m.Keystrokes = append(m.Keystrokes, "F12")
 	return m.SendInputResult
 }
 
+func (m *MockKeyboardInjector) SendCtrlS() bool {
+	m.SendCtrlSCalled = true
+	m.Keystrokes = append(m.Keystrokes, "Ctrl+S")
+	return m.SendCtrlSResult
+}
+
+func (m *MockKeyboardInjector) SendEscToWindow(hwnd uintptr) bool {
+	m.SendEscCalls = append(m.SendEscCalls, hwnd)
+	m.Keystrokes = append(m.Keystrokes, "Esc")
+	return true
+}
+
+func (m *MockKeyboardInjector) WithSendCtrlSResult(result bool) *MockKeyboardInjector {
+	m.SendCtrlSResult = result
+	return m
+}
+
 // MockControlReader
 type MockControlReader struct {
 	ListBoxItems            []string
@@ -226,6 +402,15 @@ type MockControlReader struct {
 	FindButtonResult        bool
 	FindButtonCalls         []string
 	FindAndClickButtonCalls []FindAndClickButtonCall
+	TextLengths             map[uintptr]int
+	TextLimits              map[uintptr]int
+	SetTextLimitCalls       []SetTextLimitCall
+	SetTextLimitFails       bool
+}
+
+type SetTextLimitCall struct {
+	Hwnd  uintptr
+	Limit int
 }
 
 type FindAndClickButtonCall struct {
@@ -237,6 +422,8 @@ func NewMockControlReader() *MockControlReader {
 	return &MockControlReader{
 		FindButtonResult: true,
 		FindButtonCalls:  []string{},
+		TextLengths:      make(map[uintptr]int),
+		TextLimits:       make(map[uintptr]int),
 	}
 }
 
@@ -248,6 +435,24 @@ func (m *MockControlReader) GetEditText(hwnd uintptr) string {
 	return m.EditText
 }
 
+func (m *MockControlReader) GetTextLength(hwnd uintptr) int {
+	return m.TextLengths[hwnd]
+}
+
+func (m *MockControlReader) GetTextLimit(hwnd uintptr) int {
+	return m.TextLimits[hwnd]
+}
+
+func (m *MockControlReader) SetTextLimit(hwnd uintptr, limit int) bool {
+	m.SetTextLimitCalls = append(m.SetTextLimitCalls, SetTextLimitCall{Hwnd: hwnd, Limit: limit})
+	if m.SetTextLimitFails {
+		return false
+	}
+
+	m.TextLimits[hwnd] = limit
+	return true
+}
+
 func (m *MockControlReader) FindAndClickButton(parentHwnd uintptr, buttonText string) bool {
 	m.FindButtonCalls = append(m.FindButtonCalls, buttonText)
 	m.FindAndClickButtonCalls = append(m.FindAndClickButtonCalls, FindAndClickButtonCall{
@@ -268,6 +473,21 @@ func (m *MockControlReader) WithEditText(text string) *MockControlReader {
 	return m
 }
 
+func (m *MockControlReader) WithTextLength(hwnd uintptr, length int) *MockControlReader {
+	m.TextLengths[hwnd] = length
+	return m
+}
+
+func (m *MockControlReader) WithTextLimit(hwnd uintptr, limit int) *MockControlReader {
+	m.TextLimits[hwnd] = limit
+	return m
+}
+
+func (m *MockControlReader) WithSetTextLimitFails() *MockControlReader {
+	m.SetTextLimitFails = true
+	return m
+}
+
 func (m *MockControlReader) WithFindButtonResult(result bool) *MockControlReader {
 	m.FindButtonResult = result
 	return m
@@ -277,3 +497,18 @@ func (m *MockControlReader) WithFindAndClickButtonResult(result bool) *MockContr
 	m.FindButtonResult = result
 	return m
 }
+
+// MockSound records the tones played instead of playing them
+type MockSound struct {
+	Played []windows.Tone
+	Err    error // Returned by Play, after recording the tone
+}
+
+func NewMockSound() *MockSound {
+	return &MockSound{}
+}
+
+func (m *MockSound) Play(t windows.Tone) error {
+	m.Played = append(m.Played, t)
+	return m.Err
+}
@@ -0,0 +1,70 @@
+// Package textutil normalizes text captured from VTPro windows and controls.
+package textutil
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// replacer maps the typographic characters VTPro sometimes renders to their plain equivalents
+var replacer = strings.NewReplacer(
+	"\u00a0", " ", // no-break space
+	"\u2007", " ", // figure space
+	"\u202f", " ", // narrow no-break space
+	"\u2018", "'", // left single quotation mark
+	"\u2019", "'", // right single quotation mark
+	"\u201a", "'", // single low-9 quotation mark
+	"\u201b", "'", // single high-reversed-9 quotation mark
+	"\u201c", `"`, // left double quotation mark
+	"\u201d", `"`, // right double quotation mark
+	"\u201e", `"`, // double low-9 quotation mark
+	"\u201f", `"`, // double high-reversed-9 quotation mark
+	"\ufeff", "", // byte order mark
+)
+
+// Normalize returns s in Unicode NFC form with non-breaking spaces converted to
+// regular spaces and smart quotes converted to straight quotes
+func Normalize(s string) string {
+	return replacer.Replace(norm.NFC.String(s))
+}
+
+// ContainsFold reports whether substr is within s, ignoring case, after normalizing both
+func ContainsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(Normalize(s)), strings.ToLower(Normalize(substr)))
+}
+
+// asciiReplacer maps the punctuation VTPro messages use to ASCII, for consoles
+// that cannot be switched to UTF-8
+var asciiReplacer = strings.NewReplacer(
+	"\u2010", "-", // hyphen
+	"\u2011", "-", // non-breaking hyphen
+	"\u2012", "-", // figure dash
+	"\u2013", "-", // en dash
+	"\u2014", "--", // em dash
+	"\u2015", "--", // horizontal bar
+	"\u2212", "-", // minus sign
+	"\u2022", "*", // bullet
+	"\u2026", "...", // horizontal ellipsis
+	"\u2032", "'", // prime
+	"\u2033", `"`, // double prime
+	"\u2039", "<", // single left-pointing angle quotation mark
+	"\u203a", ">", // single right-pointing angle quotation mark
+	"\u00ab", "<<", // left-pointing double angle quotation mark
+	"\u00bb", ">>", // right-pointing double angle quotation mark
+	"\u00d7", "x", // multiplication sign
+	"\u2190", "<-", // leftwards arrow
+	"\u2192", "->", // rightwards arrow
+	"\u2264", "<=", // less-than or equal to
+	"\u2265", ">=", // greater-than or equal to
+	"\u2122", "(TM)", // trade mark sign
+	"\u00a9", "(C)", // copyright sign
+	"\u00ae", "(R)", // registered sign
+)
+
+// Transliterate returns s normalized, with the punctuation that legacy console
+// code pages cannot show replaced by ASCII. Letters, including accented ones,
+// are left as they are.
+func Transliterate(s string) string {
+	return asciiReplacer.Replace(Normalize(s))
+}
@@ -0,0 +1,63 @@
+package textutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain ascii", "Main Page", "Main Page"},
+		{"decomposed accent", "Chambre a\u0300 coucher", "Chambre à coucher"},
+		{"precomposed accent", "Chambre à coucher", "Chambre à coucher"},
+		{"no-break space", "Chambre\u00a0à\u00a0coucher", "Chambre à coucher"},
+		{"narrow no-break space", "10\u202f%", "10 %"},
+		{"smart double quotes", "Object \u201cVolume\u201d on Page \u201cMain\u201d", `Object "Volume" on Page "Main"`},
+		{"smart single quotes", "Page \u2018Lobby\u2019", "Page 'Lobby'"},
+		{"byte order mark", "\ufeffBoot", "Boot"},
+		{"cjk unchanged", "会議室", "会議室"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Normalize(tt.input), tt.name)
+	}
+}
+
+func TestContainsFold(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, ContainsFold("CHAMBRE À COUCHER.VTP - VisionTools Pro-e", "chambre à coucher.vtp"))
+	assert.True(t, ContainsFold("Chambre a\u0300\u00a0coucher.vtp - VisionTools Pro-e", "Chambre à coucher.vtp"))
+	assert.True(t, ContainsFold("会議室.vtp - VisionTools Pro-e", "会議室.VTP"))
+	assert.False(t, ContainsFold("Lobby.vtp - VisionTools Pro-e", "Boardroom.vtp"))
+}
+
+func TestTransliterate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain ascii", "Main Page", "Main Page"},
+		{"smart quotes", "Object \u201cVolume\u201d on Page \u2018Lobby\u2019", `Object "Volume" on Page 'Lobby'`},
+		{"dashes", "Pages 1\u20133 \u2014 see log", "Pages 1-3 -- see log"},
+		{"ellipsis", "Compiling\u2026", "Compiling..."},
+		{"symbols", "Crestron\u00ae VisionTools\u2122 \u2192 1024\u00d7768", "Crestron(R) VisionTools(TM) -> 1024x768"},
+		{"accented letters kept", "Chambre a\u0300 coucher", "Chambre à coucher"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Transliterate(tt.input), tt.name)
+	}
+}
@@ -1,4 +1,5 @@
-// Package timeouts defines timeout and delay constants for VTPro operations.
+// Package timeouts defines timeout and delay constants for VTPro operations,
+// and the input delays that are adjusted to the machine vtpc runs on.
 package timeouts
 
 import "time"
@@ -24,20 +25,12 @@ const (
 	// UI state to stabilize before interacting with the application.
 	UISettlingDelay = 5 * time.Second
 
-	// FocusVerificationDelay allows time to verify that window focus has
-	// successfully changed after a focus operation.
-	FocusVerificationDelay = 1 * time.Second
-
 	// Windows API Interaction Delays
 
 	// WindowMessageDelay is the delay after sending window messages (WM_CLOSE,
 	// WM_SETFOCUS, etc.) to allow the target application to process the message.
 	WindowMessageDelay = 500 * time.Millisecond
 
-	// KeystrokeDelay is the delay between keyboard events (key down/up) to ensure
-	// the target application reliably receives and processes the input.
-	KeystrokeDelay = 50 * time.Millisecond
-
 	// Compiler Dialog Timeouts
 
 	// CompilationCompleteTimeout is the maximum time to wait for the entire
@@ -50,6 +43,20 @@ const (
 	// confirmation dialog to appear.
 	DialogConfirmationTimeout = 2 * time.Second
 
+	// SaveDialogTimeout is the maximum time to wait for a save dialog to
+	// appear after Ctrl+S, and then for it to close again.
+	SaveDialogTimeout = 10 * time.Second
+
+	// SlowDialogThreshold is how long a dialog other than the Compiling
+	// dialog may stay open before vtpc warns about it. A progress dialog
+	// waiting on a network resource can hang for a minute at 99%.
+	SlowDialogThreshold = 30 * time.Second
+
+	// TelemetrySendTimeout is the most a run waits to post its telemetry
+	// before giving up. The run has finished by then, so an unreachable
+	// endpoint only delays vtpc's exit this long.
+	TelemetrySendTimeout = 3 * time.Second
+
 	// Polling and Verification Intervals
 
 	// StatePollingInterval is the delay between checks in tight polling loops
@@ -70,3 +77,41 @@ const (
 	// before performing verification checks or additional cleanup operations.
 	CleanupDelay = 1 * time.Second
 )
+
+// Input holds the delays around the keystrokes vtpc injects. They are
+// lengthened where injected input arrives late, such as in a VDI session.
+type Input struct {
+	// Keystroke is the delay between keyboard events (key down/up) to ensure
+	// the target application reliably receives and processes the input.
+	Keystroke time.Duration
+
+	// FocusVerification allows time to verify that window focus has
+	// successfully changed after a focus operation.
+	FocusVerification time.Duration
+}
+
+// DefaultInput returns the input delays for a physical machine at its console
+func DefaultInput() Input {
+	return Input{
+		Keystroke:         50 * time.Millisecond,
+		FocusVerification: 1 * time.Second,
+	}
+}
+
+// Scale returns the delays multiplied by multiplier, rounded to the
+// millisecond. Multipliers below 1 leave the delays unchanged, so adapting to a
+// slow machine never shortens them.
+func (in Input) Scale(multiplier float64) Input {
+	if multiplier <= 1 {
+		return in
+	}
+
+	scale := func(d time.Duration) time.Duration {
+		return time.Duration(float64(d) * multiplier).Round(time.Millisecond)
+	}
+
+	return Input{
+		Keystroke:         scale(in.Keystroke),
+		FocusVerification: scale(in.FocusVerification),
+	}
+}
@@ -0,0 +1,33 @@
+package timeouts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInput_Scale(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		multiplier float64
+		want       Input
+	}{
+		{name: "one leaves the defaults", multiplier: 1, want: DefaultInput()},
+		{name: "below one never shortens", multiplier: 0.5, want: DefaultInput()},
+		{name: "zero never shortens", multiplier: 0, want: DefaultInput()},
+		{name: "whole multiplier", multiplier: 4, want: Input{Keystroke: 200 * time.Millisecond, FocusVerification: 4 * time.Second}},
+		{name: "fractional multiplier", multiplier: 2.5, want: Input{Keystroke: 125 * time.Millisecond, FocusVerification: 2500 * time.Millisecond}},
+		{name: "rounded to the millisecond", multiplier: 1.00001, want: Input{Keystroke: 50 * time.Millisecond, FocusVerification: 1 * time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, DefaultInput().Scale(tt.multiplier))
+		})
+	}
+}
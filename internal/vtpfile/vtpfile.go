@@ -0,0 +1,67 @@
+// Package vtpfile checks that a file looks like a VTPro project before VTPro is launched.
+package vtpfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+// ErrNotProject is returned when a file is clearly not a VTPro project
+var ErrNotProject = errcode.New(errcode.NotProject, "not a VTPro project")
+
+const (
+	// MinProjectSize is the smallest file accepted as a project. Real projects
+	// are far larger; anything below this is a placeholder or a truncated file.
+	MinProjectSize = 256
+
+	// headerSize is how much of the file is read to look for known non-project content
+	headerSize = 512
+)
+
+// lfsPointerPrefix starts every Git LFS pointer file
+var lfsPointerPrefix = []byte("version https://git-lfs")
+
+// Check reads the start of a project file and rejects content that cannot be a
+// VTPro project. It is deliberately conservative: unrecognised content passes.
+func Check(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening project file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("error reading project file: %w", err)
+	}
+
+	header := make([]byte, headerSize)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("error reading project file: %w", err)
+	}
+
+	return checkHeader(path, info.Size(), header[:n])
+}
+
+// checkHeader applies the detection heuristics to a file's size and first bytes
+func checkHeader(path string, size int64, header []byte) error {
+	if size == 0 {
+		return fmt.Errorf("%w: %s is empty", ErrNotProject, path)
+	}
+
+	if bytes.HasPrefix(header, lfsPointerPrefix) {
+		return fmt.Errorf("%w: %s is a Git LFS pointer, run \"git lfs pull\" to fetch the real file", ErrNotProject, path)
+	}
+
+	if size < MinProjectSize {
+		return fmt.Errorf("%w: %s is only %d bytes", ErrNotProject, path, size)
+	}
+
+	return nil
+}
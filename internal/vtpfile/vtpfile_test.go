@@ -0,0 +1,76 @@
+package vtpfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		fixture  string
+		wantErr  bool
+		contains string
+	}{
+		{"empty.vtp", true, "is empty"},
+		{"lfs_pointer.vtp", true, "git lfs pull"},
+		{"tiny.vtp", true, "only 12 bytes"},
+		{"binary.vtp", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			t.Parallel()
+
+			err := Check(filepath.Join("testdata", tt.fixture))
+
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrNotProject)
+			assert.Contains(t, err.Error(), tt.contains)
+		})
+	}
+}
+
+func TestCheck_LargeTextFilePasses(t *testing.T) {
+	t.Parallel()
+
+	// Unknown content is accepted, even when it happens to be text
+	path := filepath.Join(t.TempDir(), "project.vtp")
+	content := make([]byte, 4096)
+	for i := range content {
+		content[i] = 'a'
+	}
+	require.NoError(t, os.WriteFile(path, content, 0o644))
+
+	assert.NoError(t, Check(path))
+}
+
+func TestCheck_MissingFile(t *testing.T) {
+	t.Parallel()
+
+	err := Check(filepath.Join(t.TempDir(), "missing.vtp"))
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotProject)
+}
+
+func TestCheckHeader_LFSPointerWithinSizeLimit(t *testing.T) {
+	t.Parallel()
+
+	// LFS pointers are reported as such rather than as merely too small
+	err := checkHeader("lobby.vtp", 130, []byte("version https://git-lfs.github.com/spec/v1\n"))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Git LFS pointer")
+	assert.NotContains(t, err.Error(), "only")
+}
@@ -0,0 +1,95 @@
+package vtpro
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+var (
+	// ErrNotRunning is returned by Attach when no running VTPro has a main window
+	ErrNotRunning = errcode.New(errcode.AttachNotRunning, "no running VTPro with a project open")
+
+	// ErrSeveralRunning is returned by Attach without a PID when more than one VTPro has a project open
+	ErrSeveralRunning = errcode.New(errcode.AttachAmbiguous, "more than one VTPro has a project open")
+)
+
+// Running is the main window of a VTPro vtpc did not launch
+type Running struct {
+	Pid   uint32
+	Hwnd  uintptr
+	Title string
+}
+
+// Attach finds the main window of a VTPro that is already open, to read its
+// Message Log without launching or closing anything. With pid 0 it uses the
+// only vtpro.exe with a project open.
+func (c *Client) Attach(pid uint32) (Running, error) {
+	windows.PruneClassCache()
+	return c.attach(c.enumerate(), pid)
+}
+
+// attach chooses the main window to attach to from every top-level window
+func (c *Client) attach(all []windows.WindowInfo, pid uint32) (Running, error) {
+	pids := []uint32{pid}
+	if pid == 0 {
+		pids = c.runningPids(all)
+	} else if name, exe := c.inspector.ProcessName(pid), baseName(GetVTProPath()); name != "" && !strings.EqualFold(name, exe) {
+		// An image name that cannot be read, e.g. of an elevated VTPro, is given the benefit of the doubt
+		return Running{}, fmt.Errorf("%w: PID %d is %s, not %s", ErrNotRunning, pid, name, exe)
+	}
+
+	var found []Running
+
+	for _, p := range pids {
+		sel := c.selectMainWindow(all, p)
+		c.selection = sel
+
+		c.log.Debug("Looked for a running VTPro main window", slog.Uint64("pid", uint64(p)), slog.String("selection", sel.Rationale()))
+
+		if cand, ok := sel.Chosen(); ok {
+			found = append(found, Running{Pid: p, Hwnd: cand.Hwnd, Title: cand.Title})
+		}
+	}
+
+	switch {
+	case len(found) == 1:
+		return found[0], nil
+	case len(found) > 1:
+		var list []string
+		for _, r := range found {
+			list = append(list, fmt.Sprintf("%d (%s)", r.Pid, r.Title))
+		}
+
+		return Running{}, fmt.Errorf("%w: PIDs %s; choose one with --pid", ErrSeveralRunning, strings.Join(list, ", "))
+	case pid != 0:
+		return Running{}, fmt.Errorf("%w: PID %d has no VTPro main window", ErrNotRunning, pid)
+	default:
+		return Running{}, ErrNotRunning
+	}
+}
+
+// runningPids returns the PIDs of the vtpro.exe processes that own a window, in window order
+func (c *Client) runningPids(all []windows.WindowInfo) []uint32 {
+	exe := baseName(GetVTProPath())
+	seen := make(map[uint32]bool)
+
+	var pids []uint32
+
+	for _, w := range all {
+		if seen[w.Pid] {
+			continue
+		}
+
+		seen[w.Pid] = true
+
+		if strings.EqualFold(c.inspector.ProcessName(w.Pid), exe) {
+			pids = append(pids, w.Pid)
+		}
+	}
+
+	return pids
+}
@@ -0,0 +1,97 @@
+package vtpro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// namedInspector implements ProcessInspector with an image name per PID
+type namedInspector map[uint32]string
+
+func (n namedInspector) ProcessName(pid uint32) string     { return n[pid] }
+func (namedInspector) Windows(uint32) []windows.WindowInfo { return nil }
+
+func TestAttach(t *testing.T) {
+	t.Setenv("VTPRO_PATH", "")
+
+	prober := fakeProber{
+		0x300: {title: "Lobby.vtp - VisionTools Pro-e", class: "VWT32AppClass", hasMenu: true, width: 1920, height: 1080},
+		0x500: {title: "Boardroom.vtp - VisionTools Pro-e", class: "VWT32AppClass", hasMenu: true, width: 1920, height: 1080},
+		0x600: {title: "VisionTools Pro-e", class: "#32770", width: 400, height: 150},
+	}
+
+	lobby := windows.WindowInfo{Hwnd: 0x300, Title: "Lobby.vtp - VisionTools Pro-e", Pid: 1234}
+	boardroom := windows.WindowInfo{Hwnd: 0x500, Title: "Boardroom.vtp - VisionTools Pro-e", Pid: 5678}
+	loading := windows.WindowInfo{Hwnd: 0x600, Title: "VisionTools Pro-e", Pid: 5678}
+	notepad := windows.WindowInfo{Hwnd: 0x900, Title: "Lobby.vtp - Notepad", Pid: 4321}
+
+	inspector := namedInspector{1234: "vtpro.exe", 5678: "VTPro.exe", 4321: "notepad.exe"}
+
+	tests := []struct {
+		name    string
+		windows []windows.WindowInfo
+		pid     uint32
+		want    Running
+		wantErr error
+	}{
+		{
+			name:    "only VTPro is attached to",
+			windows: []windows.WindowInfo{notepad, lobby, loading},
+			want:    Running{Pid: 1234, Hwnd: 0x300, Title: lobby.Title},
+		},
+		{
+			name:    "PID chooses between several",
+			windows: []windows.WindowInfo{lobby, boardroom},
+			pid:     5678,
+			want:    Running{Pid: 5678, Hwnd: 0x500, Title: boardroom.Title},
+		},
+		{
+			name:    "several without a PID",
+			windows: []windows.WindowInfo{lobby, boardroom},
+			wantErr: ErrSeveralRunning,
+		},
+		{
+			name:    "no VTPro running",
+			windows: []windows.WindowInfo{notepad},
+			wantErr: ErrNotRunning,
+		},
+		{
+			name:    "VTPro still loading",
+			windows: []windows.WindowInfo{loading},
+			wantErr: ErrNotRunning,
+		},
+		{
+			name:    "PID without a main window",
+			windows: []windows.WindowInfo{lobby, loading},
+			pid:     5678,
+			wantErr: ErrNotRunning,
+		},
+		{
+			name:    "PID of another program",
+			windows: []windows.WindowInfo{lobby, notepad},
+			pid:     4321,
+			wantErr: ErrNotRunning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newSelectionClient()
+			c.prober = prober
+			c.inspector = inspector
+
+			got, err := c.attach(tt.windows, tt.pid)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
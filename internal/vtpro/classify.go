@@ -0,0 +1,205 @@
+package vtpro
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/textutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+const (
+	// DefaultMainWindowClass is the window class of the VTPro main application window
+	DefaultMainWindowClass = "VWT32AppClass"
+
+	// DefaultSplashTitle is the title used by the English VTPro splash screen
+	DefaultSplashTitle = "VTPro"
+
+	// dialogWindowClass is the standard Windows dialog class (#32770)
+	dialogWindowClass = "#32770"
+
+	// Splash screens are small fixed-size windows. Anything larger than this
+	// is not treated as a splash screen on size alone.
+	splashMaxWidth  = 800
+	splashMaxHeight = 600
+)
+
+// WindowIdentity is what VTPro's main window and splash screen are recognised
+// by. Builds of VTPro that register different window classes need their own.
+type WindowIdentity struct {
+	MainClass   string // Window class of the main application window
+	SplashTitle string // Title of the splash screen
+	SplashClass string // Window class of the splash screen, "" to recognise it by title and size alone
+}
+
+// DefaultWindowIdentity returns the identity of the VTPro builds vtpc is tested with
+func DefaultWindowIdentity() WindowIdentity {
+	return WindowIdentity{MainClass: DefaultMainWindowClass, SplashTitle: DefaultSplashTitle}
+}
+
+// WindowKind identifies what a top-level VTPro window is
+type WindowKind int
+
+const (
+	WindowKindUnknown WindowKind = iota
+	WindowKindMain
+	WindowKindSplash
+	WindowKindProgress
+	WindowKindDialog
+)
+
+// String returns a human-readable name for the window kind
+func (k WindowKind) String() string {
+	switch k {
+	case WindowKindMain:
+		return "main"
+	case WindowKindSplash:
+		return "splash"
+	case WindowKindProgress:
+		return "progress"
+	case WindowKindDialog:
+		return "dialog"
+	default:
+		return "unknown"
+	}
+}
+
+// WindowProber reads the window attributes used for classification
+// It exists so classification can be tested without real windows
+type WindowProber interface {
+	GetWindowText(hwnd uintptr) string
+	GetClassName(hwnd uintptr) string
+	HasMenu(hwnd uintptr) bool
+	HasControls(hwnd uintptr) bool
+	GetWindowSize(hwnd uintptr) (width, height int32)
+}
+
+// windowsProber is the production WindowProber backed by the Windows API
+type windowsProber struct{}
+
+func (windowsProber) GetWindowText(hwnd uintptr) string {
+	return textutil.Normalize(windows.GetWindowText(hwnd))
+}
+func (windowsProber) GetClassName(hwnd uintptr) string { return windows.CachedClassName(hwnd) }
+func (windowsProber) HasMenu(hwnd uintptr) bool        { return windows.HasMenu(hwnd) }
+func (windowsProber) HasControls(hwnd uintptr) bool    { return windows.HasChildWindows(hwnd) }
+func (windowsProber) GetWindowSize(hwnd uintptr) (int32, int32) {
+	return windows.GetWindowSize(hwnd)
+}
+
+// ClassifyWindow determines what kind of VTPro window hwnd refers to
+func (c *Client) ClassifyWindow(hwnd uintptr) WindowKind {
+	return c.classify(windows.WindowInfo{
+		Hwnd:  hwnd,
+		Title: c.prober.GetWindowText(hwnd),
+	})
+}
+
+// classify classifies a window, treating a title that names the project file as the main window
+func (c *Client) classify(w windows.WindowInfo) WindowKind {
+	kind, _ := c.classifyWithReason(w)
+	return kind
+}
+
+// classifyWithReason classifies a window and says which rule decided it
+func (c *Client) classifyWithReason(w windows.WindowInfo) (WindowKind, string) {
+	if titleMatchesProject(w.Title, c.project) {
+		return WindowKindMain, "title names the project file"
+	}
+
+	return classifyWindowWithReason(c.prober, c.identity, w)
+}
+
+// titleMatchesProject reports whether a window title contains the project file's
+// basename, compared case-insensitively after normalization
+func titleMatchesProject(title, projectPath string) bool {
+	if projectPath == "" {
+		return false
+	}
+
+	base := baseName(projectPath)
+
+	return base != "" && textutil.ContainsFold(title, base)
+}
+
+// baseName returns the last element of a Windows or slash-separated path
+func baseName(path string) string {
+	if idx := strings.LastIndexAny(path, `\/`); idx != -1 {
+		return path[idx+1:]
+	}
+
+	return path
+}
+
+// ClassifyRecorded classifies a window from the title and class a
+// --record-events recording kept of it. Nothing else about the window was
+// recorded, so it is taken to have no menu bar and no known size.
+func ClassifyRecorded(id WindowIdentity, title, class string) WindowKind {
+	return classifyWindow(recordedProber{class: class}, id, windows.WindowInfo{Title: title})
+}
+
+// recordedProber is a WindowProber for a window known only from a recording
+type recordedProber struct {
+	class string
+}
+
+func (recordedProber) GetWindowText(uintptr) string                { return "" }
+func (p recordedProber) GetClassName(uintptr) string               { return p.class }
+func (recordedProber) HasMenu(uintptr) bool                        { return false }
+func (recordedProber) HasControls(uintptr) bool                    { return false }
+func (recordedProber) GetWindowSize(uintptr) (width, height int32) { return 0, 0 }
+
+// classifyWindow classifies a window using its title, class, menu bar and size.
+// The title alone is not trusted for the splash screen because localized
+// installs use different splash titles.
+func classifyWindow(prober WindowProber, id WindowIdentity, w windows.WindowInfo) WindowKind {
+	kind, _ := classifyWindowWithReason(prober, id, w)
+	return kind
+}
+
+// classifyWindowWithReason is classifyWindow, also returning which rule decided the kind
+func classifyWindowWithReason(prober WindowProber, id WindowIdentity, w windows.WindowInfo) (WindowKind, string) {
+	className := prober.GetClassName(w.Hwnd)
+	title := strings.ToLower(textutil.Normalize(w.Title))
+
+	// A window with .vtp in the title means the file is definitely loaded
+	if strings.Contains(title, ".vtp") {
+		return WindowKindMain, "title contains .vtp"
+	}
+
+	// The main window class is the main application window, even before the title updates
+	if id.MainClass != "" && className == id.MainClass {
+		return WindowKindMain, "main window class " + id.MainClass
+	}
+
+	if strings.Contains(title, "progress") {
+		return WindowKindProgress, "title mentions progress"
+	}
+
+	if className == dialogWindowClass {
+		return WindowKindDialog, "dialog class " + dialogWindowClass
+	}
+
+	// Only the main window has a menu bar
+	if prober.HasMenu(w.Hwnd) {
+		return WindowKindMain, "has a menu bar"
+	}
+
+	if id.SplashClass != "" && className == id.SplashClass {
+		return WindowKindSplash, "splash screen class " + id.SplashClass
+	}
+
+	if w.Title == id.SplashTitle {
+		return WindowKindSplash, "splash screen title"
+	}
+
+	// A small window without a menu bar is the splash screen, whatever its title,
+	// unless it has controls: the splash screen is a bare image, while a small
+	// window of another class, such as a message box, has buttons and text
+	width, height := prober.GetWindowSize(w.Hwnd)
+	if width > 0 && height > 0 && width <= splashMaxWidth && height <= splashMaxHeight && !prober.HasControls(w.Hwnd) {
+		return WindowKindSplash, fmt.Sprintf("small window (%dx%d) without a menu bar or controls", width, height)
+	}
+
+	return WindowKindUnknown, "no main window traits"
+}
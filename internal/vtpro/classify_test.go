@@ -0,0 +1,246 @@
+package vtpro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// fakeWindow describes the attributes a fakeProber reports for one hwnd
+type fakeWindow struct {
+	title       string
+	class       string
+	hasMenu     bool
+	hasControls bool
+	width       int32
+	height      int32
+}
+
+// fakeProber implements WindowProber over a fixed set of synthetic windows
+type fakeProber map[uintptr]fakeWindow
+
+func (f fakeProber) GetWindowText(hwnd uintptr) string { return f[hwnd].title }
+func (f fakeProber) GetClassName(hwnd uintptr) string  { return f[hwnd].class }
+func (f fakeProber) HasMenu(hwnd uintptr) bool         { return f[hwnd].hasMenu }
+func (f fakeProber) HasControls(hwnd uintptr) bool     { return f[hwnd].hasControls }
+func (f fakeProber) GetWindowSize(hwnd uintptr) (int32, int32) {
+	return f[hwnd].width, f[hwnd].height
+}
+
+func TestClassifyWindow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		window fakeWindow
+		want   WindowKind
+	}{
+		{
+			name:   "title with project file",
+			window: fakeWindow{title: "project.vtp - VisionTools Pro-e", class: "Afx:400000", width: 1920, height: 1080},
+			want:   WindowKindMain,
+		},
+		{
+			name:   "title with upper-case project file",
+			window: fakeWindow{title: "PROJECT.VTP - VisionTools Pro-e", width: 1920, height: 1080},
+			want:   WindowKindMain,
+		},
+		{
+			name:   "main class before title updates",
+			window: fakeWindow{title: "VisionTools Pro-e", class: "VWT32AppClass", width: 1920, height: 1080},
+			want:   WindowKindMain,
+		},
+		{
+			name:   "menu bar without main class or project title",
+			window: fakeWindow{title: "VisionTools Pro-e", class: "Afx:400000", hasMenu: true, width: 1920, height: 1080},
+			want:   WindowKindMain,
+		},
+		{
+			name:   "english splash",
+			window: fakeWindow{title: "VTPro", class: "Afx:400000", width: 500, height: 300},
+			want:   WindowKindSplash,
+		},
+		{
+			name:   "localized splash by size and missing menu",
+			window: fakeWindow{title: "VTPro-e Démarrage", class: "Afx:400000", width: 500, height: 300},
+			want:   WindowKindSplash,
+		},
+		{
+			name:   "small window with controls",
+			window: fakeWindow{title: "Update available", class: "Afx:400000", hasControls: true, width: 420, height: 180},
+			want:   WindowKindUnknown,
+		},
+		{
+			name:   "progress dialog",
+			window: fakeWindow{title: "Progress [42%]", class: "#32770", width: 400, height: 150},
+			want:   WindowKindProgress,
+		},
+		{
+			name:   "standard dialog",
+			window: fakeWindow{title: "VisionTools(R) Pro-e", class: "#32770", width: 400, height: 150},
+			want:   WindowKindDialog,
+		},
+		{
+			name:   "accented project title with no-break space",
+			window: fakeWindow{title: "Chambre\u00a0a\u0300 coucher.VTP - VisionTools Pro-e", width: 1920, height: 1080},
+			want:   WindowKindMain,
+		},
+		{
+			name:   "cjk project title",
+			window: fakeWindow{title: "会議室.vtp - VisionTools Pro-e", width: 1920, height: 1080},
+			want:   WindowKindMain,
+		},
+		{
+			name:   "large window without menu",
+			window: fakeWindow{title: "Output Compiler", class: "Afx:400000", width: 1200, height: 900},
+			want:   WindowKindUnknown,
+		},
+		{
+			name:   "window with unknown size",
+			window: fakeWindow{title: "Something", class: "Afx:400000"},
+			want:   WindowKindUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			prober := fakeProber{0x100: tt.window}
+			got := classifyWindow(prober, DefaultWindowIdentity(), windows.WindowInfo{Hwnd: 0x100, Title: tt.window.title})
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClassifyWindow_CustomIdentity(t *testing.T) {
+	t.Parallel()
+
+	// A VTPro build whose main window registers another class, and whose
+	// splash screen is larger than the size rule allows
+	prober := fakeProber{
+		0x100: {title: "VisionTools Pro-e", class: "VWT64AppClass", width: 1920, height: 1080},
+		0x200: {title: "Loading", class: "VTSplash", width: 1024, height: 768},
+	}
+	main := windows.WindowInfo{Hwnd: 0x100, Title: "VisionTools Pro-e"}
+	splash := windows.WindowInfo{Hwnd: 0x200, Title: "Loading"}
+
+	assert.Equal(t, WindowKindUnknown, classifyWindow(prober, DefaultWindowIdentity(), main))
+	assert.Equal(t, WindowKindUnknown, classifyWindow(prober, DefaultWindowIdentity(), splash))
+
+	id := WindowIdentity{MainClass: "VWT64AppClass", SplashTitle: DefaultSplashTitle, SplashClass: "VTSplash"}
+
+	kind, reason := classifyWindowWithReason(prober, id, main)
+	assert.Equal(t, WindowKindMain, kind)
+	assert.Equal(t, "main window class VWT64AppClass", reason)
+
+	kind, reason = classifyWindowWithReason(prober, id, splash)
+	assert.Equal(t, WindowKindSplash, kind)
+	assert.Equal(t, "splash screen class VTSplash", reason)
+}
+
+func TestClassifyWindow_CustomSplashTitle(t *testing.T) {
+	t.Parallel()
+
+	prober := fakeProber{0x100: {title: "VT Pro-e", class: "Afx:400000", width: 1024, height: 768}}
+	w := windows.WindowInfo{Hwnd: 0x100, Title: "VT Pro-e"}
+
+	assert.Equal(t, WindowKindUnknown, classifyWindow(prober, DefaultWindowIdentity(), w))
+	assert.Equal(t, WindowKindSplash, classifyWindow(prober, WindowIdentity{MainClass: DefaultMainWindowClass, SplashTitle: "VT Pro-e"}, w))
+}
+
+func TestClassifyWindow_ReplacedDefaultClassIsNotMain(t *testing.T) {
+	t.Parallel()
+
+	prober := fakeProber{0x100: {title: "VisionTools Pro-e", class: DefaultMainWindowClass, width: 1920, height: 1080}}
+	w := windows.WindowInfo{Hwnd: 0x100, Title: "VisionTools Pro-e"}
+
+	assert.Equal(t, WindowKindUnknown, classifyWindow(prober, WindowIdentity{MainClass: "VWT64AppClass"}, w))
+}
+
+func TestClassifyRecorded(t *testing.T) {
+	t.Parallel()
+
+	id := WindowIdentity{MainClass: "VWT64AppClass", SplashTitle: DefaultSplashTitle}
+
+	assert.Equal(t, WindowKindMain, ClassifyRecorded(id, "Lobby.vtp - VisionTools Pro-e", "Afx:00400000:8"))
+	assert.Equal(t, WindowKindMain, ClassifyRecorded(id, "VisionTools Pro-e", "VWT64AppClass"), "the configured main window class")
+	assert.Equal(t, WindowKindUnknown, ClassifyRecorded(id, "VisionTools Pro-e", DefaultMainWindowClass))
+	assert.Equal(t, WindowKindDialog, ClassifyRecorded(id, "Address Book", "#32770"))
+	assert.Equal(t, WindowKindSplash, ClassifyRecorded(id, DefaultSplashTitle, "Afx:00400000:0"))
+}
+
+func TestClient_ClassifyWindow_UsesProber(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(logger.NewNoOpLogger())
+	c.prober = fakeProber{
+		0x100: {title: "VTPro", width: 500, height: 300},
+		0x200: {title: "project.vtp - VisionTools Pro-e", width: 1920, height: 1080},
+	}
+
+	assert.Equal(t, WindowKindSplash, c.ClassifyWindow(0x100))
+	assert.Equal(t, WindowKindMain, c.ClassifyWindow(0x200))
+}
+
+func TestWindowKind_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "main", WindowKindMain.String())
+	assert.Equal(t, "splash", WindowKindSplash.String())
+	assert.Equal(t, "progress", WindowKindProgress.String())
+	assert.Equal(t, "dialog", WindowKindDialog.String())
+	assert.Equal(t, "unknown", WindowKindUnknown.String())
+}
+
+func TestClient_ClassifyWindow_MatchesProjectBasename(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		project string
+		title   string
+		want    WindowKind
+	}{
+		{"accented name with smart quotes", `C:\Projects\Chambre à coucher “A”.vtp`, "CHAMBRE À COUCHER \"A\".VTP - VisionTools Pro-e", WindowKindMain},
+		{"decomposed accent and no-break space", `C:\Projects\Chambre à coucher.vtp`, "Chambre\u00a0a\u0300 coucher.vtp - VisionTools Pro-e", WindowKindMain},
+		{"cjk name", `C:\Projects\会議室.vtp`, "会議室.VTP - VisionTools Pro-e", WindowKindMain},
+		{"different project", `C:\Projects\Lobby.vtp`, "VisionTools Pro-e", WindowKindUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewClient(logger.NewNoOpLogger()).WithProjectFile(tt.project)
+			c.prober = fakeProber{0x100: {title: tt.title, width: 1920, height: 1080}}
+
+			assert.Equal(t, tt.want, c.ClassifyWindow(0x100))
+		})
+	}
+}
+
+func TestTitleMatchesProject(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, titleMatchesProject("Lobby.vtp - VisionTools Pro-e", `C:\Projects\LOBBY.VTP`))
+	assert.True(t, titleMatchesProject("Lobby.vtp - VisionTools Pro-e", "/projects/Lobby.vtp"))
+	assert.False(t, titleMatchesProject("Lobby.vtp - VisionTools Pro-e", ""))
+	assert.False(t, titleMatchesProject("Lobby.vtp - VisionTools Pro-e", `C:\Projects\`))
+	assert.False(t, titleMatchesProject("Boardroom.vtp - VisionTools Pro-e", `C:\Projects\Lobby.vtp`))
+}
+
+func TestClient_LoadedWhileMinimized(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{project: `C:\Projects\Lobby.vtp`}
+	assert.False(t, c.loadedWhileMinimized("Lobby.vtp - VisionTools Pro-e"), "only a minimized launch skips the loading dialogs")
+
+	c.WithLaunchMinimized(true)
+	assert.True(t, c.loadedWhileMinimized("Lobby.vtp - VisionTools Pro-e"))
+	assert.False(t, c.loadedWhileMinimized("VisionTools Pro-e"), "the project is not open until the title names it")
+}
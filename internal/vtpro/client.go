@@ -2,31 +2,147 @@
 package vtpro
 
 import (
-	"context"
+	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/errcode"
 	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/textutil"
 	"github.com/Norgate-AV/vtpc/internal/timeouts"
 	"github.com/Norgate-AV/vtpc/internal/windows"
 )
 
 // Client provides methods for interacting with VTPro processes
 type Client struct {
-	log logger.LoggerInterface
-	win *windows.Client
+	log       logger.LoggerInterface
+	win       *windows.Client
+	prober    WindowProber
+	controls  dialog.ControlSource
+	dialogs   *dialog.Registry
+	inspector ProcessInspector
+	project   string // Project file whose name identifies the main window title
+	titles    *TitleHistory
+	clock     clock.Clock // Measures the wait loops' deadlines
+	mainHwnd  uintptr     // Main window found by WaitForAppear, sampled for title changes
+	appearErr error       // Why the last WaitForAppear found no main window
+
+	expectTitle string         // Substring the main window's title must contain to be selected
+	selection   Selection      // Candidates considered by the most recent main window search
+	identity    WindowIdentity // What the main window and splash screen are recognised by
+
+	sleepPolicy        clock.SleepPolicy // What wait loops do when the system sleeps part way through
+	allowGlobalMonitor bool              // Allow StartMonitoring with PID 0 to watch every window
+	confirmTerminate   ConfirmTerminate  // Asked before force terminating VTPro, if set
+	neverTerminate     bool              // Leave a VTPro that will not close running rather than terminate it
+	launchMinimized    bool              // VTPro was launched minimized, so its loading dialogs may never show
+
+	enumerate   func() []windows.WindowInfo // Lists the top-level windows; windows.EnumerateWindows outside tests
+	kill        func(pid uint32) error      // Force terminates a process; windows.TerminateProcess outside tests
+	windowPid   func(hwnd uintptr) uint32   // PID owning a window, 0 once it is destroyed; windows.GetWindowPid outside tests
+	leftRunning atomic.Uint32               // PID of a VTPro cleanup left running under neverTerminate, or 0
 }
 
 // NewClient creates a new VTPro client
 func NewClient(log logger.LoggerInterface) *Client {
 	return &Client{
-		log: log,
-		win: windows.NewClient(log),
+		log:       log,
+		win:       windows.NewClient(log),
+		prober:    windowsProber{},
+		controls:  windows.NewWindowsAPI(log),
+		dialogs:   dialog.Default(),
+		inspector: windowsInspector{},
+		enumerate: windows.EnumerateWindows,
+		kill:      windows.TerminateProcess,
+		windowPid: windows.GetWindowPid,
+		titles:    NewTitleHistory(clock.New(), maxTitleHistory),
+		clock:     clock.New(),
+
+		sleepPolicy: clock.SleepExtend,
+		identity:    DefaultWindowIdentity(),
 	}
 }
 
+// WithSleepPolicy sets what the wait loops do when the system sleeps part way
+// through a wait; the default extends their deadlines by the sleep
+func (c *Client) WithSleepPolicy(policy clock.SleepPolicy) *Client {
+	c.sleepPolicy = policy
+	return c
+}
+
+// WithAllowGlobalMonitor allows StartMonitoring to be called with PID 0, which
+// watches every window on the desktop rather than just VTPro's
+func (c *Client) WithAllowGlobalMonitor(allow bool) *Client {
+	c.allowGlobalMonitor = allow
+	return c
+}
+
+// WithDialogs sets the registry dialogs are recognised by
+func (c *Client) WithDialogs(r *dialog.Registry) *Client {
+	c.dialogs = r
+	return c
+}
+
+// WithLaunchMinimized tells the client VTPro was launched minimized
+func (c *Client) WithLaunchMinimized(minimized bool) *Client {
+	c.launchMinimized = minimized
+	return c
+}
+
+// WithExpectTitle only accepts a main window whose title contains substr,
+// compared case-insensitively, so a wrong window is never selected silently
+func (c *Client) WithExpectTitle(substr string) *Client {
+	c.expectTitle = substr
+	return c
+}
+
+// WithWindowIdentity sets what VTPro's main window and splash screen are recognised
+// by, for VTPro builds whose windows differ from DefaultWindowIdentity
+func (c *Client) WithWindowIdentity(id WindowIdentity) *Client {
+	c.identity = id
+	return c
+}
+
+// Selection returns the windows considered by the most recent main window search
+func (c *Client) Selection() Selection {
+	return c.selection
+}
+
+// TitleHistory returns the main window titles seen while VTPro started and loaded the file
+func (c *Client) TitleHistory() *TitleHistory {
+	return c.titles
+}
+
+// recordTitle adds a main window title to the history, logging it if it changed
+func (c *Client) recordTitle(title string) {
+	if title == "" {
+		return
+	}
+
+	if c.titles.Record(title) {
+		c.log.Debug("Main window title changed", slog.String("title", title))
+	}
+}
+
+// AppearFailure returns why the last WaitForAppear found no main window: either
+// VTPro never showed a window, or it showed only windows that were not selected
+// as its main window. It is nil if the main window was found.
+func (c *Client) AppearFailure() error {
+	return c.appearErr
+}
+
+// WithProjectFile sets the project file whose name is matched against window titles
+func (c *Client) WithProjectFile(path string) *Client {
+	c.project = path
+	return c
+}
+
 // FindWindow searches for the VTPro main window belonging to a specific process
 // targetPid must be a valid process ID - passing 0 will return no results
 func (c *Client) FindWindow(targetPid uint32, debug bool) (uintptr, string) {
@@ -34,16 +150,73 @@ func (c *Client) FindWindow(targetPid uint32, debug bool) (uintptr, string) {
 	return result.mainHwnd, result.mainTitle
 }
 
+// ResolveMainWindow returns cached if it is still a window of pid. VTPro can
+// replace its main window, for example after a conversion dialog, so otherwise
+// the main window is searched for again. It reports false if pid has no main
+// window.
+func (c *Client) ResolveMainWindow(pid uint32, cached uintptr) (uintptr, bool) {
+	if cached != 0 && c.windowPid(cached) == pid {
+		return cached, true
+	}
+
+	c.log.Debug("Main window is gone, searching for it again",
+		slog.Uint64("hwnd", uint64(cached)),
+		slog.Uint64("pid", uint64(pid)),
+	)
+
+	result := c.findWindowWithTracking(pid, true, nil)
+	if result.mainHwnd == 0 {
+		c.log.Warn("VTPro no longer has a main window", slog.Uint64("pid", uint64(pid)))
+		return 0, false
+	}
+
+	c.log.Info("VTPro replaced its main window",
+		slog.Uint64("was", uint64(cached)),
+		slog.Uint64("now", uint64(result.mainHwnd)),
+	)
+
+	c.recordTitle(result.mainTitle)
+	c.mainHwnd = result.mainHwnd
+
+	return result.mainHwnd, true
+}
+
 // windowSearchResult contains the results of a window search
 type windowSearchResult struct {
 	mainHwnd    uintptr
 	mainTitle   string
 	foundSplash bool
+	splashTitle string
+}
+
+// seenWindows records the windows of the process a wait has seen, in the order
+// they first appeared, each as it was last seen
+type seenWindows struct {
+	index   map[uintptr]int
+	windows []Candidate
+}
+
+// newSeenWindows creates an empty record of seen windows
+func newSeenWindows() *seenWindows {
+	return &seenWindows{index: make(map[uintptr]int)}
+}
+
+// add records a candidate, reporting whether its window had not been seen before
+func (s *seenWindows) add(cand Candidate) bool {
+	if i, ok := s.index[cand.Hwnd]; ok {
+		s.windows[i] = cand
+		return false
+	}
+
+	s.index[cand.Hwnd] = len(s.windows)
+	s.windows = append(s.windows, cand)
+
+	return true
 }
 
 // findWindowWithTracking is the internal implementation that supports window tracking
 // Returns the main window handle and title if found, or indicates if only splash screen was detected
-func (c *Client) findWindowWithTracking(targetPid uint32, debug bool, seenWindows map[uintptr]bool) windowSearchResult {
+func (c *Client) findWindowWithTracking(targetPid uint32, debug bool, seen *seenWindows) windowSearchResult {
 	result := windowSearchResult{}
 
 	// Must have a valid PID to search for windows
@@ -54,75 +227,47 @@ func (c *Client) findWindowWithTracking(targetPid uint32, debug bool, seenWindow
 		return result
 	}
 
-	// Enumerate windows (thread-safe)
-	windowsList := windows.EnumerateWindows()
-
-	// Look for windows belonging to our process
-	var mainWindow windows.WindowInfo
-	var splashWindow windows.WindowInfo
-
-	for _, w := range windowsList {
-		if w.Pid == targetPid {
-			// Only log if debug is enabled AND we haven't seen this window before
-			shouldLog := debug && (seenWindows == nil || !seenWindows[w.Hwnd])
-			if shouldLog {
-				c.log.Debug("Window found",
-					slog.String("title", w.Title),
-					slog.Uint64("hwnd", uint64(w.Hwnd)),
+	// Enumerate windows (thread-safe), forgetting classes of windows that have gone
+	windows.PruneClassCache()
+	sel := c.selectMainWindow(c.enumerate(), targetPid)
+	c.selection = sel
+
+	for _, cand := range sel.Candidates {
+		// Only log if debug is enabled AND we haven't seen this window before
+		isNew := seen == nil || seen.add(cand)
+		if debug && isNew {
+			c.log.Debug("Window found",
+				slog.String("title", cand.Title),
+				slog.Uint64("hwnd", uint64(cand.Hwnd)),
+				slog.String("kind", cand.Kind.String()),
+				slog.Bool("chosen", cand.Chosen),
+				slog.String("reason", cand.Reason),
+			)
+			if cand.Kind == WindowKindMain && c.expectTitle != "" && !textutil.ContainsFold(cand.Title, c.expectTitle) {
+				c.log.Warn("Main window rejected by --expect-title",
+					slog.String("title", cand.Title),
+					slog.String("expected", c.expectTitle),
 				)
-				if seenWindows != nil {
-					seenWindows[w.Hwnd] = true
-				}
-			}
-
-			// Get window class name and lowercase title for identification
-			className := windows.GetClassName(w.Hwnd)
-			title := strings.ToLower(w.Title)
-
-			// Priority 1: Window with .vtp in title - file is definitely loaded
-			if strings.Contains(w.Title, ".vtp") {
-				mainWindow = w
-				break
-			}
-
-			// Priority 2: VWT32AppClass - this is the main application window
-			if className == "VWT32AppClass" {
-				mainWindow = w
-				break
-			}
-
-			// Skip progress dialogs
-			if strings.Contains(title, "progress") {
-				continue
-			}
-
-			// Skip splash screen - remember it but keep looking
-			if w.Title == "VTPro" {
-				splashWindow = w
-				continue
 			}
+		}
 
-			// Skip common dialog window class (#32770)
-			if className == "#32770" {
-				continue
-			}
+		// Remember the splash screen in case there is no main window yet
+		if cand.Kind == WindowKindSplash && !result.foundSplash {
+			result.foundSplash = true
+			result.splashTitle = cand.Title
 		}
 	}
 
 	// If we found a main window with a more specific title, use it
-	if mainWindow.Hwnd != 0 {
+	if chosen, ok := sel.Chosen(); ok {
 		if debug {
-			c.log.Debug("Found main window", slog.String("title", mainWindow.Title))
+			c.log.Debug("Found main window",
+				slog.String("title", chosen.Title),
+				slog.String("selection", sel.String()),
+			)
 		}
 
-		result.mainHwnd = mainWindow.Hwnd
-		result.mainTitle = mainWindow.Title
-		return result
-	}
-
-	// If we only found the generic splash screen, indicate it but return no handle
-	if splashWindow.Hwnd != 0 {
-		result.foundSplash = true
+		return windowSearchResult{mainHwnd: chosen.Hwnd, mainTitle: chosen.Title}
 	}
 
 	return result
@@ -130,7 +275,7 @@ func (c *Client) findWindowWithTracking(targetPid uint32, debug bool, seenWindow
 
 // WaitForReady waits for a window to become fully responsive
 func (c *Client) WaitForReady(hwnd uintptr, timeout time.Duration) bool {
-	deadline := time.Now().Add(timeout)
+	deadline := clock.NewDeadline(c.clock, timeout, c.sleepPolicy)
 	elapsed := 0
 
 	c.log.Debug("Waiting for window ready state",
@@ -138,7 +283,7 @@ func (c *Client) WaitForReady(hwnd uintptr, timeout time.Duration) bool {
 		slog.String("timeout", timeout.String()),
 	)
 
-	for time.Now().Before(deadline) {
+	for c.waiting(deadline) {
 		debug := elapsed%30 == 0 // Debug every 3 seconds
 
 		if c.isWindowResponsive(hwnd, debug) {
@@ -168,20 +313,26 @@ func (c *Client) WaitForReady(hwnd uintptr, timeout time.Duration) bool {
 // WaitForAppear waits for the VTPro main window to appear for a specific process
 // targetPid must be a valid process ID - passing 0 will immediately return failure
 func (c *Client) WaitForAppear(targetPid uint32, timeout time.Duration) (uintptr, bool) {
-	deadline := time.Now().Add(timeout)
-	seenWindows := make(map[uintptr]bool) // Track windows we've already logged
-	loggedSplashOnly := false             // Track if we've logged "splash screen detected" message
+	deadline := clock.NewDeadline(c.clock, timeout, c.sleepPolicy)
+	seen := newSeenWindows()  // Track windows we've already logged
+	loggedSplashOnly := false // Track if we've logged "splash screen detected" message
+
+	c.appearErr = nil
 
 	c.log.Debug("Searching for window", slog.Uint64("pid", uint64(targetPid)))
 
-	for time.Now().Before(deadline) {
-		// Check for the main VTPro window, passing seenWindows for tracking
-		result := c.findWindowWithTracking(targetPid, true, seenWindows)
+	for c.waiting(deadline) {
+		// Check for the main VTPro window, passing seen for tracking
+		result := c.findWindowWithTracking(targetPid, true, seen)
 
 		if result.mainHwnd != 0 {
+			c.recordTitle(result.mainTitle)
+			c.mainHwnd = result.mainHwnd
 			return result.mainHwnd, true
 		}
 
+		c.recordTitle(result.splashTitle)
+
 		// If we detected a splash screen but no main window yet, log it once
 		if result.foundSplash && !loggedSplashOnly {
 			c.log.Debug("Found splash screen, continuing to wait for main window")
@@ -192,16 +343,70 @@ func (c *Client) WaitForAppear(targetPid uint32, timeout time.Duration) (uintptr
 	}
 
 	c.log.Debug("Timeout reached, performing final detailed check")
-	result := c.findWindowWithTracking(targetPid, true, seenWindows)
+	result := c.findWindowWithTracking(targetPid, true, seen)
 	if result.mainHwnd != 0 {
 		c.log.Debug("Found window at timeout", slog.String("title", result.mainTitle))
+		c.recordTitle(result.mainTitle)
+		c.mainHwnd = result.mainHwnd
 		return result.mainHwnd, true
 	}
 
+	c.appearErr = appearFailure(targetPid, seen.windows, c.identity)
+
 	return 0, false
 }
 
+// ErrNoWindows and ErrNoMainWindow tell apart the two ways WaitForAppear can time out
+var (
+	ErrNoWindows    = errcode.New(errcode.VTProNoWindows, "VTPro never showed a window")
+	ErrNoMainWindow = errcode.New(errcode.VTProNoMainWindow, "VTPro showed windows but none was selected as its main window")
+)
+
+// appearFailure explains a wait that found no main window from the windows it
+// saw. No windows at all points at the launch; windows that were not recognised
+// are listed, with their classes, so the classifier can be extended to them or
+// the main window class changed to match the VTPro build.
+func appearFailure(pid uint32, seen []Candidate, id WindowIdentity) error {
+	if len(seen) == 0 {
+		return fmt.Errorf("%w for PID %d; check that it launched, and that antivirus did not block or quarantine it", ErrNoWindows, pid)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d window(s) seen for PID %d:", len(seen), pid)
+
+	for _, cand := range seen {
+		b.WriteString("\n  ")
+		b.WriteString(cand.String())
+	}
+
+	var classes []string
+	for _, cand := range seen {
+		if cand.Class != "" && !slices.Contains(classes, cand.Class) {
+			classes = append(classes, cand.Class)
+		}
+	}
+
+	if len(classes) > 0 {
+		fmt.Fprintf(&b, "\nclasses seen: %s; the main window class expected is %s, change it with --main-window-class or vtpro.mainWindowClass in the config file",
+			strings.Join(classes, ", "), id.MainClass)
+	}
+
+	return fmt.Errorf("%w; %s", ErrNoMainWindow, b.String())
+}
+
+// waiting polls a wait loop's deadline, logging any sleep it detects, and
+// reports whether the loop should keep waiting
+func (c *Client) waiting(d *clock.Deadline) bool {
+	slept, err := d.Poll()
+	if slept > 0 {
+		c.log.Warn(d.SleepMessage(slept))
+	}
+
+	return err == nil && !d.Expired()
+}
+
 // Cleanup ensures VTPro is properly closed, with fallback to force termination
+// unless WithNeverTerminate was used
 func (c *Client) Cleanup(hwnd uintptr, pid uint32) {
 	if hwnd == 0 {
 		return
@@ -220,9 +425,9 @@ func (c *Client) Cleanup(hwnd uintptr, pid uint32) {
 	// Poll for up to 3 seconds to see if window closes
 	maxWait := 3 * time.Second
 	pollInterval := 200 * time.Millisecond
-	deadline := time.Now().Add(maxWait)
+	deadline := clock.NewDeadline(c.clock, maxWait, c.sleepPolicy)
 
-	for time.Now().Before(deadline) {
+	for c.waiting(deadline) {
 		if !windows.IsWindow(hwnd) {
 			c.log.Debug("Window closed successfully")
 			return
@@ -231,16 +436,16 @@ func (c *Client) Cleanup(hwnd uintptr, pid uint32) {
 		time.Sleep(pollInterval)
 	}
 
-	// Window still exists after waiting - force terminate
+	// Window still exists after waiting - force terminate, if allowed
 	c.log.Warn("VTPro did not close properly after waiting")
 	if pid != 0 {
-		c.log.Debug("Attempting to force terminate process", slog.Uint64("pid", uint64(pid)))
-		_ = windows.TerminateProcess(pid)
+		c.terminate(pid)
 	}
 }
 
 // ForceCleanup attempts to forcefully close VTPro using the known PID.
-// It tries two approaches in order:
+// The PID is verified, and confirmed if WithConfirmTerminate was used, before
+// the process is terminated. It tries two approaches in order:
 // 1. Use hwnd if available (graceful close with PID for force termination)
 // 2. Use known PID (forced termination)
 func (c *Client) ForceCleanup(hwnd uintptr, knownPid uint32) {
@@ -253,7 +458,7 @@ func (c *Client) ForceCleanup(hwnd uintptr, knownPid uint32) {
 	// Strategy 2: Use known PID for forced termination
 	if knownPid != 0 {
 		c.log.Debug("Force terminating with known PID", slog.Uint64("pid", uint64(knownPid)))
-		_ = windows.TerminateProcess(knownPid)
+		c.terminate(knownPid)
 		return
 	}
 
@@ -269,11 +474,8 @@ func (c *Client) WaitForFileLoaded(pid uint32, timeout time.Duration) bool {
 		return false
 	}
 
-	deadline := time.Now().Add(timeout)
-	const (
-		dialogFileLoading = "VisionTools Pro-e"
-		dialogProgress    = "Progress"
-	)
+	start := time.Now()
+	deadline := clock.NewDeadline(c.clock, timeout, c.sleepPolicy)
 
 	// Track dialog states
 	seenFileLoadingDialog := false
@@ -282,32 +484,45 @@ func (c *Client) WaitForFileLoaded(pid uint32, timeout time.Duration) bool {
 
 	c.log.Info("Waiting for file to fully load...")
 	c.log.Debug("Monitoring for file loading dialogs",
-		slog.String("fileLoadingDialog", dialogFileLoading),
-		slog.String("progressDialog", dialogProgress))
+		slog.String("fileLoadingDialog", dialog.FileLoading.Name),
+		slog.String("progressDialog", dialog.LoadProgress.Name))
 
-	for time.Now().Before(deadline) {
+	for c.waiting(deadline) {
 		select {
 		case ev := <-windows.MonitorCh:
+			d, _ := c.dialogs.Match(dialog.PhaseLoad, ev.Title, ev.Class)
+
 			// Check for file loading dialog
-			if ev.Title == dialogFileLoading {
+			if d.Name == dialog.FileLoading.Name {
 				if !seenFileLoadingDialog {
-					c.log.Debug("Detected file loading dialog", slog.String("title", ev.Title))
+					c.log.Debug("Detected file loading dialog", slog.String("title", ev.DisplayTitle()))
 					seenFileLoadingDialog = true
 				}
 				lastDialogSeenTime = time.Now()
 			}
 
 			// Check for progress dialog (can appear multiple times with % in title)
-			if strings.Contains(ev.Title, dialogProgress) {
+			if d.Name == dialog.LoadProgress.Name {
 				if !seenProgressDialog {
-					c.log.Debug("Detected progress dialog", slog.String("title", ev.Title))
+					c.log.Debug("Detected progress dialog", slog.String("title", ev.DisplayTitle()))
 					seenProgressDialog = true
 				}
 				lastDialogSeenTime = time.Now()
 			}
 
 		default:
-			// No events in channel
+			// No events in channel, so sample the main window title for the history
+			if c.mainHwnd != 0 {
+				title := c.prober.GetWindowText(c.mainHwnd)
+				c.recordTitle(title)
+
+				if !seenFileLoadingDialog && time.Since(start) > 2*time.Second && c.loadedWhileMinimized(title) {
+					c.log.Info("File loading complete")
+					c.log.Debug("Minimized main window names the project", slog.String("title", title))
+					return true
+				}
+			}
+
 			// If we've seen loading dialogs and haven't seen any for 2 seconds, they're likely closed
 			if seenFileLoadingDialog && time.Since(lastDialogSeenTime) > 2*time.Second {
 				c.log.Debug("File loading dialogs appear to have closed")
@@ -333,39 +548,56 @@ func (c *Client) WaitForFileLoaded(pid uint32, timeout time.Duration) bool {
 	return false
 }
 
-// StartMonitoring starts a background goroutine that monitors VTPro dialogs for a specific PID
-// Returns a function to stop the monitoring
-func (c *Client) StartMonitoring(pid uint32) func() {
-	ctx, cancel := context.WithCancel(context.Background())
+// loadedWhileMinimized reports whether a VTPro launched minimized has loaded the
+// project. Its loading dialogs are hidden along with the minimized main window,
+// so the main window title naming the project is taken as the sign instead.
+func (c *Client) loadedWhileMinimized(title string) bool {
+	return c.launchMinimized && titleMatchesProject(title, c.project)
+}
 
-	go func() {
-		// Init channel with larger buffer to handle bursts of events
-		// (startup splash screens, progress dialogs, warnings, compiling dialog, etc.)
-		windows.MonitorCh = make(chan windows.WindowEvent, 256)
+// ErrNoMonitorPid is returned when monitoring is started without a PID and global monitoring is not allowed
+var ErrNoMonitorPid = errcode.New(errcode.MonitorNoPid, "window monitor needs a VTPro PID; monitoring every window is not allowed")
 
-		if pid == 0 {
-			c.log.Warn("Window monitor started with PID=0, monitoring all processes (not recommended)")
-			c.win.Monitor.StartWindowMonitor(ctx, 0, timeouts.MonitorPollingInterval)
-		} else {
-			c.log.Debug("Window monitor targeting SIMPL PID", slog.Uint64("pid", uint64(pid)))
-			c.win.Monitor.StartWindowMonitor(ctx, pid, timeouts.MonitorPollingInterval)
+// StartMonitoring starts a background monitor of VTPro dialogs for a specific PID.
+// windows.MonitorCh is ready when it returns, and the returned stop function
+// waits for the monitor to exit.
+func (c *Client) StartMonitoring(pid uint32) (stop func(), err error) {
+	if pid == 0 {
+		if !c.allowGlobalMonitor {
+			return nil, ErrNoMonitorPid
 		}
 
-		// Wait for cancellation
-		<-ctx.Done()
-	}()
+		c.log.Warn("Window monitor started with PID=0, monitoring all processes (not recommended)")
+	} else {
+		c.log.Debug("Window monitor targeting VTPro PID", slog.Uint64("pid", uint64(pid)))
+	}
+
+	// Dialogs whose text is read may close themselves before their event is handled
+	c.win.Monitor.SetSnapshotFilter(c.dialogs.Critical)
 
-	return func() {
-		cancel()
+	stop, err = c.win.Monitor.StartWindowMonitor(pid, timeouts.MonitorPollingInterval)
+	if err != nil {
+		return nil, fmt.Errorf("error starting window monitor: %w", err)
 	}
+
+	return stop, nil
+}
+
+// MonitorStats returns the stats of the current or most recent window monitor run
+func (c *Client) MonitorStats() windows.MonitorStats {
+	return c.win.Monitor.Stats()
+}
+
+// DialogSpans returns how long each window of the current or most recent
+// window monitor run stayed open, longest first, counting those still open up to end
+func (c *Client) DialogSpans(end time.Time) []windows.DialogSpan {
+	return c.win.Monitor.DialogSpans(end)
 }
 
 // HandlePostLoadDialogs checks for and dismisses warning dialogs that may appear after file load
 // This includes the "VisionTools(R) Pro-e" warning dialog containing messages like path limitation warnings.
 // This MUST be called BEFORE bringing the window to foreground to ensure dialogs don't interfere.
 func (c *Client) HandlePostLoadDialogs() error {
-	const dialogVTProWarning = "VisionTools(R) Pro-e"
-
 	// Longer timeout to catch warning dialogs that may appear after file load
 	timeout := time.NewTimer(3 * time.Second)
 	defer timeout.Stop()
@@ -377,25 +609,25 @@ func (c *Client) HandlePostLoadDialogs() error {
 		select {
 		case ev := <-windows.MonitorCh:
 			c.log.Debug("Received post-load dialog event",
-				slog.String("title", ev.Title),
+				slog.String("title", ev.DisplayTitle()),
 				slog.Uint64("hwnd", uint64(ev.Hwnd)))
 
 			dialogCount++
 
 			// Enumerate and log all child controls for this dialog (for debugging)
-			c.enumerateDialogControls(ev.Hwnd, ev.Title)
+			dialog.LogControls(c.log, c.controls, ev.Hwnd, ev.DisplayTitle())
 
 			// Handle warning dialogs that may appear after file load
-			if ev.Title == dialogVTProWarning {
+			if d, ok := c.dialogs.Match(dialog.PhasePostLoad, ev.Title, ev.Class); ok && d.Action == dialog.ActionClose {
 				c.log.Debug("Detected VTPro warning dialog - closing")
 				c.log.Info("Handling post-load warning dialog")
-				c.win.Window.CloseWindow(ev.Hwnd, dialogVTProWarning)
+				c.win.Window.CloseWindow(ev.Hwnd, ev.DisplayTitle())
 
 				// Give time for dialog to close
 				time.Sleep(500 * time.Millisecond)
 			} else {
 				// Log but don't handle other dialogs here
-				c.log.Trace("Ignoring post-load dialog", slog.String("title", ev.Title))
+				c.log.Trace("Ignoring post-load dialog", slog.String("title", ev.DisplayTitle()))
 			}
 
 		case <-timeout.C:
@@ -410,54 +642,6 @@ func (c *Client) HandlePostLoadDialogs() error {
 	return nil
 }
 
-// enumerateDialogControls enumerates and logs all child controls in a dialog window
-func (c *Client) enumerateDialogControls(hwnd uintptr, title string) {
-	c.log.Trace("Enumerating dialog controls",
-		slog.String("title", title),
-		slog.Uint64("hwnd", uint64(hwnd)))
-
-	// Get the main window text (dialog body text, if any)
-	windowText := windows.GetWindowText(hwnd)
-	if windowText != "" {
-		c.log.Trace("Dialog window text",
-			slog.String("title", title),
-			slog.String("text", windowText))
-	}
-
-	// Collect all child controls
-	childInfos := windows.CollectChildInfos(hwnd)
-
-	if len(childInfos) == 0 {
-		c.log.Trace("No child controls found in dialog",
-			slog.String("title", title))
-		return
-	}
-
-	c.log.Trace("Found child controls in dialog",
-		slog.String("title", title),
-		slog.Int("count", len(childInfos)))
-
-	// Log details for each child control
-	for i, ci := range childInfos {
-		logAttrs := []any{
-			slog.String("title", title),
-			slog.Int("index", i),
-			slog.Uint64("childHwnd", uint64(ci.Hwnd)),
-			slog.String("className", ci.ClassName),
-		}
-
-		if ci.Text != "" {
-			logAttrs = append(logAttrs, slog.String("text", ci.Text))
-		}
-
-		if len(ci.Items) > 0 {
-			logAttrs = append(logAttrs, slog.Any("items", ci.Items))
-		}
-
-		c.log.Trace("Child control", logAttrs...)
-	}
-}
-
 // isWindowResponsive checks if a window is responding to messages
 func (c *Client) isWindowResponsive(hwnd uintptr, debug bool) bool {
 	var result uintptr
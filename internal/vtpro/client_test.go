@@ -0,0 +1,238 @@
+package vtpro
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestClient_WaitingExtendsOverSleep(t *testing.T) {
+	t.Parallel()
+
+	log := testutil.NewMockLogger()
+	clk := clock.NewFake(titleEpoch)
+	c := NewClient(log).WithSleepPolicy(clock.SleepExtend)
+	deadline := clock.NewDeadline(clk, 3*time.Minute, c.sleepPolicy)
+
+	clk.Advance(42 * time.Minute)
+	assert.True(t, c.waiting(deadline), "the sleep is not counted against the wait")
+	assert.Equal(t, []string{"System appears to have slept for 42m; extending deadline"}, log.Messages())
+
+	for range 5 {
+		clk.Advance(30 * time.Second)
+		assert.True(t, c.waiting(deadline))
+	}
+
+	clk.Advance(30 * time.Second)
+	assert.False(t, c.waiting(deadline), "waiting after the sleep still counts")
+}
+
+func TestClient_WaitingStopsOnSleepWhenFailing(t *testing.T) {
+	t.Parallel()
+
+	log := testutil.NewMockLogger()
+	clk := clock.NewFake(titleEpoch)
+	c := NewClient(log).WithSleepPolicy(clock.SleepFail)
+	deadline := clock.NewDeadline(clk, 3*time.Minute, c.sleepPolicy)
+
+	clk.Advance(time.Minute)
+	assert.True(t, c.waiting(deadline))
+
+	clk.Advance(42 * time.Minute)
+	assert.False(t, c.waiting(deadline))
+	assert.Equal(t, []string{"System appears to have slept for 42m; failing the wait"}, log.Messages())
+}
+
+// scriptedClient returns a client whose window enumerations return each of
+// script in turn, repeating the last, with 20 seconds passing on each
+func scriptedClient(script ...[]windows.WindowInfo) *Client {
+	clk := clock.NewFake(titleEpoch)
+
+	c := NewClient(logger.NewNoOpLogger())
+	c.prober = selectionProber
+	c.clock = clk
+
+	calls := 0
+	c.enumerate = func() []windows.WindowInfo {
+		step := script[min(calls, len(script)-1)]
+		calls++
+		clk.Advance(20 * time.Second)
+
+		return step
+	}
+
+	return c
+}
+
+func TestWaitForAppear_FindsMainWindow(t *testing.T) {
+	t.Parallel()
+
+	c := scriptedClient(nil, selectionWindows[:2], selectionWindows)
+
+	hwnd, ok := c.WaitForAppear(1234, time.Minute)
+	assert.True(t, ok)
+	assert.Equal(t, uintptr(0x300), hwnd)
+	assert.NoError(t, c.AppearFailure())
+}
+
+func TestWaitForAppear_NoWindows(t *testing.T) {
+	t.Parallel()
+
+	// Only another process ever shows a window
+	c := scriptedClient([]windows.WindowInfo{{Hwnd: 0x900, Title: "Notepad", Pid: 4321}})
+
+	_, ok := c.WaitForAppear(1234, time.Minute)
+	require.False(t, ok)
+
+	err := c.AppearFailure()
+	assert.ErrorIs(t, err, ErrNoWindows)
+	assert.NotErrorIs(t, err, ErrNoMainWindow)
+	assert.Equal(t, "VTPro never showed a window for PID 1234; check that it launched, and that antivirus did not block or quarantine it", err.Error())
+}
+
+func TestWaitForAppear_OnlyUnrecognisedWindows(t *testing.T) {
+	t.Parallel()
+
+	// The tool window shows first, then the dialog, and neither is the main window
+	c := scriptedClient(selectionWindows[:1], selectionWindows[:2])
+
+	_, ok := c.WaitForAppear(1234, time.Minute)
+	require.False(t, ok)
+
+	err := c.AppearFailure()
+	assert.ErrorIs(t, err, ErrNoMainWindow)
+	assert.NotErrorIs(t, err, ErrNoWindows)
+	assert.Equal(t, "VTPro showed windows but none was selected as its main window; 2 window(s) seen for PID 1234:\n"+
+		`  0x100 "Output Compiler" class=Afx:400000 kind=unknown: rejected (not the main window: no main window traits)`+"\n"+
+		`  0x200 "VisionTools(R) Pro-e" class=#32770 kind=dialog: rejected (not the main window: dialog class #32770)`+"\n"+
+		"classes seen: Afx:400000, #32770; the main window class expected is VWT32AppClass, change it with --main-window-class or vtpro.mainWindowClass in the config file",
+		err.Error())
+}
+
+func TestWaitForAppear_CustomMainWindowClass(t *testing.T) {
+	t.Parallel()
+
+	// A VTPro build whose main window registers a class of its own, before its title names the project
+	window := []windows.WindowInfo{{Hwnd: 0x500, Title: "VisionTools Pro-e", Pid: 1234}}
+	prober := fakeProber{0x500: {title: "VisionTools Pro-e", class: "VWT64AppClass", width: 1920, height: 1080}}
+
+	c := scriptedClient(window)
+	c.prober = prober
+
+	_, ok := c.WaitForAppear(1234, time.Minute)
+	require.False(t, ok, "the default class does not match")
+	assert.Contains(t, c.AppearFailure().Error(), "classes seen: VWT64AppClass; the main window class expected is VWT32AppClass")
+
+	c = scriptedClient(window).WithWindowIdentity(WindowIdentity{MainClass: "VWT64AppClass", SplashTitle: DefaultSplashTitle})
+	c.prober = prober
+
+	hwnd, ok := c.WaitForAppear(1234, time.Minute)
+	require.True(t, ok)
+	assert.Equal(t, uintptr(0x500), hwnd)
+}
+
+func TestWaitForAppear_ListsWindowsThatCameAndWent(t *testing.T) {
+	t.Parallel()
+
+	// The dialog closes before the wait ends, but is still listed
+	c := scriptedClient(selectionWindows[1:2], selectionWindows[:1])
+
+	_, ok := c.WaitForAppear(1234, time.Minute)
+	require.False(t, ok)
+
+	err := c.AppearFailure()
+	assert.ErrorIs(t, err, ErrNoMainWindow)
+	assert.Contains(t, err.Error(), "2 window(s) seen for PID 1234")
+	assert.Contains(t, err.Error(), `0x200 "VisionTools(R) Pro-e"`)
+}
+
+func TestSeenWindows_KeepsFirstSeenOrderAndLatestState(t *testing.T) {
+	t.Parallel()
+
+	seen := newSeenWindows()
+
+	assert.True(t, seen.add(Candidate{Hwnd: 0x100, Title: ""}))
+	assert.True(t, seen.add(Candidate{Hwnd: 0x200, Title: "VisionTools(R) Pro-e"}))
+	assert.False(t, seen.add(Candidate{Hwnd: 0x100, Title: "Output Compiler"}))
+
+	assert.Equal(t, []Candidate{
+		{Hwnd: 0x100, Title: "Output Compiler"},
+		{Hwnd: 0x200, Title: "VisionTools(R) Pro-e"},
+	}, seen.windows)
+}
+
+// windowOwners returns a windowPid that finds each window's owner in owners,
+// 0 for a window that has been destroyed
+func windowOwners(owners map[uintptr]uint32) func(uintptr) uint32 {
+	return func(hwnd uintptr) uint32 { return owners[hwnd] }
+}
+
+func TestResolveMainWindow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		cached   uintptr
+		owners   map[uintptr]uint32
+		windows  []windows.WindowInfo
+		wantHwnd uintptr
+		wantOK   bool
+	}{
+		{
+			name:     "cached window still valid",
+			cached:   0x300,
+			owners:   map[uintptr]uint32{0x300: 1234},
+			wantHwnd: 0x300,
+			wantOK:   true,
+		},
+		{
+			name:     "stale window replaced",
+			cached:   0x250,
+			owners:   map[uintptr]uint32{0x300: 1234},
+			windows:  selectionWindows,
+			wantHwnd: 0x300,
+			wantOK:   true,
+		},
+		{
+			name:     "handle reused by another process",
+			cached:   0x900,
+			owners:   map[uintptr]uint32{0x900: 4321, 0x300: 1234},
+			windows:  selectionWindows,
+			wantHwnd: 0x300,
+			wantOK:   true,
+		},
+		{
+			name:    "no replacement",
+			cached:  0x250,
+			owners:  map[uintptr]uint32{},
+			windows: selectionWindows[:3],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newSelectionClient()
+			c.windowPid = windowOwners(tt.owners)
+
+			enumerated := false
+			c.enumerate = func() []windows.WindowInfo {
+				enumerated = true
+				return tt.windows
+			}
+
+			hwnd, ok := c.ResolveMainWindow(1234, tt.cached)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantHwnd, hwnd)
+			assert.Equal(t, tt.windows != nil, enumerated, "windows are only searched when the cached one is gone")
+		})
+	}
+}
@@ -3,8 +3,13 @@ package vtpro
 import (
 	"fmt"
 	"os"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
 )
 
+// ErrVTProNotFound is returned when the VTPro executable does not exist
+var ErrVTProNotFound = errcode.New(errcode.VTProMissing, "VTPro not found")
+
 const DefaultVTProPath = "C:\\Program Files (x86)\\Crestron\\VtPro-e\\vtpro.exe"
 
 // GetVTProPath returns the path to the VTPro executable.
@@ -26,12 +31,12 @@ func ValidateVTProInstallation() error {
 	var err error
 	if _, err = os.Stat(path); os.IsNotExist(err) {
 		if os.Getenv("VTPRO_PATH") != "" {
-			return fmt.Errorf("VTPro not found at custom path: %s\n"+
-				"Please verify the VTPRO_PATH environment variable is correct", path)
+			return fmt.Errorf("%w at custom path: %s\n"+
+				"Please verify the VTPRO_PATH environment variable is correct", ErrVTProNotFound, path)
 		}
 
-		return fmt.Errorf("VTPro not found at default path: %s\n"+
-			"Please install VTPro or set VTPRO_PATH environment variable", path)
+		return fmt.Errorf("%w at default path: %s\n"+
+			"Please install VTPro or set VTPRO_PATH environment variable", ErrVTProNotFound, path)
 	}
 
 	if err != nil {
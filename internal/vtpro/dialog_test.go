@@ -0,0 +1,73 @@
+package vtpro
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/dialog"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestClient_HandlePostLoadDialogsLogsControls(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	mockWin := testutil.NewMockWindowManager().
+		WithWindowText(0x5555, "Theme").
+		WithChildInfosForHwnd(0x5555,
+			windows.ChildInfo{Hwnd: 0x5501, ClassName: "Static", Text: "Loading project themes"},
+			windows.ChildInfo{Hwnd: 0x5502, ClassName: "ListBox", Items: []string{"Dark", "Light"}},
+		)
+	log := testutil.NewMockLogger()
+	c := &Client{log: log, controls: mockWin, dialogs: dialog.Default()}
+
+	// A dialog vtpc does not handle is only logged, so no real window is touched
+	testutil.SendEventsToMonitor(windows.WindowEvent{Hwnd: 0x5555, Title: "Theme"})
+
+	assert.NoError(t, c.HandlePostLoadDialogs())
+
+	// The client logs the dialog exactly as the shared helper does
+	want := testutil.NewMockLogger()
+	dialog.LogControls(want, mockWin, 0x5555, "Theme")
+
+	start := -1
+	for i, msg := range log.Messages() {
+		if msg == "Enumerating dialog controls" {
+			start = i
+			break
+		}
+	}
+
+	if assert.GreaterOrEqual(t, start, 0, "dialog controls should be logged") {
+		assert.Equal(t, want.Entries, log.Entries[start:start+len(want.Entries)])
+	}
+
+	assert.Contains(t, log.Messages(), "Ignoring post-load dialog")
+	assert.Empty(t, mockWin.CloseWindowCalls)
+}
+
+func TestClient_HandlePostLoadDialogsIgnoresUntitledDialogs(t *testing.T) {
+	testutil.SetupMonitorChannel()
+	defer testutil.CleanupMonitorChannel()
+
+	mockWin := testutil.NewMockWindowManager()
+	log := testutil.NewMockLogger()
+	c := &Client{log: log, controls: mockWin, dialogs: dialog.Default()}
+
+	// Closing would need c.win, so a match here would panic rather than pass
+	testutil.SendEventsToMonitor(windows.WindowEvent{Hwnd: 0x5555, Title: "", Class: windows.DialogClass})
+
+	assert.NoError(t, c.HandlePostLoadDialogs())
+
+	for _, e := range log.Entries {
+		if e.Message == "Ignoring post-load dialog" {
+			assert.Equal(t, []any{slog.String("title", "Untitled (#32770)")}, e.Args)
+			return
+		}
+	}
+
+	t.Fatal("the untitled dialog should be logged as ignored")
+}
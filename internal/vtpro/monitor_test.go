@@ -0,0 +1,82 @@
+package vtpro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+func TestClient_StartMonitoringCreatesChannelBeforeReturning(t *testing.T) {
+	c := NewClient(logger.NewNoOpLogger())
+
+	stop, err := c.StartMonitoring(1234)
+	require.NoError(t, err)
+	defer stop()
+
+	assert.NotNil(t, windows.MonitorCh, "callers can read events as soon as StartMonitoring returns")
+}
+
+func TestClient_StartMonitoringTwiceFails(t *testing.T) {
+	c := NewClient(logger.NewNoOpLogger())
+
+	stop, err := c.StartMonitoring(1234)
+	require.NoError(t, err)
+
+	_, err = c.StartMonitoring(1234)
+	require.ErrorIs(t, err, windows.ErrMonitorRunning)
+
+	stop()
+	stop() // Stopping again is a no-op
+
+	stop, err = c.StartMonitoring(1234)
+	require.NoError(t, err, "the monitor can start again once stopped")
+	stop()
+}
+
+// Run with -race: start and stop must not race on MonitorCh or leak the monitor goroutine
+func TestClient_StartStopMonitoringLoop(t *testing.T) {
+	c := NewClient(logger.NewNoOpLogger())
+
+	for range 200 {
+		stop, err := c.StartMonitoring(1234)
+		require.NoError(t, err)
+
+		ch := windows.MonitorCh
+		require.NotNil(t, ch)
+
+		stop()
+	}
+}
+
+func TestClient_StartMonitoringWithoutPidFails(t *testing.T) {
+	c := NewClient(logger.NewNoOpLogger())
+
+	stop, err := c.StartMonitoring(0)
+
+	require.ErrorIs(t, err, ErrNoMonitorPid)
+	assert.Nil(t, stop)
+}
+
+func TestClient_StartMonitoringWithoutPidWhenAllowed(t *testing.T) {
+	c := NewClient(logger.NewNoOpLogger()).WithAllowGlobalMonitor(true)
+
+	stop, err := c.StartMonitoring(0)
+	require.NoError(t, err)
+	stop()
+}
+
+func TestClient_MonitorStatsCountsPolls(t *testing.T) {
+	c := NewClient(logger.NewNoOpLogger())
+
+	stop, err := c.StartMonitoring(1234)
+	require.NoError(t, err)
+	stop()
+
+	stats := c.MonitorStats()
+	assert.GreaterOrEqual(t, stats.Polls, 1, "the monitor polls at least once before stopping")
+	assert.Positive(t, stats.Interval)
+}
@@ -0,0 +1,64 @@
+package vtpro
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/Norgate-AV/vtpc/internal/artifact"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// Where VTPro-e keeps the compile output directory set in Edit > Preferences.
+// These are read best-effort; when none is set, artifacts are looked for next to the project.
+const (
+	preferencesRegistryKey = `Software\Crestron Electronics Inc.\VisionTools Pro-e\Preferences`
+	preferencesINISection  = "Preferences"
+)
+
+// outputDirNames are the value names the output directory has been stored under
+var outputDirNames = []string{"OutputDirectory", "CompileOutputPath"}
+
+// RegistryPreferences reads the compile output directory from VTPro's registry settings
+type RegistryPreferences struct {
+	Key   string
+	Names []string
+	read  func(subkey, value string) (string, error)
+}
+
+// OutputDir returns the first of the candidate values that is set
+func (p RegistryPreferences) OutputDir() (string, bool, error) {
+	for _, name := range p.Names {
+		dir, err := p.read(p.Key, name)
+		if errors.Is(err, windows.ErrRegistryNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return "", false, err
+		}
+
+		if dir != "" {
+			return dir, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// NewPreferenceReader returns the reader for VTPro's output directory preference,
+// trying the registry first and then the ini file in the user's AppData
+func NewPreferenceReader() artifact.PreferenceReader {
+	return artifact.Readers{
+		RegistryPreferences{
+			Key:   preferencesRegistryKey,
+			Names: outputDirNames,
+			read:  windows.ReadUserRegistryString,
+		},
+		artifact.INIPreferences{
+			Path:    filepath.Join(os.Getenv("APPDATA"), "Crestron", "VisionTools Pro-e", "VTPro.ini"),
+			Section: preferencesINISection,
+			Keys:    outputDirNames,
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package vtpro
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// fakeRegistry returns values from a map, or ErrRegistryNotFound
+func fakeRegistry(values map[string]string) func(subkey, value string) (string, error) {
+	return func(_, value string) (string, error) {
+		if v, ok := values[value]; ok {
+			return v, nil
+		}
+
+		return "", windows.ErrRegistryNotFound
+	}
+}
+
+func TestRegistryPreferences(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   string
+		wantOK bool
+	}{
+		{"first name", map[string]string{"OutputDirectory": `D:\Builds`}, `D:\Builds`, true},
+		{"falls back to second name", map[string]string{"CompileOutputPath": `E:\Out`}, `E:\Out`, true},
+		{"empty value is not configured", map[string]string{"OutputDirectory": ""}, "", false},
+		{"nothing set", map[string]string{}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := RegistryPreferences{Key: preferencesRegistryKey, Names: outputDirNames, read: fakeRegistry(tt.values)}
+
+			dir, ok, err := p.OutputDir()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, dir)
+		})
+	}
+}
+
+func TestRegistryPreferences_ReturnsReadErrors(t *testing.T) {
+	t.Parallel()
+
+	p := RegistryPreferences{
+		Key:   preferencesRegistryKey,
+		Names: outputDirNames,
+		read: func(string, string) (string, error) {
+			return "", errors.New("access denied")
+		},
+	}
+
+	_, ok, err := p.OutputDir()
+	assert.False(t, ok)
+	assert.ErrorContains(t, err, "access denied")
+}
@@ -0,0 +1,112 @@
+package vtpro
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/textutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// Candidate is a VTPro window considered while looking for the main window
+type Candidate struct {
+	Hwnd   uintptr
+	Title  string
+	Class  string
+	Kind   WindowKind
+	Chosen bool
+	Reason string // Why the window was chosen or rejected
+}
+
+// String formats a candidate on one line
+func (c Candidate) String() string {
+	verdict := "rejected"
+	if c.Chosen {
+		verdict = "chosen"
+	}
+
+	return fmt.Sprintf("0x%X %q class=%s kind=%s: %s (%s)", c.Hwnd, c.Title, c.Class, c.Kind, verdict, c.Reason)
+}
+
+// Selection records the windows the last main window search considered and which one it chose
+type Selection struct {
+	Pid         uint32
+	ExpectTitle string // Substring the chosen window's title had to contain, if set
+	Candidates  []Candidate
+}
+
+// Chosen returns the candidate selected as the main window, if any
+func (s Selection) Chosen() (Candidate, bool) {
+	for _, c := range s.Candidates {
+		if c.Chosen {
+			return c, true
+		}
+	}
+
+	return Candidate{}, false
+}
+
+// Rationale summarises the selection on one line
+func (s Selection) Rationale() string {
+	if c, ok := s.Chosen(); ok {
+		return fmt.Sprintf("chose 0x%X %q (%s) from %d candidate(s)", c.Hwnd, c.Title, c.Reason, len(s.Candidates))
+	}
+
+	if len(s.Candidates) == 0 {
+		return fmt.Sprintf("no windows found for PID %d", s.Pid)
+	}
+
+	return fmt.Sprintf("no main window among %d candidate(s) for PID %d", len(s.Candidates), s.Pid)
+}
+
+// String formats the rationale followed by every candidate, one per line
+func (s Selection) String() string {
+	var b strings.Builder
+	b.WriteString(s.Rationale())
+
+	for _, c := range s.Candidates {
+		b.WriteString("\n  ")
+		b.WriteString(c.String())
+	}
+
+	return b.String()
+}
+
+// selectMainWindow chooses the VTPro main window from the windows belonging to
+// targetPid, recording why each one was chosen or rejected. The first main
+// window wins; with an expected title, a main window whose title lacks it is rejected.
+func (c *Client) selectMainWindow(windowsList []windows.WindowInfo, targetPid uint32) Selection {
+	sel := Selection{Pid: targetPid, ExpectTitle: c.expectTitle}
+	chosen := false
+
+	for _, w := range windowsList {
+		if w.Pid != targetPid {
+			continue
+		}
+
+		kind, reason := c.classifyWithReason(w)
+		candidate := Candidate{
+			Hwnd:   w.Hwnd,
+			Title:  w.Title,
+			Class:  c.prober.GetClassName(w.Hwnd),
+			Kind:   kind,
+			Reason: reason,
+		}
+
+		switch {
+		case kind != WindowKindMain:
+			candidate.Reason = "not the main window: " + reason
+		case chosen:
+			candidate.Reason = "another main window was chosen first: " + reason
+		case c.expectTitle != "" && !textutil.ContainsFold(w.Title, c.expectTitle):
+			candidate.Reason = fmt.Sprintf("title does not contain %q: %s", c.expectTitle, reason)
+		default:
+			candidate.Chosen = true
+			chosen = true
+		}
+
+		sel.Candidates = append(sel.Candidates, candidate)
+	}
+
+	return sel
+}
@@ -0,0 +1,144 @@
+package vtpro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// selectionProber is a VTPro process mid-startup: a tool window, a dialog and the main window
+var selectionProber = fakeProber{
+	0x100: {title: "Output Compiler", class: "Afx:400000", width: 1200, height: 900},
+	0x200: {title: "VisionTools(R) Pro-e", class: "#32770", width: 400, height: 150},
+	0x300: {title: "Lobby.vtp - VisionTools Pro-e", class: "VWT32AppClass", hasMenu: true, width: 1920, height: 1080},
+	0x400: {title: "VTPro", class: "Afx:400000", width: 500, height: 300},
+}
+
+// selectionWindows lists the windows as EnumerateWindows would, including one from another process
+var selectionWindows = []windows.WindowInfo{
+	{Hwnd: 0x100, Title: "Output Compiler", Pid: 1234},
+	{Hwnd: 0x200, Title: "VisionTools(R) Pro-e", Pid: 1234},
+	{Hwnd: 0x900, Title: "Notepad", Pid: 4321},
+	{Hwnd: 0x300, Title: "Lobby.vtp - VisionTools Pro-e", Pid: 1234},
+}
+
+func newSelectionClient() *Client {
+	c := NewClient(logger.NewNoOpLogger())
+	c.prober = selectionProber
+	return c
+}
+
+func TestSelectMainWindow_RecordsEveryCandidate(t *testing.T) {
+	t.Parallel()
+
+	sel := newSelectionClient().selectMainWindow(selectionWindows, 1234)
+
+	require.Len(t, sel.Candidates, 3, "windows from other processes are not candidates")
+
+	assert.Equal(t, WindowKindUnknown, sel.Candidates[0].Kind)
+	assert.False(t, sel.Candidates[0].Chosen)
+	assert.Equal(t, "not the main window: no main window traits", sel.Candidates[0].Reason)
+
+	assert.Equal(t, WindowKindDialog, sel.Candidates[1].Kind)
+	assert.Equal(t, "#32770", sel.Candidates[1].Class)
+	assert.False(t, sel.Candidates[1].Chosen)
+
+	chosen, ok := sel.Chosen()
+	require.True(t, ok)
+	assert.Equal(t, uintptr(0x300), chosen.Hwnd)
+	assert.Equal(t, "title contains .vtp", chosen.Reason)
+	assert.Equal(t, `chose 0x300 "Lobby.vtp - VisionTools Pro-e" (title contains .vtp) from 3 candidate(s)`, sel.Rationale())
+}
+
+func TestSelectMainWindow_FirstMainWindowWins(t *testing.T) {
+	t.Parallel()
+
+	c := newSelectionClient()
+	c.prober = fakeProber{
+		0x100: {title: "VisionTools Pro-e", class: "VWT32AppClass", width: 1920, height: 1080},
+		0x300: selectionProber[0x300],
+	}
+
+	sel := c.selectMainWindow([]windows.WindowInfo{
+		{Hwnd: 0x100, Title: "VisionTools Pro-e", Pid: 1234},
+		{Hwnd: 0x300, Title: "Lobby.vtp - VisionTools Pro-e", Pid: 1234},
+	}, 1234)
+
+	chosen, ok := sel.Chosen()
+	require.True(t, ok)
+	assert.Equal(t, uintptr(0x100), chosen.Hwnd)
+	assert.Equal(t, "main window class VWT32AppClass", chosen.Reason)
+	assert.Contains(t, sel.Candidates[1].Reason, "another main window was chosen first")
+}
+
+func TestSelectMainWindow_ExpectTitle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		expectTitle string
+		wantHwnd    uintptr
+		wantReason  string
+	}{
+		{"no expectation", "", 0x300, ""},
+		{"matching substring", "lobby.VTP", 0x300, ""},
+		{"mismatch rejects the main window", "Boardroom", 0, `title does not contain "Boardroom": title contains .vtp`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newSelectionClient().WithExpectTitle(tt.expectTitle)
+			sel := c.selectMainWindow(selectionWindows, 1234)
+
+			chosen, ok := sel.Chosen()
+			assert.Equal(t, tt.wantHwnd != 0, ok)
+			assert.Equal(t, tt.wantHwnd, chosen.Hwnd)
+			assert.Equal(t, tt.expectTitle, sel.ExpectTitle)
+
+			if tt.wantReason != "" {
+				assert.Equal(t, tt.wantReason, sel.Candidates[2].Reason)
+				assert.Equal(t, "no main window among 3 candidate(s) for PID 1234", sel.Rationale())
+			}
+		})
+	}
+}
+
+func TestSelectMainWindow_OnlySplash(t *testing.T) {
+	t.Parallel()
+
+	sel := newSelectionClient().selectMainWindow([]windows.WindowInfo{
+		{Hwnd: 0x400, Title: "VTPro", Pid: 1234},
+	}, 1234)
+
+	_, ok := sel.Chosen()
+	assert.False(t, ok)
+	require.Len(t, sel.Candidates, 1)
+	assert.Equal(t, WindowKindSplash, sel.Candidates[0].Kind)
+	assert.Equal(t, "not the main window: splash screen title", sel.Candidates[0].Reason)
+}
+
+func TestSelection_NoWindows(t *testing.T) {
+	t.Parallel()
+
+	sel := newSelectionClient().selectMainWindow(selectionWindows, 5555)
+
+	assert.Empty(t, sel.Candidates)
+	assert.Equal(t, "no windows found for PID 5555", sel.Rationale())
+}
+
+func TestSelection_String(t *testing.T) {
+	t.Parallel()
+
+	sel := newSelectionClient().selectMainWindow(selectionWindows[:2], 1234)
+
+	assert.Equal(t, "no main window among 2 candidate(s) for PID 1234\n"+
+		`  0x100 "Output Compiler" class=Afx:400000 kind=unknown: rejected (not the main window: no main window traits)`+"\n"+
+		`  0x200 "VisionTools(R) Pro-e" class=#32770 kind=dialog: rejected (not the main window: dialog class #32770)`,
+		sel.String())
+}
@@ -0,0 +1,131 @@
+package vtpro
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// ErrTerminateRefused is returned when a PID can no longer be shown to be the
+// VTPro vtpc launched, so force terminating it could destroy someone else's work
+var ErrTerminateRefused = errcode.New(errcode.TerminateRefused, "refusing to terminate process")
+
+// ProcessInspector reads what is needed to check a PID before terminating it
+// It exists so the check can be tested without real processes
+type ProcessInspector interface {
+	ProcessName(pid uint32) string
+	Windows(pid uint32) []windows.WindowInfo
+}
+
+// windowsInspector is the production ProcessInspector backed by the Windows API
+type windowsInspector struct{}
+
+func (windowsInspector) ProcessName(pid uint32) string { return windows.ProcessName(pid) }
+
+func (windowsInspector) Windows(pid uint32) []windows.WindowInfo {
+	var owned []windows.WindowInfo
+
+	for _, w := range windows.EnumerateWindows() {
+		if w.Pid == pid {
+			owned = append(owned, w)
+		}
+	}
+
+	return owned
+}
+
+// ConfirmTerminate asks whether to force terminate VTPro with the given PID,
+// which has the named project open
+type ConfirmTerminate func(pid uint32, project string) bool
+
+// WithConfirmTerminate asks confirm before force terminating VTPro. Without
+// it, a process that passes verification is terminated without asking.
+func (c *Client) WithConfirmTerminate(confirm ConfirmTerminate) *Client {
+	c.confirmTerminate = confirm
+	return c
+}
+
+// WithNeverTerminate stops every cleanup path from force terminating VTPro.
+// A VTPro that will not close is left running and LeftRunning reports its PID.
+func (c *Client) WithNeverTerminate(never bool) *Client {
+	c.neverTerminate = never
+	return c
+}
+
+// LeftRunning returns the PID of a VTPro that cleanup would have force
+// terminated but left running because of WithNeverTerminate, or 0
+func (c *Client) LeftRunning() uint32 {
+	return c.leftRunning.Load()
+}
+
+// verifyTermination checks that pid is still VTPro with the project open: its
+// image must be vtpro.exe, and if it has a main window, the title must name the
+// project. A VTPro that never showed a main window has no unsaved work to lose.
+// It returns the project name to show when asking for confirmation.
+func (c *Client) verifyTermination(pid uint32) (string, error) {
+	exe := baseName(GetVTProPath())
+
+	name := c.inspector.ProcessName(pid)
+	if name == "" {
+		return "", fmt.Errorf("%w %d: cannot read its image name", ErrTerminateRefused, pid)
+	}
+
+	if !strings.EqualFold(name, exe) {
+		return "", fmt.Errorf("%w %d: image is %s, not %s", ErrTerminateRefused, pid, name, exe)
+	}
+
+	project := baseName(c.project)
+	if c.project == "" {
+		return "", nil
+	}
+
+	var titles []string
+
+	for _, w := range c.inspector.Windows(pid) {
+		if c.classify(w) != WindowKindMain {
+			continue
+		}
+
+		if titleMatchesProject(w.Title, c.project) {
+			return project, nil
+		}
+
+		titles = append(titles, fmt.Sprintf("%q", w.Title))
+	}
+
+	if len(titles) > 0 {
+		return "", fmt.Errorf("%w %d: main window %s does not name %s",
+			ErrTerminateRefused, pid, strings.Join(titles, ", "), project)
+	}
+
+	return project, nil
+}
+
+// terminate force terminates VTPro once the PID is verified and, if asked for,
+// confirmed. Under WithNeverTerminate it only records that VTPro was left running.
+func (c *Client) terminate(pid uint32) {
+	if c.neverTerminate {
+		c.leftRunning.Store(pid)
+		c.log.Warn("Not force terminating VTPro (--never-terminate)", slog.Uint64("pid", uint64(pid)))
+		return
+	}
+
+	project, err := c.verifyTermination(pid)
+	if err != nil {
+		c.log.Warn("Not force terminating VTPro", slog.Any("error", err))
+		return
+	}
+
+	if c.confirmTerminate != nil && !c.confirmTerminate(pid, project) {
+		c.log.Warn("Force termination of VTPro declined", slog.Uint64("pid", uint64(pid)))
+		return
+	}
+
+	c.log.Debug("Attempting to force terminate process", slog.Uint64("pid", uint64(pid)))
+	if err := c.kill(pid); err != nil {
+		c.log.Debug("Could not terminate VTPro", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,172 @@
+package vtpro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+	"github.com/Norgate-AV/vtpc/internal/testutil"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// fakeInspector implements ProcessInspector for a single process
+type fakeInspector struct {
+	name    string
+	windows []windows.WindowInfo
+}
+
+func (f fakeInspector) ProcessName(uint32) string           { return f.name }
+func (f fakeInspector) Windows(uint32) []windows.WindowInfo { return f.windows }
+
+func newTerminateClient(project string, inspector fakeInspector, prober fakeProber) *Client {
+	return &Client{
+		log:       logger.NewNoOpLogger(),
+		prober:    prober,
+		inspector: inspector,
+		project:   project,
+	}
+}
+
+func TestVerifyTermination(t *testing.T) {
+	t.Setenv("VTPRO_PATH", "")
+
+	mainProber := fakeProber{
+		1: {title: "project.vtp - VisionTools Pro-e", width: 1920, height: 1080},
+		2: {title: "other.vtp - VisionTools Pro-e", class: "VWT32AppClass", width: 1920, height: 1080},
+	}
+
+	tests := []struct {
+		name      string
+		inspector fakeInspector
+		wantErr   string
+	}{
+		{
+			name:      "main window names the project",
+			inspector: fakeInspector{name: "vtpro.exe", windows: []windows.WindowInfo{{Hwnd: 1, Title: "project.vtp - VisionTools Pro-e", Pid: 1234}}},
+		},
+		{
+			name:      "image name is compared case-insensitively",
+			inspector: fakeInspector{name: "VTPro.EXE", windows: []windows.WindowInfo{{Hwnd: 1, Title: "project.vtp - VisionTools Pro-e", Pid: 1234}}},
+		},
+		{
+			name:      "no main window yet",
+			inspector: fakeInspector{name: "vtpro.exe"},
+		},
+		{
+			name:      "main window has another project open",
+			inspector: fakeInspector{name: "vtpro.exe", windows: []windows.WindowInfo{{Hwnd: 2, Title: "other.vtp - VisionTools Pro-e", Pid: 1234}}},
+			wantErr:   `main window "other.vtp - VisionTools Pro-e" does not name project.vtp`,
+		},
+		{
+			name:      "PID reused by another program",
+			inspector: fakeInspector{name: "notepad.exe"},
+			wantErr:   "image is notepad.exe, not vtpro.exe",
+		},
+		{
+			name:      "process gone",
+			inspector: fakeInspector{},
+			wantErr:   "cannot read its image name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTerminateClient(`C:\Projects\project.vtp`, tt.inspector, mainProber)
+
+			project, err := c.verifyTermination(1234)
+			if tt.wantErr != "" {
+				require.ErrorIs(t, err, ErrTerminateRefused)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "project.vtp", project)
+		})
+	}
+}
+
+func TestTerminate_RefusedSkipsConfirmation(t *testing.T) {
+	t.Setenv("VTPRO_PATH", "")
+
+	asked := false
+	c := newTerminateClient(`C:\Projects\project.vtp`, fakeInspector{name: "notepad.exe"}, fakeProber{}).
+		WithConfirmTerminate(func(uint32, string) bool {
+			asked = true
+			return true
+		})
+
+	c.terminate(1234)
+
+	assert.False(t, asked, "a process that fails verification is never offered for termination")
+}
+
+func TestTerminate_DeclinedIsNotTerminated(t *testing.T) {
+	t.Setenv("VTPRO_PATH", "")
+
+	var gotPid uint32
+	var gotProject string
+
+	// PID 0 is never a real process, so nothing is terminated if the decline is ignored
+	c := newTerminateClient(`C:\Projects\project.vtp`, fakeInspector{name: "vtpro.exe"}, fakeProber{}).
+		WithConfirmTerminate(func(pid uint32, project string) bool {
+			gotPid, gotProject = pid, project
+			return false
+		})
+
+	c.terminate(0)
+
+	assert.Equal(t, uint32(0), gotPid)
+	assert.Equal(t, "project.vtp", gotProject)
+}
+
+func TestTerminate_TerminatesVerifiedProcess(t *testing.T) {
+	t.Setenv("VTPRO_PATH", "")
+
+	var killed []uint32
+
+	c := newTerminateClient(`C:\Projects\project.vtp`, fakeInspector{name: "vtpro.exe"}, fakeProber{})
+	c.kill = func(pid uint32) error {
+		killed = append(killed, pid)
+		return nil
+	}
+
+	c.terminate(1234)
+
+	assert.Equal(t, []uint32{1234}, killed)
+	assert.Zero(t, c.LeftRunning())
+}
+
+func TestNeverTerminate_NoCleanupPathTerminates(t *testing.T) {
+	t.Setenv("VTPRO_PATH", "")
+
+	var killed []uint32
+
+	log := testutil.NewMockLogger()
+	c := newTerminateClient(`C:\Projects\project.vtp`, fakeInspector{name: "vtpro.exe"}, fakeProber{}).
+		WithNeverTerminate(true).
+		WithConfirmTerminate(func(uint32, string) bool {
+			t.Error("never asked, since the answer cannot change anything")
+			return true
+		})
+	c.log = log
+	c.kill = func(pid uint32) error {
+		killed = append(killed, pid)
+		return nil
+	}
+
+	c.terminate(1234)
+	assert.Equal(t, uint32(1234), c.LeftRunning())
+
+	// Without a window, ForceCleanup goes straight to the PID, as the signal handlers do
+	c.ForceCleanup(0, 5678)
+	assert.Equal(t, uint32(5678), c.LeftRunning())
+
+	// Cleanup returns at once for a window that is gone, without falling back to the PID
+	c.Cleanup(0, 9012)
+
+	assert.Empty(t, killed, "TerminateProcess is never called")
+	assert.Contains(t, log.Messages(), "Not force terminating VTPro (--never-terminate)")
+}
@@ -0,0 +1,92 @@
+package vtpro
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+)
+
+// maxTitleHistory bounds how many title changes are kept; the oldest are dropped first
+const maxTitleHistory = 32
+
+// TitleChange is a main window title and when it was first seen
+type TitleChange struct {
+	At    time.Time
+	Title string
+}
+
+// TitleHistory records the distinct titles the VTPro main window passes through
+// while it starts and loads the file, so a timeout can say where loading stalled
+type TitleHistory struct {
+	mu      sync.Mutex
+	clk     clock.Clock
+	limit   int
+	start   time.Time
+	changes []TitleChange
+	dropped int
+}
+
+// NewTitleHistory creates a history that keeps at most limit changes
+func NewTitleHistory(clk clock.Clock, limit int) *TitleHistory {
+	return &TitleHistory{
+		clk:   clk,
+		limit: limit,
+		start: clk.Now(),
+	}
+}
+
+// Record adds a title if it differs from the last one recorded.
+// It reports whether the title was a change.
+func (h *TitleHistory) Record(title string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if n := len(h.changes); n > 0 && h.changes[n-1].Title == title {
+		return false
+	}
+
+	h.changes = append(h.changes, TitleChange{At: h.clk.Now(), Title: title})
+	if len(h.changes) > h.limit {
+		h.changes = h.changes[1:]
+		h.dropped++
+	}
+
+	return true
+}
+
+// Changes returns a copy of the recorded title changes, oldest first
+func (h *TitleHistory) Changes() []TitleChange {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	changes := make([]TitleChange, len(h.changes))
+	copy(changes, h.changes)
+
+	return changes
+}
+
+// String formats the history as titles with their offset from when recording started,
+// e.g. `"VTPro" (+0s) -> "VisionTools Pro-e" (+2.5s)`
+func (h *TitleHistory) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.changes) == 0 {
+		return "no window titles seen"
+	}
+
+	parts := make([]string, 0, len(h.changes)+1)
+	if h.dropped > 0 {
+		parts = append(parts, fmt.Sprintf("(%d earlier)", h.dropped))
+	}
+
+	for _, c := range h.changes {
+		offset := c.At.Sub(h.start).Round(100 * time.Millisecond)
+		parts = append(parts, fmt.Sprintf("%q (+%s)", c.Title, offset))
+	}
+
+	return strings.Join(parts, " -> ")
+}
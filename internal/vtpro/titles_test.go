@@ -0,0 +1,91 @@
+package vtpro
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/clock"
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+var titleEpoch = time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+func TestTitleHistory_SkipsUnchangedTitles(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(titleEpoch)
+	h := NewTitleHistory(clk, maxTitleHistory)
+
+	assert.True(t, h.Record("VTPro"))
+	clk.Advance(time.Second)
+	assert.False(t, h.Record("VTPro"), "an unchanged title is not a transition")
+	clk.Advance(time.Second)
+	assert.True(t, h.Record("VisionTools Pro-e"))
+	clk.Advance(500 * time.Millisecond)
+	assert.True(t, h.Record("lobby.vtp - VisionTools Pro-e"))
+
+	assert.Equal(t, []TitleChange{
+		{At: titleEpoch, Title: "VTPro"},
+		{At: titleEpoch.Add(2 * time.Second), Title: "VisionTools Pro-e"},
+		{At: titleEpoch.Add(2500 * time.Millisecond), Title: "lobby.vtp - VisionTools Pro-e"},
+	}, h.Changes())
+}
+
+func TestTitleHistory_RecordsReturnToEarlierTitle(t *testing.T) {
+	t.Parallel()
+
+	h := NewTitleHistory(clock.NewFake(titleEpoch), maxTitleHistory)
+
+	h.Record("VTPro")
+	h.Record("VisionTools Pro-e")
+	assert.True(t, h.Record("VTPro"), "only consecutive duplicates are skipped")
+	assert.Len(t, h.Changes(), 3)
+}
+
+func TestTitleHistory_IsBounded(t *testing.T) {
+	t.Parallel()
+
+	h := NewTitleHistory(clock.NewFake(titleEpoch), 3)
+
+	for i := range 5 {
+		h.Record(fmt.Sprintf("title %d", i))
+	}
+
+	changes := h.Changes()
+	assert.Len(t, changes, 3)
+	assert.Equal(t, "title 2", changes[0].Title, "the oldest titles are dropped first")
+	assert.Contains(t, h.String(), "(2 earlier)")
+}
+
+func TestTitleHistory_String(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(titleEpoch)
+	h := NewTitleHistory(clk, maxTitleHistory)
+
+	assert.Equal(t, "no window titles seen", h.String())
+
+	h.Record("VTPro")
+	clk.Advance(2500 * time.Millisecond)
+	h.Record("VisionTools Pro-e")
+
+	assert.Equal(t, `"VTPro" (+0s) -> "VisionTools Pro-e" (+2.5s)`, h.String())
+}
+
+func TestClient_RecordTitleIgnoresEmptyTitles(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{
+		log:    logger.NewNoOpLogger(),
+		titles: NewTitleHistory(clock.NewFake(titleEpoch), maxTitleHistory),
+	}
+
+	c.recordTitle("")
+	c.recordTitle("VTPro")
+	c.recordTitle("VTPro")
+
+	assert.Len(t, c.TitleHistory().Changes(), 1)
+}
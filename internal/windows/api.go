@@ -15,6 +15,8 @@ const (
 	LB_GETCOUNT      = 0x018B
 	LB_GETTEXT       = 0x0189
 	LB_GETTEXTLEN    = 0x018A
+	EM_SETLIMITTEXT  = 0x00C5
+	EM_GETLIMITTEXT  = 0x00D5
 )
 
 var (
@@ -30,8 +32,21 @@ var (
 	procOpenProcess              = kernel32.NewProc("OpenProcess")
 	procTerminateProcess         = kernel32.NewProc("TerminateProcess")
 	procCreateProcessW           = kernel32.NewProc("CreateProcessW")
+	procGetDiskFreeSpaceExW      = kernel32.NewProc("GetDiskFreeSpaceExW")
+	procCreateNamedPipeW         = kernel32.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe         = kernel32.NewProc("ConnectNamedPipe")
+	procDisconnectNamedPipe      = kernel32.NewProc("DisconnectNamedPipe")
+	procGetNamedPipeClientPID    = kernel32.NewProc("GetNamedPipeClientProcessId")
+	procLocalFree                = kernel32.NewProc("LocalFree")
+	procFlushFileBuffers         = kernel32.NewProc("FlushFileBuffers")
+	procQueryFullProcessImageW   = kernel32.NewProc("QueryFullProcessImageNameW")
+	procGetConsoleSBInfo         = kernel32.NewProc("GetConsoleScreenBufferInfo")
+	procGetProcessTimes          = kernel32.NewProc("GetProcessTimes")
 	advapi32                     = syscall.NewLazyDLL("advapi32.dll")
 	procGetTokenInformation      = advapi32.NewProc("GetTokenInformation")
+	procRegGetValueW             = advapi32.NewProc("RegGetValueW")
+	procConvertStringSDToSD      = advapi32.NewProc("ConvertStringSecurityDescriptorToSecurityDescriptorW")
+	procConvertSidToStringSid    = advapi32.NewProc("ConvertSidToStringSidW")
 	user32                       = syscall.NewLazyDLL("user32.dll")
 	procEnumWindows              = user32.NewProc("EnumWindows")
 	procGetWindowTextW           = user32.NewProc("GetWindowTextW")
@@ -47,8 +62,12 @@ var (
 	procKeybd_event              = user32.NewProc("keybd_event")
 	procSendInput                = user32.NewProc("SendInput")
 	procShowWindow               = user32.NewProc("ShowWindow")
+	procIsIconic                 = user32.NewProc("IsIconic")
 	procEnumChildWindows         = user32.NewProc("EnumChildWindows")
 	procGetClassNameW            = user32.NewProc("GetClassNameW")
+	procGetMenu                  = user32.NewProc("GetMenu")
+	procGetWindow                = user32.NewProc("GetWindow")
+	procGetWindowRect            = user32.NewProc("GetWindowRect")
 )
 
 const (
@@ -68,16 +87,27 @@ const (
 	KEYEVENTF_KEYUP       = 0x0002
 	KEYEVENTF_EXTENDEDKEY = 0x0001
 
-	VK_MENU   = 0x12 // Alt key
-	VK_F12    = 0x7B
-	VK_RETURN = 0x0D
+	VK_MENU    = 0x12 // Alt key
+	VK_CONTROL = 0x11
+	VK_F12     = 0x7B
+	VK_RETURN  = 0x0D
+	VK_S       = 0x53
+	VK_ESCAPE  = 0x1B
 
 	SC_F12     = 0x58
 	SW_RESTORE = 9
 	GW_CHILD   = 5
 
-	TOKEN_QUERY    = 0x0008
-	TokenElevation = 20
+	SW_SHOWNORMAL      = 1
+	SW_MINIMIZE        = 6
+	SW_SHOWMINNOACTIVE = 7
+
+	TOKEN_QUERY         = 0x0008
+	TokenUser           = 1
+	TokenElevation      = 20
+	TokenIntegrityLevel = 25
+
+	PROCESS_QUERY_LIMITED_INFORMATION = 0x1000
 )
 
 const (
@@ -105,6 +135,12 @@ func (w *WindowsAPI) CloseWindow(hwnd uintptr, title string) {
 	w.client.Window.CloseWindow(hwnd, title)
 }
 func (w *WindowsAPI) SetForeground(hwnd uintptr) bool { return w.client.Window.SetForeground(hwnd) }
+func (w *WindowsAPI) RestoreWindow(hwnd uintptr) bool {
+	return w.client.Window.RestoreWindow(hwnd)
+}
+func (w *WindowsAPI) MinimizeWindow(hwnd uintptr) bool {
+	return w.client.Window.MinimizeWindow(hwnd)
+}
 func (w *WindowsAPI) VerifyForegroundWindow(expectedHwnd uintptr, expectedPid uint32) bool {
 	return w.client.Window.VerifyForegroundWindow(expectedHwnd, expectedPid)
 }
@@ -117,6 +153,31 @@ func (w *WindowsAPI) CollectChildInfos(hwnd uintptr) []ChildInfo {
 	return CollectChildInfos(hwnd)
 }
 
+// GetForegroundWindow returns the window that has keyboard focus, 0 if none does
+func (w *WindowsAPI) GetForegroundWindow() uintptr { return GetForegroundWindow() }
+
+// GetWindowPid returns the ID of the process that created a window
+func (w *WindowsAPI) GetWindowPid(hwnd uintptr) uint32 { return GetWindowPid(hwnd) }
+
+// GetProcessIntegrity returns the mandatory integrity level of a process
+func (w *WindowsAPI) GetProcessIntegrity(pid uint32) (IntegrityLevel, error) {
+	return ProcessIntegrity(pid)
+}
+
+// GetProcessName returns the executable name of a process
+func (w *WindowsAPI) GetProcessName(pid uint32) string { return ProcessName(pid) }
+
+// GetWindowRect returns a window's bounds in screen coordinates
+func (w *WindowsAPI) GetWindowRect(hwnd uintptr) (RECT, bool) { return WindowRect(hwnd) }
+
+// GetScreenBounds returns the bounds of the virtual screen and of the primary monitor
+func (w *WindowsAPI) GetScreenBounds() (virtual, primary RECT) {
+	return VirtualScreen(), PrimaryScreen()
+}
+
+// MoveWindow moves a window without resizing or activating it
+func (w *WindowsAPI) MoveWindow(hwnd uintptr, x, y int32) bool { return MoveWindow(hwnd, x, y) }
+
 // GetWindowText retrieves the text of a window
 func (w *WindowsAPI) GetWindowText(hwnd uintptr) string {
 	return GetWindowText(hwnd)
@@ -126,6 +187,9 @@ func (w *WindowsAPI) WaitOnMonitor(timeout time.Duration, matchers ...func(Windo
 	return w.client.Window.WaitOnMonitor(timeout, matchers...)
 }
 
+// SetKeystrokeDelay sets the delay between each key's down and up events
+func (w *WindowsAPI) SetKeystrokeDelay(d time.Duration) { w.client.Keyboard.delay = d }
+
 // KeyboardInjector interface implementation
 func (w *WindowsAPI) SendF12()   { w.client.Keyboard.SendF12() }
 func (w *WindowsAPI) SendEnter() { w.client.Keyboard.SendEnter() }
@@ -133,6 +197,11 @@ func (w *WindowsAPI) SendF12ToWindow(hwnd uintptr) bool {
 	return w.client.Keyboard.SendF12ToWindow(hwnd)
 }
 
+func (w *WindowsAPI) SendCtrlS() bool { return w.client.Keyboard.SendCtrlS() }
+func (w *WindowsAPI) SendEscToWindow(hwnd uintptr) bool {
+	return w.client.Keyboard.SendEscToWindow(hwnd)
+}
+
 func (w *WindowsAPI) SendF12WithSendInput() bool {
 	return w.client.Keyboard.SendF12WithSendInput()
 }
@@ -140,6 +209,14 @@ func (w *WindowsAPI) SendF12WithSendInput() bool {
 // ControlReader interface implementation
 func (w *WindowsAPI) GetListBoxItems(hwnd uintptr) []string { return GetListBoxItems(hwnd) }
 func (w *WindowsAPI) GetEditText(hwnd uintptr) string       { return GetEditText(hwnd) }
+func (w *WindowsAPI) GetTextLength(hwnd uintptr) int        { return GetTextLength(hwnd) }
+func (w *WindowsAPI) GetTextLimit(hwnd uintptr) int         { return GetEditLimit(hwnd) }
+
+// SetTextLimit raises or lowers an Edit control's text limit and reports whether it took effect
+func (w *WindowsAPI) SetTextLimit(hwnd uintptr, limit int) bool {
+	SetEditLimit(hwnd, limit)
+	return GetEditLimit(hwnd) >= limit
+}
 func (w *WindowsAPI) FindAndClickButton(parentHwnd uintptr, buttonText string) bool {
 	return w.client.Window.FindAndClickButton(parentHwnd, buttonText)
 }
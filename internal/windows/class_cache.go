@@ -0,0 +1,91 @@
+//go:build windows
+
+package windows
+
+import "sync"
+
+// ClassCache remembers window class names by hwnd. A window's class never
+// changes, so only the first lookup needs a GetClassName call. Handles are
+// recycled once a window is destroyed, so Prune must run regularly to drop
+// entries whose window is gone before a new window can reuse the handle.
+type ClassCache struct {
+	mu       sync.Mutex
+	classes  map[uintptr]string
+	lookup   func(hwnd uintptr) string
+	isWindow func(hwnd uintptr) bool
+	hits     int
+	misses   int
+}
+
+// NewClassCache creates a ClassCache backed by GetClassName and IsWindow
+func NewClassCache() *ClassCache {
+	return newClassCache(GetClassName, IsWindow)
+}
+
+// newClassCache creates a ClassCache with the given lookups, for testing
+func newClassCache(lookup func(uintptr) string, isWindow func(uintptr) bool) *ClassCache {
+	return &ClassCache{
+		classes:  make(map[uintptr]string),
+		lookup:   lookup,
+		isWindow: isWindow,
+	}
+}
+
+// classCache is shared by the window monitor and window searches
+var classCache = NewClassCache()
+
+// CachedClassName returns a window's class name from the shared cache
+func CachedClassName(hwnd uintptr) string {
+	return classCache.ClassName(hwnd)
+}
+
+// PruneClassCache drops shared cache entries for windows that no longer exist
+func PruneClassCache() {
+	classCache.Prune()
+}
+
+// ClassName returns the class name of hwnd, looking it up on first use.
+// A failed lookup is not cached, since the window may be mid-creation or gone.
+func (c *ClassCache) ClassName(hwnd uintptr) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if class, ok := c.classes[hwnd]; ok {
+		c.hits++
+		return class
+	}
+
+	c.misses++
+
+	class := c.lookup(hwnd)
+	if class != "" {
+		c.classes[hwnd] = class
+	}
+
+	return class
+}
+
+// Prune drops entries whose window no longer exists and returns how many were dropped
+func (c *ClassCache) Prune() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dropped := 0
+
+	for hwnd := range c.classes {
+		if !c.isWindow(hwnd) {
+			delete(c.classes, hwnd)
+			dropped++
+		}
+	}
+
+	return dropped
+}
+
+// Counts returns the number of cache hits and misses so far
+func (c *ClassCache) Counts() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.hits, c.misses
+}
@@ -0,0 +1,132 @@
+//go:build windows
+
+package windows
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeWindows stands in for GetClassName and IsWindow, counting class lookups
+type fakeWindows struct {
+	classes map[uintptr]string // Live windows and their classes
+	lookups int
+}
+
+func newFakeWindows() *fakeWindows {
+	return &fakeWindows{classes: make(map[uintptr]string)}
+}
+
+func (f *fakeWindows) className(hwnd uintptr) string {
+	f.lookups++
+	return f.classes[hwnd]
+}
+
+func (f *fakeWindows) isWindow(hwnd uintptr) bool {
+	_, ok := f.classes[hwnd]
+	return ok
+}
+
+func (f *fakeWindows) cache() *ClassCache {
+	return newClassCache(f.className, f.isWindow)
+}
+
+func TestClassCache_LooksUpOnce(t *testing.T) {
+	t.Parallel()
+
+	fw := newFakeWindows()
+	fw.classes[0x100] = "#32770"
+	c := fw.cache()
+
+	assert.Equal(t, "#32770", c.ClassName(0x100))
+	assert.Equal(t, "#32770", c.ClassName(0x100))
+	assert.Equal(t, "#32770", c.ClassName(0x100))
+
+	assert.Equal(t, 1, fw.lookups)
+
+	hits, misses := c.Counts()
+	assert.Equal(t, 2, hits)
+	assert.Equal(t, 1, misses)
+}
+
+func TestClassCache_FailedLookupNotCached(t *testing.T) {
+	t.Parallel()
+
+	fw := newFakeWindows()
+	c := fw.cache()
+
+	assert.Empty(t, c.ClassName(0x100))
+
+	fw.classes[0x100] = "VWT32AppClass"
+	assert.Equal(t, "VWT32AppClass", c.ClassName(0x100))
+}
+
+func TestClassCache_RecycledHandleGetsFreshClass(t *testing.T) {
+	t.Parallel()
+
+	fw := newFakeWindows()
+	fw.classes[0x100] = "#32770"
+	c := fw.cache()
+
+	assert.Equal(t, "#32770", c.ClassName(0x100))
+
+	// The dialog is destroyed and the next poll prunes it
+	delete(fw.classes, 0x100)
+	assert.Equal(t, 1, c.Prune())
+
+	// Windows hands the same handle to a new window of another class
+	fw.classes[0x100] = "VWT32AppClass"
+	assert.Equal(t, "VWT32AppClass", c.ClassName(0x100), "a recycled handle must not serve the stale class")
+}
+
+func TestClassCache_PruneKeepsLiveWindows(t *testing.T) {
+	t.Parallel()
+
+	fw := newFakeWindows()
+	fw.classes[0x100] = "#32770"
+	fw.classes[0x200] = "VWT32AppClass"
+	c := fw.cache()
+
+	c.ClassName(0x100)
+	c.ClassName(0x200)
+
+	delete(fw.classes, 0x100)
+	assert.Equal(t, 1, c.Prune())
+
+	c.ClassName(0x200)
+	assert.Equal(t, 2, fw.lookups, "the live window is still cached")
+}
+
+// benchmarkPolls simulates polls that each need the class of every window,
+// reporting the GetClassName calls made per poll
+func benchmarkPolls(b *testing.B, className func(f *fakeWindows) func(uintptr) string) {
+	fw := newFakeWindows()
+	for hwnd := uintptr(1); hwnd <= 50; hwnd++ {
+		fw.classes[hwnd] = "#32770"
+	}
+
+	lookup := className(fw)
+
+	b.ResetTimer()
+
+	for b.Loop() {
+		for hwnd := range fw.classes {
+			lookup(hwnd)
+		}
+	}
+
+	b.ReportMetric(float64(fw.lookups)/float64(b.N), "syscalls/poll")
+}
+
+func BenchmarkClassName_Uncached(b *testing.B) {
+	benchmarkPolls(b, func(f *fakeWindows) func(uintptr) string {
+		return f.className
+	})
+}
+
+func BenchmarkClassName_Cached(b *testing.B) {
+	benchmarkPolls(b, func(f *fakeWindows) func(uintptr) string {
+		return f.cache().ClassName
+	})
+}
@@ -0,0 +1,96 @@
+//go:build windows
+
+package windows
+
+import (
+	"os"
+	"unsafe"
+)
+
+// CodePageUTF8 is the console code page for UTF-8
+const CodePageUTF8 = 65001
+
+var (
+	procGetConsoleOutputCP = kernel32DLL.NewProc("GetConsoleOutputCP")
+	procSetConsoleOutputCP = kernel32DLL.NewProc("SetConsoleOutputCP")
+	procGetConsoleMode     = kernel32DLL.NewProc("GetConsoleMode")
+)
+
+// CodePageConsole is the console stdout writes to. The real one is StdoutConsole;
+// tests use a fake so the decisions EnsureUTF8Output makes can be checked.
+type CodePageConsole interface {
+	// IsConsole reports whether stdout is a console rather than a file or pipe
+	IsConsole() bool
+	// OutputCP returns the console's output code page
+	OutputCP() uint32
+	// SetOutputCP sets the console's output code page
+	SetOutputCP(cp uint32) error
+}
+
+// ConsoleOutput describes what EnsureUTF8Output found and did
+type ConsoleOutput struct {
+	Redirected bool   // stdout is a file or pipe, so the code page does not apply
+	Original   uint32 // the console's code page at startup, 0 when redirected
+	Switched   bool   // the code page was changed to UTF-8 and is restored on exit
+	Err        error  // why the code page could not be changed, if it could not
+}
+
+// UTF8 reports whether text written to stdout is shown as UTF-8. Redirected
+// output is passed on as written, which is UTF-8.
+func (o ConsoleOutput) UTF8() bool {
+	return o.Redirected || o.Original == CodePageUTF8 || o.Switched
+}
+
+// EnsureUTF8Output sets the console's output code page to UTF-8 so text from
+// VTPro, such as smart quotes and dashes, is not shown as mojibake on machines
+// with a legacy code page like 850. It leaves redirected output and consoles
+// already on UTF-8 alone. The returned function restores the original code
+// page; it must be called before exiting, since the console outlives vtpc.
+func EnsureUTF8Output(c CodePageConsole) (ConsoleOutput, func()) {
+	if !c.IsConsole() {
+		return ConsoleOutput{Redirected: true}, func() {}
+	}
+
+	out := ConsoleOutput{Original: c.OutputCP()}
+	if out.Original == CodePageUTF8 {
+		return out, func() {}
+	}
+
+	if err := c.SetOutputCP(CodePageUTF8); err != nil {
+		out.Err = err
+		return out, func() {}
+	}
+
+	out.Switched = true
+
+	return out, func() { _ = c.SetOutputCP(out.Original) }
+}
+
+// StdoutConsole is the console the process's stdout is attached to
+type StdoutConsole struct{}
+
+// IsConsole reports whether stdout is a console. GetConsoleMode fails for
+// handles that are files or pipes.
+func (StdoutConsole) IsConsole() bool {
+	var mode uint32
+
+	ret, _, _ := procGetConsoleMode.Call(os.Stdout.Fd(), uintptr(unsafe.Pointer(&mode)))
+
+	return ret != 0
+}
+
+// OutputCP returns the console's output code page
+func (StdoutConsole) OutputCP() uint32 {
+	ret, _, _ := procGetConsoleOutputCP.Call()
+	return uint32(ret)
+}
+
+// SetOutputCP sets the console's output code page
+func (StdoutConsole) SetOutputCP(cp uint32) error {
+	ret, _, err := procSetConsoleOutputCP.Call(uintptr(cp))
+	if ret == 0 {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+//go:build windows
+
+package windows
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConsole records the code pages set on it
+type fakeConsole struct {
+	redirected bool
+	cp         uint32
+	setErr     error
+	set        []uint32
+}
+
+func (f *fakeConsole) IsConsole() bool  { return !f.redirected }
+func (f *fakeConsole) OutputCP() uint32 { return f.cp }
+
+func (f *fakeConsole) SetOutputCP(cp uint32) error {
+	f.set = append(f.set, cp)
+	if f.setErr != nil {
+		return f.setErr
+	}
+
+	f.cp = cp
+
+	return nil
+}
+
+func TestEnsureUTF8Output(t *testing.T) {
+	t.Parallel()
+
+	denied := errors.New("the handle is invalid")
+
+	tests := []struct {
+		name        string
+		console     fakeConsole
+		want        ConsoleOutput
+		wantUTF8    bool
+		wantSet     []uint32 // code pages set by EnsureUTF8Output and then restore
+		wantRestore uint32   // code page after restore
+	}{
+		{
+			name:        "legacy code page is switched and restored",
+			console:     fakeConsole{cp: 850},
+			want:        ConsoleOutput{Original: 850, Switched: true},
+			wantUTF8:    true,
+			wantSet:     []uint32{CodePageUTF8, 850},
+			wantRestore: 850,
+		},
+		{
+			name:        "already UTF-8",
+			console:     fakeConsole{cp: CodePageUTF8},
+			want:        ConsoleOutput{Original: CodePageUTF8},
+			wantUTF8:    true,
+			wantRestore: CodePageUTF8,
+		},
+		{
+			name:        "redirected output is left alone",
+			console:     fakeConsole{redirected: true, cp: 850},
+			want:        ConsoleOutput{Redirected: true},
+			wantUTF8:    true,
+			wantRestore: 850,
+		},
+		{
+			name:        "switch fails",
+			console:     fakeConsole{cp: 437, setErr: denied},
+			want:        ConsoleOutput{Original: 437, Err: denied},
+			wantSet:     []uint32{CodePageUTF8},
+			wantRestore: 437,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := tt.console
+			got, restore := EnsureUTF8Output(&c)
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantUTF8, got.UTF8())
+
+			restore()
+			assert.Equal(t, tt.wantSet, c.set)
+			assert.Equal(t, tt.wantRestore, c.cp)
+		})
+	}
+}
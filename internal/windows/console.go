@@ -3,7 +3,9 @@
 package windows
 
 import (
+	"os"
 	"syscall"
+	"unsafe"
 )
 
 var (
@@ -69,3 +71,37 @@ func GetCtrlTypeName(ctrlType uint32) string {
 		return "UNKNOWN"
 	}
 }
+
+// COORD and SMALL_RECT for GetConsoleScreenBufferInfo
+type COORD struct {
+	X, Y int16
+}
+
+type SMALL_RECT struct {
+	Left, Top, Right, Bottom int16
+}
+
+// CONSOLE_SCREEN_BUFFER_INFO for GetConsoleScreenBufferInfo
+type CONSOLE_SCREEN_BUFFER_INFO struct {
+	DwSize              COORD
+	DwCursorPosition    COORD
+	WAttributes         uint16
+	SrWindow            SMALL_RECT
+	DwMaximumWindowSize COORD
+}
+
+// ConsoleWidth returns the width in columns of the console window stdout is
+// attached to, or 0 when stdout is not a console, such as when it is piped
+func ConsoleWidth() int {
+	var info CONSOLE_SCREEN_BUFFER_INFO
+
+	ret, _, _ := procGetConsoleSBInfo.Call(
+		os.Stdout.Fd(),
+		uintptr(unsafe.Pointer(&info)),
+	)
+	if ret == 0 {
+		return 0
+	}
+
+	return int(info.SrWindow.Right-info.SrWindow.Left) + 1
+}
@@ -121,6 +121,23 @@ func GetEditText(hwnd uintptr) string {
 	return syscall.UTF16ToString(buf)
 }
 
+// GetTextLength returns the length of a control's text in characters
+func GetTextLength(hwnd uintptr) int {
+	length, _, _ := procSendMessageW.Call(hwnd, WM_GETTEXTLENGTH, 0, 0)
+	return int(length)
+}
+
+// GetEditLimit returns the maximum number of characters an Edit control accepts
+func GetEditLimit(hwnd uintptr) int {
+	limit, _, _ := procSendMessageW.Call(hwnd, EM_GETLIMITTEXT, 0, 0)
+	return int(limit)
+}
+
+// SetEditLimit sets the maximum number of characters an Edit control accepts
+func SetEditLimit(hwnd uintptr, limit int) {
+	_, _, _ = procSendMessageW.Call(hwnd, EM_SETLIMITTEXT, uintptr(limit), 0)
+}
+
 // CollectChildTexts retrieves the text of all child windows
 func CollectChildTexts(hwnd uintptr) []string {
 	texts := []string{}
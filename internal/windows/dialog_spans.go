@@ -0,0 +1,72 @@
+//go:build windows
+
+package windows
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// maxLifecycleEvents bounds the openings and closings a monitor run keeps, so
+// a run that sees many short-lived windows, such as tooltips, cannot grow
+// without limit. Later ones are not kept.
+const maxLifecycleEvents = 4096
+
+// LifecycleEvent is a window the monitor published opening, or going away
+type LifecycleEvent struct {
+	Hwnd   uintptr
+	Title  string // Title when it opened, empty for a closing
+	Class  string
+	Closed bool
+	At     time.Time
+}
+
+// DialogSpan is how long one window the monitor published stayed open
+type DialogSpan struct {
+	Hwnd     uintptr
+	Title    string
+	Class    string
+	Opened   time.Time
+	Duration time.Duration
+	Open     bool // Still open at the end of the events, so Duration runs to then
+}
+
+// DialogSpans pairs each window opening in events with its closing and
+// returns how long each window stayed open, longest first. A window with no
+// closing is counted up to end. Events are in the order the monitor saw them.
+func DialogSpans(events []LifecycleEvent, end time.Time) []DialogSpan {
+	var spans []DialogSpan
+
+	open := make(map[uintptr]int) // Index in spans of each window still open
+
+	closeSpan := func(hwnd uintptr, at time.Time) {
+		if i, ok := open[hwnd]; ok {
+			spans[i].Duration = max(at.Sub(spans[i].Opened), 0)
+			spans[i].Open = false
+			delete(open, hwnd)
+		}
+	}
+
+	for _, ev := range events {
+		// A handle seen opening again was reused, so its first window has gone
+		closeSpan(ev.Hwnd, ev.At)
+
+		if ev.Closed {
+			continue
+		}
+
+		open[ev.Hwnd] = len(spans)
+		spans = append(spans, DialogSpan{Hwnd: ev.Hwnd, Title: ev.Title, Class: ev.Class, Opened: ev.At, Open: true})
+	}
+
+	for _, i := range open {
+		spans[i].Duration = max(end.Sub(spans[i].Opened), 0)
+	}
+
+	slices.SortStableFunc(spans, func(a, b DialogSpan) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+
+	return spans
+}
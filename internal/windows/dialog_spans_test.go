@@ -0,0 +1,69 @@
+//go:build windows
+
+package windows
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialogSpans(t *testing.T) {
+	t.Parallel()
+
+	t0 := time.Date(2025, 6, 1, 8, 30, 0, 0, time.UTC)
+	at := func(s float64) time.Time { return t0.Add(time.Duration(s * float64(time.Second))) }
+
+	spans := DialogSpans([]LifecycleEvent{
+		{Hwnd: 0x1111, Title: "VisionTools Pro-e Compiling...", Class: DialogClass, At: at(0)},
+		{Hwnd: 0x2222, Title: "Progress [99%]", Class: DialogClass, At: at(1)},
+		{Hwnd: 0x3333, Title: "Compile Complete", Class: DialogClass, At: at(2)},
+		{Hwnd: 0x3333, Closed: true, At: at(2.5)},
+		{Hwnd: 0x2222, Closed: true, At: at(63)},
+		{Hwnd: 0x4444, Closed: true, At: at(64)},
+		{Hwnd: 0x5555, Title: "Address Book", Class: DialogClass, At: at(65)},
+	}, at(70))
+
+	assert.Equal(t, []DialogSpan{
+		{Hwnd: 0x1111, Title: "VisionTools Pro-e Compiling...", Class: DialogClass, Opened: at(0), Duration: 70 * time.Second, Open: true},
+		{Hwnd: 0x2222, Title: "Progress [99%]", Class: DialogClass, Opened: at(1), Duration: 62 * time.Second},
+		{Hwnd: 0x5555, Title: "Address Book", Class: DialogClass, Opened: at(65), Duration: 5 * time.Second, Open: true},
+		{Hwnd: 0x3333, Title: "Compile Complete", Class: DialogClass, Opened: at(2), Duration: 500 * time.Millisecond},
+	}, spans, "longest first; a closing with no opening is ignored and windows still open run to the end")
+}
+
+func TestDialogSpans_ReusedHandle(t *testing.T) {
+	t.Parallel()
+
+	t0 := time.Date(2025, 6, 1, 8, 30, 0, 0, time.UTC)
+
+	spans := DialogSpans([]LifecycleEvent{
+		{Hwnd: 0x2222, Title: "Progress [10%]", At: t0},
+		{Hwnd: 0x2222, Title: "Progress [50%]", At: t0.Add(3 * time.Second)},
+		{Hwnd: 0x2222, Closed: true, At: t0.Add(4 * time.Second)},
+	}, t0.Add(time.Minute))
+
+	assert.Equal(t, []DialogSpan{
+		{Hwnd: 0x2222, Title: "Progress [10%]", Opened: t0, Duration: 3 * time.Second},
+		{Hwnd: 0x2222, Title: "Progress [50%]", Opened: t0.Add(3 * time.Second), Duration: time.Second},
+	}, spans)
+}
+
+func TestDialogSpans_Empty(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, DialogSpans(nil, time.Now()))
+}
+
+func TestClosedWindows(t *testing.T) {
+	t.Parallel()
+
+	open := map[uintptr]bool{0x1111: true, 0x2222: false, 0x3333: true}
+
+	closed := closedWindows([]WindowInfo{{Hwnd: 0x1111}, {Hwnd: 0x9999}}, open)
+
+	assert.Equal(t, map[uintptr]bool{0x2222: false, 0x3333: true}, closed, "with whether a recorder saw them open")
+	assert.Equal(t, map[uintptr]bool{0x1111: true}, open)
+	assert.Nil(t, closedWindows(nil, map[uintptr]bool{}))
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package windows
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// FreeDiskSpace returns the bytes available to the caller on the volume containing path
+func FreeDiskSpace(path string) (uint64, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var freeToCaller, total, totalFree uint64
+
+	ret, _, callErr := procGetDiskFreeSpaceExW.Call(
+		uintptr(unsafe.Pointer(pathPtr)),
+		uintptr(unsafe.Pointer(&freeToCaller)),
+		uintptr(unsafe.Pointer(&total)),
+		uintptr(unsafe.Pointer(&totalFree)),
+	)
+	if ret == 0 {
+		return 0, fmt.Errorf("GetDiskFreeSpaceEx failed for %s: %w", path, callErr)
+	}
+
+	return freeToCaller, nil
+}
+
+// DiskSpace reports free disk space using GetDiskFreeSpaceEx
+type DiskSpace struct{}
+
+// FreeBytes returns the bytes available to the caller on the volume containing path
+func (DiskSpace) FreeBytes(path string) (uint64, error) {
+	return FreeDiskSpace(path)
+}
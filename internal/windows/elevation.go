@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"syscall"
 	"unsafe"
 )
 
@@ -60,8 +61,13 @@ func RelaunchAsAdmin() error {
 		return fmt.Errorf("cannot relaunch when run via 'go run', please build the executable first with: go build -o vtpc.exe")
 	}
 
-	// Build args string (excluding the exe name)
-	args := strings.Join(os.Args[1:], " ")
+	// Build args string (excluding the exe name), quoting paths with spaces
+	quoted := make([]string, len(os.Args)-1)
+	for i, arg := range os.Args[1:] {
+		quoted[i] = syscall.EscapeArg(arg)
+	}
+
+	args := strings.Join(quoted, " ")
 
 	return ShellExecute(0, "runas", exe, args, "", 1)
 }
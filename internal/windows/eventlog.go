@@ -0,0 +1,37 @@
+//go:build windows
+
+package windows
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventSourcesKey is where the event sources of the Application log are registered
+const eventSourcesKey = `SYSTEM\CurrentControlSet\Services\EventLog\Application\`
+
+// OpenEventLog opens an Application log event source, registering it first if it
+// does not exist. Registering writes to HKLM, so it needs administrator rights.
+func OpenEventLog(source string) (*eventlog.Log, error) {
+	if !eventSourceExists(source) {
+		if err := eventlog.InstallAsEventCreate(source, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+			return nil, fmt.Errorf("could not register event source: %w", err)
+		}
+	}
+
+	return eventlog.Open(source)
+}
+
+// eventSourceExists reports whether an Application log event source is registered
+func eventSourceExists(source string) bool {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, eventSourcesKey+source, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+
+	_ = k.Close()
+
+	return true
+}
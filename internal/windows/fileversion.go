@@ -0,0 +1,79 @@
+//go:build windows
+
+package windows
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modversion                  = syscall.NewLazyDLL("version.dll")
+	procGetFileVersionInfoSizeW = modversion.NewProc("GetFileVersionInfoSizeW")
+	procGetFileVersionInfoW     = modversion.NewProc("GetFileVersionInfoW")
+	procVerQueryValueW          = modversion.NewProc("VerQueryValueW")
+)
+
+// vsFixedFileInfo is VS_FIXEDFILEINFO, the language-neutral part of a version resource
+type vsFixedFileInfo struct {
+	Signature        uint32
+	StrucVersion     uint32
+	FileVersionMS    uint32
+	FileVersionLS    uint32
+	ProductVersionMS uint32
+	ProductVersionLS uint32
+	FileFlagsMask    uint32
+	FileFlags        uint32
+	FileOS           uint32
+	FileType         uint32
+	FileSubtype      uint32
+	FileDateMS       uint32
+	FileDateLS       uint32
+}
+
+// FileVersion returns the file version from an executable's version resource,
+// e.g. "6.2.3.1"
+func FileVersion(path string) (string, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return "", err
+	}
+
+	size, _, callErr := procGetFileVersionInfoSizeW.Call(uintptr(unsafe.Pointer(pathPtr)), 0)
+	if size == 0 {
+		return "", fmt.Errorf("%s has no version resource: %w", path, callErr)
+	}
+
+	buf := make([]byte, size)
+
+	ret, _, callErr := procGetFileVersionInfoW.Call(uintptr(unsafe.Pointer(pathPtr)), 0, size, uintptr(unsafe.Pointer(&buf[0])))
+	if ret == 0 {
+		return "", fmt.Errorf("reading the version resource of %s: %w", path, callErr)
+	}
+
+	root, _ := syscall.UTF16PtrFromString(`\`)
+
+	var (
+		info *vsFixedFileInfo
+		n    uint32
+	)
+
+	ret, _, _ = procVerQueryValueW.Call(
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(unsafe.Pointer(root)),
+		uintptr(unsafe.Pointer(&info)),
+		uintptr(unsafe.Pointer(&n)),
+	)
+	if ret == 0 || info == nil || n < uint32(unsafe.Sizeof(*info)) {
+		return "", fmt.Errorf("%s has no fixed file version", path)
+	}
+
+	return formatFileVersion(info.FileVersionMS, info.FileVersionLS), nil
+}
+
+// formatFileVersion formats the two halves of a VS_FIXEDFILEINFO version as
+// major.minor.build.revision
+func formatFileVersion(ms, ls uint32) string {
+	return fmt.Sprintf("%d.%d.%d.%d", ms>>16, ms&0xffff, ls>>16, ls&0xffff)
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package windows
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatFileVersion(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "6.2.3.1", formatFileVersion(6<<16|2, 3<<16|1))
+	assert.Equal(t, "65535.0.0.65535", formatFileVersion(0xffff0000, 0x0000ffff))
+}
@@ -0,0 +1,117 @@
+//go:build windows
+
+package windows
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	fileNameNormalized = 0x0 // FILE_NAME_NORMALIZED
+	volumeNameDOS      = 0x0 // VOLUME_NAME_DOS
+)
+
+var (
+	procGetFinalPathNameByHandleW = kernel32.NewProc("GetFinalPathNameByHandleW")
+	procSHGetKnownFolderPath      = shell32.NewProc("SHGetKnownFolderPath")
+	procCoTaskMemFree             = syscall.NewLazyDLL("ole32.dll").NewProc("CoTaskMemFree")
+)
+
+// folderIDProgramData is FOLDERID_ProgramData, {62AB5D82-FDC1-4DC3-A9DD-070D1D495D97}
+var folderIDProgramData = syscall.GUID{
+	Data1: 0x62AB5D82,
+	Data2: 0xFDC1,
+	Data3: 0x4DC3,
+	Data4: [8]byte{0xA9, 0xDD, 0x07, 0x0D, 0x1D, 0x49, 0x5D, 0x97},
+}
+
+// FinalPath returns the path the file system finally opens for path, with every
+// symlink, junction and mapped drive along the way resolved, e.g. a junction
+// C:\Projects\Link to D:\Work gives D:\Work. The \\?\ prefix is removed, so a
+// UNC path comes back as \\server\share\... The path must exist.
+func FinalPath(path string) (string, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return "", err
+	}
+
+	// FILE_FLAG_BACKUP_SEMANTICS is needed to open a directory
+	h, err := syscall.CreateFile(pathPtr, 0,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	defer syscall.CloseHandle(h)
+
+	buf := make([]uint16, syscall.MAX_PATH)
+
+	for {
+		n, _, callErr := procGetFinalPathNameByHandleW.Call(
+			uintptr(h),
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(len(buf)),
+			fileNameNormalized|volumeNameDOS,
+		)
+		if n == 0 {
+			return "", fmt.Errorf("GetFinalPathNameByHandle failed for %s: %w", path, callErr)
+		}
+
+		// A result longer than the buffer is the size needed, including the terminator
+		if int(n) < len(buf) {
+			return trimLongPathPrefix(syscall.UTF16ToString(buf[:n])), nil
+		}
+
+		buf = make([]uint16, n)
+	}
+}
+
+// trimLongPathPrefix turns \\?\C:\x into C:\x and \\?\UNC\server\share into \\server\share
+func trimLongPathPrefix(path string) string {
+	if rest, ok := strings.CutPrefix(path, `\\?\UNC\`); ok {
+		return `\\` + rest
+	}
+
+	return strings.TrimPrefix(path, `\\?\`)
+}
+
+// ProgramDataDir returns the machine-wide application data directory, usually
+// C:\ProgramData. It asks the shell rather than reading %ProgramData%, which the
+// user starting vtpc controls.
+func ProgramDataDir() (string, error) {
+	var pathPtr *uint16
+
+	ret, _, _ := procSHGetKnownFolderPath.Call(
+		uintptr(unsafe.Pointer(&folderIDProgramData)),
+		0,
+		0,
+		uintptr(unsafe.Pointer(&pathPtr)),
+	)
+	if pathPtr != nil {
+		defer procCoTaskMemFree.Call(uintptr(unsafe.Pointer(pathPtr)))
+	}
+
+	if ret != 0 {
+		return "", fmt.Errorf("SHGetKnownFolderPath failed for ProgramData: HRESULT 0x%08X", uint32(ret))
+	}
+
+	return utf16PtrToString(pathPtr), nil
+}
+
+// utf16PtrToString reads a NUL-terminated UTF-16 string the system allocated
+func utf16PtrToString(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+
+	n := 0
+	for ptr := unsafe.Pointer(p); *(*uint16)(ptr) != 0; n++ {
+		ptr = unsafe.Add(ptr, 2)
+	}
+
+	return syscall.UTF16ToString(unsafe.Slice(p, n))
+}
@@ -0,0 +1,74 @@
+//go:build windows
+
+package windows
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// junction creates a directory junction at link pointing to target, as mklink /J does
+func junction(t *testing.T, link, target string) {
+	t.Helper()
+
+	out, err := exec.Command("cmd", "/c", "mklink", "/J", link, target).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestTrimLongPathPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{`\\?\C:\Projects\lobby.vtp`, `C:\Projects\lobby.vtp`},
+		{`\\?\UNC\nas\projects\lobby.vtp`, `\\nas\projects\lobby.vtp`},
+		{`C:\Projects\lobby.vtp`, `C:\Projects\lobby.vtp`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, trimLongPathPrefix(tt.path))
+	}
+}
+
+func TestFinalPath_ResolvesJunctions(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	target := filepath.Join(base, "Target")
+	require.NoError(t, os.Mkdir(target, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(target, "lobby.vtp"), nil, 0o644))
+
+	link := filepath.Join(base, "Link")
+	junction(t, link, target)
+
+	want, err := FinalPath(filepath.Join(target, "lobby.vtp"))
+	require.NoError(t, err)
+	assert.False(t, strings.HasPrefix(want, `\\?\`))
+
+	got, err := FinalPath(filepath.Join(link, "lobby.vtp"))
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestFinalPath_MissingPath(t *testing.T) {
+	t.Parallel()
+
+	_, err := FinalPath(filepath.Join(t.TempDir(), "missing.vtp"))
+	assert.Error(t, err)
+}
+
+func TestProgramDataDir(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ProgramDataDir()
+	require.NoError(t, err)
+	assert.True(t, filepath.IsAbs(dir), dir)
+}
@@ -0,0 +1,78 @@
+//go:build windows
+
+package windows
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// spiGetForegroundLockTimeout is SPI_GETFOREGROUNDLOCKTIMEOUT
+const spiGetForegroundLockTimeout = 0x2000
+
+var procSystemParametersInfo = user32.NewProc("SystemParametersInfoW")
+
+// ForegroundLock is the foreground lock timeout: for this long after the user's
+// last input, Windows refuses to let any other process take the foreground,
+// elevated or not. Hardened images sometimes set it very high.
+type ForegroundLock struct {
+	Timeout time.Duration // The timeout in effect, from SystemParametersInfo
+	Source  string        // The registry value that configures it, "" if none does
+	Policy  bool          // Source is a policy key, which users cannot change
+}
+
+// foregroundLockValue is a registry value the timeout can be configured in
+type foregroundLockValue struct {
+	root     registry.Key
+	rootName string
+	path     string
+	policy   bool
+}
+
+// foregroundLockValues are where the timeout can be configured, policy first
+var foregroundLockValues = []foregroundLockValue{
+	{registry.CURRENT_USER, "HKCU", `Software\Policies\Microsoft\Windows\Control Panel\Desktop`, true},
+	{registry.LOCAL_MACHINE, "HKLM", `Software\Policies\Microsoft\Windows\Control Panel\Desktop`, true},
+	{registry.CURRENT_USER, "HKCU", `Control Panel\Desktop`, false},
+}
+
+// ReadForegroundLock reads the foreground lock timeout in effect and the registry
+// value that configures it
+func ReadForegroundLock() (ForegroundLock, error) {
+	var ms uint32
+
+	ret, _, err := procSystemParametersInfo.Call(spiGetForegroundLockTimeout, 0, uintptr(unsafe.Pointer(&ms)), 0)
+	if ret == 0 {
+		return ForegroundLock{}, fmt.Errorf("SystemParametersInfo(SPI_GETFOREGROUNDLOCKTIMEOUT): %w", err)
+	}
+
+	lock := ForegroundLock{Timeout: time.Duration(ms) * time.Millisecond}
+
+	for _, v := range foregroundLockValues {
+		if readForegroundLockValue(v) {
+			lock.Source = v.rootName + `\` + v.path + `\ForegroundLockTimeout`
+			lock.Policy = v.policy
+
+			break
+		}
+	}
+
+	return lock, nil
+}
+
+// readForegroundLockValue reports whether v is set
+func readForegroundLockValue(v foregroundLockValue) bool {
+	k, err := registry.OpenKey(v.root, v.path, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+
+	defer func() { _ = k.Close() }()
+
+	_, _, err = k.GetIntegerValue("ForegroundLockTimeout")
+
+	return err == nil
+}
@@ -0,0 +1,54 @@
+//go:build windows
+
+package windows
+
+import "fmt"
+
+// minimizedPosition is where Windows parks the top-left corner of a minimized window
+const minimizedPosition = -32000
+
+// Width returns the rectangle's width in pixels
+func (r RECT) Width() int32 { return r.Right - r.Left }
+
+// Height returns the rectangle's height in pixels
+func (r RECT) Height() int32 { return r.Bottom - r.Top }
+
+// Intersects reports whether two rectangles share at least one pixel
+func (r RECT) Intersects(o RECT) bool {
+	return r.Left < o.Right && o.Left < r.Right && r.Top < o.Bottom && o.Top < r.Bottom
+}
+
+// String formats the rectangle as "(left,top)-(right,bottom)"
+func (r RECT) String() string {
+	return fmt.Sprintf("(%d,%d)-(%d,%d)", r.Left, r.Top, r.Right, r.Bottom)
+}
+
+// Minimized reports whether the rectangle is where Windows parks a minimized window
+func (r RECT) Minimized() bool {
+	return r.Left == minimizedPosition && r.Top == minimizedPosition
+}
+
+// OffScreen reports whether a window lies entirely outside the virtual screen,
+// the box around every connected monitor. A minimized window, or a screen
+// whose bounds could not be read, is never off-screen.
+func OffScreen(window, screen RECT) bool {
+	if screen.Width() <= 0 || screen.Height() <= 0 {
+		return false
+	}
+
+	if window.Minimized() {
+		return false
+	}
+
+	return !window.Intersects(screen)
+}
+
+// OnScreenPosition returns the top-left corner that centres a window on the
+// primary monitor. A window larger than the monitor is put at its top-left
+// corner instead, so the title bar can still be reached.
+func OnScreenPosition(window, primary RECT) (x, y int32) {
+	x = primary.Left + max((primary.Width()-window.Width())/2, 0)
+	y = primary.Top + max((primary.Height()-window.Height())/2, 0)
+
+	return x, y
+}
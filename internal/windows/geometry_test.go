@@ -0,0 +1,73 @@
+//go:build windows
+
+package windows
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dockedScreen is a 1920x1080 primary monitor with a second one to its left
+var dockedScreen = RECT{Left: -2560, Top: 0, Right: 1920, Bottom: 1440}
+
+func TestOffScreen(t *testing.T) {
+	t.Parallel()
+
+	primary := RECT{Left: 0, Top: 0, Right: 1920, Bottom: 1080}
+
+	tests := []struct {
+		name   string
+		window RECT
+		screen RECT
+		want   bool
+	}{
+		{name: "on the primary monitor", window: RECT{100, 100, 900, 700}, screen: primary},
+		{name: "on the secondary monitor", window: RECT{-2000, 100, -1200, 700}, screen: dockedScreen},
+		{name: "left behind by an unplugged monitor", window: RECT{-2000, 100, -1200, 700}, screen: primary, want: true},
+		{name: "below every monitor", window: RECT{100, 1200, 900, 1800}, screen: primary, want: true},
+		{name: "partly on screen", window: RECT{-400, 100, 400, 700}, screen: primary},
+		{name: "touching the edge only", window: RECT{1920, 100, 2720, 700}, screen: primary, want: true},
+		{name: "minimized", window: RECT{-32000, -32000, -31840, -31972}, screen: primary},
+		{name: "screen bounds unknown", window: RECT{-2000, 100, -1200, 700}, screen: RECT{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, OffScreen(tt.window, tt.screen))
+		})
+	}
+}
+
+func TestOnScreenPosition(t *testing.T) {
+	t.Parallel()
+
+	primary := RECT{Left: 0, Top: 0, Right: 1920, Bottom: 1080}
+
+	x, y := OnScreenPosition(RECT{-2000, 100, -1200, 700}, primary)
+	assert.Equal(t, int32(560), x, "800 wide is centred")
+	assert.Equal(t, int32(240), y, "600 high is centred")
+
+	x, y = OnScreenPosition(RECT{-3000, -200, -500, 1400}, primary)
+	assert.Equal(t, int32(0), x, "wider than the monitor goes to its left edge")
+	assert.Equal(t, int32(0), y, "taller than the monitor goes to its top edge")
+
+	x, y = OnScreenPosition(RECT{0, 0, 800, 600}, RECT{Left: 1920, Top: 0, Right: 3840, Bottom: 1080})
+	assert.Equal(t, int32(2480), x, "centred on a primary monitor that does not start at the origin")
+	assert.Equal(t, int32(240), y)
+}
+
+func TestRECT_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "(-2000,100)-(-1200,700)", RECT{-2000, 100, -1200, 700}.String())
+}
+
+func TestRECT_Minimized(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, RECT{-32000, -32000, -31840, -31972}.Minimized())
+	assert.False(t, RECT{-2000, 100, -1200, 700}.Minimized(), "a window on a monitor left of the primary is not minimized")
+}
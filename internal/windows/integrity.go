@@ -0,0 +1,175 @@
+//go:build windows
+
+package windows
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// IntegrityLevel is a process's mandatory integrity level, the RID of its
+// token's mandatory label SID. User Interface Privilege Isolation (UIPI)
+// silently drops input sent to a window whose process has a higher level.
+type IntegrityLevel uint32
+
+const (
+	IntegrityUntrusted  IntegrityLevel = 0x0000
+	IntegrityLow        IntegrityLevel = 0x1000
+	IntegrityMedium     IntegrityLevel = 0x2000
+	IntegrityMediumPlus IntegrityLevel = 0x2100
+	IntegrityHigh       IntegrityLevel = 0x3000
+	IntegritySystem     IntegrityLevel = 0x4000
+	IntegrityProtected  IntegrityLevel = 0x5000
+)
+
+// String returns the level's name, as shown by Process Explorer
+func (l IntegrityLevel) String() string {
+	switch l {
+	case IntegrityUntrusted:
+		return "Untrusted"
+	case IntegrityLow:
+		return "Low"
+	case IntegrityMedium:
+		return "Medium"
+	case IntegrityMediumPlus:
+		return "Medium Plus"
+	case IntegrityHigh:
+		return "High"
+	case IntegritySystem:
+		return "System"
+	case IntegrityProtected:
+		return "Protected"
+	default:
+		return fmt.Sprintf("0x%04X", uint32(l))
+	}
+}
+
+// CanSendInputTo reports whether UIPI lets a process at level l send input to a window at target
+func (l IntegrityLevel) CanSendInputTo(target IntegrityLevel) bool {
+	return l >= target
+}
+
+// mandatoryLabelAuthority is SECURITY_MANDATORY_LABEL_AUTHORITY, S-1-16
+var mandatoryLabelAuthority = [6]byte{0, 0, 0, 0, 0, 16}
+
+// integrityFromSID extracts the integrity level from a mandatory label SID in
+// its binary form: revision, sub-authority count, 6-byte identifier authority,
+// then little-endian 32-bit sub-authorities, the last of which is the level
+func integrityFromSID(sid []byte) (IntegrityLevel, error) {
+	if len(sid) < 8 {
+		return 0, errors.New("mandatory label SID is truncated")
+	}
+
+	if [6]byte(sid[2:8]) != mandatoryLabelAuthority {
+		return 0, errors.New("SID is not a mandatory label")
+	}
+
+	count := int(sid[1])
+	if count == 0 {
+		return 0, errors.New("mandatory label SID has no sub-authorities")
+	}
+
+	if len(sid) < 8+4*count {
+		return 0, errors.New("mandatory label SID is truncated")
+	}
+
+	rid := binary.LittleEndian.Uint32(sid[8+4*(count-1):])
+
+	return IntegrityLevel(rid), nil
+}
+
+// ProcessIntegrity returns the integrity level of the process with the given PID
+func ProcessIntegrity(pid uint32) (IntegrityLevel, error) {
+	hProcess, _, err := procOpenProcess.Call(
+		uintptr(PROCESS_QUERY_LIMITED_INFORMATION),
+		uintptr(0),
+		uintptr(pid),
+	)
+	if hProcess == 0 {
+		return 0, fmt.Errorf("failed to open process %d: %w", pid, err)
+	}
+	defer func() { _, _, _ = ProcCloseHandle.Call(hProcess) }()
+
+	var token uintptr
+
+	ret, _, err := procOpenProcessToken.Call(hProcess, uintptr(TOKEN_QUERY), uintptr(unsafe.Pointer(&token)))
+	if ret == 0 {
+		return 0, fmt.Errorf("failed to open token of process %d: %w", pid, err)
+	}
+	defer func() { _, _, _ = ProcCloseHandle.Call(token) }()
+
+	// The first call reports the size of the TOKEN_MANDATORY_LABEL and the SID it points to
+	var size uint32
+	_, _, _ = procGetTokenInformation.Call(token, uintptr(TokenIntegrityLevel), 0, 0, uintptr(unsafe.Pointer(&size)))
+	if size == 0 {
+		return 0, fmt.Errorf("failed to size integrity level of process %d", pid)
+	}
+
+	buf := make([]byte, size)
+
+	ret, _, err = procGetTokenInformation.Call(
+		token,
+		uintptr(TokenIntegrityLevel),
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(size),
+		uintptr(unsafe.Pointer(&size)),
+	)
+	if ret == 0 {
+		return 0, fmt.Errorf("failed to read integrity level of process %d: %w", pid, err)
+	}
+
+	// TOKEN_MANDATORY_LABEL starts with a pointer to the label SID, which Windows
+	// places later in the same buffer
+	sidAddr := *(*uintptr)(unsafe.Pointer(&buf[0]))
+	offset := sidAddr - uintptr(unsafe.Pointer(&buf[0]))
+	if offset >= uintptr(len(buf)) {
+		return 0, fmt.Errorf("integrity level of process %d points outside the token buffer", pid)
+	}
+
+	return integrityFromSID(buf[offset:])
+}
+
+// ProcessName returns the executable name of the process with the given PID,
+// or "" if it cannot be read
+func ProcessName(pid uint32) string {
+	path := ProcessImagePath(pid)
+	if path == "" {
+		return ""
+	}
+
+	return filepath.Base(path)
+}
+
+// ProcessImagePath returns the full path of the executable of the process with
+// the given PID, or "" if it cannot be read. It only needs limited query
+// access, so it also works on elevated processes.
+func ProcessImagePath(pid uint32) string {
+	hProcess, _, _ := procOpenProcess.Call(
+		uintptr(PROCESS_QUERY_LIMITED_INFORMATION),
+		uintptr(0),
+		uintptr(pid),
+	)
+	if hProcess == 0 {
+		return ""
+	}
+	defer func() { _, _, _ = ProcCloseHandle.Call(hProcess) }()
+
+	buf := make([]uint16, MAX_PATH)
+	size := uint32(len(buf))
+
+	ret, _, _ := procQueryFullProcessImageW.Call(
+		hProcess,
+		0,
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(unsafe.Pointer(&size)),
+	)
+	if ret == 0 {
+		return ""
+	}
+
+	return syscall.UTF16ToString(buf[:size])
+}
@@ -0,0 +1,82 @@
+//go:build windows
+
+package windows
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// labelSID builds the binary form of S-1-16-<rids...>, as found in a token's mandatory label
+func labelSID(rids ...uint32) []byte {
+	sid := []byte{1, byte(len(rids)), 0, 0, 0, 0, 0, 16}
+	for _, rid := range rids {
+		sid = binary.LittleEndian.AppendUint32(sid, rid)
+	}
+
+	return sid
+}
+
+func TestIntegrityFromSID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sid  []byte
+		want IntegrityLevel
+	}{
+		{"medium", labelSID(0x2000), IntegrityMedium},
+		{"high", labelSID(0x3000), IntegrityHigh},
+		{"system", labelSID(0x4000), IntegritySystem},
+		{"last sub-authority wins", labelSID(0x1000, 0x2100), IntegrityMediumPlus},
+		{"trailing bytes ignored", append(labelSID(0x1000), 0xFF, 0xFF), IntegrityLow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := integrityFromSID(tt.sid)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIntegrityFromSID_Invalid(t *testing.T) {
+	t.Parallel()
+
+	// S-1-5-32-544 (Administrators) is not a mandatory label
+	admins := []byte{1, 2, 0, 0, 0, 0, 0, 5}
+	admins = binary.LittleEndian.AppendUint32(admins, 32)
+	admins = binary.LittleEndian.AppendUint32(admins, 544)
+
+	for name, sid := range map[string][]byte{
+		"empty":              nil,
+		"short header":       {1, 1, 0, 0},
+		"no sub-authorities": labelSID(),
+		"truncated rid":      labelSID(0x3000)[:10],
+		"not a label":        admins,
+	} {
+		_, err := integrityFromSID(sid)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestIntegrityLevel_CanSendInputTo(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IntegrityMedium.CanSendInputTo(IntegrityMedium))
+	assert.True(t, IntegrityHigh.CanSendInputTo(IntegrityMedium))
+	assert.False(t, IntegrityMedium.CanSendInputTo(IntegrityHigh), "UIPI blocks input to an elevated window")
+	assert.False(t, IntegrityHigh.CanSendInputTo(IntegritySystem))
+}
+
+func TestIntegrityLevel_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "High", IntegrityHigh.String())
+	assert.Equal(t, "Medium Plus", IntegrityMediumPlus.String())
+	assert.Equal(t, "0x3500", IntegrityLevel(0x3500).String())
+}
@@ -13,12 +13,13 @@ import (
 
 // keyboardInjector implements the KeyboardInjector interface
 type keyboardInjector struct {
-	log logger.LoggerInterface
+	log   logger.LoggerInterface
+	delay time.Duration // Between each key's down and up events
 }
 
 // newKeyboardInjector creates a new keyboard injector
 func newKeyboardInjector(log logger.LoggerInterface) *keyboardInjector {
-	return &keyboardInjector{log: log}
+	return &keyboardInjector{log: log, delay: timeouts.DefaultInput().Keystroke}
 }
 
 // SendF12 sends the F12 key
@@ -29,12 +30,12 @@ func (k *keyboardInjector) SendF12() {
 	// keybd_event(vk, scan, flags, extraInfo)
 	// Note: keybd_event has void return type, no error checking needed
 	k.log.Debug("Sending F12 KEYDOWN")
-	_, _, _ = procKeybd_event.Call(vkCode, 0, 0x1, 0) // KEYEVENTF_EXTENDEDKEY
+	_, _, _ = call(procKeybd_event, vkCode, 0, 0x1, 0) // KEYEVENTF_EXTENDEDKEY
 
-	time.Sleep(timeouts.KeystrokeDelay)
+	time.Sleep(k.delay)
 
 	k.log.Debug("Sending F12 KEYUP")
-	_, _, _ = procKeybd_event.Call(vkCode, 0, 0x1|0x2, 0) // KEYEVENTF_EXTENDEDKEY | KEYEVENTF_KEYUP
+	_, _, _ = call(procKeybd_event, vkCode, 0, 0x1|0x2, 0) // KEYEVENTF_EXTENDEDKEY | KEYEVENTF_KEYUP
 }
 
 // SendEnter sends the Enter key
@@ -44,11 +45,11 @@ func (k *keyboardInjector) SendEnter() {
 
 	// Note: keybd_event has void return type, no error checking needed
 	k.log.Debug("Sending Enter KEYDOWN")
-	_, _, _ = procKeybd_event.Call(vkCode, 0, 0x1, 0)
-	time.Sleep(timeouts.KeystrokeDelay)
+	_, _, _ = call(procKeybd_event, vkCode, 0, 0x1, 0)
+	time.Sleep(k.delay)
 
 	k.log.Debug("Sending Enter KEYUP")
-	_, _, _ = procKeybd_event.Call(vkCode, 0, 0x1|0x2, 0)
+	_, _, _ = call(procKeybd_event, vkCode, 0, 0x1|0x2, 0)
 }
 
 // SendF12ToWindow sends F12 key directly to a specific window using SendMessage
@@ -69,17 +70,38 @@ func (k *keyboardInjector) SendF12ToWindow(hwnd uintptr) bool {
 
 	// Try SendMessage first (synchronous)
 	k.log.Debug("Trying SendMessage for F12")
-	ret, _, _ := procSendMessageW.Call(hwnd, WM_KEYDOWN, VK_F12, lParamDown)
+	ret, _, _ := call(procSendMessageW, hwnd, WM_KEYDOWN, VK_F12, lParamDown)
 	k.log.Debug("SendMessage WM_KEYDOWN returned", slog.Uint64("ret", uint64(ret)))
-	time.Sleep(timeouts.KeystrokeDelay)
+	time.Sleep(k.delay)
 
-	ret, _, _ = procSendMessageW.Call(hwnd, WM_KEYUP, VK_F12, lParamUp)
+	ret, _, _ = call(procSendMessageW, hwnd, WM_KEYUP, VK_F12, lParamUp)
 	k.log.Debug("SendMessage WM_KEYUP returned", slog.Uint64("ret", uint64(ret)))
 
 	k.log.Debug("F12 sent via SendMessage (synchronous)")
 	return true
 }
 
+// SendEscToWindow posts Escape to a specific window, e.g. to dismiss a dialog that
+// received a keystroke meant for another window
+func (k *keyboardInjector) SendEscToWindow(hwnd uintptr) bool {
+	k.log.Debug("Sending Escape to window via PostMessage", slog.Uint64("hwnd", uint64(hwnd)))
+
+	const scanCodeEscape = 0x01
+	lParamDown := uintptr(1 | (scanCodeEscape << 16))
+	lParamUp := uintptr(1 | (scanCodeEscape << 16) | (1 << 30) | (1 << 31))
+
+	ret, _, _ := call(procPostMessageW, hwnd, WM_KEYDOWN, VK_ESCAPE, lParamDown)
+	if ret == 0 {
+		k.log.Debug("PostMessage WM_KEYDOWN failed for Escape")
+		return false
+	}
+
+	time.Sleep(k.delay)
+
+	ret, _, _ = call(procPostMessageW, hwnd, WM_KEYUP, VK_ESCAPE, lParamUp)
+	return ret != 0
+}
+
 // SendF12WithSendInput sends F12 key using SendInput API (more modern than keybd_event)
 func (k *keyboardInjector) SendF12WithSendInput() bool {
 	k.log.Debug("Sending F12 via SendInput")
@@ -100,11 +122,12 @@ func (k *keyboardInjector) SendF12WithSendInput() bool {
 	kb2.DwFlags = KEYEVENTF_EXTENDEDKEY | KEYEVENTF_KEYUP
 
 	// Send the input
-	ret, _, _ := procSendInput.Call(
+	ret, _, err := procSendInput.Call(
 		uintptr(len(inputs)),
 		uintptr(unsafe.Pointer(&inputs[0])),
 		uintptr(unsafe.Sizeof(INPUT{})),
 	)
+	traceCall(procSendInput, ret, err, uintptr(len(inputs)), uintptr(unsafe.Pointer(&inputs[0])), uintptr(unsafe.Sizeof(INPUT{})))
 
 	if ret != uintptr(len(inputs)) {
 		k.log.Warn("SendInput failed", slog.Uint64("expected", uint64(len(inputs))), slog.Uint64("sent", uint64(ret)))
@@ -114,3 +137,50 @@ func (k *keyboardInjector) SendF12WithSendInput() bool {
 	k.log.Debug("F12 sent via SendInput successfully")
 	return true
 }
+
+// SendCtrlS sends Ctrl+S to save the active document
+func (k *keyboardInjector) SendCtrlS() bool {
+	return k.SendChord(VK_CONTROL, VK_S)
+}
+
+// SendChord sends a modifier+key combination using SendInput, e.g. Ctrl+S.
+// The four key events are sent atomically so no other input can interleave.
+func (k *keyboardInjector) SendChord(modifier, key uint16) bool {
+	k.log.Debug("Sending key chord via SendInput",
+		slog.Uint64("modifier", uint64(modifier)),
+		slog.Uint64("key", uint64(key)),
+	)
+
+	events := []struct {
+		vk    uint16
+		flags uint32
+	}{
+		{modifier, 0},
+		{key, 0},
+		{key, KEYEVENTF_KEYUP},
+		{modifier, KEYEVENTF_KEYUP},
+	}
+
+	inputs := make([]INPUT, len(events))
+	for i, ev := range events {
+		inputs[i].Type = INPUT_KEYBOARD
+		kb := (*KEYBDINPUT)(unsafe.Pointer(&inputs[i].Data[0]))
+		kb.WVk = ev.vk
+		kb.DwFlags = ev.flags
+	}
+
+	ret, _, err := procSendInput.Call(
+		uintptr(len(inputs)),
+		uintptr(unsafe.Pointer(&inputs[0])),
+		uintptr(unsafe.Sizeof(INPUT{})),
+	)
+	traceCall(procSendInput, ret, err, uintptr(len(inputs)), uintptr(unsafe.Pointer(&inputs[0])), uintptr(unsafe.Sizeof(INPUT{})))
+
+	if ret != uintptr(len(inputs)) {
+		k.log.Warn("SendInput failed", slog.Uint64("expected", uint64(len(inputs))), slog.Uint64("sent", uint64(ret)))
+		return false
+	}
+
+	k.log.Debug("Key chord sent via SendInput successfully")
+	return true
+}
@@ -5,14 +5,18 @@ package windows
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/Norgate-AV/vtpc/internal/errcode"
 	"github.com/Norgate-AV/vtpc/internal/logger"
 )
 
 // monitorManager handles window monitoring functionality
 type monitorManager struct {
-	log logger.LoggerInterface
+	log      logger.LoggerInterface
+	stats    statsCollector
+	snapshot SnapshotFilter // Windows whose child text is read on detection, nil for none
 }
 
 // newMonitorManager creates a new monitor manager
@@ -20,81 +24,216 @@ func newMonitorManager(log logger.LoggerInterface) *monitorManager {
 	return &monitorManager{log: log}
 }
 
-// StartWindowMonitor launches a background goroutine that monitors windows
-// The goroutine will stop when the context is canceled
-func (m *monitorManager) StartWindowMonitor(ctx context.Context, pid uint32, interval time.Duration) {
-	seen := make(map[uintptr]bool)
+// ErrMonitorRunning is returned when a window monitor is started while another is running
+var ErrMonitorRunning = errcode.New(errcode.MonitorRunning, "window monitor is already running")
+
+var (
+	monitorMu      sync.Mutex
+	monitorRunning bool
+)
+
+// statsLogInterval is how often a running monitor logs its stats
+const statsLogInterval = 30 * time.Second
+
+// Stats returns the stats of the current or most recent monitor run
+func (m *monitorManager) Stats() MonitorStats {
+	return m.stats.snapshot()
+}
+
+// DialogSpans returns how long each window the current or most recent monitor
+// run published stayed open, longest first, counting those still open up to end
+func (m *monitorManager) DialogSpans(end time.Time) []DialogSpan {
+	return DialogSpans(m.stats.lifecycleEvents(), end)
+}
+
+// StartWindowMonitor creates MonitorCh and launches a background goroutine that
+// sends it an event for each new window. MonitorCh is ready to read when this
+// returns. The returned stop function waits for the goroutine to exit.
+func (m *monitorManager) StartWindowMonitor(pid uint32, interval time.Duration) (stop func(), err error) {
+	monitorMu.Lock()
+	defer monitorMu.Unlock()
+
+	if monitorRunning {
+		return nil, ErrMonitorRunning
+	}
+
+	// Larger buffer to handle bursts of events
+	// (startup splash screens, progress dialogs, warnings, compiling dialog, etc.)
+	events := make(chan WindowEvent, 256)
+	MonitorCh = events
+	monitorRunning = true
+	m.stats.reset(interval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
 
 	go func() {
-		m.log.Debug("Window monitor started")
-
-		for {
-			select {
-			case <-ctx.Done():
-				m.log.Debug("Window monitor stopped")
-				return
-			default:
-			}
+		defer wg.Done()
+		m.run(ctx, pid, interval, events)
+	}()
 
-			windows := EnumerateWindows()
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			cancel()
+			wg.Wait()
 
-			for _, w := range windows {
-				if pid != 0 && w.Pid != pid {
-					continue
-				}
-				if !seen[w.Hwnd] {
-					seen[w.Hwnd] = true
-					// Log top-level window info
-					m.log.Debug("Window detected",
-						slog.Uint64("hwnd", uint64(w.Hwnd)),
-						slog.Uint64("pid", uint64(w.Pid)),
-						slog.String("class", GetClassName(w.Hwnd)),
-						slog.String("title", w.Title),
-					)
+			monitorMu.Lock()
+			monitorRunning = false
+			monitorMu.Unlock()
+		})
+	}
+
+	return stop, nil
+}
+
+// run polls for new windows until the context is canceled
+func (m *monitorManager) run(ctx context.Context, pid uint32, interval time.Duration, events chan<- WindowEvent) {
+	seen := make(map[uintptr]bool)
+
+	// Published windows that are still open, so their closing is noticed, and
+	// whether a recorder was told of their opening
+	open := make(map[uintptr]bool)
+
+	m.log.Debug("Window monitor started")
 
-					// Enumerate child controls and log their text (trace level - file only)
-					childTexts := CollectChildTexts(w.Hwnd)
-					if len(childTexts) > 0 {
-						for _, ct := range childTexts {
-							if ct != "" {
-								m.log.Trace("Child control text", slog.String("text", ct))
-							}
+	lastStatsLog := time.Now()
+
+	// The class cache is shared, so count only the lookups made during this run
+	baseHits, baseMisses := classCache.Counts()
+
+	for {
+		pollStart := time.Now()
+		windows := EnumerateWindows()
+		recorder := currentEventRecorder()
+
+		// Drop cached classes of destroyed windows before their handles can be reused
+		classCache.Prune()
+
+		for _, w := range windows {
+			if pid != 0 && w.Pid != pid {
+				continue
+			}
+			if !seen[w.Hwnd] {
+				seen[w.Hwnd] = true
+				class := CachedClassName(w.Hwnd)
+
+				// Log top-level window info
+				m.log.Debug("Window detected",
+					slog.Uint64("hwnd", uint64(w.Hwnd)),
+					slog.Uint64("pid", uint64(w.Pid)),
+					slog.String("class", class),
+					slog.String("title", DisplayTitle(w.Title, class)),
+				)
+
+				// Enumerate child controls and log their text (trace level - file only)
+				childTexts := CollectChildTexts(w.Hwnd)
+				if len(childTexts) > 0 {
+					for _, ct := range childTexts {
+						if ct != "" {
+							m.log.Trace("Child control text", slog.String("text", ct))
 						}
 					}
+				}
 
-					// Broadcast event (non-blocking) and store in recent cache
-					if MonitorCh != nil {
-						ev := WindowEvent{
-							Hwnd:  w.Hwnd,
-							Title: w.Title,
-							Pid:   w.Pid,
-							Class: GetClassName(w.Hwnd),
-						}
+				// Broadcast event (non-blocking) and store in recent cache
+				ev := WindowEvent{
+					Hwnd:  w.Hwnd,
+					Title: w.Title,
+					Pid:   w.Pid,
+					Class: class,
+				}
 
-						recentMu.Lock()
-						recentEvents = append(recentEvents, ev)
+				// Read now rather than by the consumer, which a dialog that closes itself may not wait for
+				if m.snapshot != nil && m.snapshot(w.Title, class) {
+					ev.ChildTextSnapshot = childTextSnapshot(CollectChildInfos(w.Hwnd))
+				}
 
-						if len(recentEvents) > 256 {
-							recentEvents = recentEvents[len(recentEvents)-256:]
-						}
+				recentMu.Lock()
+				recentEvents = append(recentEvents, ev)
 
-						recentMu.Unlock()
-
-						select {
-						case MonitorCh <- ev:
-						default:
-							m.log.Warn("window monitor buffer full, event dropped",
-								slog.String("title", ev.Title),
-								slog.Uint64("hwnd", uint64(ev.Hwnd)),
-								slog.Uint64("pid", uint64(ev.Pid)),
-								slog.String("class", ev.Class),
-							)
-						}
+				if len(recentEvents) > 256 {
+					recentEvents = recentEvents[len(recentEvents)-256:]
+				}
+
+				recentMu.Unlock()
+
+				m.stats.recordLifecycle(LifecycleEvent{Hwnd: ev.Hwnd, Title: ev.Title, Class: ev.Class, At: time.Now()})
+				open[ev.Hwnd] = recorder != nil
+
+				if recorder != nil {
+					var controls []ChildInfo
+					if ev.Class == DialogClass {
+						controls = CollectChildInfos(ev.Hwnd)
 					}
+
+					recorder.RecordOpen(ev, controls)
+				}
+
+				select {
+				case events <- ev:
+					m.stats.recordEvent(ev, false)
+				default:
+					m.stats.recordEvent(ev, true)
+					m.log.Warn("window monitor buffer full, event dropped",
+						slog.String("title", ev.DisplayTitle()),
+						slog.Uint64("hwnd", uint64(ev.Hwnd)),
+						slog.Uint64("pid", uint64(ev.Pid)),
+						slog.String("class", ev.Class),
+					)
 				}
 			}
+		}
+
+		for hwnd, recorded := range closedWindows(windows, open) {
+			m.stats.recordLifecycle(LifecycleEvent{Hwnd: hwnd, Closed: true, At: time.Now()})
 
-			time.Sleep(interval)
+			if recorded && recorder != nil {
+				recorder.RecordClose(hwnd)
+			}
 		}
-	}()
+
+		m.stats.recordPoll(len(windows), time.Since(pollStart))
+
+		hits, misses := classCache.Counts()
+		m.stats.recordClassCache(hits-baseHits, misses-baseMisses)
+
+		if time.Since(lastStatsLog) >= statsLogInterval {
+			lastStatsLog = time.Now()
+			m.log.Debug("Window monitor stats", slog.String("stats", m.Stats().String()))
+		}
+
+		select {
+		case <-ctx.Done():
+			m.log.Debug("Window monitor stopped", slog.String("stats", m.Stats().String()))
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// closedWindows returns the windows in open that are no longer enumerated,
+// with their values in open, and forgets them
+func closedWindows(current []WindowInfo, open map[uintptr]bool) map[uintptr]bool {
+	if len(open) == 0 {
+		return nil
+	}
+
+	present := make(map[uintptr]bool, len(current))
+	for _, w := range current {
+		present[w.Hwnd] = true
+	}
+
+	closed := make(map[uintptr]bool)
+
+	for hwnd, v := range open {
+		if !present[hwnd] {
+			closed[hwnd] = v
+			delete(open, hwnd)
+		}
+	}
+
+	return closed
 }
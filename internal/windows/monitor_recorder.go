@@ -0,0 +1,36 @@
+//go:build windows
+
+package windows
+
+import "sync"
+
+// EventRecorder receives what the window monitor sees, for --record-events
+type EventRecorder interface {
+	// RecordOpen is called for each event the monitor publishes. controls holds
+	// the child controls of dialog windows and is nil for other windows.
+	RecordOpen(ev WindowEvent, controls []ChildInfo)
+
+	// RecordClose is called when a window the monitor published has gone
+	RecordClose(hwnd uintptr)
+}
+
+var (
+	eventRecorder   EventRecorder
+	eventRecorderMu sync.Mutex
+)
+
+// SetEventRecorder makes the window monitor report to r. Pass nil to stop recording.
+func SetEventRecorder(r EventRecorder) {
+	eventRecorderMu.Lock()
+	defer eventRecorderMu.Unlock()
+
+	eventRecorder = r
+}
+
+// currentEventRecorder returns the recorder set by SetEventRecorder, or nil
+func currentEventRecorder() EventRecorder {
+	eventRecorderMu.Lock()
+	defer eventRecorderMu.Unlock()
+
+	return eventRecorder
+}
@@ -0,0 +1,66 @@
+//go:build windows
+
+package windows
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Bounds on the child text the monitor reads from a window, so a dialog with
+// a long list or edit control cannot stall the poll or hold much memory
+const (
+	SnapshotMaxControls = 32       // Controls read, counting only those with text
+	SnapshotMaxBytes    = 8 * 1024 // Text kept across all of them
+)
+
+// SnapshotFilter reports, by its title and class, whether the window monitor
+// should read a window's child text as soon as it detects it
+type SnapshotFilter func(title, class string) bool
+
+// SetSnapshotFilter sets the windows the monitor reads the child text of when
+// it detects them, into WindowEvent.ChildTextSnapshot. The read costs a child
+// enumeration, so keep the filter to dialogs whose text vtpc needs. Set it
+// before StartWindowMonitor; with none, no text is read.
+func (m *monitorManager) SetSnapshotFilter(f SnapshotFilter) {
+	m.snapshot = f
+}
+
+// childTextSnapshot returns the trimmed text of each control other than
+// buttons, in order, within SnapshotMaxControls and SnapshotMaxBytes, or nil
+// if none has any
+func childTextSnapshot(controls []ChildInfo) []string {
+	var (
+		texts []string
+		size  int
+	)
+
+	for _, ci := range controls {
+		if len(texts) == SnapshotMaxControls || size == SnapshotMaxBytes {
+			break
+		}
+
+		text := strings.TrimSpace(ci.Text)
+		if ci.ClassName == "Button" || text == "" {
+			continue
+		}
+
+		if size+len(text) > SnapshotMaxBytes {
+			text = truncateUTF8(text, SnapshotMaxBytes-size)
+		}
+
+		texts = append(texts, text)
+		size += len(text)
+	}
+
+	return texts
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
@@ -0,0 +1,48 @@
+//go:build windows
+
+package windows
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChildTextSnapshot(t *testing.T) {
+	t.Parallel()
+
+	got := childTextSnapshot([]ChildInfo{
+		{ClassName: "Static", Text: "  Unable to save lobby.vtp.\r\n"},
+		{ClassName: "Button", Text: "OK"},
+		{ClassName: "Static", Text: "   "},
+		{ClassName: "Edit", Text: "Program Errors: 0\r\nWarnings: 2"},
+	})
+
+	assert.Equal(t, []string{"Unable to save lobby.vtp.", "Program Errors: 0\r\nWarnings: 2"}, got)
+	assert.Nil(t, childTextSnapshot([]ChildInfo{{ClassName: "Button", Text: "OK"}}))
+}
+
+func TestChildTextSnapshot_Bounded(t *testing.T) {
+	t.Parallel()
+
+	many := make([]ChildInfo, SnapshotMaxControls+5)
+	for i := range many {
+		many[i] = ChildInfo{ClassName: "Static", Text: "line"}
+	}
+
+	assert.Len(t, childTextSnapshot(many), SnapshotMaxControls)
+
+	// A long control is cut at the byte limit, on a character boundary
+	got := childTextSnapshot([]ChildInfo{
+		{ClassName: "Static", Text: "Warnings"},
+		{ClassName: "Edit", Text: strings.Repeat("é", SnapshotMaxBytes)},
+		{ClassName: "Static", Text: "after the limit"},
+	})
+
+	if assert.Len(t, got, 2) {
+		assert.True(t, utf8.ValidString(got[1]))
+		assert.Equal(t, SnapshotMaxBytes, len(got[0])+len(got[1]))
+	}
+}
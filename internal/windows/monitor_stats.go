@@ -0,0 +1,161 @@
+//go:build windows
+
+package windows
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DialogClass is the window class of standard Windows dialogs, the windows vtpc must not miss
+const DialogClass = "#32770"
+
+// MonitorStats summarises the work done by a window monitor
+type MonitorStats struct {
+	Interval          time.Duration // Time between polls the monitor was started with
+	Polls             int
+	WindowsEnumerated int // Top-level windows seen, summed over all polls
+	MaxWindowsPerPoll int
+	EventsPublished   int
+	EventsDropped     int // Events lost because MonitorCh was full
+	DroppedCritical   int // Dropped events for dialogs, which vtpc may have needed to handle
+	TotalPollTime     time.Duration
+	MaxPollDuration   time.Duration
+	SlowPolls         int // Polls that took longer than Interval
+	ClassCacheHits    int // Class names served from the cache during the run
+	ClassCacheMisses  int // Class names that needed a GetClassName call
+}
+
+// AveragePollDuration returns the mean time a poll took
+func (s MonitorStats) AveragePollDuration() time.Duration {
+	if s.Polls == 0 {
+		return 0
+	}
+
+	return s.TotalPollTime / time.Duration(s.Polls)
+}
+
+// FallingBehind reports whether any poll took longer than the polling interval
+func (s MonitorStats) FallingBehind() bool {
+	return s.SlowPolls > 0
+}
+
+// Warnings describes monitor problems that may explain a flaky run
+func (s MonitorStats) Warnings() []string {
+	var warnings []string
+
+	if s.DroppedCritical > 0 {
+		warnings = append(warnings, fmt.Sprintf(
+			"vtpc: window monitor dropped %d dialog event(s) because its buffer was full; a VTPro dialog may have been missed",
+			s.DroppedCritical))
+	}
+
+	if s.FallingBehind() {
+		warnings = append(warnings, fmt.Sprintf(
+			"vtpc: window monitor fell behind: %d poll(s) took longer than %s (slowest %s)",
+			s.SlowPolls, s.Interval, s.MaxPollDuration.Round(time.Millisecond)))
+	}
+
+	return warnings
+}
+
+// String formats the stats on one line for logs and reports
+func (s MonitorStats) String() string {
+	str := fmt.Sprintf("%d polls, %d windows enumerated (max %d per poll), %d events published, %d dropped (%d dialogs), poll avg %s max %s",
+		s.Polls, s.WindowsEnumerated, s.MaxWindowsPerPoll, s.EventsPublished, s.EventsDropped, s.DroppedCritical,
+		s.AveragePollDuration().Round(time.Microsecond), s.MaxPollDuration.Round(time.Microsecond))
+
+	if s.ClassCacheHits+s.ClassCacheMisses > 0 {
+		str += fmt.Sprintf(", class cache %d hits %d misses", s.ClassCacheHits, s.ClassCacheMisses)
+	}
+
+	return str
+}
+
+// recordPoll adds one poll that enumerated the given number of windows and took d
+func (s *MonitorStats) recordPoll(enumerated int, d time.Duration) {
+	s.Polls++
+	s.WindowsEnumerated += enumerated
+	s.MaxWindowsPerPoll = max(s.MaxWindowsPerPoll, enumerated)
+	s.TotalPollTime += d
+	s.MaxPollDuration = max(s.MaxPollDuration, d)
+
+	if s.Interval > 0 && d > s.Interval {
+		s.SlowPolls++
+	}
+}
+
+// recordEvent adds one event the monitor tried to publish
+func (s *MonitorStats) recordEvent(ev WindowEvent, dropped bool) {
+	if !dropped {
+		s.EventsPublished++
+		return
+	}
+
+	s.EventsDropped++
+	if ev.Class == DialogClass {
+		s.DroppedCritical++
+	}
+}
+
+// statsCollector guards MonitorStats shared between the monitor goroutine and readers
+type statsCollector struct {
+	mu        sync.Mutex
+	stats     MonitorStats
+	lifecycle []LifecycleEvent // Published windows opening and closing, in order
+}
+
+func (c *statsCollector) reset(interval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats = MonitorStats{Interval: interval}
+	c.lifecycle = nil
+}
+
+func (c *statsCollector) recordLifecycle(ev LifecycleEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.lifecycle) < maxLifecycleEvents {
+		c.lifecycle = append(c.lifecycle, ev)
+	}
+}
+
+func (c *statsCollector) lifecycleEvents() []LifecycleEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.lifecycle)
+}
+
+func (c *statsCollector) recordPoll(enumerated int, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.recordPoll(enumerated, d)
+}
+
+func (c *statsCollector) recordEvent(ev WindowEvent, dropped bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.recordEvent(ev, dropped)
+}
+
+func (c *statsCollector) recordClassCache(hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.ClassCacheHits = hits
+	c.stats.ClassCacheMisses = misses
+}
+
+func (c *statsCollector) snapshot() MonitorStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
@@ -0,0 +1,151 @@
+//go:build windows
+
+package windows
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitorStats_RecordPoll(t *testing.T) {
+	t.Parallel()
+
+	s := MonitorStats{Interval: 100 * time.Millisecond}
+	s.recordPoll(40, 10*time.Millisecond)
+	s.recordPoll(55, 30*time.Millisecond)
+	s.recordPoll(45, 20*time.Millisecond)
+
+	assert.Equal(t, 3, s.Polls)
+	assert.Equal(t, 140, s.WindowsEnumerated)
+	assert.Equal(t, 55, s.MaxWindowsPerPoll)
+	assert.Equal(t, 60*time.Millisecond, s.TotalPollTime)
+	assert.Equal(t, 30*time.Millisecond, s.MaxPollDuration)
+	assert.Equal(t, 20*time.Millisecond, s.AveragePollDuration())
+	assert.Zero(t, s.SlowPolls)
+	assert.False(t, s.FallingBehind())
+}
+
+func TestMonitorStats_SlowPollFallsBehind(t *testing.T) {
+	t.Parallel()
+
+	s := MonitorStats{Interval: 100 * time.Millisecond}
+	s.recordPoll(10, 100*time.Millisecond) // Exactly the interval keeps up
+	assert.False(t, s.FallingBehind())
+
+	s.recordPoll(10, 250*time.Millisecond)
+	assert.Equal(t, 1, s.SlowPolls)
+	assert.True(t, s.FallingBehind())
+}
+
+func TestMonitorStats_NoIntervalNeverFallsBehind(t *testing.T) {
+	t.Parallel()
+
+	var s MonitorStats
+	s.recordPoll(10, time.Second)
+
+	assert.False(t, s.FallingBehind())
+}
+
+func TestMonitorStats_AverageWithoutPolls(t *testing.T) {
+	t.Parallel()
+
+	assert.Zero(t, MonitorStats{}.AveragePollDuration())
+}
+
+func TestMonitorStats_RecordEvent(t *testing.T) {
+	t.Parallel()
+
+	var s MonitorStats
+	s.recordEvent(WindowEvent{Title: "Compiling...", Class: DialogClass}, false)
+	s.recordEvent(WindowEvent{Title: "project.vtp - VisionTools Pro-e", Class: "Afx:400000"}, false)
+	s.recordEvent(WindowEvent{Title: "Tooltip", Class: "tooltips_class32"}, true)
+	s.recordEvent(WindowEvent{Title: "VisionTools(R) Pro-e", Class: DialogClass}, true)
+
+	assert.Equal(t, 2, s.EventsPublished)
+	assert.Equal(t, 2, s.EventsDropped)
+	assert.Equal(t, 1, s.DroppedCritical, "only dropped dialogs are critical")
+}
+
+func TestMonitorStats_Warnings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		stats MonitorStats
+		want  []string
+	}{
+		{
+			name:  "healthy",
+			stats: MonitorStats{Interval: 100 * time.Millisecond, Polls: 10, EventsDropped: 2},
+		},
+		{
+			name:  "dropped dialogs",
+			stats: MonitorStats{EventsDropped: 3, DroppedCritical: 2},
+			want:  []string{"dropped 2 dialog event(s)"},
+		},
+		{
+			name:  "falling behind",
+			stats: MonitorStats{Interval: 100 * time.Millisecond, SlowPolls: 4, MaxPollDuration: 312 * time.Millisecond},
+			want:  []string{"fell behind: 4 poll(s) took longer than 100ms (slowest 312ms)"},
+		},
+		{
+			name:  "both",
+			stats: MonitorStats{Interval: time.Second, DroppedCritical: 1, SlowPolls: 1, MaxPollDuration: 2 * time.Second},
+			want:  []string{"dropped 1 dialog event(s)", "fell behind"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.stats.Warnings()
+
+			if assert.Len(t, got, len(tt.want)) {
+				for i, want := range tt.want {
+					assert.Contains(t, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestMonitorStats_String(t *testing.T) {
+	t.Parallel()
+
+	s := MonitorStats{
+		Polls:             2,
+		WindowsEnumerated: 90,
+		MaxWindowsPerPoll: 50,
+		EventsPublished:   7,
+		EventsDropped:     1,
+		DroppedCritical:   1,
+		TotalPollTime:     4 * time.Millisecond,
+		MaxPollDuration:   3 * time.Millisecond,
+	}
+
+	assert.Equal(t, "2 polls, 90 windows enumerated (max 50 per poll), 7 events published, 1 dropped (1 dialogs), poll avg 2ms max 3ms", s.String())
+}
+
+func TestMonitorStats_StringWithClassCache(t *testing.T) {
+	t.Parallel()
+
+	s := MonitorStats{Polls: 1, ClassCacheHits: 40, ClassCacheMisses: 3}
+
+	assert.Contains(t, s.String(), ", class cache 40 hits 3 misses")
+}
+
+func TestStatsCollector_ResetAndSnapshot(t *testing.T) {
+	t.Parallel()
+
+	var c statsCollector
+	c.recordPoll(10, time.Millisecond)
+	c.recordEvent(WindowEvent{Class: DialogClass}, true)
+
+	c.reset(time.Second)
+	got := c.snapshot()
+
+	assert.Equal(t, MonitorStats{Interval: time.Second}, got, "reset starts a new run")
+}
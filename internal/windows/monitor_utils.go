@@ -5,6 +5,8 @@ package windows
 import (
 	"sync"
 	"syscall"
+
+	"github.com/Norgate-AV/vtpc/internal/textutil"
 )
 
 var (
@@ -22,7 +24,7 @@ var (
 
 func enumWindowsCallback(hwnd uintptr, lparam uintptr) uintptr {
 	if IsWindowVisible(hwnd) {
-		title := GetWindowText(hwnd)
+		title := textutil.Normalize(GetWindowText(hwnd))
 		pid := GetWindowPid(hwnd)
 
 		// Include even if title is empty; we may match by child text later
@@ -39,7 +41,7 @@ func EnumerateWindows() []WindowInfo {
 
 	foundWindows = nil
 	callback := syscall.NewCallback(enumWindowsCallback)
-	ret, _, _ := procEnumWindows.Call(callback, 0)
+	ret, _, _ := call(procEnumWindows, callback, 0)
 	if ret == 0 {
 		return nil
 	}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package windows
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var procRtlGetVersion = syscall.NewLazyDLL("ntdll.dll").NewProc("RtlGetVersion")
+
+// osVersionInfo is RTL_OSVERSIONINFOW
+type osVersionInfo struct {
+	Size         uint32
+	MajorVersion uint32
+	MinorVersion uint32
+	BuildNumber  uint32
+	PlatformID   uint32
+	CSDVersion   [128]uint16
+}
+
+// OSBuild returns the Windows version and build number, e.g. "10.0.22631",
+// or "" if it cannot be read. RtlGetVersion is used because GetVersionEx
+// reports whatever version the executable's manifest declares support for.
+func OSBuild() string {
+	info := osVersionInfo{Size: uint32(unsafe.Sizeof(osVersionInfo{}))}
+
+	// RtlGetVersion returns STATUS_SUCCESS, which is 0
+	if status, _, _ := procRtlGetVersion.Call(uintptr(unsafe.Pointer(&info))); status != 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%d.%d.%d", info.MajorVersion, info.MinorVersion, info.BuildNumber)
+}
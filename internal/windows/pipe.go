@@ -0,0 +1,310 @@
+//go:build windows
+
+package windows
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+const (
+	pipeAccessDuplex          = 0x00000003
+	fileFlagFirstPipeInstance = 0x00080000
+	pipeRejectRemoteClients   = 0x00000008 // PIPE_TYPE_BYTE | PIPE_READMODE_BYTE | PIPE_WAIT are all 0
+	pipeUnlimitedInstances    = 255
+	pipeBufferSize            = 64 * 1024
+	sddlRevision1             = 1
+
+	errorAccessDenied  syscall.Errno = 5
+	errorPipeConnected syscall.Errno = 535
+
+	invalidHandleValue = ^uintptr(0)
+)
+
+// pipeSDDL lets SYSTEM and the user who started the daemon, whose SID fills
+// in %s, use the pipe. An elevated daemon runs as the same user as that user's
+// editor, so the editor can connect without being elevated, but nobody else can.
+const pipeSDDL = "D:P(A;;GA;;;SY)(A;;GA;;;%s)"
+
+var (
+	// ErrPipeInUse is returned when another process already owns the pipe name
+	ErrPipeInUse = errcode.New(errcode.PipeInUse, "named pipe is already in use")
+
+	// ErrPipeClosed is returned by Accept once the listener is closed
+	ErrPipeClosed = errcode.New(errcode.PipeClosed, "named pipe listener is closed")
+)
+
+// currentProcess is the pseudo handle GetCurrentProcess returns
+const currentProcess = ^uintptr(0)
+
+// securityAttributes is the Win32 SECURITY_ATTRIBUTES structure
+type securityAttributes struct {
+	Length             uint32
+	SecurityDescriptor uintptr
+	InheritHandle      uint32
+}
+
+// PipeListener accepts connections on a named pipe, one pipe instance per connection
+type PipeListener struct {
+	name  string
+	sa    *securityAttributes
+	owner string // SID of the user who started the listener
+
+	mu      sync.Mutex
+	pending uintptr // First instance, created by ListenPipe to claim the name
+	closed  bool
+}
+
+// ListenPipe claims the named pipe and returns a listener for it.
+// It fails with ErrPipeInUse if another process is already listening.
+func ListenPipe(name string) (*PipeListener, error) {
+	owner, err := processUser(currentProcess)
+	if err != nil {
+		return nil, err
+	}
+
+	sa, err := pipeSecurity(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := createPipeInstance(name, sa, true)
+	if err != nil {
+		if errors.Is(err, errorAccessDenied) {
+			return nil, fmt.Errorf("%w: %s", ErrPipeInUse, name)
+		}
+
+		return nil, err
+	}
+
+	return &PipeListener{name: name, sa: sa, owner: owner, pending: h}, nil
+}
+
+// Accept waits for a client to connect and returns the connection. Clients
+// running as anyone but the listener's user are disconnected and not returned,
+// in case the pipe's security descriptor ever lets one through.
+func (l *PipeListener) Accept() (io.ReadWriteCloser, error) {
+	for {
+		h, err := l.connect()
+		if err != nil {
+			return nil, err
+		}
+
+		if user, err := pipeClientUser(h); err == nil && user == l.owner {
+			return pipeConn{File: os.NewFile(h, l.name)}, nil
+		}
+
+		_, _, _ = procDisconnectNamedPipe.Call(h)
+		_, _, _ = ProcCloseHandle.Call(h)
+	}
+}
+
+// connect waits for a client to connect to a pipe instance and returns the instance
+func (l *PipeListener) connect() (uintptr, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return 0, ErrPipeClosed
+	}
+
+	h := l.pending
+	l.pending = 0
+	l.mu.Unlock()
+
+	if h == 0 {
+		var err error
+		if h, err = createPipeInstance(l.name, l.sa, false); err != nil {
+			return 0, err
+		}
+	}
+
+	ret, _, callErr := procConnectNamedPipe.Call(h, 0)
+	if ret == 0 && !errors.Is(callErr, errorPipeConnected) {
+		_, _, _ = ProcCloseHandle.Call(h)
+		return 0, fmt.Errorf("ConnectNamedPipe failed for %s: %w", l.name, callErr)
+	}
+
+	l.mu.Lock()
+	closed := l.closed
+	l.mu.Unlock()
+
+	// The connection may be Close waking us up
+	if closed {
+		_, _, _ = ProcCloseHandle.Call(h)
+		return 0, ErrPipeClosed
+	}
+
+	return h, nil
+}
+
+// Close stops the listener and wakes an Accept blocked waiting for a client
+func (l *PipeListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+
+	l.closed = true
+	pending := l.pending
+	l.pending = 0
+	l.mu.Unlock()
+
+	if pending != 0 {
+		_, _, _ = ProcCloseHandle.Call(pending)
+		return nil
+	}
+
+	// ConnectNamedPipe cannot be interrupted, so connect to it ourselves
+	if conn, err := DialPipe(l.name); err == nil {
+		_ = conn.Close()
+	}
+
+	return nil
+}
+
+// DialPipe connects to a named pipe
+func DialPipe(name string) (io.ReadWriteCloser, error) {
+	return os.OpenFile(name, os.O_RDWR, 0)
+}
+
+// pipeConn is the server end of a pipe connection
+type pipeConn struct {
+	*os.File
+}
+
+// Close waits for the client to read what was written, then disconnects it
+func (c pipeConn) Close() error {
+	_, _, _ = procFlushFileBuffers.Call(c.Fd())
+	_, _, _ = procDisconnectNamedPipe.Call(c.Fd())
+
+	return c.File.Close()
+}
+
+// createPipeInstance creates one instance of a byte-mode named pipe
+func createPipeInstance(name string, sa *securityAttributes, first bool) (uintptr, error) {
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+
+	openMode := uintptr(pipeAccessDuplex)
+	if first {
+		openMode |= fileFlagFirstPipeInstance
+	}
+
+	h, _, callErr := procCreateNamedPipeW.Call(
+		uintptr(unsafe.Pointer(namePtr)),
+		openMode,
+		pipeRejectRemoteClients,
+		pipeUnlimitedInstances,
+		pipeBufferSize,
+		pipeBufferSize,
+		0,
+		uintptr(unsafe.Pointer(sa)),
+	)
+	if h == invalidHandleValue {
+		return 0, fmt.Errorf("CreateNamedPipe failed for %s: %w", name, callErr)
+	}
+
+	return h, nil
+}
+
+// pipeSecurity builds the security attributes described by pipeSDDL for the owner's SID
+func pipeSecurity(owner string) (*securityAttributes, error) {
+	sddlPtr, err := syscall.UTF16PtrFromString(fmt.Sprintf(pipeSDDL, owner))
+	if err != nil {
+		return nil, err
+	}
+
+	var sd uintptr
+
+	ret, _, callErr := procConvertStringSDToSD.Call(
+		uintptr(unsafe.Pointer(sddlPtr)),
+		sddlRevision1,
+		uintptr(unsafe.Pointer(&sd)),
+		0,
+	)
+	if ret == 0 {
+		return nil, fmt.Errorf("failed to build pipe security descriptor: %w", callErr)
+	}
+
+	return &securityAttributes{
+		Length:             uint32(unsafe.Sizeof(securityAttributes{})),
+		SecurityDescriptor: sd,
+	}, nil
+}
+
+// pipeClientUser returns the SID of the user the client of a connected pipe instance runs as
+func pipeClientUser(h uintptr) (string, error) {
+	var pid uint32
+
+	ret, _, err := procGetNamedPipeClientPID.Call(h, uintptr(unsafe.Pointer(&pid)))
+	if ret == 0 {
+		return "", fmt.Errorf("failed to identify pipe client: %w", err)
+	}
+
+	hProcess, _, err := procOpenProcess.Call(uintptr(PROCESS_QUERY_LIMITED_INFORMATION), 0, uintptr(pid))
+	if hProcess == 0 {
+		return "", fmt.Errorf("failed to open pipe client process %d: %w", pid, err)
+	}
+	defer func() { _, _, _ = ProcCloseHandle.Call(hProcess) }()
+
+	return processUser(hProcess)
+}
+
+// processUser returns the SID, as a string such as "S-1-5-21-...", of the user
+// the process runs as
+func processUser(hProcess uintptr) (string, error) {
+	var token uintptr
+
+	ret, _, err := procOpenProcessToken.Call(hProcess, uintptr(TOKEN_QUERY), uintptr(unsafe.Pointer(&token)))
+	if ret == 0 {
+		return "", fmt.Errorf("failed to open process token: %w", err)
+	}
+	defer func() { _, _, _ = ProcCloseHandle.Call(token) }()
+
+	// The first call reports the size of the TOKEN_USER and the SID it points to
+	var size uint32
+	_, _, _ = procGetTokenInformation.Call(token, uintptr(TokenUser), 0, 0, uintptr(unsafe.Pointer(&size)))
+	if size == 0 {
+		return "", errors.New("failed to size process token user")
+	}
+
+	buf := make([]byte, size)
+
+	ret, _, err = procGetTokenInformation.Call(
+		token,
+		uintptr(TokenUser),
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(size),
+		uintptr(unsafe.Pointer(&size)),
+	)
+	if ret == 0 {
+		return "", fmt.Errorf("failed to read process token user: %w", err)
+	}
+
+	// TOKEN_USER starts with a pointer to the user's SID, which Windows places
+	// later in the same buffer
+	sid := *(*uintptr)(unsafe.Pointer(&buf[0]))
+
+	var str *uint16
+
+	ret, _, err = procConvertSidToStringSid.Call(sid, uintptr(unsafe.Pointer(&str)))
+	runtime.KeepAlive(buf)
+
+	if ret == 0 {
+		return "", fmt.Errorf("failed to convert user SID: %w", err)
+	}
+	defer func() { _, _, _ = procLocalFree.Call(uintptr(unsafe.Pointer(str))) }()
+
+	return utf16PtrToString(str), nil
+}
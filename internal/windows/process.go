@@ -0,0 +1,171 @@
+//go:build windows
+
+package windows
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// Process is a process started by CreateProcess. The caller owns Handle and
+// must call Close when it no longer needs it.
+type Process struct {
+	Pid    uint32
+	Handle uintptr
+}
+
+// Close releases the process handle. It does not stop the process.
+func (p *Process) Close() error {
+	if p == nil || p.Handle == 0 {
+		return nil
+	}
+
+	ret, _, err := ProcCloseHandle.Call(p.Handle)
+	p.Handle = 0
+	if ret == 0 {
+		return fmt.Errorf("failed to close process handle: %w", err)
+	}
+
+	return nil
+}
+
+// CPUTime returns the CPU time the process has used in user and kernel mode.
+// It still works after the process has exited, as long as the handle is open.
+func (p *Process) CPUTime() (time.Duration, error) {
+	return processCPUTime(p.Handle)
+}
+
+// CurrentProcessCPUTime returns the CPU time vtpc itself has used so far
+func CurrentProcessCPUTime() (time.Duration, error) {
+	self, _, _ := procGetCurrentProcess.Call()
+	return processCPUTime(self)
+}
+
+// processCPUTime returns the user plus kernel time of the process behind handle
+func processCPUTime(handle uintptr) (time.Duration, error) {
+	var creation, exit, kernel, user FILETIME
+
+	ret, _, err := procGetProcessTimes.Call(
+		handle,
+		uintptr(unsafe.Pointer(&creation)),
+		uintptr(unsafe.Pointer(&exit)),
+		uintptr(unsafe.Pointer(&kernel)),
+		uintptr(unsafe.Pointer(&user)),
+	)
+
+	if ret == 0 {
+		return 0, fmt.Errorf("GetProcessTimes failed: %w", err)
+	}
+
+	return fileTimeDuration(kernel) + fileTimeDuration(user), nil
+}
+
+// fileTimeDuration converts a FILETIME holding an interval, rather than a date, to a duration
+func fileTimeDuration(ft FILETIME) time.Duration {
+	return time.Duration(uint64(ft.HighDateTime)<<32|uint64(ft.LowDateTime)) * 100
+}
+
+// CreateProcess launches an executable with arguments and returns the process with
+// its handle still open. This provides direct control over the command line, unlike
+// ShellExecuteEx which may modify arguments based on shell integration and file associations.
+func CreateProcess(exePath, args string, showCmd int, log logger.LoggerInterface) (*Process, error) {
+	// Validate that the executable exists before attempting to launch
+	if _, err := os.Stat(exePath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("executable not found: %s", exePath)
+	} else if err != nil {
+		return nil, fmt.Errorf("error checking executable at %s: %w", exePath, err)
+	}
+
+	cmdLinePtr, err := syscall.UTF16PtrFromString(buildCommandLine(exePath, args))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert command line to UTF16: %w", err)
+	}
+
+	si := newStartupInfo(showCmd)
+
+	var pi PROCESS_INFORMATION
+
+	// Call CreateProcessW
+	// lpApplicationName = nil (use command line instead)
+	// lpCommandLine = full command line
+	ret, _, err := procCreateProcessW.Call(
+		0,                                   // lpApplicationName (nil)
+		uintptr(unsafe.Pointer(cmdLinePtr)), // lpCommandLine
+		0,                                   // lpProcessAttributes (nil)
+		0,                                   // lpThreadAttributes (nil)
+		0,                                   // bInheritHandles (FALSE)
+		0,                                   // dwCreationFlags
+		0,                                   // lpEnvironment (nil)
+		0,                                   // lpCurrentDirectory (nil)
+		uintptr(unsafe.Pointer(&si)),        // lpStartupInfo
+		uintptr(unsafe.Pointer(&pi)),        // lpProcessInformation
+	)
+
+	if ret == 0 {
+		return nil, fmt.Errorf("CreateProcessW failed: %w", err)
+	}
+
+	// The thread handle is never needed; the process handle goes to the caller
+	if pi.HThread != 0 {
+		if ret, _, err := ProcCloseHandle.Call(pi.HThread); ret == 0 {
+			log.Debug("Failed to close thread handle", slog.Any("error", err))
+		}
+	}
+
+	return &Process{Pid: pi.DwProcessId, Handle: pi.HProcess}, nil
+}
+
+// buildCommandLine builds the full command line CreateProcess expects: "executable" arguments
+func buildCommandLine(exePath, args string) string {
+	if args == "" {
+		return QuoteArg(exePath)
+	}
+
+	return QuoteArg(exePath) + " " + args
+}
+
+// QuoteArg quotes one command-line argument so CommandLineToArgvW reads it back
+// unchanged. Unlike %q it leaves the backslashes in Windows paths alone.
+func QuoteArg(arg string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+
+	backslashes := 0
+	for i := 0; i < len(arg); i++ {
+		switch c := arg[i]; c {
+		case '\\':
+			backslashes++
+		case '"':
+			// Backslashes before a quote are escaped, then the quote itself
+			b.WriteString(strings.Repeat(`\`, backslashes*2+1))
+			b.WriteByte('"')
+			backslashes = 0
+		default:
+			b.WriteString(strings.Repeat(`\`, backslashes))
+			b.WriteByte(c)
+			backslashes = 0
+		}
+	}
+
+	// Trailing backslashes would escape the closing quote, so they are doubled
+	b.WriteString(strings.Repeat(`\`, backslashes*2))
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// newStartupInfo returns a STARTUPINFO that applies showCmd to the new process's window
+func newStartupInfo(showCmd int) STARTUPINFO {
+	return STARTUPINFO{
+		Cb:          uint32(unsafe.Sizeof(STARTUPINFO{})),
+		DwFlags:     STARTF_USESHOWWINDOW,
+		WShowWindow: uint16(showCmd),
+	}
+}
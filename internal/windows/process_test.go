@@ -0,0 +1,102 @@
+//go:build windows
+
+package windows
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+func TestQuoteArg(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		arg  string
+		want string
+	}{
+		{"windows path keeps single backslashes", `C:\Projects\Lobby Panel\lobby.vtp`, `"C:\Projects\Lobby Panel\lobby.vtp"`},
+		{"empty", "", `""`},
+		{"embedded quote", `say "hi"`, `"say \"hi\""`},
+		{"backslashes before a quote", `a\\"b`, `"a\\\\\"b"`},
+		{"trailing backslash", `C:\Projects\`, `"C:\Projects\\"`},
+		{"unicode", `C:\Projets\Salle à manger.vtp`, `"C:\Projets\Salle à manger.vtp"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, QuoteArg(tt.arg))
+		})
+	}
+}
+
+func TestBuildCommandLine(t *testing.T) {
+	t.Parallel()
+
+	exe := `C:\Program Files (x86)\Crestron\VtPro-e\vtpro.exe`
+
+	assert.Equal(t, `"C:\Program Files (x86)\Crestron\VtPro-e\vtpro.exe"`, buildCommandLine(exe, ""))
+	assert.Equal(t,
+		`"C:\Program Files (x86)\Crestron\VtPro-e\vtpro.exe" "C:\Projects\lobby.vtp"`,
+		buildCommandLine(exe, QuoteArg(`C:\Projects\lobby.vtp`)),
+	)
+}
+
+func TestNewStartupInfo(t *testing.T) {
+	t.Parallel()
+
+	si := newStartupInfo(1)
+
+	assert.Equal(t, uint32(unsafe.Sizeof(STARTUPINFO{})), si.Cb)
+	assert.Equal(t, uint32(STARTF_USESHOWWINDOW), si.DwFlags)
+	assert.Equal(t, uint16(1), si.WShowWindow)
+}
+
+func TestFileTimeDuration(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, time.Duration(0), fileTimeDuration(FILETIME{}))
+	assert.Equal(t, 1500*time.Millisecond, fileTimeDuration(FILETIME{LowDateTime: 15_000_000}))
+	assert.Equal(t, time.Duration(1<<32)*100, fileTimeDuration(FILETIME{HighDateTime: 1}))
+}
+
+func TestCreateProcess_MissingExecutable(t *testing.T) {
+	t.Parallel()
+
+	proc, err := CreateProcess(filepath.Join(t.TempDir(), "vtpro.exe"), "", 1, logger.NewNoOpLogger())
+
+	require.Error(t, err)
+	assert.Nil(t, proc)
+	assert.Contains(t, err.Error(), "executable not found")
+}
+
+func TestProcess_CloseWithoutHandle(t *testing.T) {
+	t.Parallel()
+
+	var nilProc *Process
+	require.NoError(t, nilProc.Close())
+	require.NoError(t, (&Process{Pid: 1234}).Close())
+}
+
+func TestCreateProcess_ExistingFileThatIsNotRunnable(t *testing.T) {
+	t.Parallel()
+
+	// A file that exists gets as far as CreateProcessW, which rejects it
+	exe := filepath.Join(t.TempDir(), "not-a-program.exe")
+	require.NoError(t, os.WriteFile(exe, []byte("not a program"), 0o644))
+
+	proc, err := CreateProcess(exe, "", 1, logger.NewNoOpLogger())
+
+	require.Error(t, err)
+	assert.Nil(t, proc)
+	assert.Contains(t, err.Error(), "CreateProcessW failed")
+}
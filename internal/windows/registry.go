@@ -0,0 +1,69 @@
+//go:build windows
+
+package windows
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/Norgate-AV/vtpc/internal/errcode"
+)
+
+const (
+	HKEY_CURRENT_USER = 0x80000001
+	RRF_RT_REG_SZ     = 0x00000002
+
+	errorFileNotFound = 2 // ERROR_FILE_NOT_FOUND, returned for a missing key or value
+)
+
+// ErrRegistryNotFound is returned when a registry key or value does not exist
+var ErrRegistryNotFound = errcode.New(errcode.RegistryNotFound, "registry value not found")
+
+// ReadUserRegistryString reads a string value from HKEY_CURRENT_USER
+func ReadUserRegistryString(subkey, value string) (string, error) {
+	subkeyPtr, err := syscall.UTF16PtrFromString(subkey)
+	if err != nil {
+		return "", err
+	}
+
+	valuePtr, err := syscall.UTF16PtrFromString(value)
+	if err != nil {
+		return "", err
+	}
+
+	// First call gets the size of the value in bytes
+	var size uint32
+	ret, _, _ := procRegGetValueW.Call(
+		HKEY_CURRENT_USER,
+		uintptr(unsafe.Pointer(subkeyPtr)),
+		uintptr(unsafe.Pointer(valuePtr)),
+		RRF_RT_REG_SZ,
+		0,
+		0,
+		uintptr(unsafe.Pointer(&size)),
+	)
+	if ret == errorFileNotFound {
+		return "", ErrRegistryNotFound
+	}
+
+	if ret != 0 {
+		return "", fmt.Errorf("error reading registry value %s\\%s: %w", subkey, value, syscall.Errno(ret))
+	}
+
+	buf := make([]uint16, size/2+1)
+	ret, _, _ = procRegGetValueW.Call(
+		HKEY_CURRENT_USER,
+		uintptr(unsafe.Pointer(subkeyPtr)),
+		uintptr(unsafe.Pointer(valuePtr)),
+		RRF_RT_REG_SZ,
+		0,
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(unsafe.Pointer(&size)),
+	)
+	if ret != 0 {
+		return "", fmt.Errorf("error reading registry value %s\\%s: %w", subkey, value, syscall.Errno(ret))
+	}
+
+	return syscall.UTF16ToString(buf), nil
+}
@@ -0,0 +1,67 @@
+//go:build windows
+
+package windows
+
+import "unsafe"
+
+// GetSystemMetrics indexes for the screen bounds
+const (
+	smCxScreen        = 0
+	smCyScreen        = 1
+	smXVirtualScreen  = 76
+	smYVirtualScreen  = 77
+	smCxVirtualScreen = 78
+	smCyVirtualScreen = 79
+)
+
+// SetWindowPos flags that move a window without resizing, reordering or activating it
+const (
+	swpNoSize     = 0x0001
+	swpNoZOrder   = 0x0004
+	swpNoActivate = 0x0010
+)
+
+var (
+	procGetSystemMetrics = user32.NewProc("GetSystemMetrics")
+	procSetWindowPos     = user32.NewProc("SetWindowPos")
+)
+
+// WindowRect returns a window's bounds in screen coordinates
+func WindowRect(hwnd uintptr) (RECT, bool) {
+	var rect RECT
+
+	ret, _, _ := procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&rect)))
+
+	return rect, ret != 0
+}
+
+// VirtualScreen returns the bounds of the virtual screen, the box around every
+// connected monitor. Monitors left of or above the primary one have negative coordinates.
+func VirtualScreen() RECT {
+	left, top := systemMetric(smXVirtualScreen), systemMetric(smYVirtualScreen)
+
+	return RECT{
+		Left:   left,
+		Top:    top,
+		Right:  left + systemMetric(smCxVirtualScreen),
+		Bottom: top + systemMetric(smCyVirtualScreen),
+	}
+}
+
+// PrimaryScreen returns the bounds of the primary monitor, which starts at the origin
+func PrimaryScreen() RECT {
+	return RECT{Right: systemMetric(smCxScreen), Bottom: systemMetric(smCyScreen)}
+}
+
+// MoveWindow moves a window's top-left corner to x, y without resizing,
+// reordering or activating it
+func MoveWindow(hwnd uintptr, x, y int32) bool {
+	ret, _, _ := procSetWindowPos.Call(hwnd, 0, uintptr(x), uintptr(y), 0, 0, swpNoSize|swpNoZOrder|swpNoActivate)
+	return ret != 0
+}
+
+// systemMetric returns a GetSystemMetrics value, which is 0 if it cannot be read
+func systemMetric(index int) int32 {
+	ret, _, _ := procGetSystemMetrics.Call(uintptr(index))
+	return int32(ret)
+}
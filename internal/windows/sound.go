@@ -0,0 +1,46 @@
+//go:build windows
+
+package windows
+
+import "fmt"
+
+var procMessageBeep = user32.NewProc("MessageBeep")
+
+// Tone is a system sound, identified by the MessageBeep type that plays it
+type Tone uint32
+
+const (
+	ToneSuccess Tone = 0x40 // MB_ICONASTERISK, the system "Asterisk" sound
+	ToneFailure Tone = 0x30 // MB_ICONEXCLAMATION, the system "Exclamation" sound
+)
+
+// String names the tone as the Sound control panel does
+func (t Tone) String() string {
+	switch t {
+	case ToneSuccess:
+		return "asterisk"
+	case ToneFailure:
+		return "exclamation"
+	default:
+		return fmt.Sprintf("tone(0x%X)", uint32(t))
+	}
+}
+
+// Sound plays a tone to get the user's attention
+type Sound interface {
+	Play(t Tone) error
+}
+
+// MessageBeepSound plays tones with MessageBeep, so they follow the user's sound
+// scheme. The call returns as soon as the sound starts.
+type MessageBeepSound struct{}
+
+// Play plays the tone
+func (MessageBeepSound) Play(t Tone) error {
+	ret, _, err := procMessageBeep.Call(uintptr(t))
+	if ret == 0 {
+		return fmt.Errorf("MessageBeep failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package windows
+
+import "strings"
+
+// untitledLabel stands in for the title of an untitled window in logs
+const untitledLabel = "Untitled"
+
+// IsUntitled reports whether a window title is empty or only whitespace. Such
+// titles are never matched by title rules, which could otherwise catch
+// splash screens and progress shells that were never meant to be closed.
+func IsUntitled(title string) bool {
+	return strings.TrimSpace(title) == ""
+}
+
+// DisplayTitle returns a window title for logs: the title itself, or for an
+// untitled window "Untitled" with its class, so it can still be told apart
+func DisplayTitle(title, class string) string {
+	if !IsUntitled(title) {
+		return title
+	}
+
+	if class == "" {
+		return untitledLabel
+	}
+
+	return untitledLabel + " (" + class + ")"
+}
+
+// Untitled reports whether the event's window has no title; see IsUntitled
+func (e WindowEvent) Untitled() bool {
+	return IsUntitled(e.Title)
+}
+
+// DisplayTitle returns the event's title for logs; see DisplayTitle
+func (e WindowEvent) DisplayTitle() string {
+	return DisplayTitle(e.Title, e.Class)
+}
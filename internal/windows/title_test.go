@@ -0,0 +1,30 @@
+//go:build windows
+
+package windows
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsUntitled(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsUntitled(""))
+	assert.True(t, IsUntitled(" \t "))
+	assert.False(t, IsUntitled("Progress [42%]"))
+	assert.False(t, IsUntitled(" VTPro "))
+}
+
+func TestDisplayTitle(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "VisionTools Pro-e", DisplayTitle("VisionTools Pro-e", DialogClass))
+	assert.Equal(t, "Untitled (#32770)", DisplayTitle("  ", DialogClass))
+	assert.Equal(t, "Untitled", DisplayTitle("", ""))
+
+	ev := WindowEvent{Hwnd: 0x100, Title: "", Class: "Afx:400000"}
+	assert.True(t, ev.Untitled())
+	assert.Equal(t, "Untitled (Afx:400000)", ev.DisplayTitle())
+}
@@ -0,0 +1,83 @@
+//go:build windows
+
+package windows
+
+import (
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// win32Tracer logs the Win32 calls made through call, nil when tracing is off
+var win32Tracer atomic.Pointer[tracer]
+
+// tracer logs Win32 calls at Trace level, numbering them in the order they return
+type tracer struct {
+	log logger.LoggerInterface
+	seq atomic.Uint64
+}
+
+// SetWin32Trace logs every Win32 call vtpc makes to drive VTPro's windows and
+// keyboard to log at Trace level, with its arguments, return value and last
+// error. A nil log stops tracing.
+func SetWin32Trace(log logger.LoggerInterface) {
+	if log == nil {
+		win32Tracer.Store(nil)
+		return
+	}
+
+	win32Tracer.Store(&tracer{log: log})
+}
+
+// call calls proc with args, logging the call when Win32 tracing is on
+func call(proc *syscall.LazyProc, args ...uintptr) (r1, r2 uintptr, err error) {
+	return traced(proc.Name, proc.Call, args...)
+}
+
+// traceCall logs a call already made to proc. It is for calls that pass a
+// pointer to Go memory, which Go only keeps in place during the call when it is
+// converted to a uintptr in proc.Call's own arguments.
+func traceCall(proc *syscall.LazyProc, ret uintptr, err error, args ...uintptr) {
+	if t := win32Tracer.Load(); t != nil {
+		t.record(proc.Name, args, ret, err)
+	}
+}
+
+// traced calls fn with args, logging the call as name when Win32 tracing is
+// on. What fn returns is returned unchanged either way.
+func traced(name string, fn func(...uintptr) (uintptr, uintptr, error), args ...uintptr) (r1, r2 uintptr, err error) {
+	r1, r2, err = fn(args...)
+
+	if t := win32Tracer.Load(); t != nil {
+		t.record(name, args, r1, err)
+	}
+
+	return r1, r2, err
+}
+
+// record logs one call. err is what GetLastError returned after it.
+func (t *tracer) record(name string, args []uintptr, ret uintptr, err error) {
+	attrs := []any{
+		slog.Uint64("seq", t.seq.Add(1)),
+		slog.String("proc", name),
+	}
+
+	for _, a := range decodeArgs(name, args) {
+		attrs = append(attrs, a)
+	}
+
+	attrs = append(attrs, slog.String("ret", fmt.Sprintf("0x%X", ret)))
+
+	// The last error only means something when the call failed, but is logged either way
+	errno, _ := err.(syscall.Errno)
+
+	attrs = append(attrs, slog.Uint64("lastError", uint64(errno)))
+	if errno != 0 {
+		attrs = append(attrs, slog.String("lastErrorText", errno.Error()))
+	}
+
+	t.log.Trace("Win32 call", attrs...)
+}
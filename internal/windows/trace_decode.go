@@ -0,0 +1,141 @@
+//go:build windows
+
+package windows
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// argKind is how a traced Win32 call's argument is shown
+type argKind int
+
+const (
+	argHex     argKind = iota // Handles, pointers and bit fields
+	argNumber                 // Counts, sizes and thread IDs
+	argMessage                // A window message, by name
+	argWParam                 // A message's wParam, as a virtual key for key messages
+	argVK                     // A virtual key, by name
+	argShow                   // A ShowWindow command, by name
+	argBool                   // A Win32 BOOL
+)
+
+// traceArg names one argument of a traced Win32 call
+type traceArg struct {
+	name string
+	kind argKind
+}
+
+// traceArgs is the arguments of each traced Win32 call, in order. Arguments of
+// calls not listed are shown in hex as arg0, arg1 and so on.
+var traceArgs = map[string][]traceArg{
+	"PostMessageW":             {{"hwnd", argHex}, {"msg", argMessage}, {"wParam", argWParam}, {"lParam", argHex}},
+	"SendMessageW":             {{"hwnd", argHex}, {"msg", argMessage}, {"wParam", argWParam}, {"lParam", argHex}},
+	"SetForegroundWindow":      {{"hwnd", argHex}},
+	"GetForegroundWindow":      {},
+	"GetWindowThreadProcessId": {{"hwnd", argHex}, {"pid", argHex}},
+	"AttachThreadInput":        {{"attach", argNumber}, {"attachTo", argNumber}, {"fAttach", argBool}},
+	"ShowWindow":               {{"hwnd", argHex}, {"cmd", argShow}},
+	"IsWindow":                 {{"hwnd", argHex}},
+	"keybd_event":              {{"vk", argVK}, {"scan", argHex}, {"flags", argHex}, {"extraInfo", argHex}},
+	"SendInput":                {{"count", argNumber}, {"inputs", argHex}, {"size", argNumber}},
+	"EnumWindows":              {{"callback", argHex}, {"lParam", argHex}},
+}
+
+// messageNames is the window messages vtpc sends, by value
+var messageNames = map[uintptr]string{
+	WM_NULL:          "WM_NULL",
+	WM_GETTEXT:       "WM_GETTEXT",
+	WM_GETTEXTLENGTH: "WM_GETTEXTLENGTH",
+	WM_CLOSE:         "WM_CLOSE",
+	WM_KEYDOWN:       "WM_KEYDOWN",
+	WM_KEYUP:         "WM_KEYUP",
+	WM_SYSKEYDOWN:    "WM_SYSKEYDOWN",
+	WM_SYSKEYUP:      "WM_SYSKEYUP",
+	WM_COMMAND:       "WM_COMMAND",
+	LB_GETTEXT:       "LB_GETTEXT",
+	LB_GETTEXTLEN:    "LB_GETTEXTLEN",
+	LB_GETCOUNT:      "LB_GETCOUNT",
+	EM_SETLIMITTEXT:  "EM_SETLIMITTEXT",
+	EM_GETLIMITTEXT:  "EM_GETLIMITTEXT",
+}
+
+// vkNames is the virtual keys vtpc sends, by value
+var vkNames = map[uintptr]string{
+	VK_RETURN:  "VK_RETURN",
+	VK_CONTROL: "VK_CONTROL",
+	VK_MENU:    "VK_MENU",
+	VK_ESCAPE:  "VK_ESCAPE",
+	VK_S:       "VK_S",
+	VK_F12:     "VK_F12",
+}
+
+// showNames is the ShowWindow commands vtpc uses, by value
+var showNames = map[uintptr]string{
+	SW_SHOWNORMAL:      "SW_SHOWNORMAL",
+	SW_MINIMIZE:        "SW_MINIMIZE",
+	SW_SHOWMINNOACTIVE: "SW_SHOWMINNOACTIVE",
+	SW_RESTORE:         "SW_RESTORE",
+}
+
+// keyMessages take a virtual key as their wParam
+var keyMessages = map[uintptr]bool{WM_KEYDOWN: true, WM_KEYUP: true, WM_SYSKEYDOWN: true, WM_SYSKEYUP: true}
+
+// decodeArgs returns the arguments of a call to the Win32 function name as
+// attributes, with constants shown by name
+func decodeArgs(name string, args []uintptr) []slog.Attr {
+	known := traceArgs[name]
+	attrs := make([]slog.Attr, len(args))
+
+	var msg uintptr
+
+	for i, v := range args {
+		arg := traceArg{name: fmt.Sprintf("arg%d", i), kind: argHex}
+		if i < len(known) {
+			arg = known[i]
+		}
+
+		if arg.kind == argMessage {
+			msg = v
+		}
+
+		attrs[i] = slog.String(arg.name, decodeArg(arg.kind, v, msg))
+	}
+
+	return attrs
+}
+
+// decodeArg formats v as kind. msg is the message a wParam belongs to.
+func decodeArg(kind argKind, v, msg uintptr) string {
+	switch kind {
+	case argNumber:
+		return fmt.Sprint(v)
+	case argMessage:
+		return named(messageNames, v)
+	case argWParam:
+		if keyMessages[msg] {
+			return named(vkNames, v)
+		}
+	case argVK:
+		return named(vkNames, v)
+	case argShow:
+		return named(showNames, v)
+	case argBool:
+		if v == 0 {
+			return "FALSE"
+		}
+
+		return "TRUE"
+	}
+
+	return fmt.Sprintf("0x%X", v)
+}
+
+// named returns v's name in names, or v in hex if it has none
+func named(names map[uintptr]string, v uintptr) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%X", v)
+}
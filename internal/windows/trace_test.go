@@ -0,0 +1,124 @@
+//go:build windows
+
+package windows
+
+import (
+	"io"
+	"log/slog"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+func TestDecodeArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		proc string
+		args []uintptr
+		want []slog.Attr
+	}{
+		{
+			name: "key message",
+			proc: "PostMessageW",
+			args: []uintptr{0x1A2B, WM_KEYDOWN, VK_ESCAPE, 0x10001},
+			want: []slog.Attr{slog.String("hwnd", "0x1A2B"), slog.String("msg", "WM_KEYDOWN"), slog.String("wParam", "VK_ESCAPE"), slog.String("lParam", "0x10001")},
+		},
+		{
+			name: "wParam of another message",
+			proc: "SendMessageW",
+			args: []uintptr{0x1A2B, WM_COMMAND, VK_ESCAPE, 0x3C4D},
+			want: []slog.Attr{slog.String("hwnd", "0x1A2B"), slog.String("msg", "WM_COMMAND"), slog.String("wParam", "0x1B"), slog.String("lParam", "0x3C4D")},
+		},
+		{
+			name: "unknown message",
+			proc: "PostMessageW",
+			args: []uintptr{0x1A2B, 0x0400, 0, 0},
+			want: []slog.Attr{slog.String("hwnd", "0x1A2B"), slog.String("msg", "0x400"), slog.String("wParam", "0x0"), slog.String("lParam", "0x0")},
+		},
+		{
+			name: "show command",
+			proc: "ShowWindow",
+			args: []uintptr{0x1A2B, SW_RESTORE},
+			want: []slog.Attr{slog.String("hwnd", "0x1A2B"), slog.String("cmd", "SW_RESTORE")},
+		},
+		{
+			name: "virtual key",
+			proc: "keybd_event",
+			args: []uintptr{VK_F12, 0, KEYEVENTF_EXTENDEDKEY | KEYEVENTF_KEYUP, 0},
+			want: []slog.Attr{slog.String("vk", "VK_F12"), slog.String("scan", "0x0"), slog.String("flags", "0x3"), slog.String("extraInfo", "0x0")},
+		},
+		{
+			name: "numbers and booleans",
+			proc: "AttachThreadInput",
+			args: []uintptr{4120, 388, 1},
+			want: []slog.Attr{slog.String("attach", "4120"), slog.String("attachTo", "388"), slog.String("fAttach", "TRUE")},
+		},
+		{
+			name: "unknown proc",
+			proc: "GetMenu",
+			args: []uintptr{0x1A2B, 255},
+			want: []slog.Attr{slog.String("arg0", "0x1A2B"), slog.String("arg1", "0xFF")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, decodeArgs(tt.proc, tt.args))
+		})
+	}
+}
+
+// traceLog records what is logged at Trace level
+type traceLog struct {
+	logger.LoggerInterface
+	entries [][]any
+}
+
+func (l *traceLog) Trace(msg string, args ...any) {
+	l.entries = append(l.entries, append([]any{msg}, args...))
+}
+
+func TestTraced(t *testing.T) {
+	fake := func(args ...uintptr) (uintptr, uintptr, error) {
+		return args[0] + 1, 7, syscall.Errno(5)
+	}
+
+	t.Cleanup(func() { SetWin32Trace(nil) })
+
+	// Off, the call is made and nothing is logged
+	log := &traceLog{LoggerInterface: logger.NewBootstrapLogger(io.Discard)}
+	r1, r2, err := traced("IsWindow", fake, 0x10)
+	assert.Equal(t, uintptr(0x11), r1)
+	assert.Equal(t, uintptr(7), r2)
+	assert.Equal(t, syscall.Errno(5), err)
+	assert.Empty(t, log.entries)
+
+	SetWin32Trace(log)
+
+	for range 2 {
+		r1, r2, err = traced("IsWindow", fake, 0x10)
+		assert.Equal(t, uintptr(0x11), r1, "tracing does not change what the call returns")
+		assert.Equal(t, uintptr(7), r2)
+		assert.Equal(t, syscall.Errno(5), err)
+	}
+
+	require.Len(t, log.entries, 2)
+	assert.Equal(t, []any{
+		"Win32 call",
+		slog.Uint64("seq", 1),
+		slog.String("proc", "IsWindow"),
+		slog.String("hwnd", "0x10"),
+		slog.String("ret", "0x11"),
+		slog.Uint64("lastError", 5),
+		slog.String("lastErrorText", syscall.Errno(5).Error()),
+	}, log.entries[0])
+	assert.Equal(t, slog.Uint64("seq", 2), log.entries[1][1], "calls are numbered in order")
+}
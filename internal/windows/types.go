@@ -56,6 +56,14 @@ type PROCESSENTRY32 struct {
 	SzExeFile           [MAX_PATH]uint16
 }
 
+// RECT for GetWindowRect API
+type RECT struct {
+	Left   int32
+	Top    int32
+	Right  int32
+	Bottom int32
+}
+
 type WindowInfo struct {
 	Hwnd  uintptr
 	Title string
@@ -67,25 +75,12 @@ type WindowEvent struct {
 	Title string
 	Pid   uint32
 	Class string
-}
 
-// SHELLEXECUTEINFO for ShellExecuteEx API
-type SHELLEXECUTEINFO struct {
-	CbSize       uint32
-	FMask        uint32
-	Hwnd         uintptr
-	LpVerb       *uint16
-	LpFile       *uint16
-	LpParameters *uint16
-	LpDirectory  *uint16
-	NShow        int32
-	HInstApp     uintptr
-	LpIDList     uintptr
-	LpClass      *uint16
-	HkeyClass    uintptr
-	DwHotKey     uint32
-	HIcon        uintptr
-	HProcess     uintptr
+	// ChildTextSnapshot is the text of the window's controls other than
+	// buttons, read by the monitor as it detected the window, so it is known
+	// even once a dialog that closes itself has gone. It is nil for windows
+	// the monitor's SnapshotFilter did not match, or whose controls had no text.
+	ChildTextSnapshot []string
 }
 
 // STARTUPINFO for CreateProcess API
@@ -118,6 +113,12 @@ type PROCESS_INFORMATION struct {
 	DwThreadId  uint32
 }
 
+// FILETIME for GetProcessTimes, in 100-nanosecond intervals
+type FILETIME struct {
+	LowDateTime  uint32
+	HighDateTime uint32
+}
+
 // SECURITY_ATTRIBUTES for CreateProcess API
 type SECURITY_ATTRIBUTES struct {
 	NLength              uint32
@@ -0,0 +1,88 @@
+//go:build windows
+
+package windows
+
+import (
+	"golang.org/x/sys/windows/registry"
+
+	"github.com/Norgate-AV/vtpc/internal/cpuid"
+)
+
+// smRemoteSession is the GetSystemMetrics index that is non-zero in a Remote
+// Desktop, Citrix or other remote session
+const smRemoteSession = 0x1000
+
+// rootPartitionHypervisor is the vendor Hyper-V reports. Windows itself runs on
+// Hyper-V when virtualization-based security is on, so a physical machine
+// reports it too and it is not evidence of a virtual machine on its own.
+const rootPartitionHypervisor = "Microsoft Hv"
+
+// guestAgent is software installed only inside a virtual machine or VDI desktop
+type guestAgent struct {
+	name string
+	path string // Key under HKLM that exists when the agent is installed
+}
+
+// guestAgents are the agents whose presence marks a virtual or remoted desktop
+var guestAgents = []guestAgent{
+	{"VMware Tools", `SOFTWARE\VMware, Inc.\VMware Tools`},
+	{"VMware Horizon Agent", `SOFTWARE\VMware, Inc.\VMware VDM\Agent`},
+	{"Citrix Virtual Delivery Agent", `SOFTWARE\Citrix\VirtualDesktopAgent`},
+	{"VirtualBox Guest Additions", `SOFTWARE\Oracle\VirtualBox Guest Additions`},
+	{"Parallels Tools", `SOFTWARE\Parallels\Parallels Tools`},
+	{"QEMU Guest Agent", `SYSTEM\CurrentControlSet\Services\QEMU-GA`},
+}
+
+// VirtualIndicators are the signs that vtpc is running in a virtual machine or
+// remote session, where injected input can arrive late
+type VirtualIndicators struct {
+	RemoteSession bool     // GetSystemMetrics(SM_REMOTESESSION) is set
+	Hypervisor    string   // Vendor the CPU reports, "" on bare metal
+	GuestAgents   []string // Names of the guest agents that are installed
+}
+
+// ReadVirtualIndicators reads the signs of a virtual machine or remote session
+func ReadVirtualIndicators() VirtualIndicators {
+	v := VirtualIndicators{RemoteSession: systemMetric(smRemoteSession) != 0}
+	v.Hypervisor, _ = cpuid.Hypervisor()
+
+	for _, a := range guestAgents {
+		if registryKeyExists(registry.LOCAL_MACHINE, a.path) {
+			v.GuestAgents = append(v.GuestAgents, a.name)
+		}
+	}
+
+	return v
+}
+
+// SlowInputReasons returns why injected input may arrive late here, or nothing
+// on a physical machine at its console
+func (v VirtualIndicators) SlowInputReasons() []string {
+	var reasons []string
+
+	if v.RemoteSession {
+		reasons = append(reasons, "remote session")
+	}
+
+	if v.Hypervisor != "" && v.Hypervisor != rootPartitionHypervisor {
+		reasons = append(reasons, "hypervisor "+v.Hypervisor)
+	}
+
+	for _, a := range v.GuestAgents {
+		reasons = append(reasons, a+" installed")
+	}
+
+	return reasons
+}
+
+// registryKeyExists reports whether path exists under root
+func registryKeyExists(root registry.Key, path string) bool {
+	k, err := registry.OpenKey(root, path, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+
+	_ = k.Close()
+
+	return true
+}
@@ -0,0 +1,59 @@
+//go:build windows
+
+package windows
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVirtualIndicators_SlowInputReasons(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		indicators VirtualIndicators
+		want       []string
+	}{
+		{
+			name: "physical machine at its console",
+		},
+		{
+			name:       "Windows on Hyper-V for virtualization-based security",
+			indicators: VirtualIndicators{Hypervisor: "Microsoft Hv"},
+		},
+		{
+			name:       "remote desktop to a physical machine",
+			indicators: VirtualIndicators{RemoteSession: true},
+			want:       []string{"remote session"},
+		},
+		{
+			name:       "virtual machine",
+			indicators: VirtualIndicators{Hypervisor: "VMwareVMware"},
+			want:       []string{"hypervisor VMwareVMware"},
+		},
+		{
+			name:       "Hyper-V guest with an agent installed",
+			indicators: VirtualIndicators{Hypervisor: "Microsoft Hv", GuestAgents: []string{"Citrix Virtual Delivery Agent"}},
+			want:       []string{"Citrix Virtual Delivery Agent installed"},
+		},
+		{
+			name: "VDI desktop",
+			indicators: VirtualIndicators{
+				RemoteSession: true,
+				Hypervisor:    "VMwareVMware",
+				GuestAgents:   []string{"VMware Tools", "VMware Horizon Agent"},
+			},
+			want: []string{"remote session", "hypervisor VMwareVMware", "VMware Tools installed", "VMware Horizon Agent installed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.indicators.SlowInputReasons())
+		})
+	}
+}
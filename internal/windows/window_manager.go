@@ -26,7 +26,7 @@ func newWindowManager(log logger.LoggerInterface) *windowManager {
 func (w *windowManager) CloseWindow(hwnd uintptr, title string) {
 	w.log.Debug("Closing window", slog.String("title", title))
 
-	ret, _, err := procPostMessageW.Call(hwnd, WM_CLOSE, 0, 0)
+	ret, _, err := call(procPostMessageW, hwnd, WM_CLOSE, 0, 0)
 	if ret == 0 {
 		w.log.Debug("PostMessage WM_CLOSE failed",
 			slog.String("title", title),
@@ -37,14 +37,16 @@ func (w *windowManager) CloseWindow(hwnd uintptr, title string) {
 	time.Sleep(timeouts.WindowMessageDelay)
 }
 
-// SetForeground brings a window to the foreground using AttachThreadInput technique
+// SetForeground brings a window to the foreground using AttachThreadInput technique.
+// A minimized window is restored first; one that is not keeps its size, so a
+// maximized VTPro stays maximized.
 func (w *windowManager) SetForeground(hwnd uintptr) bool {
-	// Restore window if minimized
-	ret, _, _ := procShowWindow.Call(hwnd, uintptr(SW_RESTORE))
-	w.log.Debug("ShowWindow(SW_RESTORE)", slog.Uint64("ret", uint64(ret)))
+	if IsMinimized(hwnd) {
+		w.RestoreWindow(hwnd)
+	}
 
 	// Try standard SetForegroundWindow first
-	ret, _, _ = procSetForegroundWindow.Call(hwnd)
+	ret, _, _ := call(procSetForegroundWindow, hwnd)
 	if ret != 0 {
 		w.log.Debug("SetForegroundWindow succeeded (standard)")
 		return w.verifyForeground(hwnd)
@@ -53,15 +55,15 @@ func (w *windowManager) SetForeground(hwnd uintptr) bool {
 	w.log.Debug("Standard SetForegroundWindow failed, trying AttachThreadInput technique")
 
 	// Get current foreground window and its thread
-	fgHwnd, _, _ := procGetForegroundWindow.Call()
+	fgHwnd, _, _ := call(procGetForegroundWindow)
 	if fgHwnd == 0 || fgHwnd == hwnd {
 		w.log.Debug("No foreground window or already focused")
 		return true
 	}
 
 	// Get thread IDs
-	fgThreadID, _, _ := procGetWindowThreadProcessId.Call(fgHwnd, 0)
-	targetThreadID, _, _ := procGetWindowThreadProcessId.Call(hwnd, 0)
+	fgThreadID, _, _ := call(procGetWindowThreadProcessId, fgHwnd, 0)
+	targetThreadID, _, _ := call(procGetWindowThreadProcessId, hwnd, 0)
 
 	if fgThreadID == 0 || targetThreadID == 0 {
 		w.log.Warn("Could not get thread IDs",
@@ -75,18 +77,18 @@ func (w *windowManager) SetForeground(hwnd uintptr) bool {
 		slog.Uint64("targetThreadID", uint64(targetThreadID)))
 
 	// Attach our thread to the foreground window's thread
-	ret, _, _ = procAttachThreadInput.Call(targetThreadID, fgThreadID, 1)
+	ret, _, _ = call(procAttachThreadInput, targetThreadID, fgThreadID, 1)
 	if ret == 0 {
 		w.log.Warn("AttachThreadInput failed")
 		return false
 	}
 
 	// Now SetForegroundWindow should work
-	ret, _, _ = procSetForegroundWindow.Call(hwnd)
+	ret, _, _ = call(procSetForegroundWindow, hwnd)
 	success := ret != 0
 
 	// Detach threads
-	ret, _, _ = procAttachThreadInput.Call(targetThreadID, fgThreadID, 0)
+	ret, _, _ = call(procAttachThreadInput, targetThreadID, fgThreadID, 0)
 	if ret == 0 {
 		w.log.Warn("Failed to detach threads")
 	}
@@ -100,11 +102,30 @@ func (w *windowManager) SetForeground(hwnd uintptr) bool {
 	return false
 }
 
+// RestoreWindow restores a minimized window to its previous size and position
+// It reports whether the window was visible before the call, as ShowWindow does
+func (w *windowManager) RestoreWindow(hwnd uintptr) bool {
+	ret, _, _ := call(procShowWindow, hwnd, uintptr(SW_RESTORE))
+	w.log.Debug("ShowWindow(SW_RESTORE)", slog.Uint64("ret", uint64(ret)))
+
+	return ret != 0
+}
+
+// MinimizeWindow minimizes a window, handing activation to the next window in
+// the Z order, which is usually the one the user was working in
+// It reports whether the window was visible before the call, as ShowWindow does
+func (w *windowManager) MinimizeWindow(hwnd uintptr) bool {
+	ret, _, _ := call(procShowWindow, hwnd, uintptr(SW_MINIMIZE))
+	w.log.Debug("ShowWindow(SW_MINIMIZE)", slog.Uint64("ret", uint64(ret)))
+
+	return ret != 0
+}
+
 // verifyForeground checks if the window is now in foreground
 func (w *windowManager) verifyForeground(hwnd uintptr) bool {
 	time.Sleep(timeouts.WindowMessageDelay)
 
-	fgHwnd, _, _ := procGetForegroundWindow.Call()
+	fgHwnd, _, _ := call(procGetForegroundWindow)
 	if fgHwnd == hwnd {
 		w.log.Debug("Window confirmed in foreground")
 		return true
@@ -120,7 +141,7 @@ func (w *windowManager) verifyForeground(hwnd uintptr) bool {
 // VerifyForegroundWindow checks if the specified window is currently in the foreground
 // and optionally verifies it belongs to the expected PID
 func (w *windowManager) VerifyForegroundWindow(expectedHwnd uintptr, expectedPid uint32) bool {
-	fgHwnd, _, _ := procGetForegroundWindow.Call()
+	fgHwnd, _, _ := call(procGetForegroundWindow)
 
 	if fgHwnd != expectedHwnd {
 		w.log.Warn("Wrong window in foreground",
@@ -134,6 +155,7 @@ func (w *windowManager) VerifyForegroundWindow(expectedHwnd uintptr, expectedPid
 	if expectedPid != 0 {
 		var actualPid uint32
 		ret, _, err := procGetWindowThreadProcessId.Call(fgHwnd, uintptr(unsafe.Pointer(&actualPid)))
+		traceCall(procGetWindowThreadProcessId, ret, err, fgHwnd, uintptr(unsafe.Pointer(&actualPid)))
 		if ret == 0 {
 			w.log.Debug("GetWindowThreadProcessId failed", slog.Any("error", err))
 		}
@@ -163,7 +185,7 @@ func (w *windowManager) IsElevated() bool {
 
 // IsWindowValid checks if a window handle still refers to a valid window
 func (w *windowManager) IsWindowValid(hwnd uintptr) bool {
-	ret, _, _ := procIsWindow.Call(hwnd)
+	ret, _, _ := call(procIsWindow, hwnd)
 	return ret != 0
 }
 
@@ -223,7 +245,7 @@ func (w *windowManager) FindAndClickButton(parentHwnd uintptr, buttonText string
 
 			// Send BN_CLICKED notification to parent
 			// WM_COMMAND: wParam = MAKEWPARAM(controlID, BN_CLICKED), lParam = hwnd
-			ret, _, err := procSendMessageW.Call(parentHwnd, WM_COMMAND, uintptr(BN_CLICKED), ci.Hwnd)
+			ret, _, err := call(procSendMessageW, parentHwnd, WM_COMMAND, uintptr(BN_CLICKED), ci.Hwnd)
 			if ret == 0 {
 				w.log.Debug("SendMessage BN_CLICKED failed",
 					slog.String("text", ci.Text),
@@ -4,12 +4,8 @@ package windows
 
 import (
 	"fmt"
-	"log/slog"
-	"os"
 	"syscall"
 	"unsafe"
-
-	"github.com/Norgate-AV/vtpc/internal/logger"
 )
 
 // ShellExecute executes a file using the Windows shell
@@ -60,77 +56,6 @@ func ShellExecute(hwnd uintptr, verb, file, args, cwd string, showCmd int) error
 	return nil
 }
 
-// CreateProcessSimple launches an executable with arguments and returns the process ID
-// This provides direct control over the command line, unlike ShellExecuteEx which
-// may modify arguments based on shell integration and file associations.
-func CreateProcessSimple(exePath, args string, showCmd int, log logger.LoggerInterface) (uint32, error) {
-	// Validate that the executable exists before attempting to launch
-	if _, err := os.Stat(exePath); os.IsNotExist(err) {
-		return 0, fmt.Errorf("executable not found: %s", exePath)
-	} else if err != nil {
-		return 0, fmt.Errorf("error checking executable at %s: %w", exePath, err)
-	}
-
-	// Build the command line: "executable" "arguments"
-	// Windows CreateProcess requires the full command line including the executable
-	var cmdLine string
-	if args != "" {
-		cmdLine = fmt.Sprintf("%q %s", exePath, args)
-	} else {
-		cmdLine = fmt.Sprintf("%q", exePath)
-	}
-
-	cmdLinePtr, err := syscall.UTF16PtrFromString(cmdLine)
-	if err != nil {
-		return 0, fmt.Errorf("failed to convert command line to UTF16: %w", err)
-	}
-
-	// Initialize STARTUPINFO
-	si := STARTUPINFO{
-		Cb:          uint32(unsafe.Sizeof(STARTUPINFO{})),
-		DwFlags:     STARTF_USESHOWWINDOW,
-		WShowWindow: uint16(showCmd),
-	}
-
-	// Initialize PROCESS_INFORMATION
-	var pi PROCESS_INFORMATION
-
-	// Call CreateProcessW
-	// lpApplicationName = nil (use command line instead)
-	// lpCommandLine = full command line
-	ret, _, err := procCreateProcessW.Call(
-		0,                                   // lpApplicationName (nil)
-		uintptr(unsafe.Pointer(cmdLinePtr)), // lpCommandLine
-		0,                                   // lpProcessAttributes (nil)
-		0,                                   // lpThreadAttributes (nil)
-		0,                                   // bInheritHandles (FALSE)
-		0,                                   // dwCreationFlags
-		0,                                   // lpEnvironment (nil)
-		0,                                   // lpCurrentDirectory (nil)
-		uintptr(unsafe.Pointer(&si)),        // lpStartupInfo
-		uintptr(unsafe.Pointer(&pi)),        // lpProcessInformation
-	)
-
-	if ret == 0 {
-		return 0, fmt.Errorf("CreateProcessW failed: %w", err)
-	}
-
-	// Close handles - we only need the PID
-	if pi.HThread != 0 {
-		if ret, _, err := ProcCloseHandle.Call(pi.HThread); ret == 0 {
-			log.Debug("Failed to close thread handle", slog.Any("error", err))
-		}
-	}
-
-	if pi.HProcess != 0 {
-		if ret, _, err := ProcCloseHandle.Call(pi.HProcess); ret == 0 {
-			log.Debug("Failed to close process handle", slog.Any("error", err))
-		}
-	}
-
-	return pi.DwProcessId, nil
-}
-
 // GetWindowText retrieves the text of a window
 func GetWindowText(hwnd uintptr) string {
 	buf := make([]uint16, 256)
@@ -155,6 +80,30 @@ func GetClassName(hwnd uintptr) string {
 	return syscall.UTF16ToString(buf)
 }
 
+// HasMenu reports whether a window has a menu bar attached
+func HasMenu(hwnd uintptr) bool {
+	ret, _, _ := procGetMenu.Call(hwnd)
+	return ret != 0
+}
+
+// HasChildWindows reports whether a window has any child windows, such as controls
+func HasChildWindows(hwnd uintptr) bool {
+	ret, _, _ := procGetWindow.Call(hwnd, GW_CHILD)
+	return ret != 0
+}
+
+// GetWindowSize retrieves the outer width and height of a window in pixels
+func GetWindowSize(hwnd uintptr) (width, height int32) {
+	var rect RECT
+
+	ret, _, _ := procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&rect)))
+	if ret == 0 {
+		return 0, 0
+	}
+
+	return rect.Right - rect.Left, rect.Bottom - rect.Top
+}
+
 // IsWindow checks if a window handle is valid
 func IsWindow(hwnd uintptr) bool {
 	ret, _, _ := procIsWindow.Call(hwnd)
@@ -167,6 +116,12 @@ func IsWindowVisible(hwnd uintptr) bool {
 	return ret != 0
 }
 
+// IsMinimized checks if a window is minimized (iconic)
+func IsMinimized(hwnd uintptr) bool {
+	ret, _, _ := procIsIconic.Call(hwnd)
+	return ret != 0
+}
+
 // GetWindowPid retrieves the process ID of a window
 func GetWindowPid(hwnd uintptr) uint32 {
 	var pid uint32
@@ -209,3 +164,9 @@ func TerminateProcess(pid uint32) error {
 
 	return nil
 }
+
+// GetForegroundWindow returns the window that currently has keyboard focus
+func GetForegroundWindow() uintptr {
+	hwnd, _, _ := procGetForegroundWindow.Call()
+	return hwnd
+}
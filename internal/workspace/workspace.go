@@ -0,0 +1,248 @@
+// Package workspace stages a VTPro project into an isolated temporary directory
+// so intermediate files from one run cannot affect another.
+package workspace
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// ArtifactExtensions are the extensions of files VTPro produces when compiling
+var ArtifactExtensions = []string{".vtz"}
+
+// Workspace is a temporary copy of a project and its sidecars
+type Workspace struct {
+	Dir     string // Temporary working directory
+	Project string // Path of the project file inside Dir
+
+	log      logger.LoggerInterface
+	snapshot map[string]time.Time // Modification times of the staged files, keyed by name
+}
+
+// New creates a temporary directory and copies the project and its sidecars into it.
+// Sidecars are paths relative to the project's directory and may be files or directories.
+func New(projectPath string, sidecars []string, log logger.LoggerInterface) (*Workspace, error) {
+	dir, err := os.MkdirTemp("", "vtpc-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create isolated working directory: %w", err)
+	}
+
+	ws := &Workspace{
+		Dir:     dir,
+		Project: filepath.Join(dir, filepath.Base(projectPath)),
+		log:     log,
+	}
+
+	if err := ws.stage(projectPath, sidecars); err != nil {
+		ws.remove()
+		return nil, err
+	}
+
+	log.Info("Compiling in isolated working directory", slog.String("dir", dir))
+
+	return ws, nil
+}
+
+// stage copies the project and sidecars into the workspace and records what was copied
+func (w *Workspace) stage(projectPath string, sidecars []string) error {
+	if err := copyFile(projectPath, w.Project); err != nil {
+		return fmt.Errorf("failed to copy project into isolated working directory: %w", err)
+	}
+
+	srcDir := filepath.Dir(projectPath)
+
+	for _, sidecar := range sidecars {
+		if filepath.IsAbs(sidecar) || strings.HasPrefix(filepath.Clean(sidecar), "..") {
+			return fmt.Errorf("sidecar %q must be relative to the project directory", sidecar)
+		}
+
+		src := filepath.Join(srcDir, sidecar)
+		dst := filepath.Join(w.Dir, sidecar)
+
+		if err := copyPath(src, dst); err != nil {
+			return fmt.Errorf("failed to copy sidecar %q: %w", sidecar, err)
+		}
+
+		w.log.Debug("Copied sidecar", slog.String("path", sidecar))
+	}
+
+	snapshot, err := modTimes(w.Dir)
+	if err != nil {
+		return err
+	}
+
+	w.snapshot = snapshot
+
+	return nil
+}
+
+// CollectArtifacts copies compiled artifacts that are new or changed since staging into
+// destDir and returns their destination paths
+func (w *Workspace) CollectArtifacts(destDir string) ([]string, error) {
+	current, err := modTimes(w.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	var copied []string
+
+	for name, modTime := range current {
+		if !isArtifact(name) {
+			continue
+		}
+
+		if before, ok := w.snapshot[name]; ok && before.Equal(modTime) {
+			continue
+		}
+
+		dst := filepath.Join(destDir, filepath.Base(name))
+		if err := copyFile(filepath.Join(w.Dir, name), dst); err != nil {
+			return copied, fmt.Errorf("failed to copy artifact %q: %w", name, err)
+		}
+
+		w.log.Info("Copied artifact", slog.String("path", dst))
+		copied = append(copied, dst)
+	}
+
+	if len(copied) == 0 {
+		w.log.Warn("No compiled artifact found in isolated working directory", slog.String("dir", w.Dir))
+	}
+
+	return copied, nil
+}
+
+// Cleanup removes the workspace. When keep is true the directory is left in place
+// for inspection instead. Removal is best-effort: failures are logged, not returned.
+func (w *Workspace) Cleanup(keep bool) {
+	if keep {
+		w.log.Info("Keeping isolated working directory", slog.String("dir", w.Dir))
+		return
+	}
+
+	w.remove()
+}
+
+// remove deletes the workspace directory, logging on failure
+func (w *Workspace) remove() {
+	if err := os.RemoveAll(w.Dir); err != nil {
+		w.log.Warn("Failed to remove isolated working directory",
+			slog.String("dir", w.Dir),
+			slog.Any("error", err),
+		)
+
+		return
+	}
+
+	w.log.Debug("Removed isolated working directory", slog.String("dir", w.Dir))
+}
+
+// isArtifact reports whether a file has one of the ArtifactExtensions
+func isArtifact(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+
+	for _, e := range ArtifactExtensions {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+// modTimes returns the modification time of every file under root, keyed by relative path
+func modTimes(root string) (map[string]time.Time, error) {
+	times := make(map[string]time.Time)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		times[rel] = info.ModTime()
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan isolated working directory: %w", err)
+	}
+
+	return times, nil
+}
+
+// copyPath copies a file or a directory tree from src to dst
+func copyPath(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return copyFile(src, dst)
+	}
+
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies a single file, creating the destination directory if needed
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,168 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/logger"
+)
+
+// setupProject creates a project directory with a .vtp, a .vta sidecar and a resource directory
+func setupProject(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lobby.vtp"), []byte("project"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lobby.vta"), []byte("sidecar"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lobby Files", "images"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lobby Files", "images", "logo.png"), []byte("png"), 0o644))
+
+	return filepath.Join(dir, "lobby.vtp")
+}
+
+// isolateTempDir points the system temp directory at a test-owned directory
+func isolateTempDir(t *testing.T) string {
+	t.Helper()
+
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv("TMP", tmp)
+	t.Setenv("TEMP", tmp)
+
+	return tmp
+}
+
+func TestNew_CopiesProjectAndSidecars(t *testing.T) {
+	isolateTempDir(t)
+	project := setupProject(t)
+
+	ws, err := New(project, []string{"lobby.vta", "lobby Files"}, logger.NewNoOpLogger())
+	require.NoError(t, err)
+	defer ws.Cleanup(false)
+
+	assert.Equal(t, filepath.Join(ws.Dir, "lobby.vtp"), ws.Project)
+	assert.FileExists(t, ws.Project)
+	assert.FileExists(t, filepath.Join(ws.Dir, "lobby.vta"))
+
+	data, err := os.ReadFile(filepath.Join(ws.Dir, "lobby Files", "images", "logo.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(data))
+}
+
+func TestNew_RemovesDirectoryWhenStagingFails(t *testing.T) {
+	tmp := isolateTempDir(t)
+	project := setupProject(t)
+
+	tests := []struct {
+		name     string
+		sidecars []string
+	}{
+		{"missing sidecar", []string{"lobby.vta", "missing.vta"}},
+		{"sidecar outside project", []string{"../other.vta"}},
+		{"absolute sidecar", []string{filepath.Join(filepath.Dir(project), "lobby.vta")}},
+	}
+
+	for _, tt := range tests {
+		ws, err := New(project, tt.sidecars, logger.NewNoOpLogger())
+		assert.Error(t, err, tt.name)
+		assert.Nil(t, ws, tt.name)
+
+		entries, err := os.ReadDir(tmp)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "%s: temp directory is removed", tt.name)
+	}
+}
+
+func TestNew_MissingProject(t *testing.T) {
+	isolateTempDir(t)
+
+	_, err := New(filepath.Join(t.TempDir(), "missing.vtp"), nil, logger.NewNoOpLogger())
+	assert.Error(t, err)
+}
+
+func TestCollectArtifacts_CopiesNewArtifactNextToOriginal(t *testing.T) {
+	isolateTempDir(t)
+	project := setupProject(t)
+
+	ws, err := New(project, nil, logger.NewNoOpLogger())
+	require.NoError(t, err)
+	defer ws.Cleanup(false)
+
+	// Simulate VTPro compiling in the workspace
+	require.NoError(t, os.WriteFile(filepath.Join(ws.Dir, "lobby.vtz"), []byte("compiled"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(ws.Dir, "lobby.tmp"), []byte("intermediate"), 0o644))
+
+	copied, err := ws.CollectArtifacts(filepath.Dir(project))
+	require.NoError(t, err)
+
+	dst := filepath.Join(filepath.Dir(project), "lobby.vtz")
+	assert.Equal(t, []string{dst}, copied)
+
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "compiled", string(data))
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(project), "lobby.tmp"), "intermediates stay behind")
+}
+
+func TestCollectArtifacts_ToOutDir(t *testing.T) {
+	isolateTempDir(t)
+	project := setupProject(t)
+
+	ws, err := New(project, nil, logger.NewNoOpLogger())
+	require.NoError(t, err)
+	defer ws.Cleanup(false)
+
+	require.NoError(t, os.WriteFile(filepath.Join(ws.Dir, "lobby.vtz"), []byte("compiled"), 0o644))
+
+	outDir := filepath.Join(t.TempDir(), "out", "nested")
+	copied, err := ws.CollectArtifacts(outDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(outDir, "lobby.vtz")}, copied)
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(project), "lobby.vtz"))
+}
+
+func TestCollectArtifacts_IgnoresUnchangedStagedArtifact(t *testing.T) {
+	isolateTempDir(t)
+	project := setupProject(t)
+
+	// A stale artifact staged as a sidecar must not be reported as produced
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(project), "old.vtz"), []byte("stale"), 0o644))
+
+	ws, err := New(project, []string{"old.vtz"}, logger.NewNoOpLogger())
+	require.NoError(t, err)
+	defer ws.Cleanup(false)
+
+	copied, err := ws.CollectArtifacts(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, copied)
+
+	// Rewriting it during the compile does count
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(ws.Dir, "old.vtz"), later, later))
+
+	copied, err = ws.CollectArtifacts(t.TempDir())
+	require.NoError(t, err)
+	assert.Len(t, copied, 1)
+}
+
+func TestCleanup(t *testing.T) {
+	isolateTempDir(t)
+	project := setupProject(t)
+
+	ws, err := New(project, nil, logger.NewNoOpLogger())
+	require.NoError(t, err)
+
+	ws.Cleanup(true)
+	assert.DirExists(t, ws.Dir, "kept for inspection")
+
+	ws.Cleanup(false)
+	assert.NoDirExists(t, ws.Dir)
+
+	// Cleaning up twice is harmless
+	ws.Cleanup(false)
+}
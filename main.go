@@ -8,7 +8,13 @@ import (
 )
 
 func main() {
-	if err := cmd.RootCmd.Execute(); err != nil {
-		os.Exit(1)
+	restoreConsole := cmd.SetupConsole()
+
+	err := cmd.RootCmd.Execute()
+	cmd.PauseBeforeExit()
+	restoreConsole()
+
+	if err != nil {
+		os.Exit(cmd.ExitCode(err))
 	}
 }
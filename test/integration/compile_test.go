@@ -4,7 +4,6 @@
 package integration
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -93,6 +92,23 @@ func TestIntegration_CompileWithErrors(t *testing.T) {
 	}
 }
 
+// TestIntegration_LaunchMinimized compiles with VTPro launched minimized. Watch the
+// desktop while it runs: VTPro should only be shown around the compile keystroke.
+func TestIntegration_LaunchMinimized(t *testing.T) {
+	if !windows.IsElevated() {
+		t.Skip("Integration tests require administrator privileges")
+	}
+
+	fixturePath := getFixturePath(t, "simple.vtp")
+	require.FileExists(t, fixturePath, "Fixture file should exist")
+
+	result, cleanup := compileFileWith(t, fixturePath, true)
+	defer cleanup()
+
+	assert.False(t, result.HasErrors, "Simple file should compile without errors when launched minimized")
+	assert.Zero(t, result.MisdirectedKeystrokes, "The restored window should take the keystroke first time")
+}
+
 // TestIntegration_FileValidation tests the file validation that should occur before compilation
 func TestIntegration_FileValidation(t *testing.T) {
 	// This test doesn't require admin privileges - it's just file validation
@@ -136,6 +152,11 @@ func getFixturePath(t *testing.T, filename string) string {
 
 // compileFile performs end-to-end compilation and returns result with cleanup function
 func compileFile(t *testing.T, filePath string) (*compiler.CompileResult, func()) {
+	return compileFileWith(t, filePath, false)
+}
+
+// compileFileWith is compileFile, optionally launching VTPro minimized as --launch-minimized does
+func compileFileWith(t *testing.T, filePath string, minimized bool) (*compiler.CompileResult, func()) {
 	require.FileExists(t, filePath, "File should exist before compilation")
 
 	// Convert to absolute path
@@ -152,16 +173,25 @@ func compileFile(t *testing.T, filePath string) (*compiler.CompileResult, func()
 	require.NoError(t, err, "VTPro should be installed")
 
 	// Create SIMPL client
-	vtproClient := vtpro.NewClient(testLog)
+	vtproClient := vtpro.NewClient(testLog).WithProjectFile(filePath).WithLaunchMinimized(minimized)
 
-	// Open file with VTPro using CreateProcessSimple (ShellExecuteEx doesn't work with VTPro)
+	// Open file with VTPro using CreateProcess (ShellExecuteEx doesn't work with VTPro)
 	t.Logf("Opening VTPro with file: %s", absPath)
-	pid, err := windows.CreateProcessSimple(vtpro.GetVTProPath(), fmt.Sprintf("%q", absPath), 1, testLog)
+	showCmd := windows.SW_SHOWNORMAL
+	if minimized {
+		showCmd = windows.SW_SHOWMINNOACTIVE
+	}
+
+	proc, err := windows.CreateProcess(vtpro.GetVTProPath(), windows.QuoteArg(absPath), showCmd, testLog)
 	require.NoError(t, err, "Should launch VTPro")
+	defer func() { _ = proc.Close() }()
+
+	pid := proc.Pid
 	t.Logf("VTPro process started with PID: %d", pid)
 
 	// Start background window monitor with the exact PID we just launched
-	stopMonitor := vtproClient.StartMonitoring(pid)
+	stopMonitor, err := vtproClient.StartMonitoring(pid)
+	require.NoError(t, err, "Should start window monitor")
 
 	// Wait for process to start
 	time.Sleep(timeouts.WindowMessageDelay)
@@ -180,7 +210,7 @@ func compileFile(t *testing.T, filePath string) (*compiler.CompileResult, func()
 	// Allow UI to settle
 	time.Sleep(timeouts.UISettlingDelay)
 
-	// Use the PID from ShellExecuteEx for compilation
+	// Use the PID from CreateProcess for compilation
 	vtproPid := pid
 
 	// Cleanup function
@@ -191,7 +221,7 @@ func compileFile(t *testing.T, filePath string) (*compiler.CompileResult, func()
 			vtproClient.Cleanup(hwnd, pid)
 		}
 		// Give it time to close
-		time.Sleep(timeouts.FocusVerificationDelay)
+		time.Sleep(timeouts.DefaultInput().FocusVerification)
 	}
 
 	// Run compilation
@@ -201,10 +231,11 @@ func compileFile(t *testing.T, filePath string) (*compiler.CompileResult, func()
 	comp := compiler.NewCompiler(testLog)
 
 	result, err := comp.Compile(compiler.CompileOptions{
-		FilePath:    absPath,
-		Hwnd:        hwnd,
-		VTProPid:    vtproPid,
-		VTProPidPtr: &vtproPid,
+		FilePath:        absPath,
+		Hwnd:            hwnd,
+		VTProPid:        vtproPid,
+		VTProPidPtr:     &vtproPid,
+		LaunchMinimized: minimized,
 	})
 	// Note: We don't require NoError here because some tests expect compilation to fail
 	if err != nil {
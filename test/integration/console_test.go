@@ -0,0 +1,35 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// TestIntegration_ConsoleCodePage switches the console to UTF-8 and back. Run
+// it from cmd.exe after "chcp 850" without redirecting output, and check that
+// the quotes and dashes below are shown as written and that chcp reports 850
+// afterwards.
+func TestIntegration_ConsoleCodePage(t *testing.T) {
+	console := windows.StdoutConsole{}
+	if !console.IsConsole() {
+		t.Skip("stdout is redirected, so the console code page does not apply")
+	}
+
+	original := console.OutputCP()
+
+	state, restore := windows.EnsureUTF8Output(console)
+	assert.True(t, state.UTF8(), "output should be UTF-8: %v", state.Err)
+	assert.Equal(t, uint32(windows.CodePageUTF8), console.OutputCP())
+
+	fmt.Println("Object “Volume” on Page ‘Lobby’ – Compiling…")
+
+	restore()
+	assert.Equal(t, original, console.OutputCP())
+}
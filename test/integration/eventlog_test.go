@@ -0,0 +1,40 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Norgate-AV/vtpc/internal/eventlog"
+	"github.com/Norgate-AV/vtpc/internal/report"
+	"github.com/Norgate-AV/vtpc/internal/windows"
+)
+
+// TestIntegration_EventLog registers the vtpc event source if needed and writes
+// an entry to the Application log. Check it in Event Viewer under source "vtpc".
+func TestIntegration_EventLog(t *testing.T) {
+	if !windows.IsElevated() {
+		t.Skip("Registering an event source requires administrator privileges")
+	}
+
+	run := &report.Run{
+		ID:       "integration-test",
+		Project:  "integration.vtp",
+		Warnings: 1,
+		Summary:  report.Summary{Duration: time.Second},
+	}
+
+	err := eventlog.Write(func() (eventlog.Log, error) {
+		l, err := windows.OpenEventLog(eventlog.Source)
+		if err != nil {
+			return nil, err
+		}
+
+		return l, nil
+	}, run)
+	require.NoError(t, err)
+}